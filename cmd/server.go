@@ -1,17 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"go-ecommerce-api/internal/infrastructure/persistence/sqlite"
 	httpRouter "go-ecommerce-api/internal/interface/http"
+	"go-ecommerce-api/internal/interface/http/handler"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
 
+// shutdownGrace bounds how long in-flight requests and background jobs may
+// take to finish after SIGINT or SIGTERM.
+const shutdownGrace = 30 * time.Second
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
@@ -35,13 +45,14 @@ func main() {
 	}
 
 	// Create Echo router
-	e := httpRouter.NewRouter(db)
+	e, workers := httpRouter.NewRouter(db)
 
 	// CORS middleware
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", "Authorization", handler.CartTokenHeader},
+		ExposeHeaders: []string{"Retry-After", echo.HeaderXRequestID, handler.CartTokenHeader},
 	}))
 
 	// Health check endpoint for Docker
@@ -80,7 +91,24 @@ func main() {
 	log.Printf("Database path: %s", dbPath)
 	log.Printf("Assets path: %s", assetsPath)
 
-	if err := e.Start(":" + port); err != nil {
-		log.Fatalf("server error: %v", err)
+	go func() {
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	// Wait for a termination signal, then drain requests and jobs
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	if !workers.Shutdown(shutdownGrace) {
+		log.Println("background jobs cancelled after the grace period")
 	}
 }
@@ -17,4 +17,8 @@ type Address struct {
 	Postcode string `json:"postcode" gorm:"size:20;not null"`
 	Street   string `json:"street" gorm:"size:200;not null"`
 	Number   string `json:"number" gorm:"size:50;not null"`
+
+	// Optional buyer details printed on invoices.
+	Company string `json:"company,omitempty" gorm:"size:200"`
+	TaxID   string `json:"tax_id,omitempty" gorm:"size:50"`
 }
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKey struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name    string     `json:"name" gorm:"size:100;not null"`
+	Prefix  string     `json:"prefix" gorm:"size:16;uniqueIndex;not null"`
+	KeyHash string     `json:"-" gorm:"size:64;not null"`
+	Scopes  StringList `json:"scopes" gorm:"not null"`
+
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedByID uint `json:"created_by_id" gorm:"not null;index"`
+}
+
+// API key scopes follow the "<resource>:<read|write>" pattern.
+const (
+	ScopeProductsRead    = "products:read"
+	ScopeProductsWrite   = "products:write"
+	ScopeCategoriesRead  = "categories:read"
+	ScopeCategoriesWrite = "categories:write"
+	ScopeOrdersRead      = "orders:read"
+	ScopeOrdersWrite     = "orders:write"
+	ScopeUsersRead       = "users:read"
+	ScopeUsersWrite      = "users:write"
+	ScopeCartsRead       = "carts:read"
+	ScopeCartsWrite      = "carts:write"
+	ScopeShippingRead    = "shipping:read"
+	ScopeShippingWrite   = "shipping:write"
+	ScopeTaxRead         = "tax:read"
+	ScopeTaxWrite        = "tax:write"
+	ScopeCurrenciesRead  = "currencies:read"
+	ScopeCurrenciesWrite = "currencies:write"
+)
+
+var APIKeyScopes = []string{
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopeCategoriesRead,
+	ScopeCategoriesWrite,
+	ScopeOrdersRead,
+	ScopeOrdersWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeCartsRead,
+	ScopeCartsWrite,
+	ScopeShippingRead,
+	ScopeShippingWrite,
+	ScopeTaxRead,
+	ScopeTaxWrite,
+	ScopeCurrenciesRead,
+	ScopeCurrenciesWrite,
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records who did what to which entity. Rows are never updated or
+// deleted. Before and After hold only the fields that changed; a create has
+// no Before and a delete no After.
+type AuditEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+
+	ActorID        *uint  `json:"actor_id,omitempty" gorm:"index"`
+	ActorRole      string `json:"actor_role,omitempty" gorm:"size:20"`
+	APIKeyID       *uint  `json:"api_key_id,omitempty"`
+	ImpersonatorID *uint  `json:"impersonator_id,omitempty"`
+
+	Action     string `json:"action" gorm:"size:50;not null;index"`
+	EntityType string `json:"entity_type" gorm:"size:50;not null;index:idx_audit_entity"`
+	EntityID   uint   `json:"entity_id" gorm:"index:idx_audit_entity"`
+
+	Before json.RawMessage `json:"before,omitempty" gorm:"type:text"`
+	After  json.RawMessage `json:"after,omitempty" gorm:"type:text"`
+
+	IPAddress string `json:"ip_address,omitempty" gorm:"size:64"`
+	RequestID string `json:"request_id,omitempty" gorm:"size:64;index"`
+}
+
+// Audited entity types.
+const (
+	AuditEntityUser                = "user"
+	AuditEntityProduct             = "product"
+	AuditEntityCategory            = "category"
+	AuditEntityOrder               = "order"
+	AuditEntityAPIKey              = "api_key"
+	AuditEntityPrivacyRequest      = "privacy_request"
+	AuditEntityWebhook             = "webhook"
+	AuditEntityShippingZone        = "shipping_zone"
+	AuditEntityShippingMethod      = "shipping_method"
+	AuditEntityTaxRate             = "tax_rate"
+	AuditEntityInvoice             = "invoice"
+	AuditEntityExchangeRate        = "exchange_rate"
+	AuditEntityPriceSchedule       = "price_schedule"
+	AuditEntityProductImage        = "product_image"
+	AuditEntityProductTranslation  = "product_translation"
+	AuditEntityCategoryTranslation = "category_translation"
+)
+
+// Audited actions.
+const (
+	AuditCreate         = "create"
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"
+	AuditLogin          = "login"
+	AuditLoginFailed    = "login_failed"
+	AuditRoleChange     = "role_change"
+	AuditDeactivate     = "deactivate"
+	AuditReactivate     = "reactivate"
+	AuditImpersonate    = "impersonate"
+	AuditPasswordChange = "password_change"
+	AuditEmailChange    = "email_change"
+	AuditEmailVerify    = "email_verify"
+	AuditAttachOrders   = "attach_orders"
+	AuditClose          = "close"
+	AuditCancel         = "cancel"
+	AuditRevoke         = "revoke"
+	AuditComplete       = "complete"
+	AuditReject         = "reject"
+	AuditRotateSecret   = "rotate_secret"
+)
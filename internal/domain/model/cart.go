@@ -12,8 +12,38 @@ type Cart struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	UserID uint       `json:"user_id" gorm:"uniqueIndex;not null"`
-	User   *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Items  []CartItem `json:"items,omitempty" gorm:"foreignKey:CartID"`
-	Total  float64    `json:"total" gorm:"type:decimal(12,2);not null"`
+	// UserID is nil for a guest cart, which is identified by GuestTokenHash
+	// instead until it is merged into a user's cart on login or registration.
+	UserID         *uint      `json:"user_id,omitempty" gorm:"uniqueIndex"`
+	User           *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	GuestTokenHash *string    `json:"-" gorm:"size:64;uniqueIndex"`
+	Items          []CartItem `json:"items,omitempty" gorm:"foreignKey:CartID"`
+	Total          float64    `json:"total" gorm:"type:decimal(12,2);not null"`
+
+	// Currency of the prices in a response. Carts are kept in the base
+	// currency and converted for display only.
+	Currency string `json:"currency,omitempty" gorm:"-"`
+	// PriceMode tells whether Total includes tax. NetTotal, TaxTotal and
+	// GrossTotal split it up for the country asked for. They are not stored.
+	PriceMode  PriceMode `json:"price_mode,omitempty" gorm:"-"`
+	NetTotal   *float64  `json:"net_total,omitempty" gorm:"-"`
+	TaxTotal   *float64  `json:"tax_total,omitempty" gorm:"-"`
+	GrossTotal *float64  `json:"gross_total,omitempty" gorm:"-"`
+
+	// AbandonedAt is set when a cart with items has been idle too long and
+	// cleared by the next change to it.
+	AbandonedAt *time.Time `json:"abandoned_at,omitempty" gorm:"index"`
+}
+
+// IsGuest reports whether the cart belongs to an anonymous visitor.
+func (c *Cart) IsGuest() bool {
+	return c.UserID == nil
+}
+
+// OwnerID returns the ID of the user owning the cart, or 0 for a guest cart.
+func (c *Cart) OwnerID() uint {
+	if c.UserID == nil {
+		return 0
+	}
+	return *c.UserID
 }
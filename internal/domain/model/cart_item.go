@@ -21,4 +21,9 @@ type CartItem struct {
 	Quantity  int     `json:"quantity" gorm:"not null;default:1"`
 	UnitPrice float64 `json:"unit_price" gorm:"type:decimal(10,2);not null"`
 	Subtotal  float64 `json:"subtotal" gorm:"type:decimal(10,2);not null"`
+
+	// NetSubtotal and GrossSubtotal show Subtotal without and with tax in
+	// the country asked for. They are not stored.
+	NetSubtotal   *float64 `json:"net_subtotal,omitempty" gorm:"-"`
+	GrossSubtotal *float64 `json:"gross_subtotal,omitempty" gorm:"-"`
 }
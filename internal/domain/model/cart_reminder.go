@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// CartReminder is a recovery email sent for an abandoned cart. It keeps a
+// snapshot of the items so the signed link can restore them, and records the
+// order the reminder led to, if any.
+type CartReminder struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CartID    uint               `json:"cart_id" gorm:"not null;index"`
+	UserID    uint               `json:"user_id" gorm:"not null;index"`
+	Items     []CartReminderItem `json:"items" gorm:"serializer:json;type:text"`
+	CartValue float64            `json:"cart_value" gorm:"type:decimal(12,2);not null"`
+
+	SentAt     *time.Time `json:"sent_at,omitempty" gorm:"index"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+
+	RecoveredOrderID *uint      `json:"recovered_order_id,omitempty"`
+	RecoveredRevenue float64    `json:"recovered_revenue" gorm:"type:decimal(12,2)"`
+	RecoveredAt      *time.Time `json:"recovered_at,omitempty"`
+}
+
+type CartReminderItem struct {
+	ProductID uint    `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
@@ -12,11 +12,41 @@ type Category struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	Name    string  `json:"name" gorm:"size:100;uniqueIndex;not null"`
-	IconURL *string `json:"icon_url,omitempty" gorm:"type:text"`
+	Name string `json:"name" gorm:"size:100;uniqueIndex;not null"`
+	// Slug names the category in storefront URLs. It is made from the name
+	// unless given, and is unique among categories.
+	Slug            string  `json:"slug" gorm:"size:200;uniqueIndex"`
+	MetaTitle       string  `json:"meta_title" gorm:"size:200"`
+	MetaDescription string  `json:"meta_description" gorm:"size:500"`
+	IconURL         *string `json:"icon_url,omitempty" gorm:"type:text"`
+	// Image is set when the icon was uploaded rather than given by URL.
+	Image *ImageVariants `json:"image,omitempty" gorm:"serializer:json;type:text"`
+	// ImageKeys are the files of the uploaded icon, removed with it.
+	ImageKeys StringList `json:"-"`
 
 	Products       []Product  `json:"products,omitempty" gorm:"foreignKey:CategoryID"`
 	ParentID       *uint      `json:"parent_id,omitempty"`
 	ParentCategory *Category  `json:"parent_category,omitempty" gorm:"foreignKey:ParentID"`
 	Subcategories  []Category `json:"subcategories,omitempty" gorm:"foreignKey:ParentID"`
 }
+
+// CategoryNode is a category in the tree of all categories, with its
+// subcategories as Children.
+type CategoryNode struct {
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	IconURL  *string `json:"icon_url,omitempty"`
+	ParentID *uint   `json:"parent_id,omitempty"`
+	// ProductCount counts the category's own products; TotalProductCount
+	// adds those of all its descendants.
+	ProductCount      int64          `json:"product_count"`
+	TotalProductCount int64          `json:"total_product_count"`
+	Children          []CategoryNode `json:"children"`
+}
+
+// CategoryBreadcrumb is one step of the path from a top-level category
+// down to another.
+type CategoryBreadcrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// ExchangeRate is how many units of Currency one unit of the store's base
+// currency buys, e.g. 0.92 for EUR in a store that keeps its books in USD.
+// The base currency has no rate of its own; it is always 1.
+type ExchangeRate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Currency is an upper-case ISO 4217 code.
+	Currency string  `json:"currency" gorm:"size:3;not null;uniqueIndex"`
+	Rate     float64 `json:"rate" gorm:"not null"`
+}
+
+// ConvertAmount converts amount between two currencies given their rates
+// against the base currency. The result is rounded half away from zero to
+// cents, like every other amount.
+func ConvertAmount(amount, fromRate, toRate float64) float64 {
+	if fromRate == toRate {
+		return RoundMoney(amount)
+	}
+	return RoundMoney(amount / fromRate * toRate)
+}
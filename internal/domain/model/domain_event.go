@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+// DomainEvent is something that happened to an aggregate which other parts of
+// the system may want to react to. Events are plain data and are stored as JSON.
+type DomainEvent interface {
+	EventType() string
+	AggregateType() string
+	AggregateID() uint
+}
+
+// Event types.
+const (
+	EventOrderCreated        = "OrderCreated"
+	EventOrderPaid           = "OrderPaid"
+	EventOrderCancelled      = "OrderCancelled"
+	EventProductPriceChanged = "ProductPriceChanged"
+	EventStockLow            = "StockLow"
+	EventCartUpdated         = "CartUpdated"
+)
+
+// StockLowThreshold is the stock level below which StockLow is raised. The
+// event fires once when stock drops below it, not on every later sale.
+const StockLowThreshold = 5
+
+type OrderCreatedItem struct {
+	ProductID uint    `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+type OrderCreated struct {
+	OrderID       uint          `json:"order_id"`
+	UserID        uint          `json:"user_id"`
+	PaymentMethod PaymentMethod `json:"payment_method"`
+	Total         float64       `json:"total"`
+	// Currency of Total and the unit prices, and its rate against the base
+	// currency when the order was placed.
+	Currency     string             `json:"currency"`
+	ExchangeRate float64            `json:"exchange_rate"`
+	Items        []OrderCreatedItem `json:"items"`
+}
+
+func (e OrderCreated) EventType() string     { return EventOrderCreated }
+func (e OrderCreated) AggregateType() string { return AuditEntityOrder }
+func (e OrderCreated) AggregateID() uint     { return e.OrderID }
+
+type OrderPaid struct {
+	OrderID  uint      `json:"order_id"`
+	UserID   uint      `json:"user_id"`
+	Total    float64   `json:"total"`
+	Currency string    `json:"currency"`
+	PaidAt   time.Time `json:"paid_at"`
+}
+
+func (e OrderPaid) EventType() string     { return EventOrderPaid }
+func (e OrderPaid) AggregateType() string { return AuditEntityOrder }
+func (e OrderPaid) AggregateID() uint     { return e.OrderID }
+
+type OrderCancelled struct {
+	OrderID     uint      `json:"order_id"`
+	UserID      uint      `json:"user_id"`
+	Total       float64   `json:"total"`
+	Currency    string    `json:"currency"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+func (e OrderCancelled) EventType() string     { return EventOrderCancelled }
+func (e OrderCancelled) AggregateType() string { return AuditEntityOrder }
+func (e OrderCancelled) AggregateID() uint     { return e.OrderID }
+
+type ProductPriceChanged struct {
+	ProductID uint    `json:"product_id"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+	Currency  string  `json:"currency"`
+}
+
+func (e ProductPriceChanged) EventType() string     { return EventProductPriceChanged }
+func (e ProductPriceChanged) AggregateType() string { return AuditEntityProduct }
+func (e ProductPriceChanged) AggregateID() uint     { return e.ProductID }
+
+type StockLow struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+}
+
+func (e StockLow) EventType() string     { return EventStockLow }
+func (e StockLow) AggregateType() string { return AuditEntityProduct }
+func (e StockLow) AggregateID() uint     { return e.ProductID }
+
+type CartUpdated struct {
+	CartID uint `json:"cart_id"`
+	// UserID is 0 for guest carts.
+	UserID uint    `json:"user_id"`
+	Total  float64 `json:"total"`
+}
+
+func (e CartUpdated) EventType() string     { return EventCartUpdated }
+func (e CartUpdated) AggregateType() string { return "cart" }
+func (e CartUpdated) AggregateID() uint     { return e.CartID }
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// EmailChange is a pending request to move an account to a new email address.
+// It takes effect only after the token sent to the new address is confirmed.
+type EmailChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	NewEmail  string `json:"new_email" gorm:"size:100;not null"`
+	TokenHash string `json:"-" gorm:"size:64;uniqueIndex;not null"`
+
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
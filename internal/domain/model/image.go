@@ -0,0 +1,9 @@
+package model
+
+// ImageVariants are the URLs of the copies made of an uploaded image, each
+// scaled down to fit a square of its size.
+type ImageVariants struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Large     string `json:"large"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Impersonation records every token an admin obtained to act as another user.
+// Rows are never updated or deleted.
+type Impersonation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	AdminID      uint   `json:"admin_id" gorm:"not null;index"`
+	Admin        *User  `json:"admin,omitempty" gorm:"foreignKey:AdminID"`
+	TargetUserID uint   `json:"target_user_id" gorm:"not null;index"`
+	TargetUser   *User  `json:"target_user,omitempty" gorm:"foreignKey:TargetUserID"`
+	Reason       string `json:"reason" gorm:"size:500;not null"`
+	IPAddress    string `json:"ip_address" gorm:"size:64"`
+
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+}
@@ -0,0 +1,92 @@
+package model
+
+import "time"
+
+type InvoiceType string
+
+const (
+	InvoiceTypeInvoice    InvoiceType = "INVOICE"
+	InvoiceTypeCreditNote InvoiceType = "CREDIT_NOTE"
+)
+
+// Invoice is a VAT invoice issued for a paid order, or a credit note that
+// corrects one. Seller and buyer data are copied when it is issued, so later
+// changes to the store or the account do not alter it. Invoices are never
+// updated or deleted; refunds are recorded as credit notes with negative
+// quantities and amounts.
+type Invoice struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Number is e.g. "INV/2026/000042": the type's prefix, the year and the
+	// sequence, which counts each type from 1 every year without gaps.
+	Number   string      `json:"number" gorm:"size:50;not null;uniqueIndex"`
+	Type     InvoiceType `json:"type" gorm:"type:VARCHAR(20);not null;uniqueIndex:idx_invoice_sequence"`
+	Year     int         `json:"year" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	Sequence int         `json:"sequence" gorm:"not null;uniqueIndex:idx_invoice_sequence"`
+	IssuedAt time.Time   `json:"issued_at" gorm:"not null"`
+
+	OrderID uint `json:"order_id" gorm:"not null;index"`
+
+	// The invoice a credit note corrects, and why.
+	CorrectedInvoiceID *uint  `json:"corrected_invoice_id,omitempty" gorm:"index"`
+	CorrectedNumber    string `json:"corrected_number,omitempty" gorm:"size:50"`
+	Reason             string `json:"reason,omitempty" gorm:"size:500"`
+
+	SellerName    string `json:"seller_name" gorm:"size:200;not null"`
+	SellerAddress string `json:"seller_address" gorm:"size:500"`
+	SellerTaxID   string `json:"seller_tax_id" gorm:"size:50"`
+
+	BuyerName    string `json:"buyer_name" gorm:"size:200"`
+	BuyerCompany string `json:"buyer_company,omitempty" gorm:"size:200"`
+	BuyerTaxID   string `json:"buyer_tax_id,omitempty" gorm:"size:50"`
+	BuyerEmail   string `json:"buyer_email" gorm:"size:100"`
+	BuyerAddress string `json:"buyer_address" gorm:"size:500"`
+
+	Lines    []InvoiceLine    `json:"lines" gorm:"foreignKey:InvoiceID"`
+	TaxLines []InvoiceTaxLine `json:"tax_lines" gorm:"foreignKey:InvoiceID"`
+
+	NetTotal float64 `json:"net_total" gorm:"type:decimal(12,2);not null"`
+	TaxTotal float64 `json:"tax_total" gorm:"type:decimal(12,2);not null"`
+	Total    float64 `json:"total" gorm:"type:decimal(12,2);not null"`
+	// Currency of all amounts, that of the order.
+	Currency string `json:"currency" gorm:"size:3;not null;default:'USD'"`
+}
+
+// InvoiceLine is an order item, or the shipping, as invoiced.
+type InvoiceLine struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InvoiceID uint `json:"invoice_id" gorm:"not null;index"`
+	// OrderItemID is nil for the shipping line.
+	OrderItemID *uint `json:"order_item_id,omitempty"`
+
+	Name     string  `json:"name" gorm:"size:200;not null"`
+	Quantity int     `json:"quantity" gorm:"not null"`
+	UnitNet  float64 `json:"unit_net" gorm:"type:decimal(12,2);not null"`
+	TaxRate  float64 `json:"tax_rate" gorm:"type:decimal(5,2);not null"`
+	Net      float64 `json:"net" gorm:"type:decimal(12,2);not null"`
+	Tax      float64 `json:"tax" gorm:"type:decimal(12,2);not null"`
+	Gross    float64 `json:"gross" gorm:"type:decimal(12,2);not null"`
+}
+
+// InvoiceTaxLine is one row of the invoice's VAT summary.
+type InvoiceTaxLine struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InvoiceID uint `json:"invoice_id" gorm:"not null;index"`
+
+	TaxAmounts `gorm:"embedded"`
+}
+
+// InvoiceSequence holds the last number issued for a type in a year.
+type InvoiceSequence struct {
+	Type       InvoiceType `gorm:"primaryKey;type:VARCHAR(20)"`
+	Year       int         `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int         `gorm:"not null"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// JobLease guards a scheduled job so that only one replica runs each slot.
+// A replica owns the lease until LockedUntil; LastSlot is the most recent
+// scheduled time that was claimed, so a slot is never run twice.
+type JobLease struct {
+	Name        string     `gorm:"primaryKey;size:64" json:"name"`
+	Owner       string     `json:"owner" gorm:"size:128"`
+	LockedUntil time.Time  `json:"locked_until"`
+	LastSlot    *time.Time `json:"last_slot,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobRun is one execution of a background job, kept as history.
+type JobRun struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	JobName      string     `json:"job_name" gorm:"size:64;not null;index"`
+	Trigger      JobTrigger `json:"trigger" gorm:"type:VARCHAR(20);not null"`
+	Owner        string     `json:"owner" gorm:"size:128"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	DurationMs   int64      `json:"duration_ms"`
+	Status       JobStatus  `json:"status" gorm:"type:VARCHAR(20);not null;index"`
+	Result       string     `json:"result,omitempty" gorm:"size:500"`
+	Error        string     `json:"error,omitempty" gorm:"size:500"`
+}
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "RUNNING"
+	JobSucceeded JobStatus = "SUCCEEDED"
+	JobFailed    JobStatus = "FAILED"
+)
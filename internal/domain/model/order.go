@@ -12,8 +12,16 @@ type Order struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	UserID uint `json:"user_id" gorm:"not null;index"`
-	User   User `json:"user" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	// UserID is nil for a guest order until it is attached to an account
+	// registered with GuestEmail.
+	UserID *uint `json:"user_id,omitempty" gorm:"index"`
+	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+
+	// Contact details given at guest checkout. They stay on the order after
+	// it is attached to an account, until the account is closed or erased.
+	GuestEmail   string `json:"guest_email,omitempty" gorm:"size:100;index"`
+	GuestName    string `json:"guest_name,omitempty" gorm:"size:100"`
+	GuestSurname string `json:"guest_surname,omitempty" gorm:"size:100"`
 
 	Status OrderStatus `json:"status" gorm:"type:VARCHAR(20);not null;default:'PENDING'"`
 
@@ -26,9 +34,50 @@ type Order struct {
 
 	PaymentMethod PaymentMethod `json:"payment_method" gorm:"type:VARCHAR(30);not null"`
 
+	// The shipping method chosen at checkout. Its name and cost are copied so
+	// the order is unaffected by later changes to the method.
+	ShippingMethodID   *uint   `json:"shipping_method_id,omitempty" gorm:"index"`
+	ShippingMethodName string  `json:"shipping_method_name,omitempty" gorm:"size:100"`
+	ShippingCost       float64 `json:"shipping_cost" gorm:"type:decimal(12,2);not null;default:0"`
+
+	// ShippingTax is the part of ShippingCost that is tax, charged at the
+	// standard rate of the destination country. ShippingCost includes it.
+	ShippingTax     float64 `json:"shipping_tax" gorm:"type:decimal(12,2);not null;default:0"`
+	ShippingTaxRate float64 `json:"shipping_tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+
 	Items []OrderItem `json:"items,omitempty" gorm:"foreignKey:OrderID"`
 
+	// Tax totals of the items and shipping, with one line per rate.
+	NetTotal float64        `json:"net_total" gorm:"type:decimal(12,2);not null;default:0"`
+	TaxTotal float64        `json:"tax_total" gorm:"type:decimal(12,2);not null;default:0"`
+	TaxLines []OrderTaxLine `json:"tax_lines,omitempty" gorm:"foreignKey:OrderID"`
+
+	// Total is the sum of the items plus ShippingCost, tax included. It
+	// equals NetTotal plus TaxTotal.
 	Total float64 `json:"total" gorm:"type:decimal(12,2);not null"`
+
+	// All amounts of the order are in Currency, converted from the catalog
+	// prices at ExchangeRate, the rate of Currency against the base currency
+	// when the order was placed.
+	Currency     string  `json:"currency" gorm:"size:3;not null;default:'USD'"`
+	ExchangeRate float64 `json:"exchange_rate" gorm:"not null;default:1"`
+	// Locale is the language the order was placed in, which the item
+	// names are in.
+	Locale string `json:"locale,omitempty" gorm:"size:10"`
+}
+
+// IsGuest reports whether the order was placed without an account and has
+// not been attached to one since.
+func (o *Order) IsGuest() bool {
+	return o.UserID == nil
+}
+
+// OwnerID returns the ID of the user owning the order, or 0 for a guest order.
+func (o *Order) OwnerID() uint {
+	if o.UserID == nil {
+		return 0
+	}
+	return *o.UserID
 }
 
 type OrderStatus string
@@ -19,5 +19,14 @@ type OrderItem struct {
 	Name      string  `json:"name" gorm:"size:200;not null"`
 	UnitPrice float64 `json:"unit_price" gorm:"not null"`
 	Quantity  int     `json:"quantity" gorm:"not null"`
-	Subtotal  float64 `json:"subtotal" gorm:"type:decimal(10,2);not null"`
+	// Subtotal is the line total including tax; UnitPrice is the catalog
+	// price, which includes tax only in the gross price mode.
+	Subtotal float64 `json:"subtotal" gorm:"type:decimal(10,2);not null"`
+
+	// The tax charged on the line at the rate of TaxClass in the country the
+	// order ships to.
+	TaxClass    TaxClass `json:"tax_class" gorm:"type:VARCHAR(20);not null;default:'STANDARD'"`
+	TaxRate     float64  `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+	NetSubtotal float64  `json:"net_subtotal" gorm:"type:decimal(10,2);not null;default:0"`
+	TaxAmount   float64  `json:"tax_amount" gorm:"type:decimal(10,2);not null;default:0"`
 }
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is a domain event waiting to be delivered to subscribers. It is
+// written in the same transaction as the change that raised it, so an event
+// exists if and only if the change was committed.
+type OutboxEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Type          string          `json:"type" gorm:"size:64;not null;index"`
+	AggregateType string          `json:"aggregate_type" gorm:"size:50;not null"`
+	AggregateID   uint            `json:"aggregate_id" gorm:"index"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:text"`
+
+	Status        OutboxStatus `json:"status" gorm:"type:VARCHAR(20);not null;default:'PENDING';index:idx_outbox_due"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index:idx_outbox_due"`
+	LastError     string       `json:"last_error,omitempty" gorm:"size:500"`
+	DeliveredAt   *time.Time   `json:"delivered_at,omitempty"`
+}
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "PENDING"
+	OutboxDelivered OutboxStatus = "DELIVERED"
+	OutboxFailed    OutboxStatus = "FAILED"
+)
+
+// Decode unmarshals the payload into the typed event, e.g. *OrderCreated.
+func (e *OutboxEvent) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
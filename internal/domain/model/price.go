@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// PriceSchedule puts a product on sale at SalePrice from StartsAt until
+// EndsAt, or until further notice when EndsAt is nil. The sale price is in
+// the product's currency.
+type PriceSchedule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ProductID uint       `json:"product_id" gorm:"not null;index"`
+	SalePrice float64    `json:"sale_price" gorm:"type:decimal(12,2);not null"`
+	StartsAt  time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt    *time.Time `json:"ends_at,omitempty" gorm:"index"`
+}
+
+// ActiveAt reports whether the sale is on at t.
+func (s PriceSchedule) ActiveAt(t time.Time) bool {
+	return !t.Before(s.StartsAt) && (s.EndsAt == nil || t.Before(*s.EndsAt))
+}
+
+// Overlaps reports whether the two sales are on at the same time at any point.
+func (s PriceSchedule) Overlaps(other PriceSchedule) bool {
+	startsBeforeOtherEnds := other.EndsAt == nil || s.StartsAt.Before(*other.EndsAt)
+	endsAfterOtherStarts := s.EndsAt == nil || other.StartsAt.Before(*s.EndsAt)
+	return startsBeforeOtherEnds && endsAfterOtherStarts
+}
+
+// PriceChangeReason tells why a product's price changed.
+type PriceChangeReason string
+
+const (
+	PriceChangeCreated     PriceChangeReason = "created"
+	PriceChangeUpdated     PriceChangeReason = "updated"
+	PriceChangeSaleStarted PriceChangeReason = "sale_started"
+	PriceChangeSaleEnded   PriceChangeReason = "sale_ended"
+)
+
+// PriceHistory is a price a product sold at, from ChangedAt until the next
+// entry of the same product. Entries are never changed or deleted, so that
+// the lowest price of the past 30 days can be shown as the EU Omnibus
+// Directive requires.
+type PriceHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ProductID uint              `json:"product_id" gorm:"not null;index:idx_price_history_product_changed,priority:1"`
+	Price     float64           `json:"price" gorm:"type:decimal(12,2);not null"`
+	Currency  string            `json:"currency" gorm:"size:10;not null"`
+	Reason    PriceChangeReason `json:"reason" gorm:"type:VARCHAR(20);not null"`
+	ChangedAt time.Time         `json:"changed_at" gorm:"not null;index:idx_price_history_product_changed,priority:2"`
+}
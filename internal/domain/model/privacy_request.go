@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// PrivacyRequest tracks a data subject request (GDPR export or erasure) from
+// the moment it is received until an admin completes or rejects it.
+type PrivacyRequest struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID uint                 `json:"user_id" gorm:"not null;index"`
+	User   *User                `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Type   PrivacyRequestType   `json:"type" gorm:"type:VARCHAR(20);not null"`
+	Status PrivacyRequestStatus `json:"status" gorm:"type:VARCHAR(20);not null;default:'PENDING';index"`
+	Note   string               `json:"note,omitempty" gorm:"size:1000"`
+
+	ProcessedByID *uint      `json:"processed_by_id,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+}
+
+type PrivacyRequestType string
+
+const (
+	PrivacyExport  PrivacyRequestType = "EXPORT"
+	PrivacyErasure PrivacyRequestType = "ERASURE"
+)
+
+type PrivacyRequestStatus string
+
+const (
+	PrivacyPending   PrivacyRequestStatus = "PENDING"
+	PrivacyCompleted PrivacyRequestStatus = "COMPLETED"
+	PrivacyRejected  PrivacyRequestStatus = "REJECTED"
+)
@@ -12,12 +12,42 @@ type Product struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// SKU is the merchant's own product code, optional and unique. Imports
+	// match products by it.
+	SKU         *string `json:"sku,omitempty" gorm:"size:64;uniqueIndex"`
 	Name        string  `json:"name" gorm:"size:200;not null"`
 	Description string  `json:"description" gorm:"type:text"`
-	Price       float64 `json:"price" gorm:"not null"`
-	Currency    string  `json:"currency" gorm:"size:10;not null;default:'USD'"`
-	Stock       int     `json:"stock" gorm:"not null;default:0"`
-	IsActive    bool    `json:"is_active" gorm:"not null;default:true"`
+	// Slug names the product in storefront URLs. It is made from the name
+	// unless given, and is unique among products.
+	Slug string `json:"slug" gorm:"size:200;uniqueIndex"`
+	// MetaTitle and MetaDescription are for search engines; storefronts
+	// fall back to the name and description when they are empty.
+	MetaTitle       string  `json:"meta_title" gorm:"size:200"`
+	MetaDescription string  `json:"meta_description" gorm:"size:500"`
+	Price           float64 `json:"price" gorm:"not null"`
+	Currency        string  `json:"currency" gorm:"size:10;not null;default:'USD'"`
+	// CompareAtPrice is the regular price while the product is on sale, in
+	// which case Price is shown as the sale price. It is not stored.
+	CompareAtPrice *float64 `json:"compare_at_price,omitempty" gorm:"-"`
+	Stock          int      `json:"stock" gorm:"not null;default:0"`
+	IsActive       bool     `json:"is_active" gorm:"not null;default:true"`
+
+	// TaxClass picks the product's rate from the tax table of the country
+	// an order ships to.
+	TaxClass TaxClass `json:"tax_class" gorm:"type:VARCHAR(20);not null;default:'STANDARD'"`
+	// PriceMode tells whether Price includes tax. NetPrice and GrossPrice
+	// show it without and with tax in the country asked for. They are not
+	// stored.
+	PriceMode  PriceMode `json:"price_mode,omitempty" gorm:"-"`
+	NetPrice   *float64  `json:"net_price,omitempty" gorm:"-"`
+	GrossPrice *float64  `json:"gross_price,omitempty" gorm:"-"`
+
+	// Shipping data: weight in kg, dimensions of the packed item in cm.
+	// Zero means unknown and does not limit the shipping methods offered.
+	Weight float64 `json:"weight" gorm:"not null;default:0"`
+	Length float64 `json:"length" gorm:"not null;default:0"`
+	Width  float64 `json:"width" gorm:"not null;default:0"`
+	Height float64 `json:"height" gorm:"not null;default:0"`
 
 	CategoryID uint     `json:"category_id" gorm:"not null;index"`
 	Category   Category `json:"category" gorm:"foreignKey:CategoryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
@@ -34,4 +64,18 @@ type ProductImage struct {
 	URL       string  `json:"url" gorm:"size:500;not null"`
 	ProductID uint    `json:"product_id" gorm:"not null;index"`
 	Product   Product `gorm:"foreignKey:ProductID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	// Position orders the product's images from 0. The primary image is the
+	// one shown in listings.
+	Position  int  `json:"position" gorm:"not null;default:0"`
+	IsPrimary bool `json:"is_primary" gorm:"not null;default:false"`
+
+	// Uploaded images have resized variants and known dimensions; images
+	// added by URL have neither.
+	Variants    *ImageVariants `json:"variants,omitempty" gorm:"serializer:json;type:text"`
+	ContentType string         `json:"content_type,omitempty" gorm:"size:50"`
+	Width       int            `json:"width,omitempty"`
+	Height      int            `json:"height,omitempty"`
+	// StorageKeys are the files of an uploaded image, removed with it.
+	StorageKeys StringList `json:"-"`
 }
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// ProductImport is one upload of products from a CSV or JSON file. Small
+// files are imported while the request waits; larger ones are queued and
+// processed in the background, with progress kept here.
+type ProductImport struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// The admin or API key that uploaded the file; queued imports write to
+	// the catalog on their behalf.
+	UserID   uint   `json:"user_id,omitempty" gorm:"index"`
+	APIKeyID uint   `json:"api_key_id,omitempty"`
+	Role     string `json:"-" gorm:"size:20"`
+
+	Format ProductFileFormat   `json:"format" gorm:"type:VARCHAR(10);not null"`
+	DryRun bool                `json:"dry_run"`
+	Status ProductImportStatus `json:"status" gorm:"type:VARCHAR(20);not null;index"`
+
+	// Created and Updated count the products written, or for a dry run the
+	// products that would be.
+	TotalRows     int                  `json:"total_rows"`
+	ProcessedRows int                  `json:"processed_rows"`
+	Created       int                  `json:"created"`
+	Updated       int                  `json:"updated"`
+	Failed        int                  `json:"failed"`
+	Errors        []ProductImportError `json:"errors" gorm:"serializer:json;type:text"`
+	Error         string               `json:"error,omitempty" gorm:"size:500"`
+
+	// Payload is the uploaded file, kept until a queued import has run.
+	Payload    []byte     `json:"-"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ProductImportError explains why a row was not imported. Row counts the
+// records of the file from 1, not counting the CSV header.
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+type ProductFileFormat string
+
+const (
+	ProductFileCSV  ProductFileFormat = "csv"
+	ProductFileJSON ProductFileFormat = "json"
+)
+
+type ProductImportStatus string
+
+const (
+	ProductImportPending   ProductImportStatus = "PENDING"
+	ProductImportRunning   ProductImportStatus = "RUNNING"
+	ProductImportCompleted ProductImportStatus = "COMPLETED"
+	ProductImportFailed    ProductImportStatus = "FAILED"
+)
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShippingZone is an area we deliver to: a set of countries, optionally
+// narrowed down to postcode ranges.
+type ShippingZone struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Name string `json:"name" gorm:"size:100;not null"`
+	// Countries are compared with Address.Country, ignoring case.
+	Countries StringList `json:"countries" gorm:"not null"`
+	// PostcodeRanges entries are either "from..to" or a single prefix, e.g.
+	// "00-001..39-999" or "30". An empty list covers the whole countries.
+	PostcodeRanges StringList `json:"postcode_ranges"`
+}
+
+// Covers reports whether the zone includes the address.
+func (z *ShippingZone) Covers(address Address) bool {
+	inCountry := false
+	for _, country := range z.Countries {
+		if strings.EqualFold(strings.TrimSpace(country), strings.TrimSpace(address.Country)) {
+			inCountry = true
+			break
+		}
+	}
+	if !inCountry {
+		return false
+	}
+	if len(z.PostcodeRanges) == 0 {
+		return true
+	}
+
+	postcode := NormalizePostcode(address.Postcode)
+	for _, entry := range z.PostcodeRanges {
+		from, to, isRange := strings.Cut(entry, "..")
+		from, to = NormalizePostcode(from), NormalizePostcode(to)
+		if !isRange {
+			if from != "" && strings.HasPrefix(postcode, from) {
+				return true
+			}
+			continue
+		}
+		// Bounds are compared with the postcode cut to their length, so
+		// "30..39" covers every postcode from 30xxx to 39xxx.
+		if len(postcode) >= len(from) && len(postcode) >= len(to) &&
+			postcode[:len(from)] >= from && postcode[:len(to)] <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizePostcode upper-cases a postcode and drops spaces and dashes.
+func NormalizePostcode(postcode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(postcode)))
+}
+
+type ShippingMethodType string
+
+const (
+	ShippingCourier      ShippingMethodType = "COURIER"
+	ShippingParcelLocker ShippingMethodType = "PARCEL_LOCKER"
+	ShippingPickup       ShippingMethodType = "PICKUP"
+)
+
+// ShippingMethod is a way of delivering to one zone, priced by its rates.
+type ShippingMethod struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	ZoneID uint         `json:"zone_id" gorm:"not null;index"`
+	Zone   ShippingZone `json:"zone" gorm:"foreignKey:ZoneID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+
+	Name     string             `json:"name" gorm:"size:100;not null"`
+	Type     ShippingMethodType `json:"type" gorm:"type:VARCHAR(20);not null"`
+	IsActive bool               `json:"is_active" gorm:"not null;default:true"`
+
+	// Largest item the method accepts, in cm; zero means no limit. Items are
+	// compared side by side with both sorted, so they may be turned.
+	MaxLength float64 `json:"max_length" gorm:"not null;default:0"`
+	MaxWidth  float64 `json:"max_width" gorm:"not null;default:0"`
+	MaxHeight float64 `json:"max_height" gorm:"not null;default:0"`
+
+	Rates []ShippingRate `json:"rates" gorm:"foreignKey:MethodID"`
+}
+
+// ShippingRate prices a method for parcels within its bounds. A zero upper
+// bound is open-ended. When several rates match, the cheapest applies, so a
+// rate with MinSubtotal and a Price of 0 makes shipping free above that value.
+type ShippingRate struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	MethodID uint `json:"method_id" gorm:"not null;index"`
+
+	MinWeight   float64 `json:"min_weight"`
+	MaxWeight   float64 `json:"max_weight"`
+	MinSubtotal float64 `json:"min_subtotal"`
+	MaxSubtotal float64 `json:"max_subtotal"`
+	MinItems    int     `json:"min_items"`
+	MaxItems    int     `json:"max_items"`
+
+	Price float64 `json:"price" gorm:"type:decimal(12,2);not null"`
+}
+
+// Matches reports whether a parcel of the given weight (kg), value and item
+// count falls within the rate's bounds.
+func (r *ShippingRate) Matches(weight, subtotal float64, items int) bool {
+	return weight >= r.MinWeight && (r.MaxWeight == 0 || weight <= r.MaxWeight) &&
+		subtotal >= r.MinSubtotal && (r.MaxSubtotal == 0 || subtotal <= r.MaxSubtotal) &&
+		items >= r.MinItems && (r.MaxItems == 0 || items <= r.MaxItems)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Kinds of entities with slugs.
+const (
+	SlugEntityProduct  = "product"
+	SlugEntityCategory = "category"
+)
+
+// SlugRedirect sends links to a slug a product or category no longer has to
+// the one it has now.
+type SlugRedirect struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	EntityType string `json:"entity_type" gorm:"size:20;not null;uniqueIndex:idx_slug_redirect"`
+	Slug       string `json:"slug" gorm:"size:200;not null;uniqueIndex:idx_slug_redirect"`
+	EntityID   uint   `json:"entity_id" gorm:"not null;index"`
+}
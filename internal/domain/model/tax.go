@@ -0,0 +1,117 @@
+package model
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TaxClass string
+
+const (
+	TaxStandard TaxClass = "STANDARD"
+	TaxReduced  TaxClass = "REDUCED"
+	TaxZero     TaxClass = "ZERO"
+)
+
+// PriceMode tells whether catalog prices already include tax.
+type PriceMode string
+
+const (
+	// PriceModeGross prices include tax, which is taken out of them.
+	PriceModeGross PriceMode = "gross"
+	// PriceModeNet prices exclude tax, which is added on top of them.
+	PriceModeNet PriceMode = "net"
+)
+
+// TaxRate is the rate charged on one tax class when shipping to a country.
+// Countries without a rate for a class are charged no tax on it.
+type TaxRate struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Country is compared with Address.Country, ignoring case.
+	Country string   `json:"country" gorm:"size:100;not null;index"`
+	Class   TaxClass `json:"class" gorm:"type:VARCHAR(20);not null"`
+	// Rate is a percentage, e.g. 23 for 23% VAT.
+	Rate float64 `json:"rate" gorm:"type:decimal(5,2);not null"`
+}
+
+// TaxAmounts is what was charged at one rate.
+type TaxAmounts struct {
+	Rate  float64 `json:"rate" gorm:"type:decimal(5,2);not null"`
+	Net   float64 `json:"net" gorm:"type:decimal(12,2);not null"`
+	Tax   float64 `json:"tax" gorm:"type:decimal(12,2);not null"`
+	Gross float64 `json:"gross" gorm:"type:decimal(12,2);not null"`
+}
+
+// OrderTaxLine sums up the order's items and shipping charged at one rate,
+// as printed in the VAT summary of an invoice.
+type OrderTaxLine struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	OrderID uint `json:"order_id" gorm:"not null;index"`
+
+	TaxAmounts `gorm:"embedded"`
+}
+
+// TaxSummary adds amounts up per rate. The zero value is ready to use.
+type TaxSummary struct {
+	byRate map[float64]*TaxAmounts
+}
+
+// Add charges net plus tax at rate. Lines where both are zero are skipped.
+func (s *TaxSummary) Add(rate, net, tax float64) {
+	if net == 0 && tax == 0 {
+		return
+	}
+	if s.byRate == nil {
+		s.byRate = map[float64]*TaxAmounts{}
+	}
+	amounts, ok := s.byRate[rate]
+	if !ok {
+		amounts = &TaxAmounts{Rate: rate}
+		s.byRate[rate] = amounts
+	}
+	amounts.Net = RoundMoney(amounts.Net + net)
+	amounts.Tax = RoundMoney(amounts.Tax + tax)
+	amounts.Gross = RoundMoney(amounts.Net + amounts.Tax)
+}
+
+// Amounts returns the sums per rate, highest rate first, with their totals.
+func (s *TaxSummary) Amounts() (amounts []TaxAmounts, net, tax float64) {
+	for _, a := range s.byRate {
+		amounts = append(amounts, *a)
+		net = RoundMoney(net + a.Net)
+		tax = RoundMoney(tax + a.Tax)
+	}
+	sort.Slice(amounts, func(i, j int) bool {
+		return amounts[i].Rate > amounts[j].Rate
+	})
+	return amounts, net, tax
+}
+
+// SplitTax splits amount, priced in the given mode, into its net part and
+// the tax charged at rate percent. Both are rounded to cents and always add
+// up to the gross amount.
+func SplitTax(amount, rate float64, mode PriceMode) (net, tax float64) {
+	if mode == PriceModeNet {
+		net = RoundMoney(amount)
+		return net, RoundMoney(net * rate / 100)
+	}
+	gross := RoundMoney(amount)
+	net = RoundMoney(gross / (1 + rate/100))
+	return net, RoundMoney(gross - net)
+}
+
+// RoundMoney rounds an amount to cents, halves away from zero. It never
+// returns negative zero, which would print as "-0.00".
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100)/100 + 0
+}
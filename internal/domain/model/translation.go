@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// ProductTranslation holds a product's name and description in a locale
+// other than the default one, which the product's own fields are in.
+type ProductTranslation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ProductID   uint   `json:"product_id" gorm:"not null;uniqueIndex:idx_product_translation,priority:1"`
+	Locale      string `json:"locale" gorm:"size:10;not null;uniqueIndex:idx_product_translation,priority:2"`
+	Name        string `json:"name" gorm:"size:200;not null"`
+	Description string `json:"description" gorm:"type:text"`
+}
+
+// CategoryTranslation holds a category's name in a locale other than the
+// default one.
+type CategoryTranslation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CategoryID uint   `json:"category_id" gorm:"not null;uniqueIndex:idx_category_translation,priority:1"`
+	Locale     string `json:"locale" gorm:"size:10;not null;uniqueIndex:idx_category_translation,priority:2"`
+	Name       string `json:"name" gorm:"size:100;not null"`
+}
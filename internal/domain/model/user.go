@@ -20,6 +20,32 @@ type User struct {
 	AddressID uint    `json:"address_id" gorm:"not null"`
 	Address   Address `json:"address" gorm:"foreignKey:AddressID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 
+	// Currency is the preferred currency for prices, empty for the store's
+	// base currency. A currency chosen in the request takes precedence.
+	Currency string `json:"currency,omitempty" gorm:"size:3"`
+
+	// EmailVerifiedAt is set once the user proved they own Email, either with
+	// the verification link or by confirming an email change.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// TokenVersion is raised whenever the password changes. Tokens issued
+	// with an older version are refused.
+	TokenVersion int `json:"-" gorm:"not null;default:0"`
+
+	FailedLoginAttempts int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	DeactivatedAt       *time.Time `json:"deactivated_at,omitempty"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+
 	Cart   *Cart   `json:"cart,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Orders []Order `json:"orders,omitempty" gorm:"foreignKey:UserID"`
 }
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
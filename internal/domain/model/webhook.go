@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription tells us to POST events of the listed types to URL,
+// signed with Secret. It is disabled automatically after too many failed
+// delivery attempts in a row.
+type WebhookSubscription struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	URL         string     `json:"url" gorm:"size:2048;not null"`
+	Description string     `json:"description,omitempty" gorm:"size:255"`
+	EventTypes  StringList `json:"event_types" gorm:"not null"`
+	Secret      string     `json:"-" gorm:"size:100;not null"`
+
+	IsActive            bool       `json:"is_active" gorm:"not null"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+
+	CreatedByID uint `json:"created_by_id" gorm:"index"`
+}
+
+// Subscribes reports whether the subscription wants events of the given type.
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return s.EventTypes.Contains(eventType)
+}
+
+// WebhookEventTypes are the domain events partners can subscribe to.
+var WebhookEventTypes = []string{
+	EventOrderCreated,
+	EventOrderPaid,
+	EventOrderCancelled,
+	EventProductPriceChanged,
+	EventStockLow,
+}
+
+// WebhookDelivery is one event sent to one subscription, with the outcome of
+// the latest attempt. Redelivering creates a new row so the log stays intact.
+type WebhookDelivery struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SubscriptionID uint            `json:"subscription_id" gorm:"not null;index:idx_webhook_delivery_event"`
+	OutboxEventID  uint            `json:"outbox_event_id" gorm:"index:idx_webhook_delivery_event"`
+	EventType      string          `json:"event_type" gorm:"size:64;not null"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:text"`
+	RedeliveryOfID *uint           `json:"redelivery_of_id,omitempty"`
+
+	Status        WebhookDeliveryStatus `json:"status" gorm:"type:VARCHAR(20);not null;default:'PENDING';index:idx_webhook_delivery_due"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	ResponseCode  int                   `json:"response_code,omitempty"`
+	ResponseBody  string                `json:"response_body,omitempty" gorm:"size:1000"`
+	LastError     string                `json:"last_error,omitempty" gorm:"size:500"`
+	DurationMs    int64                 `json:"duration_ms"`
+	DeliveredAt   *time.Time            `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "FAILED"
+)
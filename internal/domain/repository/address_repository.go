@@ -7,4 +7,7 @@ type AddressRepository interface {
 	Create(address *model.Address) error
 	Update(address *model.Address) error
 	Delete(id uint) error
+	// IsUsedByOrders reports whether any order ships to the address, in which
+	// case it is part of the order record and must not be altered.
+	IsUsedByOrders(id uint) (bool, error)
 }
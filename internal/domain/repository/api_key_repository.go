@@ -0,0 +1,11 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type APIKeyRepository interface {
+	FindByID(id uint) (*model.APIKey, error)
+	FindByPrefix(prefix string) (*model.APIKey, error)
+	FindAll() ([]model.APIKey, error)
+	Create(key *model.APIKey) error
+	Update(key *model.APIKey) error
+}
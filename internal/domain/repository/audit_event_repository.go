@@ -0,0 +1,9 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+// AuditEventRepository is append-only: events can be added and read, never changed.
+type AuditEventRepository interface {
+	FindWithFilters(filters map[string]string) ([]model.AuditEvent, error)
+	Create(event *model.AuditEvent) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type CartReminderRepository interface {
+	FindByID(id uint) (*model.CartReminder, error)
+	// FindLatestByCart returns the newest reminder for a cart, or nil.
+	FindLatestByCart(cartID uint) (*model.CartReminder, error)
+	// FindAttributable returns the reminders sent to userID in [from, to)
+	// that have not been credited with an order yet, newest first.
+	FindAttributable(userID uint, from, to time.Time) ([]model.CartReminder, error)
+	// FindSentBetween returns reminders sent in [from, to).
+	FindSentBetween(from, to time.Time) ([]model.CartReminder, error)
+	Create(reminder *model.CartReminder) error
+	Update(reminder *model.CartReminder) error
+}
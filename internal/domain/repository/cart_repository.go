@@ -1,12 +1,27 @@
 package repository
 
-import "go-ecommerce-api/internal/domain/model"
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
 
 type CartRepository interface {
 	FindByUserID(userID uint) (*model.Cart, error)
 	FindByCartID(cartID uint) (*model.Cart, error)
+	FindByGuestTokenHash(hash string) (*model.Cart, error)
 	FindWithFilters(filters map[string]string) ([]model.Cart, error)
 	Create(cart *model.Cart) error
 	Update(cart *model.Cart) error
 	Delete(cartID uint) error
+	// DeleteStale removes carts without items, and guest carts, that were
+	// last touched before the given time and returns how many were removed.
+	DeleteStale(before time.Time) (int64, error)
+	// DeleteUnusedGuests removes guest carts created before the given time
+	// that were never changed after the request that created them, i.e.
+	// whose token never came back, and returns how many were removed.
+	DeleteUnusedGuests(createdBefore time.Time) (int64, error)
+	// MarkAbandoned flags carts with items that have not changed since
+	// idleSince and returns how many were flagged.
+	MarkAbandoned(idleSince, now time.Time) (int64, error)
 }
@@ -0,0 +1,9 @@
+package repository
+
+// CatalogRepository tells when what the storefront shows of the catalog
+// has changed.
+type CatalogRepository interface {
+	// Version returns a value that changes whenever a product, one of its
+	// images, its price or a category is created, changed or deleted.
+	Version() (string, error)
+}
@@ -5,8 +5,21 @@ import "go-ecommerce-api/internal/domain/model"
 type CategoryRepository interface {
 	FindByID(id uint) (*model.Category, error)
 	FindAll() ([]model.Category, error)
+	// FindBySlug returns the category with the given slug, or nil if none has it.
+	FindBySlug(slug string) (*model.Category, error)
+	// SlugTaken reports whether a category other than exceptID, deleted ones
+	// included, has the slug.
+	SlugTaken(slug string, exceptID uint) (bool, error)
+	// FindWithoutSlug returns the categories saved before slugs existed.
+	FindWithoutSlug() ([]model.Category, error)
 	FindWithFilters(filters map[string]string) ([]model.Category, error)
 	Create(category *model.Category) error
 	Update(category *model.Category) error
+	// UpdateParent moves the category under parentID, or to the top when
+	// it is nil.
+	UpdateParent(id uint, parentID *uint) error
+	// CountProducts returns the number of products in each category that
+	// has any, not counting subcategories.
+	CountProducts() (map[uint]int64, error)
 	Delete(id uint) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type EmailChangeRepository interface {
+	FindByTokenHash(hash string) (*model.EmailChange, error)
+	Create(change *model.EmailChange) error
+	Update(change *model.EmailChange) error
+	// DeleteExpired removes unconfirmed requests that expired before the given
+	// time and returns how many were removed.
+	DeleteExpired(before time.Time) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type ExchangeRateRepository interface {
+	FindByID(id uint) (*model.ExchangeRate, error)
+	FindAll() ([]model.ExchangeRate, error)
+	// FindByCurrency returns the rate of a currency, or nil if it has none.
+	FindByCurrency(currency string) (*model.ExchangeRate, error)
+	Create(rate *model.ExchangeRate) error
+	Update(rate *model.ExchangeRate) error
+	Delete(id uint) error
+	// Upsert sets the rates of the given currencies, creating the missing
+	// ones, in one transaction.
+	Upsert(rates []model.ExchangeRate) error
+	// CountProducts returns how many products are priced in currency.
+	CountProducts(currency string) (int64, error)
+}
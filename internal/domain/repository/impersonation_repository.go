@@ -0,0 +1,8 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type ImpersonationRepository interface {
+	FindWithFilters(filters map[string]string) ([]model.Impersonation, error)
+	Create(impersonation *model.Impersonation) error
+}
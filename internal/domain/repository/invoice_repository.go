@@ -0,0 +1,18 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+// InvoiceRepository loads invoices with their lines and tax lines.
+type InvoiceRepository interface {
+	FindByID(id uint) (*model.Invoice, error)
+	// FindByOrderID returns the invoice and credit notes of an order, oldest first.
+	FindByOrderID(orderID uint) ([]model.Invoice, error)
+	// Issue takes the next sequence of the invoice's type and year, sets
+	// Sequence and Number (built by number) and saves the invoice, all in one
+	// transaction, so a failed save leaves no gap. It fails with
+	// gorm.ErrDuplicatedKey when the order already has an invoice of type
+	// INVOICE. Before numbering, prepare is called with the documents already
+	// issued for the order, read under the sequence lock; an error from it
+	// aborts the transaction.
+	Issue(invoice *model.Invoice, prepare func(issued []model.Invoice) error, number func(sequence int) string) error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type JobLeaseRepository interface {
+	// Acquire claims the named lease for owner until the given time, provided
+	// it is not held by anyone and slot has not been claimed before. It
+	// reports whether the lease was obtained.
+	Acquire(name, owner string, slot, now, until time.Time) (bool, error)
+	// Release gives up a lease held by owner, keeping its last slot.
+	Release(name, owner string) error
+}
+
+type JobRunRepository interface {
+	FindWithFilters(filters map[string]string) ([]model.JobRun, error)
+	Create(run *model.JobRun) error
+	Update(run *model.JobRun) error
+}
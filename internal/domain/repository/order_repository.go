@@ -9,4 +9,10 @@ type OrderRepository interface {
 	FindWithFilters(filters map[string]string) ([]model.Order, error)
 	Create(order *model.Order) error
 	Update(order *model.Order) error
+	// AttachGuestOrders gives the guest orders placed with email to the user
+	// and returns how many were attached.
+	AttachGuestOrders(email string, userID uint) (int64, error)
+	// ClearGuestContact blanks the guest checkout contact details on the
+	// user's orders.
+	ClearGuestContact(userID uint) error
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type OutboxRepository interface {
+	FindByID(id uint) (*model.OutboxEvent, error)
+	FindWithFilters(filters map[string]string) ([]model.OutboxEvent, error)
+	// FindDue returns pending events whose next attempt is at or before now, oldest first.
+	FindDue(now time.Time, limit int) ([]model.OutboxEvent, error)
+	Create(event *model.OutboxEvent) error
+	Update(event *model.OutboxEvent) error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type PriceScheduleRepository interface {
+	FindByID(id uint) (*model.PriceSchedule, error)
+	// FindByProduct returns the product's schedules, earliest start first.
+	FindByProduct(productID uint) ([]model.PriceSchedule, error)
+	// FindActive returns the schedules of the given products that are on at t.
+	FindActive(productIDs []uint, at time.Time) ([]model.PriceSchedule, error)
+	// FindProductsChanged returns the IDs of products whose sales started or
+	// ended between from (exclusive) and to (inclusive).
+	FindProductsChanged(from, to time.Time) ([]uint, error)
+	Create(schedule *model.PriceSchedule) error
+	Update(schedule *model.PriceSchedule) error
+	Delete(id uint) error
+}
+
+type PriceHistoryRepository interface {
+	Create(entry *model.PriceHistory) error
+	// FindLatest returns the product's current entry, or nil if it has none.
+	FindLatest(productID uint) (*model.PriceHistory, error)
+	// FindSince returns the product's entries changed after since, oldest
+	// first, preceded by the entry that was current at since, if any.
+	FindSince(productID uint, since time.Time) ([]model.PriceHistory, error)
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type PrivacyRequestRepository interface {
+	FindByID(id uint) (*model.PrivacyRequest, error)
+	FindWithFilters(filters map[string]string) ([]model.PrivacyRequest, error)
+	Create(request *model.PrivacyRequest) error
+	Update(request *model.PrivacyRequest) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type ProductImageRepository interface {
+	// FindByProduct returns the product's images by position.
+	FindByProduct(productID uint) ([]model.ProductImage, error)
+	Create(image *model.ProductImage) error
+	// Save writes the images, all or none of them.
+	Save(images []model.ProductImage) error
+	Delete(ids ...uint) error
+}
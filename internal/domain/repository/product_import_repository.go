@@ -0,0 +1,16 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type ProductImportRepository interface {
+	FindByID(id uint) (*model.ProductImport, error)
+	// FindRecent returns the latest imports, newest first.
+	FindRecent(limit int) ([]model.ProductImport, error)
+	// FindNextPending returns the oldest queued import, or nil if none is.
+	FindNextPending() (*model.ProductImport, error)
+	// FailRunning marks imports left RUNNING as FAILED with the given reason
+	// and reports how many there were.
+	FailRunning(reason string) (int64, error)
+	Create(productImport *model.ProductImport) error
+	Update(productImport *model.ProductImport) error
+}
@@ -4,8 +4,20 @@ import "go-ecommerce-api/internal/domain/model"
 
 type ProductRepository interface {
 	FindByID(id uint) (*model.Product, error)
+	// FindBySKU returns the product with the given SKU, or nil if none has it.
+	FindBySKU(sku string) (*model.Product, error)
+	// FindBySlug returns the product with the given slug, or nil if none has it.
+	FindBySlug(slug string) (*model.Product, error)
+	// SlugTaken reports whether a product other than exceptID, deleted ones
+	// included, has the slug.
+	SlugTaken(slug string, exceptID uint) (bool, error)
+	// FindWithoutSlug returns the products saved before slugs existed.
+	FindWithoutSlug() ([]model.Product, error)
 	FindAll() ([]model.Product, error)
 	FindWithFilters(filters map[string]string) ([]model.Product, error)
+	// FindWithFiltersInBatches passes the products matching the filters to fn
+	// batchSize at a time, in ID order, stopping at the first error.
+	FindWithFiltersInBatches(filters map[string]string, batchSize int, fn func(products []model.Product) error) error
 	Create(product *model.Product) error
 	Update(product *model.Product) error
 	Delete(id uint) error
@@ -0,0 +1,24 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type ShippingZoneRepository interface {
+	FindByID(id uint) (*model.ShippingZone, error)
+	FindAll() ([]model.ShippingZone, error)
+	Create(zone *model.ShippingZone) error
+	Update(zone *model.ShippingZone) error
+	Delete(id uint) error
+}
+
+// ShippingMethodRepository loads methods with their zone and rates.
+type ShippingMethodRepository interface {
+	FindByID(id uint) (*model.ShippingMethod, error)
+	FindAll() ([]model.ShippingMethod, error)
+	FindActive() ([]model.ShippingMethod, error)
+	CountByZone(zoneID uint) (int64, error)
+	CountActive() (int64, error)
+	Create(method *model.ShippingMethod) error
+	// Update saves the method and replaces its rates with method.Rates.
+	Update(method *model.ShippingMethod) error
+	Delete(id uint) error
+}
@@ -0,0 +1,11 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type SlugRedirectRepository interface {
+	// Find returns the redirect from an old slug, or nil if there is none.
+	Find(entityType, slug string) (*model.SlugRedirect, error)
+	// Save stores the redirect, replacing any other from the same slug.
+	Save(redirect *model.SlugRedirect) error
+	Delete(entityType, slug string) error
+}
@@ -0,0 +1,13 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type TaxRateRepository interface {
+	FindByID(id uint) (*model.TaxRate, error)
+	FindAll() ([]model.TaxRate, error)
+	// FindByCountry returns the rates of a country, matched ignoring case.
+	FindByCountry(country string) ([]model.TaxRate, error)
+	Create(rate *model.TaxRate) error
+	Update(rate *model.TaxRate) error
+	Delete(id uint) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+// TxRepositories are bound to one database transaction.
+type TxRepositories struct {
+	Orders          OrderRepository
+	Carts           CartRepository
+	CartItems       CartItemRepository
+	Products        ProductRepository
+	Outbox          OutboxRepository
+	Users           UserRepository
+	Addresses       AddressRepository
+	PrivacyRequests PrivacyRequestRepository
+	SlugRedirects   SlugRedirectRepository
+	PriceHistory    PriceHistoryRepository
+}
+
+// Transactor runs fn in a transaction. Everything written through the
+// repositories passed to fn is committed if fn returns nil and rolled back otherwise.
+type Transactor interface {
+	WithinTransaction(fn func(repos TxRepositories) error) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import "go-ecommerce-api/internal/domain/model"
+
+type TranslationRepository interface {
+	// FindProductTranslations returns the product's translations, by locale.
+	FindProductTranslations(productID uint) ([]model.ProductTranslation, error)
+	// FindProductTranslationsIn returns the translations of the products
+	// into locale; products without one are left out.
+	FindProductTranslationsIn(locale string, productIDs []uint) ([]model.ProductTranslation, error)
+	// SaveProductTranslation creates the translation or replaces the one
+	// the product has in its locale.
+	SaveProductTranslation(translation *model.ProductTranslation) error
+	DeleteProductTranslation(productID uint, locale string) error
+
+	FindCategoryTranslations(categoryID uint) ([]model.CategoryTranslation, error)
+	FindCategoryTranslationsIn(locale string, categoryIDs []uint) ([]model.CategoryTranslation, error)
+	SaveCategoryTranslation(translation *model.CategoryTranslation) error
+	DeleteCategoryTranslation(categoryID uint, locale string) error
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "go-ecommerce-api/internal/domain/model"
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
 
 type UserRepository interface {
 	FindByID(id uint) (*model.User, error)
@@ -10,4 +14,10 @@ type UserRepository interface {
 	Create(user *model.User) error
 	Update(user *model.User) error
 	Delete(id uint) error
+	// IncrementFailedLogins adds one to the user's failed login attempts in
+	// a single statement and returns the new count, so concurrent failures
+	// are all counted.
+	IncrementFailedLogins(id uint) (int, error)
+	// LockUntil refuses logins to the user until until.
+	LockUntil(id uint, until time.Time) error
 }
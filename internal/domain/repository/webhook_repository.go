@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+type WebhookSubscriptionRepository interface {
+	FindByID(id uint) (*model.WebhookSubscription, error)
+	FindAll() ([]model.WebhookSubscription, error)
+	FindActive() ([]model.WebhookSubscription, error)
+	Create(subscription *model.WebhookSubscription) error
+	Update(subscription *model.WebhookSubscription) error
+	Delete(id uint) error
+}
+
+type WebhookDeliveryRepository interface {
+	FindByID(id uint) (*model.WebhookDelivery, error)
+	// FindByEvent returns the original (non-redelivery) delivery of an outbox event to a subscription.
+	FindByEvent(subscriptionID, outboxEventID uint) (*model.WebhookDelivery, error)
+	FindWithFilters(filters map[string]string) ([]model.WebhookDelivery, error)
+	// FindDue returns pending deliveries whose next attempt is at or before now, oldest first.
+	FindDue(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	Create(delivery *model.WebhookDelivery) error
+	Update(delivery *model.WebhookDelivery) error
+}
@@ -17,18 +17,44 @@ func getJWTSecret() []byte {
 	return []byte("your-256-bit-secret")
 }
 
-func GenerateToken(userID uint, role string) (string, error) {
+func GenerateToken(userID uint, role string, tokenVersion int) (string, error) {
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"user_id":       userID,
+		"role":          role,
+		"token_version": tokenVersion,
+		"exp":           time.Now().Add(24 * time.Hour).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(getJWTSecret())
 }
 
-func JWTMiddleware() echo.MiddlewareFunc {
-	return echojwt.WithConfig(echojwt.Config{
+// GenerateImpersonationToken issues a short-lived token for userID that
+// records which admin is acting and which audit record authorised it.
+func GenerateImpersonationToken(userID uint, role string, tokenVersion int, impersonatorID, impersonationID uint, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id":          userID,
+		"role":             role,
+		"token_version":    tokenVersion,
+		"impersonator_id":  impersonatorID,
+		"impersonation_id": impersonationID,
+		"exp":              expiresAt.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(getJWTSecret())
+}
+
+// UserValidator returns the stored role of userID, or an error for users
+// that may no longer use the API even though their token is still valid,
+// e.g. deactivated accounts or tokens issued before a password change.
+type UserValidator func(userID uint, tokenVersion int) (string, error)
+
+// JWTMiddleware authenticates requests with the Authorization JWT or, as an
+// alternative for integrations, with an X-API-Key header checked by apiKeys.
+// JWT users are additionally checked with users on every request, and tokens
+// whose role claim no longer matches the stored role are refused, so a role
+// change takes effect at once instead of when the token expires.
+func JWTMiddleware(apiKeys APIKeyValidator, users UserValidator) echo.MiddlewareFunc {
+	jwtMiddleware := echojwt.WithConfig(echojwt.Config{
 		SigningKey:    getJWTSecret(),
 		SigningMethod: "HS256",
 		ContextKey:    "user",
@@ -37,9 +63,60 @@ func JWTMiddleware() echo.MiddlewareFunc {
 			return echo.NewHTTPError(401, "invalid or expired jwt")
 		},
 	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withJWT := jwtMiddleware(func(c echo.Context) error {
+			if users != nil {
+				uid, err := UserIDFromContext(c)
+				if err != nil {
+					return echo.NewHTTPError(401, "invalid or expired jwt")
+				}
+				role, err := users(uid, tokenVersionFromContext(c))
+				if err != nil {
+					return echo.NewHTTPError(401, "account is not active or the token was revoked")
+				}
+				if claimed, err := RoleFromContext(c); err != nil || claimed != role {
+					return echo.NewHTTPError(401, "role has changed, log in again")
+				}
+			}
+			return next(c)
+		})
+		return func(c echo.Context) error {
+			rawKey := c.Request().Header.Get(APIKeyHeader)
+			if rawKey == "" || apiKeys == nil {
+				return withJWT(c)
+			}
+			principal, err := apiKeys(rawKey)
+			if err != nil {
+				return echo.NewHTTPError(401, "invalid or expired api key")
+			}
+			c.Set(principalContextKey, principal)
+			return next(c)
+		}
+	}
+}
+
+// Optional applies authMW only to requests that carry credentials, so routes
+// open to anonymous callers still reject a bad token instead of ignoring it.
+func Optional(authMW echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withAuth := authMW(next)
+		return func(c echo.Context) error {
+			h := c.Request().Header
+			if h.Get(echo.HeaderAuthorization) == "" && h.Get(APIKeyHeader) == "" {
+				return next(c)
+			}
+			return withAuth(c)
+		}
+	}
 }
 
+// UserIDFromContext returns the authenticated user's ID. Service principals
+// have no user and resolve to 0.
 func UserIDFromContext(c echo.Context) (uint, error) {
+	if PrincipalFromContext(c) != nil {
+		return 0, nil
+	}
 	user := c.Get("user")
 	if user == nil {
 		return 0, errors.New("no token in context")
@@ -60,6 +137,9 @@ func UserIDFromContext(c echo.Context) (uint, error) {
 }
 
 func RoleFromContext(c echo.Context) (string, error) {
+	if p := PrincipalFromContext(c); p != nil {
+		return p.Role, nil
+	}
 	user := c.Get("user")
 	if user == nil {
 		return "", errors.New("no token in context")
@@ -78,3 +158,36 @@ func RoleFromContext(c echo.Context) (string, error) {
 	}
 	return role, nil
 }
+
+// tokenVersionFromContext returns the token_version claim. Tokens issued
+// before the claim existed count as version 0.
+func tokenVersionFromContext(c echo.Context) int {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return 0
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0
+	}
+	version, _ := claims["token_version"].(float64)
+	return int(version)
+}
+
+// ImpersonatorIDFromContext returns the admin acting on behalf of the user,
+// if the request carries an impersonation token.
+func ImpersonatorIDFromContext(c echo.Context) (uint, bool) {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	id, ok := claims["impersonator_id"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminRoute mimics an admin-only handler, which trusts RoleFromContext.
+func adminRoute(c echo.Context) error {
+	role, err := RoleFromContext(c)
+	if err != nil || role != RoleAdmin {
+		return echo.NewHTTPError(http.StatusForbidden)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func callWithToken(t *testing.T, mw echo.MiddlewareFunc, token string) error {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(echo.HeaderAuthorization, token)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	return mw(adminRoute)(c)
+}
+
+func statusOf(err error) int {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return 0
+}
+
+func TestJWTMiddlewareRefusesTokensOfDemotedAdmins(t *testing.T) {
+	roles := map[uint]string{1: RoleAdmin}
+	users := func(userID uint, _ int) (string, error) {
+		return roles[userID], nil
+	}
+	mw := JWTMiddleware(nil, users)
+
+	token, err := GenerateToken(1, RoleAdmin, 0)
+	assert.NoError(t, err)
+	// Assertion 775: An admin token should reach admin routes while the user is an admin
+	assert.NoError(t, callWithToken(t, mw, token))
+
+	roles[1] = RoleUser
+	// Assertion 776: The same token should be refused once the admin is demoted
+	assert.Equal(t, http.StatusUnauthorized, statusOf(callWithToken(t, mw, token)))
+
+	token, _ = GenerateToken(1, RoleUser, 0)
+	// Assertion 777: A new token should carry the new role and stay out of admin routes
+	assert.Equal(t, http.StatusForbidden, statusOf(callWithToken(t, mw, token)))
+}
+
+func TestJWTMiddlewareRefusesInactiveUsers(t *testing.T) {
+	mw := JWTMiddleware(nil, func(uint, int) (string, error) {
+		return "", errors.New("account deactivated")
+	})
+	token, _ := GenerateToken(1, RoleAdmin, 0)
+
+	// Assertion 778: Tokens of deactivated users should be refused
+	assert.Equal(t, http.StatusUnauthorized, statusOf(callWithToken(t, mw, token)))
+	// Assertion 779: Requests without a valid token should be refused
+	assert.Equal(t, http.StatusUnauthorized, statusOf(callWithToken(t, mw, "not-a-jwt")))
+}
+
+func TestJWTMiddlewarePassesTokenVersion(t *testing.T) {
+	var current, seen int
+	mw := JWTMiddleware(nil, func(_ uint, tokenVersion int) (string, error) {
+		seen = tokenVersion
+		if tokenVersion != current {
+			return "", errors.New("token revoked")
+		}
+		return RoleAdmin, nil
+	})
+	old, _ := GenerateToken(1, RoleAdmin, 0)
+	current = 1
+	fresh, _ := GenerateToken(1, RoleAdmin, 1)
+
+	// Assertion 851: Tokens issued before a password change should be refused
+	assert.Equal(t, http.StatusUnauthorized, statusOf(callWithToken(t, mw, old)))
+	assert.Equal(t, 0, seen)
+	// Assertion 852: The token issued with the new version should be accepted
+	assert.NoError(t, callWithToken(t, mw, fresh))
+	assert.Equal(t, 1, seen)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	RoleUser    = model.RoleUser
+	RoleAdmin   = model.RoleAdmin
+	RoleService = "service"
+
+	APIKeyHeader = "X-API-Key"
+
+	principalContextKey = "principal"
+)
+
+// Principal is a non-human caller authenticated with an API key.
+// It has no user ID; what it may do is limited by its scopes.
+type Principal struct {
+	APIKeyID uint
+	Role     string
+	Scopes   []string
+}
+
+func NewServicePrincipal(apiKeyID uint, scopes []string) *Principal {
+	return &Principal{APIKeyID: apiKeyID, Role: RoleService, Scopes: scopes}
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyValidator resolves the value of the X-API-Key header to a principal.
+type APIKeyValidator func(rawKey string) (*Principal, error)
+
+// PrincipalFromContext returns the API key principal, or nil for JWT-authenticated users.
+func PrincipalFromContext(c echo.Context) *Principal {
+	p, _ := c.Get(principalContextKey).(*Principal)
+	return p
+}
+
+// IsPrivileged reports whether role may use admin endpoints. Service
+// principals are additionally restricted per resource by RequireScope.
+func IsPrivileged(role string) bool {
+	return role == RoleAdmin || role == RoleService
+}
+
+// RequireScope restricts API key principals to resources their key was
+// scoped for: safe methods need "<resource>:read", everything else
+// "<resource>:write". JWT-authenticated users pass through.
+func RequireScope(resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := PrincipalFromContext(c)
+			if p == nil {
+				return next(c)
+			}
+			action := "write"
+			if m := c.Request().Method; m == http.MethodGet || m == http.MethodHead {
+				action = "read"
+			}
+			if !p.HasScope(resource + ":" + action) {
+				return echo.NewHTTPError(http.StatusForbidden, "api key lacks scope "+resource+":"+action)
+			}
+			return next(c)
+		}
+	}
+}
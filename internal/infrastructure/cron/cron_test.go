@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Parse(expr)
+		// Assertion 794: Malformed or out-of-range expressions should be rejected
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	cases := []struct {
+		expr, from, want string
+	}{
+		{"*/15 * * * *", "2026-03-10 10:07", "2026-03-10 10:15"},
+		{"0 3 * * *", "2026-03-10 03:00", "2026-03-11 03:00"},
+		{"@hourly", "2026-03-10 10:59", "2026-03-10 11:00"},
+		{"@monthly", "2026-03-10 10:00", "2026-04-01 00:00"},
+		{"0 0 * * 7", "2026-03-10 10:00", "2026-03-15 00:00"},
+		{"30 9 1-5/2 * *", "2026-03-02 00:00", "2026-03-03 09:30"},
+		{"0 12 * * 1,3", "2026-03-10 13:00", "2026-03-11 12:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.expr)
+		// Assertion 795: Next should return the first matching minute after the given time
+		assert.NoError(t, err, c.expr)
+		assert.Equal(t, at(c.want), s.Next(at(c.from)), c.expr)
+	}
+}
+
+func TestNextDayFields(t *testing.T) {
+	// 2026-03-10 is a Tuesday.
+	s, err := Parse("0 0 13 * 5")
+	assert.NoError(t, err)
+	// Assertion 796: When both day fields are restricted either one should match
+	assert.Equal(t, at("2026-03-13 00:00"), s.Next(at("2026-03-10 00:00")))
+	assert.Equal(t, at("2026-03-20 00:00"), s.Next(at("2026-03-13 00:00")))
+
+	s, err = Parse("0 0 */2 * 5")
+	assert.NoError(t, err)
+	// Assertion 797: A stepped "*" day of month should not widen the match to every Friday
+	assert.Equal(t, at("2026-03-13 00:00"), s.Next(at("2026-03-10 00:00")))
+	assert.Equal(t, at("2026-03-27 00:00"), s.Next(at("2026-03-13 00:00")))
+
+	s, err = Parse("0 0 1 * */2")
+	assert.NoError(t, err)
+	// Assertion 798: A stepped "*" day of week should only narrow the day of month
+	assert.Equal(t, at("2026-08-01 00:00"), s.Next(at("2026-03-10 00:00")))
+}
+
+func TestNextNeverMatching(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	assert.NoError(t, err)
+	// Assertion 799: An expression that never matches should give the zero time
+	assert.True(t, s.Next(at("2026-03-10 00:00")).IsZero())
+}
@@ -0,0 +1,187 @@
+// Package imaging decodes uploaded images and scales them down, using only
+// the standard library.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrTooManyPixels     = errors.New("image has too many pixels")
+)
+
+// jpegQuality is used for the JPEG images Encode writes.
+const jpegQuality = 85
+
+// Format is an image encoding Decode reads.
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	GIF  Format = "gif"
+)
+
+// Sniff tells the format of data from its first bytes, the way browsers do.
+func Sniff(data []byte) (Format, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/jpeg":
+		return JPEG, nil
+	case "image/png":
+		return PNG, nil
+	case "image/gif":
+		return GIF, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+}
+
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+func (f Format) Extension() string {
+	if f == JPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// Decode reads an image in the given format, checking its dimensions first
+// so that a small file cannot expand into more than maxPixels pixels. Only
+// the first frame of an animated GIF is read.
+func Decode(data []byte, format Format, maxPixels int) (image.Image, error) {
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch format {
+	case JPEG:
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case PNG:
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case GIF:
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("image is %dx%d", config.Width, config.Height)
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, config.Width, config.Height)
+	}
+	return decode(bytes.NewReader(data))
+}
+
+// Encode writes img as JPEG or, for the other formats, as PNG, which keeps
+// transparency and does not limit the palette like GIF.
+func Encode(w io.Writer, img image.Image, format Format) (Format, error) {
+	if format == JPEG {
+		return JPEG, jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return PNG, png.Encode(w, img)
+}
+
+// Fit scales img down to fit in a size×size square, keeping its aspect
+// ratio. Images that already fit are returned as they are; nothing is
+// scaled up.
+func Fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	dw, dh := size, size
+	if w > h {
+		dh = max(1, int(math.Round(float64(h)*float64(size)/float64(w))))
+	} else {
+		dw = max(1, int(math.Round(float64(w)*float64(size)/float64(h))))
+	}
+	return resize(toRGBA(img), dw, dh)
+}
+
+// toRGBA converts img to premultiplied RGBA with its origin at 0,0, so that
+// averaging pixels also averages transparency correctly.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// contribution is the share a source pixel has in a destination pixel.
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// weights maps src pixels onto n < src pixels by area: each destination
+// pixel averages the source pixels it covers, those on its edges in part.
+func weights(src, n int) [][]contribution {
+	scale := float64(src) / float64(n)
+	out := make([][]contribution, n)
+	for i := range out {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < src && float64(j) < end; j++ {
+			if w := math.Min(end, float64(j+1)) - math.Max(start, float64(j)); w > 0 {
+				out[i] = append(out[i], contribution{index: j, weight: w / scale})
+			}
+		}
+	}
+	return out
+}
+
+// resize scales src down to dw×dh, a row at a time, so that memory use does
+// not grow with the size of the source.
+func resize(src *image.RGBA, dw, dh int) *image.RGBA {
+	xs := weights(src.Rect.Dx(), dw)
+	ys := weights(src.Rect.Dy(), dh)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	row := make([]float64, dw*4)
+	sum := make([]float64, dw*4)
+	for y, rows := range ys {
+		clear(sum)
+		for _, r := range rows {
+			scaleRow(row, src.Pix[r.index*src.Stride:], xs)
+			for i, v := range row {
+				sum[i] += v * r.weight
+			}
+		}
+		out := dst.Pix[y*dst.Stride:]
+		for i, v := range sum {
+			out[i] = uint8(min(255, v+0.5))
+		}
+	}
+	return dst
+}
+
+func scaleRow(dst []float64, src []uint8, xs [][]contribution) {
+	for x, cols := range xs {
+		var r, g, b, a float64
+		for _, c := range cols {
+			p := src[c.index*4 : c.index*4+4]
+			r += float64(p[0]) * c.weight
+			g += float64(p[1]) * c.weight
+			b += float64(p[2]) * c.weight
+			a += float64(p[3]) * c.weight
+		}
+		dst[x*4], dst[x*4+1], dst[x*4+2], dst[x*4+3] = r, g, b, a
+	}
+}
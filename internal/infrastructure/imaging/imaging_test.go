@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encoded(t *testing.T, format Format, img image.Image) []byte {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&buf, img, nil)
+	case PNG:
+		err = png.Encode(&buf, img)
+	case GIF:
+		err = gif.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	img := solid(4, 4, color.White)
+	for _, format := range []Format{JPEG, PNG, GIF} {
+		sniffed, err := Sniff(encoded(t, format, img))
+		// Assertion 827: Sniff should recognize JPEG, PNG and GIF by their content
+		assert.NoError(t, err)
+		assert.Equal(t, format, sniffed)
+	}
+
+	_, err := Sniff([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	// Assertion 828: Other content should be refused as unsupported
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// Assertion 829: Formats should name their content type and file extension
+	assert.Equal(t, "image/jpeg", JPEG.ContentType())
+	assert.Equal(t, ".jpg", JPEG.Extension())
+	assert.Equal(t, ".png", PNG.Extension())
+}
+
+func TestDecode(t *testing.T) {
+	data := encoded(t, PNG, solid(40, 30, color.White))
+
+	img, err := Decode(data, PNG, 40*30)
+	// Assertion 830: Decode should read images within the pixel limit
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 40, 30), img.Bounds())
+
+	_, err = Decode(data, PNG, 40*30-1)
+	// Assertion 831: Decode should refuse images over the pixel limit before decoding them
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+
+	_, err = Decode(data, JPEG, 40*30)
+	// Assertion 832: Decode should fail when the data is not in the given format
+	assert.Error(t, err)
+	_, err = Decode(data, Format("webp"), 40*30)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestFit(t *testing.T) {
+	small := solid(50, 20, color.White)
+	// Assertion 833: Images that fit should be returned unchanged, never scaled up
+	assert.Same(t, small, Fit(small, 100).(*image.RGBA))
+
+	// Assertion 834: Larger images should be scaled to fit, keeping the aspect ratio
+	assert.Equal(t, image.Rect(0, 0, 100, 50), Fit(solid(400, 200, color.White), 100).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 25, 100), Fit(solid(60, 240, color.White), 100).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 100, 1), Fit(solid(1000, 2, color.White), 100).Bounds())
+
+	// Assertion 835: Scaling should average the covered pixels
+	stripes := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 0 {
+				stripes.Set(x, y, color.RGBA{255, 255, 255, 255})
+			} else {
+				stripes.Set(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	scaled := Fit(stripes, 2).(*image.RGBA)
+	assert.Equal(t, color.RGBA{128, 128, 128, 255}, scaled.RGBAAt(0, 0))
+
+	// Assertion 836: Transparency should be averaged too, without dark fringes
+	half := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	half.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	half.SetNRGBA(0, 1, color.NRGBA{255, 0, 0, 255})
+	scaled = Fit(half, 1).(*image.RGBA)
+	assert.Equal(t, color.RGBA{128, 0, 0, 128}, scaled.RGBAAt(0, 0))
+}
+
+func TestEncode(t *testing.T) {
+	img := solid(8, 8, color.White)
+	for format, want := range map[Format]Format{JPEG: JPEG, PNG: PNG, GIF: PNG} {
+		var buf bytes.Buffer
+		got, err := Encode(&buf, img, format)
+		// Assertion 837: Encode should keep JPEG and write the other formats as PNG
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+		sniffed, _ := Sniff(buf.Bytes())
+		assert.Equal(t, want, sniffed)
+	}
+}
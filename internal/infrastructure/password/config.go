@@ -0,0 +1,27 @@
+package password
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const defaultBcryptCost = 12
+
+// HasherFromEnv builds the hasher selected by PASSWORD_HASH_ALGORITHM
+// ("argon2id" by default, or "bcrypt" with BCRYPT_COST). The other scheme is
+// kept for verification so existing hashes are upgraded on the next login.
+func HasherFromEnv() Hasher {
+	cost := defaultBcryptCost
+	if v, err := strconv.Atoi(os.Getenv("BCRYPT_COST")); err == nil && v >= bcrypt.MinCost && v <= bcrypt.MaxCost {
+		cost = v
+	}
+	bcryptScheme := NewBcryptScheme(cost)
+	argonScheme := NewArgon2idScheme()
+
+	if os.Getenv("PASSWORD_HASH_ALGORITHM") == "bcrypt" {
+		return NewHasher(bcryptScheme, argonScheme)
+	}
+	return NewHasher(argonScheme, bcryptScheme)
+}
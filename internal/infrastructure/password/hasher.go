@@ -0,0 +1,54 @@
+package password
+
+import "errors"
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Scheme is a single password hashing algorithm.
+type Scheme interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	// Recognizes reports whether encoded was produced by this scheme.
+	Recognizes(encoded string) bool
+	// NeedsRehash reports whether encoded uses weaker parameters than the scheme is configured with.
+	NeedsRehash(encoded string) bool
+}
+
+// Hasher hashes new passwords and verifies stored hashes of any supported scheme.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify checks password against encoded and reports whether the hash
+	// should be replaced because it uses an outdated algorithm or cost.
+	Verify(encoded, password string) (ok bool, needsRehash bool, err error)
+}
+
+type hasher struct {
+	preferred Scheme
+	schemes   []Scheme
+}
+
+// NewHasher hashes with preferred and still accepts hashes produced by legacy schemes.
+func NewHasher(preferred Scheme, legacy ...Scheme) Hasher {
+	return &hasher{
+		preferred: preferred,
+		schemes:   append([]Scheme{preferred}, legacy...),
+	}
+}
+
+func (h *hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *hasher) Verify(encoded, password string) (bool, bool, error) {
+	for _, s := range h.schemes {
+		if !s.Recognizes(encoded) {
+			continue
+		}
+		ok, err := s.Verify(encoded, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, s != h.preferred || s.NeedsRehash(encoded), nil
+	}
+	return false, false, ErrUnknownHashFormat
+}
@@ -0,0 +1,124 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = loadCommonPasswords(commonPasswordList)
+
+func loadCommonPasswords(list string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, line := range strings.Split(list, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	return set
+}
+
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectCommon  bool
+}
+
+// PolicyError lists every rule a password violates.
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:    8,
+		RequireUpper: true,
+		RequireLower: true,
+		RequireDigit: true,
+		RejectCommon: true,
+	}
+}
+
+// PolicyFromEnv starts from DefaultPolicy and applies PASSWORD_MIN_LENGTH,
+// PASSWORD_REQUIRE_UPPER, PASSWORD_REQUIRE_LOWER, PASSWORD_REQUIRE_DIGIT,
+// PASSWORD_REQUIRE_SYMBOL and PASSWORD_REJECT_COMMON when set.
+func PolicyFromEnv() Policy {
+	p := DefaultPolicy()
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+		p.MinLength = v
+	}
+	envBool("PASSWORD_REQUIRE_UPPER", &p.RequireUpper)
+	envBool("PASSWORD_REQUIRE_LOWER", &p.RequireLower)
+	envBool("PASSWORD_REQUIRE_DIGIT", &p.RequireDigit)
+	envBool("PASSWORD_REQUIRE_SYMBOL", &p.RequireSymbol)
+	envBool("PASSWORD_REJECT_COMMON", &p.RejectCommon)
+	return p
+}
+
+func envBool(name string, target *bool) {
+	if v, err := strconv.ParseBool(os.Getenv(name)); err == nil {
+		*target = v
+	}
+}
+
+func (p Policy) Validate(password string) error {
+	var violations []string
+
+	minLength := p.MinLength
+	if minLength < 1 {
+		minLength = 1
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", minLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+	if p.RejectCommon {
+		if _, ok := commonPasswords[strings.ToLower(password)]; ok {
+			violations = append(violations, "is too common")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected a PolicyError, got %v", err)
+	}
+	return policyErr.Violations
+}
+
+func TestPolicyValidate(t *testing.T) {
+	policy := DefaultPolicy()
+
+	// Assertion 765: The default policy should accept a long mixed-class password
+	assert.NoError(t, policy.Validate("Str0ngEnough!"))
+
+	// Assertion 766: Each broken rule should be listed
+	assert.Equal(t, []string{
+		"must be at least 8 characters long",
+		"must contain an uppercase letter",
+		"must contain a digit",
+	}, violations(t, policy.Validate("short")))
+
+	// Assertion 767: Length should be counted in characters, not bytes
+	assert.NoError(t, Policy{MinLength: 4}.Validate("żółć"))
+	assert.Error(t, Policy{MinLength: 5}.Validate("żółć"))
+
+	// Assertion 768: Common passwords should be rejected whatever their case
+	assert.Contains(t, violations(t, policy.Validate("Password123")), "is too common")
+
+	symbols := Policy{MinLength: 1, RequireSymbol: true}
+	// Assertion 769: RequireSymbol should accept punctuation and reject letters only
+	assert.NoError(t, symbols.Validate("a!"))
+	assert.Equal(t, []string{"must contain a symbol"}, violations(t, symbols.Validate("abc")))
+
+	// Assertion 770: The minimum length should never drop below one character
+	assert.Equal(t, []string{"must be at least 1 characters long"}, violations(t, Policy{}.Validate("")))
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REQUIRE_SYMBOL", "true")
+	t.Setenv("PASSWORD_REJECT_COMMON", "false")
+	t.Setenv("PASSWORD_REQUIRE_UPPER", "not-a-bool")
+
+	policy := PolicyFromEnv()
+	// Assertion 771: Set variables should override the default policy
+	assert.Equal(t, 12, policy.MinLength)
+	assert.True(t, policy.RequireSymbol)
+	assert.False(t, policy.RejectCommon)
+	// Assertion 772: Invalid values should keep the default
+	assert.True(t, policy.RequireUpper)
+}
@@ -0,0 +1,71 @@
+package pdf
+
+// Glyph widths of the printable ASCII characters, from space to '~', in
+// thousandths of the font size, as published in the Adobe font metrics of
+// the standard fonts.
+var asciiWidths = [][95]int{
+	Regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	Bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// glyphWidth returns the width of a Windows-1252 byte. Characters outside
+// ASCII are taken to be as wide as a digit, which is close enough for the
+// accented letters they mostly are.
+func glyphWidth(b byte, font Font) int {
+	if b >= ' ' && b <= '~' {
+		return asciiWidths[font][b-' ']
+	}
+	return 556
+}
+
+// Windows-1252 codes of the characters it adds to Latin-1.
+var cp1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, 'Š': 0x8A, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'™': 0x99, 'š': 0x9A, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// ASCII stand-ins for Central European letters missing from Windows-1252.
+var transliterations = map[rune]byte{
+	'Ą': 'A', 'ą': 'a', 'Ć': 'C', 'ć': 'c', 'Č': 'C', 'č': 'c', 'Ď': 'D', 'ď': 'd',
+	'Ę': 'E', 'ę': 'e', 'Ě': 'E', 'ě': 'e', 'Ğ': 'G', 'ğ': 'g', 'İ': 'I', 'ı': 'i',
+	'Ł': 'L', 'ł': 'l', 'Ľ': 'L', 'ľ': 'l', 'Ĺ': 'L', 'ĺ': 'l', 'Ń': 'N', 'ń': 'n',
+	'Ň': 'N', 'ň': 'n', 'Ő': 'O', 'ő': 'o', 'Ř': 'R', 'ř': 'r', 'Ŕ': 'R', 'ŕ': 'r',
+	'Ś': 'S', 'ś': 's', 'Ş': 'S', 'ş': 's', 'Ș': 'S', 'ș': 's', 'Ť': 'T', 'ť': 't',
+	'Ț': 'T', 'ț': 't', 'Ů': 'U', 'ů': 'u', 'Ű': 'U', 'ű': 'u', 'Ź': 'Z', 'ź': 'z',
+	'Ż': 'Z', 'ż': 'z', 'Ă': 'A', 'ă': 'a', 'Ā': 'A', 'ā': 'a', 'Ē': 'E', 'ē': 'e',
+	'Ī': 'I', 'ī': 'i', 'Ū': 'U', 'ū': 'u', 'Ģ': 'G', 'ģ': 'g', 'Ķ': 'K', 'ķ': 'k',
+	'Ļ': 'L', 'ļ': 'l', 'Ņ': 'N', 'ņ': 'n', 'Ė': 'E', 'ė': 'e', 'Į': 'I', 'į': 'i',
+}
+
+// encode converts s to Windows-1252, the encoding of the standard fonts.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		case cp1252[r] != 0:
+			out = append(out, cp1252[r])
+		case transliterations[r] != 0:
+			out = append(out, transliterations[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
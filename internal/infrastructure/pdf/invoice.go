@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+const (
+	margin     = 40.0
+	rowHeight  = 14.0
+	bodySize   = 9.0
+	bottomEdge = 60.0
+)
+
+// Right edges of the numeric columns of the line table.
+var invoiceColumns = []struct {
+	title string
+	right float64
+}{
+	{"Qty", 300}, {"Unit net", 360}, {"Net", 420}, {"VAT %", 460}, {"VAT", 510}, {"Gross", PageWidth - margin},
+}
+
+// Invoice renders an invoice or credit note.
+func Invoice(invoice *model.Invoice) []byte {
+	d := New()
+	y := PageHeight - margin - 18
+
+	title := "Invoice "
+	if invoice.Type == model.InvoiceTypeCreditNote {
+		title = "Credit note "
+	}
+	d.Text(margin, y, Bold, 18, title+invoice.Number)
+	y -= 22
+	d.Text(margin, y, Regular, bodySize, "Issue date: "+invoice.IssuedAt.Format("2006-01-02"))
+	d.TextRight(PageWidth-margin, y, Regular, bodySize, "Order #"+strconv.FormatUint(uint64(invoice.OrderID), 10))
+	if invoice.CorrectedNumber != "" {
+		y -= rowHeight
+		d.Text(margin, y, Regular, bodySize, "Corrects invoice: "+invoice.CorrectedNumber)
+	}
+	if invoice.Reason != "" {
+		y -= rowHeight
+		d.Text(margin, y, Regular, bodySize, Truncate("Reason: "+invoice.Reason, Regular, bodySize, PageWidth-2*margin))
+	}
+
+	y -= 2 * rowHeight
+	seller := []string{invoice.SellerName, invoice.SellerAddress, taxID(invoice.SellerTaxID)}
+	buyer := []string{invoice.BuyerCompany, invoice.BuyerName, invoice.BuyerAddress, taxID(invoice.BuyerTaxID), invoice.BuyerEmail}
+	sellerEnd := party(d, margin, y, "Seller", seller)
+	buyerEnd := party(d, PageWidth/2, y, "Buyer", buyer)
+	y = min(sellerEnd, buyerEnd) - rowHeight
+
+	y = lineTableHeader(d, y)
+	for i, line := range invoice.Lines {
+		if y < bottomEdge {
+			d.AddPage()
+			y = lineTableHeader(d, PageHeight-margin)
+		}
+		d.Text(margin, y, Regular, bodySize, strconv.Itoa(i+1))
+		d.Text(margin+20, y, Regular, bodySize, Truncate(line.Name, Regular, bodySize, 190))
+		values := []string{strconv.Itoa(line.Quantity), money(line.UnitNet), money(line.Net), percent(line.TaxRate), money(line.Tax), money(line.Gross)}
+		for c, value := range values {
+			d.TextRight(invoiceColumns[c].right, y, Regular, bodySize, value)
+		}
+		y -= rowHeight
+	}
+
+	// The VAT summary and totals stay together on one page.
+	if y-rowHeight*float64(len(invoice.TaxLines)+6) < bottomEdge {
+		d.AddPage()
+		y = PageHeight - margin
+	}
+	y -= rowHeight
+	d.Text(300, y, Bold, bodySize, "VAT summary")
+	for _, c := range invoiceColumns[2:] {
+		d.TextRight(c.right, y, Bold, bodySize, c.title)
+	}
+	y -= 4
+	d.Line(300, y, PageWidth-margin, y)
+	y -= rowHeight - 4
+	for _, line := range invoice.TaxLines {
+		values := []string{money(line.Net), percent(line.Rate), money(line.Tax), money(line.Gross)}
+		for c, value := range values {
+			d.TextRight(invoiceColumns[c+2].right, y, Regular, bodySize, value)
+		}
+		y -= rowHeight
+	}
+
+	y -= rowHeight
+	totals := []struct {
+		label string
+		value float64
+	}{{"Total net", invoice.NetTotal}, {"Total VAT", invoice.TaxTotal}, {"Total", invoice.Total}}
+	for i, total := range totals {
+		font, size := Regular, bodySize
+		if i == len(totals)-1 {
+			font, size = Bold, 12.0
+		}
+		d.Text(300, y, font, size, total.label)
+		d.TextRight(PageWidth-margin, y, font, size, strings.TrimSpace(money(total.value)+" "+invoice.Currency))
+		y -= rowHeight + 2
+	}
+	return d.Bytes()
+}
+
+// party prints a titled block of non-empty lines and returns where it ends.
+func party(d *Document, x, y float64, title string, lines []string) float64 {
+	d.Text(x, y, Bold, bodySize+1, title)
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		y -= rowHeight
+		d.Text(x, y, Regular, bodySize, Truncate(line, Regular, bodySize, PageWidth/2-margin-10))
+	}
+	return y
+}
+
+func lineTableHeader(d *Document, y float64) float64 {
+	d.Text(margin, y, Bold, bodySize, "#")
+	d.Text(margin+20, y, Bold, bodySize, "Description")
+	for _, c := range invoiceColumns {
+		d.TextRight(c.right, y, Bold, bodySize, c.title)
+	}
+	y -= 4
+	d.Line(margin, y, PageWidth-margin, y)
+	return y - rowHeight + 4
+}
+
+func taxID(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "Tax ID: " + id
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,121 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testInvoice() *model.Invoice {
+	return &model.Invoice{
+		Number:        "INV/2026/000042",
+		Type:          model.InvoiceTypeInvoice,
+		IssuedAt:      time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		OrderID:       17,
+		SellerName:    "E-Commerce Store",
+		SellerAddress: "Main 1, 00-950 Warsaw",
+		SellerTaxID:   "PL5260001246",
+		BuyerName:     "Łucja Nowak",
+		BuyerEmail:    "lucja@example.com",
+		BuyerAddress:  "Długa 5, 30-001 Kraków",
+		Lines: []model.InvoiceLine{
+			{Name: "Lamp", Quantity: 2, UnitNet: 100, TaxRate: 23, Net: 200, Tax: 46, Gross: 246},
+			{Name: "Shipping", Quantity: 1, UnitNet: 10, TaxRate: 23, Net: 10, Tax: 2.30, Gross: 12.30},
+		},
+		TaxLines: []model.InvoiceTaxLine{
+			{TaxAmounts: model.TaxAmounts{Rate: 23, Net: 210, Tax: 48.30, Gross: 258.30}},
+		},
+		NetTotal: 210,
+		TaxTotal: 48.30,
+		Total:    258.30,
+		Currency: "PLN",
+	}
+}
+
+// shown returns the strings drawn by the Tj operators of a rendered document.
+func shown(out []byte) []string {
+	var texts []string
+	for _, line := range strings.Split(string(out), "\n") {
+		start, end := strings.Index(line, "("), strings.LastIndex(line, ") Tj")
+		if start >= 0 && end > start {
+			texts = append(texts, line[start+1:end])
+		}
+	}
+	return texts
+}
+
+func TestInvoice(t *testing.T) {
+	out := Invoice(testInvoice())
+	texts := shown(out)
+
+	// Assertion 814: The invoice should show its number, issue date and order
+	assert.Contains(t, texts, "Invoice INV/2026/000042")
+	assert.Contains(t, texts, "Issue date: 2026-03-10")
+	assert.Contains(t, texts, "Order #17")
+
+	// Assertion 815: Seller and buyer should be printed, skipping empty lines and transliterating names
+	assert.Contains(t, texts, "Tax ID: PL5260001246")
+	assert.Contains(t, texts, "Lucja Nowak")
+	assert.Contains(t, texts, "Dluga 5, 30-001 Krak\xf3w")
+	for _, text := range texts {
+		assert.NotEqual(t, "Tax ID: ", text)
+	}
+
+	// Assertion 816: Each line should show quantity, prices, rate and amounts
+	for _, value := range []string{"Lamp", "2", "100.00", "200.00", "23%", "46.00", "246.00"} {
+		assert.Contains(t, texts, value)
+	}
+
+	// Assertion 817: The VAT summary and totals should be printed with the currency
+	assert.Contains(t, texts, "VAT summary")
+	assert.Contains(t, texts, "258.30")
+	assert.Contains(t, texts, "48.30")
+	assert.Contains(t, texts, "258.30 PLN")
+	assert.Contains(t, string(out), "/Count 1")
+}
+
+func TestInvoiceCreditNote(t *testing.T) {
+	invoice := testInvoice()
+	invoice.Type = model.InvoiceTypeCreditNote
+	invoice.Number = "CN/2026/000003"
+	invoice.CorrectedNumber = "INV/2026/000042"
+	invoice.Reason = "Damaged (returned)"
+	invoice.Lines = invoice.Lines[:1]
+	invoice.Lines[0].Quantity, invoice.Lines[0].Net, invoice.Lines[0].Tax, invoice.Lines[0].Gross = -1, -100, -23, -123
+	texts := shown(Invoice(invoice))
+
+	// Assertion 818: A credit note should name itself and the invoice it corrects
+	assert.Contains(t, texts, "Credit note CN/2026/000003")
+	assert.Contains(t, texts, "Corrects invoice: INV/2026/000042")
+	assert.Contains(t, texts, `Reason: Damaged \(returned\)`)
+
+	// Assertion 819: Negative amounts should keep their sign
+	assert.Contains(t, texts, "-1")
+	assert.Contains(t, texts, "-123.00")
+}
+
+func TestInvoiceBreaksLongTablesAcrossPages(t *testing.T) {
+	invoice := testInvoice()
+	invoice.Lines = nil
+	for i := 0; i < 80; i++ {
+		invoice.Lines = append(invoice.Lines, model.InvoiceLine{Name: fmt.Sprintf("Item %d", i+1), Quantity: 1, UnitNet: 1, Net: 1, Gross: 1})
+	}
+	out := Invoice(invoice)
+	texts := shown(out)
+
+	// Assertion 820: Long invoices should continue on further pages with the table header repeated
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, texts, "Item 80")
+	headers := 0
+	for _, text := range texts {
+		if text == "Description" {
+			headers++
+		}
+	}
+	assert.Equal(t, 2, headers)
+}
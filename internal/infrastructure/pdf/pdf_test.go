@@ -0,0 +1,61 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	// Assertion 807: Latin-1 and Windows-1252 characters should keep their own codes
+	assert.Equal(t, []byte{'C', 'a', 'f', 0xE9, ' ', 0x80}, encode("Café €"))
+
+	// Assertion 808: Central European letters should fall back to ASCII and the rest to "?"
+	assert.Equal(t, []byte{'Z', 0xF3, 'l', 'c', ' ', 'g', 'e', 's', 'l', 'a', ' ', '?'}, encode("Żółć gęśla 日"))
+}
+
+func TestTextWidthAndTruncate(t *testing.T) {
+	// Assertion 809: Widths should follow the font metrics and scale with the size
+	assert.InDelta(t, 5.56, TextWidth("0", Regular, 10), 0.001)
+	assert.InDelta(t, 11.12, TextWidth("00", Regular, 10), 0.001)
+	assert.Greater(t, TextWidth("Total", Bold, 10), TextWidth("Total", Regular, 10))
+
+	// Assertion 810: Truncate should leave short text alone and shorten long text to fit
+	assert.Equal(t, "Lamp", Truncate("Lamp", Regular, 9, 100))
+	short := Truncate("A very long product name that does not fit", Regular, 9, 60)
+	assert.Regexp(t, `^A very.*\.\.\.$`, short)
+	assert.LessOrEqual(t, TextWidth(short, Regular, 9), 60.0)
+}
+
+func TestDocumentBytes(t *testing.T) {
+	d := New()
+	d.Text(40, 800, Bold, 12, "Invoice (copy) \\ 1")
+	d.AddPage()
+	d.Line(40, 40, 100, 40)
+	out := d.Bytes()
+
+	// Assertion 811: The document should be a PDF with one page object per page
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Len(t, regexp.MustCompile(`/Type /Page /Parent`).FindAll(out, -1), 2)
+
+	// Assertion 812: Parentheses and backslashes in text should be escaped
+	assert.Contains(t, string(out), `(Invoice \(copy\) \\ 1) Tj`)
+
+	// Assertion 813: startxref and every xref entry should point at their objects
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if assert.NotNil(t, m) {
+		xref, _ := strconv.Atoi(string(m[1]))
+		assert.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n")))
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1)
+	assert.Len(t, entries, 8)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(strconv.Itoa(i+1)+" 0 obj\n")), "object %d", i+1)
+	}
+}
@@ -38,3 +38,9 @@ func (r *addressRepo) Update(address *model.Address) error {
 func (r *addressRepo) Delete(id uint) error {
 	return r.db.Delete(&model.Address{}, id).Error
 }
+
+func (r *addressRepo) IsUsedByOrders(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Order{}).Where("shipping_address_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventRepository(db *gorm.DB) repository.AuditEventRepository {
+	return &auditEventRepository{db: db}
+}
+
+func (r *auditEventRepository) FindWithFilters(filters map[string]string) ([]model.AuditEvent, error) {
+	db := r.db.Model(&model.AuditEvent{}).Order("created_at DESC, id DESC")
+
+	if v, ok := filters["actor_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeAuditByActor(uint(id)))
+		}
+	}
+	if v, ok := filters["api_key_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeAuditByAPIKey(uint(id)))
+		}
+	}
+	if v, ok := filters["action"]; ok {
+		db = db.Scopes(scope.ScopeAuditByAction(v))
+	}
+	if v, ok := filters["entity_type"]; ok {
+		db = db.Scopes(scope.ScopeAuditByEntityType(v))
+	}
+	if v, ok := filters["entity_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeAuditByEntityID(uint(id)))
+		}
+	}
+	if v, ok := filters["request_id"]; ok {
+		db = db.Scopes(scope.ScopeAuditByRequestID(v))
+	}
+	if v, ok := filters["created_after"]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			db = db.Scopes(scope.ScopeAuditCreatedAfter(t))
+		}
+	}
+	if v, ok := filters["created_before"]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			db = db.Scopes(scope.ScopeAuditCreatedBefore(t))
+		}
+	}
+
+	limit := defaultAuditLimit
+	if v, ok := filters["limit"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+	db = db.Scopes(scope.ScopeAuditLimit(limit))
+
+	var events []model.AuditEvent
+	if err := db.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditEventRepository) Create(event *model.AuditEvent) error {
+	return r.db.Create(event).Error
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type cartReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewCartReminderRepository(db *gorm.DB) repository.CartReminderRepository {
+	return &cartReminderRepository{db: db}
+}
+
+func (r *cartReminderRepository) FindByID(id uint) (*model.CartReminder, error) {
+	return r.first(r.db.Where("id = ?", id))
+}
+
+func (r *cartReminderRepository) FindLatestByCart(cartID uint) (*model.CartReminder, error) {
+	return r.first(r.db.Scopes(scope.ScopeCartReminderByCart(cartID)).Order("id DESC"))
+}
+
+func (r *cartReminderRepository) FindAttributable(userID uint, from, to time.Time) ([]model.CartReminder, error) {
+	var reminders []model.CartReminder
+	err := r.db.
+		Scopes(scope.ScopeCartReminderByUser(userID), scope.ScopeCartReminderSentBetween(from, to), scope.ScopeCartReminderNotRecovered()).
+		Order("sent_at DESC").Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *cartReminderRepository) FindSentBetween(from, to time.Time) ([]model.CartReminder, error) {
+	var reminders []model.CartReminder
+	err := r.db.Scopes(scope.ScopeCartReminderSentBetween(from, to)).Order("id DESC").Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *cartReminderRepository) first(db *gorm.DB) (*model.CartReminder, error) {
+	var reminder model.CartReminder
+	if err := db.First(&reminder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (r *cartReminderRepository) Create(reminder *model.CartReminder) error {
+	return r.db.Create(reminder).Error
+}
+
+func (r *cartReminderRepository) Update(reminder *model.CartReminder) error {
+	result := r.db.Save(reminder)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -45,6 +45,19 @@ func (r *cartRepository) FindByCartID(cartID uint) (*model.Cart, error) {
 	return &cart, nil
 }
 
+func (r *cartRepository) FindByGuestTokenHash(hash string) (*model.Cart, error) {
+	var cart model.Cart
+	if err := r.db.Preload("Items.Product").
+		Where("guest_token_hash = ?", hash).
+		First(&cart).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cart, nil
+}
+
 func (r *cartRepository) FindWithFilters(filters map[string]string) ([]model.Cart, error) {
 	db := r.db.Model(&model.Cart{})
 	db = db.Scopes(scope.ScopeCartWithItems())
@@ -53,6 +66,7 @@ func (r *cartRepository) FindWithFilters(filters map[string]string) ([]model.Car
 	db = r.applyTotalRangeFilter(db, filters)
 	db = r.applyCreatedAfterFilter(db, filters)
 	db = r.applyCreatedBeforeFilter(db, filters)
+	db = r.applyAbandonedFilter(db, filters)
 
 	var carts []model.Cart
 	if err := db.Find(&carts).Error; err != nil {
@@ -103,6 +117,15 @@ func (r *cartRepository) applyCreatedBeforeFilter(db *gorm.DB, filters map[strin
 	return db
 }
 
+func (r *cartRepository) applyAbandonedFilter(db *gorm.DB, filters map[string]string) *gorm.DB {
+	if v, ok := filters["abandoned"]; ok {
+		if abandoned, err := strconv.ParseBool(v); err == nil {
+			db = db.Scopes(scope.ScopeCartAbandoned(abandoned))
+		}
+	}
+	return db
+}
+
 func (r *cartRepository) Create(cart *model.Cart) error {
 	return r.db.Create(cart).Error
 }
@@ -128,3 +151,69 @@ func (r *cartRepository) Delete(cartID uint) error {
 	}
 	return nil
 }
+
+// DeleteStale hard-deletes the carts, since user_id is unique regardless of
+// soft deletion and the user must be able to get a fresh cart later. Their
+// items, including soft deleted ones, go with them.
+func (r *cartRepository) DeleteStale(before time.Time) (int64, error) {
+	var removed int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&model.Cart{}).
+			Scopes(scope.ScopeCartUpdatedBefore(before), scope.ScopeCartDisposable()).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		var err error
+		removed, err = hardDeleteCarts(tx, ids)
+		return err
+	})
+	return removed, err
+}
+
+// unusedGuestCartSlack is how soon after creation a guest cart may last
+// change and still count as unused. A cart created by the first
+// POST /cart/add gets its item within the same request.
+const unusedGuestCartSlack = time.Minute
+
+func (r *cartRepository) DeleteUnusedGuests(createdBefore time.Time) (int64, error) {
+	var removed int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var carts []model.Cart
+		if err := tx.Unscoped().Select("id", "created_at", "updated_at").
+			Scopes(scope.ScopeCartGuest(), scope.ScopeCartCreatedBefore(createdBefore)).
+			Find(&carts).Error; err != nil {
+			return err
+		}
+		var ids []uint
+		for _, cart := range carts {
+			if cart.UpdatedAt.Sub(cart.CreatedAt) < unusedGuestCartSlack {
+				ids = append(ids, cart.ID)
+			}
+		}
+		var err error
+		removed, err = hardDeleteCarts(tx, ids)
+		return err
+	})
+	return removed, err
+}
+
+func hardDeleteCarts(tx *gorm.DB, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := tx.Unscoped().Where("cart_id IN ?", ids).Delete(&model.CartItem{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Unscoped().Delete(&model.Cart{}, ids)
+	return result.RowsAffected, result.Error
+}
+
+// MarkAbandoned uses UpdateColumn so flagging does not itself count as
+// activity and bump updated_at.
+func (r *cartRepository) MarkAbandoned(idleSince, now time.Time) (int64, error) {
+	result := r.db.Model(&model.Cart{}).
+		Scopes(scope.ScopeCartAbandoned(false), scope.ScopeCartUpdatedBefore(idleSince), scope.ScopeCartNotEmpty()).
+		UpdateColumn("abandoned_at", now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type catalogRepository struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepository(db *gorm.DB) repository.CatalogRepository {
+	return &catalogRepository{db: db}
+}
+
+// catalogStamps select, for each table the catalog is made of, values that
+// change with any write: the row count, the latest change and, for soft
+// deletes, the latest deletion. Price history only grows.
+var catalogStamps = []struct {
+	model any
+	stamp string
+}{
+	{&model.Product{}, "COUNT(*) AS total, MAX(updated_at) AS changed, MAX(deleted_at) AS deleted"},
+	{&model.ProductImage{}, "COUNT(*) AS total, MAX(updated_at) AS changed, MAX(deleted_at) AS deleted"},
+	{&model.Category{}, "COUNT(*) AS total, MAX(updated_at) AS changed, MAX(deleted_at) AS deleted"},
+	{&model.PriceHistory{}, "COUNT(*) AS total, MAX(id) AS changed, NULL AS deleted"},
+}
+
+func (r *catalogRepository) Version() (string, error) {
+	parts := make([]string, 0, len(catalogStamps))
+	for _, table := range catalogStamps {
+		var stamp struct {
+			Total   int64
+			Changed sql.NullString
+			Deleted sql.NullString
+		}
+		if err := r.db.Unscoped().Model(table.model).Select(table.stamp).Scan(&stamp).Error; err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%d/%s/%s", stamp.Total, stamp.Changed.String, stamp.Deleted.String))
+	}
+	return strings.Join(parts, ";"), nil
+}
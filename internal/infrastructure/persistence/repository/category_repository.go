@@ -42,6 +42,32 @@ func (r *categoryRepository) FindAll() ([]model.Category, error) {
 	return categories, nil
 }
 
+func (r *categoryRepository) FindBySlug(slug string) (*model.Category, error) {
+	var category model.Category
+	if err := r.db.Preload("Subcategories").
+		Preload("ParentCategory").
+		Where("slug = ?", slug).
+		First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) SlugTaken(slug string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&model.Category{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *categoryRepository) FindWithoutSlug() ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.Scopes(scope.ScopeWithoutSlug).Order("id").Find(&categories).Error
+	return categories, err
+}
+
 func (r *categoryRepository) FindWithFilters(filters map[string]string) ([]model.Category, error) {
 	db := r.db.Model(&model.Category{})
 
@@ -68,7 +94,11 @@ func (r *categoryRepository) applyBooleanFilters(db *gorm.DB, filters map[string
 
 func (r *categoryRepository) applyStringFilters(db *gorm.DB, filters map[string]string) {
 	if v, ok := filters["name"]; ok {
-		db.Scopes(scope.ScopeCategoryByName(v))
+		if locale := filters["locale"]; locale != "" {
+			db.Scopes(scope.ScopeCategoryByTranslatedName(v, locale))
+		} else {
+			db.Scopes(scope.ScopeCategoryByName(v))
+		}
 	}
 }
 
@@ -113,6 +143,35 @@ func (r *categoryRepository) Update(category *model.Category) error {
 	return nil
 }
 
+func (r *categoryRepository) UpdateParent(id uint, parentID *uint) error {
+	result := r.db.Model(&model.Category{}).Where("id = ?", id).Update("parent_id", parentID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *categoryRepository) CountProducts() (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	if err := r.db.Model(&model.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Group("category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *categoryRepository) Delete(id uint) error {
 	result := r.db.Delete(&model.Category{}, id)
 	if result.Error != nil {
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type emailChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailChangeRepository(db *gorm.DB) repository.EmailChangeRepository {
+	return &emailChangeRepository{db: db}
+}
+
+func (r *emailChangeRepository) FindByTokenHash(hash string) (*model.EmailChange, error) {
+	var change model.EmailChange
+	if err := r.db.Where("token_hash = ?", hash).First(&change).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (r *emailChangeRepository) Create(change *model.EmailChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *emailChangeRepository) Update(change *model.EmailChange) error {
+	return r.db.Save(change).Error
+}
+
+func (r *emailChangeRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("confirmed_at IS NULL AND expires_at < ?", before).Delete(&model.EmailChange{})
+	return result.RowsAffected, result.Error
+}
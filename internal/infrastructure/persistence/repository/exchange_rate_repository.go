@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) repository.ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+func (r *exchangeRateRepository) FindByID(id uint) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := r.db.First(&rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *exchangeRateRepository) FindAll() ([]model.ExchangeRate, error) {
+	var rates []model.ExchangeRate
+	err := r.db.Order("currency ASC").Find(&rates).Error
+	return rates, err
+}
+
+func (r *exchangeRateRepository) FindByCurrency(currency string) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := r.db.Scopes(scope.ScopeExchangeRateByCurrency(currency)).First(&rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *exchangeRateRepository) Create(rate *model.ExchangeRate) error {
+	return r.db.Create(rate).Error
+}
+
+func (r *exchangeRateRepository) Update(rate *model.ExchangeRate) error {
+	result := r.db.Save(rate)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *exchangeRateRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.ExchangeRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *exchangeRateRepository) Upsert(rates []model.ExchangeRate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			var existing model.ExchangeRate
+			err := tx.Scopes(scope.ScopeExchangeRateByCurrency(rates[i].Currency)).First(&existing).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				err = tx.Create(&rates[i]).Error
+			case err == nil:
+				existing.Rate = rates[i].Rate
+				err = tx.Save(&existing).Error
+				rates[i] = existing
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *exchangeRateRepository) CountProducts(currency string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Product{}).Where("currency = ?", currency).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+func NewImpersonationRepository(db *gorm.DB) repository.ImpersonationRepository {
+	return &impersonationRepository{db: db}
+}
+
+func (r *impersonationRepository) FindWithFilters(filters map[string]string) ([]model.Impersonation, error) {
+	db := r.db.Model(&model.Impersonation{}).
+		Preload("Admin").
+		Preload("TargetUser").
+		Order("created_at DESC")
+
+	if v, ok := filters["admin_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeImpersonationByAdmin(uint(id)))
+		}
+	}
+	if v, ok := filters["target_user_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeImpersonationByTarget(uint(id)))
+		}
+	}
+	if v, ok := filters["created_after"]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			db = db.Scopes(scope.ScopeImpersonationCreatedAfter(t))
+		}
+	}
+	if v, ok := filters["created_before"]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			db = db.Scopes(scope.ScopeImpersonationCreatedBefore(t))
+		}
+	}
+
+	var impersonations []model.Impersonation
+	if err := db.Find(&impersonations).Error; err != nil {
+		return nil, err
+	}
+	return impersonations, nil
+}
+
+func (r *impersonationRepository) Create(impersonation *model.Impersonation) error {
+	return r.db.Create(impersonation).Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) repository.InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) FindByID(id uint) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := r.db.Scopes(scope.ScopeInvoiceDetails()).First(&invoice, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) FindByOrderID(orderID uint) ([]model.Invoice, error) {
+	var invoices []model.Invoice
+	err := r.db.Scopes(scope.ScopeInvoiceByOrder(orderID), scope.ScopeInvoiceDetails()).
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *invoiceRepository) Issue(invoice *model.Invoice, prepare func(issued []model.Invoice) error, number func(sequence int) string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if invoice.Type == model.InvoiceTypeInvoice {
+			var existing int64
+			err := tx.Model(&model.Invoice{}).
+				Scopes(scope.ScopeInvoiceByOrder(invoice.OrderID)).
+				Where("type = ?", model.InvoiceTypeInvoice).
+				Count(&existing).Error
+			if err != nil {
+				return err
+			}
+			if existing > 0 {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+
+		// The upsert takes the write lock before the number is read, so
+		// concurrent transactions cannot get the same number.
+		sequence := model.InvoiceSequence{Type: invoice.Type, Year: invoice.Year, LastNumber: 1}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "type"}, {Name: "year"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("last_number + 1")}),
+		}).Create(&sequence).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&sequence, "type = ? AND year = ?", invoice.Type, invoice.Year).Error; err != nil {
+			return err
+		}
+
+		// Documents issued before the lock was taken are all visible here.
+		var issued []model.Invoice
+		err = tx.Scopes(scope.ScopeInvoiceByOrder(invoice.OrderID), scope.ScopeInvoiceDetails()).
+			Order("id ASC").
+			Find(&issued).Error
+		if err != nil {
+			return err
+		}
+		if err := prepare(issued); err != nil {
+			return err
+		}
+
+		invoice.Sequence = sequence.LastNumber
+		invoice.Number = number(sequence.LastNumber)
+		return tx.Create(invoice).Error
+	})
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultJobRunLimit = 100
+	maxJobRunLimit     = 1000
+)
+
+type jobLeaseRepository struct {
+	db *gorm.DB
+}
+
+func NewJobLeaseRepository(db *gorm.DB) repository.JobLeaseRepository {
+	return &jobLeaseRepository{db: db}
+}
+
+// Acquire is a compare-and-set on the lease row: the UPDATE only matches when
+// the lease is free and the slot is new, so of several replicas racing for the
+// same slot exactly one sees a row affected.
+func (r *jobLeaseRepository) Acquire(name, owner string, slot, now, until time.Time) (bool, error) {
+	seed := model.JobLease{Name: name, LockedUntil: time.Unix(0, 0).UTC()}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&seed).Error; err != nil {
+		return false, err
+	}
+
+	result := r.db.Model(&model.JobLease{}).
+		Where("name = ?", name).
+		Scopes(scope.ScopeJobLeaseFree(now), scope.ScopeJobLeaseSlotUnclaimed(slot)).
+		Updates(map[string]interface{}{
+			"owner":        owner,
+			"locked_until": until,
+			"last_slot":    slot,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *jobLeaseRepository) Release(name, owner string) error {
+	now := time.Now()
+	return r.db.Model(&model.JobLease{}).
+		Where("name = ? AND owner = ?", name, owner).
+		Updates(map[string]interface{}{"locked_until": now, "updated_at": now}).Error
+}
+
+type jobRunRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRunRepository(db *gorm.DB) repository.JobRunRepository {
+	return &jobRunRepository{db: db}
+}
+
+func (r *jobRunRepository) FindWithFilters(filters map[string]string) ([]model.JobRun, error) {
+	db := r.db.Model(&model.JobRun{}).Order("id DESC")
+
+	if v, ok := filters["job"]; ok {
+		db = db.Scopes(scope.ScopeJobRunByName(v))
+	}
+	if v, ok := filters["status"]; ok {
+		db = db.Scopes(scope.ScopeJobRunByStatus(strings.ToUpper(v)))
+	}
+
+	limit := defaultJobRunLimit
+	if v, ok := filters["limit"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > maxJobRunLimit {
+		limit = maxJobRunLimit
+	}
+
+	var runs []model.JobRun
+	if err := db.Limit(limit).Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *jobRunRepository) Create(run *model.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *jobRunRepository) Update(run *model.JobRun) error {
+	result := r.db.Save(run)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ func (r *orderRepository) FindByID(id uint) (*model.Order, error) {
 	if err := r.db.Preload("User").
 		Preload("ShippingAddress").
 		Preload("Items").
+		Preload("TaxLines").
 		First(&order, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -37,6 +38,7 @@ func (r *orderRepository) FindByUserID(userID uint) ([]model.Order, error) {
 	var orders []model.Order
 	err := r.db.Preload("ShippingAddress").
 		Preload("Items").
+		Preload("TaxLines").
 		Where("user_id = ?", userID).
 		Find(&orders).Error
 	return orders, err
@@ -47,6 +49,7 @@ func (r *orderRepository) FindAll() ([]model.Order, error) {
 	err := r.db.Preload("User").
 		Preload("ShippingAddress").
 		Preload("Items").
+		Preload("TaxLines").
 		Find(&orders).Error
 	return orders, err
 }
@@ -56,6 +59,7 @@ func (r *orderRepository) FindWithFilters(filters map[string]string) ([]model.Or
 		Scopes(scope.ScopeWithAssociations())
 
 	r.applyUserFilter(db, filters)
+	r.applyGuestFilters(db, filters)
 	r.applyStatusFilter(db, filters)
 	r.applyTotalRangeFilter(db, filters)
 	r.applyTimeFilters(db, filters)
@@ -75,6 +79,17 @@ func (r *orderRepository) applyUserFilter(db *gorm.DB, filters map[string]string
 	}
 }
 
+func (r *orderRepository) applyGuestFilters(db *gorm.DB, filters map[string]string) {
+	if v, ok := filters["guest"]; ok {
+		if guest, err := strconv.ParseBool(v); err == nil {
+			db.Scopes(scope.ScopeGuestOrder(guest))
+		}
+	}
+	if v, ok := filters["guest_email"]; ok {
+		db.Scopes(scope.ScopeGuestEmail(v))
+	}
+}
+
 func (r *orderRepository) applyStatusFilter(db *gorm.DB, filters map[string]string) {
 	if v, ok := filters["status"]; ok {
 		db.Scopes(scope.ScopeByStatus(v))
@@ -122,3 +137,16 @@ func (r *orderRepository) Update(order *model.Order) error {
 	}
 	return nil
 }
+
+func (r *orderRepository) AttachGuestOrders(email string, userID uint) (int64, error) {
+	result := r.db.Model(&model.Order{}).
+		Scopes(scope.ScopeGuestOrder(true), scope.ScopeGuestEmail(email)).
+		Update("user_id", userID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *orderRepository) ClearGuestContact(userID uint) error {
+	return r.db.Model(&model.Order{}).
+		Where("user_id = ? AND guest_email <> ''", userID).
+		Updates(map[string]interface{}{"guest_email": "", "guest_name": "", "guest_surname": ""}).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOutboxLimit = 100
+	maxOutboxLimit     = 1000
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repository.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) FindByID(id uint) (*model.OutboxEvent, error) {
+	var event model.OutboxEvent
+	if err := r.db.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *outboxRepository) FindWithFilters(filters map[string]string) ([]model.OutboxEvent, error) {
+	db := r.db.Model(&model.OutboxEvent{}).Order("id DESC")
+
+	if v, ok := filters["status"]; ok {
+		db = db.Scopes(scope.ScopeOutboxByStatus(strings.ToUpper(v)))
+	}
+	if v, ok := filters["type"]; ok {
+		db = db.Scopes(scope.ScopeOutboxByType(v))
+	}
+	if v, ok := filters["aggregate_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopeOutboxByAggregateID(uint(id)))
+		}
+	}
+
+	limit := defaultOutboxLimit
+	if v, ok := filters["limit"]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > maxOutboxLimit {
+		limit = maxOutboxLimit
+	}
+
+	var events []model.OutboxEvent
+	if err := db.Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) FindDue(now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := r.db.
+		Scopes(scope.ScopeOutboxByStatus(string(model.OutboxPending)), scope.ScopeOutboxDue(now)).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepository) Create(event *model.OutboxEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *outboxRepository) Update(event *model.OutboxEvent) error {
+	result := r.db.Save(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
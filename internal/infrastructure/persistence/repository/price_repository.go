@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type priceScheduleRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceScheduleRepository(db *gorm.DB) repository.PriceScheduleRepository {
+	return &priceScheduleRepository{db: db}
+}
+
+func (r *priceScheduleRepository) FindByID(id uint) (*model.PriceSchedule, error) {
+	var schedule model.PriceSchedule
+	if err := r.db.First(&schedule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *priceScheduleRepository) FindByProduct(productID uint) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	err := r.db.Scopes(scope.ScopePriceScheduleByProducts([]uint{productID})).
+		Order("starts_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *priceScheduleRepository) FindActive(productIDs []uint, at time.Time) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	if len(productIDs) == 0 {
+		return schedules, nil
+	}
+	err := r.db.Scopes(
+		scope.ScopePriceScheduleByProducts(productIDs),
+		scope.ScopePriceScheduleActiveAt(at),
+	).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *priceScheduleRepository) FindProductsChanged(from, to time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.PriceSchedule{}).
+		Where("(starts_at > ? AND starts_at <= ?) OR (ends_at > ? AND ends_at <= ?)", from, to, from, to).
+		Distinct().
+		Pluck("product_id", &ids).Error
+	return ids, err
+}
+
+func (r *priceScheduleRepository) Create(schedule *model.PriceSchedule) error {
+	return r.db.Create(schedule).Error
+}
+
+func (r *priceScheduleRepository) Update(schedule *model.PriceSchedule) error {
+	result := r.db.Save(schedule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *priceScheduleRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.PriceSchedule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+type priceHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceHistoryRepository(db *gorm.DB) repository.PriceHistoryRepository {
+	return &priceHistoryRepository{db: db}
+}
+
+func (r *priceHistoryRepository) Create(entry *model.PriceHistory) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *priceHistoryRepository) FindLatest(productID uint) (*model.PriceHistory, error) {
+	var entry model.PriceHistory
+	err := r.db.Scopes(scope.ScopePriceHistoryByProduct(productID)).
+		Order("changed_at DESC, id DESC").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *priceHistoryRepository) FindSince(productID uint, since time.Time) ([]model.PriceHistory, error) {
+	var entries []model.PriceHistory
+	var current model.PriceHistory
+	err := r.db.Scopes(scope.ScopePriceHistoryByProduct(productID)).
+		Where("changed_at <= ?", since).
+		Order("changed_at DESC, id DESC").
+		First(&current).Error
+	switch {
+	case err == nil:
+		entries = append(entries, current)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	var changed []model.PriceHistory
+	err = r.db.Scopes(scope.ScopePriceHistoryByProduct(productID)).
+		Where("changed_at > ?", since).
+		Order("changed_at ASC, id ASC").
+		Find(&changed).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, changed...), nil
+}
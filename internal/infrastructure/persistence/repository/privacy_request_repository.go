@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type privacyRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRequestRepository(db *gorm.DB) repository.PrivacyRequestRepository {
+	return &privacyRequestRepository{db: db}
+}
+
+func (r *privacyRequestRepository) FindByID(id uint) (*model.PrivacyRequest, error) {
+	var request model.PrivacyRequest
+	if err := r.db.Preload("User.Address").First(&request, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *privacyRequestRepository) FindWithFilters(filters map[string]string) ([]model.PrivacyRequest, error) {
+	db := r.db.Model(&model.PrivacyRequest{}).
+		Preload("User.Address").
+		Order("created_at ASC")
+
+	if v, ok := filters["user_id"]; ok {
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			db = db.Scopes(scope.ScopePrivacyRequestByUser(uint(id)))
+		}
+	}
+	if v, ok := filters["type"]; ok {
+		db = db.Scopes(scope.ScopePrivacyRequestByType(strings.ToUpper(v)))
+	}
+	if v, ok := filters["status"]; ok {
+		db = db.Scopes(scope.ScopePrivacyRequestByStatus(strings.ToUpper(v)))
+	}
+
+	var requests []model.PrivacyRequest
+	if err := db.Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (r *privacyRequestRepository) Create(request *model.PrivacyRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *privacyRequestRepository) Update(request *model.PrivacyRequest) error {
+	result := r.db.Omit("User").Save(request)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) repository.ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+func (r *productImageRepository) FindByProduct(productID uint) ([]model.ProductImage, error) {
+	var images []model.ProductImage
+	if err := r.db.Scopes(scope.OrderProductImages).Where("product_id = ?", productID).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *productImageRepository) Create(image *model.ProductImage) error {
+	return r.db.Omit("Product").Create(image).Error
+}
+
+func (r *productImageRepository) Save(images []model.ProductImage) error {
+	if len(images) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range images {
+			if err := tx.Omit("Product").Save(&images[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *productImageRepository) Delete(ids ...uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Delete(&model.ProductImage{}, ids).Error
+}
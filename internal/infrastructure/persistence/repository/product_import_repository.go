@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type productImportRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImportRepository(db *gorm.DB) repository.ProductImportRepository {
+	return &productImportRepository{db: db}
+}
+
+// FindByID leaves out the uploaded file, which only the import itself needs.
+func (r *productImportRepository) FindByID(id uint) (*model.ProductImport, error) {
+	var productImport model.ProductImport
+	if err := r.db.Omit("payload").First(&productImport, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &productImport, nil
+}
+
+func (r *productImportRepository) FindRecent(limit int) ([]model.ProductImport, error) {
+	var imports []model.ProductImport
+	err := r.db.Omit("payload").Order("id DESC").Limit(limit).Find(&imports).Error
+	return imports, err
+}
+
+func (r *productImportRepository) FindNextPending() (*model.ProductImport, error) {
+	var productImport model.ProductImport
+	err := r.db.Scopes(scope.ScopeProductImportByStatus(model.ProductImportPending)).
+		Order("id ASC").
+		First(&productImport).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &productImport, nil
+}
+
+func (r *productImportRepository) FailRunning(reason string) (int64, error) {
+	result := r.db.Model(&model.ProductImport{}).
+		Scopes(scope.ScopeProductImportByStatus(model.ProductImportRunning)).
+		Updates(map[string]interface{}{"status": model.ProductImportFailed, "error": reason, "payload": nil})
+	return result.RowsAffected, result.Error
+}
+
+func (r *productImportRepository) Create(productImport *model.ProductImport) error {
+	return r.db.Create(productImport).Error
+}
+
+func (r *productImportRepository) Update(productImport *model.ProductImport) error {
+	result := r.db.Save(productImport)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -22,7 +22,7 @@ func (r *productRepository) FindByID(id uint) (*model.Product, error) {
 	var prod model.Product
 	if err := r.db.
 		Preload("Category").
-		Preload("Images").
+		Preload("Images", scope.OrderProductImages).
 		First(&prod, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -32,43 +32,99 @@ func (r *productRepository) FindByID(id uint) (*model.Product, error) {
 	return &prod, nil
 }
 
+func (r *productRepository) FindBySKU(sku string) (*model.Product, error) {
+	var prod model.Product
+	if err := r.db.
+		Preload("Category").
+		Preload("Images", scope.OrderProductImages).
+		Where("sku = ?", sku).
+		First(&prod).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &prod, nil
+}
+
+func (r *productRepository) FindBySlug(slug string) (*model.Product, error) {
+	var prod model.Product
+	if err := r.db.
+		Preload("Category").
+		Preload("Images", scope.OrderProductImages).
+		Where("slug = ?", slug).
+		First(&prod).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &prod, nil
+}
+
+func (r *productRepository) SlugTaken(slug string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&model.Product{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *productRepository) FindWithoutSlug() ([]model.Product, error) {
+	var prods []model.Product
+	err := r.db.Scopes(scope.ScopeWithoutSlug).Order("id").Find(&prods).Error
+	return prods, err
+}
+
 func (r *productRepository) FindAll() ([]model.Product, error) {
 	var prods []model.Product
 	err := r.db.
 		Preload("Category").
-		Preload("Images").
+		Preload("Images", scope.OrderProductImages).
 		Find(&prods).Error
 	return prods, err
 }
 
 func (r *productRepository) FindWithFilters(filters map[string]string) ([]model.Product, error) {
+	var products []model.Product
+	if err := r.filtered(filters).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *productRepository) FindWithFiltersInBatches(filters map[string]string, batchSize int, fn func(products []model.Product) error) error {
+	var batch []model.Product
+	return r.filtered(filters).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+func (r *productRepository) filtered(filters map[string]string) *gorm.DB {
 	db := r.db.Model(&model.Product{}).
 		Preload("Category").
-		Preload("Images")
+		Preload("Images", scope.OrderProductImages)
 
 	r.applyCategoryFilter(db, filters)
 	r.applyNameFilter(db, filters)
 	r.applyActiveFilter(db, filters)
 	r.applyPriceRangeFilter(db, filters)
-
-	var products []model.Product
-	if err := db.Find(&products).Error; err != nil {
-		return nil, err
-	}
-	return products, nil
+	return db
 }
 
 func (r *productRepository) applyCategoryFilter(db *gorm.DB, filters map[string]string) {
 	if v, ok := filters["category_id"]; ok {
 		if id, err := strconv.Atoi(v); err == nil {
-			db.Scopes(scope.ScopeProductByCategory(uint(id)))
+			db.Scopes(scope.ScopeProductByCategoryTree(uint(id)))
 		}
 	}
 }
 
 func (r *productRepository) applyNameFilter(db *gorm.DB, filters map[string]string) {
 	if v, ok := filters["name"]; ok {
-		db.Scopes(scope.ScopeProductByName(v))
+		if locale := filters["locale"]; locale != "" {
+			db.Scopes(scope.ScopeProductByTranslatedName(v, locale))
+		} else {
+			db.Scopes(scope.ScopeProductByName(v))
+		}
 	}
 }
 
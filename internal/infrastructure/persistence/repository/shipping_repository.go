@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type shippingZoneRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingZoneRepository(db *gorm.DB) repository.ShippingZoneRepository {
+	return &shippingZoneRepository{db: db}
+}
+
+func (r *shippingZoneRepository) FindByID(id uint) (*model.ShippingZone, error) {
+	var zone model.ShippingZone
+	if err := r.db.First(&zone, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &zone, nil
+}
+
+func (r *shippingZoneRepository) FindAll() ([]model.ShippingZone, error) {
+	var zones []model.ShippingZone
+	err := r.db.Order("id ASC").Find(&zones).Error
+	return zones, err
+}
+
+func (r *shippingZoneRepository) Create(zone *model.ShippingZone) error {
+	return r.db.Create(zone).Error
+}
+
+func (r *shippingZoneRepository) Update(zone *model.ShippingZone) error {
+	result := r.db.Save(zone)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *shippingZoneRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.ShippingZone{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+type shippingMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingMethodRepository(db *gorm.DB) repository.ShippingMethodRepository {
+	return &shippingMethodRepository{db: db}
+}
+
+func (r *shippingMethodRepository) FindByID(id uint) (*model.ShippingMethod, error) {
+	var method model.ShippingMethod
+	if err := r.db.Scopes(scope.ScopeShippingMethodDetails()).First(&method, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &method, nil
+}
+
+func (r *shippingMethodRepository) FindAll() ([]model.ShippingMethod, error) {
+	var methods []model.ShippingMethod
+	err := r.db.Scopes(scope.ScopeShippingMethodDetails()).Order("id ASC").Find(&methods).Error
+	return methods, err
+}
+
+func (r *shippingMethodRepository) FindActive() ([]model.ShippingMethod, error) {
+	var methods []model.ShippingMethod
+	err := r.db.Scopes(scope.ScopeShippingMethodActive(), scope.ScopeShippingMethodDetails()).
+		Order("id ASC").
+		Find(&methods).Error
+	return methods, err
+}
+
+func (r *shippingMethodRepository) CountByZone(zoneID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ShippingMethod{}).Scopes(scope.ScopeShippingMethodByZone(zoneID)).Count(&count).Error
+	return count, err
+}
+
+func (r *shippingMethodRepository) CountActive() (int64, error) {
+	var count int64
+	err := r.db.Model(&model.ShippingMethod{}).Scopes(scope.ScopeShippingMethodActive()).Count(&count).Error
+	return count, err
+}
+
+func (r *shippingMethodRepository) Create(method *model.ShippingMethod) error {
+	return r.db.Omit("Zone").Create(method).Error
+}
+
+func (r *shippingMethodRepository) Update(method *model.ShippingMethod) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Omit("Zone", "Rates").Save(method)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Where("method_id = ?", method.ID).Delete(&model.ShippingRate{}).Error; err != nil {
+			return err
+		}
+		if len(method.Rates) == 0 {
+			return nil
+		}
+		for i := range method.Rates {
+			method.Rates[i].ID = 0
+			method.Rates[i].MethodID = method.ID
+		}
+		return tx.Create(&method.Rates).Error
+	})
+}
+
+func (r *shippingMethodRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.ShippingMethod{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
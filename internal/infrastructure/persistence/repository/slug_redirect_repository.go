@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"errors"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type slugRedirectRepository struct {
+	db *gorm.DB
+}
+
+func NewSlugRedirectRepository(db *gorm.DB) repository.SlugRedirectRepository {
+	return &slugRedirectRepository{db: db}
+}
+
+func (r *slugRedirectRepository) Find(entityType, slug string) (*model.SlugRedirect, error) {
+	var redirect model.SlugRedirect
+	if err := r.db.Where("entity_type = ? AND slug = ?", entityType, slug).First(&redirect).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &redirect, nil
+}
+
+func (r *slugRedirectRepository) Save(redirect *model.SlugRedirect) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entity_id", "updated_at"}),
+	}).Create(redirect).Error
+}
+
+func (r *slugRedirectRepository) Delete(entityType, slug string) error {
+	return r.db.Where("entity_type = ? AND slug = ?", entityType, slug).Delete(&model.SlugRedirect{}).Error
+}
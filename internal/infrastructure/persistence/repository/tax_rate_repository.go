@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/persistence/scope"
+
+	"gorm.io/gorm"
+)
+
+type taxRateRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRateRepository(db *gorm.DB) repository.TaxRateRepository {
+	return &taxRateRepository{db: db}
+}
+
+func (r *taxRateRepository) FindByID(id uint) (*model.TaxRate, error) {
+	var rate model.TaxRate
+	if err := r.db.First(&rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *taxRateRepository) FindAll() ([]model.TaxRate, error) {
+	var rates []model.TaxRate
+	err := r.db.Order("country ASC, class ASC").Find(&rates).Error
+	return rates, err
+}
+
+func (r *taxRateRepository) FindByCountry(country string) ([]model.TaxRate, error) {
+	var rates []model.TaxRate
+	err := r.db.Scopes(scope.ScopeTaxRateByCountry(country)).Order("id ASC").Find(&rates).Error
+	return rates, err
+}
+
+func (r *taxRateRepository) Create(rate *model.TaxRate) error {
+	return r.db.Create(rate).Error
+}
+
+func (r *taxRateRepository) Update(rate *model.TaxRate) error {
+	result := r.db.Save(rate)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *taxRateRepository) Delete(id uint) error {
+	result := r.db.Delete(&model.TaxRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
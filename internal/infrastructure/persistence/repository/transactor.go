@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type gormTransactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) repository.Transactor {
+	return &gormTransactor{db: db}
+}
+
+func (t *gormTransactor) WithinTransaction(fn func(repos repository.TxRepositories) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(repository.TxRepositories{
+			Orders:          NewOrderRepository(tx),
+			Carts:           NewCartRepository(tx),
+			CartItems:       NewCartItemRepository(tx),
+			Products:        NewProductRepository(tx),
+			Outbox:          NewOutboxRepository(tx),
+			Users:           NewUserRepository(tx),
+			Addresses:       NewAddressRepository(tx),
+			PrivacyRequests: NewPrivacyRequestRepository(tx),
+			SlugRedirects:   NewSlugRedirectRepository(tx),
+			PriceHistory:    NewPriceHistoryRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type translationRepository struct {
+	db *gorm.DB
+}
+
+func NewTranslationRepository(db *gorm.DB) repository.TranslationRepository {
+	return &translationRepository{db: db}
+}
+
+func (r *translationRepository) FindProductTranslations(productID uint) ([]model.ProductTranslation, error) {
+	var translations []model.ProductTranslation
+	err := r.db.Where("product_id = ?", productID).Order("locale").Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) FindProductTranslationsIn(locale string, productIDs []uint) ([]model.ProductTranslation, error) {
+	var translations []model.ProductTranslation
+	if len(productIDs) == 0 {
+		return translations, nil
+	}
+	err := r.db.Where("locale = ? AND product_id IN ?", locale, productIDs).Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) SaveProductTranslation(translation *model.ProductTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "updated_at"}),
+	}).Create(translation).Error
+}
+
+func (r *translationRepository) DeleteProductTranslation(productID uint, locale string) error {
+	result := r.db.Where("product_id = ? AND locale = ?", productID, locale).Delete(&model.ProductTranslation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *translationRepository) FindCategoryTranslations(categoryID uint) ([]model.CategoryTranslation, error) {
+	var translations []model.CategoryTranslation
+	err := r.db.Where("category_id = ?", categoryID).Order("locale").Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) FindCategoryTranslationsIn(locale string, categoryIDs []uint) ([]model.CategoryTranslation, error) {
+	var translations []model.CategoryTranslation
+	if len(categoryIDs) == 0 {
+		return translations, nil
+	}
+	err := r.db.Where("locale = ? AND category_id IN ?", locale, categoryIDs).Find(&translations).Error
+	return translations, err
+}
+
+func (r *translationRepository) SaveCategoryTranslation(translation *model.CategoryTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "category_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+	}).Create(translation).Error
+}
+
+func (r *translationRepository) DeleteCategoryTranslation(categoryID uint, locale string) error {
+	result := r.db.Where("category_id = ? AND locale = ?", categoryID, locale).Delete(&model.CategoryTranslation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
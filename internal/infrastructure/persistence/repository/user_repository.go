@@ -2,6 +2,7 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/domain/repository"
@@ -97,3 +98,26 @@ func (r *userRepository) Delete(id uint) error {
 	}
 	return nil
 }
+
+func (r *userRepository) IncrementFailedLogins(id uint) (int, error) {
+	var attempts int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ?", id).
+			UpdateColumn("failed_login_attempts", gorm.Expr("failed_login_attempts + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		// The update holds the write lock, so this reads our own increment.
+		return tx.Model(&model.User{}).Where("id = ?", id).
+			Pluck("failed_login_attempts", &attempts).Error
+	})
+	return attempts, err
+}
+
+func (r *userRepository) LockUntil(id uint, until time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		UpdateColumn("locked_until", until).Error
+}
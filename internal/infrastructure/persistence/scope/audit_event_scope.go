@@ -0,0 +1,61 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeAuditByActor(actorID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("actor_id = ?", actorID)
+	}
+}
+
+func ScopeAuditByAPIKey(apiKeyID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("api_key_id = ?", apiKeyID)
+	}
+}
+
+func ScopeAuditByAction(action string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("action = ?", action)
+	}
+}
+
+func ScopeAuditByEntityType(entityType string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("entity_type = ?", entityType)
+	}
+}
+
+func ScopeAuditByEntityID(entityID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("entity_id = ?", entityID)
+	}
+}
+
+func ScopeAuditByRequestID(requestID string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("request_id = ?", requestID)
+	}
+}
+
+func ScopeAuditCreatedAfter(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at >= ?", t)
+	}
+}
+
+func ScopeAuditCreatedBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at <= ?", t)
+	}
+}
+
+func ScopeAuditLimit(limit int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Limit(limit)
+	}
+}
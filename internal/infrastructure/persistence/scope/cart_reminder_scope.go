@@ -0,0 +1,31 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeCartReminderByCart(cartID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("cart_id = ?", cartID)
+	}
+}
+
+func ScopeCartReminderByUser(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	}
+}
+
+func ScopeCartReminderSentBetween(from, to time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("sent_at >= ? AND sent_at < ?", from, to)
+	}
+}
+
+func ScopeCartReminderNotRecovered() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("recovered_order_id IS NULL")
+	}
+}
@@ -12,6 +12,13 @@ func ScopeCartByUser(userID uint) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// ScopeCartGuest matches carts of anonymous visitors.
+func ScopeCartGuest() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id IS NULL")
+	}
+}
+
 func ScopeCartByTotalRange(min, max float64) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("total BETWEEN ? AND ?", min, max)
@@ -35,3 +42,33 @@ func ScopeCartCreatedBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
 		return db.Where("created_at <= ?", t)
 	}
 }
+
+func ScopeCartUpdatedBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("updated_at < ?", t)
+	}
+}
+
+// ScopeCartAbandoned matches carts flagged (or, with false, not flagged) as abandoned.
+func ScopeCartAbandoned(abandoned bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if abandoned {
+			return db.Where("abandoned_at IS NOT NULL")
+		}
+		return db.Where("abandoned_at IS NULL")
+	}
+}
+
+func ScopeCartNotEmpty() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.deleted_at IS NULL)")
+	}
+}
+
+// ScopeCartDisposable matches guest carts and carts without any live (not
+// soft deleted) item.
+func ScopeCartDisposable() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id IS NULL OR NOT EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.deleted_at IS NULL)")
+	}
+}
@@ -13,6 +13,17 @@ func ScopeCategoryByName(name string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// ScopeCategoryByTranslatedName matches categories whose name, or whose
+// name in locale, contains name.
+func ScopeCategoryByTranslatedName(name, locale string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := "%" + strings.ToLower(name) + "%"
+		return db.Where(`LOWER(categories.name) LIKE ? OR categories.id IN (
+			SELECT category_id FROM category_translations WHERE locale = ? AND LOWER(name) LIKE ?
+		)`, pattern, locale, pattern)
+	}
+}
+
 func ScopeCategoryCreatedAfter(t time.Time) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("created_at >= ?", t)
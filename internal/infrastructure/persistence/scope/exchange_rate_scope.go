@@ -0,0 +1,9 @@
+package scope
+
+import "gorm.io/gorm"
+
+func ScopeExchangeRateByCurrency(currency string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("currency = ?", currency)
+	}
+}
@@ -0,0 +1,31 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeImpersonationByAdmin(adminID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("admin_id = ?", adminID)
+	}
+}
+
+func ScopeImpersonationByTarget(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("target_user_id = ?", userID)
+	}
+}
+
+func ScopeImpersonationCreatedAfter(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at >= ?", t)
+	}
+}
+
+func ScopeImpersonationCreatedBefore(t time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("created_at <= ?", t)
+	}
+}
@@ -0,0 +1,23 @@
+package scope
+
+import "gorm.io/gorm"
+
+func ScopeInvoiceByOrder(orderID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("order_id = ?", orderID)
+	}
+}
+
+// ScopeInvoiceDetails preloads the lines in invoice order and the VAT
+// summary, highest rate first.
+func ScopeInvoiceDetails() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Preload("Lines", func(db *gorm.DB) *gorm.DB {
+				return db.Order("id ASC")
+			}).
+			Preload("TaxLines", func(db *gorm.DB) *gorm.DB {
+				return db.Order("rate DESC")
+			})
+	}
+}
@@ -0,0 +1,31 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeJobLeaseFree(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("locked_until <= ?", now)
+	}
+}
+
+func ScopeJobLeaseSlotUnclaimed(slot time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("last_slot IS NULL OR last_slot < ?", slot)
+	}
+}
+
+func ScopeJobRunByName(name string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("job_name = ?", name)
+	}
+}
+
+func ScopeJobRunByStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
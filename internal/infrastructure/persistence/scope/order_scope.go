@@ -12,6 +12,23 @@ func ScopeByUser(userID uint) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// ScopeGuestOrder matches orders not (or, with guest false, already) attached to an account.
+func ScopeGuestOrder(guest bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if guest {
+			return db.Where("user_id IS NULL")
+		}
+		return db.Where("user_id IS NOT NULL")
+	}
+}
+
+// ScopeGuestEmail matches orders placed at guest checkout with email, ignoring case.
+func ScopeGuestEmail(email string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("LOWER(guest_email) = LOWER(?)", email)
+	}
+}
+
 func ScopeByStatus(status string) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("status = ?", status)
@@ -41,6 +58,7 @@ func ScopeWithAssociations() func(db *gorm.DB) *gorm.DB {
 		return db.
 			Preload("User").
 			Preload("ShippingAddress").
-			Preload("Items")
+			Preload("Items").
+			Preload("TaxLines")
 	}
 }
@@ -0,0 +1,31 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeOutboxByStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
+
+func ScopeOutboxByType(eventType string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("type = ?", eventType)
+	}
+}
+
+func ScopeOutboxByAggregateID(id uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("aggregate_id = ?", id)
+	}
+}
+
+func ScopeOutboxDue(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("next_attempt_at <= ?", now)
+	}
+}
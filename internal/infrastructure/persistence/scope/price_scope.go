@@ -0,0 +1,25 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopePriceScheduleByProducts(productIDs []uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("product_id IN ?", productIDs)
+	}
+}
+
+func ScopePriceScheduleActiveAt(at time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", at, at)
+	}
+}
+
+func ScopePriceHistoryByProduct(productID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("product_id = ?", productID)
+	}
+}
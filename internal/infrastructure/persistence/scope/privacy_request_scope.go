@@ -0,0 +1,21 @@
+package scope
+
+import "gorm.io/gorm"
+
+func ScopePrivacyRequestByUser(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ?", userID)
+	}
+}
+
+func ScopePrivacyRequestByType(requestType string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("type = ?", requestType)
+	}
+}
+
+func ScopePrivacyRequestByStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
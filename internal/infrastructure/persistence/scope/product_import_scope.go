@@ -0,0 +1,13 @@
+package scope
+
+import (
+	"go-ecommerce-api/internal/domain/model"
+
+	"gorm.io/gorm"
+)
+
+func ScopeProductImportByStatus(status model.ProductImportStatus) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
@@ -6,9 +6,19 @@ import (
 	"gorm.io/gorm"
 )
 
-func ScopeProductByCategory(categoryID uint) func(db *gorm.DB) *gorm.DB {
+// ScopeProductByCategoryTree matches products in the category or any of its
+// descendants. UNION rather than UNION ALL stops at categories already
+// seen, should the parents ever form a cycle.
+func ScopeProductByCategoryTree(categoryID uint) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		return db.Where("category_id = ?", categoryID)
+		return db.Where(`category_id IN (
+			WITH RECURSIVE tree(id) AS (
+				SELECT ?
+				UNION
+				SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id WHERE c.deleted_at IS NULL
+			)
+			SELECT id FROM tree
+		)`, categoryID)
 	}
 }
 
@@ -18,6 +28,17 @@ func ScopeProductByName(name string) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// ScopeProductByTranslatedName matches products whose name, or whose name
+// in locale, contains name.
+func ScopeProductByTranslatedName(name, locale string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := "%" + strings.ToLower(name) + "%"
+		return db.Where(`LOWER(products.name) LIKE ? OR products.id IN (
+			SELECT product_id FROM product_translations WHERE locale = ? AND LOWER(name) LIKE ?
+		)`, pattern, locale, pattern)
+	}
+}
+
 func ScopeProductByIsActive(active bool) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 		return db.Where("is_active = ?", active)
@@ -29,3 +50,8 @@ func ScopeProductByPriceRange(min, max float64) func(db *gorm.DB) *gorm.DB {
 		return db.Where("price BETWEEN ? AND ?", min, max)
 	}
 }
+
+// OrderProductImages sorts images the way the product shows them.
+func OrderProductImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
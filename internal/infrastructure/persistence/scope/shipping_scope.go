@@ -0,0 +1,25 @@
+package scope
+
+import "gorm.io/gorm"
+
+func ScopeShippingMethodActive() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_active = ?", true)
+	}
+}
+
+func ScopeShippingMethodByZone(zoneID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("zone_id = ?", zoneID)
+	}
+}
+
+// ScopeShippingMethodDetails preloads the zone and the rates, cheapest first.
+func ScopeShippingMethodDetails() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Zone").
+			Preload("Rates", func(db *gorm.DB) *gorm.DB {
+				return db.Order("price ASC, id ASC")
+			})
+	}
+}
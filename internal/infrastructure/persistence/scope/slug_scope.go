@@ -0,0 +1,8 @@
+package scope
+
+import "gorm.io/gorm"
+
+// ScopeWithoutSlug matches rows saved before the slug column was added.
+func ScopeWithoutSlug(db *gorm.DB) *gorm.DB {
+	return db.Where("slug IS NULL OR slug = ''")
+}
@@ -0,0 +1,13 @@
+package scope
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+func ScopeTaxRateByCountry(country string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("LOWER(country) = ?", strings.ToLower(strings.TrimSpace(country)))
+	}
+}
@@ -0,0 +1,43 @@
+package scope
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func ScopeWebhookActive() func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_active = ?", true)
+	}
+}
+
+func ScopeWebhookDeliveryBySubscription(subscriptionID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("subscription_id = ?", subscriptionID)
+	}
+}
+
+func ScopeWebhookDeliveryByOutboxEvent(outboxEventID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("outbox_event_id = ?", outboxEventID)
+	}
+}
+
+func ScopeWebhookDeliveryByStatus(status string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", status)
+	}
+}
+
+func ScopeWebhookDeliveryByEventType(eventType string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("event_type = ?", eventType)
+	}
+}
+
+func ScopeWebhookDeliveryDue(now time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("next_attempt_at <= ?", now)
+	}
+}
@@ -1,13 +1,27 @@
 package sqlite
 
 import (
+	"strings"
+
 	"go-ecommerce-api/internal/domain/model"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// busyTimeout makes a connection wait for a concurrent write transaction
+// (e.g. the outbox dispatcher) instead of failing with "database is locked".
+const busyTimeout = "_busy_timeout=5000"
+
 func NewGormDB(dsn string) (*gorm.DB, error) {
+	if !strings.Contains(dsn, "_busy_timeout") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + busyTimeout
+		} else {
+			dsn += "?" + busyTimeout
+		}
+	}
+
 	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		return nil, err
@@ -19,10 +33,37 @@ func NewGormDB(dsn string) (*gorm.DB, error) {
 		&model.Category{},
 		&model.Product{},
 		&model.ProductImage{},
+		&model.ProductImport{},
+		&model.SlugRedirect{},
+		&model.ProductTranslation{},
+		&model.CategoryTranslation{},
 		&model.Cart{},
 		&model.CartItem{},
+		&model.CartReminder{},
+		&model.ShippingZone{},
+		&model.ShippingMethod{},
+		&model.ShippingRate{},
+		&model.TaxRate{},
+		&model.ExchangeRate{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
 		&model.Order{},
 		&model.OrderItem{},
+		&model.OrderTaxLine{},
+		&model.InvoiceSequence{},
+		&model.Invoice{},
+		&model.InvoiceLine{},
+		&model.InvoiceTaxLine{},
+		&model.APIKey{},
+		&model.Impersonation{},
+		&model.EmailChange{},
+		&model.PrivacyRequest{},
+		&model.AuditEvent{},
+		&model.OutboxEvent{},
+		&model.WebhookSubscription{},
+		&model.WebhookDelivery{},
+		&model.JobLease{},
+		&model.JobRun{},
 	}
 
 	if err := db.AutoMigrate(models...); err != nil {
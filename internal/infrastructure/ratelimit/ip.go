@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPExtractor tells echo how to find the client IP that ByIP and the audit
+// log use. Without trusted proxies it is the address of the connection, so
+// a client cannot pick its own IP with X-Forwarded-For. With them, the
+// header is followed back through the trusted proxies only.
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range trustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// IPExtractorFromEnv reads TRUSTED_PROXIES, a comma-separated list of IPs
+// or CIDR ranges of the reverse proxies in front of the API.
+func IPExtractorFromEnv() (echo.IPExtractor, error) {
+	proxies, err := ParseNetworks(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+	return IPExtractor(proxies), nil
+}
+
+// ParseNetworks parses a comma-separated list of IPs and CIDR ranges. A
+// single IP stands for a range of one address.
+func ParseNetworks(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("ratelimit: invalid proxy address %q", item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: invalid proxy range %q", item)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveLimited sends a request from remoteAddr with the given
+// X-Forwarded-For header through a router limited to one request per IP.
+func serveLimited(e *echo.Echo, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func limitedRouter(extractor echo.IPExtractor) *echo.Echo {
+	e := echo.New()
+	e.IPExtractor = extractor
+	e.Use(Middleware(NewLimiter(NewMemoryStore(), "test", 1, time.Minute), ByIP))
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	return e
+}
+
+func TestForgedForwardedForDoesNotResetLimit(t *testing.T) {
+	e := limitedRouter(IPExtractor(nil))
+
+	// Assertion 838: Without trusted proxies a forged X-Forwarded-For should not give a fresh limit
+	assert.Equal(t, http.StatusNoContent, serveLimited(e, "203.0.113.7:4000", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(e, "203.0.113.7:4001", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(e, "203.0.113.7:4002", ""))
+}
+
+func TestForwardedForThroughTrustedProxy(t *testing.T) {
+	proxies, err := ParseNetworks("10.0.0.0/8, 192.0.2.10")
+	assert.NoError(t, err)
+	e := limitedRouter(IPExtractor(proxies))
+
+	// Assertion 839: Behind a trusted proxy each client should have its own limit
+	assert.Equal(t, http.StatusNoContent, serveLimited(e, "10.1.2.3:4000", "198.51.100.1"))
+	assert.Equal(t, http.StatusNoContent, serveLimited(e, "192.0.2.10:4000", "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(e, "10.1.2.3:4001", "198.51.100.1"))
+
+	// Assertion 840: Addresses a client prepends before the proxy's entry should be ignored
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(e, "10.1.2.3:4002", "192.0.2.99, 198.51.100.1"))
+
+	// Assertion 841: A client connecting directly should not be trusted to forward
+	assert.Equal(t, http.StatusNoContent, serveLimited(e, "203.0.113.7:4000", "198.51.100.3"))
+	assert.Equal(t, http.StatusTooManyRequests, serveLimited(e, "203.0.113.7:4001", "198.51.100.4"))
+
+	_, err = ParseNetworks("10.0.0.0/8, proxy")
+	// Assertion 842: Invalid proxy addresses should be reported
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package ratelimit
+
+import "time"
+
+// Limiter allows at most Limit hits per key inside a sliding Window.
+type Limiter struct {
+	store  Store
+	limit  int
+	window time.Duration
+	prefix string
+}
+
+func NewLimiter(store Store, prefix string, limit int, window time.Duration) *Limiter {
+	return &Limiter{store: store, limit: limit, window: window, prefix: prefix}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+// When it is not, the returned duration says how long the caller has to wait
+// before the oldest hit leaves the window.
+func (l *Limiter) Allow(key string) (bool, time.Duration, error) {
+	now := time.Now()
+	count, oldest, err := l.store.Increment(l.prefix+":"+key, now, l.window)
+	if err != nil {
+		return false, 0, err
+	}
+	if count <= l.limit {
+		return true, 0, nil
+	}
+	retryAfter := oldest.Add(l.window).Sub(now)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return false, retryAfter, nil
+}
+
+func (l *Limiter) Reset(key string) error {
+	return l.store.Reset(l.prefix + ":" + key)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const errTooManyRequests = "too many requests"
+
+// maxKeyBodySize caps how much of a request body ByEmail reads. Login and
+// registration bodies are far smaller.
+const maxKeyBodySize = 64 << 10
+
+// KeyFunc extracts the identity a limit is applied to. An empty key skips the limiter.
+type KeyFunc func(c echo.Context) string
+
+// ByIP keys requests by the client IP address.
+func ByIP(c echo.Context) string {
+	return c.RealIP()
+}
+
+// ByEmail keys requests by the "email" field of a JSON body, leaving the body
+// intact for the handler. It is used to limit attempts per account. Only the
+// first maxKeyBodySize bytes are read and passed on, so a larger body reaches
+// the handler cut short and fails to bind instead of skipping the limit.
+func ByEmail(c echo.Context) string {
+	req := c.Request()
+	if req.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxKeyBodySize))
+	if err != nil {
+		return ""
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After header.
+func Middleware(limiter *Limiter, keyFunc KeyFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := keyFunc(c)
+			if key == "" {
+				return next(c)
+			}
+			allowed, retryAfter, err := limiter.Allow(key)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+			if !allowed {
+				SetRetryAfter(c, retryAfter.Seconds())
+				return echo.NewHTTPError(http.StatusTooManyRequests, errTooManyRequests)
+			}
+			return next(c)
+		}
+	}
+}
+
+// SetRetryAfter sets the Retry-After header, rounding up to whole seconds.
+func SetRetryAfter(c echo.Context, seconds float64) {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(seconds))))
+}
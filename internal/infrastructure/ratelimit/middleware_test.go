@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(body string) echo.Context {
+	req := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestByEmail(t *testing.T) {
+	c := newContext(`{"email":"  Ann@Example.com ","password":"secret"}`)
+	key := ByEmail(c)
+	// Assertion 757: The key should be the trimmed, lower-cased email
+	assert.Equal(t, "ann@example.com", key)
+
+	body, _ := io.ReadAll(c.Request().Body)
+	// Assertion 758: The handler should still get the whole body
+	assert.JSONEq(t, `{"email":"  Ann@Example.com ","password":"secret"}`, string(body))
+
+	// Assertion 759: Bodies that are not JSON should not be limited per account
+	assert.Empty(t, ByEmail(newContext("email=ann@example.com")))
+}
+
+func TestByEmailLimitsBodySize(t *testing.T) {
+	padding := strings.Repeat("x", 2*maxKeyBodySize)
+	c := newContext(`{"email":"ann@example.com","password":"` + padding + `"}`)
+
+	// Assertion 760: A body over the limit should not be read in full
+	assert.Empty(t, ByEmail(c))
+
+	body, _ := io.ReadAll(c.Request().Body)
+	// Assertion 761: The handler should get the body cut short so it cannot bypass the limit
+	assert.Len(t, body, maxKeyBodySize)
+}
+
+func TestMiddlewareRejectsOverLimit(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), "test", 1, time.Minute)
+	handler := Middleware(limiter, ByIP)(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	c := newContext("{}")
+	// Assertion 762: Requests within the limit should reach the handler
+	assert.NoError(t, handler(c))
+	assert.Equal(t, http.StatusNoContent, c.Response().Status)
+
+	c = newContext("{}")
+	err := handler(c)
+	var httpErr *echo.HTTPError
+	// Assertion 763: Requests over the limit should get 429 with Retry-After
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.Code)
+	assert.Equal(t, "60", c.Response().Header().Get("Retry-After"))
+
+	skipped := Middleware(limiter, func(echo.Context) string { return "" })(func(c echo.Context) error {
+		return nil
+	})
+	// Assertion 764: An empty key should skip the limiter
+	assert.NoError(t, skipped(newContext("{}")))
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store keeps the hit history used by the sliding-window limiter.
+// The in-memory implementation is enough for a single instance; a shared
+// implementation (e.g. Redis) can be plugged in when running several replicas.
+type Store interface {
+	// Increment records a hit for key at now and returns the number of hits
+	// inside the window together with the time of the oldest of them.
+	Increment(key string, now time.Time, window time.Duration) (int, time.Time, error)
+	// Reset forgets all hits recorded for key.
+	Reset(key string) error
+}
+
+const sweepInterval = 1000
+
+type memoryEntry struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	calls   int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]*memoryEntry{}}
+}
+
+func (s *MemoryStore) Increment(key string, now time.Time, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.calls%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	entry.window = window
+	entry.hits = append(prune(entry.hits, now.Add(-window)), now)
+
+	return len(entry.hits), entry.hits[0], nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep drops keys whose newest hit already left its window.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if len(entry.hits) == 0 || entry.hits[len(entry.hits)-1].Add(entry.window).Before(now) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func prune(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store.Increment("k", start, time.Minute)
+	store.Increment("k", start.Add(20*time.Second), time.Minute)
+	count, oldest, err := store.Increment("k", start.Add(40*time.Second), time.Minute)
+	// Assertion 749: Hits inside the window should all count
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, start, oldest)
+
+	count, oldest, _ = store.Increment("k", start.Add(70*time.Second), time.Minute)
+	// Assertion 750: Hits older than the window should drop out
+	assert.Equal(t, 3, count)
+	assert.Equal(t, start.Add(20*time.Second), oldest)
+
+	count, _, _ = store.Increment("other", start.Add(70*time.Second), time.Minute)
+	// Assertion 751: Keys should be counted separately
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, store.Reset("k"))
+	count, _, _ = store.Increment("k", start.Add(80*time.Second), time.Minute)
+	// Assertion 752: Reset should forget the key's hits
+	assert.Equal(t, 1, count)
+}
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := NewLimiter(NewMemoryStore(), "test", 2, time.Minute)
+
+	first, _, _ := limiter.Allow("1.2.3.4")
+	second, _, _ := limiter.Allow("1.2.3.4")
+	allowed, retryAfter, err := limiter.Allow("1.2.3.4")
+	// Assertion 753: Hits up to the limit should be allowed and the next refused
+	assert.NoError(t, err)
+	assert.True(t, first)
+	assert.True(t, second)
+	assert.False(t, allowed)
+
+	// Assertion 754: A refused hit should wait until the oldest hit leaves the window
+	assert.Greater(t, retryAfter, 58*time.Second)
+	assert.LessOrEqual(t, retryAfter, time.Minute)
+
+	allowed, _, _ = limiter.Allow("5.6.7.8")
+	// Assertion 755: Other keys should keep their own limit
+	assert.True(t, allowed)
+
+	assert.NoError(t, limiter.Reset("1.2.3.4"))
+	allowed, _, _ = limiter.Allow("1.2.3.4")
+	// Assertion 756: Reset should lift the limit
+	assert.True(t, allowed)
+}
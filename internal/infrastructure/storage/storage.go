@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps uploaded files under slash-separated keys such as
+// "products/12/3f9a.jpg" and tells where they can be downloaded.
+type Storage interface {
+	// Put stores the file under key, replacing any file already there.
+	Put(key string, r io.Reader, contentType string) error
+	// Delete removes the file under key. A missing file is not an error.
+	Delete(key string) error
+	// URL returns the address the file under key is served at.
+	URL(key string) string
+}
+
+// Local stores files in a directory on disk, served by the API itself when
+// its base URL is a path.
+type Local struct {
+	root    string
+	baseURL string
+}
+
+func NewLocal(root, baseURL string) *Local {
+	return &Local{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Root is the directory the files are written to.
+func (s *Local) Root() string {
+	return s.root
+}
+
+// BaseURL is the URL the root directory is served at.
+func (s *Local) BaseURL() string {
+	return s.baseURL
+}
+
+// path maps key to a file under the root, refusing keys that would leave it.
+func (s *Local) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so that a file being replaced is
+// never served half-written.
+func (s *Local) Put(key string, r io.Reader, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (s *Local) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *Local) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// FromEnv returns local storage in UPLOADS_PATH (default "uploads", or
+// /app/data/uploads in the Docker image) served at UPLOADS_URL (default
+// "/uploads"). Set UPLOADS_URL to a full URL when a CDN or web server in
+// front of the API serves the directory.
+func FromEnv() *Local {
+	root := os.Getenv("UPLOADS_PATH")
+	if root == "" {
+		root = "uploads"
+		if _, err := os.Stat("/app/data"); err == nil {
+			root = "/app/data/uploads"
+		}
+	}
+	baseURL := os.Getenv("UPLOADS_URL")
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return NewLocal(root, baseURL)
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalPutAndDelete(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocal(root, "/uploads/")
+	name := filepath.Join(root, "products", "12", "3f9a.jpg")
+
+	err := s.Put("products/12/3f9a.jpg", strings.NewReader("first"), "image/jpeg")
+	// Assertion 821: Put should create the directories and write the file under the root
+	assert.NoError(t, err)
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(data))
+
+	err = s.Put("products/12/3f9a.jpg", strings.NewReader("second"), "image/jpeg")
+	// Assertion 822: Put should replace the file and leave no temporary files behind
+	assert.NoError(t, err)
+	data, _ = os.ReadFile(name)
+	assert.Equal(t, "second", string(data))
+	entries, _ := os.ReadDir(filepath.Dir(name))
+	assert.Len(t, entries, 1)
+
+	// Assertion 823: URL should join the base URL and the key
+	assert.Equal(t, "/uploads/products/12/3f9a.jpg", s.URL("products/12/3f9a.jpg"))
+
+	// Assertion 824: Delete should remove the file and accept a missing one
+	assert.NoError(t, s.Delete("products/12/3f9a.jpg"))
+	_, err = os.Stat(name)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoError(t, s.Delete("products/12/3f9a.jpg"))
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	s := NewLocal(filepath.Join(parent, "uploads"), "/uploads")
+
+	for _, key := range []string{"", "..", "../secret", "/etc/passwd", "products/../../secret", "products//1.jpg", "./1.jpg", "products/"} {
+		// Assertion 825: Keys that are empty, absolute, unclean or leave the root should be refused
+		assert.ErrorIs(t, s.Put(key, strings.NewReader("x"), "text/plain"), ErrInvalidKey, key)
+		assert.ErrorIs(t, s.Delete(key), ErrInvalidKey, key)
+	}
+
+	// Assertion 826: Nothing should have been written outside the root
+	entries, err := os.ReadDir(parent)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,193 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery. Receivers verify a delivery by computing
+// Sign(secret, timestamp, body) and comparing it with SignatureHeader, and
+// should reject timestamps that are too old to prevent replays.
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+
+	signatureVersion = "v1"
+	maxResponseBody  = 1000
+	defaultTimeout   = 10 * time.Second
+	lookupTimeout    = 5 * time.Second
+)
+
+// ErrForbiddenDestination is returned for URLs whose host is, or resolves
+// to, an address that is not public: loopback, private, link-local (which
+// includes cloud metadata services), shared, multicast or unspecified.
+var ErrForbiddenDestination = errors.New("destination is not a public address")
+
+// nonPublicNets are ranges the net.IP predicates do not cover.
+var nonPublicNets = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+type Request struct {
+	URL        string
+	EventType  string
+	DeliveryID uint
+	Secret     string
+	Body       []byte
+	Timestamp  time.Time
+}
+
+type Response struct {
+	StatusCode int
+	Body       string
+}
+
+// Sender posts signed webhook deliveries. A non-2xx response is returned as
+// a Response, not an error; errors are reserved for transport failures.
+type Sender interface {
+	Send(req Request) (*Response, error)
+	// CheckURL reports whether deliveries to rawURL would be refused, so
+	// subscriptions can be rejected when they are saved.
+	CheckURL(rawURL string) error
+}
+
+// Sign returns the value of SignatureHeader: "v1=" followed by the hex
+// HMAC-SHA256 of "<unix timestamp>.<body>" keyed with the subscription secret.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign in constant time.
+func Verify(secret string, timestamp time.Time, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// HTTPSender delivers over HTTP. Unless allowPrivate is set it refuses to
+// connect to addresses that are not public, so subscribers cannot point it
+// at internal services. The check runs on every connection, after DNS
+// resolution and for every redirect, so a host that later resolves to a
+// private address is refused too.
+type HTTPSender struct {
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewHTTPSender returns a sender limited to public addresses, or to any
+// address when allowPrivate is set, e.g. for local development.
+func NewHTTPSender(allowPrivate bool) *HTTPSender {
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			return checkIP(net.ParseIP(host))
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &HTTPSender{
+		client:       &http.Client{Timeout: defaultTimeout, Transport: transport},
+		allowPrivate: allowPrivate,
+	}
+}
+
+// SenderFromEnv returns a sender limited to public addresses unless
+// WEBHOOK_ALLOW_PRIVATE_NETWORKS is true.
+func SenderFromEnv() *HTTPSender {
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"))
+	return NewHTTPSender(allowPrivate)
+}
+
+// CheckURL resolves the host of rawURL and fails with ErrForbiddenDestination
+// if any of its addresses is not public.
+func (s *HTTPSender) CheckURL(rawURL string) error {
+	if s.allowPrivate {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkIP(ip net.IP) error {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return ErrForbiddenDestination
+	}
+	for _, ipNet := range nonPublicNets {
+		if ipNet.Contains(ip) {
+			return ErrForbiddenDestination
+		}
+	}
+	return nil
+}
+
+func (s *HTTPSender) Send(req Request) (*Response, error) {
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "go-ecommerce-api-webhooks/1")
+	httpReq.Header.Set(EventHeader, req.EventType)
+	httpReq.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(TimestampHeader, strconv.FormatInt(req.Timestamp.Unix(), 10))
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, req.Timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return &Response{StatusCode: resp.StatusCode, Body: string(body)}, nil
+}
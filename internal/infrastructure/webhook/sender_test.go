@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSenderCheckURL(t *testing.T) {
+	sender := NewHTTPSender(false)
+
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fd00:ec2::254]/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+	} {
+		// Assertion 786: Internal and metadata addresses should be refused
+		assert.ErrorIs(t, sender.CheckURL(rawURL), ErrForbiddenDestination, rawURL)
+	}
+
+	// Assertion 787: Public addresses should be accepted
+	assert.NoError(t, sender.CheckURL("https://93.184.215.14/hook"))
+
+	// Assertion 788: A sender allowed to use private networks should accept them
+	assert.NoError(t, NewHTTPSender(true).CheckURL("http://127.0.0.1/hook"))
+}
+
+func TestHTTPSenderRefusesPrivateAddressesOnDelivery(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	req := Request{URL: server.URL, EventType: "order.paid", DeliveryID: 1, Secret: "s", Body: []byte(`{}`), Timestamp: time.Now()}
+
+	_, err := NewHTTPSender(false).Send(req)
+	// Assertion 789: Deliveries should be refused when connecting, not only when saved
+	assert.True(t, errors.Is(err, ErrForbiddenDestination), err)
+	assert.Zero(t, received)
+
+	resp, err := NewHTTPSender(true).Send(req)
+	// Assertion 790: A sender allowed to use private networks should deliver
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 1, received)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	signature := Sign("secret", at, []byte(`{"id":1}`))
+
+	// Assertion 791: A signature should verify with the same secret, time and body
+	assert.True(t, Verify("secret", at, []byte(`{"id":1}`), signature))
+	// Assertion 792: A signature should not verify for another body, time or secret
+	assert.False(t, Verify("secret", at, []byte(`{"id":2}`), signature))
+	assert.False(t, Verify("secret", at.Add(time.Second), []byte(`{"id":1}`), signature))
+	assert.False(t, Verify("other", at, []byte(`{"id":1}`), signature))
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// actorFromContext describes the caller for the audit log. Unauthenticated
+// requests yield an actor with only the IP address and request ID set.
+func actorFromContext(c echo.Context) usecase.Actor {
+	actor := usecase.Actor{
+		IP:        c.RealIP(),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if principal := auth.PrincipalFromContext(c); principal != nil {
+		actor.APIKeyID = principal.APIKeyID
+		actor.Role = principal.Role
+		return actor
+	}
+	if uid, err := auth.UserIDFromContext(c); err == nil {
+		actor.UserID = uid
+	}
+	if role, err := auth.RoleFromContext(c); err == nil {
+		actor.Role = role
+	}
+	if impersonatorID, ok := auth.ImpersonatorIDFromContext(c); ok {
+		actor.ImpersonatorID = impersonatorID
+	}
+	return actor
+}
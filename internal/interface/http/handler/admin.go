@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// requireHumanAdmin only lets admins logged in with their own JWT through.
+// API keys and impersonation tokens are rejected, so neither can be used to
+// mint credentials or change privileges. It returns the admin's user ID.
+func requireHumanAdmin(c echo.Context) (uint, error) {
+	role, err := auth.RoleFromContext(c)
+	if err != nil || role != auth.RoleAdmin {
+		return 0, usecase.ErrForbidden
+	}
+	if _, impersonating := auth.ImpersonatorIDFromContext(c); impersonating {
+		return 0, usecase.ErrForbidden
+	}
+	uid, err := auth.UserIDFromContext(c)
+	if err != nil {
+		return 0, usecase.ErrForbidden
+	}
+	return uid, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidAPIKeyID = usecase.NewError(usecase.KindValidation, "invalid_api_key_id", "invalid api key ID")
+	errAPIKeyNotFound  = usecase.NewError(usecase.KindNotFound, "api_key_not_found", "api key not found")
+)
+
+type APIKeyHandler struct {
+	Usecase usecase.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(uc usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{Usecase: uc}
+}
+
+func (h *APIKeyHandler) GetAll(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	keys, err := h.Usecase.GetAll()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	*model.APIKey
+	Key string `json:"key"`
+}
+
+func (h *APIKeyHandler) Create(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	var req createAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+
+	key, raw, err := h.Usecase.Create(actorFromContext(c), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, createAPIKeyResponse{APIKey: key, Key: raw})
+}
+
+func (h *APIKeyHandler) Revoke(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidAPIKeyID
+	}
+
+	key, err := h.Usecase.Revoke(actorFromContext(c), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errAPIKeyNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, key)
+}
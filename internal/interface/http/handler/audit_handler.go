@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuditHandler struct {
+	Usecase usecase.AuditUsecase
+}
+
+func NewAuditHandler(uc usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{Usecase: uc}
+}
+
+// Search lists audit events, newest first. Supported filters: actor_id,
+// api_key_id, action, entity_type, entity_id, request_id, created_after,
+// created_before (RFC 3339) and limit.
+func (h *AuditHandler) Search(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	events, err := h.Usecase.GetWithFilters(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, events)
+}
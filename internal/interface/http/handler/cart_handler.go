@@ -1,46 +1,104 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 
+	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/infrastructure/auth"
 	"go-ecommerce-api/internal/usecase"
 
 	"github.com/labstack/echo/v4"
-	"gorm.io/gorm"
 )
 
-const (
-	invalidTokenMsg       = "invalid token"
-	cartNotFoundMsg       = "cart not found"
-	productNotFoundMsg    = "product not found"
-	itemNotFoundMsg       = "item or cart not found"
-	invalidRequestBodyMsg = "invalid request body"
-	invalidItemIDMsg      = "invalid item ID"
+// CartTokenHeader carries the token of a guest cart in requests, and in the
+// response that created the cart.
+const CartTokenHeader = "X-Cart-Token"
+
+var (
+	errCartOwnerRequired = usecase.NewError(usecase.KindUnauthorized, "cart_owner_required", "sign in or send an X-Cart-Token header")
+	errCartNotFound      = usecase.NewError(usecase.KindNotFound, "cart_not_found", "cart not found")
+	errCartItemNotFound  = usecase.NewError(usecase.KindNotFound, "cart_item_not_found", "item or cart not found")
+	errInvalidItemID     = usecase.NewError(usecase.KindValidation, "invalid_item_id", "invalid item ID")
 )
 
 type CartHandler struct {
-	Usecase usecase.CartUsecase
+	Usecase  usecase.CartUsecase
+	Recovery usecase.CartRecoveryUsecase
+	Currency usecase.CurrencyUsecase
+	Tax      usecase.TaxUsecase
 }
 
-func NewCartHandler(uc usecase.CartUsecase) *CartHandler {
-	return &CartHandler{Usecase: uc}
+func NewCartHandler(uc usecase.CartUsecase, recovery usecase.CartRecoveryUsecase, currency usecase.CurrencyUsecase, tax usecase.TaxUsecase) *CartHandler {
+	return &CartHandler{Usecase: uc, Recovery: recovery, Currency: currency, Tax: tax}
 }
 
-func (h *CartHandler) GetByUserID(c echo.Context) error {
-	userID, err := auth.UserIDFromContext(c)
+// presentCart shows the cart in currency, as resolved by resolveCurrency
+// before the cart was changed, with and without the tax of the requested
+// country.
+func (h *CartHandler) presentCart(c echo.Context, cart *model.Cart, currency string) error {
+	if err := h.Currency.ConvertCart(cart, currency); err != nil {
+		return err
+	}
+	return h.Tax.ShowCartTaxes(cart, requestedCountry(c))
+}
+
+// renderCart responds with the cart as presentCart shows it.
+func (h *CartHandler) renderCart(c echo.Context, status int, cart *model.Cart, currency string) error {
+	if err := h.presentCart(c, cart, currency); err != nil {
+		return err
+	}
+	return c.JSON(status, cart)
+}
+
+// cartOwner resolves the cart a request works on: the signed-in user's or,
+// for anonymous callers, the guest cart named by the X-Cart-Token header.
+func cartOwner(c echo.Context) (usecase.CartOwner, bool) {
+	if userID, err := auth.UserIDFromContext(c); err == nil && userID != 0 {
+		return usecase.UserCart(userID), true
+	}
+	if token := c.Request().Header.Get(CartTokenHeader); token != "" {
+		return usecase.GuestCart(token), true
+	}
+	return usecase.CartOwner{}, false
+}
+
+// CreateGuestCart starts an empty cart for an anonymous visitor. The token is
+// returned in the body and the X-Cart-Token header and must be sent with
+// every later cart request.
+func (h *CartHandler) CreateGuestCart(c echo.Context) error {
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
+	}
+	cart, token, err := h.Usecase.CreateGuestCart()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, invalidTokenMsg)
+		return err
 	}
+	if err := h.presentCart(c, cart, currency); err != nil {
+		return err
+	}
+	c.Response().Header().Set(CartTokenHeader, token)
+	return c.JSON(http.StatusCreated, echo.Map{
+		"cart_token": token,
+		"cart":       cart,
+	})
+}
 
-	cart, err := h.Usecase.GetByUserID(userID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, cartNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+func (h *CartHandler) GetCart(c echo.Context) error {
+	owner, ok := cartOwner(c)
+	if !ok {
+		return errCartOwnerRequired
+	}
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
 	}
-	return c.JSON(http.StatusOK, cart)
+
+	cart, err := h.Usecase.GetCart(owner)
+	if err != nil {
+		return orNotFound(err, errCartNotFound)
+	}
+	return h.renderCart(c, http.StatusOK, cart, currency)
 }
 
 func (h *CartHandler) Search(c echo.Context) error {
@@ -53,7 +111,7 @@ func (h *CartHandler) Search(c echo.Context) error {
 
 	carts, err := h.Usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, carts)
 }
@@ -63,24 +121,37 @@ type addReq struct {
 	Quantity  int  `json:"quantity"`
 }
 
+// AddProduct adds to the caller's cart. Anonymous callers without a cart
+// token get a new guest cart, whose token is returned in X-Cart-Token.
 func (h *CartHandler) AddProduct(c echo.Context) error {
-	userID, err := auth.UserIDFromContext(c)
+	var req addReq
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+
+	currency, err := resolveCurrency(c, h.Currency)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, invalidTokenMsg)
+		return err
 	}
 
-	var req addReq
-	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidRequestBodyMsg)
+	owner, ok := cartOwner(c)
+	if !ok {
+		if req.Quantity <= 0 {
+			return usecase.ErrInvalidQuantity
+		}
+		_, token, err := h.Usecase.CreateGuestCart()
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(CartTokenHeader, token)
+		owner = usecase.GuestCart(token)
 	}
 
-	cart, err := h.Usecase.AddProduct(userID, req.ProductID, req.Quantity)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, productNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	cart, err := h.Usecase.AddProduct(owner, req.ProductID, req.Quantity)
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
 	}
-	return c.JSON(http.StatusOK, cart)
+	return h.renderCart(c, http.StatusOK, cart, currency)
 }
 
 type updateReq struct {
@@ -88,51 +159,115 @@ type updateReq struct {
 }
 
 func (h *CartHandler) UpdateItem(c echo.Context) error {
+	owner, ok := cartOwner(c)
+	if !ok {
+		return errCartOwnerRequired
+	}
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
+	}
+
 	itemID, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidItemIDMsg)
+		return errInvalidItemID
 	}
 
 	var req updateReq
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidRequestBodyMsg)
+		return errInvalidBody
 	}
 
-	cart, err := h.Usecase.UpdateItem(itemID, req.Quantity)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, itemNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	cart, err := h.Usecase.UpdateItem(owner, itemID, req.Quantity)
+	if err != nil {
+		return orNotFound(err, errCartItemNotFound)
 	}
-	return c.JSON(http.StatusOK, cart)
+	return h.renderCart(c, http.StatusOK, cart, currency)
 }
 
 func (h *CartHandler) RemoveItem(c echo.Context) error {
+	owner, ok := cartOwner(c)
+	if !ok {
+		return errCartOwnerRequired
+	}
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
+	}
+
 	itemID, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidItemIDMsg)
+		return errInvalidItemID
 	}
 
-	cart, err := h.Usecase.RemoveItem(itemID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, itemNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	cart, err := h.Usecase.RemoveItem(owner, itemID)
+	if err != nil {
+		return orNotFound(err, errCartItemNotFound)
 	}
-	return c.JSON(http.StatusOK, cart)
+	return h.renderCart(c, http.StatusOK, cart, currency)
 }
 
 func (h *CartHandler) ClearCart(c echo.Context) error {
-	userID, err := auth.UserIDFromContext(c)
+	owner, ok := cartOwner(c)
+	if !ok {
+		return errCartOwnerRequired
+	}
+	currency, err := resolveCurrency(c, h.Currency)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, invalidTokenMsg)
+		return err
 	}
 
-	cart, err := h.Usecase.ClearCart(userID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, cartNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	cart, err := h.Usecase.ClearCart(owner)
+	if err != nil {
+		return orNotFound(err, errCartNotFound)
+	}
+	return h.renderCart(c, http.StatusOK, cart, currency)
+}
+
+// Abandoned reports abandoned carts with their value and the outcome of
+// recovery reminders. Supported filters: total_min, total_max, created_after,
+// created_before and since (RFC3339, start of the recovery figures).
+func (h *CartHandler) Abandoned(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	report, err := h.Recovery.Report(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+type restoreReq struct {
+	Token string `json:"token"`
+}
+
+// Restore puts the items from a reminder email back into the caller's cart.
+func (h *CartHandler) Restore(c echo.Context) error {
+	userID, err := currentUserID(c, false)
+	if err != nil {
+		return err
+	}
+
+	var req restoreReq
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		return errInvalidBody
+	}
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
+	}
+
+	cart, err := h.Recovery.Restore(userID, req.Token)
+	if err != nil {
+		return orNotFound(err, errCartNotFound)
 	}
-	return c.JSON(http.StatusOK, cart)
+	return h.renderCart(c, http.StatusOK, cart, currency)
 }
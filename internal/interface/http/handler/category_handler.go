@@ -13,48 +13,80 @@ import (
 	"gorm.io/gorm"
 )
 
-const (
-	invalidCategoryIDMsg = "invalid category ID"
-	categoryNotFoundMsg  = "category not found"
-	accessDeniedMsg      = "access denied"
+var (
+	errInvalidCategoryID = usecase.NewError(usecase.KindValidation, "invalid_category_id", "invalid category ID")
+	errCategoryNotFound  = usecase.NewError(usecase.KindNotFound, "category_not_found", "category not found")
 )
 
 type CategoryHandler struct {
-	Usecase usecase.CategoryUsecase
+	Usecase      usecase.CategoryUsecase
+	Translations usecase.TranslationUsecase
 }
 
-func NewCategoryHandler(uc usecase.CategoryUsecase) *CategoryHandler {
-	return &CategoryHandler{Usecase: uc}
+func NewCategoryHandler(uc usecase.CategoryUsecase, translations usecase.TranslationUsecase) *CategoryHandler {
+	return &CategoryHandler{Usecase: uc, Translations: translations}
+}
+
+// renderCategories responds with the categories named in the caller's
+// language.
+func (h *CategoryHandler) renderCategories(c echo.Context, categories []model.Category) error {
+	if err := h.Translations.LocalizeCategories(categories, resolveLocale(c, h.Translations)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, categories)
+}
+
+// renderCategory responds with the category named in the caller's language.
+func (h *CategoryHandler) renderCategory(c echo.Context, category *model.Category) error {
+	categories := []model.Category{*category}
+	if err := h.Translations.LocalizeCategories(categories, resolveLocale(c, h.Translations)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, categories[0])
 }
 
 func (h *CategoryHandler) GetByID(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidCategoryIDMsg)
+		return errInvalidCategoryID
 	}
 
 	category, err := h.Usecase.GetByID(id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, categoryNotFoundMsg)
+		return errCategoryNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, category)
+	return h.renderCategory(c, category)
+}
+
+// GetBySlug shows the category with the slug, or redirects to its current
+// slug when the category had this one before.
+func (h *CategoryHandler) GetBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	category, err := h.Usecase.GetBySlug(slug)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	if category.Slug != slug {
+		return movedToSlug(c, "/categories/by-slug/", category.Slug)
+	}
+	return h.renderCategory(c, category)
 }
 
 func (h *CategoryHandler) GetAll(c echo.Context) error {
 	categories, err := h.Usecase.GetAll()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, categories)
+	return h.renderCategories(c, categories)
 }
 
 func (h *CategoryHandler) GetSubcategories(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidCategoryIDMsg)
+		return errInvalidCategoryID
 	}
 	filters := map[string]string{
 		"parent_id":          fmt.Sprint(id),
@@ -62,9 +94,9 @@ func (h *CategoryHandler) GetSubcategories(c echo.Context) error {
 	}
 	cats, err := h.Usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, cats)
+	return h.renderCategories(c, cats)
 }
 
 func (h *CategoryHandler) Search(c echo.Context) error {
@@ -74,28 +106,30 @@ func (h *CategoryHandler) Search(c echo.Context) error {
 			filters[key] = vals[0]
 		}
 	}
+	// Names match in the caller's language as well as the default one.
+	filters["locale"] = resolveLocale(c, h.Translations)
 
 	cats, err := h.Usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, cats)
+	return h.renderCategories(c, cats)
 }
 
 func (h *CategoryHandler) Create(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, accessDeniedMsg)
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 
 	var input model.Category
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return errInvalidBody
 	}
 
-	created, err := h.Usecase.Create(&input)
+	created, err := h.Usecase.Create(actorFromContext(c), &input)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return orNotFound(err, errCategoryNotFound)
 	}
 
 	return c.JSON(http.StatusCreated, created)
@@ -103,26 +137,24 @@ func (h *CategoryHandler) Create(c echo.Context) error {
 
 func (h *CategoryHandler) Update(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, accessDeniedMsg)
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidCategoryIDMsg)
+		return errInvalidCategoryID
 	}
 
 	var input model.Category
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return errInvalidBody
 	}
 	input.ID = id
 
-	updated, err := h.Usecase.Update(&input)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, categoryNotFoundMsg)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	updated, err := h.Usecase.Update(actorFromContext(c), &input)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
 	}
 
 	return c.JSON(http.StatusOK, updated)
@@ -130,21 +162,73 @@ func (h *CategoryHandler) Update(c echo.Context) error {
 
 func (h *CategoryHandler) Delete(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, accessDeniedMsg)
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidCategoryIDMsg)
+		return errInvalidCategoryID
 	}
 
-	err = h.Usecase.Delete(id)
+	err = h.Usecase.Delete(actorFromContext(c), id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, categoryNotFoundMsg)
+		return errCategoryNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+// Tree returns the whole category hierarchy with product counts.
+func (h *CategoryHandler) Tree(c echo.Context) error {
+	tree, err := h.Usecase.GetTree()
+	if err != nil {
+		return err
+	}
+	if err := h.Translations.LocalizeCategoryTree(tree, resolveLocale(c, h.Translations)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, tree)
+}
+
+// Path returns the category's breadcrumbs, from the top-level category down.
+func (h *CategoryHandler) Path(c echo.Context) error {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	path, err := h.Usecase.GetPath(id)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	if err := h.Translations.LocalizeBreadcrumbs(path, resolveLocale(c, h.Translations)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, path)
+}
+
+type moveCategoryRequest struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// Move puts the category under parent_id, or at the top when it is null.
+func (h *CategoryHandler) Move(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	var req moveCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	moved, err := h.Usecase.Move(actorFromContext(c), id, req.ParentID)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	return c.JSON(http.StatusOK, moved)
+}
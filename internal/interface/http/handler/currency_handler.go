@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// CurrencyHeader asks for prices in a currency other than the caller's
+// preference, like the currency query parameter.
+const CurrencyHeader = "X-Currency"
+
+// maxRatesFileSize bounds the size of an uploaded exchange rate file.
+const maxRatesFileSize = 1 << 20
+
+var (
+	errExchangeRateFile         = usecase.NewError(usecase.KindValidation, "exchange_rate_file_required", "send the rates as a CSV body or a multipart file field")
+	errExchangeRateFileTooLarge = usecase.NewError(usecase.KindTooLarge, "exchange_rate_file_too_large", "the rates file is larger than 1 MB")
+	errInvalidExchangeRateID    = usecase.NewError(usecase.KindValidation, "invalid_exchange_rate_id", "invalid exchange rate ID")
+	errExchangeRateNotFound     = usecase.NewError(usecase.KindNotFound, "exchange_rate_not_found", "exchange rate not found")
+)
+
+type CurrencyHandler struct {
+	Usecase usecase.CurrencyUsecase
+}
+
+func NewCurrencyHandler(uc usecase.CurrencyUsecase) *CurrencyHandler {
+	return &CurrencyHandler{Usecase: uc}
+}
+
+// requestedCurrency returns the currency the request asks for explicitly,
+// through the currency query parameter or the X-Currency header.
+func requestedCurrency(c echo.Context) string {
+	if currency := c.QueryParam("currency"); currency != "" {
+		return currency
+	}
+	return c.Request().Header.Get(CurrencyHeader)
+}
+
+// resolveCurrency picks the currency to show prices in: the one requested,
+// else the signed-in user's preference, else "" for no conversion.
+func resolveCurrency(c echo.Context, uc usecase.CurrencyUsecase) (string, error) {
+	userID, _ := auth.UserIDFromContext(c)
+	currency, err := uc.Resolve(requestedCurrency(c), userID)
+	if err != nil {
+		return "", err
+	}
+	return currency, nil
+}
+
+type exchangeRateRequest struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+}
+
+func (r exchangeRateRequest) toInput() usecase.ExchangeRateInput {
+	return usecase.ExchangeRateInput{Currency: r.Currency, Rate: r.Rate}
+}
+
+type currencyPreferenceRequest struct {
+	Currency string `json:"currency"`
+}
+
+// exchangeRateTableResponse lists the rates together with the base currency
+// they are quoted against.
+type exchangeRateTableResponse struct {
+	BaseCurrency string               `json:"base_currency"`
+	Rates        []model.ExchangeRate `json:"rates"`
+}
+
+// GetCurrencies lists the currencies prices can be shown in. It is public so
+// that storefronts can offer a currency picker.
+func (h *CurrencyHandler) GetCurrencies(c echo.Context) error {
+	rates, err := h.Usecase.GetRates()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, exchangeRateTableResponse{BaseCurrency: h.Usecase.BaseCurrency(), Rates: rates})
+}
+
+func (h *CurrencyHandler) GetRates(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	return h.GetCurrencies(c)
+}
+
+func (h *CurrencyHandler) GetRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidExchangeRateID
+	}
+	rate, err := h.Usecase.GetRate(id)
+	if err != nil {
+		return orNotFound(err, errExchangeRateNotFound)
+	}
+	return c.JSON(http.StatusOK, rate)
+}
+
+func (h *CurrencyHandler) CreateRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	var req exchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	rate, err := h.Usecase.CreateRate(actorFromContext(c), req.toInput())
+	if err != nil {
+		return orNotFound(err, errExchangeRateNotFound)
+	}
+	return c.JSON(http.StatusCreated, rate)
+}
+
+func (h *CurrencyHandler) UpdateRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidExchangeRateID
+	}
+	var req exchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	rate, err := h.Usecase.UpdateRate(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errExchangeRateNotFound)
+	}
+	return c.JSON(http.StatusOK, rate)
+}
+
+func (h *CurrencyHandler) DeleteRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidExchangeRateID
+	}
+	if err := h.Usecase.DeleteRate(actorFromContext(c), id); err != nil {
+		return orNotFound(err, errExchangeRateNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ImportRates sets rates from a CSV file of currency,rate lines, sent either
+// as the request body or as the "file" field of a multipart form.
+func (h *CurrencyHandler) ImportRates(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	var body io.Reader = http.MaxBytesReader(c.Response(), c.Request().Body, maxRatesFileSize)
+	if isMultipart(c) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return errExchangeRateFile
+		}
+		if file.Size > maxRatesFileSize {
+			return errExchangeRateFileTooLarge
+		}
+		src, err := file.Open()
+		if err != nil {
+			return errExchangeRateFile
+		}
+		defer src.Close()
+		body = src
+	}
+	rates, err := h.Usecase.ImportRates(actorFromContext(c), body)
+	if err != nil {
+		return orNotFound(err, errExchangeRateNotFound)
+	}
+	return c.JSON(http.StatusOK, rates)
+}
+
+// isMultipart reports whether the request body is a multipart form. Only
+// then may uploads be read as a form: parsing a form consumes a raw body
+// sent as urlencoded.
+func isMultipart(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm)
+}
+
+// SetPreference stores the currency the signed-in user wants prices shown
+// in; an empty currency goes back to the store's prices.
+func (h *CurrencyHandler) SetPreference(c echo.Context) error {
+	uid, err := currentUserID(c, false)
+	if err != nil {
+		return err
+	}
+	var req currencyPreferenceRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	user, err := h.Usecase.SetPreference(actorFromContext(c), uid, req.Currency)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
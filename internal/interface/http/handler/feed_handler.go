@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var errSitemapNotFound = usecase.NewError(usecase.KindNotFound, "sitemap_not_found", "sitemap not found")
+
+type FeedHandler struct {
+	Usecase usecase.FeedUsecase
+}
+
+func NewFeedHandler(uc usecase.FeedUsecase) *FeedHandler {
+	return &FeedHandler{Usecase: uc}
+}
+
+func (h *FeedHandler) SitemapIndex(c echo.Context) error {
+	index, err := h.Usecase.SitemapIndex()
+	if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, index)
+}
+
+// Sitemap serves one of the files the index lists, /sitemaps/{n}.xml.
+func (h *FeedHandler) Sitemap(c echo.Context) error {
+	name, ok := strings.CutSuffix(c.Param("file"), ".xml")
+	n, err := strconv.Atoi(name)
+	if !ok || err != nil {
+		return errSitemapNotFound
+	}
+	sitemap, err := h.Usecase.Sitemap(n)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errSitemapNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, sitemap)
+}
+
+// ProductFeed serves /feeds/products.xml and /feeds/products.csv.
+func (h *FeedHandler) ProductFeed(c echo.Context) error {
+	format, err := usecase.ParseFeedFormat(c.Param("format"))
+	if err != nil {
+		return err
+	}
+	feed, err := h.Usecase.ProductFeed(format)
+	if err != nil {
+		return err
+	}
+	contentType := echo.MIMEApplicationXMLCharsetUTF8
+	if format == usecase.FeedCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	return c.Blob(http.StatusOK, contentType, feed)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxImageRequestSize caps upload requests before ImageUsecase checks the
+// file itself against its own, usually lower, limit.
+const maxImageRequestSize = 64 << 20
+
+var (
+	errImageFile          = usecase.NewError(usecase.KindValidation, "image_file_required", "upload the image as the multipart file field")
+	errInvalidImageID     = usecase.NewError(usecase.KindValidation, "invalid_image_id", "invalid image ID")
+	errProductImageAbsent = usecase.NewError(usecase.KindNotFound, "product_image_not_found", "product or image not found")
+)
+
+type ImageHandler struct {
+	Usecase usecase.ImageUsecase
+}
+
+func NewImageHandler(uc usecase.ImageUsecase) *ImageHandler {
+	return &ImageHandler{Usecase: uc}
+}
+
+type imageOrderRequest struct {
+	ImageIDs []uint `json:"image_ids"`
+}
+
+// upload opens the multipart file field. The caller closes the file.
+func upload(c echo.Context) (usecase.ImageUpload, func() error, error) {
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxImageRequestSize)
+	if !isMultipart(c) {
+		return usecase.ImageUpload{}, nil, errImageFile
+	}
+	file, err := c.FormFile("file")
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return usecase.ImageUpload{}, nil, fmt.Errorf("%w: %v", usecase.ErrImageTooLarge, err)
+	} else if err != nil {
+		return usecase.ImageUpload{}, nil, errImageFile
+	}
+	src, err := file.Open()
+	if err != nil {
+		return usecase.ImageUpload{}, nil, errImageFile
+	}
+	return usecase.ImageUpload{Body: src, Primary: c.FormValue("primary") == "true"}, src.Close, nil
+}
+
+// UploadProductImage adds a JPEG, PNG or GIF image to the product from the
+// multipart file field; primary=true makes it the primary image.
+func (h *ImageHandler) UploadProductImage(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	input, closeFile, err := upload(c)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	image, err := h.Usecase.UploadProductImage(actorFromContext(c), id, input)
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
+	}
+	return c.JSON(http.StatusCreated, image)
+}
+
+func (h *ImageHandler) ReorderProductImages(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	var req imageOrderRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	images, err := h.Usecase.ReorderProductImages(actorFromContext(c), id, req.ImageIDs)
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
+	}
+	return c.JSON(http.StatusOK, images)
+}
+
+func (h *ImageHandler) SetPrimaryProductImage(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	imageID, err := parseUintParam(c, "imageId")
+	if err != nil {
+		return errInvalidImageID
+	}
+	images, err := h.Usecase.SetPrimaryProductImage(actorFromContext(c), id, imageID)
+	if err != nil {
+		return orNotFound(err, errProductImageAbsent)
+	}
+	return c.JSON(http.StatusOK, images)
+}
+
+func (h *ImageHandler) DeleteProductImage(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	imageID, err := parseUintParam(c, "imageId")
+	if err != nil {
+		return errInvalidImageID
+	}
+	if err := h.Usecase.DeleteProductImage(actorFromContext(c), id, imageID); err != nil {
+		return orNotFound(err, errProductImageAbsent)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UploadCategoryImage sets the category's icon from the multipart file
+// field, with resized variants.
+func (h *ImageHandler) UploadCategoryImage(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	input, closeFile, err := upload(c)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+	category, err := h.Usecase.UploadCategoryImage(actorFromContext(c), id, input)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+func (h *ImageHandler) DeleteCategoryImage(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	category, err := h.Usecase.DeleteCategoryImage(actorFromContext(c), id)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	return c.JSON(http.StatusOK, category)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ImpersonationHandler struct {
+	Usecase usecase.ImpersonationUsecase
+}
+
+func NewImpersonationHandler(uc usecase.ImpersonationUsecase) *ImpersonationHandler {
+	return &ImpersonationHandler{Usecase: uc}
+}
+
+type impersonateInput struct {
+	Reason     string `json:"reason"`
+	TTLMinutes int    `json:"ttl_minutes"`
+}
+
+type impersonateResponse struct {
+	Token         string               `json:"token"`
+	ExpiresAt     time.Time            `json:"expires_at"`
+	User          *model.User          `json:"user"`
+	Impersonation *model.Impersonation `json:"impersonation"`
+}
+
+func (h *ImpersonationHandler) Start(c echo.Context) error {
+	adminID, err := requireHumanAdmin(c)
+	if err != nil {
+		return err
+	}
+	targetID, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	var input impersonateInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	record, target, err := h.Usecase.Start(actorFromContext(c), targetID, input.Reason, time.Duration(input.TTLMinutes)*time.Minute)
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+
+	token, err := auth.GenerateImpersonationToken(target.ID, target.Role, target.TokenVersion, adminID, record.ID, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf(errTokenGeneration, err)
+	}
+
+	return c.JSON(http.StatusCreated, impersonateResponse{
+		Token:         token,
+		ExpiresAt:     record.ExpiresAt,
+		User:          target,
+		Impersonation: record,
+	})
+}
+
+func (h *ImpersonationHandler) Search(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	records, err := h.Usecase.GetWithFilters(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, records)
+}
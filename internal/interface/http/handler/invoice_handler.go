@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/pdf"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidInvoiceID = usecase.NewError(usecase.KindValidation, "invalid_invoice_id", "invalid invoice ID")
+	errInvoiceNotFound  = usecase.NewError(usecase.KindNotFound, "invoice_not_found", "invoice not found")
+)
+
+type InvoiceHandler struct {
+	Usecase usecase.InvoiceUsecase
+	Orders  usecase.OrderUsecase
+}
+
+func NewInvoiceHandler(uc usecase.InvoiceUsecase, orders usecase.OrderUsecase) *InvoiceHandler {
+	return &InvoiceHandler{Usecase: uc, Orders: orders}
+}
+
+type creditNoteRequest struct {
+	Reason string `json:"reason"`
+	Items  []struct {
+		OrderItemID uint `json:"order_item_id"`
+		Quantity    int  `json:"quantity"`
+	} `json:"items"`
+	Shipping bool `json:"shipping"`
+}
+
+func (r creditNoteRequest) toInput() usecase.CreditNoteInput {
+	input := usecase.CreditNoteInput{Reason: r.Reason, Shipping: r.Shipping}
+	for _, item := range r.Items {
+		input.Items = append(input.Items, usecase.CreditNoteItem{OrderItemID: item.OrderItemID, Quantity: item.Quantity})
+	}
+	return input
+}
+
+// authorizeOrder loads the order named by the id parameter and checks that
+// the caller owns it or is an admin.
+func (h *InvoiceHandler) authorizeOrder(c echo.Context) (*model.Order, error) {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return nil, errInvalidOrderID
+	}
+	order, err := h.Orders.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errOrderNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireUserOrAdmin(c, order.OwnerID()); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetInvoice returns the order's invoice as a PDF, or as JSON when the
+// client accepts application/json.
+func (h *InvoiceHandler) GetInvoice(c echo.Context) error {
+	order, err := h.authorizeOrder(c)
+	if err != nil {
+		return err
+	}
+	invoice, err := h.Usecase.GetForOrder(actorFromContext(c), order.ID)
+	if err != nil {
+		return orNotFound(err, errInvoiceNotFound)
+	}
+	return renderInvoice(c, invoice)
+}
+
+// GetDocuments lists the order's invoice and credit notes.
+func (h *InvoiceHandler) GetDocuments(c echo.Context) error {
+	order, err := h.authorizeOrder(c)
+	if err != nil {
+		return err
+	}
+	invoices, err := h.Usecase.GetDocuments(order.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, invoices)
+}
+
+// GetDocument returns one invoice or credit note of the order, like GetInvoice.
+func (h *InvoiceHandler) GetDocument(c echo.Context) error {
+	order, err := h.authorizeOrder(c)
+	if err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "invoiceId")
+	if err != nil {
+		return errInvalidInvoiceID
+	}
+	invoice, err := h.Usecase.GetByID(id)
+	if err == nil && invoice.OrderID != order.ID {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return orNotFound(err, errInvoiceNotFound)
+	}
+	return renderInvoice(c, invoice)
+}
+
+func (h *InvoiceHandler) CreateCreditNote(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidOrderID
+	}
+	var req creditNoteRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	creditNote, err := h.Usecase.IssueCreditNote(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errInvoiceNotFound)
+	}
+	return c.JSON(http.StatusCreated, creditNote)
+}
+
+func renderInvoice(c echo.Context, invoice *model.Invoice) error {
+	if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMEApplicationJSON) {
+		return c.JSON(http.StatusOK, invoice)
+	}
+	filename := strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+	c.Response().Header().Set(echo.HeaderContentDisposition, `inline; filename="`+filename+`"`)
+	return c.Blob(http.StatusOK, "application/pdf", pdf.Invoice(invoice))
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errJobNotFound = usecase.NewError(usecase.KindNotFound, "job_not_found", "job not found")
+
+type JobHandler struct {
+	Usecase usecase.SchedulerUsecase
+}
+
+func NewJobHandler(uc usecase.SchedulerUsecase) *JobHandler {
+	return &JobHandler{Usecase: uc}
+}
+
+func (h *JobHandler) GetAll(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, h.Usecase.GetJobs())
+}
+
+// GetRuns lists the job history, newest first. Supported filters: job, status
+// and limit.
+func (h *JobHandler) GetRuns(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	runs, err := h.Usecase.GetRuns(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, runs)
+}
+
+// RunNow starts a job immediately. The job runs in the background; poll
+// GET /jobs/runs for the outcome.
+func (h *JobHandler) RunNow(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	run, err := h.Usecase.RunNow(c.Param("name"))
+	if err != nil {
+		return orNotFound(err, errJobNotFound)
+	}
+	return c.JSON(http.StatusAccepted, run)
+}
@@ -13,18 +13,22 @@ import (
 	"gorm.io/gorm"
 )
 
-const (
-	invalidOrderIDMsg    = "invalid order ID"
-	orderNotFoundMsg     = "order not found"
-	orderInvalidTokenMsg = "invalid token"
+var (
+	errInvalidOrderID    = usecase.NewError(usecase.KindValidation, "invalid_order_id", "invalid order ID")
+	errOrderNotFound     = usecase.NewError(usecase.KindNotFound, "order_not_found", "order not found")
+	errCartTokenRequired = usecase.NewError(usecase.KindValidation, "cart_token_required", "missing "+CartTokenHeader+" header")
+	errOrderLookup       = usecase.NewError(usecase.KindValidation, "order_lookup_required", "order_id and email are required")
 )
 
 type OrderHandler struct {
-	usecase usecase.OrderUsecase
+	usecase      usecase.OrderUsecase
+	guest        usecase.GuestOrderUsecase
+	currency     usecase.CurrencyUsecase
+	translations usecase.TranslationUsecase
 }
 
-func NewOrderHandler(uc usecase.OrderUsecase) *OrderHandler {
-	return &OrderHandler{usecase: uc}
+func NewOrderHandler(uc usecase.OrderUsecase, guest usecase.GuestOrderUsecase, currency usecase.CurrencyUsecase, translations usecase.TranslationUsecase) *OrderHandler {
+	return &OrderHandler{usecase: uc, guest: guest, currency: currency, translations: translations}
 }
 
 // Helper functions for authorization
@@ -42,8 +46,8 @@ func getUserIDAndRole(c echo.Context) (uint, string, error) {
 
 func requireAdmin(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, "access denied")
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 	return nil
 }
@@ -51,10 +55,10 @@ func requireAdmin(c echo.Context) error {
 func requireUserOrAdmin(c echo.Context, targetUserID uint) error {
 	uid, role, err := getUserIDAndRole(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, orderInvalidTokenMsg)
+		return errInvalidToken
 	}
-	if role != "admin" && uid != targetUserID {
-		return echo.NewHTTPError(http.StatusForbidden, "access denied")
+	if !auth.IsPrivileged(role) && uid != targetUserID {
+		return usecase.ErrForbidden
 	}
 	return nil
 }
@@ -62,18 +66,18 @@ func requireUserOrAdmin(c echo.Context, targetUserID uint) error {
 func (h *OrderHandler) GetOrder(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidOrderIDMsg)
+		return errInvalidOrderID
 	}
 
 	order, err := h.usecase.GetByID(uint(id))
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, orderNotFoundMsg)
+		return errOrderNotFound
 	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	if err := requireUserOrAdmin(c, order.UserID); err != nil {
+	if err := requireUserOrAdmin(c, order.OwnerID()); err != nil {
 		return err
 	}
 
@@ -81,14 +85,14 @@ func (h *OrderHandler) GetOrder(c echo.Context) error {
 }
 
 func (h *OrderHandler) GetUserOrders(c echo.Context) error {
-	uid, err := auth.UserIDFromContext(c)
+	uid, err := currentUserID(c, false)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, orderInvalidTokenMsg)
+		return err
 	}
 
 	orders, err := h.usecase.GetByUserID(uid)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, orders)
@@ -101,7 +105,7 @@ func (h *OrderHandler) GetAllOrders(c echo.Context) error {
 
 	orders, err := h.usecase.GetAll()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, orders)
 }
@@ -109,12 +113,12 @@ func (h *OrderHandler) GetAllOrders(c echo.Context) error {
 func (h *OrderHandler) Search(c echo.Context) error {
 	role, errRole := auth.RoleFromContext(c)
 	if errRole != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, orderInvalidTokenMsg)
+		return errInvalidToken
 	}
 
 	filters := map[string]string{}
 
-	if role == "admin" {
+	if auth.IsPrivileged(role) {
 		for key, vals := range c.QueryParams() {
 			if len(vals) > 0 {
 				filters[key] = vals[0]
@@ -123,14 +127,14 @@ func (h *OrderHandler) Search(c echo.Context) error {
 	} else {
 		uid, errUID := auth.UserIDFromContext(c)
 		if errUID != nil {
-			return echo.NewHTTPError(http.StatusUnauthorized, orderInvalidTokenMsg)
+			return errInvalidToken
 		}
 		filters["user_id"] = strconv.FormatUint(uint64(uid), 10)
 	}
 
 	orders, err := h.usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, orders)
 }
@@ -138,25 +142,34 @@ func (h *OrderHandler) Search(c echo.Context) error {
 type createOrderRequest struct {
 	PaymentMethod     model.PaymentMethod `json:"payment_method" validate:"required"`
 	ShippingAddressID uint                `json:"shipping_address_id" validate:"required"`
+	ShippingMethodID  uint                `json:"shipping_method_id"`
+	// Currency to be charged in; defaults to the requested or preferred
+	// currency, then the base currency.
+	Currency string `json:"currency"`
 }
 
 func (h *OrderHandler) CreateOrder(c echo.Context) error {
-	uid, err := auth.UserIDFromContext(c)
+	uid, err := currentUserID(c, false)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, orderInvalidTokenMsg)
+		return err
 	}
 
 	var req createOrderRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return errInvalidBody
 	}
 
-	order, err := h.usecase.CreateFromCart(uid, req.PaymentMethod, req.ShippingAddressID)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	if req.Currency == "" {
+		req.Currency = requestedCurrency(c)
 	}
+	currency, err := h.currency.Resolve(req.Currency, uid)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
+	}
+
+	order, err := h.usecase.CreateFromCart(actorFromContext(c), uid, req.PaymentMethod, req.ShippingAddressID, req.ShippingMethodID, currency, resolveLocale(c, h.translations))
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, order)
@@ -169,7 +182,7 @@ type updateStatusRequest struct {
 func (h *OrderHandler) UpdateStatus(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidOrderIDMsg)
+		return errInvalidOrderID
 	}
 
 	if err := requireAdmin(c); err != nil {
@@ -178,15 +191,15 @@ func (h *OrderHandler) UpdateStatus(c echo.Context) error {
 
 	var req updateStatusRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		return errInvalidBody
 	}
 
-	order, err := h.usecase.UpdateStatus(uint(id), req.Status)
+	order, err := h.usecase.UpdateStatus(actorFromContext(c), uint(id), req.Status)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, orderNotFoundMsg)
+		return errOrderNotFound
 	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, order)
@@ -195,28 +208,134 @@ func (h *OrderHandler) UpdateStatus(c echo.Context) error {
 func (h *OrderHandler) CancelOrder(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, invalidOrderIDMsg)
+		return errInvalidOrderID
 	}
 
 	order, err := h.usecase.GetByID(uint(id))
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, orderNotFoundMsg)
+		return errOrderNotFound
 	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	if err := requireUserOrAdmin(c, order.UserID); err != nil {
+	if err := requireUserOrAdmin(c, order.OwnerID()); err != nil {
 		return err
 	}
 
-	updatedOrder, err := h.usecase.CancelOrder(uint(id))
+	updatedOrder, err := h.usecase.CancelOrder(actorFromContext(c), uint(id))
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, orderNotFoundMsg)
+		return errOrderNotFound
 	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, updatedOrder)
 }
+
+type guestAddressRequest struct {
+	Country  string `json:"country"`
+	City     string `json:"city"`
+	Postcode string `json:"postcode"`
+	Street   string `json:"street"`
+	Number   string `json:"number"`
+	Company  string `json:"company"`
+	TaxID    string `json:"tax_id"`
+}
+
+type guestCheckoutRequest struct {
+	Email            string              `json:"email"`
+	Name             string              `json:"name"`
+	Surname          string              `json:"surname"`
+	PaymentMethod    model.PaymentMethod `json:"payment_method"`
+	ShippingAddress  guestAddressRequest `json:"shipping_address"`
+	ShippingMethodID uint                `json:"shipping_method_id"`
+	Currency         string              `json:"currency"`
+}
+
+// GuestCheckout places an order from the guest cart named by the
+// X-Cart-Token header, without an account.
+func (h *OrderHandler) GuestCheckout(c echo.Context) error {
+	token := c.Request().Header.Get(CartTokenHeader)
+	if token == "" {
+		return errCartTokenRequired
+	}
+
+	var req guestCheckoutRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	if req.Currency == "" {
+		req.Currency = requestedCurrency(c)
+	}
+
+	receipt, err := h.guest.Checkout(actorFromContext(c), token, usecase.GuestCheckout{
+		Email:         req.Email,
+		Name:          req.Name,
+		Surname:       req.Surname,
+		PaymentMethod: req.PaymentMethod,
+		ShippingAddress: model.Address{
+			Country:  req.ShippingAddress.Country,
+			City:     req.ShippingAddress.City,
+			Postcode: req.ShippingAddress.Postcode,
+			Street:   req.ShippingAddress.Street,
+			Number:   req.ShippingAddress.Number,
+			Company:  req.ShippingAddress.Company,
+			TaxID:    req.ShippingAddress.TaxID,
+		},
+		ShippingMethodID: req.ShippingMethodID,
+		Currency:         req.Currency,
+		Locale:           resolveLocale(c, h.translations),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, receipt)
+}
+
+type orderLookupRequest struct {
+	OrderID uint   `json:"order_id"`
+	Email   string `json:"email"`
+}
+
+// LookupOrder returns a guest order to whoever knows its number and email.
+func (h *OrderHandler) LookupOrder(c echo.Context) error {
+	var req orderLookupRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	var missing []usecase.FieldError
+	if req.OrderID == 0 {
+		missing = append(missing, usecase.FieldError{Field: "order_id", Code: usecase.FieldRequired})
+	}
+	if req.Email == "" {
+		missing = append(missing, usecase.FieldError{Field: "email", Code: usecase.FieldRequired})
+	}
+	if len(missing) > 0 {
+		return errOrderLookup.WithFields(missing...)
+	}
+
+	order, err := h.guest.Lookup(req.OrderID, req.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, order)
+}
+
+// LookupOrderByToken returns the order behind the link from a guest order
+// confirmation.
+func (h *OrderHandler) LookupOrderByToken(c echo.Context) error {
+	order, err := h.guest.LookupByToken(c.QueryParam("token"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, order)
+}
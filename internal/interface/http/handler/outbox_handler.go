@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errInvalidOutboxEventID = usecase.NewError(usecase.KindValidation, "invalid_event_id", "invalid event ID")
+	errOutboxEventNotFound  = usecase.NewError(usecase.KindNotFound, "event_not_found", "event not found")
+)
+
+type OutboxHandler struct {
+	Usecase usecase.OutboxUsecase
+}
+
+func NewOutboxHandler(uc usecase.OutboxUsecase) *OutboxHandler {
+	return &OutboxHandler{Usecase: uc}
+}
+
+// Search lists outbox events, newest first. Supported filters: status, type,
+// aggregate_id and limit.
+func (h *OutboxHandler) Search(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	events, err := h.Usecase.GetWithFilters(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, events)
+}
+
+func (h *OutboxHandler) Retry(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidOutboxEventID
+	}
+
+	event, err := h.Usecase.Retry(id)
+	if err != nil {
+		return orNotFound(err, errOutboxEventNotFound)
+	}
+	return c.JSON(http.StatusOK, event)
+}
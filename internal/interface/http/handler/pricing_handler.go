@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	errInvalidPriceScheduleID = usecase.NewError(usecase.KindValidation, "invalid_price_schedule_id", "invalid price schedule ID")
+	errPriceScheduleNotFound  = usecase.NewError(usecase.KindNotFound, "price_schedule_not_found", "product or price schedule not found")
+)
+
+type PricingHandler struct {
+	Usecase usecase.PricingUsecase
+}
+
+func NewPricingHandler(uc usecase.PricingUsecase) *PricingHandler {
+	return &PricingHandler{Usecase: uc}
+}
+
+type priceScheduleRequest struct {
+	SalePrice float64    `json:"sale_price"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+}
+
+func (r priceScheduleRequest) toInput() usecase.PriceScheduleInput {
+	return usecase.PriceScheduleInput{SalePrice: r.SalePrice, StartsAt: r.StartsAt, EndsAt: r.EndsAt}
+}
+
+// History shows the product's prices of the past 30 days with the lowest
+// of them, in the product's own currency.
+func (h *PricingHandler) History(c echo.Context) error {
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	report, err := h.Usecase.History(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errProductNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+func (h *PricingHandler) GetSchedules(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	schedules, err := h.Usecase.GetSchedules(id)
+	if err != nil {
+		return orNotFound(err, errPriceScheduleNotFound)
+	}
+	return c.JSON(http.StatusOK, schedules)
+}
+
+func (h *PricingHandler) CreateSchedule(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	var req priceScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	schedule, err := h.Usecase.CreateSchedule(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errPriceScheduleNotFound)
+	}
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *PricingHandler) UpdateSchedule(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	scheduleID, err := parseUintParam(c, "scheduleId")
+	if err != nil {
+		return errInvalidPriceScheduleID
+	}
+	var req priceScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	schedule, err := h.Usecase.UpdateSchedule(actorFromContext(c), id, scheduleID, req.toInput())
+	if err != nil {
+		return orNotFound(err, errPriceScheduleNotFound)
+	}
+	return c.JSON(http.StatusOK, schedule)
+}
+
+func (h *PricingHandler) DeleteSchedule(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	scheduleID, err := parseUintParam(c, "scheduleId")
+	if err != nil {
+		return errInvalidPriceScheduleID
+	}
+	if err := h.Usecase.DeleteSchedule(actorFromContext(c), id, scheduleID); err != nil {
+		return orNotFound(err, errPriceScheduleNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
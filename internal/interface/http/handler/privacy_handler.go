@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errInvalidPrivacyReqID = usecase.NewError(usecase.KindValidation, "invalid_privacy_request_id", "invalid privacy request ID")
+	errPrivacyReqNotFound  = usecase.NewError(usecase.KindNotFound, "privacy_request_not_found", "privacy request not found")
+	errInvalidExportFormat = usecase.NewError(usecase.KindValidation, "invalid_export_format", "format must be zip or json")
+)
+
+type PrivacyHandler struct {
+	Usecase usecase.PrivacyUsecase
+}
+
+func NewPrivacyHandler(uc usecase.PrivacyUsecase) *PrivacyHandler {
+	return &PrivacyHandler{Usecase: uc}
+}
+
+// Export returns the caller's data as a ZIP of JSON files (default) or, with
+// ?format=json, as a single JSON document.
+func (h *PrivacyHandler) Export(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "zip"
+	}
+	if format != "zip" && format != "json" {
+		return errInvalidExportFormat
+	}
+
+	export, err := h.Usecase.Export(uid)
+	if err != nil {
+		return orNotFound(err, errPrivacyReqNotFound)
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s", uid, export.ExportedAt.Format("20060102"))
+	if format == "json" {
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, filename))
+		return c.JSON(http.StatusOK, export)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.zip"`, filename))
+	c.Response().WriteHeader(http.StatusOK)
+	return writeExportZip(c.Response(), export)
+}
+
+func writeExportZip(w http.ResponseWriter, export *usecase.DataExport) error {
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"addresses.json", export.Addresses},
+		{"cart.json", export.Cart},
+		{"orders.json", export.Orders},
+	}
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(fw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+type erasureInput struct {
+	UserID uint   `json:"user_id"`
+	Note   string `json:"note"`
+}
+
+// RequestErasure queues erasure of the caller's own account.
+func (h *PrivacyHandler) RequestErasure(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	var input erasureInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	request, err := h.Usecase.RequestErasure(uid, input.Note)
+	if err != nil {
+		return orNotFound(err, errPrivacyReqNotFound)
+	}
+	return c.JSON(http.StatusAccepted, request)
+}
+
+// Create lets an admin log an erasure request received outside the API,
+// e.g. by email or letter.
+func (h *PrivacyHandler) Create(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	var input erasureInput
+	if err := c.Bind(&input); err != nil || input.UserID == 0 {
+		return errInvalidBody
+	}
+
+	request, err := h.Usecase.RequestErasure(input.UserID, input.Note)
+	if err != nil {
+		return orNotFound(err, errPrivacyReqNotFound)
+	}
+	return c.JSON(http.StatusCreated, request)
+}
+
+func (h *PrivacyHandler) Search(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	requests, err := h.Usecase.GetWithFilters(filters)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, requests)
+}
+
+func (h *PrivacyHandler) Complete(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidPrivacyReqID
+	}
+
+	request, err := h.Usecase.Complete(actorFromContext(c), id)
+	if err != nil {
+		return orNotFound(err, errPrivacyReqNotFound)
+	}
+	return c.JSON(http.StatusOK, request)
+}
+
+type rejectPrivacyInput struct {
+	Note string `json:"note"`
+}
+
+func (h *PrivacyHandler) Reject(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidPrivacyReqID
+	}
+	var input rejectPrivacyInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	request, err := h.Usecase.Reject(actorFromContext(c), id, input.Note)
+	if err != nil {
+		return orNotFound(err, errPrivacyReqNotFound)
+	}
+	return c.JSON(http.StatusOK, request)
+}
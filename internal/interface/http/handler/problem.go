@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/infrastructure/password"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// Codes of the errors the handler reports without a usecase.Error.
+const (
+	codeInternal       = "internal_server_error"
+	codeValidation     = "validation_failed"
+	codeWeakPassword   = "weak_password"
+	codeAccountLocked  = "account_locked"
+	fallbackCatalogTag = "en"
+)
+
+// Errors shared by several handlers.
+var (
+	errInvalidBody  = usecase.NewError(usecase.KindValidation, "invalid_body", "invalid request body")
+	errInvalidToken = usecase.NewError(usecase.KindUnauthorized, "invalid_token", "invalid token")
+)
+
+// Problem is the body of every error response. Code identifies the error
+// and never changes, while Title and Detail are in the client's language.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      string         `json:"code"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []ProblemField `json:"errors,omitempty"`
+	// Violations lists the password policy rules a password breaks.
+	Violations []string `json:"violations,omitempty"`
+	// LockedUntil is when a locked account can log in again.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+// ProblemField is an invalid input field. Field is its JSON name, with
+// nested fields joined by dots as in "shipping_address.city".
+type ProblemField struct {
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+//go:embed messages/*.json
+var messageFiles embed.FS
+
+// messages holds the catalogs in messages/, one per language, keyed by
+// error code. Titles are keyed "title.<status>" and field errors
+// "field.<code>".
+var messages, messageTags = loadMessages()
+
+func loadMessages() (map[string]map[string]string, []language.Tag) {
+	files, err := messageFiles.ReadDir("messages")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string)
+	// The matcher falls back to its first tag, so English goes first.
+	tags := []language.Tag{language.Make(fallbackCatalogTag)}
+	for _, file := range files {
+		data, err := messageFiles.ReadFile(path.Join("messages", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("messages/%s: %v", file.Name(), err))
+		}
+		locale := strings.TrimSuffix(file.Name(), ".json")
+		catalogs[locale] = catalog
+		if locale != fallbackCatalogTag {
+			tags = append(tags, language.Make(locale))
+		}
+	}
+	return catalogs, tags
+}
+
+// NewHTTPErrorHandler answers failed requests with a Problem in the
+// language the lang query parameter or Accept-Language header asks for,
+// else in defaultLocale, else in English. Server errors are logged and
+// never shown to the client.
+func NewHTTPErrorHandler(defaultLocale string) echo.HTTPErrorHandler {
+	fallback := fallbackCatalogTag
+	if _, ok := messages[defaultLocale]; ok {
+		fallback = defaultLocale
+	}
+	matcher := language.NewMatcher(messageTags)
+
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		locale := errorLocale(c, matcher, fallback)
+		p := newProblem(err, locale)
+		if p.Status >= http.StatusInternalServerError {
+			c.Logger().Error(err)
+		}
+		p.Instance = c.Request().URL.Path
+		p.RequestID = c.Response().Header().Get(echo.HeaderXRequestID)
+
+		header := c.Response().Header()
+		header.Set("Content-Language", locale)
+		if !strings.Contains(header.Get(echo.HeaderVary), "Accept-Language") {
+			header.Add(echo.HeaderVary, "Accept-Language")
+		}
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(p.Status)
+		} else {
+			var body []byte
+			if body, err = json.Marshal(p); err == nil {
+				err = c.Blob(p.Status, ProblemContentType, body)
+			}
+		}
+		if err != nil {
+			c.Logger().Error(err)
+		}
+	}
+}
+
+// errorLocale picks the catalog to answer in, the way resolveLocale picks
+// the language of content.
+func errorLocale(c echo.Context, matcher language.Matcher, fallback string) string {
+	var desired []language.Tag
+	if lang := strings.TrimSpace(c.QueryParam("lang")); lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			desired = []language.Tag{tag}
+		}
+	} else {
+		desired, _, _ = language.ParseAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+	}
+	if len(desired) == 0 {
+		return fallback
+	}
+	_, index, confidence := matcher.Match(desired...)
+	if confidence == language.No {
+		return fallback
+	}
+	return messageTags[index].String()
+}
+
+// newProblem describes err. Errors the handlers and usecases declare carry
+// their code; anything else that is not an HTTP error is a server error.
+func newProblem(err error, locale string) *Problem {
+	var (
+		policyErr *password.PolicyError
+		lockedErr *usecase.AccountLockedError
+		invalid   validator.ValidationErrors
+		httpErr   *echo.HTTPError
+	)
+	switch {
+	case errors.As(err, &policyErr):
+		p := problem(locale, http.StatusBadRequest, codeWeakPassword, "")
+		p.Errors = fieldProblems(locale, usecase.FieldError{Field: "password", Code: usecase.FieldInvalid})
+		p.Violations = policyErr.Violations
+		return p
+	case errors.As(err, &lockedErr):
+		p := problem(locale, http.StatusTooManyRequests, codeAccountLocked, "")
+		p.LockedUntil = &lockedErr.Until
+		return p
+	case errors.As(err, &invalid):
+		fields := make([]usecase.FieldError, 0, len(invalid))
+		for _, fe := range invalid {
+			fields = append(fields, validationField(fe))
+		}
+		p := problem(locale, http.StatusBadRequest, codeValidation, "")
+		p.Errors = fieldProblems(locale, fields...)
+		return p
+	}
+
+	// HTTP errors unwrap to their Internal error, so usecase errors that
+	// handlers wrap in one are found here too.
+	if domainErr, ok := usecase.AsError(err); ok {
+		// Wrapped errors add what was wrong after the message, as in
+		// "invalid tax rate: rate must be between 0 and 100". That part is
+		// only in English.
+		detail := messageFor(locale, domainErr.Code, domainErr.Message)
+		if locale == fallbackCatalogTag {
+			detail += wrappedDetail(err, domainErr)
+		}
+		p := problem(locale, kindStatus(domainErr.Kind), domainErr.Code, detail)
+		p.Errors = fieldProblems(locale, domainErr.Fields...)
+		return p
+	}
+
+	if errors.As(err, &httpErr) {
+		if httpErr.Code >= http.StatusInternalServerError {
+			return problem(locale, httpErr.Code, statusCode(httpErr.Code), "")
+		}
+		// Middleware such as the JWT and rate limit checks give their
+		// reason in English.
+		detail := ""
+		if msg, ok := httpErr.Message.(string); ok && locale == fallbackCatalogTag {
+			detail = msg
+		}
+		return problem(locale, httpErr.Code, statusCode(httpErr.Code), detail)
+	}
+	return problem(locale, http.StatusInternalServerError, codeInternal, "")
+}
+
+// problem builds a Problem, taking the detail from the catalog unless one
+// is given.
+func problem(locale string, status int, code, detail string) *Problem {
+	if detail == "" {
+		detail = messageFor(locale, code, http.StatusText(status))
+	}
+	return &Problem{
+		Type:   "about:blank",
+		Title:  messageFor(locale, fmt.Sprintf("title.%d", status), http.StatusText(status)),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// messageFor looks key up in the locale's catalog, then in the English one.
+func messageFor(locale, key, fallback string) string {
+	if msg, ok := messages[locale][key]; ok {
+		return msg
+	}
+	if msg, ok := messages[fallbackCatalogTag][key]; ok {
+		return msg
+	}
+	return fallback
+}
+
+func fieldProblems(locale string, fields ...usecase.FieldError) []ProblemField {
+	problems := make([]ProblemField, 0, len(fields))
+	for _, f := range fields {
+		problems = append(problems, ProblemField{
+			Field:  f.Field,
+			Code:   f.Code,
+			Detail: messageFor(locale, "field."+f.Code, f.Code),
+		})
+	}
+	return problems
+}
+
+// wrappedDetail returns what err says after domainErr's message.
+func wrappedDetail(err error, domainErr *usecase.Error) string {
+	full := err.Error()
+	if i := strings.Index(full, domainErr.Message); i >= 0 {
+		return full[i+len(domainErr.Message):]
+	}
+	return ""
+}
+
+// validationField turns a failed validate tag into a FieldError. The
+// validator reports JSON field names; the top-level struct is left out.
+func validationField(fe validator.FieldError) usecase.FieldError {
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+	code := usecase.FieldInvalid
+	if strings.HasPrefix(fe.Tag(), "required") {
+		code = usecase.FieldRequired
+	}
+	return usecase.FieldError{Field: field, Code: code}
+}
+
+func kindStatus(kind usecase.ErrorKind) int {
+	switch kind {
+	case usecase.KindNotFound:
+		return http.StatusNotFound
+	case usecase.KindConflict, usecase.KindInsufficientStock:
+		return http.StatusConflict
+	case usecase.KindValidation:
+		return http.StatusBadRequest
+	case usecase.KindUnauthorized:
+		return http.StatusUnauthorized
+	case usecase.KindForbidden:
+		return http.StatusForbidden
+	case usecase.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusCode is the code of an HTTP error that has none of its own, such
+// as "not_found" for routes that do not exist.
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// orNotFound reports a missing record as notFound, which names what the
+// request was looking for, and returns other errors as they are.
+func orNotFound(err error, notFound *usecase.Error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return err
+}
@@ -12,27 +12,56 @@ import (
 	"gorm.io/gorm"
 )
 
-// Error message constants
-const (
-	errInvalidProductID = "invalid product ID"
-	errProductNotFound  = "product not found"
-	errAccessDenied     = "access denied"
-	errInvalidBody      = "invalid request body"
+// Errors
+var (
+	errInvalidProductID = usecase.NewError(usecase.KindValidation, "invalid_product_id", "invalid product ID")
+	errProductNotFound  = usecase.NewError(usecase.KindNotFound, "product_not_found", "product not found")
 )
 
 type ProductHandler struct {
-	Usecase usecase.ProductUsecase
+	Usecase      usecase.ProductUsecase
+	Currency     usecase.CurrencyUsecase
+	Tax          usecase.TaxUsecase
+	Translations usecase.TranslationUsecase
 }
 
-func NewProductHandler(uc usecase.ProductUsecase) *ProductHandler {
-	return &ProductHandler{Usecase: uc}
+func NewProductHandler(uc usecase.ProductUsecase, currency usecase.CurrencyUsecase, tax usecase.TaxUsecase, translations usecase.TranslationUsecase) *ProductHandler {
+	return &ProductHandler{Usecase: uc, Currency: currency, Tax: tax, Translations: translations}
+}
+
+// presentProducts shows the products' prices in the currency the caller
+// asked for or prefers, with and without the tax of the requested country,
+// and their names and descriptions in the caller's language.
+func (h *ProductHandler) presentProducts(c echo.Context, products []model.Product) error {
+	currency, err := resolveCurrency(c, h.Currency)
+	if err != nil {
+		return err
+	}
+	if err := h.Currency.ConvertProducts(products, currency); err != nil {
+		return err
+	}
+	if err := h.Tax.ShowProductTaxes(products, requestedCountry(c)); err != nil {
+		return err
+	}
+	if err := h.Translations.LocalizeProducts(products, resolveLocale(c, h.Translations)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// renderProducts responds with the products as presentProducts shows them.
+func (h *ProductHandler) renderProducts(c echo.Context, products []model.Product) error {
+	if err := h.presentProducts(c, products); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, products)
 }
 
 // checkAdminRole verifies if the user has admin role
 func (h *ProductHandler) checkAdminRole(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, errAccessDenied)
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 	return nil
 }
@@ -40,23 +69,47 @@ func (h *ProductHandler) checkAdminRole(c echo.Context) error {
 func (h *ProductHandler) GetByID(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidProductID)
+		return errInvalidProductID
 	}
 	prod, err := h.Usecase.GetByID(id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errProductNotFound)
+		return errProductNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, prod)
+	products := []model.Product{*prod}
+	if err := h.presentProducts(c, products); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, products[0])
+}
+
+// GetBySlug shows the product with the slug, or redirects to its current
+// slug when the product had this one before.
+func (h *ProductHandler) GetBySlug(c echo.Context) error {
+	slug := c.Param("slug")
+	prod, err := h.Usecase.GetBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errProductNotFound
+	} else if err != nil {
+		return err
+	}
+	if prod.Slug != slug {
+		return movedToSlug(c, "/products/by-slug/", prod.Slug)
+	}
+	products := []model.Product{*prod}
+	if err := h.presentProducts(c, products); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, products[0])
 }
 
 func (h *ProductHandler) GetAll(c echo.Context) error {
 	prods, err := h.Usecase.GetAll()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, prods)
+	return h.renderProducts(c, prods)
 }
 
 func (h *ProductHandler) Search(c echo.Context) error {
@@ -66,11 +119,13 @@ func (h *ProductHandler) Search(c echo.Context) error {
 			filters[key] = vals[0]
 		}
 	}
+	// Names match in the caller's language as well as the default one.
+	filters["locale"] = resolveLocale(c, h.Translations)
 	prods, err := h.Usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
-	return c.JSON(http.StatusOK, prods)
+	return h.renderProducts(c, prods)
 }
 
 func (h *ProductHandler) Create(c echo.Context) error {
@@ -80,11 +135,11 @@ func (h *ProductHandler) Create(c echo.Context) error {
 
 	var input model.Product
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidBody)
+		return errInvalidBody
 	}
-	created, err := h.Usecase.Create(&input)
+	created, err := h.Usecase.Create(actorFromContext(c), &input)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusCreated, created)
 }
@@ -96,18 +151,16 @@ func (h *ProductHandler) Update(c echo.Context) error {
 
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidProductID)
+		return errInvalidProductID
 	}
 	var input model.Product
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidBody)
+		return errInvalidBody
 	}
 	input.ID = id
-	updated, err := h.Usecase.Update(&input)
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errProductNotFound)
-	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	updated, err := h.Usecase.Update(actorFromContext(c), &input)
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
 	}
 	return c.JSON(http.StatusOK, updated)
 }
@@ -119,12 +172,12 @@ func (h *ProductHandler) Delete(c echo.Context) error {
 
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidProductID)
+		return errInvalidProductID
 	}
-	if err := h.Usecase.Delete(id); errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errProductNotFound)
+	if err := h.Usecase.Delete(actorFromContext(c), id); errors.Is(err, gorm.ErrRecordNotFound) {
+		return errProductNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.NoContent(http.StatusNoContent)
 }
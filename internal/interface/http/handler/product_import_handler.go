@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// maxProductFileSize caps uploaded product files. Multipart requests may be
+// a little larger, for the form framing around the file.
+const (
+	maxProductFileSize    = 10 << 20
+	maxProductRequestSize = maxProductFileSize + 1<<20
+)
+
+var (
+	errProductFile            = usecase.NewError(usecase.KindValidation, "product_file_required", "upload the products as the request body or a multipart file field, at most 10 MB")
+	errProductFileTooLarge    = usecase.NewError(usecase.KindTooLarge, "product_file_too_large", "the product file is larger than 10 MB")
+	errProductFileFormat      = usecase.NewError(usecase.KindValidation, "product_file_format_required", "give the format as ?format=csv or ?format=json")
+	errInvalidProductImportID = usecase.NewError(usecase.KindValidation, "invalid_product_import_id", "invalid product import ID")
+	errProductImportNotFound  = usecase.NewError(usecase.KindNotFound, "product_import_not_found", "product import not found")
+)
+
+type ProductImportHandler struct {
+	Usecase usecase.ProductImportUsecase
+	Jobs    usecase.SchedulerUsecase
+}
+
+func NewProductImportHandler(uc usecase.ProductImportUsecase, jobs usecase.SchedulerUsecase) *ProductImportHandler {
+	return &ProductImportHandler{Usecase: uc, Jobs: jobs}
+}
+
+// Import creates and updates products from a CSV or JSON file. Small files
+// are imported at once (200); larger ones, and any with async=true, are
+// queued (202) and can be followed at GET /products/imports/{id}.
+// dry_run=true only validates the rows.
+func (h *ProductImportHandler) Import(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	multipart := isMultipart(c)
+	limit := int64(maxProductFileSize)
+	if multipart {
+		limit = maxProductRequestSize
+	}
+	// Capped before FormFile, which would otherwise spool any size to disk.
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, limit)
+	var body io.Reader = c.Request().Body
+	name := ""
+	if multipart {
+		file, err := c.FormFile("file")
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return errProductFileTooLarge
+		} else if err != nil {
+			return errProductFile
+		}
+		if file.Size > maxProductFileSize {
+			return errProductFileTooLarge
+		}
+		src, err := file.Open()
+		if err != nil {
+			return errProductFile
+		}
+		defer src.Close()
+		body = src
+		name = file.Filename
+	}
+	payload, err := io.ReadAll(body)
+	if err != nil {
+		return errProductFileTooLarge
+	}
+	format, err := productFileFormat(c, name)
+	if err != nil {
+		return err
+	}
+
+	productImport, err := h.Usecase.Import(actorFromContext(c), usecase.ProductImportInput{
+		Format:  format,
+		Payload: payload,
+		DryRun:  c.QueryParam("dry_run") == "true",
+		Async:   c.QueryParam("async") == "true",
+	})
+	if err != nil {
+		return err
+	}
+	if productImport.Status == model.ProductImportPending {
+		// Start on it now rather than at the next minute. If the job is
+		// already running it picks the import up before it finishes.
+		_, _ = h.Jobs.RunNow(usecase.JobProcessProductImports)
+		return c.JSON(http.StatusAccepted, productImport)
+	}
+	return c.JSON(http.StatusOK, productImport)
+}
+
+func (h *ProductImportHandler) GetImports(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	imports, err := h.Usecase.GetImports()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, imports)
+}
+
+func (h *ProductImportHandler) GetImport(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "importId")
+	if err != nil {
+		return errInvalidProductImportID
+	}
+	productImport, err := h.Usecase.GetImport(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errProductImportNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, productImport)
+}
+
+// Export streams the products matching the search filters as CSV (the
+// default) or JSON, in the layout Import reads.
+func (h *ProductImportHandler) Export(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	format := model.ProductFileCSV
+	if v := c.QueryParam("format"); v != "" {
+		var err error
+		if format, err = usecase.ParseProductFileFormat(v); err != nil {
+			return err
+		}
+	}
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 && key != "format" {
+			filters[key] = vals[0]
+		}
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == model.ProductFileJSON {
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	c.Response().WriteHeader(http.StatusOK)
+	// The status is sent by now, so a failure can only cut the file short.
+	if err := h.Usecase.Export(format, filters, c.Response()); err != nil {
+		c.Logger().Errorf("product export: %v", err)
+	}
+	return nil
+}
+
+// productFileFormat takes the format from the format parameter, else from
+// the uploaded file's extension, else from the request's content type.
+func productFileFormat(c echo.Context, filename string) (model.ProductFileFormat, error) {
+	candidates := []string{
+		c.QueryParam("format"),
+		strings.TrimPrefix(filepath.Ext(filename), "."),
+	}
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		candidates = append(candidates, string(model.ProductFileJSON))
+	case strings.Contains(contentType, "csv"):
+		candidates = append(candidates, string(model.ProductFileCSV))
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		format, err := usecase.ParseProductFileFormat(candidate)
+		if err != nil {
+			return "", err
+		}
+		return format, nil
+	}
+	return "", errProductFileFormat
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errInvalidShippingZoneID   = usecase.NewError(usecase.KindValidation, "invalid_shipping_zone_id", "invalid shipping zone ID")
+	errInvalidShippingMethodID = usecase.NewError(usecase.KindValidation, "invalid_shipping_method_id", "invalid shipping method ID")
+	errShippingNotFound        = usecase.NewError(usecase.KindNotFound, "shipping_not_found", "shipping zone or method not found")
+)
+
+type ShippingHandler struct {
+	Usecase usecase.ShippingUsecase
+}
+
+func NewShippingHandler(uc usecase.ShippingUsecase) *ShippingHandler {
+	return &ShippingHandler{Usecase: uc}
+}
+
+type shippingZoneRequest struct {
+	Name           string   `json:"name"`
+	Countries      []string `json:"countries"`
+	PostcodeRanges []string `json:"postcode_ranges"`
+}
+
+func (r shippingZoneRequest) toInput() usecase.ShippingZoneInput {
+	return usecase.ShippingZoneInput{
+		Name:           r.Name,
+		Countries:      r.Countries,
+		PostcodeRanges: r.PostcodeRanges,
+	}
+}
+
+type shippingMethodRequest struct {
+	ZoneID    uint                     `json:"zone_id"`
+	Name      string                   `json:"name"`
+	Type      model.ShippingMethodType `json:"type"`
+	IsActive  *bool                    `json:"is_active"`
+	MaxLength float64                  `json:"max_length"`
+	MaxWidth  float64                  `json:"max_width"`
+	MaxHeight float64                  `json:"max_height"`
+	Rates     []model.ShippingRate     `json:"rates"`
+}
+
+func (r shippingMethodRequest) toInput() usecase.ShippingMethodInput {
+	return usecase.ShippingMethodInput{
+		ZoneID:    r.ZoneID,
+		Name:      r.Name,
+		Type:      r.Type,
+		IsActive:  r.IsActive,
+		MaxLength: r.MaxLength,
+		MaxWidth:  r.MaxWidth,
+		MaxHeight: r.MaxHeight,
+		Rates:     r.Rates,
+	}
+}
+
+// Options lists the shipping methods available for the current cart. The
+// address comes from the country and postcode query parameters or, when
+// they are missing, from the signed-in user's profile.
+func (h *ShippingHandler) Options(c echo.Context) error {
+	owner, ok := cartOwner(c)
+	if !ok {
+		return errCartOwnerRequired
+	}
+	var address *model.Address
+	if country := c.QueryParam("country"); country != "" {
+		address = &model.Address{Country: country, Postcode: c.QueryParam("postcode")}
+	}
+
+	options, err := h.Usecase.Options(owner, address)
+	if err != nil {
+		return orNotFound(err, errCartNotFound)
+	}
+	return c.JSON(http.StatusOK, options)
+}
+
+func (h *ShippingHandler) GetZones(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	zones, err := h.Usecase.GetZones()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, zones)
+}
+
+func (h *ShippingHandler) GetZone(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingZoneID
+	}
+	zone, err := h.Usecase.GetZone(id)
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusOK, zone)
+}
+
+func (h *ShippingHandler) CreateZone(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	var req shippingZoneRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	zone, err := h.Usecase.CreateZone(actorFromContext(c), req.toInput())
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusCreated, zone)
+}
+
+func (h *ShippingHandler) UpdateZone(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingZoneID
+	}
+	var req shippingZoneRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	zone, err := h.Usecase.UpdateZone(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusOK, zone)
+}
+
+func (h *ShippingHandler) DeleteZone(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingZoneID
+	}
+	if err := h.Usecase.DeleteZone(actorFromContext(c), id); err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ShippingHandler) GetMethods(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	methods, err := h.Usecase.GetMethods()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, methods)
+}
+
+func (h *ShippingHandler) GetMethod(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingMethodID
+	}
+	method, err := h.Usecase.GetMethod(id)
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusOK, method)
+}
+
+func (h *ShippingHandler) CreateMethod(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	var req shippingMethodRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	method, err := h.Usecase.CreateMethod(actorFromContext(c), req.toInput())
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusCreated, method)
+}
+
+func (h *ShippingHandler) UpdateMethod(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingMethodID
+	}
+	var req shippingMethodRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	method, err := h.Usecase.UpdateMethod(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.JSON(http.StatusOK, method)
+}
+
+func (h *ShippingHandler) DeleteMethod(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidShippingMethodID
+	}
+	if err := h.Usecase.DeleteMethod(actorFromContext(c), id); err != nil {
+		return orNotFound(err, errShippingNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+)
+
+type slugRedirectResponse struct {
+	Slug     string `json:"slug"`
+	Location string `json:"location"`
+}
+
+// movedToSlug answers a request for a slug the entity no longer has with a
+// permanent redirect to the path with its current slug, keeping the query.
+func movedToSlug(c echo.Context, prefix, slug string) error {
+	location := prefix + url.PathEscape(slug)
+	if query := c.QueryString(); query != "" {
+		location += "?" + query
+	}
+	c.Response().Header().Set(echo.HeaderLocation, location)
+	return c.JSON(http.StatusMovedPermanently, slugRedirectResponse{Slug: slug, Location: location})
+}
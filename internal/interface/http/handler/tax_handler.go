@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errInvalidTaxRateID = usecase.NewError(usecase.KindValidation, "invalid_tax_rate_id", "invalid tax rate ID")
+	errTaxRateNotFound  = usecase.NewError(usecase.KindNotFound, "tax_rate_not_found", "tax rate not found")
+)
+
+type TaxHandler struct {
+	Usecase usecase.TaxUsecase
+}
+
+func NewTaxHandler(uc usecase.TaxUsecase) *TaxHandler {
+	return &TaxHandler{Usecase: uc}
+}
+
+type taxRateRequest struct {
+	Country string         `json:"country"`
+	Class   model.TaxClass `json:"class"`
+	Rate    float64        `json:"rate"`
+}
+
+func (r taxRateRequest) toInput() usecase.TaxRateInput {
+	return usecase.TaxRateInput{Country: r.Country, Class: r.Class, Rate: r.Rate}
+}
+
+// requestedCountry returns the ?country= query parameter, whose tax rates
+// product and cart prices are split with.
+func requestedCountry(c echo.Context) string {
+	return c.QueryParam("country")
+}
+
+// taxTableResponse lists the rates together with the price mode they are
+// applied in.
+type taxTableResponse struct {
+	PriceMode model.PriceMode `json:"price_mode"`
+	Rates     []model.TaxRate `json:"rates"`
+}
+
+func (h *TaxHandler) GetRates(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	rates, err := h.Usecase.GetRates()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, taxTableResponse{PriceMode: h.Usecase.PriceMode(), Rates: rates})
+}
+
+func (h *TaxHandler) GetRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidTaxRateID
+	}
+	rate, err := h.Usecase.GetRate(id)
+	if err != nil {
+		return orNotFound(err, errTaxRateNotFound)
+	}
+	return c.JSON(http.StatusOK, rate)
+}
+
+func (h *TaxHandler) CreateRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	var req taxRateRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	rate, err := h.Usecase.CreateRate(actorFromContext(c), req.toInput())
+	if err != nil {
+		return orNotFound(err, errTaxRateNotFound)
+	}
+	return c.JSON(http.StatusCreated, rate)
+}
+
+func (h *TaxHandler) UpdateRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidTaxRateID
+	}
+	var req taxRateRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	rate, err := h.Usecase.UpdateRate(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errTaxRateNotFound)
+	}
+	return c.JSON(http.StatusOK, rate)
+}
+
+func (h *TaxHandler) DeleteRate(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidTaxRateID
+	}
+	if err := h.Usecase.DeleteRate(actorFromContext(c), id); err != nil {
+		return orNotFound(err, errTaxRateNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errTranslationNotFound = usecase.NewError(usecase.KindNotFound, "translation_not_found", "translation not found")
+)
+
+// resolveLocale picks the locale to show content in: the one the lang query
+// parameter asks for, else the closest one in the Accept-Language header,
+// else the default. It tells caches that the response depends on it.
+func resolveLocale(c echo.Context, uc usecase.TranslationUsecase) string {
+	locale := uc.Negotiate(c.QueryParam("lang"), c.Request().Header.Get("Accept-Language"))
+	header := c.Response().Header()
+	header.Set("Content-Language", locale)
+	if !strings.Contains(header.Get(echo.HeaderVary), "Accept-Language") {
+		header.Add(echo.HeaderVary, "Accept-Language")
+	}
+	return locale
+}
+
+type TranslationHandler struct {
+	Usecase usecase.TranslationUsecase
+}
+
+func NewTranslationHandler(uc usecase.TranslationUsecase) *TranslationHandler {
+	return &TranslationHandler{Usecase: uc}
+}
+
+type productTranslationRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type categoryTranslationRequest struct {
+	Name string `json:"name"`
+}
+
+type localesResponse struct {
+	Default   string   `json:"default"`
+	Supported []string `json:"supported"`
+}
+
+// GetLocales lists the locales content can be shown in. It is public so
+// that storefronts can offer a language picker.
+func (h *TranslationHandler) GetLocales(c echo.Context) error {
+	return c.JSON(http.StatusOK, localesResponse{Default: h.Usecase.DefaultLocale(), Supported: h.Usecase.Locales()})
+}
+
+func (h *TranslationHandler) GetProductTranslations(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	translations, err := h.Usecase.GetProductTranslations(id)
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
+	}
+	return c.JSON(http.StatusOK, translations)
+}
+
+// SetProductTranslation creates or replaces the product's translation into
+// the locale in the path.
+func (h *TranslationHandler) SetProductTranslation(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	var req productTranslationRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	translation, err := h.Usecase.SetProductTranslation(actorFromContext(c), id, c.Param("locale"), usecase.ProductTranslationInput{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if err != nil {
+		return orNotFound(err, errProductNotFound)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+func (h *TranslationHandler) DeleteProductTranslation(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidProductID
+	}
+	if err := h.Usecase.DeleteProductTranslation(actorFromContext(c), id, c.Param("locale")); err != nil {
+		return orNotFound(err, errTranslationNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TranslationHandler) GetCategoryTranslations(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	translations, err := h.Usecase.GetCategoryTranslations(id)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	return c.JSON(http.StatusOK, translations)
+}
+
+// SetCategoryTranslation creates or replaces the category's translation
+// into the locale in the path.
+func (h *TranslationHandler) SetCategoryTranslation(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	var req categoryTranslationRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	translation, err := h.Usecase.SetCategoryTranslation(actorFromContext(c), id, c.Param("locale"), req.Name)
+	if err != nil {
+		return orNotFound(err, errCategoryNotFound)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+func (h *TranslationHandler) DeleteCategoryTranslation(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidCategoryID
+	}
+	if err := h.Usecase.DeleteCategoryTranslation(actorFromContext(c), id, c.Param("locale")); err != nil {
+		return orNotFound(err, errTranslationNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
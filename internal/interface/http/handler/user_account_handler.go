@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const (
+	errEmailChangeRequested = "confirmation sent to the new email address"
+	errVerificationSent     = "verification token sent to your email address"
+)
+
+var (
+	errNotAUser              = usecase.NewError(usecase.KindForbidden, "user_token_required", "this endpoint requires a user token")
+	errImpersonationDenied   = usecase.NewError(usecase.KindForbidden, "impersonation_denied", "not allowed while impersonating a user")
+	errCurrentPasswordNeeded = usecase.NewError(usecase.KindValidation, "current_password_required", "current password is required", usecase.FieldError{Field: "current_password", Code: usecase.FieldRequired})
+)
+
+type profileInput struct {
+	Name    *string            `json:"name"`
+	Surname *string            `json:"surname"`
+	Address *addressPatchInput `json:"address"`
+}
+
+type addressPatchInput struct {
+	Country  *string `json:"country"`
+	City     *string `json:"city"`
+	Postcode *string `json:"postcode"`
+	Street   *string `json:"street"`
+	Number   *string `json:"number"`
+	Company  *string `json:"company"`
+	TaxID    *string `json:"tax_id"`
+}
+
+func (in profileInput) toUpdate() usecase.ProfileUpdate {
+	update := usecase.ProfileUpdate{Name: in.Name, Surname: in.Surname}
+	if in.Address != nil {
+		update.Address = &usecase.AddressUpdate{
+			Country:  in.Address.Country,
+			City:     in.Address.City,
+			Postcode: in.Address.Postcode,
+			Street:   in.Address.Street,
+			Number:   in.Address.Number,
+			Company:  in.Address.Company,
+			TaxID:    in.Address.TaxID,
+		}
+	}
+	return update
+}
+
+// currentUserID returns the ID of the user behind the token. API keys have no
+// user; impersonation tokens are rejected when sensitive is set, so support
+// staff cannot take over credentials or close accounts.
+func currentUserID(c echo.Context, sensitive bool) (uint, error) {
+	if auth.PrincipalFromContext(c) != nil {
+		return 0, errNotAUser
+	}
+	uid, err := auth.UserIDFromContext(c)
+	if err != nil {
+		return 0, errInvalidToken
+	}
+	if _, impersonating := auth.ImpersonatorIDFromContext(c); sensitive && impersonating {
+		return 0, errImpersonationDenied
+	}
+	return uid, nil
+}
+
+func (h *UserHandler) GetMe(c echo.Context) error {
+	uid, err := currentUserID(c, false)
+	if err != nil {
+		return err
+	}
+	user, err := h.Account.GetProfile(uid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateMe(c echo.Context) error {
+	uid, err := currentUserID(c, false)
+	if err != nil {
+		return err
+	}
+	var input profileInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	user, err := h.Account.UpdateProfile(actorFromContext(c), uid, input.toUpdate())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errUserNotFound
+	} else if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+type changePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (h *UserHandler) ChangePassword(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	var input changePasswordInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	user, err := h.Account.ChangePassword(actorFromContext(c), uid, input.CurrentPassword, input.NewPassword)
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	// Tokens issued before the change no longer work, including the caller's.
+	token, err := auth.GenerateToken(user.ID, user.Role, user.TokenVersion)
+	if err != nil {
+		return fmt.Errorf(errTokenGeneration, err)
+	}
+	return c.JSON(http.StatusOK, echo.Map{"token": token})
+}
+
+type changeEmailInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewEmail        string `json:"new_email"`
+}
+
+func (h *UserHandler) RequestEmailChange(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	var input changeEmailInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	change, err := h.Account.RequestEmailChange(uid, input.CurrentPassword, input.NewEmail)
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.JSON(http.StatusAccepted, echo.Map{
+		"message":    errEmailChangeRequested,
+		"new_email":  change.NewEmail,
+		"expires_at": change.ExpiresAt,
+	})
+}
+
+type confirmEmailInput struct {
+	Token string `json:"token"`
+}
+
+func (h *UserHandler) ConfirmEmailChange(c echo.Context) error {
+	var input confirmEmailInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	user, err := h.Account.ConfirmEmailChange(actorFromContext(c), input.Token)
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// RequestEmailVerification sends the signed-in user a new verification token.
+func (h *UserHandler) RequestEmailVerification(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	if err := h.Account.RequestEmailVerification(uid); err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.JSON(http.StatusAccepted, echo.Map{"message": errVerificationSent})
+}
+
+func (h *UserHandler) VerifyEmail(c echo.Context) error {
+	var input confirmEmailInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	user, err := h.Account.VerifyEmail(actorFromContext(c), input.Token)
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+type closeAccountInput struct {
+	CurrentPassword string `json:"current_password"`
+}
+
+func (h *UserHandler) CloseAccount(c echo.Context) error {
+	uid, err := currentUserID(c, true)
+	if err != nil {
+		return err
+	}
+	var input closeAccountInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+	if input.CurrentPassword == "" {
+		return errCurrentPasswordNeeded
+	}
+
+	if err := h.Account.Close(actorFromContext(c), uid, input.CurrentPassword); err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
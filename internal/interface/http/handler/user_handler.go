@@ -2,8 +2,11 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/infrastructure/auth"
@@ -13,35 +16,48 @@ import (
 	"gorm.io/gorm"
 )
 
-// Error message constants
-const (
-	errInvalidToken     = "invalid token"
-	errInvalidUserID    = "invalid user ID"
-	errUserAccessDenied = "access denied"
-	errUserNotFound     = "user not found"
-	errInvalidRequest   = "invalid request"
-	errInvalidReqBody   = "invalid request body"
-	errInvalidCreds     = "invalid credentials"
-	errTokenGeneration  = "could not generate token"
+const errTokenGeneration = "could not generate token: %w"
+
+var (
+	errInvalidUserID = usecase.NewError(usecase.KindValidation, "invalid_user_id", "invalid user ID")
+	errUserNotFound  = usecase.NewError(usecase.KindNotFound, "user_not_found", "user not found")
 )
 
 type UserHandler struct {
 	Usecase usecase.UserUsecase
+	Account usecase.AccountUsecase
+	Cart    usecase.CartUsecase
+}
+
+func NewUserHandler(uc usecase.UserUsecase, account usecase.AccountUsecase, cart usecase.CartUsecase) *UserHandler {
+	return &UserHandler{Usecase: uc, Account: account, Cart: cart}
 }
 
-func NewUserHandler(uc usecase.UserUsecase) *UserHandler {
-	return &UserHandler{Usecase: uc}
+// mergeGuestCart moves the guest cart named by X-Cart-Token, if any, into the
+// user's cart. Signing in must not fail because of the cart, so errors are
+// only logged.
+func (h *UserHandler) mergeGuestCart(c echo.Context, userID uint) *usecase.CartMergeResult {
+	token := c.Request().Header.Get(CartTokenHeader)
+	if token == "" || h.Cart == nil {
+		return nil
+	}
+	result, err := h.Cart.MergeGuestCart(userID, token)
+	if err != nil {
+		c.Logger().Errorf("failed to merge guest cart into cart of user %d: %v", userID, err)
+		return nil
+	}
+	return result
 }
 
 // getUserFromToken extracts user ID and role from token
 func (h *UserHandler) getUserFromToken(c echo.Context) (uint, string, error) {
 	uidToken, err := auth.UserIDFromContext(c)
 	if err != nil {
-		return 0, "", echo.NewHTTPError(http.StatusUnauthorized, errInvalidToken)
+		return 0, "", errInvalidToken
 	}
 	role, err := auth.RoleFromContext(c)
 	if err != nil {
-		return 0, "", echo.NewHTTPError(http.StatusUnauthorized, errInvalidToken)
+		return 0, "", errInvalidToken
 	}
 	return uidToken, role, nil
 }
@@ -53,8 +69,8 @@ func (h *UserHandler) checkUserAccess(c echo.Context, targetUserID uint) error {
 		return err
 	}
 
-	if role != "admin" && uidToken != targetUserID {
-		return echo.NewHTTPError(http.StatusForbidden, errUserAccessDenied)
+	if !auth.IsPrivileged(role) && uidToken != targetUserID {
+		return usecase.ErrForbidden
 	}
 	return nil
 }
@@ -62,8 +78,8 @@ func (h *UserHandler) checkUserAccess(c echo.Context, targetUserID uint) error {
 // checkAdminAccess verifies if user has admin role
 func (h *UserHandler) checkAdminAccess(c echo.Context) error {
 	role, err := auth.RoleFromContext(c)
-	if err != nil || role != "admin" {
-		return echo.NewHTTPError(http.StatusForbidden, errUserAccessDenied)
+	if err != nil || !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
 	}
 	return nil
 }
@@ -71,7 +87,7 @@ func (h *UserHandler) checkAdminAccess(c echo.Context) error {
 func (h *UserHandler) GetByID(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidUserID)
+		return errInvalidUserID
 	}
 
 	if err := h.checkUserAccess(c, id); err != nil {
@@ -80,9 +96,9 @@ func (h *UserHandler) GetByID(c echo.Context) error {
 
 	user, err := h.Usecase.GetByID(id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errUserNotFound)
+		return errUserNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
@@ -95,7 +111,7 @@ func (h *UserHandler) GetAll(c echo.Context) error {
 
 	users, err := h.Usecase.GetAll()
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, users)
 }
@@ -113,7 +129,7 @@ func (h *UserHandler) Search(c echo.Context) error {
 	}
 	users, err := h.Usecase.GetWithFilters(filters)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 	return c.JSON(http.StatusOK, users)
 }
@@ -129,7 +145,7 @@ type registerInput struct {
 func (h *UserHandler) Register(c echo.Context) error {
 	var input registerInput
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidRequest)
+		return errInvalidBody
 	}
 
 	user := &model.User{
@@ -138,14 +154,18 @@ func (h *UserHandler) Register(c echo.Context) error {
 		Surname: input.Surname,
 	}
 
-	createdUser, err := h.Usecase.Register(user, input.Password, &input.Address)
+	createdUser, err := h.Usecase.Register(actorFromContext(c), user, input.Password, &input.Address)
 	if err != nil {
-		if err.Error() == "email already in use" {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
+	if h.Account != nil {
+		// Registration succeeds regardless; the user can ask for a new token.
+		if err := h.Account.RequestEmailVerification(createdUser.ID); err != nil {
+			c.Logger().Errorf("failed to send email verification to user %d: %v", createdUser.ID, err)
+		}
+	}
+	h.mergeGuestCart(c, createdUser.ID)
 	return c.JSON(http.StatusCreated, createdUser)
 }
 
@@ -157,75 +177,149 @@ type loginInput struct {
 func (h *UserHandler) Login(c echo.Context) error {
 	var input loginInput
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidRequest)
+		return errInvalidBody
 	}
 	if err := c.Validate(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return err
 	}
 
-	user, err := h.Usecase.Login(input.Email, input.Password)
+	user, err := h.Usecase.Login(actorFromContext(c), input.Email, input.Password)
+	var lockedErr *usecase.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		retryAfter := math.Ceil(time.Until(lockedErr.Until).Seconds())
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Max(retryAfter, 1))))
+	}
 	if err != nil {
-		return echo.NewHTTPError(http.StatusUnauthorized, errInvalidCreds)
+		return err
 	}
 
-	token, err := auth.GenerateToken(user.ID, user.Role)
+	token, err := auth.GenerateToken(user.ID, user.Role, user.TokenVersion)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, errTokenGeneration)
+		return fmt.Errorf(errTokenGeneration, err)
 	}
 
-	return c.JSON(http.StatusOK, echo.Map{
+	resp := echo.Map{
 		"token": token,
 		"user":  user,
-	})
+	}
+	if merged := h.mergeGuestCart(c, user.ID); merged != nil {
+		resp["cart"] = merged
+	}
+	return c.JSON(http.StatusOK, resp)
 }
 
 func (h *UserHandler) Update(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidUserID)
+		return errInvalidUserID
 	}
 
 	if err := h.checkUserAccess(c, id); err != nil {
 		return err
 	}
 
-	var input model.User
+	// Only profile fields can be changed here; email, password and role have
+	// dedicated endpoints that re-authenticate or require an admin.
+	var input profileInput
 	if err := c.Bind(&input); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidReqBody)
+		return errInvalidBody
 	}
-	input.ID = id
-	input.Role = ""
 
-	updated, err := h.Usecase.Update(&input)
+	updated, err := h.Account.UpdateProfile(actorFromContext(c), id, input.toUpdate())
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errUserNotFound)
+		return errUserNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, updated)
 }
 
+// Delete removes a user outright and is for admins only. Users deleting
+// their own account are closed instead, which keeps their orders and needs
+// the current password just like DELETE /users/me.
 func (h *UserHandler) Delete(c echo.Context) error {
 	id, err := parseUintParam(c, "id")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errInvalidUserID)
+		return errInvalidUserID
 	}
 
-	if err := h.checkUserAccess(c, id); err != nil {
+	uid, role, err := h.getUserFromToken(c)
+	if err != nil {
 		return err
 	}
+	if uid == id && auth.PrincipalFromContext(c) == nil {
+		return h.CloseAccount(c)
+	}
+	if !auth.IsPrivileged(role) {
+		return usecase.ErrForbidden
+	}
 
-	err = h.Usecase.Delete(id)
+	err = h.Usecase.Delete(actorFromContext(c), id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return echo.NewHTTPError(http.StatusNotFound, errUserNotFound)
+		return errUserNotFound
 	} else if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
+type changeRoleInput struct {
+	Role string `json:"role"`
+}
+
+func (h *UserHandler) ChangeRole(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	var input changeRoleInput
+	if err := c.Bind(&input); err != nil {
+		return errInvalidBody
+	}
+
+	user, err := h.Usecase.ChangeRole(actorFromContext(c), id, input.Role)
+	return h.respondAdminChange(c, user, err)
+}
+
+func (h *UserHandler) Deactivate(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	user, err := h.Usecase.Deactivate(actorFromContext(c), id)
+	return h.respondAdminChange(c, user, err)
+}
+
+func (h *UserHandler) Reactivate(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	user, err := h.Usecase.Reactivate(actorFromContext(c), id)
+	return h.respondAdminChange(c, user, err)
+}
+
+func (h *UserHandler) respondAdminChange(c echo.Context, user *model.User, err error) error {
+	if err != nil {
+		return orNotFound(err, errUserNotFound)
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
 func parseUintParam(c echo.Context, name string) (uint, error) {
 	idParam := c.Param(name)
 	parsed, err := strconv.ParseUint(idParam, 10, 64)
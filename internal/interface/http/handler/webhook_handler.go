@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	errInvalidWebhookID         = usecase.NewError(usecase.KindValidation, "invalid_webhook_id", "invalid webhook ID")
+	errInvalidWebhookDeliveryID = usecase.NewError(usecase.KindValidation, "invalid_delivery_id", "invalid delivery ID")
+	errWebhookNotFound          = usecase.NewError(usecase.KindNotFound, "webhook_not_found", "webhook or delivery not found")
+)
+
+type WebhookHandler struct {
+	Usecase usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(uc usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{Usecase: uc}
+}
+
+type webhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+func (r webhookRequest) toInput() usecase.WebhookInput {
+	return usecase.WebhookInput{
+		URL:         r.URL,
+		Description: r.Description,
+		EventTypes:  r.EventTypes,
+		IsActive:    r.IsActive,
+	}
+}
+
+type webhookSecretResponse struct {
+	*model.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) GetAll(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	subscriptions, err := h.Usecase.GetAll()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *WebhookHandler) GetByID(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+	subscription, err := h.Usecase.GetByID(id)
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) Create(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	var req webhookRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	subscription, secret, err := h.Usecase.Create(actorFromContext(c), req.toInput())
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusCreated, webhookSecretResponse{WebhookSubscription: subscription, Secret: secret})
+}
+
+func (h *WebhookHandler) Update(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+	var req webhookRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody
+	}
+	subscription, err := h.Usecase.Update(actorFromContext(c), id, req.toInput())
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+	if err := h.Usecase.Delete(actorFromContext(c), id); err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) RotateSecret(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+	subscription, secret, err := h.Usecase.RotateSecret(actorFromContext(c), id)
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusOK, webhookSecretResponse{WebhookSubscription: subscription, Secret: secret})
+}
+
+// GetDeliveries lists the delivery log of a subscription, newest first.
+// Supported filters: status, event_type and limit.
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+
+	filters := map[string]string{}
+	for key, vals := range c.QueryParams() {
+		if len(vals) > 0 {
+			filters[key] = vals[0]
+		}
+	}
+	deliveries, err := h.Usecase.GetDeliveries(id, filters)
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	if _, err := requireHumanAdmin(c); err != nil {
+		return err
+	}
+	id, err := parseUintParam(c, "id")
+	if err != nil {
+		return errInvalidWebhookID
+	}
+	deliveryID, err := parseUintParam(c, "deliveryId")
+	if err != nil {
+		return errInvalidWebhookDeliveryID
+	}
+	delivery, err := h.Usecase.Redeliver(id, deliveryID)
+	if err != nil {
+		return orNotFound(err, errWebhookNotFound)
+	}
+	return c.JSON(http.StatusAccepted, delivery)
+}
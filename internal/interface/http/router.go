@@ -1,13 +1,29 @@
 package http
 
 import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/infrastructure/auth"
+	"go-ecommerce-api/internal/infrastructure/mail"
+	"go-ecommerce-api/internal/infrastructure/password"
 	"go-ecommerce-api/internal/infrastructure/persistence/repository"
+	"go-ecommerce-api/internal/infrastructure/ratelimit"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+	"go-ecommerce-api/internal/infrastructure/storage"
+	"go-ecommerce-api/internal/infrastructure/webhook"
 	"go-ecommerce-api/internal/interface/http/handler"
 	"go-ecommerce-api/internal/usecase"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"gorm.io/gorm"
 )
 
@@ -19,122 +35,582 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
 
-func NewRouter(db *gorm.DB) *echo.Echo {
+// newValidator reports fields by their JSON names, as clients know them.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// Rate limits applied by NewRouter. All windows are sliding.
+const (
+	apiRequestsPerIP     = 300
+	apiWindow            = time.Minute
+	loginAttemptsPerIP   = 20
+	loginAttemptsPerUser = 10
+	loginWindow          = 15 * time.Minute
+	registrationsPerIP   = 5
+	registrationWindow   = time.Hour
+	guestCartRequests    = 60
+	guestCartWindow      = time.Minute
+)
+
+// Polling intervals of the background dispatchers.
+const (
+	outboxPollInterval  = 2 * time.Second
+	webhookPollInterval = 5 * time.Second
+)
+
+type RateLimiters struct {
+	API          *ratelimit.Limiter
+	LoginByIP    *ratelimit.Limiter
+	LoginByEmail *ratelimit.Limiter
+	RegisterByIP *ratelimit.Limiter
+	GuestCart    *ratelimit.Limiter
+}
+
+func newRateLimiters(store ratelimit.Store) *RateLimiters {
+	return &RateLimiters{
+		API:          ratelimit.NewLimiter(store, "api", apiRequestsPerIP, apiWindow),
+		LoginByIP:    ratelimit.NewLimiter(store, "login_ip", loginAttemptsPerIP, loginWindow),
+		LoginByEmail: ratelimit.NewLimiter(store, "login_email", loginAttemptsPerUser, loginWindow),
+		RegisterByIP: ratelimit.NewLimiter(store, "register_ip", registrationsPerIP, registrationWindow),
+		GuestCart:    ratelimit.NewLimiter(store, "guest_cart_ip", guestCartRequests, guestCartWindow),
+	}
+}
+
+// Workers are the background loops started by NewRouter: the outbox and
+// webhook dispatchers and the job scheduler.
+type Workers struct {
+	cancel    context.CancelFunc
+	loops     sync.WaitGroup
+	scheduler usecase.SchedulerUsecase
+}
+
+// Shutdown stops starting new work and waits up to grace for running jobs
+// and the batch each dispatcher is working on, cancelling the jobs after
+// that. It reports whether everything finished in time.
+func (w *Workers) Shutdown(grace time.Duration) bool {
+	deadline := time.Now().Add(grace)
+	w.cancel()
+
+	// The scheduler loop has to return before the scheduler waits for its
+	// jobs, or a last tick could start one during the wait.
+	done := make(chan struct{})
+	go func() {
+		w.loops.Wait()
+		close(done)
+	}()
+	looped := true
+	select {
+	case <-done:
+	case <-time.After(time.Until(deadline)):
+		looped = false
+	}
+	return w.scheduler.Stop(time.Until(deadline)) && looped
+}
+
+// start runs loop in the background and tracks it for Shutdown.
+func (w *Workers) start(loop func()) {
+	w.loops.Add(1)
+	go func() {
+		defer w.loops.Done()
+		loop()
+	}()
+}
+
+func NewRouter(db *gorm.DB) (*echo.Echo, *Workers) {
 	e := echo.New()
-	e.Validator = &CustomValidator{validator: validator.New()}
+	ipExtractor, err := ratelimit.IPExtractorFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	e.IPExtractor = ipExtractor
+	e.Validator = &CustomValidator{validator: newValidator()}
+	e.HTTPErrorHandler = handler.NewHTTPErrorHandler(localeConfigFromEnv().Default)
+	e.Use(middleware.RequestID())
+
+	limiters := newRateLimiters(ratelimit.NewMemoryStore())
+	e.Use(ratelimit.Middleware(limiters.API, ratelimit.ByIP))
+
+	// Uploaded images are served from their directory unless UPLOADS_URL
+	// points elsewhere.
+	uploads := storage.FromEnv()
+	if strings.HasPrefix(uploads.BaseURL(), "/") {
+		e.Static(uploads.BaseURL(), uploads.Root())
+	}
 
 	// Initialize repositories and use cases
-	handlers := initializeHandlers(db)
+	handlers := initializeHandlers(db, uploads)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := &Workers{cancel: cancel, scheduler: handlers.Job.Usecase}
+	workers.start(func() { handlers.Outbox.Usecase.Run(ctx, outboxPollInterval) })
+	workers.start(func() { handlers.Webhook.Usecase.Run(ctx, webhookPollInterval) })
+	workers.start(func() { handlers.Job.Usecase.Run(ctx) })
+
+	authMW := auth.JWTMiddleware(apiKeyValidator(handlers.APIKey.Usecase), handlers.User.Usecase.CheckActive)
 
 	// Setup routes
-	setupPublicRoutes(e, handlers)
-	setupAuthenticatedRoutes(e, handlers)
+	setupPublicRoutes(e, handlers, limiters)
+	setupAuthenticatedRoutes(e, handlers, limiters, authMW)
+
+	return e, workers
+}
 
-	return e
+// apiKeyValidator lets auth.JWTMiddleware accept X-API-Key as an alternative to a JWT.
+func apiKeyValidator(uc usecase.APIKeyUsecase) auth.APIKeyValidator {
+	return func(rawKey string) (*auth.Principal, error) {
+		key, err := uc.Authenticate(rawKey)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewServicePrincipal(key.ID, key.Scopes), nil
+	}
 }
 
 type Handlers struct {
-	User     *handler.UserHandler
-	Category *handler.CategoryHandler
-	Product  *handler.ProductHandler
-	Cart     *handler.CartHandler
-	Order    *handler.OrderHandler
+	User          *handler.UserHandler
+	Category      *handler.CategoryHandler
+	Product       *handler.ProductHandler
+	Cart          *handler.CartHandler
+	Order         *handler.OrderHandler
+	APIKey        *handler.APIKeyHandler
+	Impersonation *handler.ImpersonationHandler
+	Privacy       *handler.PrivacyHandler
+	Audit         *handler.AuditHandler
+	Outbox        *handler.OutboxHandler
+	Webhook       *handler.WebhookHandler
+	Job           *handler.JobHandler
+	Shipping      *handler.ShippingHandler
+	Tax           *handler.TaxHandler
+	Currency      *handler.CurrencyHandler
+	Pricing       *handler.PricingHandler
+	ProductImport *handler.ProductImportHandler
+	Image         *handler.ImageHandler
+	Invoice       *handler.InvoiceHandler
+	Feed          *handler.FeedHandler
+	Translation   *handler.TranslationHandler
 }
 
-func initializeHandlers(db *gorm.DB) *Handlers {
+func initializeHandlers(db *gorm.DB, uploads storage.Storage) *Handlers {
 	// Initialize repositories
 	addressRepo := repository.NewAddressRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
 	productRepo := repository.NewProductRepository(db)
+	productImageRepo := repository.NewProductImageRepository(db)
+	productImportRepo := repository.NewProductImportRepository(db)
+	slugRedirectRepo := repository.NewSlugRedirectRepository(db)
+	translationRepo := repository.NewTranslationRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
 	cartItemRepo := repository.NewCartItemRepository(db)
 	cartRepo := repository.NewCartRepository(db)
+	cartReminderRepo := repository.NewCartReminderRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
+	shippingZoneRepo := repository.NewShippingZoneRepository(db)
+	shippingMethodRepo := repository.NewShippingMethodRepository(db)
+	taxRateRepo := repository.NewTaxRateRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+	priceScheduleRepo := repository.NewPriceScheduleRepository(db)
+	priceHistoryRepo := repository.NewPriceHistoryRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	impersonationRepo := repository.NewImpersonationRepository(db)
+	emailChangeRepo := repository.NewEmailChangeRepository(db)
+	privacyRepo := repository.NewPrivacyRequestRepository(db)
+	auditRepo := repository.NewAuditEventRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	transactor := repository.NewTransactor(db)
+	webhookSubscriptionRepo := repository.NewWebhookSubscriptionRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	jobLeaseRepo := repository.NewJobLeaseRepository(db)
+	jobRunRepo := repository.NewJobRunRepository(db)
+
+	hasher := password.HasherFromEnv()
+	policy := password.PolicyFromEnv()
+	mailer := mail.FromEnv()
 
 	// Initialize use cases
-	userUC := usecase.NewUserUsecase(userRepo, addressRepo)
-	catUC := usecase.NewCategoryUsecase(categoryRepo)
-	prodUC := usecase.NewProductUsecase(productRepo)
-	cartUC := usecase.NewCartUsecase(cartRepo, cartItemRepo, productRepo)
-	orderUC := usecase.NewOrderUsecase(orderRepo, cartRepo, cartItemRepo, productRepo, userRepo, addressRepo)
+	auditUC := usecase.NewAuditUsecase(auditRepo)
+	outboxUC := usecase.NewOutboxUsecase(outboxRepo)
+	userUC := usecase.NewUserUsecase(userRepo, addressRepo, hasher, policy, auditUC)
+	catUC := usecase.NewCategoryUsecase(categoryRepo, slugRedirectRepo, auditUC)
+	currencyUC := usecase.NewCurrencyUsecase(exchangeRateRepo, userRepo, auditUC, currencyConfigFromEnv())
+	pricingUC := usecase.NewPricingUsecase(productRepo, priceScheduleRepo, priceHistoryRepo, auditUC)
+	translationUC := usecase.NewTranslationUsecase(translationRepo, productRepo, categoryRepo, auditUC, localeConfigFromEnv())
+	prodUC := usecase.NewProductUsecase(productRepo, transactor, currencyUC, pricingUC, slugRedirectRepo, auditUC)
+	feedUC := usecase.NewFeedUsecase(catalogRepo, productRepo, categoryRepo, pricingUC, feedConfigFromEnv())
+	imageUC := usecase.NewImageUsecase(productImageRepo, productRepo, categoryRepo, uploads, auditUC, imageConfigFromEnv())
+	productImportUC := usecase.NewProductImportUsecase(productImportRepo, productRepo, categoryRepo, prodUC, imageUC, currencyUC, productImportConfigFromEnv())
+	cartUC := usecase.NewCartUsecase(cartRepo, cartItemRepo, productRepo, transactor, currencyUC, pricingUC)
+	shippingUC := usecase.NewShippingUsecase(shippingZoneRepo, shippingMethodRepo, userRepo, cartUC, auditUC)
+	taxUC := usecase.NewTaxUsecase(taxRateRepo, auditUC, taxConfigFromEnv())
+	orderUC := usecase.NewOrderUsecase(orderRepo, cartRepo, cartItemRepo, productRepo, userRepo, addressRepo, transactor, shippingUC, taxUC, currencyUC, pricingUC, translationUC, auditUC)
+	invoiceUC := usecase.NewInvoiceUsecase(invoiceRepo, orderRepo, auditUC, invoiceConfigFromEnv())
+	invoiceUC.Subscribe(outboxUC)
+	signer := signedtoken.FromEnv()
+	guestOrderUC := usecase.NewGuestOrderUsecase(orderUC, orderRepo, userRepo, mailer, signer, auditUC, guestOrderConfigFromEnv())
+	accountUC := usecase.NewAccountUsecase(userRepo, addressRepo, orderRepo, emailChangeRepo, hasher, policy, mailer, signer, guestOrderUC, auditUC)
+	apiKeyUC := usecase.NewAPIKeyUsecase(apiKeyRepo, auditUC)
+	impersonationUC := usecase.NewImpersonationUsecase(impersonationRepo, userRepo, auditUC)
+	webhookUC := usecase.NewWebhookUsecase(webhookSubscriptionRepo, webhookDeliveryRepo, webhook.SenderFromEnv(), auditUC)
+	webhookUC.Subscribe(outboxUC)
+	cartRecoveryUC := usecase.NewCartRecoveryUsecase(cartRepo, cartReminderRepo, userRepo, cartUC, mailer, signer, cartRecoveryConfigFromEnv())
+	cartRecoveryUC.Subscribe(outboxUC)
+	privacyUC := usecase.NewPrivacyUsecase(privacyRepo, userRepo, addressRepo, orderRepo, cartRepo, cartItemRepo, transactor, auditUC)
+	maintenanceUC := usecase.NewMaintenanceUsecase(orderRepo, emailChangeRepo, cartRepo, orderUC, maintenanceConfigFromEnv())
+	schedulerUC := usecase.NewSchedulerUsecase(jobLeaseRepo, jobRunRepo)
+	jobs := append(maintenanceUC.Jobs(), cartRecoveryUC.Jobs()...)
+	jobs = append(jobs, currencyUC.Jobs()...)
+	jobs = append(jobs, pricingUC.Jobs()...)
+	jobs = append(jobs, productImportUC.Jobs()...)
+	for _, job := range jobs {
+		if err := schedulerUC.Register(job); err != nil {
+			panic(err)
+		}
+	}
+	if err := usecase.BackfillSlugs(productRepo, categoryRepo, slugRedirectRepo); err != nil {
+		panic(err)
+	}
 
 	// Initialize handlers
 	return &Handlers{
-		User:     handler.NewUserHandler(userUC),
-		Category: handler.NewCategoryHandler(catUC),
-		Product:  handler.NewProductHandler(prodUC),
-		Cart:     handler.NewCartHandler(cartUC),
-		Order:    handler.NewOrderHandler(orderUC),
+		User:          handler.NewUserHandler(userUC, accountUC, cartUC),
+		Category:      handler.NewCategoryHandler(catUC, translationUC),
+		Product:       handler.NewProductHandler(prodUC, currencyUC, taxUC, translationUC),
+		Cart:          handler.NewCartHandler(cartUC, cartRecoveryUC, currencyUC, taxUC),
+		Order:         handler.NewOrderHandler(orderUC, guestOrderUC, currencyUC, translationUC),
+		APIKey:        handler.NewAPIKeyHandler(apiKeyUC),
+		Impersonation: handler.NewImpersonationHandler(impersonationUC),
+		Privacy:       handler.NewPrivacyHandler(privacyUC),
+		Audit:         handler.NewAuditHandler(auditUC),
+		Outbox:        handler.NewOutboxHandler(outboxUC),
+		Webhook:       handler.NewWebhookHandler(webhookUC),
+		Job:           handler.NewJobHandler(schedulerUC),
+		Shipping:      handler.NewShippingHandler(shippingUC),
+		Tax:           handler.NewTaxHandler(taxUC),
+		Currency:      handler.NewCurrencyHandler(currencyUC),
+		Pricing:       handler.NewPricingHandler(pricingUC),
+		ProductImport: handler.NewProductImportHandler(productImportUC, schedulerUC),
+		Image:         handler.NewImageHandler(imageUC),
+		Invoice:       handler.NewInvoiceHandler(invoiceUC, orderUC),
+		Feed:          handler.NewFeedHandler(feedUC),
+		Translation:   handler.NewTranslationHandler(translationUC),
+	}
+}
+
+// maintenanceConfigFromEnv reads ORDER_PAYMENT_TIMEOUT_HOURS, STALE_CART_DAYS
+// and UNUSED_GUEST_CART_HOURS, falling back to the defaults when unset or
+// invalid.
+func maintenanceConfigFromEnv() usecase.MaintenanceConfig {
+	config := usecase.DefaultMaintenanceConfig()
+	if v, err := strconv.Atoi(os.Getenv("ORDER_PAYMENT_TIMEOUT_HOURS")); err == nil && v > 0 {
+		config.UnpaidOrderTimeout = time.Duration(v) * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("STALE_CART_DAYS")); err == nil && v > 0 {
+		config.StaleCartAge = time.Duration(v) * 24 * time.Hour
 	}
+	if v, err := strconv.Atoi(os.Getenv("UNUSED_GUEST_CART_HOURS")); err == nil && v > 0 {
+		config.UnusedGuestCartAge = time.Duration(v) * time.Hour
+	}
+	return config
+}
+
+// cartRecoveryConfigFromEnv reads ABANDONED_CART_HOURS, CART_REMINDER_TTL_DAYS
+// and CART_RESTORE_URL, falling back to the defaults when unset or invalid.
+func cartRecoveryConfigFromEnv() usecase.CartRecoveryConfig {
+	config := usecase.DefaultCartRecoveryConfig()
+	if v, err := strconv.Atoi(os.Getenv("ABANDONED_CART_HOURS")); err == nil && v > 0 {
+		config.AbandonAfter = time.Duration(v) * time.Hour
+	}
+	if v, err := strconv.Atoi(os.Getenv("CART_REMINDER_TTL_DAYS")); err == nil && v > 0 {
+		config.ReminderTTL = time.Duration(v) * 24 * time.Hour
+	}
+	if v := os.Getenv("CART_RESTORE_URL"); v != "" {
+		config.RestoreURL = v
+	}
+	return config
 }
 
-func setupPublicRoutes(e *echo.Echo, h *Handlers) {
+// guestOrderConfigFromEnv reads ORDER_LOOKUP_URL and ORDER_LINK_TTL_DAYS,
+// falling back to the defaults when unset or invalid.
+func guestOrderConfigFromEnv() usecase.GuestOrderConfig {
+	config := usecase.DefaultGuestOrderConfig()
+	if v := os.Getenv("ORDER_LOOKUP_URL"); v != "" {
+		config.LookupURL = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("ORDER_LINK_TTL_DAYS")); err == nil && v > 0 {
+		config.LinkTTL = time.Duration(v) * 24 * time.Hour
+	}
+	return config
+}
+
+// taxConfigFromEnv reads PRICE_MODE ("gross" or "net") and
+// TAX_DISPLAY_COUNTRY, falling back to the defaults when unset or invalid.
+func taxConfigFromEnv() usecase.TaxConfig {
+	config := usecase.DefaultTaxConfig()
+	switch mode := model.PriceMode(strings.ToLower(os.Getenv("PRICE_MODE"))); mode {
+	case model.PriceModeGross, model.PriceModeNet:
+		config.PriceMode = mode
+	}
+	config.DisplayCountry = strings.TrimSpace(os.Getenv("TAX_DISPLAY_COUNTRY"))
+	return config
+}
+
+// currencyConfigFromEnv reads BASE_CURRENCY and EXCHANGE_RATES_FILE, falling
+// back to the defaults when unset.
+func currencyConfigFromEnv() usecase.CurrencyConfig {
+	config := usecase.DefaultCurrencyConfig()
+	if v := os.Getenv("BASE_CURRENCY"); v != "" {
+		config.BaseCurrency = v
+	}
+	config.RatesFile = os.Getenv("EXCHANGE_RATES_FILE")
+	return config
+}
+
+// productImportConfigFromEnv reads PRODUCT_IMPORT_SYNC_ROWS, falling back to
+// the default when unset or invalid.
+func productImportConfigFromEnv() usecase.ProductImportConfig {
+	config := usecase.DefaultProductImportConfig()
+	if v, err := strconv.Atoi(os.Getenv("PRODUCT_IMPORT_SYNC_ROWS")); err == nil && v >= 0 {
+		config.SyncRows = v
+	}
+	return config
+}
+
+// feedConfigFromEnv reads STOREFRONT_URL, API_URL and SITEMAP_URLS_PER_FILE,
+// falling back to the defaults when unset or invalid.
+func feedConfigFromEnv() usecase.FeedConfig {
+	config := usecase.DefaultFeedConfig()
+	if v := os.Getenv("STOREFRONT_URL"); v != "" {
+		config.StorefrontURL = v
+	}
+	if v := os.Getenv("API_URL"); v != "" {
+		config.APIURL = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("SITEMAP_URLS_PER_FILE")); err == nil && v > 0 {
+		config.SitemapSize = v
+	}
+	return config
+}
+
+// localeConfigFromEnv reads DEFAULT_LOCALE and SUPPORTED_LOCALES (comma
+// separated), falling back to the defaults when unset.
+func localeConfigFromEnv() usecase.LocaleConfig {
+	config := usecase.DefaultLocaleConfig()
+	if v := os.Getenv("DEFAULT_LOCALE"); v != "" {
+		config.Default = v
+	}
+	if v := os.Getenv("SUPPORTED_LOCALES"); v != "" {
+		config.Supported = strings.Split(v, ",")
+	}
+	return config
+}
+
+// imageConfigFromEnv reads IMAGE_MAX_FILE_SIZE_MB and IMAGE_MAX_PIXELS,
+// falling back to the defaults when unset or invalid.
+func imageConfigFromEnv() usecase.ImageConfig {
+	config := usecase.DefaultImageConfig()
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_MAX_FILE_SIZE_MB")); err == nil && v > 0 {
+		config.MaxFileSize = int64(v) << 20
+	}
+	if v, err := strconv.Atoi(os.Getenv("IMAGE_MAX_PIXELS")); err == nil && v > 0 {
+		config.MaxPixels = v
+	}
+	return config
+}
+
+// invoiceConfigFromEnv reads SELLER_NAME, SELLER_ADDRESS, SELLER_TAX_ID,
+// INVOICE_PREFIX and CREDIT_NOTE_PREFIX, falling back to the defaults when unset.
+func invoiceConfigFromEnv() usecase.InvoiceConfig {
+	config := usecase.DefaultInvoiceConfig()
+	for env, field := range map[string]*string{
+		"SELLER_NAME":        &config.SellerName,
+		"SELLER_ADDRESS":     &config.SellerAddress,
+		"SELLER_TAX_ID":      &config.SellerTaxID,
+		"INVOICE_PREFIX":     &config.InvoicePrefix,
+		"CREDIT_NOTE_PREFIX": &config.CreditNotePrefix,
+	} {
+		if v := os.Getenv(env); v != "" {
+			*field = v
+		}
+	}
+	return config
+}
+
+func setupPublicRoutes(e *echo.Echo, h *Handlers, l *RateLimiters) {
 	e.Static("/images", "assets/images/")
 
 	// Public user routes
-	e.POST("/users/register", h.User.Register)
-	e.POST("/users/login", h.User.Login)
+	e.POST("/users/register", h.User.Register,
+		ratelimit.Middleware(l.RegisterByIP, ratelimit.ByIP))
+	e.POST("/users/login", h.User.Login,
+		ratelimit.Middleware(l.LoginByIP, ratelimit.ByIP),
+		ratelimit.Middleware(l.LoginByEmail, ratelimit.ByEmail))
+	e.POST("/users/email/confirm", h.User.ConfirmEmailChange,
+		ratelimit.Middleware(l.LoginByIP, ratelimit.ByIP))
+	e.POST("/users/email/verify", h.User.VerifyEmail,
+		ratelimit.Middleware(l.LoginByIP, ratelimit.ByIP))
+
+	// Public guest order routes
+	e.POST("/orders/guest", h.Order.GuestCheckout,
+		ratelimit.Middleware(l.RegisterByIP, ratelimit.ByIP))
+	e.POST("/orders/lookup", h.Order.LookupOrder,
+		ratelimit.Middleware(l.LoginByIP, ratelimit.ByIP))
+	e.GET("/orders/lookup", h.Order.LookupOrderByToken,
+		ratelimit.Middleware(l.LoginByIP, ratelimit.ByIP))
 
 	// Public category routes
 	e.GET("/categories", h.Category.GetAll)
 	e.GET("/categories/:id", h.Category.GetByID)
 	e.GET("/categories/:id/subcategories", h.Category.GetSubcategories)
 	e.GET("/categories/search", h.Category.Search)
+	e.GET("/categories/tree", h.Category.Tree)
+	e.GET("/categories/:id/path", h.Category.Path)
+	e.GET("/categories/by-slug/:slug", h.Category.GetBySlug)
 
-	// Public product routes
-	e.GET("/products", h.Product.GetAll)
-	e.GET("/products/search", h.Product.Search)
-	e.GET("/products/:id", h.Product.GetByID)
+	e.GET("/sitemap.xml", h.Feed.SitemapIndex)
+	e.GET("/sitemaps/:file", h.Feed.Sitemap)
+	e.GET("/feeds/products.:format", h.Feed.ProductFeed)
+
+	// Public currency and language routes
+	e.GET("/currencies", h.Currency.GetCurrencies)
+	e.GET("/locales", h.Translation.GetLocales)
 }
 
-func setupAuthenticatedRoutes(e *echo.Echo, h *Handlers) {
-	setupUserRoutes(e, h)
-	setupCategoryRoutes(e, h)
-	setupProductRoutes(e, h)
-	setupCartRoutes(e, h)
-	setupOrderRoutes(e, h)
+func setupAuthenticatedRoutes(e *echo.Echo, h *Handlers, l *RateLimiters, authMW echo.MiddlewareFunc) {
+	setupUserRoutes(e, h, authMW)
+	setupCategoryRoutes(e, h, authMW)
+	setupProductRoutes(e, h, authMW)
+	setupCartRoutes(e, h, l, authMW)
+	setupOrderRoutes(e, h, authMW)
+	setupAPIKeyRoutes(e, h, authMW)
+	setupPrivacyRoutes(e, h, authMW)
+	setupWebhookRoutes(e, h, authMW)
+	setupJobRoutes(e, h, authMW)
+	setupShippingRoutes(e, h, authMW)
+	setupTaxRoutes(e, h, authMW)
+	setupCurrencyRoutes(e, h, authMW)
 }
 
-func setupUserRoutes(e *echo.Echo, h *Handlers) {
+func setupUserRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
 	userGroup := e.Group("/users")
-	userGroup.Use(auth.JWTMiddleware())
+	userGroup.Use(authMW, auth.RequireScope("users"))
+
+	// Self-service
+	userGroup.GET("/me", h.User.GetMe)
+	userGroup.PATCH("/me", h.User.UpdateMe)
+	userGroup.POST("/me/password", h.User.ChangePassword)
+	userGroup.POST("/me/email", h.User.RequestEmailChange)
+	userGroup.POST("/me/email/verification", h.User.RequestEmailVerification)
+	userGroup.DELETE("/me", h.User.CloseAccount)
+	userGroup.GET("/me/export", h.Privacy.Export)
+	userGroup.POST("/me/erasure", h.Privacy.RequestErasure)
+	userGroup.PUT("/me/currency", h.Currency.SetPreference)
+
 	userGroup.GET("/:id", h.User.GetByID)
 	userGroup.GET("", h.User.GetAll)
 	userGroup.GET("/search", h.User.Search)
 	userGroup.PUT("/:id", h.User.Update)
 	userGroup.DELETE("/:id", h.User.Delete)
+
+	// Admin user management
+	userGroup.PUT("/:id/role", h.User.ChangeRole)
+	userGroup.POST("/:id/deactivate", h.User.Deactivate)
+	userGroup.POST("/:id/reactivate", h.User.Reactivate)
+	userGroup.POST("/:id/impersonate", h.Impersonation.Start)
+	e.GET("/impersonations", h.Impersonation.Search, authMW)
+	e.GET("/audit", h.Audit.Search, authMW)
+	e.GET("/outbox", h.Outbox.Search, authMW)
+	e.POST("/outbox/:id/retry", h.Outbox.Retry, authMW)
 }
 
-func setupCategoryRoutes(e *echo.Echo, h *Handlers) {
+func setupCategoryRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
 	categoryGroup := e.Group("/categories")
-	categoryGroup.Use(auth.JWTMiddleware())
+	categoryGroup.Use(authMW, auth.RequireScope("categories"))
 	categoryGroup.POST("", h.Category.Create)
 	categoryGroup.PUT("/:id", h.Category.Update)
 	categoryGroup.DELETE("/:id", h.Category.Delete)
+	categoryGroup.PUT("/:id/parent", h.Category.Move)
+	categoryGroup.PUT("/:id/image", h.Image.UploadCategoryImage)
+	categoryGroup.DELETE("/:id/image", h.Image.DeleteCategoryImage)
+	categoryGroup.GET("/:id/translations", h.Translation.GetCategoryTranslations)
+	categoryGroup.PUT("/:id/translations/:locale", h.Translation.SetCategoryTranslation)
+	categoryGroup.DELETE("/:id/translations/:locale", h.Translation.DeleteCategoryTranslation)
 }
 
-func setupProductRoutes(e *echo.Echo, h *Handlers) {
+func setupProductRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	// Browsing is public; signed-in users see prices in their preferred currency.
+	browseGroup := e.Group("/products")
+	browseGroup.Use(auth.Optional(authMW), auth.RequireScope("products"))
+	browseGroup.GET("", h.Product.GetAll)
+	browseGroup.GET("/search", h.Product.Search)
+	browseGroup.GET("/:id", h.Product.GetByID)
+	browseGroup.GET("/by-slug/:slug", h.Product.GetBySlug)
+	browseGroup.GET("/:id/price-history", h.Pricing.History)
+
 	productGroup := e.Group("/products")
-	productGroup.Use(auth.JWTMiddleware())
+	productGroup.Use(authMW, auth.RequireScope("products"))
 	productGroup.POST("", h.Product.Create)
 	productGroup.PUT("/:id", h.Product.Update)
 	productGroup.DELETE("/:id", h.Product.Delete)
+	productGroup.GET("/:id/price-schedules", h.Pricing.GetSchedules)
+	productGroup.POST("/:id/price-schedules", h.Pricing.CreateSchedule)
+	productGroup.PUT("/:id/price-schedules/:scheduleId", h.Pricing.UpdateSchedule)
+	productGroup.DELETE("/:id/price-schedules/:scheduleId", h.Pricing.DeleteSchedule)
+	productGroup.POST("/:id/images", h.Image.UploadProductImage)
+	productGroup.PUT("/:id/images/order", h.Image.ReorderProductImages)
+	productGroup.PUT("/:id/images/:imageId/primary", h.Image.SetPrimaryProductImage)
+	productGroup.DELETE("/:id/images/:imageId", h.Image.DeleteProductImage)
+	productGroup.GET("/:id/translations", h.Translation.GetProductTranslations)
+	productGroup.PUT("/:id/translations/:locale", h.Translation.SetProductTranslation)
+	productGroup.DELETE("/:id/translations/:locale", h.Translation.DeleteProductTranslation)
+	productGroup.POST("/import", h.ProductImport.Import)
+	productGroup.GET("/imports", h.ProductImport.GetImports)
+	productGroup.GET("/imports/:importId", h.ProductImport.GetImport)
+	productGroup.GET("/export", h.ProductImport.Export)
 }
 
-func setupCartRoutes(e *echo.Echo, h *Handlers) {
+func setupCartRoutes(e *echo.Echo, h *Handlers, l *RateLimiters, authMW echo.MiddlewareFunc) {
+	// Guests identify their cart with the X-Cart-Token header instead of a JWT.
+	// Anonymous requests are limited per IP since they can create carts.
+	guestGroup := e.Group("")
+	guestGroup.Use(auth.Optional(authMW), auth.RequireScope("carts"),
+		ratelimit.Middleware(l.GuestCart, anonymousIP))
+	guestGroup.POST("/cart/guest", h.Cart.CreateGuestCart)
+	guestGroup.GET("/cart", h.Cart.GetCart)
+	guestGroup.POST("/cart/add", h.Cart.AddProduct)
+	guestGroup.PUT("/cart/item/:id", h.Cart.UpdateItem)
+	guestGroup.DELETE("/cart/item/:id", h.Cart.RemoveItem)
+	guestGroup.DELETE("/cart/clear", h.Cart.ClearCart)
+	guestGroup.GET("/cart/shipping-options", h.Shipping.Options)
+
 	cartGroup := e.Group("")
-	cartGroup.Use(auth.JWTMiddleware())
-	cartGroup.GET("/cart", h.Cart.GetByUserID)
-	cartGroup.POST("/cart/add", h.Cart.AddProduct)
-	cartGroup.PUT("/cart/item/:id", h.Cart.UpdateItem)
-	cartGroup.DELETE("/cart/item/:id", h.Cart.RemoveItem)
-	cartGroup.DELETE("/cart/clear", h.Cart.ClearCart)
+	cartGroup.Use(authMW, auth.RequireScope("carts"))
 	cartGroup.GET("/cart/search", h.Cart.Search)
+	cartGroup.GET("/cart/abandoned", h.Cart.Abandoned)
+	cartGroup.POST("/cart/restore", h.Cart.Restore)
 }
 
-func setupOrderRoutes(e *echo.Echo, h *Handlers) {
+// anonymousIP keys requests without a token or API key by client IP and
+// skips the rest.
+func anonymousIP(c echo.Context) string {
+	if _, err := auth.UserIDFromContext(c); err == nil {
+		return ""
+	}
+	return ratelimit.ByIP(c)
+}
+
+func setupOrderRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
 	orderGroup := e.Group("")
-	orderGroup.Use(auth.JWTMiddleware())
+	orderGroup.Use(authMW, auth.RequireScope("orders"))
 	orderGroup.POST("/orders", h.Order.CreateOrder)
 	orderGroup.GET("/orders/:id", h.Order.GetOrder)
 	orderGroup.GET("/orders", h.Order.GetAllOrders)
@@ -142,4 +618,82 @@ func setupOrderRoutes(e *echo.Echo, h *Handlers) {
 	orderGroup.PUT("/orders/:id/status", h.Order.UpdateStatus)
 	orderGroup.PUT("/orders/:id/cancel", h.Order.CancelOrder)
 	orderGroup.GET("/orders/search", h.Order.Search)
+	orderGroup.GET("/orders/:id/invoice", h.Invoice.GetInvoice)
+	orderGroup.GET("/orders/:id/invoices", h.Invoice.GetDocuments)
+	orderGroup.GET("/orders/:id/invoices/:invoiceId", h.Invoice.GetDocument)
+	orderGroup.POST("/orders/:id/credit-notes", h.Invoice.CreateCreditNote)
+}
+
+func setupAPIKeyRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	apiKeyGroup := e.Group("/api-keys")
+	apiKeyGroup.Use(authMW)
+	apiKeyGroup.GET("", h.APIKey.GetAll)
+	apiKeyGroup.POST("", h.APIKey.Create)
+	apiKeyGroup.DELETE("/:id", h.APIKey.Revoke)
+}
+
+func setupPrivacyRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	privacyGroup := e.Group("/privacy-requests")
+	privacyGroup.Use(authMW)
+	privacyGroup.GET("", h.Privacy.Search)
+	privacyGroup.POST("", h.Privacy.Create)
+	privacyGroup.POST("/:id/complete", h.Privacy.Complete)
+	privacyGroup.POST("/:id/reject", h.Privacy.Reject)
+}
+
+func setupWebhookRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	webhookGroup := e.Group("/webhooks")
+	webhookGroup.Use(authMW)
+	webhookGroup.GET("", h.Webhook.GetAll)
+	webhookGroup.POST("", h.Webhook.Create)
+	webhookGroup.GET("/:id", h.Webhook.GetByID)
+	webhookGroup.PUT("/:id", h.Webhook.Update)
+	webhookGroup.DELETE("/:id", h.Webhook.Delete)
+	webhookGroup.POST("/:id/rotate-secret", h.Webhook.RotateSecret)
+	webhookGroup.GET("/:id/deliveries", h.Webhook.GetDeliveries)
+	webhookGroup.POST("/:id/deliveries/:deliveryId/redeliver", h.Webhook.Redeliver)
+}
+
+func setupJobRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	jobGroup := e.Group("/jobs")
+	jobGroup.Use(authMW)
+	jobGroup.GET("", h.Job.GetAll)
+	jobGroup.GET("/runs", h.Job.GetRuns)
+	jobGroup.POST("/:name/run", h.Job.RunNow)
+}
+
+func setupShippingRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	shippingGroup := e.Group("/shipping")
+	shippingGroup.Use(authMW, auth.RequireScope("shipping"))
+	shippingGroup.GET("/zones", h.Shipping.GetZones)
+	shippingGroup.POST("/zones", h.Shipping.CreateZone)
+	shippingGroup.GET("/zones/:id", h.Shipping.GetZone)
+	shippingGroup.PUT("/zones/:id", h.Shipping.UpdateZone)
+	shippingGroup.DELETE("/zones/:id", h.Shipping.DeleteZone)
+	shippingGroup.GET("/methods", h.Shipping.GetMethods)
+	shippingGroup.POST("/methods", h.Shipping.CreateMethod)
+	shippingGroup.GET("/methods/:id", h.Shipping.GetMethod)
+	shippingGroup.PUT("/methods/:id", h.Shipping.UpdateMethod)
+	shippingGroup.DELETE("/methods/:id", h.Shipping.DeleteMethod)
+}
+
+func setupTaxRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	taxGroup := e.Group("/tax")
+	taxGroup.Use(authMW, auth.RequireScope("tax"))
+	taxGroup.GET("/rates", h.Tax.GetRates)
+	taxGroup.POST("/rates", h.Tax.CreateRate)
+	taxGroup.GET("/rates/:id", h.Tax.GetRate)
+	taxGroup.PUT("/rates/:id", h.Tax.UpdateRate)
+	taxGroup.DELETE("/rates/:id", h.Tax.DeleteRate)
+}
+
+func setupCurrencyRoutes(e *echo.Echo, h *Handlers, authMW echo.MiddlewareFunc) {
+	currencyGroup := e.Group("/currencies")
+	currencyGroup.Use(authMW, auth.RequireScope("currencies"))
+	currencyGroup.GET("/rates", h.Currency.GetRates)
+	currencyGroup.POST("/rates", h.Currency.CreateRate)
+	currencyGroup.POST("/rates/import", h.Currency.ImportRates)
+	currencyGroup.GET("/rates/:id", h.Currency.GetRate)
+	currencyGroup.PUT("/rates/:id", h.Currency.UpdateRate)
+	currencyGroup.DELETE("/rates/:id", h.Currency.DeleteRate)
 }
@@ -0,0 +1,457 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/mail"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"gorm.io/gorm"
+)
+
+const (
+	emailChangeTokenBytes = 32
+	emailChangeTTL        = 24 * time.Hour
+	emailVerificationTTL  = 7 * 24 * time.Hour
+	// emailVerifyPurpose is followed by the address being verified, so a
+	// token stops working once the email changes.
+	emailVerifyPurpose    = "email-verify:"
+	anonymizedEmailDomain = "anonymized.invalid"
+	anonymizedName        = "Deleted"
+	anonymizedSurname     = "User"
+	// unusablePasswordHash is not produced by any hasher, so Verify always fails.
+	unusablePasswordHash = "!"
+)
+
+var (
+	ErrWrongPassword        = NewError(KindForbidden, "wrong_password", "current password is incorrect")
+	ErrEmailInUse           = NewError(KindConflict, "email_in_use", "email already in use")
+	ErrInvalidEmail         = NewError(KindValidation, "invalid_email", "invalid email address")
+	ErrInvalidConfirmation  = NewError(KindValidation, "invalid_confirmation", "invalid or expired confirmation token")
+	ErrEmailAlreadyVerified = NewError(KindConflict, "email_already_verified", "email address is already verified")
+)
+
+// ProfileUpdate carries the self-editable profile fields. Nil fields are left unchanged.
+type ProfileUpdate struct {
+	Name    *string
+	Surname *string
+	Address *AddressUpdate
+}
+
+type AddressUpdate struct {
+	Country  *string
+	City     *string
+	Postcode *string
+	Street   *string
+	Number   *string
+	Company  *string
+	TaxID    *string
+}
+
+// AccountUsecase covers what users do with their own account.
+type AccountUsecase interface {
+	GetProfile(userID uint) (*model.User, error)
+	UpdateProfile(actor Actor, userID uint, update ProfileUpdate) (*model.User, error)
+	// ChangePassword sets a new password and revokes the tokens issued
+	// before it. It returns the user so the caller can be given a new token.
+	ChangePassword(actor Actor, userID uint, current, next string) (*model.User, error)
+	// RequestEmailChange sends a confirmation token to newEmail; the address
+	// changes only once ConfirmEmailChange is called with that token.
+	RequestEmailChange(userID uint, current, newEmail string) (*model.EmailChange, error)
+	ConfirmEmailChange(actor Actor, token string) (*model.User, error)
+	// RequestEmailVerification sends the user a token proving they own their email.
+	RequestEmailVerification(userID uint) error
+	// VerifyEmail marks the email behind token as verified and attaches the
+	// guest orders placed with it to the account.
+	VerifyEmail(actor Actor, token string) (*model.User, error)
+	// Close anonymizes the account instead of deleting it, so orders stay intact.
+	Close(actor Actor, userID uint, current string) error
+}
+
+type accountUsecase struct {
+	userRepo        repository.UserRepository
+	addrRepo        repository.AddressRepository
+	orderRepo       repository.OrderRepository
+	emailChangeRepo repository.EmailChangeRepository
+	hasher          PasswordHasher
+	policy          PasswordPolicy
+	mailer          mail.Mailer
+	signer          *signedtoken.Signer
+	guestOrders     GuestOrderUsecase
+	auditor         Auditor
+}
+
+func NewAccountUsecase(
+	userRepo repository.UserRepository,
+	addrRepo repository.AddressRepository,
+	orderRepo repository.OrderRepository,
+	emailChangeRepo repository.EmailChangeRepository,
+	hasher PasswordHasher,
+	policy PasswordPolicy,
+	mailer mail.Mailer,
+	signer *signedtoken.Signer,
+	guestOrders GuestOrderUsecase,
+	auditor Auditor,
+) AccountUsecase {
+	return &accountUsecase{
+		userRepo:        userRepo,
+		addrRepo:        addrRepo,
+		orderRepo:       orderRepo,
+		emailChangeRepo: emailChangeRepo,
+		hasher:          hasher,
+		policy:          policy,
+		mailer:          mailer,
+		signer:          signer,
+		guestOrders:     guestOrders,
+		auditor:         auditor,
+	}
+}
+
+func (u *accountUsecase) GetProfile(userID uint) (*model.User, error) {
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (u *accountUsecase) UpdateProfile(actor Actor, userID uint, update ProfileUpdate) (*model.User, error) {
+	user, err := u.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	before := *user
+
+	if update.Name != nil {
+		user.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Surname != nil {
+		user.Surname = strings.TrimSpace(*update.Surname)
+	}
+	if update.Address != nil {
+		if err := u.updateAddress(user, update.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	updated, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityUser, userID, &before, updated)
+	return updated, nil
+}
+
+// updateAddress edits the user's address in place unless an order already
+// ships to it; in that case the user gets a new address and the old one stays
+// with the order.
+func (u *accountUsecase) updateAddress(user *model.User, update *AddressUpdate) error {
+	addr := user.Address
+	apply := func(field *string, value *string) {
+		if value != nil {
+			*field = strings.TrimSpace(*value)
+		}
+	}
+	apply(&addr.Country, update.Country)
+	apply(&addr.City, update.City)
+	apply(&addr.Postcode, update.Postcode)
+	apply(&addr.Street, update.Street)
+	apply(&addr.Number, update.Number)
+	apply(&addr.Company, update.Company)
+	apply(&addr.TaxID, update.TaxID)
+
+	used, err := u.addrRepo.IsUsedByOrders(user.AddressID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		addr.ID = user.AddressID
+		if err := u.addrRepo.Update(&addr); err != nil {
+			return err
+		}
+		user.Address = addr
+		return nil
+	}
+
+	addr.ID = 0
+	if err := u.addrRepo.Create(&addr); err != nil {
+		return err
+	}
+	user.AddressID = addr.ID
+	user.Address = addr
+	return nil
+}
+
+func (u *accountUsecase) ChangePassword(actor Actor, userID uint, current, next string) (*model.User, error) {
+	user, err := u.verifiedUser(userID, current)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.policy.Validate(next); err != nil {
+		return nil, err
+	}
+	hashed, err := u.hasher.Hash(next)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashed
+	user.TokenVersion++
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditPasswordChange, model.AuditEntityUser, userID, nil, nil)
+
+	// The password is changed either way; a lost notice must not report failure.
+	if err := u.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    "The password for your account was just changed. If this wasn't you, contact support immediately.",
+	}); err != nil {
+		log.Printf("account: user %d: failed to send password change notice: %v", userID, err)
+	}
+	return user, nil
+}
+
+func (u *accountUsecase) RequestEmailChange(userID uint, current, newEmail string) (*model.EmailChange, error) {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if !strings.Contains(newEmail, "@") || strings.HasSuffix(newEmail, "@"+anonymizedEmailDomain) {
+		return nil, ErrInvalidEmail
+	}
+
+	user, err := u.verifiedUser(userID, current)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.userRepo.FindByEmail(newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailInUse
+	}
+
+	token, err := randomHex(emailChangeTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	change := &model.EmailChange{
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+	if err := u.emailChangeRepo.Create(change); err != nil {
+		return nil, err
+	}
+
+	if err := u.mailer.Send(mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Confirm the change by sending this token to POST /users/email/confirm within 24 hours:\n\n%s\n\n"+
+			"If you did not request this, ignore this message.", token),
+	}); err != nil {
+		return nil, err
+	}
+	if err := u.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Email change requested",
+		Body:    fmt.Sprintf("A change of your account email to %s was requested. It will not take effect until confirmed from that address.", newEmail),
+	}); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (u *accountUsecase) ConfirmEmailChange(actor Actor, token string) (*model.User, error) {
+	change, err := u.emailChangeRepo.FindByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if change == nil || change.ConfirmedAt != nil || now.After(change.ExpiresAt) {
+		return nil, ErrInvalidConfirmation
+	}
+
+	existing, err := u.userRepo.FindByEmail(change.NewEmail)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailInUse
+	}
+
+	user, err := u.GetProfile(change.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ClosedAt != nil {
+		return nil, ErrInvalidConfirmation
+	}
+	oldEmail := user.Email
+	user.Email = change.NewEmail
+	// The confirmation token was sent to the new address, which proves it.
+	user.EmailVerifiedAt = &now
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	change.ConfirmedAt = &now
+	if err := u.emailChangeRepo.Update(change); err != nil {
+		return nil, err
+	}
+
+	// Confirmation is unauthenticated; holding the token identifies the user.
+	if actor.UserID == 0 {
+		actor.UserID = user.ID
+		actor.Role = user.Role
+	}
+	u.auditor.Record(actor, model.AuditEmailChange, model.AuditEntityUser, user.ID,
+		map[string]string{"email": oldEmail}, map[string]string{"email": user.Email})
+	if _, err := u.guestOrders.AttachToUser(actor, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *accountUsecase) RequestEmailVerification(userID uint) error {
+	user, err := u.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token := u.signer.Sign(emailVerifyPurpose+user.Email, user.ID, time.Now().Add(emailVerificationTTL))
+	return u.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Verify your email address by sending this token to POST /users/email/verify within 7 days:\n\n%s\n\n"+
+			"Orders you placed as a guest with this address will then appear in your account.", token),
+	})
+}
+
+func (u *accountUsecase) VerifyEmail(actor Actor, token string) (*model.User, error) {
+	// The purpose depends on the user's email, so the user is loaded first
+	// and the token checked against their current address.
+	id, err := signedtoken.Subject(token)
+	if err != nil {
+		return nil, ErrInvalidConfirmation
+	}
+	user, err := u.userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.ClosedAt != nil {
+		return nil, ErrInvalidConfirmation
+	}
+	if _, err := u.signer.Verify(emailVerifyPurpose+user.Email, token, time.Now()); err != nil {
+		return nil, ErrInvalidConfirmation
+	}
+
+	// Confirmation is unauthenticated; holding the token identifies the user.
+	if actor.UserID == 0 {
+		actor.UserID = user.ID
+		actor.Role = user.Role
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := u.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+		u.auditor.Record(actor, model.AuditEmailVerify, model.AuditEntityUser, user.ID, nil,
+			map[string]string{"email": user.Email})
+	}
+	if _, err := u.guestOrders.AttachToUser(actor, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *accountUsecase) Close(actor Actor, userID uint, current string) error {
+	user, err := u.verifiedUser(userID, current)
+	if err != nil {
+		return err
+	}
+	if err := anonymizeUser(u.userRepo, u.addrRepo, u.orderRepo, user); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditClose, model.AuditEntityUser, userID, nil, nil)
+	return nil
+}
+
+// anonymizeUser strips personal data from the user, their address and the
+// guest contact details on their orders while keeping the rows, so orders and
+// their totals remain consistent. An address referenced by orders is left to
+// the caller; the user gets a blank copy.
+func anonymizeUser(userRepo repository.UserRepository, addrRepo repository.AddressRepository, orderRepo repository.OrderRepository, user *model.User) error {
+	now := time.Now()
+	user.Email = fmt.Sprintf("deleted-%d@%s", user.ID, anonymizedEmailDomain)
+	user.Name = anonymizedName
+	user.Surname = anonymizedSurname
+	user.Password = unusablePasswordHash
+	user.DeactivatedAt = &now
+	user.ClosedAt = &now
+
+	used, err := addrRepo.IsUsedByOrders(user.AddressID)
+	if err != nil {
+		return err
+	}
+	blank := user.Address
+	scrubAddress(&blank)
+	if used {
+		blank.ID = 0
+		blank.CreatedAt = time.Time{}
+		if err := addrRepo.Create(&blank); err != nil {
+			return err
+		}
+		user.AddressID = blank.ID
+	} else {
+		blank.ID = user.AddressID
+		if err := addrRepo.Update(&blank); err != nil {
+			return err
+		}
+	}
+	user.Address = blank
+
+	if err := orderRepo.ClearGuestContact(user.ID); err != nil {
+		return err
+	}
+	return userRepo.Update(user)
+}
+
+// scrubAddress removes everything that locates a person. The country is kept
+// because tax records depend on it.
+func scrubAddress(addr *model.Address) {
+	addr.City = ""
+	addr.Postcode = ""
+	addr.Street = ""
+	addr.Number = ""
+	addr.Company = ""
+	addr.TaxID = ""
+}
+
+// verifiedUser loads the user and re-authenticates them with their current password.
+func (u *accountUsecase) verifiedUser(userID uint, current string) (*model.User, error) {
+	user, err := u.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	ok, _, err := u.hasher.Verify(user.Password, current)
+	if err != nil || !ok {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/mail"
+	"go-ecommerce-api/internal/infrastructure/password"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	newEmailAddress  = "new@example.com"
+	modelEmailChange = "*model.EmailChange"
+	modelAddress     = "*model.Address"
+)
+
+type MockEmailChangeRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailChangeRepository) FindByTokenHash(hash string) (*model.EmailChange, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.EmailChange), args.Error(1)
+}
+
+func (m *MockEmailChangeRepository) Create(change *model.EmailChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeRepository) Update(change *model.EmailChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockEmailChangeRepository) DeleteExpired(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// recordingMailer keeps sent messages in memory.
+type recordingMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(msg mail.Message) error {
+	m.sent = append(m.sent, msg)
+	return m.err
+}
+
+func setupAccountUsecase() (*accountUsecase, *MockUserRepository, *MockAddressRepository, *MockEmailChangeRepository, *MockOrderRepository, *recordingMailer) {
+	mockUserRepo := new(MockUserRepository)
+	mockAddrRepo := new(MockAddressRepository)
+	mockEmailRepo := new(MockEmailChangeRepository)
+	mockOrderRepo := new(MockOrderRepository)
+	mailer := &recordingMailer{}
+	auditor := &recordingAuditor{}
+	uc := &accountUsecase{
+		userRepo:        mockUserRepo,
+		addrRepo:        mockAddrRepo,
+		orderRepo:       mockOrderRepo,
+		emailChangeRepo: mockEmailRepo,
+		hasher:          newTestHasher(),
+		policy:          testPasswordPolicy,
+		mailer:          mailer,
+		signer:          signedtoken.NewSigner([]byte("test-secret")),
+		guestOrders:     &guestOrderUsecase{orderRepo: mockOrderRepo, auditor: auditor, now: time.Now},
+		auditor:         auditor,
+	}
+	return uc, mockUserRepo, mockAddrRepo, mockEmailRepo, mockOrderRepo, mailer
+}
+
+func accountTestUser(t *testing.T) *model.User {
+	hashed, err := newTestHasher().Hash(strongPassword)
+	assert.NoError(t, err)
+	return &model.User{
+		ID:        1,
+		Email:     userExampleEmail,
+		Password:  hashed,
+		Name:      "John",
+		Surname:   "Doe",
+		AddressID: 10,
+		Address:   model.Address{ID: 10, Country: "Poland", City: "Krakow", Street: "Main"},
+	}
+}
+
+func TestAccountUsecaseUpdateProfileCopiesAddressUsedByOrders(t *testing.T) {
+	uc, mockUserRepo, mockAddrRepo, _, _, _ := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockAddrRepo.On("IsUsedByOrders", uint(10)).Return(true, nil)
+	mockAddrRepo.On("Create", mock.AnythingOfType(modelAddress)).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Address).ID = 11
+	}).Return(nil)
+	mockUserRepo.On("Update", user).Return(nil)
+
+	name, city := " Jane ", "Warsaw"
+	_, err := uc.UpdateProfile(testActor, 1, ProfileUpdate{Name: &name, Address: &AddressUpdate{City: &city}})
+
+	// Assertion 466: UpdateProfile should succeed
+	assert.NoError(t, err)
+	// Assertion 467: UpdateProfile should trim and apply profile fields
+	assert.Equal(t, "Jane", user.Name)
+	assert.Equal(t, "Doe", user.Surname)
+	// Assertion 468: UpdateProfile should move the user to a new address and keep the one referenced by orders
+	assert.Equal(t, uint(11), user.AddressID)
+	assert.Equal(t, "Warsaw", user.Address.City)
+	assert.Equal(t, "Main", user.Address.Street)
+	mockAddrRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	mockUserRepo.AssertExpectations(t)
+	mockAddrRepo.AssertExpectations(t)
+}
+
+func TestAccountUsecaseChangePassword(t *testing.T) {
+	uc, mockUserRepo, _, _, _, mailer := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockUserRepo.On("Update", user).Return(nil)
+
+	_, err := uc.ChangePassword(testActor, 1, "wrong-password", "An0therStrong!")
+	// Assertion 469: ChangePassword should require the current password
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	_, err = uc.ChangePassword(testActor, 1, strongPassword, "short")
+	// Assertion 470: ChangePassword should enforce the password policy
+	var policyErr *password.PolicyError
+	assert.ErrorAs(t, err, &policyErr)
+
+	changed, err := uc.ChangePassword(testActor, 1, strongPassword, "An0therStrong!")
+	// Assertion 471: ChangePassword should store a hash of the new password
+	assert.NoError(t, err)
+	ok, _, _ := uc.hasher.Verify(user.Password, "An0therStrong!")
+	assert.True(t, ok)
+	// Assertion 849: ChangePassword should revoke the tokens issued before it
+	assert.Equal(t, 1, changed.TokenVersion)
+	// Assertion 472: ChangePassword should notify the account owner
+	assert.Len(t, mailer.sent, 1)
+	assert.Equal(t, userExampleEmail, mailer.sent[0].To)
+
+	mailer.err = errors.New("smtp down")
+	_, err = uc.ChangePassword(testActor, 1, "An0therStrong!", "Th1rdStrong!")
+	// Assertion 780: A failed notice should not fail a password change that was saved
+	assert.NoError(t, err)
+	ok, _, _ = uc.hasher.Verify(user.Password, "Th1rdStrong!")
+	assert.True(t, ok)
+}
+
+func TestAccountUsecaseRequestEmailChange(t *testing.T) {
+	uc, mockUserRepo, _, mockEmailRepo, _, mailer := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockUserRepo.On("FindByEmail", "taken@example.com").Return(&model.User{ID: 2}, nil)
+	mockUserRepo.On("FindByEmail", newEmailAddress).Return(nil, nil)
+	mockEmailRepo.On("Create", mock.AnythingOfType(modelEmailChange)).Return(nil)
+
+	_, err := uc.RequestEmailChange(1, strongPassword, "not-an-email")
+	// Assertion 473: RequestEmailChange should reject malformed addresses
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	_, err = uc.RequestEmailChange(1, strongPassword, "taken@example.com")
+	// Assertion 474: RequestEmailChange should reject addresses used by another account
+	assert.ErrorIs(t, err, ErrEmailInUse)
+
+	change, err := uc.RequestEmailChange(1, strongPassword, " New@Example.com ")
+	// Assertion 475: RequestEmailChange should store a pending change without touching the user
+	assert.NoError(t, err)
+	assert.Equal(t, newEmailAddress, change.NewEmail)
+	assert.Equal(t, userExampleEmail, user.Email)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+	// Assertion 476: RequestEmailChange should mail the new address and notify the old one
+	assert.Len(t, mailer.sent, 2)
+	assert.Equal(t, newEmailAddress, mailer.sent[0].To)
+	assert.Equal(t, userExampleEmail, mailer.sent[1].To)
+	// Assertion 477: RequestEmailChange should only persist a hash of the token
+	assert.NotContains(t, mailer.sent[0].Body, change.TokenHash)
+}
+
+func TestAccountUsecaseConfirmEmailChange(t *testing.T) {
+	uc, mockUserRepo, _, mockEmailRepo, mockOrderRepo, _ := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	pending := &model.EmailChange{UserID: 1, NewEmail: newEmailAddress, ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &model.EmailChange{UserID: 1, NewEmail: newEmailAddress, ExpiresAt: time.Now().Add(-time.Hour)}
+	mockEmailRepo.On("FindByTokenHash", hashToken("valid")).Return(pending, nil)
+	mockEmailRepo.On("FindByTokenHash", hashToken("expired")).Return(expired, nil)
+	mockEmailRepo.On("FindByTokenHash", hashToken("unknown")).Return(nil, nil)
+	mockEmailRepo.On("Update", pending).Return(nil)
+	mockUserRepo.On("FindByEmail", newEmailAddress).Return(nil, nil)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockUserRepo.On("Update", user).Return(nil)
+	mockOrderRepo.On("AttachGuestOrders", newEmailAddress, uint(1)).Return(int64(2), nil)
+
+	_, err := uc.ConfirmEmailChange(Actor{}, "unknown")
+	// Assertion 478: ConfirmEmailChange should reject unknown tokens
+	assert.ErrorIs(t, err, ErrInvalidConfirmation)
+
+	_, err = uc.ConfirmEmailChange(Actor{}, "expired")
+	// Assertion 479: ConfirmEmailChange should reject expired tokens
+	assert.ErrorIs(t, err, ErrInvalidConfirmation)
+
+	updated, err := uc.ConfirmEmailChange(Actor{}, "valid")
+	// Assertion 480: ConfirmEmailChange should switch the email and mark the change confirmed
+	assert.NoError(t, err)
+	assert.Equal(t, newEmailAddress, updated.Email)
+	assert.NotNil(t, pending.ConfirmedAt)
+	// Assertion 556: ConfirmEmailChange should verify the new email and attach its guest orders
+	assert.NotNil(t, updated.EmailVerifiedAt)
+	mockOrderRepo.AssertCalled(t, "AttachGuestOrders", newEmailAddress, uint(1))
+
+	_, err = uc.ConfirmEmailChange(Actor{}, "valid")
+	// Assertion 481: ConfirmEmailChange should not accept a token twice
+	assert.ErrorIs(t, err, ErrInvalidConfirmation)
+}
+
+func TestAccountUsecaseVerifyEmailAttachesGuestOrders(t *testing.T) {
+	uc, mockUserRepo, _, _, mockOrderRepo, mailer := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockUserRepo.On("Update", user).Return(nil)
+	mockOrderRepo.On("AttachGuestOrders", userExampleEmail, uint(1)).Return(int64(1), nil)
+
+	err := uc.RequestEmailVerification(1)
+	// Assertion 557: RequestEmailVerification should mail a token to the current address
+	assert.NoError(t, err)
+	if !assert.Len(t, mailer.sent, 1) {
+		return
+	}
+	assert.Equal(t, userExampleEmail, mailer.sent[0].To)
+	token := strings.Split(mailer.sent[0].Body, "\n\n")[1]
+
+	_, err = uc.VerifyEmail(Actor{}, "garbage")
+	// Assertion 558: VerifyEmail should reject malformed tokens
+	assert.ErrorIs(t, err, ErrInvalidConfirmation)
+
+	user.Email = "other@example.com"
+	_, err = uc.VerifyEmail(Actor{}, token)
+	// Assertion 559: VerifyEmail should reject a token issued for a previous email
+	assert.ErrorIs(t, err, ErrInvalidConfirmation)
+	mockOrderRepo.AssertNotCalled(t, "AttachGuestOrders", mock.Anything, mock.Anything)
+
+	user.Email = userExampleEmail
+	verified, err := uc.VerifyEmail(Actor{}, token)
+	// Assertion 560: VerifyEmail should mark the email verified and attach the guest orders placed with it
+	assert.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+	mockOrderRepo.AssertCalled(t, "AttachGuestOrders", userExampleEmail, uint(1))
+
+	err = uc.RequestEmailVerification(1)
+	// Assertion 561: RequestEmailVerification should refuse an already verified email
+	assert.ErrorIs(t, err, ErrEmailAlreadyVerified)
+}
+
+func TestAccountUsecaseCloseAnonymizes(t *testing.T) {
+	uc, mockUserRepo, mockAddrRepo, _, mockOrderRepo, _ := setupAccountUsecase()
+
+	user := accountTestUser(t)
+	mockUserRepo.On("FindByID", uint(1)).Return(user, nil)
+	mockAddrRepo.On("IsUsedByOrders", uint(10)).Return(false, nil)
+	mockAddrRepo.On("Update", mock.AnythingOfType(modelAddress)).Return(nil)
+	mockOrderRepo.On("ClearGuestContact", uint(1)).Return(nil)
+	mockUserRepo.On("Update", user).Return(nil)
+
+	err := uc.Close(testActor, 1, "wrong-password")
+	// Assertion 482: Close should require the current password
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	err = uc.Close(testActor, 1, strongPassword)
+	// Assertion 483: Close should succeed without deleting the user
+	assert.NoError(t, err)
+	mockUserRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	// Assertion 484: Close should strip personal data and disable the account
+	assert.Equal(t, "deleted-1@anonymized.invalid", user.Email)
+	assert.Equal(t, anonymizedName, user.Name)
+	assert.Empty(t, user.Address.Street)
+	assert.Equal(t, "Poland", user.Address.Country)
+	assert.NotNil(t, user.ClosedAt)
+	assert.NotNil(t, user.DeactivatedAt)
+	// Assertion 485: Close should leave a password that can never be verified
+	ok, _, _ := uc.hasher.Verify(user.Password, strongPassword)
+	assert.False(t, ok)
+	// Assertion 845: Close should remove the guest contact details from the user's orders
+	mockOrderRepo.AssertCalled(t, "ClearGuestContact", uint(1))
+}
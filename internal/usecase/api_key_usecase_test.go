@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	erpKeyName  = "ERP sync"
+	modelAPIKey = "*model.APIKey"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) FindByID(id uint) (*model.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindByPrefix(prefix string) (*model.APIKey, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) FindAll() ([]model.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Create(key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Update(key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func setupAPIKeyUsecase() (*apiKeyUsecase, *MockAPIKeyRepository) {
+	mockRepo := new(MockAPIKeyRepository)
+	return &apiKeyUsecase{apiKeyRepo: mockRepo, auditor: &recordingAuditor{}}, mockRepo
+}
+
+// issueTestKey creates a key through the usecase and returns the stored record and plaintext.
+func issueTestKey(t *testing.T, uc *apiKeyUsecase, mockRepo *MockAPIKeyRepository) (*model.APIKey, string) {
+	mockRepo.On("Create", mock.AnythingOfType(modelAPIKey)).Return(nil).Once()
+	key, raw, err := uc.Create(testActor, erpKeyName, []string{model.ScopeOrdersRead}, nil)
+	assert.NoError(t, err)
+	return key, raw
+}
+
+func TestAPIKeyUsecaseCreateStoresOnlyHash(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+
+	key, raw := issueTestKey(t, uc, mockRepo)
+
+	// Assertion 426: Create should return the plaintext key with its prefix embedded
+	assert.Contains(t, raw, "_"+key.Prefix+"_")
+	// Assertion 427: Create should not store the plaintext key
+	assert.NotContains(t, key.KeyHash, raw)
+	// Assertion 428: Create should store the hash of the plaintext key
+	assert.Equal(t, hashToken(raw), key.KeyHash)
+	// Assertion 429: Create should record the issuing admin
+	assert.Equal(t, uint(1), key.CreatedByID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyUsecaseCreateAcceptsEveryRoutedScope(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+	mockRepo.On("Create", mock.AnythingOfType(modelAPIKey)).Return(nil).Once()
+
+	scopes := []string{model.ScopeCartsWrite, model.ScopeShippingRead, model.ScopeTaxWrite, model.ScopeCurrenciesRead}
+	key, _, err := uc.Create(testActor, erpKeyName, scopes, nil)
+	// Assertion 773: Create should accept scopes for carts, shipping, tax and currencies
+	assert.NoError(t, err)
+	assert.Equal(t, model.StringList(scopes), key.Scopes)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyUsecaseCreateValidation(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+	past := time.Now().Add(-time.Hour)
+
+	_, _, err := uc.Create(testActor, "", []string{model.ScopeOrdersRead}, nil)
+	// Assertion 430: Create should require a name
+	assert.EqualError(t, err, errAPIKeyNameRequired)
+
+	_, _, err = uc.Create(testActor, erpKeyName, nil, nil)
+	// Assertion 431: Create should require at least one scope
+	assert.EqualError(t, err, errAPIKeyNoScopes)
+
+	_, _, err = uc.Create(testActor, erpKeyName, []string{"webhooks:write"}, nil)
+	// Assertion 432: Create should reject unknown scopes
+	assert.Error(t, err)
+
+	_, _, err = uc.Create(testActor, erpKeyName, []string{model.ScopeOrdersRead}, &past)
+	// Assertion 433: Create should reject an expiry in the past
+	assert.EqualError(t, err, errAPIKeyExpiryPast)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAPIKeyUsecaseAuthenticateSuccess(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+	key, raw := issueTestKey(t, uc, mockRepo)
+
+	mockRepo.On("FindByPrefix", key.Prefix).Return(key, nil)
+	mockRepo.On("Update", key).Return(nil).Once()
+
+	result, err := uc.Authenticate(raw)
+
+	// Assertion 434: Authenticate should accept a valid key
+	assert.NoError(t, err)
+	// Assertion 435: Authenticate should return the matching key
+	assert.Equal(t, key, result)
+	// Assertion 436: Authenticate should record the last use
+	assert.NotNil(t, result.LastUsedAt)
+
+	// A second call inside the window must not write again
+	_, err = uc.Authenticate(raw)
+	// Assertion 437: Authenticate should succeed again without updating last use
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyUsecaseAuthenticateRejectsInvalidKeys(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+	key, raw := issueTestKey(t, uc, mockRepo)
+	mockRepo.On("FindByPrefix", key.Prefix).Return(key, nil)
+
+	_, err := uc.Authenticate("not-a-key")
+	// Assertion 438: Authenticate should reject malformed keys
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = uc.Authenticate(raw + "x")
+	// Assertion 439: Authenticate should reject a key whose secret does not match
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	past := time.Now().Add(-time.Minute)
+	key.ExpiresAt = &past
+	_, err = uc.Authenticate(raw)
+	// Assertion 440: Authenticate should reject expired keys
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	key.ExpiresAt = nil
+	key.RevokedAt = &past
+	_, err = uc.Authenticate(raw)
+	// Assertion 441: Authenticate should reject revoked keys
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestAPIKeyUsecaseRevoke(t *testing.T) {
+	uc, mockRepo := setupAPIKeyUsecase()
+	key := &model.APIKey{ID: 3, Name: erpKeyName}
+
+	mockRepo.On("FindByID", uint(3)).Return(key, nil)
+	mockRepo.On("Update", key).Return(nil).Once()
+	mockRepo.On("FindByID", uint(4)).Return(nil, nil)
+
+	result, err := uc.Revoke(testActor, 3)
+	// Assertion 442: Revoke should succeed for an existing key
+	assert.NoError(t, err)
+	// Assertion 443: Revoke should set the revocation timestamp
+	assert.NotNil(t, result.RevokedAt)
+
+	_, err = uc.Revoke(testActor, 4)
+	// Assertion 444: Revoke should report missing keys
+	assert.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
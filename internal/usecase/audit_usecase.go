@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+)
+
+// Actor identifies who performs an action. Handlers build it from the request;
+// zero IDs mean "none", e.g. an anonymous login attempt has only IP and RequestID.
+type Actor struct {
+	UserID         uint
+	Role           string
+	APIKeyID       uint
+	ImpersonatorID uint
+	IP             string
+	RequestID      string
+}
+
+// Auditor records security-relevant changes. Recording never fails the
+// action being audited; errors are logged instead.
+type Auditor interface {
+	Record(actor Actor, action, entityType string, entityID uint, before, after interface{})
+}
+
+type AuditUsecase interface {
+	Auditor
+	GetWithFilters(filters map[string]string) ([]model.AuditEvent, error)
+}
+
+// auditIgnoredFields change on every write and would drown the real diff.
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditPersonalFields hold personal data, per entity type. The log is
+// append-only and an erasure cannot reach it, so for these fields only the
+// name is kept, with the value replaced by auditRedacted. IDs such as
+// address_id or user_id are kept.
+var auditPersonalFields = map[string]map[string]bool{
+	model.AuditEntityUser: {
+		"email": true, "name": true, "surname": true, "address": true,
+		"admin": true, "target_user": true,
+	},
+	model.AuditEntityOrder: {
+		"user": true, "guest_email": true, "guest_name": true, "guest_surname": true,
+		"shipping_address": true,
+	},
+	model.AuditEntityInvoice: {
+		"buyer_name": true, "buyer_company": true, "buyer_tax_id": true,
+		"buyer_email": true, "buyer_address": true,
+	},
+	model.AuditEntityPrivacyRequest: {"user": true},
+}
+
+var auditRedacted = json.RawMessage(`"[redacted]"`)
+
+type auditUsecase struct {
+	auditRepo repository.AuditEventRepository
+}
+
+func NewAuditUsecase(auditRepo repository.AuditEventRepository) AuditUsecase {
+	return &auditUsecase{auditRepo: auditRepo}
+}
+
+func (u *auditUsecase) Record(actor Actor, action, entityType string, entityID uint, before, after interface{}) {
+	beforeJSON, afterJSON, err := auditDiff(entityType, before, after)
+	if err != nil {
+		log.Printf("audit: encode %s %s#%d: %v", action, entityType, entityID, err)
+	}
+
+	event := &model.AuditEvent{
+		ActorID:        optionalID(actor.UserID),
+		ActorRole:      actor.Role,
+		APIKeyID:       optionalID(actor.APIKeyID),
+		ImpersonatorID: optionalID(actor.ImpersonatorID),
+		Action:         action,
+		EntityType:     entityType,
+		EntityID:       entityID,
+		Before:         beforeJSON,
+		After:          afterJSON,
+		IPAddress:      actor.IP,
+		RequestID:      actor.RequestID,
+	}
+	if err := u.auditRepo.Create(event); err != nil {
+		log.Printf("audit: record %s %s#%d: %v", action, entityType, entityID, err)
+	}
+}
+
+func (u *auditUsecase) GetWithFilters(filters map[string]string) ([]model.AuditEvent, error) {
+	return u.auditRepo.FindWithFilters(filters)
+}
+
+// auditDiff encodes before and after as JSON objects. When both are present
+// only the top-level fields whose values differ are kept. Personal fields of
+// entityType are redacted.
+func auditDiff(entityType string, before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b != nil && a != nil {
+		for key := range b {
+			if auditIgnoredFields[key] || bytes.Equal(b[key], a[key]) {
+				delete(b, key)
+				delete(a, key)
+			}
+		}
+		for key := range a {
+			if auditIgnoredFields[key] {
+				delete(a, key)
+			}
+		}
+	}
+	redactAuditFields(entityType, b)
+	redactAuditFields(entityType, a)
+	return encodeAuditFields(b), encodeAuditFields(a), nil
+}
+
+func redactAuditFields(entityType string, fields map[string]json.RawMessage) {
+	personal := auditPersonalFields[entityType]
+	for key, value := range fields {
+		if personal[key] && string(value) != "null" {
+			fields[key] = auditRedacted
+		}
+	}
+}
+
+func auditFields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func encodeAuditFields(fields map[string]json.RawMessage) json.RawMessage {
+	if fields == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(fields)
+	return raw
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
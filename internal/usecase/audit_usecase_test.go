@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const modelAuditEvent = "*model.AuditEvent"
+
+var testActor = Actor{UserID: 1, Role: model.RoleAdmin, IP: "10.0.0.1", RequestID: "req-1"}
+
+type MockAuditEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditEventRepository) FindWithFilters(filters map[string]string) ([]model.AuditEvent, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]model.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditEventRepository) Create(event *model.AuditEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+type recordedAudit struct {
+	actor      Actor
+	action     string
+	entityType string
+	entityID   uint
+	before     interface{}
+	after      interface{}
+}
+
+// recordingAuditor keeps audit records in memory.
+type recordingAuditor struct {
+	records []recordedAudit
+}
+
+func (a *recordingAuditor) Record(actor Actor, action, entityType string, entityID uint, before, after interface{}) {
+	a.records = append(a.records, recordedAudit{actor, action, entityType, entityID, before, after})
+}
+
+func (a *recordingAuditor) actions() []string {
+	actions := make([]string, 0, len(a.records))
+	for _, r := range a.records {
+		actions = append(actions, r.entityType+"."+r.action)
+	}
+	return actions
+}
+
+func TestAuditUsecaseRecordStoresOnlyChangedFields(t *testing.T) {
+	mockRepo := new(MockAuditEventRepository)
+	uc := NewAuditUsecase(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType(modelAuditEvent)).Return(nil)
+
+	before := &model.Product{ID: 3, Name: "Lamp", Price: 10, Stock: 4}
+	after := &model.Product{ID: 3, Name: "Lamp", Price: 8, Stock: 4}
+	uc.Record(testActor, model.AuditUpdate, model.AuditEntityProduct, 3, before, after)
+
+	event := mockRepo.Calls[0].Arguments.Get(0).(*model.AuditEvent)
+	var beforeFields, afterFields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(event.Before, &beforeFields))
+	assert.NoError(t, json.Unmarshal(event.After, &afterFields))
+
+	// Assertion 498: Record should keep only the fields that changed
+	assert.Equal(t, map[string]interface{}{"price": 10.0}, beforeFields)
+	assert.Equal(t, map[string]interface{}{"price": 8.0}, afterFields)
+	// Assertion 499: Record should copy the actor and request metadata
+	assert.Equal(t, uint(1), *event.ActorID)
+	assert.Nil(t, event.APIKeyID)
+	assert.Equal(t, "10.0.0.1", event.IPAddress)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, model.AuditEntityProduct, event.EntityType)
+}
+
+func TestAuditUsecaseRecordSnapshotsCreateAndDelete(t *testing.T) {
+	mockRepo := new(MockAuditEventRepository)
+	uc := NewAuditUsecase(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType(modelAuditEvent)).Return(nil)
+
+	category := &model.Category{ID: 5, Name: "Lighting"}
+	uc.Record(Actor{APIKeyID: 7, Role: "service"}, model.AuditCreate, model.AuditEntityCategory, 5, nil, category)
+	uc.Record(testActor, model.AuditDelete, model.AuditEntityCategory, 5, category, nil)
+
+	created := mockRepo.Calls[0].Arguments.Get(0).(*model.AuditEvent)
+	deleted := mockRepo.Calls[1].Arguments.Get(0).(*model.AuditEvent)
+
+	// Assertion 500: Record should store the full entity on create and no before state
+	assert.Nil(t, created.Before)
+	assert.Contains(t, string(created.After), `"name":"Lighting"`)
+	// Assertion 501: Record should attribute API key actions to the key, not a user
+	assert.Nil(t, created.ActorID)
+	assert.Equal(t, uint(7), *created.APIKeyID)
+	// Assertion 502: Record should store the full entity on delete and no after state
+	assert.Contains(t, string(deleted.Before), `"name":"Lighting"`)
+	assert.Nil(t, deleted.After)
+}
+
+func TestAuditUsecaseRecordRedactsPersonalData(t *testing.T) {
+	mockRepo := new(MockAuditEventRepository)
+	uc := NewAuditUsecase(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType(modelAuditEvent)).Return(nil)
+
+	before := &model.User{ID: 2, Email: userExampleEmail, Name: "John", Role: userRole, AddressID: 10, Address: model.Address{ID: 10, Street: mainStreet}}
+	after := *before
+	after.Name = "Johnny"
+	after.Role = adminRole
+	uc.Record(testActor, model.AuditUpdate, model.AuditEntityUser, 2, before, &after)
+	uc.Record(testActor, model.AuditCreate, model.AuditEntityUser, 2, nil, before)
+	order := &model.Order{ID: 4, GuestEmail: userExampleEmail, ShippingAddressID: 10, ShippingAddress: model.Address{ID: 10, Street: mainStreet}}
+	uc.Record(testActor, model.AuditCreate, model.AuditEntityOrder, 4, nil, order)
+
+	updated := mockRepo.Calls[0].Arguments.Get(0).(*model.AuditEvent)
+	created := mockRepo.Calls[1].Arguments.Get(0).(*model.AuditEvent)
+	ordered := mockRepo.Calls[2].Arguments.Get(0).(*model.AuditEvent)
+
+	// Assertion 783: Changed personal fields should be named but not stored
+	assert.JSONEq(t, `{"name":"[redacted]","role":"user"}`, string(updated.Before))
+	assert.JSONEq(t, `{"name":"[redacted]","role":"admin"}`, string(updated.After))
+	// Assertion 784: Snapshots should keep IDs but none of the user's personal data
+	assert.Contains(t, string(created.After), `"address_id":10`)
+	for _, event := range []*model.AuditEvent{created, ordered} {
+		assert.NotContains(t, string(event.After), userExampleEmail)
+		assert.NotContains(t, string(event.After), mainStreet)
+	}
+	// Assertion 785: Orders should keep the ID of the redacted shipping address
+	assert.Contains(t, string(ordered.After), `"shipping_address_id":10`)
+}
+
+func TestAuditUsecaseRecordDoesNotFailOnRepositoryError(t *testing.T) {
+	mockRepo := new(MockAuditEventRepository)
+	uc := NewAuditUsecase(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType(modelAuditEvent)).Return(errors.New("disk full"))
+
+	// Assertion 503: Record should swallow storage errors so the audited action is not affected
+	assert.NotPanics(t, func() {
+		uc.Record(testActor, model.AuditLogin, model.AuditEntityUser, 1, nil, nil)
+	})
+	mockRepo.AssertExpectations(t)
+}
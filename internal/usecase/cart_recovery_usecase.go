@@ -0,0 +1,393 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/mail"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"gorm.io/gorm"
+)
+
+// Names of the cart recovery jobs.
+const (
+	JobFlagAbandonedCarts = "flag-abandoned-carts"
+	JobSendCartReminders  = "send-cart-reminders"
+)
+
+// cartRestorePurpose scopes restore link signatures to this use.
+const cartRestorePurpose = "cart-restore"
+
+// defaultReportPeriod is how far back the recovery figures of the report go
+// when no "since" filter is given.
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+var ErrInvalidRestoreLink = NewError(KindValidation, "invalid_restore_link", "invalid or expired restore link")
+
+// CartRecoveryConfig holds the thresholds of abandoned cart recovery.
+type CartRecoveryConfig struct {
+	// AbandonAfter is how long a cart with items may stay untouched before it counts as abandoned.
+	AbandonAfter time.Duration
+	// ReminderTTL is how long a restore link stays valid. An order placed within
+	// this time after a reminder is credited to it.
+	ReminderTTL time.Duration
+	// RestoreURL is the storefront page that receives the token as ?token=.
+	RestoreURL string
+}
+
+func DefaultCartRecoveryConfig() CartRecoveryConfig {
+	return CartRecoveryConfig{
+		AbandonAfter: 4 * time.Hour,
+		ReminderTTL:  7 * 24 * time.Hour,
+		RestoreURL:   "http://localhost:3000/cart/restore",
+	}
+}
+
+// AbandonedCartReport lists abandoned carts and sums up reminder outcomes.
+type AbandonedCartReport struct {
+	Carts   []model.Cart         `json:"carts"`
+	Summary AbandonedCartSummary `json:"summary"`
+}
+
+type AbandonedCartSummary struct {
+	AbandonedCarts   int       `json:"abandoned_carts"`
+	AbandonedValue   float64   `json:"abandoned_value"`
+	Since            time.Time `json:"since"`
+	RemindersSent    int       `json:"reminders_sent"`
+	CartsRestored    int       `json:"carts_restored"`
+	RecoveredOrders  int       `json:"recovered_orders"`
+	RecoveredRevenue float64   `json:"recovered_revenue"`
+}
+
+type CartRecoveryUsecase interface {
+	// FlagAbandoned marks carts idle for longer than the threshold.
+	FlagAbandoned(ctx context.Context) (int64, error)
+	// SendReminders emails the owner of every abandoned cart once per abandonment.
+	SendReminders(ctx context.Context) (int, error)
+	// Report accepts the cart search filters (total_min, total_max,
+	// created_after, created_before) plus "since" for the recovery figures.
+	Report(filters map[string]string) (*AbandonedCartReport, error)
+	// Restore adds the items of the reminder behind token back to the user's cart.
+	Restore(userID uint, token string) (*model.Cart, error)
+	// Subscribe credits orders to the reminders that led to them.
+	Subscribe(outbox OutboxUsecase)
+	// Jobs returns FlagAbandoned and SendReminders as scheduler jobs.
+	Jobs() []Job
+}
+
+type cartRecoveryUsecase struct {
+	cartRepo     repository.CartRepository
+	reminderRepo repository.CartReminderRepository
+	userRepo     repository.UserRepository
+	cartUC       CartUsecase
+	mailer       mail.Mailer
+	signer       *signedtoken.Signer
+	config       CartRecoveryConfig
+	now          func() time.Time
+}
+
+func NewCartRecoveryUsecase(
+	cartRepo repository.CartRepository,
+	reminderRepo repository.CartReminderRepository,
+	userRepo repository.UserRepository,
+	cartUC CartUsecase,
+	mailer mail.Mailer,
+	signer *signedtoken.Signer,
+	config CartRecoveryConfig,
+) CartRecoveryUsecase {
+	return &cartRecoveryUsecase{
+		cartRepo:     cartRepo,
+		reminderRepo: reminderRepo,
+		userRepo:     userRepo,
+		cartUC:       cartUC,
+		mailer:       mailer,
+		signer:       signer,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (u *cartRecoveryUsecase) FlagAbandoned(ctx context.Context) (int64, error) {
+	now := u.now()
+	return u.cartRepo.MarkAbandoned(now.Add(-u.config.AbandonAfter), now)
+}
+
+func (u *cartRecoveryUsecase) SendReminders(ctx context.Context) (int, error) {
+	carts, err := u.cartRepo.FindWithFilters(map[string]string{"abandoned": "true"})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range carts {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		ok, err := u.remind(&carts[i])
+		if err != nil {
+			// One bad address must not hold up the rest; the next run retries.
+			log.Printf("cart recovery: cart %d: %v", carts[i].ID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// remind sends at most one reminder per abandonment. A reminder whose email
+// failed is kept unsent and retried by the next run.
+func (u *cartRecoveryUsecase) remind(cart *model.Cart) (bool, error) {
+	// Guest carts have nobody to remind.
+	if cart.AbandonedAt == nil || len(cart.Items) == 0 || cart.IsGuest() {
+		return false, nil
+	}
+	reminder, err := u.reminderRepo.FindLatestByCart(cart.ID)
+	if err != nil {
+		return false, err
+	}
+	if reminder != nil && !reminder.CreatedAt.Before(*cart.AbandonedAt) && reminder.SentAt != nil {
+		return false, nil
+	}
+
+	user, err := u.userRepo.FindByID(*cart.UserID)
+	if err != nil {
+		return false, err
+	}
+	if user == nil || user.ClosedAt != nil || user.DeactivatedAt != nil ||
+		strings.HasSuffix(user.Email, "@"+anonymizedEmailDomain) {
+		return false, nil
+	}
+
+	now := u.now()
+	if reminder == nil || reminder.CreatedAt.Before(*cart.AbandonedAt) {
+		reminder = &model.CartReminder{
+			CartID:    cart.ID,
+			UserID:    *cart.UserID,
+			CartValue: cart.Total,
+			ExpiresAt: now.Add(u.config.ReminderTTL),
+		}
+		for _, item := range cart.Items {
+			reminder.Items = append(reminder.Items, model.CartReminderItem{
+				ProductID: item.ProductID,
+				Name:      item.Product.Name,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+			})
+		}
+		if err := u.reminderRepo.Create(reminder); err != nil {
+			return false, err
+		}
+	}
+
+	if err := u.mailer.Send(u.reminderMessage(user, reminder)); err != nil {
+		return false, err
+	}
+	reminder.SentAt = &now
+	return true, u.reminderRepo.Update(reminder)
+}
+
+func (u *cartRecoveryUsecase) reminderMessage(user *model.User, reminder *model.CartReminder) mail.Message {
+	token := u.signer.Sign(cartRestorePurpose, reminder.ID, reminder.ExpiresAt)
+	link := u.config.RestoreURL + "?token=" + url.QueryEscape(token)
+
+	var items strings.Builder
+	for _, item := range reminder.Items {
+		name := item.Name
+		if name == "" {
+			name = fmt.Sprintf("Product #%d", item.ProductID)
+		}
+		fmt.Fprintf(&items, "- %d x %s\n", item.Quantity, name)
+	}
+	return mail.Message{
+		To:      user.Email,
+		Subject: "You left something in your cart",
+		Body: fmt.Sprintf("Hi %s,\n\nYour cart is still waiting for you:\n\n%s\nTotal: %.2f\n\n"+
+			"Pick up where you left off: %s\n\nThe link is valid until %s.",
+			user.Name, items.String(), reminder.CartValue, link, reminder.ExpiresAt.Format("2006-01-02")),
+	}
+}
+
+func (u *cartRecoveryUsecase) Report(filters map[string]string) (*AbandonedCartReport, error) {
+	cartFilters := map[string]string{}
+	for key, value := range filters {
+		if key != "since" {
+			cartFilters[key] = value
+		}
+	}
+	cartFilters["abandoned"] = "true"
+
+	carts, err := u.cartRepo.FindWithFilters(cartFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.now()
+	since := now.Add(-defaultReportPeriod)
+	if v, ok := filters["since"]; ok {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			since = t
+		}
+	}
+	reminders, err := u.reminderRepo.FindSentBetween(since, now)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AbandonedCartReport{Carts: carts, Summary: AbandonedCartSummary{Since: since}}
+	for _, cart := range carts {
+		report.Summary.AbandonedCarts++
+		report.Summary.AbandonedValue += cart.Total
+	}
+	for _, reminder := range reminders {
+		report.Summary.RemindersSent++
+		if reminder.RestoredAt != nil {
+			report.Summary.CartsRestored++
+		}
+		if reminder.RecoveredOrderID != nil {
+			report.Summary.RecoveredOrders++
+			report.Summary.RecoveredRevenue += reminder.RecoveredRevenue
+		}
+	}
+	return report, nil
+}
+
+func (u *cartRecoveryUsecase) Restore(userID uint, token string) (*model.Cart, error) {
+	id, err := u.signer.Verify(cartRestorePurpose, token, u.now())
+	if err != nil {
+		return nil, ErrInvalidRestoreLink
+	}
+	reminder, err := u.reminderRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// A link forwarded to someone else must not fill their cart.
+	if reminder == nil || reminder.UserID != userID {
+		return nil, ErrInvalidRestoreLink
+	}
+
+	inCart := map[uint]bool{}
+	cart, err := u.cartUC.GetCart(UserCart(userID))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if cart != nil {
+		for _, item := range cart.Items {
+			inCart[item.ProductID] = true
+		}
+	}
+
+	for _, item := range reminder.Items {
+		if inCart[item.ProductID] {
+			continue
+		}
+		restored, err := u.cartUC.AddProduct(UserCart(userID), item.ProductID, item.Quantity)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The product was removed from the catalog since.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cart = restored
+	}
+
+	if reminder.RestoredAt == nil {
+		now := u.now()
+		reminder.RestoredAt = &now
+		if err := u.reminderRepo.Update(reminder); err != nil {
+			return nil, err
+		}
+	}
+	if cart == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return cart, nil
+}
+
+func (u *cartRecoveryUsecase) Subscribe(outbox OutboxUsecase) {
+	outbox.Subscribe(model.EventOrderCreated, u.creditOrder)
+}
+
+// creditOrder attributes an order to the latest reminder sent to its buyer
+// within the reminder TTL whose cart shares a product with the order, so an
+// unrelated purchase is not counted as recovered. Each reminder is credited
+// at most once, which also makes redelivered events harmless.
+func (u *cartRecoveryUsecase) creditOrder(event model.OutboxEvent) error {
+	var created model.OrderCreated
+	if err := event.Decode(&created); err != nil {
+		return err
+	}
+
+	placedAt := event.CreatedAt
+	if placedAt.IsZero() {
+		placedAt = u.now()
+	}
+	reminders, err := u.reminderRepo.FindAttributable(created.UserID, placedAt.Add(-u.config.ReminderTTL), placedAt)
+	if err != nil {
+		return err
+	}
+	reminder := matchingReminder(reminders, created.Items)
+	if reminder == nil {
+		return nil
+	}
+
+	orderID := created.OrderID
+	reminder.RecoveredOrderID = &orderID
+	// Revenue is reported in the base currency.
+	reminder.RecoveredRevenue = created.Total
+	if created.ExchangeRate > 0 {
+		reminder.RecoveredRevenue = model.ConvertAmount(created.Total, created.ExchangeRate, 1)
+	}
+	reminder.RecoveredAt = &placedAt
+	return u.reminderRepo.Update(reminder)
+}
+
+// matchingReminder returns the first reminder with a product that was also
+// ordered, or nil.
+func matchingReminder(reminders []model.CartReminder, items []model.OrderCreatedItem) *model.CartReminder {
+	ordered := map[uint]bool{}
+	for _, item := range items {
+		ordered[item.ProductID] = true
+	}
+	for i := range reminders {
+		for _, item := range reminders[i].Items {
+			if ordered[item.ProductID] {
+				return &reminders[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (u *cartRecoveryUsecase) Jobs() []Job {
+	return []Job{
+		{
+			Name:        JobFlagAbandonedCarts,
+			Description: fmt.Sprintf("Flag carts with items untouched for more than %s as abandoned", u.config.AbandonAfter),
+			Schedule:    "*/10 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.FlagAbandoned(ctx)
+				return fmt.Sprintf("flagged %d carts", n), err
+			},
+		},
+		{
+			Name:        JobSendCartReminders,
+			Description: "Email a restore link to owners of abandoned carts",
+			Schedule:    "5 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.SendReminders(ctx)
+				return fmt.Sprintf("sent %d reminders", n), err
+			},
+		},
+	}
+}
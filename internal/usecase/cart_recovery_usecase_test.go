@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCartReminders is an in-memory CartReminderRepository.
+type memoryCartReminders struct {
+	reminders []model.CartReminder
+	now       func() time.Time
+}
+
+func (r *memoryCartReminders) FindByID(id uint) (*model.CartReminder, error) {
+	for _, rem := range r.reminders {
+		if rem.ID == id {
+			return &rem, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryCartReminders) FindLatestByCart(cartID uint) (*model.CartReminder, error) {
+	for i := len(r.reminders) - 1; i >= 0; i-- {
+		if r.reminders[i].CartID == cartID {
+			rem := r.reminders[i]
+			return &rem, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryCartReminders) FindAttributable(userID uint, from, to time.Time) ([]model.CartReminder, error) {
+	var out []model.CartReminder
+	for i := len(r.reminders) - 1; i >= 0; i-- {
+		rem := r.reminders[i]
+		if rem.UserID == userID && rem.SentAt != nil && !rem.SentAt.Before(from) && rem.SentAt.Before(to) && rem.RecoveredOrderID == nil {
+			out = append(out, rem)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryCartReminders) FindSentBetween(from, to time.Time) ([]model.CartReminder, error) {
+	var out []model.CartReminder
+	for _, rem := range r.reminders {
+		if rem.SentAt != nil && !rem.SentAt.Before(from) && rem.SentAt.Before(to) {
+			out = append(out, rem)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryCartReminders) Create(reminder *model.CartReminder) error {
+	reminder.ID = uint(len(r.reminders) + 1)
+	reminder.CreatedAt = r.now()
+	r.reminders = append(r.reminders, *reminder)
+	return nil
+}
+
+func (r *memoryCartReminders) Update(reminder *model.CartReminder) error {
+	r.reminders[reminder.ID-1] = *reminder
+	return nil
+}
+
+// stubCartFiller records the products Restore puts back into a cart.
+type stubCartFiller struct {
+	CartUsecase
+	cart  *model.Cart
+	added []uint
+}
+
+func (s *stubCartFiller) GetCart(owner CartOwner) (*model.Cart, error) {
+	return s.cart, nil
+}
+
+func (s *stubCartFiller) AddProduct(owner CartOwner, productID uint, quantity int) (*model.Cart, error) {
+	s.added = append(s.added, productID)
+	s.cart.Items = append(s.cart.Items, model.CartItem{ProductID: productID, Quantity: quantity})
+	return s.cart, nil
+}
+
+func setupCartRecoveryUsecase(now time.Time) (*cartRecoveryUsecase, *MockCartRepository, *MockUserRepository, *memoryCartReminders, *recordingMailer, *stubCartFiller) {
+	cartRepo := new(MockCartRepository)
+	userRepo := new(MockUserRepository)
+	clock := func() time.Time { return now }
+	reminders := &memoryCartReminders{now: clock}
+	mailer := &recordingMailer{}
+	carts := &stubCartFiller{cart: &model.Cart{ID: 5, UserID: uintPtr(2)}}
+	uc := NewCartRecoveryUsecase(cartRepo, reminders, userRepo, carts, mailer,
+		signedtoken.NewSigner([]byte("test-secret")), DefaultCartRecoveryConfig()).(*cartRecoveryUsecase)
+	uc.now = clock
+	return uc, cartRepo, userRepo, reminders, mailer, carts
+}
+
+func abandonedCart(abandonedAt time.Time) model.Cart {
+	return model.Cart{
+		ID:          5,
+		UserID:      uintPtr(2),
+		Total:       59.97,
+		AbandonedAt: &abandonedAt,
+		Items: []model.CartItem{
+			{ProductID: 10, Quantity: 1, UnitPrice: 29.99, Product: model.Product{Name: "Lamp"}},
+			{ProductID: 11, Quantity: 2, UnitPrice: 14.99, Product: model.Product{Name: "Bulb"}},
+		},
+	}
+}
+
+func restoreToken(t *testing.T, msg string) string {
+	i := strings.Index(msg, "?token=")
+	if !assert.True(t, i >= 0, "reminder has no restore link") {
+		return ""
+	}
+	raw := strings.Fields(msg[i+len("?token="):])[0]
+	token, err := url.QueryUnescape(raw)
+	assert.NoError(t, err)
+	return token
+}
+
+func TestCartRecoveryUsecaseSendsOneReminderPerAbandonment(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	uc, cartRepo, userRepo, reminders, mailer, _ := setupCartRecoveryUsecase(now)
+
+	cartRepo.On("MarkAbandoned", now.Add(-4*time.Hour), now).Return(int64(1), nil)
+	cartRepo.On("FindWithFilters", map[string]string{"abandoned": "true"}).Return([]model.Cart{abandonedCart(now)}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Name: "Ann", Email: "ann@example.com"}, nil)
+
+	flagged, err := uc.FlagAbandoned(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), flagged)
+
+	sent, err := uc.SendReminders(context.Background())
+	// Assertion 541: The owner of an abandoned cart gets a reminder with a restore link
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	if assert.Len(t, mailer.sent, 1) {
+		assert.Equal(t, "ann@example.com", mailer.sent[0].To)
+		assert.Contains(t, mailer.sent[0].Body, "2 x Bulb")
+		assert.NotEmpty(t, restoreToken(t, mailer.sent[0].Body))
+	}
+	// Assertion 542: The reminder keeps a snapshot of the cart
+	assert.Len(t, reminders.reminders[0].Items, 2)
+	assert.Equal(t, 59.97, reminders.reminders[0].CartValue)
+
+	sent, err = uc.SendReminders(context.Background())
+	// Assertion 543: A cart is not reminded twice for the same abandonment
+	assert.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Len(t, mailer.sent, 1)
+}
+
+func TestCartRecoveryUsecaseRestoreAndRecoveredRevenue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	uc, cartRepo, userRepo, reminders, mailer, carts := setupCartRecoveryUsecase(now)
+	cartRepo.On("FindWithFilters", map[string]string{"abandoned": "true"}).Return([]model.Cart{abandonedCart(now.Add(-time.Hour))}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Email: "ann@example.com"}, nil)
+	_, err := uc.SendReminders(context.Background())
+	assert.NoError(t, err)
+	token := restoreToken(t, mailer.sent[0].Body)
+
+	_, err = uc.Restore(3, token)
+	// Assertion 544: A restore link only works for the cart owner and must be intact
+	assert.ErrorIs(t, err, ErrInvalidRestoreLink)
+	_, err = uc.Restore(2, token+"x")
+	assert.ErrorIs(t, err, ErrInvalidRestoreLink)
+
+	carts.cart.Items = []model.CartItem{{ProductID: 10, Quantity: 1}}
+	cart, err := uc.Restore(2, token)
+	// Assertion 545: Restoring adds back only the items missing from the cart
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{11}, carts.added)
+	assert.Len(t, cart.Items, 2)
+	assert.NotNil(t, reminders.reminders[0].RestoredAt)
+
+	payload, _ := json.Marshal(model.OrderCreated{OrderID: 77, UserID: 2, Total: 59.97, Items: []model.OrderCreatedItem{{ProductID: 11, Quantity: 2}}})
+	event := model.OutboxEvent{ID: 1, Type: model.EventOrderCreated, Payload: payload, CreatedAt: now.Add(time.Hour)}
+	assert.NoError(t, uc.creditOrder(event))
+	assert.NoError(t, uc.creditOrder(model.OutboxEvent{ID: 2, Type: model.EventOrderCreated, Payload: payload, CreatedAt: now.Add(2 * time.Hour)}))
+
+	// Assertion 546: An order after a reminder is credited to it exactly once
+	assert.Equal(t, uint(77), *reminders.reminders[0].RecoveredOrderID)
+	assert.Equal(t, 59.97, reminders.reminders[0].RecoveredRevenue)
+
+	uc.now = func() time.Time { return now.Add(3 * time.Hour) }
+	cartRepo.On("FindWithFilters", map[string]string{"abandoned": "true", "total_min": "10", "total_max": "100"}).
+		Return([]model.Cart{abandonedCart(now.Add(-time.Hour))}, nil)
+	report, err := uc.Report(map[string]string{"total_min": "10", "total_max": "100", "since": now.Add(-time.Hour).Format(time.RFC3339)})
+	// Assertion 547: The report sums abandoned value and recovered revenue
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Summary.AbandonedCarts)
+	assert.Equal(t, 59.97, report.Summary.AbandonedValue)
+	assert.Equal(t, 1, report.Summary.RemindersSent)
+	assert.Equal(t, 1, report.Summary.CartsRestored)
+	assert.Equal(t, 1, report.Summary.RecoveredOrders)
+	assert.Equal(t, 59.97, report.Summary.RecoveredRevenue)
+	cartRepo.AssertExpectations(t)
+}
+
+func TestCartRecoveryUsecaseSkipsUnrelatedOrders(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	uc, cartRepo, userRepo, reminders, _, _ := setupCartRecoveryUsecase(now)
+	cartRepo.On("FindWithFilters", map[string]string{"abandoned": "true"}).Return([]model.Cart{abandonedCart(now.Add(-time.Hour))}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Email: "ann@example.com"}, nil)
+	_, err := uc.SendReminders(context.Background())
+	assert.NoError(t, err)
+
+	payload, _ := json.Marshal(model.OrderCreated{OrderID: 78, UserID: 2, Total: 120, Items: []model.OrderCreatedItem{{ProductID: 99, Quantity: 1}}})
+	assert.NoError(t, uc.creditOrder(model.OutboxEvent{ID: 1, Type: model.EventOrderCreated, Payload: payload, CreatedAt: now.Add(time.Hour)}))
+	// Assertion 800: An order without any product of the reminded cart is not credited
+	assert.Nil(t, reminders.reminders[0].RecoveredOrderID)
+	assert.Zero(t, reminders.reminders[0].RecoveredRevenue)
+
+	payload, _ = json.Marshal(model.OrderCreated{OrderID: 79, UserID: 2, Total: 29.99, Items: []model.OrderCreatedItem{{ProductID: 10, Quantity: 1}}})
+	assert.NoError(t, uc.creditOrder(model.OutboxEvent{ID: 2, Type: model.EventOrderCreated, Payload: payload, CreatedAt: now.Add(2 * time.Hour)}))
+	// Assertion 801: A later order with a product of the reminded cart is credited
+	assert.Equal(t, uint(79), *reminders.reminders[0].RecoveredOrderID)
+	assert.Equal(t, 29.99, reminders.reminders[0].RecoveredRevenue)
+}
@@ -2,38 +2,127 @@ package usecase
 
 import (
 	"errors"
-	"fmt"
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/domain/repository"
 
 	"gorm.io/gorm"
 )
 
+// guestCartTokenBytes is the entropy of a guest cart token. Only its hash is stored.
+const guestCartTokenBytes = 32
+
+// Reasons reported by MergeGuestCart for items it had to change.
+const (
+	CartAdjustmentPriceChanged    = "price_changed"
+	CartAdjustmentQuantityReduced = "quantity_reduced"
+	CartAdjustmentRemoved         = "removed"
+)
+
+var (
+	ErrInvalidCartToken = NewError(KindNotFound, "invalid_cart_token", "unknown or expired cart token")
+	ErrInvalidQuantity  = NewError(KindValidation, "invalid_quantity", "invalid quantity", FieldError{Field: "quantity", Code: FieldInvalid})
+)
+
+// CartOwner identifies whose cart an operation works on: a signed-in user or,
+// when UserID is 0, the holder of a guest cart token.
+type CartOwner struct {
+	UserID     uint
+	GuestToken string
+}
+
+func UserCart(userID uint) CartOwner {
+	return CartOwner{UserID: userID}
+}
+
+func GuestCart(token string) CartOwner {
+	return CartOwner{GuestToken: token}
+}
+
+// CartAdjustment describes a change made to an item while merging carts
+// because the product's price or stock no longer matched the cart.
+type CartAdjustment struct {
+	ProductID   uint    `json:"product_id"`
+	Name        string  `json:"name,omitempty"`
+	Reason      string  `json:"reason"`
+	OldQuantity int     `json:"old_quantity"`
+	NewQuantity int     `json:"new_quantity"`
+	OldPrice    float64 `json:"old_price"`
+	NewPrice    float64 `json:"new_price"`
+}
+
+type CartMergeResult struct {
+	Cart        *model.Cart      `json:"cart"`
+	Adjustments []CartAdjustment `json:"adjustments"`
+}
+
 type CartUsecase interface {
-	GetByUserID(userID uint) (*model.Cart, error)
+	GetCart(owner CartOwner) (*model.Cart, error)
 	GetWithFilters(filters map[string]string) ([]model.Cart, error)
-	AddProduct(userID, productID uint, quantity int) (*model.Cart, error)
-	UpdateItem(itemID uint, quantity int) (*model.Cart, error)
-	RemoveItem(itemID uint) (*model.Cart, error)
-	ClearCart(userID uint) (*model.Cart, error)
+	// CreateGuestCart starts an empty cart for an anonymous visitor and
+	// returns it with the token that identifies it from now on.
+	CreateGuestCart() (*model.Cart, string, error)
+	AddProduct(owner CartOwner, productID uint, quantity int) (*model.Cart, error)
+	UpdateItem(owner CartOwner, itemID uint, quantity int) (*model.Cart, error)
+	RemoveItem(owner CartOwner, itemID uint) (*model.Cart, error)
+	ClearCart(owner CartOwner) (*model.Cart, error)
+	// MergeGuestCart moves the guest cart behind token into the user's cart,
+	// adding up quantities of products in both, then reprices every item and
+	// caps it at the available stock. It returns nil if the token no longer
+	// refers to a cart, e.g. because it was merged already.
+	MergeGuestCart(userID uint, token string) (*CartMergeResult, error)
 }
 
 type cartUsecase struct {
 	cartRepo     repository.CartRepository
 	cartItemRepo repository.CartItemRepository
 	productRepo  repository.ProductRepository
+	transactor   repository.Transactor
+	prices       PriceConverter
+	pricing      PriceResolver
 }
 
 func NewCartUsecase(
 	cartRepo repository.CartRepository,
 	cartItemRepo repository.CartItemRepository,
 	productRepo repository.ProductRepository,
+	transactor repository.Transactor,
+	prices PriceConverter,
+	pricing PriceResolver,
 ) CartUsecase {
-	return &cartUsecase{cartRepo, cartItemRepo, productRepo}
+	return &cartUsecase{cartRepo, cartItemRepo, productRepo, transactor, prices, pricing}
 }
 
-func (u *cartUsecase) GetByUserID(userID uint) (*model.Cart, error) {
-	cart, err := u.cartRepo.FindByUserID(userID)
+// basePrice returns the price the product sells at now in the base
+// currency, which carts are kept in.
+func (u *cartUsecase) basePrice(product *model.Product) (float64, error) {
+	price, err := u.pricing.EffectivePrice(product)
+	if err != nil {
+		return 0, err
+	}
+	return u.prices.Convert(price, product.Currency, u.prices.BaseCurrency())
+}
+
+// findCart returns the owner's cart, or nil if a user has none yet. An
+// unknown guest token is an error, since guest carts exist before their token.
+func (u *cartUsecase) findCart(owner CartOwner) (*model.Cart, error) {
+	if owner.UserID != 0 {
+		return u.cartRepo.FindByUserID(owner.UserID)
+	}
+	if owner.GuestToken == "" {
+		return nil, ErrInvalidCartToken
+	}
+	cart, err := u.cartRepo.FindByGuestTokenHash(hashToken(owner.GuestToken))
+	if err != nil {
+		return nil, err
+	}
+	if cart == nil {
+		return nil, ErrInvalidCartToken
+	}
+	return cart, nil
+}
+
+func (u *cartUsecase) GetCart(owner CartOwner) (*model.Cart, error) {
+	cart, err := u.findCart(owner)
 	if err != nil {
 		return nil, err
 	}
@@ -47,21 +136,28 @@ func (u *cartUsecase) GetWithFilters(filters map[string]string) ([]model.Cart, e
 	return u.cartRepo.FindWithFilters(filters)
 }
 
-func (u *cartUsecase) AddProduct(userID, productID uint, quantity int) (*model.Cart, error) {
+func (u *cartUsecase) CreateGuestCart() (*model.Cart, string, error) {
+	token, err := randomHex(guestCartTokenBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := hashToken(token)
+	cart := &model.Cart{GuestTokenHash: &hash, Total: 0}
+	if err := u.cartRepo.Create(cart); err != nil {
+		return nil, "", err
+	}
+	return cart, token, nil
+}
+
+func (u *cartUsecase) AddProduct(owner CartOwner, productID uint, quantity int) (*model.Cart, error) {
 	if quantity <= 0 {
-		return nil, errors.New("invalid quantity")
+		return nil, ErrInvalidQuantity
 	}
 
-	cart, err := u.cartRepo.FindByUserID(userID)
+	cart, err := u.findCart(owner)
 	if err != nil {
 		return nil, err
 	}
-	if cart == nil {
-		cart = &model.Cart{UserID: userID, Total: 0}
-		if err := u.cartRepo.Create(cart); err != nil {
-			return nil, err
-		}
-	}
 
 	prod, err := u.productRepo.FindByID(productID)
 	if err != nil {
@@ -70,79 +166,117 @@ func (u *cartUsecase) AddProduct(userID, productID uint, quantity int) (*model.C
 	if prod == nil {
 		return nil, gorm.ErrRecordNotFound
 	}
-
-	item := &model.CartItem{
-		CartID:    cart.ID,
-		ProductID: prod.ID,
-		Quantity:  quantity,
-		UnitPrice: prod.Price,
-		Subtotal:  prod.Price * float64(quantity),
-	}
-	if err := u.cartItemRepo.AddItem(item); err != nil {
+	price, err := u.basePrice(prod)
+	if err != nil {
 		return nil, err
 	}
 
-	cart.Total += item.Subtotal
-	if err := u.cartRepo.Update(cart); err != nil {
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if cart == nil {
+			userID := owner.UserID
+			cart = &model.Cart{UserID: &userID, Total: 0}
+			if err := repos.Carts.Create(cart); err != nil {
+				return err
+			}
+		}
+
+		item := &model.CartItem{
+			CartID:    cart.ID,
+			ProductID: prod.ID,
+			Quantity:  quantity,
+			UnitPrice: price,
+			Subtotal:  model.RoundMoney(price * float64(quantity)),
+		}
+		if err := repos.CartItems.AddItem(item); err != nil {
+			return err
+		}
+
+		cart.Total += item.Subtotal
+		return u.saveCart(repos, cart)
+	})
+	if err != nil {
 		return nil, err
 	}
-	return u.cartRepo.FindByUserID(userID)
+	return u.findCart(owner)
 }
 
-func (u *cartUsecase) UpdateItem(itemID uint, quantity int) (*model.Cart, error) {
+// ownItem returns the item together with the owner's cart. Items in other
+// carts are reported as not found.
+func (u *cartUsecase) ownItem(owner CartOwner, itemID uint) (*model.Cart, *model.CartItem, error) {
+	cart, err := u.findCart(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cart == nil {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
 	item, err := u.cartItemRepo.FindByID(itemID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if item == nil {
-		return nil, gorm.ErrRecordNotFound
+	if item == nil || item.CartID != cart.ID {
+		return nil, nil, gorm.ErrRecordNotFound
 	}
-	fmt.Println(item.CartID)
-	cart, err := u.cartRepo.FindByCartID(item.CartID)
+	return cart, item, nil
+}
+
+func (u *cartUsecase) UpdateItem(owner CartOwner, itemID uint, quantity int) (*model.Cart, error) {
+	if quantity < 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	cart, item, err := u.ownItem(owner, itemID)
 	if err != nil {
 		return nil, err
 	}
-	if cart == nil {
-		return nil, gorm.ErrRecordNotFound
-	}
 
-	switch {
-	case quantity < 0:
-		return nil, errors.New("invalid quantity")
-	case quantity == 0:
-		cart.Total -= item.Subtotal
-		if err := u.cartRepo.Update(cart); err != nil {
-			return nil, err
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if quantity == 0 {
+			cart.Total -= item.Subtotal
+			if err := u.saveCart(repos, cart); err != nil {
+				return err
+			}
+			return repos.CartItems.DeleteItem(itemID)
 		}
-		if err := u.cartItemRepo.DeleteItem(itemID); err != nil {
-			return nil, err
-		}
-	default:
+
 		old := item.Subtotal
 		item.Quantity = quantity
-		item.Subtotal = item.UnitPrice * float64(quantity)
-		if err := u.cartItemRepo.UpdateItem(item); err != nil {
-			return nil, err
+		item.Subtotal = model.RoundMoney(item.UnitPrice * float64(quantity))
+		if err := repos.CartItems.UpdateItem(item); err != nil {
+			return err
 		}
 		cart.Total += item.Subtotal - old
-		if err := u.cartRepo.Update(cart); err != nil {
-			return nil, err
-		}
+		return u.saveCart(repos, cart)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return u.cartRepo.FindByUserID(cart.UserID)
+	return u.findCart(owner)
 }
 
-func (u *cartUsecase) RemoveItem(itemID uint) (*model.Cart, error) {
-	item, err := u.cartItemRepo.FindByID(itemID)
+func (u *cartUsecase) RemoveItem(owner CartOwner, itemID uint) (*model.Cart, error) {
+	cart, item, err := u.ownItem(owner, itemID)
 	if err != nil {
 		return nil, err
 	}
-	if item == nil {
-		return nil, gorm.ErrRecordNotFound
+
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		cart.Total -= item.Subtotal
+		if err := u.saveCart(repos, cart); err != nil {
+			return err
+		}
+		return repos.CartItems.DeleteItem(itemID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	cart, err := u.cartRepo.FindByCartID(item.CartID)
+	return u.findCart(owner)
+}
+
+func (u *cartUsecase) ClearCart(owner CartOwner) (*model.Cart, error) {
+	cart, err := u.findCart(owner)
 	if err != nil {
 		return nil, err
 	}
@@ -150,33 +284,164 @@ func (u *cartUsecase) RemoveItem(itemID uint) (*model.Cart, error) {
 		return nil, gorm.ErrRecordNotFound
 	}
 
-	cart.Total -= item.Subtotal
-	if err := u.cartRepo.Update(cart); err != nil {
-		return nil, err
-	}
-	if err := u.cartItemRepo.DeleteItem(itemID); err != nil {
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if err := repos.CartItems.ClearCart(cart.ID); err != nil {
+			return err
+		}
+		cart.Total = 0
+		return u.saveCart(repos, cart)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return u.cartRepo.FindByUserID(cart.UserID)
+	return u.findCart(owner)
 }
 
-func (u *cartUsecase) ClearCart(userID uint) (*model.Cart, error) {
-	cart, err := u.cartRepo.FindByUserID(userID)
+func (u *cartUsecase) MergeGuestCart(userID uint, token string) (*CartMergeResult, error) {
+	guest, err := u.cartRepo.FindByGuestTokenHash(hashToken(token))
 	if err != nil {
 		return nil, err
 	}
-	if cart == nil {
-		return nil, gorm.ErrRecordNotFound
+	if guest == nil {
+		return nil, nil
+	}
+	guestItems, err := u.cartItemRepo.FindByCartID(guest.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := u.cartItemRepo.ClearCart(cart.ID); err != nil {
+	cart, err := u.cartRepo.FindByUserID(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
-	cart.Total = 0
-	if err := u.cartRepo.Update(cart); err != nil {
+	var items []model.CartItem
+	if cart != nil {
+		if items, err = u.cartItemRepo.FindByCartID(cart.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &CartMergeResult{Adjustments: []CartAdjustment{}}
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		// moved marks items that have to be saved even if reconcile keeps them as they are.
+		moved := map[uint]bool{}
+		if cart == nil {
+			// Nothing to merge into: the guest cart becomes the user's cart.
+			cart = guest
+			cart.UserID = &userID
+			cart.GuestTokenHash = nil
+			items = guestItems
+		} else {
+			index := map[uint]int{}
+			for i, item := range items {
+				index[item.ProductID] = i
+			}
+			for _, item := range guestItems {
+				if i, ok := index[item.ProductID]; ok {
+					items[i].Quantity += item.Quantity
+					moved[items[i].ID] = true
+					if err := repos.CartItems.DeleteItem(item.ID); err != nil {
+						return err
+					}
+					continue
+				}
+				item.CartID = cart.ID
+				moved[item.ID] = true
+				index[item.ProductID] = len(items)
+				items = append(items, item)
+			}
+			if err := repos.Carts.Delete(guest.ID); err != nil {
+				return err
+			}
+		}
+
+		total := 0.0
+		for i := range items {
+			adjustments, keep, err := u.reconcileItem(repos, &items[i], moved[items[i].ID])
+			if err != nil {
+				return err
+			}
+			result.Adjustments = append(result.Adjustments, adjustments...)
+			if keep {
+				total += items[i].Subtotal
+			}
+		}
+
+		cart.Items = nil
+		cart.Total = total
+		return u.saveCart(repos, cart)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return u.cartRepo.FindByUserID(userID)
+	if result.Cart, err = u.cartRepo.FindByUserID(userID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reconcileItem brings a merged item in line with the current product: the
+// current price, at most the available stock, and removed if the product
+// can no longer be bought. It reports whether the item was kept.
+func (u *cartUsecase) reconcileItem(repos repository.TxRepositories, item *model.CartItem, dirty bool) ([]CartAdjustment, bool, error) {
+	prod, err := repos.Products.FindByID(item.ProductID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	adjustment := CartAdjustment{
+		ProductID:   item.ProductID,
+		Name:        item.Product.Name,
+		OldQuantity: item.Quantity,
+		OldPrice:    item.UnitPrice,
+	}
+	if prod == nil || !prod.IsActive || prod.Stock <= 0 {
+		adjustment.Reason = CartAdjustmentRemoved
+		adjustment.NewPrice = adjustment.OldPrice
+		return []CartAdjustment{adjustment}, false, repos.CartItems.DeleteItem(item.ID)
+	}
+	price, err := u.basePrice(prod)
+	if err != nil {
+		return nil, false, err
+	}
+	adjustment.Name = prod.Name
+	adjustment.NewQuantity = item.Quantity
+	adjustment.NewPrice = price
+
+	var adjustments []CartAdjustment
+	if item.UnitPrice != price {
+		changed := adjustment
+		changed.Reason = CartAdjustmentPriceChanged
+		adjustments = append(adjustments, changed)
+		item.UnitPrice = price
+		dirty = true
+	}
+	if item.Quantity > prod.Stock {
+		reduced := adjustment
+		reduced.Reason = CartAdjustmentQuantityReduced
+		reduced.NewQuantity = prod.Stock
+		adjustments = append(adjustments, reduced)
+		item.Quantity = prod.Stock
+		dirty = true
+	}
+	if !dirty {
+		return nil, true, nil
+	}
+
+	item.Subtotal = model.RoundMoney(item.UnitPrice * float64(item.Quantity))
+	// The preloaded product must not be written back along with the item.
+	item.Product = model.Product{}
+	return adjustments, true, repos.CartItems.UpdateItem(item)
+}
+
+// saveCart stores the new cart total and raises CartUpdated in the same
+// transaction. Any change makes the cart active again.
+func (u *cartUsecase) saveCart(repos repository.TxRepositories, cart *model.Cart) error {
+	cart.AbandonedAt = nil
+	if err := repos.Carts.Update(cart); err != nil {
+		return err
+	}
+	return appendEvents(repos.Outbox, model.CartUpdated{CartID: cart.ID, UserID: cart.OwnerID(), Total: cart.Total})
 }
@@ -2,12 +2,18 @@ package usecase
 
 import (
 	"testing"
+	"time"
 
 	"go-ecommerce-api/internal/domain/model"
 
+	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
+func uintPtr(v uint) *uint {
+	return &v
+}
+
 // Mock repositories for cart testing
 type mockCartRepository struct {
 	carts  []model.Cart
@@ -35,7 +41,7 @@ func newMockCartItemRepository() *mockCartItemRepository {
 
 func (m *mockCartRepository) FindByUserID(userID uint) (*model.Cart, error) {
 	for _, cart := range m.carts {
-		if cart.UserID == userID {
+		if cart.UserID != nil && *cart.UserID == userID {
 			return &cart, nil
 		}
 	}
@@ -51,6 +57,15 @@ func (m *mockCartRepository) FindByCartID(cartID uint) (*model.Cart, error) {
 	return nil, gorm.ErrRecordNotFound
 }
 
+func (m *mockCartRepository) FindByGuestTokenHash(hash string) (*model.Cart, error) {
+	for _, cart := range m.carts {
+		if cart.GuestTokenHash != nil && *cart.GuestTokenHash == hash {
+			return &cart, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockCartRepository) FindWithFilters(filters map[string]string) ([]model.Cart, error) {
 	return m.carts, nil
 }
@@ -82,6 +97,43 @@ func (m *mockCartRepository) Delete(id uint) error {
 	return gorm.ErrRecordNotFound
 }
 
+func (m *mockCartRepository) DeleteStale(before time.Time) (int64, error) {
+	var kept []model.Cart
+	for _, c := range m.carts {
+		if (c.IsGuest() || len(c.Items) == 0) && c.UpdatedAt.Before(before) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	removed := int64(len(m.carts) - len(kept))
+	m.carts = kept
+	return removed, nil
+}
+
+func (m *mockCartRepository) DeleteUnusedGuests(createdBefore time.Time) (int64, error) {
+	var kept []model.Cart
+	for _, c := range m.carts {
+		if c.IsGuest() && c.CreatedAt.Before(createdBefore) && c.UpdatedAt.Sub(c.CreatedAt) < time.Minute {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	removed := int64(len(m.carts) - len(kept))
+	m.carts = kept
+	return removed, nil
+}
+
+func (m *mockCartRepository) MarkAbandoned(idleSince, now time.Time) (int64, error) {
+	var flagged int64
+	for i, c := range m.carts {
+		if c.AbandonedAt == nil && len(c.Items) > 0 && c.UpdatedAt.Before(idleSince) {
+			m.carts[i].AbandonedAt = &now
+			flagged++
+		}
+	}
+	return flagged, nil
+}
+
 func (m *mockCartItemRepository) FindByID(id uint) (*model.CartItem, error) {
 	for _, item := range m.items {
 		if item.ID == id {
@@ -143,10 +195,10 @@ func TestCartUsecaseGetByUserID(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	// Test Case 17: Get cart for non-existent user
-	cart, err := usecase.GetByUserID(999)
+	cart, err := usecase.GetCart(UserCart(999))
 	// Assertion 61: Should return ErrRecordNotFound for non-existent user cart
 	if err != gorm.ErrRecordNotFound {
 		t.Errorf("Expected gorm.ErrRecordNotFound, got %v", err)
@@ -158,13 +210,13 @@ func TestCartUsecaseGetByUserID(t *testing.T) {
 
 	// Create test cart
 	testCart := &model.Cart{
-		UserID: 1,
+		UserID: uintPtr(1),
 		Total:  0.0,
 	}
 	cartRepo.Create(testCart)
 
 	// Test Case 18: Get existing user cart
-	cart, err = usecase.GetByUserID(1)
+	cart, err = usecase.GetCart(UserCart(1))
 	// Assertion 63: No error should occur when getting existing cart
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -175,8 +227,8 @@ func TestCartUsecaseGetByUserID(t *testing.T) {
 		return
 	}
 	// Assertion 65: Cart UserID should match requested user
-	if cart.UserID != 1 {
-		t.Errorf("Expected UserID 1, got %d", cart.UserID)
+	if cart.OwnerID() != 1 {
+		t.Errorf("Expected UserID 1, got %d", cart.OwnerID())
 	}
 	// Assertion 66: Cart should have correct total
 	if cart.Total != 0.0 {
@@ -188,13 +240,13 @@ func TestCartUsecaseGetWithFilters(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	// Add test carts
 	testCarts := []*model.Cart{
-		{UserID: 1, Total: 50.0},
-		{UserID: 2, Total: 75.0},
-		{UserID: 3, Total: 100.0},
+		{UserID: uintPtr(1), Total: 50.0},
+		{UserID: uintPtr(2), Total: 75.0},
+		{UserID: uintPtr(3), Total: 100.0},
 	}
 
 	for _, c := range testCarts {
@@ -213,8 +265,8 @@ func TestCartUsecaseGetWithFilters(t *testing.T) {
 		t.Errorf("Expected 3 carts, got %d", len(carts))
 	}
 	// Assertion 69: First cart should have correct UserID
-	if carts[0].UserID != 1 {
-		t.Errorf("Expected first cart UserID 1, got %d", carts[0].UserID)
+	if carts[0].OwnerID() != 1 {
+		t.Errorf("Expected first cart UserID 1, got %d", carts[0].OwnerID())
 	}
 	// Assertion 70: Second cart should have correct total price
 	if carts[1].Total != 75.0 {
@@ -226,7 +278,7 @@ func TestCartUsecaseAddProduct(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	// Setup test product
 	testProduct := &model.Product{
@@ -239,7 +291,7 @@ func TestCartUsecaseAddProduct(t *testing.T) {
 	productRepo.Create(testProduct)
 
 	// Test Case 20: Add product with invalid quantity (zero)
-	cart, err := usecase.AddProduct(1, 1, 0)
+	cart, err := usecase.AddProduct(UserCart(1), 1, 0)
 	// Assertion 71: Should return error for zero quantity
 	if err == nil {
 		t.Error("Expected error for zero quantity")
@@ -250,7 +302,7 @@ func TestCartUsecaseAddProduct(t *testing.T) {
 	}
 
 	// Test Case 21: Add product with negative quantity
-	cart, err = usecase.AddProduct(1, 1, -1)
+	cart, err = usecase.AddProduct(UserCart(1), 1, -1)
 	// Assertion 73: Should return error for negative quantity
 	if err == nil {
 		t.Error("Expected error for negative quantity")
@@ -261,7 +313,7 @@ func TestCartUsecaseAddProduct(t *testing.T) {
 	}
 
 	// Test Case 22: Add non-existent product
-	_, err = usecase.AddProduct(1, 999, 1)
+	_, err = usecase.AddProduct(UserCart(1), 999, 1)
 	// Assertion 75: Should return error for non-existent product
 	if err == nil {
 		t.Error("Expected error for non-existent product")
@@ -269,13 +321,13 @@ func TestCartUsecaseAddProduct(t *testing.T) {
 
 	// Create a cart for the user first (as AddProduct expects cart to exist)
 	testCart := &model.Cart{
-		UserID: 1,
+		UserID: uintPtr(1),
 		Total:  0.0,
 	}
 	cartRepo.Create(testCart)
 
 	// Test Case 23: Add product to existing cart
-	cart, err = usecase.AddProduct(1, 1, 2)
+	cart, err = usecase.AddProduct(UserCart(1), 1, 2)
 	// Assertion 76: Should not return error when adding product to existing cart
 	if err != nil {
 		t.Errorf("Expected no error adding product to existing cart, got %v", err)
@@ -302,7 +354,7 @@ func createCartTestProducts(productRepo *mockProductRepository) {
 // Helper function to setup cart with items
 func setupCartWithItems(cartRepo *mockCartRepository, cartItemRepo *mockCartItemRepository, userID uint) {
 	testCart := &model.Cart{
-		UserID: userID,
+		UserID: uintPtr(userID),
 		Total:  0.0,
 	}
 	cartRepo.Create(testCart)
@@ -330,13 +382,13 @@ func TestCartUsecaseIntegrationCreateAndRetrieve(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	createCartTestProducts(productRepo)
 	userID := uint(1)
 
 	// Initially no cart should exist
-	_, err := usecase.GetByUserID(userID)
+	_, err := usecase.GetCart(UserCart(userID))
 	// Assertion 78: Should return error for non-existent cart initially
 	if err != gorm.ErrRecordNotFound {
 		t.Errorf("Expected gorm.ErrRecordNotFound initially, got %v", err)
@@ -346,7 +398,7 @@ func TestCartUsecaseIntegrationCreateAndRetrieve(t *testing.T) {
 	setupCartWithItems(cartRepo, cartItemRepo, userID)
 
 	// Get cart and verify items
-	cart, err := usecase.GetByUserID(userID)
+	cart, err := usecase.GetCart(UserCart(userID))
 	// Assertion 79: Should successfully get cart after adding items
 	if err != nil {
 		t.Errorf("Expected no error getting cart, got %v", err)
@@ -357,8 +409,8 @@ func TestCartUsecaseIntegrationCreateAndRetrieve(t *testing.T) {
 		return
 	}
 	// Assertion 81: Cart should belong to correct user
-	if cart.UserID != userID {
-		t.Errorf("Expected cart UserID %d, got %d", userID, cart.UserID)
+	if cart.OwnerID() != userID {
+		t.Errorf("Expected cart UserID %d, got %d", userID, cart.OwnerID())
 	}
 }
 
@@ -426,7 +478,7 @@ func TestCartUsecaseIntegrationClearCart(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	createCartTestProducts(productRepo)
 	userID := uint(1)
@@ -451,13 +503,13 @@ func TestCartUsecaseIntegrationClearCart(t *testing.T) {
 	}
 
 	// Verify cart still exists but is empty
-	cart, err := usecase.GetByUserID(userID)
+	cart, err := usecase.GetCart(UserCart(userID))
 	// Assertion 90: Cart should still exist after clearing items
 	if err != nil {
 		t.Errorf("Expected no error getting cart after clearing, got %v", err)
 	}
 	// Assertion 91: Cart should not be nil after clearing items
-	if cart != nil && cart.UserID != userID {
+	if cart != nil && cart.OwnerID() != userID {
 		t.Errorf("Expected cart to still belong to user %d", userID)
 	}
 }
@@ -466,7 +518,7 @@ func TestCartUsecaseIntegrationDeleteCart(t *testing.T) {
 	cartRepo := newMockCartRepository()
 	cartItemRepo := newMockCartItemRepository()
 	productRepo := newMockProductRepository()
-	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
 
 	createCartTestProducts(productRepo)
 	userID := uint(1)
@@ -480,9 +532,154 @@ func TestCartUsecaseIntegrationDeleteCart(t *testing.T) {
 	}
 
 	// Verify cart was deleted
-	_, err = usecase.GetByUserID(userID)
+	_, err = usecase.GetCart(UserCart(userID))
 	// Assertion 93: Should return error for deleted cart
 	if err != gorm.ErrRecordNotFound {
 		t.Errorf("Expected gorm.ErrRecordNotFound for deleted cart, got %v", err)
 	}
 }
+
+func TestCartUsecaseRecordsCartUpdated(t *testing.T) {
+	cartRepo := newMockCartRepository()
+	cartItemRepo := newMockCartItemRepository()
+	productRepo := newMockProductRepository()
+	transactor := newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo)
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, transactor, newTestCurrency(), newTestPricing())
+
+	createCartTestProducts(productRepo)
+	cartRepo.Create(&model.Cart{UserID: uintPtr(1)})
+
+	// Test Case 26: Add a product, then clear the cart
+	if _, err := usecase.AddProduct(UserCart(1), 1, 2); err != nil {
+		t.Fatalf("Expected no error adding product, got %v", err)
+	}
+	if _, err := usecase.ClearCart(UserCart(1)); err != nil {
+		t.Fatalf("Expected no error clearing cart, got %v", err)
+	}
+
+	// Assertion 518: Every cart change should raise CartUpdated with the new total
+	types := transactor.outbox.types()
+	if len(types) != 2 || types[0] != model.EventCartUpdated || types[1] != model.EventCartUpdated {
+		t.Fatalf("Expected two CartUpdated events, got %v", types)
+	}
+	var cleared model.CartUpdated
+	if err := transactor.outbox.events[1].Decode(&cleared); err != nil || cleared.Total != 0 || cleared.UserID != 1 {
+		t.Errorf("Unexpected payload %+v (err %v)", cleared, err)
+	}
+}
+
+func TestCartUsecaseGuestCartOwnership(t *testing.T) {
+	cartRepo := newMockCartRepository()
+	cartItemRepo := newMockCartItemRepository()
+	productRepo := newMockProductRepository()
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
+	createCartTestProducts(productRepo)
+
+	// Test Case 27: A guest builds a cart identified only by its token
+	guest, token, err := usecase.CreateGuestCart()
+	assert.NoError(t, err)
+	// Assertion 548: Guest carts have no user and only the token's hash is stored
+	assert.True(t, guest.IsGuest())
+	assert.NotEmpty(t, token)
+	assert.NotEqual(t, token, *guest.GuestTokenHash)
+
+	cart, err := usecase.AddProduct(GuestCart(token), 1, 2)
+	// Assertion 549: The token gives access to the guest cart
+	assert.NoError(t, err)
+	assert.Equal(t, guest.ID, cart.ID)
+	assert.Equal(t, 20.0, cart.Total)
+
+	_, err = usecase.GetCart(GuestCart("not-" + token))
+	// Assertion 550: Unknown cart tokens are rejected
+	assert.ErrorIs(t, err, ErrInvalidCartToken)
+	_, err = usecase.AddProduct(CartOwner{}, 1, 1)
+	assert.ErrorIs(t, err, ErrInvalidCartToken)
+
+	cartRepo.Create(&model.Cart{UserID: uintPtr(7)})
+	_, err = usecase.AddProduct(UserCart(7), 2, 1)
+	assert.NoError(t, err)
+	userItems, _ := cartItemRepo.FindByCartID(2)
+	guestItems, _ := cartItemRepo.FindByCartID(guest.ID)
+
+	_, err = usecase.UpdateItem(GuestCart(token), userItems[0].ID, 5)
+	// Assertion 551: Items of another cart cannot be changed or removed
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = usecase.RemoveItem(UserCart(7), guestItems[0].ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	cart, err = usecase.RemoveItem(GuestCart(token), guestItems[0].ID)
+	assert.NoError(t, err)
+	assert.Zero(t, cart.Total)
+}
+
+func TestCartUsecaseMergeGuestCart(t *testing.T) {
+	cartRepo := newMockCartRepository()
+	cartItemRepo := newMockCartItemRepository()
+	productRepo := newMockProductRepository()
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
+	createCartTestProducts(productRepo)
+	cartRepo.Create(&model.Cart{UserID: uintPtr(1)})
+
+	_, err := usecase.AddProduct(UserCart(1), 1, 2)
+	assert.NoError(t, err)
+	guest, token, _ := usecase.CreateGuestCart()
+	for _, add := range []struct {
+		productID uint
+		quantity  int
+	}{{1, 1}, {2, 60}, {3, 1}} {
+		_, err := usecase.AddProduct(GuestCart(token), add.productID, add.quantity)
+		assert.NoError(t, err)
+	}
+	// Product 2 has only 50 in stock and product 3 got more expensive since.
+	productRepo.products[2].Price = 18.0
+
+	result, err := usecase.MergeGuestCart(1, token)
+	assert.NoError(t, err)
+	items, _ := cartItemRepo.FindByCartID(result.Cart.ID)
+	quantities := map[uint]int{}
+	for _, item := range items {
+		quantities[item.ProductID] = item.Quantity
+	}
+	// Assertion 552: Quantities of products in both carts are added up in the user's cart
+	assert.Equal(t, map[uint]int{1: 3, 2: 50, 3: 1}, quantities)
+	left, _ := cartItemRepo.FindByCartID(guest.ID)
+	assert.Empty(t, left)
+
+	// Assertion 553: Items are capped at the stock and repriced, and each change is reported
+	assert.ElementsMatch(t, []CartAdjustment{
+		{ProductID: 2, Name: "Product 2", Reason: CartAdjustmentQuantityReduced, OldQuantity: 60, NewQuantity: 50, OldPrice: 20, NewPrice: 20},
+		{ProductID: 3, Name: "Product 3", Reason: CartAdjustmentPriceChanged, OldQuantity: 1, NewQuantity: 1, OldPrice: 15, NewPrice: 18},
+	}, result.Adjustments)
+	assert.Equal(t, 30.0+1000.0+18.0, result.Cart.Total)
+
+	// Assertion 554: The guest cart is gone, so its token stops working and merging again is a no-op
+	_, err = usecase.GetCart(GuestCart(token))
+	assert.ErrorIs(t, err, ErrInvalidCartToken)
+	result, err = usecase.MergeGuestCart(1, token)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestCartUsecaseMergeGuestCartWithoutUserCart(t *testing.T) {
+	cartRepo := newMockCartRepository()
+	cartItemRepo := newMockCartItemRepository()
+	productRepo := newMockProductRepository()
+	usecase := NewCartUsecase(cartRepo, cartItemRepo, productRepo, newFakeTransactor(nil, cartRepo, cartItemRepo, productRepo), newTestCurrency(), newTestPricing())
+	createCartTestProducts(productRepo)
+
+	guest, token, _ := usecase.CreateGuestCart()
+	_, err := usecase.AddProduct(GuestCart(token), 1, 2)
+	assert.NoError(t, err)
+	productRepo.products[0].IsActive = false
+
+	result, err := usecase.MergeGuestCart(4, token)
+	// Assertion 555: A user without a cart takes over the guest cart, minus unavailable products
+	assert.NoError(t, err)
+	assert.Equal(t, guest.ID, result.Cart.ID)
+	assert.Equal(t, uint(4), result.Cart.OwnerID())
+	assert.Nil(t, result.Cart.GuestTokenHash)
+	assert.Zero(t, result.Cart.Total)
+	if assert.Len(t, result.Adjustments, 1) {
+		assert.Equal(t, CartAdjustmentRemoved, result.Adjustments[0].Reason)
+	}
+}
@@ -1,7 +1,10 @@
 package usecase
 
 import (
-	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/domain/repository"
@@ -9,22 +12,51 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidCategoryParent = NewError(KindValidation, "invalid_category_parent", "invalid parent category")
+	ErrCategoryCycle         = NewError(KindConflict, "category_cycle", "a category cannot be moved under itself or one of its descendants")
+	ErrInvalidCategoryData   = NewError(KindValidation, "invalid_category_data", "invalid category data", FieldError{Field: "name", Code: FieldRequired})
+	ErrInvalidCategory       = NewError(KindValidation, "invalid_category", "invalid category")
+)
+
 type CategoryUsecase interface {
 	GetByID(id uint) (*model.Category, error)
 	GetAll() ([]model.Category, error)
 	GetWithFilters(filters map[string]string) ([]model.Category, error)
-	Create(category *model.Category) (*model.Category, error)
-	Update(category *model.Category) (*model.Category, error)
-	Delete(id uint) error
+	Create(actor Actor, category *model.Category) (*model.Category, error)
+	Update(actor Actor, category *model.Category) (*model.Category, error)
+	Delete(actor Actor, id uint) error
+	// GetTree returns every category nested under the top-level ones,
+	// sorted by name, with product counts that include subcategories.
+	GetTree() ([]model.CategoryNode, error)
+	// GetPath returns the breadcrumbs from the top-level category down to
+	// the category itself.
+	GetPath(id uint) ([]model.CategoryBreadcrumb, error)
+	// Move puts the category under parentID, or at the top when it is nil.
+	// Create, Update and Move fail with ErrInvalidCategoryParent for an
+	// unknown parent and with ErrCategoryCycle for a category's own
+	// descendant.
+	Move(actor Actor, id uint, parentID *uint) (*model.Category, error)
+	// GetBySlug finds the category by its slug or by one it had before, in
+	// which case the category returned has a different slug.
+	GetBySlug(slug string) (*model.Category, error)
 }
 
 type categoryUsecase struct {
 	categoryRepo repository.CategoryRepository
+	slugs        slugs
+	auditor      Auditor
 }
 
-func NewCategoryUsecase(categoryRepo repository.CategoryRepository) CategoryUsecase {
+func NewCategoryUsecase(
+	categoryRepo repository.CategoryRepository,
+	redirects repository.SlugRedirectRepository,
+	auditor Auditor,
+) CategoryUsecase {
 	return &categoryUsecase{
 		categoryRepo: categoryRepo,
+		slugs:        categorySlugs(categoryRepo, redirects),
+		auditor:      auditor,
 	}
 }
 
@@ -39,6 +71,61 @@ func (u *categoryUsecase) GetByID(id uint) (*model.Category, error) {
 	return category, nil
 }
 
+func (u *categoryUsecase) GetBySlug(slug string) (*model.Category, error) {
+	category, err := u.categoryRepo.FindBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if category != nil {
+		return category, nil
+	}
+	redirect, err := u.slugs.redirects.Find(model.SlugEntityCategory, slug)
+	if err != nil {
+		return nil, err
+	}
+	if redirect == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u.GetByID(redirect.EntityID)
+}
+
+// normalizeSEO picks the category's slug and trims its meta fields.
+func (u *categoryUsecase) normalizeSEO(category *model.Category) (err error) {
+	category.MetaTitle = strings.TrimSpace(category.MetaTitle)
+	category.MetaDescription = strings.TrimSpace(category.MetaDescription)
+	category.Slug, err = u.slugs.choose(category.ID, category.Slug, category.Name)
+	return err
+}
+
+// checkParent makes sure parentID exists and is neither the category id
+// nor one of its descendants. id is 0 for new categories.
+func (u *categoryUsecase) checkParent(id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	categories, err := u.categoryRepo.FindAll()
+	if err != nil {
+		return err
+	}
+	parents := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	if _, ok := parents[*parentID]; !ok {
+		return fmt.Errorf("%w: category %d not found", ErrInvalidCategoryParent, *parentID)
+	}
+	// Meeting the category on the way up from its new parent means it would
+	// become its own ancestor.
+	seen := make(map[uint]bool)
+	for next := parentID; next != nil && !seen[*next]; next = parents[*next] {
+		if *next == id {
+			return ErrCategoryCycle
+		}
+		seen[*next] = true
+	}
+	return nil
+}
+
 func (u *categoryUsecase) GetAll() ([]model.Category, error) {
 	return u.categoryRepo.FindAll()
 }
@@ -47,27 +134,67 @@ func (u *categoryUsecase) GetWithFilters(filters map[string]string) ([]model.Cat
 	return u.categoryRepo.FindWithFilters(filters)
 }
 
-func (u *categoryUsecase) Create(category *model.Category) (*model.Category, error) {
+func (u *categoryUsecase) Create(actor Actor, category *model.Category) (*model.Category, error) {
 	if category == nil || category.Name == "" {
-		return nil, errors.New("invalid category data")
+		return nil, ErrInvalidCategoryData
+	}
+	if err := u.checkParent(0, category.ParentID); err != nil {
+		return nil, err
+	}
+	if err := u.normalizeSEO(category); err != nil {
+		return nil, err
 	}
 	if err := u.categoryRepo.Create(category); err != nil {
 		return nil, err
 	}
-	return u.categoryRepo.FindByID(category.ID)
+	if err := u.slugs.changed(category.ID, "", category.Slug); err != nil {
+		return nil, err
+	}
+	created, err := u.categoryRepo.FindByID(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityCategory, category.ID, nil, created)
+	return created, nil
 }
 
-func (u *categoryUsecase) Update(category *model.Category) (*model.Category, error) {
+func (u *categoryUsecase) Update(actor Actor, category *model.Category) (*model.Category, error) {
 	if category == nil || category.ID == 0 {
-		return nil, errors.New("invalid category")
+		return nil, ErrInvalidCategory
+	}
+	before, err := u.GetByID(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkParent(category.ID, category.ParentID); err != nil {
+		return nil, err
+	}
+	if category.Slug == "" {
+		category.Slug = before.Slug
+	}
+	if err := u.normalizeSEO(category); err != nil {
+		return nil, err
+	}
+	// An uploaded icon is changed and removed through ImageUsecase.
+	category.Image, category.ImageKeys = before.Image, before.ImageKeys
+	if len(before.ImageKeys) > 0 {
+		category.IconURL = before.IconURL
 	}
 	if err := u.categoryRepo.Update(category); err != nil {
 		return nil, err
 	}
-	return u.categoryRepo.FindByID(category.ID)
+	if err := u.slugs.changed(category.ID, before.Slug, category.Slug); err != nil {
+		return nil, err
+	}
+	updated, err := u.categoryRepo.FindByID(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityCategory, category.ID, before, updated)
+	return updated, nil
 }
 
-func (u *categoryUsecase) Delete(id uint) error {
+func (u *categoryUsecase) Delete(actor Actor, id uint) error {
 	category, err := u.categoryRepo.FindByID(id)
 	if err != nil {
 		return err
@@ -75,5 +202,121 @@ func (u *categoryUsecase) Delete(id uint) error {
 	if category == nil {
 		return gorm.ErrRecordNotFound
 	}
-	return u.categoryRepo.Delete(id)
+	if err := u.categoryRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityCategory, id, category, nil)
+	return nil
+}
+
+func (u *categoryUsecase) Move(actor Actor, id uint, parentID *uint) (*model.Category, error) {
+	before, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkParent(id, parentID); err != nil {
+		return nil, err
+	}
+	if err := u.categoryRepo.UpdateParent(id, parentID); err != nil {
+		return nil, err
+	}
+	moved, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityCategory, id, before, moved)
+	return moved, nil
+}
+
+func (u *categoryUsecase) GetPath(id uint) ([]model.CategoryBreadcrumb, error) {
+	categories, err := u.categoryRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.Category, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	category, ok := byID[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var path []model.CategoryBreadcrumb
+	seen := make(map[uint]bool)
+	for {
+		path = append(path, model.CategoryBreadcrumb{ID: category.ID, Name: category.Name})
+		seen[category.ID] = true
+		if category.ParentID == nil || seen[*category.ParentID] {
+			break
+		}
+		parent, ok := byID[*category.ParentID]
+		if !ok {
+			break
+		}
+		category = parent
+	}
+	slices.Reverse(path)
+	return path, nil
+}
+
+func (u *categoryUsecase) GetTree() ([]model.CategoryNode, error) {
+	categories, err := u.categoryRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := u.categoryRepo.CountProducts()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	exists := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		exists[category.ID] = true
+	}
+	var roots []model.Category
+	children := make(map[uint][]model.Category)
+	for _, category := range categories {
+		if category.ParentID == nil || !exists[*category.ParentID] {
+			roots = append(roots, category)
+		} else {
+			children[*category.ParentID] = append(children[*category.ParentID], category)
+		}
+	}
+
+	visited := make(map[uint]bool, len(categories))
+	var build func(category model.Category) model.CategoryNode
+	build = func(category model.Category) model.CategoryNode {
+		visited[category.ID] = true
+		node := model.CategoryNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			IconURL:      category.IconURL,
+			ParentID:     category.ParentID,
+			ProductCount: counts[category.ID],
+			Children:     []model.CategoryNode{},
+		}
+		node.TotalProductCount = node.ProductCount
+		for _, child := range children[category.ID] {
+			if visited[child.ID] {
+				continue
+			}
+			childNode := build(child)
+			node.TotalProductCount += childNode.TotalProductCount
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+	tree := []model.CategoryNode{}
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	// Categories whose parents form a cycle, saved before moves were
+	// checked, cannot be reached from the top. They are shown there rather
+	// than left out.
+	for _, category := range categories {
+		if !visited[category.ID] {
+			tree = append(tree, build(category))
+		}
+	}
+	return tree, nil
 }
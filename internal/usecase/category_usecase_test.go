@@ -39,6 +39,24 @@ func (m *MockCategoryRepository) FindAll() ([]model.Category, error) {
 	return args.Get(0).([]model.Category), args.Error(1)
 }
 
+func (m *MockCategoryRepository) FindBySlug(slug string) (*model.Category, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) SlugTaken(slug string, exceptID uint) (bool, error) {
+	args := m.Called(slug, exceptID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCategoryRepository) FindWithoutSlug() ([]model.Category, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
 func (m *MockCategoryRepository) FindWithFilters(filters map[string]string) ([]model.Category, error) {
 	args := m.Called(filters)
 	return args.Get(0).([]model.Category), args.Error(1)
@@ -59,6 +77,16 @@ func (m *MockCategoryRepository) Update(category *model.Category) error {
 	return args.Error(0)
 }
 
+func (m *MockCategoryRepository) UpdateParent(id uint, parentID *uint) error {
+	args := m.Called(id, parentID)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) CountProducts() (map[uint]int64, error) {
+	args := m.Called()
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
 func (m *MockCategoryRepository) Delete(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -66,15 +94,14 @@ func (m *MockCategoryRepository) Delete(id uint) error {
 
 func setupCategoryUsecase() (*categoryUsecase, *MockCategoryRepository) {
 	mockRepo := new(MockCategoryRepository)
-	uc := &categoryUsecase{
-		categoryRepo: mockRepo,
-	}
+	mockRepo.On("SlugTaken", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	uc := NewCategoryUsecase(mockRepo, newMemorySlugRedirects(), &recordingAuditor{}).(*categoryUsecase)
 	return uc, mockRepo
 }
 
 func TestNewCategoryUsecase(t *testing.T) {
 	mockRepo := new(MockCategoryRepository)
-	uc := NewCategoryUsecase(mockRepo)
+	uc := NewCategoryUsecase(mockRepo, newMemorySlugRedirects(), &recordingAuditor{})
 
 	// Assertion 201: NewCategoryUsecase should return a non-nil usecase instance
 	assert.NotNil(t, uc)
@@ -299,7 +326,7 @@ func TestCategoryUsecaseCreateSuccess(t *testing.T) {
 	})
 	mockRepo.On("FindByID", uint(1)).Return(createdCategory, nil)
 
-	result, err := uc.Create(newCategory)
+	result, err := uc.Create(testActor, newCategory)
 
 	// Assertion 236: Create should not return an error for valid category
 	assert.NoError(t, err)
@@ -318,7 +345,7 @@ func TestCategoryUsecaseCreateSuccess(t *testing.T) {
 func TestCategoryUsecaseCreateNilCategory(t *testing.T) {
 	uc, mockRepo := setupCategoryUsecase()
 
-	result, err := uc.Create(nil)
+	result, err := uc.Create(testActor, nil)
 
 	// Assertion 241: Create should return error for nil category
 	assert.Error(t, err)
@@ -335,7 +362,7 @@ func TestCategoryUsecaseCreateEmptyName(t *testing.T) {
 
 	emptyCategory := &model.Category{Name: ""}
 
-	result, err := uc.Create(emptyCategory)
+	result, err := uc.Create(testActor, emptyCategory)
 
 	// Assertion 244: Create should return error for empty name
 	assert.Error(t, err)
@@ -354,7 +381,7 @@ func TestCategoryUsecaseCreateRepositoryCreateError(t *testing.T) {
 
 	mockRepo.On("Create", newCategory).Return(errors.New("create failed"))
 
-	result, err := uc.Create(newCategory)
+	result, err := uc.Create(testActor, newCategory)
 
 	// Assertion 247: Create should return error when repository create fails
 	assert.Error(t, err)
@@ -378,7 +405,7 @@ func TestCategoryUsecaseCreateRepositoryFindError(t *testing.T) {
 	})
 	mockRepo.On("FindByID", uint(1)).Return(nil, errors.New(findFailedError))
 
-	result, err := uc.Create(newCategory)
+	result, err := uc.Create(testActor, newCategory)
 
 	// Assertion 250: Create should return error when repository find fails after create
 	assert.Error(t, err)
@@ -409,7 +436,7 @@ func TestCategoryUsecaseUpdateSuccess(t *testing.T) {
 	mockRepo.On("Update", updateCategory).Return(nil)
 	mockRepo.On("FindByID", uint(1)).Return(updatedCategory, nil)
 
-	result, err := uc.Update(updateCategory)
+	result, err := uc.Update(testActor, updateCategory)
 
 	// Assertion 253: Update should not return an error for valid category
 	assert.NoError(t, err)
@@ -428,7 +455,7 @@ func TestCategoryUsecaseUpdateSuccess(t *testing.T) {
 func TestCategoryUsecaseUpdateNilCategory(t *testing.T) {
 	uc, mockRepo := setupCategoryUsecase()
 
-	result, err := uc.Update(nil)
+	result, err := uc.Update(testActor, nil)
 
 	// Assertion 258: Update should return error for nil category
 	assert.Error(t, err)
@@ -445,7 +472,7 @@ func TestCategoryUsecaseUpdateZeroID(t *testing.T) {
 
 	zeroIDCategory := &model.Category{ID: 0, Name: "Test"}
 
-	result, err := uc.Update(zeroIDCategory)
+	result, err := uc.Update(testActor, zeroIDCategory)
 
 	// Assertion 261: Update should return error for zero ID
 	assert.Error(t, err)
@@ -462,9 +489,10 @@ func TestCategoryUsecaseUpdateRepositoryUpdateError(t *testing.T) {
 
 	updateCategory := &model.Category{ID: 1, Name: testCategoryName}
 
+	mockRepo.On("FindByID", uint(1)).Return(&model.Category{ID: 1, Name: "Old Name"}, nil)
 	mockRepo.On("Update", updateCategory).Return(errors.New("update failed"))
 
-	result, err := uc.Update(updateCategory)
+	result, err := uc.Update(testActor, updateCategory)
 
 	// Assertion 264: Update should return error when repository update fails
 	assert.Error(t, err)
@@ -474,7 +502,7 @@ func TestCategoryUsecaseUpdateRepositoryUpdateError(t *testing.T) {
 	assert.EqualError(t, err, "update failed")
 
 	mockRepo.AssertExpectations(t)
-	mockRepo.AssertNotCalled(t, "FindByID")
+	mockRepo.AssertNumberOfCalls(t, "FindByID", 1)
 }
 
 func TestCategoryUsecaseUpdateRepositoryFindError(t *testing.T) {
@@ -482,10 +510,11 @@ func TestCategoryUsecaseUpdateRepositoryFindError(t *testing.T) {
 
 	updateCategory := &model.Category{ID: 1, Name: testCategoryName}
 
+	mockRepo.On("FindByID", uint(1)).Return(&model.Category{ID: 1, Name: "Old Name"}, nil).Once()
 	mockRepo.On("Update", updateCategory).Return(nil)
 	mockRepo.On("FindByID", uint(1)).Return(nil, errors.New(findFailedError))
 
-	result, err := uc.Update(updateCategory)
+	result, err := uc.Update(testActor, updateCategory)
 
 	// Assertion 267: Update should return error when repository find fails after update
 	assert.Error(t, err)
@@ -508,7 +537,7 @@ func TestCategoryUsecaseDeleteSuccess(t *testing.T) {
 	mockRepo.On("FindByID", uint(1)).Return(existingCategory, nil)
 	mockRepo.On("Delete", uint(1)).Return(nil)
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 270: Delete should not return an error for valid category ID
 	assert.NoError(t, err)
@@ -521,7 +550,7 @@ func TestCategoryUsecaseDeleteCategoryNotFound(t *testing.T) {
 
 	mockRepo.On("FindByID", uint(999)).Return(nil, nil)
 
-	err := uc.Delete(999)
+	err := uc.Delete(testActor, 999)
 
 	// Assertion 271: Delete should return gorm.ErrRecordNotFound for non-existent category
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
@@ -535,7 +564,7 @@ func TestCategoryUsecaseDeleteRepositoryFindError(t *testing.T) {
 
 	mockRepo.On("FindByID", uint(1)).Return(nil, errors.New("find error"))
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 272: Delete should return error when repository find fails
 	assert.Error(t, err)
@@ -554,7 +583,7 @@ func TestCategoryUsecaseDeleteRepositoryDeleteError(t *testing.T) {
 	mockRepo.On("FindByID", uint(1)).Return(existingCategory, nil)
 	mockRepo.On("Delete", uint(1)).Return(errors.New("delete failed"))
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 274: Delete should return error when repository delete fails
 	assert.Error(t, err)
@@ -591,6 +620,7 @@ func TestCategoryUsecaseIntegrationCreate(t *testing.T) {
 	newCategory, createdCategory := createIntegrationTestCategory()
 
 	// Mock create flow
+	mockRepo.On("FindAll").Return([]model.Category{{ID: 1, Name: "Electronics"}}, nil)
 	mockRepo.On("Create", newCategory).Return(nil).Run(func(args mock.Arguments) {
 		cat := args.Get(0).(*model.Category)
 		cat.ID = 2
@@ -598,7 +628,7 @@ func TestCategoryUsecaseIntegrationCreate(t *testing.T) {
 	mockRepo.On("FindByID", uint(2)).Return(createdCategory, nil).Once()
 
 	// Create the category
-	created, err := uc.Create(newCategory)
+	created, err := uc.Create(testActor, newCategory)
 
 	// Assertion 276: Integration test should successfully create category
 	assert.NoError(t, err)
@@ -627,11 +657,13 @@ func TestCategoryUsecaseIntegrationUpdate(t *testing.T) {
 		ParentID: &parentID,
 	}
 
+	mockRepo.On("FindByID", uint(2)).Return(&model.Category{ID: 2, Name: "Before Update"}, nil).Once()
+	mockRepo.On("FindAll").Return([]model.Category{{ID: 1, Name: "Electronics"}, {ID: 2, Name: "Before Update"}}, nil)
 	mockRepo.On("Update", updateCategory).Return(nil)
 	mockRepo.On("FindByID", uint(2)).Return(updatedCategory, nil).Once()
 
 	// Update the category
-	updated, err := uc.Update(updateCategory)
+	updated, err := uc.Update(testActor, updateCategory)
 
 	// Assertion 278: Integration test should successfully update category
 	assert.NoError(t, err)
@@ -726,7 +758,7 @@ func TestCategoryUsecaseIntegrationDelete(t *testing.T) {
 	mockRepo.On("FindByID", uint(2)).Return(updatedCategory, nil).Once()
 	mockRepo.On("Delete", uint(2)).Return(nil)
 
-	err := uc.Delete(2)
+	err := uc.Delete(testActor, 2)
 
 	// Assertion 289: Integration test should successfully delete category
 	assert.NoError(t, err)
@@ -743,3 +775,97 @@ func TestCategoryUsecaseIntegrationDelete(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+// categoryTree is Home (1) with Lamps (2) and Lamps > Desk (3), and Books (4).
+func categoryTree() []model.Category {
+	return []model.Category{
+		{ID: 1, Name: "Home"},
+		{ID: 2, Name: "Lamps", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Desk", ParentID: uintPtr(2)},
+		{ID: 4, Name: "Books"},
+	}
+}
+
+func TestCategoryUsecaseGetTree(t *testing.T) {
+	uc, mockRepo := setupCategoryUsecase()
+	categories := append(categoryTree(),
+		model.Category{ID: 5, Name: "Loop A", ParentID: uintPtr(6)},
+		model.Category{ID: 6, Name: "Loop B", ParentID: uintPtr(5)},
+	)
+	mockRepo.On("FindAll").Return(categories, nil)
+	mockRepo.On("CountProducts").Return(map[uint]int64{1: 1, 2: 2, 3: 4, 4: 3}, nil)
+
+	tree, err := uc.GetTree()
+	// Assertion 691: GetTree should nest the categories under the top-level ones, sorted by name
+	assert.NoError(t, err)
+	assert.Equal(t, "Books", tree[0].Name)
+	assert.Equal(t, "Home", tree[1].Name)
+	assert.Equal(t, "Lamps", tree[1].Children[0].Name)
+	assert.Equal(t, "Desk", tree[1].Children[0].Children[0].Name)
+	assert.Empty(t, tree[0].Children)
+
+	// Assertion 692: Product counts should include those of all descendants
+	assert.Equal(t, int64(1), tree[1].ProductCount)
+	assert.Equal(t, int64(7), tree[1].TotalProductCount)
+	assert.Equal(t, int64(6), tree[1].Children[0].TotalProductCount)
+	assert.Equal(t, int64(3), tree[0].TotalProductCount)
+
+	// Assertion 693: Categories caught in a cycle should still be listed once
+	assert.Len(t, tree, 3)
+	assert.Equal(t, "Loop A", tree[2].Name)
+	assert.Equal(t, "Loop B", tree[2].Children[0].Name)
+	assert.Empty(t, tree[2].Children[0].Children)
+}
+
+func TestCategoryUsecaseGetPath(t *testing.T) {
+	uc, mockRepo := setupCategoryUsecase()
+	mockRepo.On("FindAll").Return(categoryTree(), nil)
+
+	path, err := uc.GetPath(3)
+	// Assertion 694: GetPath should return the breadcrumbs from the top down
+	assert.NoError(t, err)
+	assert.Equal(t, []model.CategoryBreadcrumb{{ID: 1, Name: "Home"}, {ID: 2, Name: "Lamps"}, {ID: 3, Name: "Desk"}}, path)
+
+	path, _ = uc.GetPath(4)
+	// Assertion 695: A top-level category's path should be the category alone
+	assert.Equal(t, []model.CategoryBreadcrumb{{ID: 4, Name: "Books"}}, path)
+
+	_, err = uc.GetPath(9)
+	// Assertion 696: GetPath should fail with ErrRecordNotFound for unknown categories
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCategoryUsecaseMoveRejectsCycles(t *testing.T) {
+	uc, mockRepo := setupCategoryUsecase()
+	mockRepo.On("FindAll").Return(categoryTree(), nil)
+	mockRepo.On("FindByID", uint(1)).Return(&model.Category{ID: 1, Name: "Home"}, nil)
+
+	_, err := uc.Move(testActor, 1, uintPtr(3))
+	// Assertion 697: Moving a category under its descendant or itself should fail with ErrCategoryCycle
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = uc.Move(testActor, 1, uintPtr(1))
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+
+	_, err = uc.Move(testActor, 1, uintPtr(9))
+	// Assertion 698: Moving under an unknown category should fail with ErrInvalidCategoryParent
+	assert.ErrorIs(t, err, ErrInvalidCategoryParent)
+
+	_, err = uc.Update(testActor, &model.Category{ID: 1, Name: "Home", ParentID: uintPtr(2)})
+	// Assertion 699: Update should reject cycles too
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	mockRepo.AssertNotCalled(t, "UpdateParent", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+
+	mockRepo.On("UpdateParent", uint(1), uintPtr(4)).Return(nil)
+	moved, err := uc.Move(testActor, 1, uintPtr(4))
+	// Assertion 700: Moving under another branch should save the new parent
+	assert.NoError(t, err)
+	assert.NotNil(t, moved)
+	mockRepo.AssertCalled(t, "UpdateParent", uint(1), uintPtr(4))
+
+	mockRepo.On("UpdateParent", uint(1), (*uint)(nil)).Return(nil)
+	_, err = uc.Move(testActor, 1, nil)
+	// Assertion 701: Moving to the top should clear the parent
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "UpdateParent", uint(1), (*uint)(nil))
+}
@@ -0,0 +1,484 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Error message constants
+const (
+	errCurrencyCode        = "currency must be a three-letter ISO 4217 code"
+	errExchangeRateValue   = "rate must be a number greater than 0"
+	errExchangeRateBase    = "%s is the base currency, its rate is always 1"
+	errExchangeRateLine    = "line %d: %w"
+	errExchangeRateColumns = "expected two columns, currency and rate"
+	errExchangeRatesEmpty  = "no rates to import"
+	errCurrencyInUse       = "%[3]s still prices %[2]d product(s)"
+)
+
+var (
+	ErrUnsupportedCurrency = NewError(KindValidation, "unsupported_currency", "unsupported currency")
+	ErrInvalidExchangeRate = NewError(KindValidation, "invalid_exchange_rate", "invalid exchange rate")
+	ErrExchangeRateExists  = NewError(KindConflict, "exchange_rate_exists", "an exchange rate for this currency already exists")
+	ErrCurrencyInUse       = NewError(KindConflict, "currency_in_use", "currency is in use")
+)
+
+// JobImportExchangeRates is the name of the job importing CurrencyConfig.RatesFile.
+const JobImportExchangeRates = "import-exchange-rates"
+
+// CurrencyConfig holds the store's currency settings.
+type CurrencyConfig struct {
+	// BaseCurrency is the currency the store keeps its books in. Carts,
+	// shipping rates and exchange rates are in it.
+	BaseCurrency string
+	// RatesFile is a CSV file of currency,rate lines that the
+	// import-exchange-rates job loads every day. Empty disables the job.
+	RatesFile string
+}
+
+func DefaultCurrencyConfig() CurrencyConfig {
+	return CurrencyConfig{BaseCurrency: "USD"}
+}
+
+// ExchangeRateInput describes the rate of a currency against the base currency.
+type ExchangeRateInput struct {
+	Currency string
+	Rate     float64
+}
+
+// PriceConverter converts prices between the base currency and the
+// currencies that have an exchange rate. An empty currency stands for the
+// base currency.
+type PriceConverter interface {
+	BaseCurrency() string
+	// Rate returns how many units of currency one unit of the base currency
+	// buys, 1 for the base currency. Currencies without a rate fail with
+	// ErrUnsupportedCurrency.
+	Rate(currency string) (float64, error)
+	// Convert converts amount from one currency to another, rounded to cents.
+	Convert(amount float64, from, to string) (float64, error)
+}
+
+type CurrencyUsecase interface {
+	PriceConverter
+	// Resolve picks the currency to show prices in: requested if given, else
+	// the user's preference. It returns "" when neither is set, and fails
+	// with ErrUnsupportedCurrency when the requested currency has no rate.
+	Resolve(requested string, userID uint) (string, error)
+	// SetPreference stores the user's preferred currency; "" clears it.
+	SetPreference(actor Actor, userID uint, currency string) (*model.User, error)
+	// ConvertProducts shows the products' prices in currency. An empty
+	// currency leaves them in the currencies they are priced in.
+	ConvertProducts(products []model.Product, currency string) error
+	// ConvertCart shows the cart in currency, the base currency if empty.
+	// Unit prices are converted and the subtotals and total worked out from
+	// them, the way an order in that currency would be charged.
+	ConvertCart(cart *model.Cart, currency string) error
+
+	GetRates() ([]model.ExchangeRate, error)
+	GetRate(id uint) (*model.ExchangeRate, error)
+	CreateRate(actor Actor, input ExchangeRateInput) (*model.ExchangeRate, error)
+	UpdateRate(actor Actor, id uint, input ExchangeRateInput) (*model.ExchangeRate, error)
+	// DeleteRate fails with ErrCurrencyInUse while products are priced in the currency.
+	DeleteRate(actor Actor, id uint) error
+	// ImportRates sets the rates listed in CSV data, one currency,rate pair
+	// per line with an optional header, creating currencies not seen
+	// before. Either every rate is imported or, on any invalid line, none.
+	ImportRates(actor Actor, r io.Reader) ([]model.ExchangeRate, error)
+	// Jobs returns the import of the rates file as a scheduler job, if set.
+	Jobs() []Job
+}
+
+type currencyUsecase struct {
+	rateRepo repository.ExchangeRateRepository
+	userRepo repository.UserRepository
+	auditor  Auditor
+	config   CurrencyConfig
+}
+
+func NewCurrencyUsecase(
+	rateRepo repository.ExchangeRateRepository,
+	userRepo repository.UserRepository,
+	auditor Auditor,
+	config CurrencyConfig,
+) CurrencyUsecase {
+	config.BaseCurrency = strings.ToUpper(config.BaseCurrency)
+	return &currencyUsecase{rateRepo: rateRepo, userRepo: userRepo, auditor: auditor, config: config}
+}
+
+// NormalizeCurrency upper-cases a currency code and fails with
+// ErrUnsupportedCurrency unless it is three letters. An empty code stays empty.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", nil
+	}
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, errCurrencyCode)
+	}
+	return code, nil
+}
+
+func (u *currencyUsecase) BaseCurrency() string {
+	return u.config.BaseCurrency
+}
+
+func (u *currencyUsecase) Rate(currency string) (float64, error) {
+	code, err := NormalizeCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	if code == "" || code == u.config.BaseCurrency {
+		return 1, nil
+	}
+	rate, err := u.rateRepo.FindByCurrency(code)
+	if err != nil {
+		return 0, err
+	}
+	if rate == nil {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	return rate.Rate, nil
+}
+
+func (u *currencyUsecase) Convert(amount float64, from, to string) (float64, error) {
+	return u.converter()(amount, from, to)
+}
+
+// converter returns a Convert that looks each rate up only once, for
+// converting many prices.
+func (u *currencyUsecase) converter() func(amount float64, from, to string) (float64, error) {
+	rates := map[string]float64{}
+	rate := func(currency string) (float64, error) {
+		if r, ok := rates[currency]; ok {
+			return r, nil
+		}
+		r, err := u.Rate(currency)
+		if err != nil {
+			return 0, err
+		}
+		rates[currency] = r
+		return r, nil
+	}
+	return func(amount float64, from, to string) (float64, error) {
+		fromRate, err := rate(from)
+		if err != nil {
+			return 0, err
+		}
+		toRate, err := rate(to)
+		if err != nil {
+			return 0, err
+		}
+		return model.ConvertAmount(amount, fromRate, toRate), nil
+	}
+}
+
+func (u *currencyUsecase) Resolve(requested string, userID uint) (string, error) {
+	code, err := NormalizeCurrency(requested)
+	if err != nil {
+		return "", err
+	}
+	if code != "" {
+		if _, err := u.Rate(code); err != nil {
+			return "", err
+		}
+		return code, nil
+	}
+	if userID == 0 {
+		return "", nil
+	}
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil || user == nil || user.Currency == "" {
+		return "", err
+	}
+	// A preference whose rate has since been removed falls back to the default.
+	if _, err := u.Rate(user.Currency); errors.Is(err, ErrUnsupportedCurrency) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return user.Currency, nil
+}
+
+func (u *currencyUsecase) SetPreference(actor Actor, userID uint, currency string) (*model.User, error) {
+	code, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.Rate(code); err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	before := *user
+	user.Currency = code
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityUser, user.ID, &before, user)
+	return user, nil
+}
+
+func (u *currencyUsecase) ConvertProducts(products []model.Product, currency string) error {
+	if currency == "" {
+		return nil
+	}
+	convert := u.converter()
+	for i := range products {
+		if err := convertProduct(convert, &products[i], currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func convertProduct(convert func(float64, string, string) (float64, error), product *model.Product, currency string) error {
+	price, err := convert(product.Price, product.Currency, currency)
+	if err != nil {
+		return err
+	}
+	if product.CompareAtPrice != nil {
+		compareAt, err := convert(*product.CompareAtPrice, product.Currency, currency)
+		if err != nil {
+			return err
+		}
+		product.CompareAtPrice = &compareAt
+	}
+	product.Price, product.Currency = price, currency
+	return nil
+}
+
+func (u *currencyUsecase) ConvertCart(cart *model.Cart, currency string) error {
+	if currency == "" {
+		currency = u.config.BaseCurrency
+	}
+	convert := u.converter()
+	total := 0.0
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		price, err := convert(item.UnitPrice, u.config.BaseCurrency, currency)
+		if err != nil {
+			return err
+		}
+		item.UnitPrice = price
+		item.Subtotal = model.RoundMoney(price * float64(item.Quantity))
+		total += item.Subtotal
+		if item.Product.ID != 0 {
+			if err := convertProduct(convert, &item.Product, currency); err != nil {
+				return err
+			}
+		}
+	}
+	cart.Total = model.RoundMoney(total)
+	cart.Currency = currency
+	return nil
+}
+
+func (u *currencyUsecase) GetRates() ([]model.ExchangeRate, error) {
+	return u.rateRepo.FindAll()
+}
+
+func (u *currencyUsecase) GetRate(id uint) (*model.ExchangeRate, error) {
+	rate, err := u.rateRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return rate, nil
+}
+
+func (u *currencyUsecase) CreateRate(actor Actor, input ExchangeRateInput) (*model.ExchangeRate, error) {
+	rate := &model.ExchangeRate{}
+	if err := u.applyExchangeRateInput(rate, input); err != nil {
+		return nil, err
+	}
+	if err := u.rateRepo.Create(rate); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityExchangeRate, rate.ID, nil, rate)
+	return rate, nil
+}
+
+func (u *currencyUsecase) UpdateRate(actor Actor, id uint, input ExchangeRateInput) (*model.ExchangeRate, error) {
+	rate, err := u.GetRate(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *rate
+	if err := u.applyExchangeRateInput(rate, input); err != nil {
+		return nil, err
+	}
+	if rate.Currency != before.Currency {
+		if err := u.checkUnused(before.Currency); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.rateRepo.Update(rate); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityExchangeRate, rate.ID, &before, rate)
+	return rate, nil
+}
+
+func (u *currencyUsecase) DeleteRate(actor Actor, id uint) error {
+	rate, err := u.GetRate(id)
+	if err != nil {
+		return err
+	}
+	if err := u.checkUnused(rate.Currency); err != nil {
+		return err
+	}
+	if err := u.rateRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityExchangeRate, id, rate, nil)
+	return nil
+}
+
+// checkUnused fails with ErrCurrencyInUse if products are priced in
+// currency, as they could no longer be converted without its rate.
+func (u *currencyUsecase) checkUnused(currency string) error {
+	count, err := u.rateRepo.CountProducts(currency)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: "+errCurrencyInUse, ErrCurrencyInUse, count, currency)
+	}
+	return nil
+}
+
+func (u *currencyUsecase) applyExchangeRateInput(rate *model.ExchangeRate, input ExchangeRateInput) error {
+	if err := u.validateRate(&input); err != nil {
+		return err
+	}
+	existing, err := u.rateRepo.FindByCurrency(input.Currency)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != rate.ID {
+		return ErrExchangeRateExists
+	}
+	rate.Currency = input.Currency
+	rate.Rate = input.Rate
+	return nil
+}
+
+// validateRate normalizes the input's currency and checks it can have a rate.
+func (u *currencyUsecase) validateRate(input *ExchangeRateInput) error {
+	code, err := NormalizeCurrency(input.Currency)
+	if err != nil || code == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidExchangeRate, errCurrencyCode)
+	}
+	if code == u.config.BaseCurrency {
+		return fmt.Errorf("%w: "+errExchangeRateBase, ErrInvalidExchangeRate, code)
+	}
+	if !(input.Rate > 0) || math.IsInf(input.Rate, 0) {
+		return fmt.Errorf("%w: %s", ErrInvalidExchangeRate, errExchangeRateValue)
+	}
+	input.Currency = code
+	return nil
+}
+
+func (u *currencyUsecase) ImportRates(actor Actor, r io.Reader) ([]model.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rates []model.ExchangeRate
+	index := map[string]int{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+		if len(record) != 2 {
+			return nil, fmt.Errorf(errExchangeRateLine, line, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, errExchangeRateColumns))
+		}
+		// A rate that does not parse comes back as 0 and is refused below.
+		input := ExchangeRateInput{Currency: record[0]}
+		input.Rate, _ = strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err := u.validateRate(&input); err != nil {
+			return nil, fmt.Errorf(errExchangeRateLine, line, err)
+		}
+		// A currency listed twice takes its last rate.
+		if i, ok := index[input.Currency]; ok {
+			rates[i].Rate = input.Rate
+			continue
+		}
+		index[input.Currency] = len(rates)
+		rates = append(rates, model.ExchangeRate{Currency: input.Currency, Rate: input.Rate})
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, errExchangeRatesEmpty)
+	}
+
+	current, err := u.rateRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	before := make(map[string]model.ExchangeRate, len(current))
+	for _, rate := range current {
+		before[rate.Currency] = rate
+	}
+	if err := u.rateRepo.Upsert(rates); err != nil {
+		return nil, err
+	}
+	for i := range rates {
+		old, ok := before[rates[i].Currency]
+		switch {
+		case !ok:
+			u.auditor.Record(actor, model.AuditCreate, model.AuditEntityExchangeRate, rates[i].ID, nil, &rates[i])
+		case old.Rate != rates[i].Rate:
+			u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityExchangeRate, rates[i].ID, &old, &rates[i])
+		}
+	}
+	return rates, nil
+}
+
+func (u *currencyUsecase) Jobs() []Job {
+	if u.config.RatesFile == "" {
+		return nil
+	}
+	return []Job{
+		{
+			Name:        JobImportExchangeRates,
+			Description: "Import exchange rates from " + u.config.RatesFile,
+			Schedule:    "30 6 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				file, err := os.Open(u.config.RatesFile)
+				if err != nil {
+					return "", err
+				}
+				defer file.Close()
+				rates, err := u.ImportRates(systemActor(JobImportExchangeRates), file)
+				return fmt.Sprintf("imported %d rates", len(rates)), err
+			},
+		},
+	}
+}
@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type memoryExchangeRates struct {
+	rates    []model.ExchangeRate
+	products map[string]int64
+}
+
+func (r *memoryExchangeRates) FindByID(id uint) (*model.ExchangeRate, error) {
+	for i := range r.rates {
+		if r.rates[i].ID == id {
+			rate := r.rates[i]
+			return &rate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryExchangeRates) FindAll() ([]model.ExchangeRate, error) {
+	return r.rates, nil
+}
+
+func (r *memoryExchangeRates) FindByCurrency(currency string) (*model.ExchangeRate, error) {
+	for i := range r.rates {
+		if r.rates[i].Currency == currency {
+			rate := r.rates[i]
+			return &rate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryExchangeRates) Create(rate *model.ExchangeRate) error {
+	rate.ID = uint(len(r.rates) + 1)
+	r.rates = append(r.rates, *rate)
+	return nil
+}
+
+func (r *memoryExchangeRates) Update(rate *model.ExchangeRate) error {
+	for i := range r.rates {
+		if r.rates[i].ID == rate.ID {
+			r.rates[i] = *rate
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryExchangeRates) Delete(id uint) error {
+	for i := range r.rates {
+		if r.rates[i].ID == id {
+			r.rates = append(r.rates[:i], r.rates[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryExchangeRates) Upsert(rates []model.ExchangeRate) error {
+	for i := range rates {
+		existing, _ := r.FindByCurrency(rates[i].Currency)
+		if existing == nil {
+			if err := r.Create(&rates[i]); err != nil {
+				return err
+			}
+			continue
+		}
+		rates[i].ID = existing.ID
+		if err := r.Update(&rates[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryExchangeRates) CountProducts(currency string) (int64, error) {
+	return r.products[currency], nil
+}
+
+func newTestCurrency() *currencyUsecase {
+	return &currencyUsecase{rateRepo: &memoryExchangeRates{}, auditor: &recordingAuditor{}, config: DefaultCurrencyConfig()}
+}
+
+// setupCurrencyUsecase sets up a USD store that also sells in EUR and PLN.
+func setupCurrencyUsecase(t *testing.T) *currencyUsecase {
+	uc := newTestCurrency()
+	_, err := uc.CreateRate(testActor, ExchangeRateInput{Currency: "eur", Rate: 0.92})
+	assert.NoError(t, err)
+	_, err = uc.CreateRate(testActor, ExchangeRateInput{Currency: "PLN", Rate: 4})
+	assert.NoError(t, err)
+	return uc
+}
+
+func TestCurrencyUsecaseValidatesRates(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+
+	_, err := uc.CreateRate(testActor, ExchangeRateInput{Currency: "USD", Rate: 1})
+	// Assertion 610: CreateRate should refuse a rate for the base currency
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+
+	_, err = uc.CreateRate(testActor, ExchangeRateInput{Currency: "EURO", Rate: 0.9})
+	// Assertion 611: CreateRate should refuse codes that are not three letters
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+
+	_, err = uc.CreateRate(testActor, ExchangeRateInput{Currency: "GBP", Rate: 0})
+	// Assertion 612: CreateRate should refuse rates that are not positive
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+
+	_, err = uc.CreateRate(testActor, ExchangeRateInput{Currency: "EUR", Rate: 0.93})
+	// Assertion 613: CreateRate should refuse a second rate for a currency
+	assert.ErrorIs(t, err, ErrExchangeRateExists)
+
+	rates, _ := uc.GetRates()
+	// Assertion 614: CreateRate should store currency codes upper-cased
+	assert.Equal(t, "EUR", rates[0].Currency)
+}
+
+func TestCurrencyUsecaseConvert(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+
+	price, err := uc.Convert(19.99, "", "EUR")
+	// Assertion 615: Convert should convert from the base currency and round to cents
+	assert.NoError(t, err)
+	assert.Equal(t, 18.39, price)
+
+	price, err = uc.Convert(200, "PLN", "EUR")
+	// Assertion 616: Convert should convert between two foreign currencies through the base currency
+	assert.NoError(t, err)
+	assert.Equal(t, 46.0, price)
+
+	_, err = uc.Convert(10, "USD", "GBP")
+	// Assertion 617: Convert should fail for currencies without a rate
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestCurrencyUsecaseConvertCart(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+	cart := &model.Cart{Items: []model.CartItem{
+		{ProductID: 1, Quantity: 3, UnitPrice: 19.99, Subtotal: 59.97, Product: model.Product{ID: 1, Price: 19.99, Currency: "USD"}},
+		{ProductID: 2, Quantity: 1, UnitPrice: 50, Subtotal: 50, Product: model.Product{ID: 2, Price: 200, Currency: "PLN"}},
+	}, Total: 109.97}
+
+	err := uc.ConvertCart(cart, "EUR")
+	// Assertion 618: ConvertCart should work subtotals and the total out from the converted unit prices
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{18.39, 46}, []float64{cart.Items[0].UnitPrice, cart.Items[1].UnitPrice})
+	assert.Equal(t, 55.17, cart.Items[0].Subtotal)
+	assert.Equal(t, 101.17, cart.Total)
+	assert.Equal(t, "EUR", cart.Currency)
+	assert.Equal(t, model.Product{ID: 2, Price: 46, Currency: "EUR"}, cart.Items[1].Product)
+
+	cart = &model.Cart{Total: 0}
+	// Assertion 619: ConvertCart should label carts with the base currency by default
+	assert.NoError(t, uc.ConvertCart(cart, ""))
+	assert.Equal(t, "USD", cart.Currency)
+}
+
+func TestCurrencyUsecaseResolve(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+	userRepo := new(MockUserRepository)
+	uc.userRepo = userRepo
+	userRepo.On("FindByID", uint(1)).Return(&model.User{ID: 1, Currency: "PLN"}, nil)
+	userRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Currency: "GBP"}, nil)
+
+	currency, err := uc.Resolve("eur", 1)
+	// Assertion 620: Resolve should prefer the requested currency
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", currency)
+
+	currency, err = uc.Resolve("", 1)
+	// Assertion 621: Resolve should fall back to the user's preference
+	assert.NoError(t, err)
+	assert.Equal(t, "PLN", currency)
+
+	currency, err = uc.Resolve("", 2)
+	// Assertion 622: Resolve should ignore a preference whose rate has been removed
+	assert.NoError(t, err)
+	assert.Equal(t, "", currency)
+
+	_, err = uc.Resolve("GBP", 0)
+	// Assertion 623: Resolve should refuse a requested currency without a rate
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestCurrencyUsecaseSetPreference(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+	userRepo := new(MockUserRepository)
+	uc.userRepo = userRepo
+	userRepo.On("FindByID", uint(1)).Return(&model.User{ID: 1}, nil)
+	userRepo.On("Update", mock.AnythingOfType("*model.User")).Return(nil)
+
+	_, err := uc.SetPreference(testActor, 1, "GBP")
+	// Assertion 624: SetPreference should refuse currencies without a rate
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	user, err := uc.SetPreference(testActor, 1, "eur")
+	// Assertion 625: SetPreference should store the normalized code
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", user.Currency)
+}
+
+func TestCurrencyUsecaseDeleteRateInUse(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+	uc.rateRepo.(*memoryExchangeRates).products = map[string]int64{"PLN": 2}
+
+	err := uc.DeleteRate(testActor, 2)
+	// Assertion 626: DeleteRate should refuse while products are priced in the currency
+	assert.ErrorIs(t, err, ErrCurrencyInUse)
+
+	_, err = uc.UpdateRate(testActor, 2, ExchangeRateInput{Currency: "CZK", Rate: 23})
+	// Assertion 627: UpdateRate should refuse to rename a currency products are priced in
+	assert.ErrorIs(t, err, ErrCurrencyInUse)
+
+	// Assertion 628: DeleteRate should remove unused currencies
+	assert.NoError(t, uc.DeleteRate(testActor, 1))
+	_, err = uc.Rate("EUR")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestCurrencyUsecaseImportRates(t *testing.T) {
+	uc := setupCurrencyUsecase(t)
+	auditor := &recordingAuditor{}
+	uc.auditor = auditor
+
+	rates, err := uc.ImportRates(testActor, strings.NewReader("currency,rate\neur,0.93\nGBP, 0.79\nPLN,4\nGBP,0.78\n"))
+	// Assertion 629: ImportRates should skip the header and let a repeated currency take its last rate
+	assert.NoError(t, err)
+	assert.Len(t, rates, 3)
+	rate, _ := uc.Rate("GBP")
+	assert.Equal(t, 0.78, rate)
+	rate, _ = uc.Rate("EUR")
+	assert.Equal(t, 0.93, rate)
+
+	// Assertion 630: ImportRates should audit only the rates it created or changed
+	assert.Len(t, auditor.records, 2)
+
+	_, err = uc.ImportRates(testActor, strings.NewReader("EUR,0.95\nCHF,abc\n"))
+	// Assertion 631: ImportRates should name the invalid line
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+	assert.Contains(t, err.Error(), "line 2")
+
+	rate, _ = uc.Rate("EUR")
+	// Assertion 632: ImportRates should import nothing when a line is invalid
+	assert.Equal(t, 0.93, rate)
+
+	_, err = uc.ImportRates(testActor, strings.NewReader("currency,rate\n"))
+	// Assertion 633: ImportRates should refuse a file without rates
+	assert.ErrorIs(t, err, ErrInvalidExchangeRate)
+}
+
+func TestOrderUsecaseCreateFromCartLocksCurrency(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	uc.shipping, _ = setupShippingUsecase(t)
+	uc.prices = setupCurrencyUsecase(t)
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{
+		{ID: 1, CartID: 1, ProductID: 1, Quantity: 2},
+		{ID: 2, CartID: 1, ProductID: 2, Quantity: 1},
+	}}
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1, Country: "Poland", Postcode: "00-950"}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(&model.Product{ID: 1, Name: testProduct1Name, Price: 50, Currency: "USD", Stock: 10, Weight: 2}, nil)
+	mockProductRepo.On("FindByID", uint(2)).Return(&model.Product{ID: 2, Name: testProduct2Name, Price: 200, Currency: "PLN", Stock: 10, Weight: 2}, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	_, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 1, "GBP", "")
+	// Assertion 634: CreateFromCart should refuse currencies without a rate
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	order, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 1, "eur", "")
+	// Assertion 635: CreateFromCart should lock the currency and its rate into the order
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", order.Currency)
+	assert.Equal(t, 0.92, order.ExchangeRate)
+
+	// Assertion 636: CreateFromCart should convert prices from each product's currency and shipping from the base currency
+	assert.Equal(t, []float64{46, 46}, []float64{order.Items[0].UnitPrice, order.Items[1].UnitPrice})
+	assert.Equal(t, 13.8, order.ShippingCost)
+	assert.Equal(t, 151.8, order.Total)
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrorKind tells what went wrong with a request in terms a transport can
+// answer with, such as an HTTP status.
+type ErrorKind string
+
+const (
+	KindNotFound          ErrorKind = "not_found"
+	KindConflict          ErrorKind = "conflict"
+	KindValidation        ErrorKind = "validation"
+	KindUnauthorized      ErrorKind = "unauthorized"
+	KindForbidden         ErrorKind = "forbidden"
+	KindInsufficientStock ErrorKind = "insufficient_stock"
+	KindTooLarge          ErrorKind = "too_large"
+)
+
+// Error is a failure the caller caused and can act on. Code identifies it
+// for clients and message catalogs and never changes; Message is the
+// English text. Errors are declared once and wrapped with details, as in
+// fmt.Errorf("%w: %s", ErrInvalidTaxRate, reason).
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Fields lists the input fields that caused a validation error.
+	Fields []FieldError
+	// base is the error WithFields was called on, which errors.Is still
+	// matches.
+	base *Error
+}
+
+// FieldError names an invalid input field and what is wrong with it, as a
+// code such as "required".
+type FieldError struct {
+	Field string
+	Code  string
+}
+
+// Codes of FieldError.
+const (
+	FieldRequired = "required"
+	FieldInvalid  = "invalid"
+)
+
+// NewError declares an error. fields are the input fields that always
+// cause it; WithFields adds them where they depend on the input.
+func NewError(kind ErrorKind, code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: kind, Code: code, Message: message, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return e.base != nil && errors.Is(e.base, target)
+}
+
+// WithFields returns e with the fields that caused it.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	return &Error{Kind: e.Kind, Code: e.Code, Message: e.Message, Fields: fields, base: e}
+}
+
+var (
+	ErrNotFound  = NewError(KindNotFound, "not_found", "record not found")
+	ErrForbidden = NewError(KindForbidden, "forbidden", "access denied")
+)
+
+// AsError returns the Error in err's chain. Records the repositories could
+// not find count as ErrNotFound. Anything else is not the caller's fault.
+func AsError(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound, true
+	}
+	return nil, false
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestAsErrorFindsWrappedErrors(t *testing.T) {
+	err := fmt.Errorf("failed to save: %w", fmt.Errorf("%w: rate must be positive", ErrInvalidTaxRate))
+
+	domainErr, ok := AsError(err)
+	// Assertion 740: AsError should find the declared error under any wrapping
+	assert.True(t, ok)
+	assert.Same(t, ErrInvalidTaxRate, domainErr)
+	assert.Equal(t, KindValidation, domainErr.Kind)
+	assert.Equal(t, "invalid_tax_rate", domainErr.Code)
+
+	// Assertion 741: Wrapping should keep the English message and the detail
+	assert.EqualError(t, err, "failed to save: invalid tax rate: rate must be positive")
+
+	domainErr, ok = AsError(fmt.Errorf("failed to get address: %w", gorm.ErrRecordNotFound))
+	// Assertion 742: Missing records should count as ErrNotFound
+	assert.True(t, ok)
+	assert.Same(t, ErrNotFound, domainErr)
+
+	_, ok = AsError(errors.New("disk full"))
+	// Assertion 743: Other errors should not be the caller's fault
+	assert.False(t, ok)
+}
+
+func TestErrorWithFields(t *testing.T) {
+	err := ErrInvalidGuestCheckout.WithFields(FieldError{Field: "email", Code: FieldRequired})
+
+	// Assertion 744: An error with fields should still match the declared error
+	assert.ErrorIs(t, err, ErrInvalidGuestCheckout)
+	assert.NotErrorIs(t, err, ErrInvalidShipping)
+	assert.Equal(t, ErrInvalidGuestCheckout.Code, err.Code)
+
+	// Assertion 745: Adding fields should not change the declared error
+	assert.Equal(t, []FieldError{{Field: "email", Code: FieldRequired}}, err.Fields)
+	assert.Empty(t, ErrInvalidGuestCheckout.Fields)
+}
+
+func TestGuestCheckoutFieldErrors(t *testing.T) {
+	_, err := normalizeGuestCheckout(&GuestCheckout{
+		Email:           "buyer",
+		Name:            "Ann",
+		PaymentMethod:   model.PaymentCard,
+		ShippingAddress: model.Address{Country: "PL", City: " ", Postcode: "00-950", Street: "Main"},
+	})
+
+	domainErr, ok := AsError(err)
+	// Assertion 746: Guest checkout should name each field that is wrong
+	assert.True(t, ok)
+	assert.Equal(t, []FieldError{
+		{Field: "email", Code: FieldInvalid},
+		{Field: "shipping_address.city", Code: FieldRequired},
+	}, domainErr.Fields)
+}
+
+func TestInsufficientStockError(t *testing.T) {
+	err := fmt.Errorf(errNotEnoughStock, ErrInsufficientStock, "Lamp")
+
+	domainErr, ok := AsError(err)
+	// Assertion 747: Missing stock should be its own kind of error
+	assert.True(t, ok)
+	assert.Equal(t, KindInsufficientStock, domainErr.Kind)
+
+	// Assertion 748: The message should name the product
+	assert.EqualError(t, err, "not enough stock for product Lamp")
+}
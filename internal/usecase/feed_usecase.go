@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidFeedFormat = NewError(KindNotFound, "feed_not_found", "feed format must be xml or csv")
+
+const (
+	// maxSitemapSize is the most URLs the sitemap protocol allows in one file.
+	maxSitemapSize = 50000
+	feedBatchSize  = 500
+
+	sitemapNamespace  = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	merchantNamespace = "http://base.google.com/ns/1.0"
+)
+
+type FeedFormat string
+
+const (
+	FeedXML FeedFormat = "xml"
+	FeedCSV FeedFormat = "csv"
+)
+
+func ParseFeedFormat(s string) (FeedFormat, error) {
+	switch format := FeedFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case FeedXML, FeedCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidFeedFormat, s)
+	}
+}
+
+type FeedConfig struct {
+	// StorefrontURL is where the storefront shows products, at
+	// /products/{slug}, and categories, at /categories/{slug}.
+	StorefrontURL string
+	// APIURL is the public address of the API, which serves the sitemap
+	// files the index lists and the images uploaded to it.
+	APIURL string
+	// SitemapSize is the most URLs in one sitemap file.
+	SitemapSize int
+}
+
+func DefaultFeedConfig() FeedConfig {
+	return FeedConfig{
+		StorefrontURL: "http://localhost:3000",
+		APIURL:        "http://localhost:8080",
+		SitemapSize:   maxSitemapSize,
+	}
+}
+
+// FeedUsecase publishes the catalog for search engines and marketplaces.
+// The files are kept until the catalog changes, then made again on the
+// next request.
+type FeedUsecase interface {
+	// SitemapIndex lists the sitemap files, numbered from 1.
+	SitemapIndex() ([]byte, error)
+	// Sitemap returns sitemap file n, with the pages of the categories and
+	// the active products.
+	Sitemap(n int) ([]byte, error)
+	// ProductFeed lists the active products at the price they sell at now,
+	// as a Google Merchant Center RSS feed or the same fields in CSV.
+	ProductFeed(format FeedFormat) ([]byte, error)
+}
+
+type feedUsecase struct {
+	catalog      repository.CatalogRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	pricing      PricingUsecase
+	config       FeedConfig
+
+	mu      sync.Mutex
+	version string
+	files   *feedFiles
+}
+
+func NewFeedUsecase(
+	catalog repository.CatalogRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	pricing PricingUsecase,
+	config FeedConfig,
+) FeedUsecase {
+	if config.SitemapSize <= 0 || config.SitemapSize > maxSitemapSize {
+		config.SitemapSize = maxSitemapSize
+	}
+	config.StorefrontURL = strings.TrimSuffix(config.StorefrontURL, "/")
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+	return &feedUsecase{
+		catalog:      catalog,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		pricing:      pricing,
+		config:       config,
+	}
+}
+
+// feedFiles are the files made from one version of the catalog.
+type feedFiles struct {
+	sitemapIndex []byte
+	sitemaps     [][]byte
+	xml          []byte
+	csv          []byte
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// feedItem holds the Merchant Center attributes of a product. The CSV feed
+// has a column for each, named after the attribute.
+type feedItem struct {
+	ID           string `xml:"g:id"`
+	Title        string `xml:"g:title"`
+	Description  string `xml:"g:description"`
+	Link         string `xml:"g:link"`
+	ImageLink    string `xml:"g:image_link,omitempty"`
+	Availability string `xml:"g:availability"`
+	Price        string `xml:"g:price"`
+	SalePrice    string `xml:"g:sale_price,omitempty"`
+	ProductType  string `xml:"g:product_type,omitempty"`
+	Condition    string `xml:"g:condition"`
+}
+
+var feedColumns = []string{
+	"id", "title", "description", "link", "image_link", "availability",
+	"price", "sale_price", "product_type", "condition",
+}
+
+func (i feedItem) record() []string {
+	return []string{
+		i.ID, i.Title, i.Description, i.Link, i.ImageLink, i.Availability,
+		i.Price, i.SalePrice, i.ProductType, i.Condition,
+	}
+}
+
+type productFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Xmlns   string      `xml:"xmlns:g,attr"`
+	Channel feedChannel `xml:"channel"`
+}
+
+type feedChannel struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description"`
+	Items       []feedItem `xml:"item"`
+}
+
+func (u *feedUsecase) SitemapIndex() ([]byte, error) {
+	files, err := u.current()
+	if err != nil {
+		return nil, err
+	}
+	return files.sitemapIndex, nil
+}
+
+func (u *feedUsecase) Sitemap(n int) ([]byte, error) {
+	files, err := u.current()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(files.sitemaps) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return files.sitemaps[n-1], nil
+}
+
+func (u *feedUsecase) ProductFeed(format FeedFormat) ([]byte, error) {
+	files, err := u.current()
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FeedXML:
+		return files.xml, nil
+	case FeedCSV:
+		return files.csv, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFeedFormat, format)
+	}
+}
+
+// current returns the files of the catalog as it is now, making them again
+// if it changed since they were made. Requests arriving meanwhile wait for
+// the files rather than each making their own.
+func (u *feedUsecase) current() (*feedFiles, error) {
+	version, err := u.catalog.Version()
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.files != nil && u.version == version {
+		return u.files, nil
+	}
+	files, err := u.generate()
+	if err != nil {
+		return nil, err
+	}
+	u.version, u.files = version, files
+	return files, nil
+}
+
+func (u *feedUsecase) generate() (*feedFiles, error) {
+	categories, err := u.categoryRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	index := newCategoryIndex(categories)
+	var urls []sitemapURL
+	for _, category := range categories {
+		if category.Slug != "" {
+			urls = append(urls, sitemapURL{Loc: u.pageURL("categories", category.Slug), LastMod: lastMod(category.UpdatedAt)})
+		}
+	}
+	var items []feedItem
+	err = u.productRepo.FindWithFiltersInBatches(map[string]string{"is_active": "true"}, feedBatchSize, func(products []model.Product) error {
+		if err := u.pricing.ApplyPrices(products); err != nil {
+			return err
+		}
+		for _, product := range products {
+			if product.Slug == "" {
+				continue
+			}
+			urls = append(urls, sitemapURL{Loc: u.pageURL("products", product.Slug), LastMod: lastMod(product.UpdatedAt)})
+			items = append(items, u.feedItem(product, index))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	files := &feedFiles{}
+	if files.sitemaps, files.sitemapIndex, err = u.sitemaps(urls); err != nil {
+		return nil, err
+	}
+	if files.xml, err = u.feedXML(items); err != nil {
+		return nil, err
+	}
+	if files.csv, err = feedCSV(items); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (u *feedUsecase) pageURL(section, slug string) string {
+	return u.config.StorefrontURL + "/" + section + "/" + url.PathEscape(slug)
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// sitemaps splits urls into files of SitemapSize and lists them in an
+// index. An empty catalog still has one, empty, file.
+func (u *feedUsecase) sitemaps(urls []sitemapURL) ([][]byte, []byte, error) {
+	var files [][]byte
+	index := sitemapIndex{Xmlns: sitemapNamespace}
+	for start := 0; start == 0 || start < len(urls); start += u.config.SitemapSize {
+		chunk := urls[start:min(start+u.config.SitemapSize, len(urls))]
+		file, err := marshalXML(sitemapURLSet{Xmlns: sitemapNamespace, URLs: chunk})
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, file)
+		var latest string
+		for _, entry := range chunk {
+			latest = max(latest, entry.LastMod)
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{
+			Loc:     fmt.Sprintf("%s/sitemaps/%d.xml", u.config.APIURL, len(files)),
+			LastMod: latest,
+		})
+	}
+	indexFile, err := marshalXML(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, indexFile, nil
+}
+
+func (u *feedUsecase) feedItem(product model.Product, categories categoryIndex) feedItem {
+	item := feedItem{
+		ID:           strconv.FormatUint(uint64(product.ID), 10),
+		Title:        product.Name,
+		Description:  product.Description,
+		Link:         u.pageURL("products", product.Slug),
+		Availability: "out_of_stock",
+		Price:        feedPrice(product.Price, product.Currency),
+		ProductType:  categories.path(product.CategoryID),
+		Condition:    "new",
+	}
+	if item.Description == "" {
+		item.Description = product.Name
+	}
+	if product.Stock > 0 {
+		item.Availability = "in_stock"
+	}
+	if product.CompareAtPrice != nil {
+		item.Price = feedPrice(*product.CompareAtPrice, product.Currency)
+		item.SalePrice = feedPrice(product.Price, product.Currency)
+	}
+	if len(product.Images) > 0 {
+		image := product.Images[0]
+		for _, candidate := range product.Images {
+			if candidate.IsPrimary {
+				image = candidate
+				break
+			}
+		}
+		item.ImageLink = image.URL
+		// Images uploaded to the API have paths rather than full URLs.
+		if strings.HasPrefix(image.URL, "/") {
+			item.ImageLink = u.config.APIURL + image.URL
+		}
+	}
+	return item
+}
+
+func feedPrice(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func (u *feedUsecase) feedXML(items []feedItem) ([]byte, error) {
+	return marshalXML(productFeed{
+		Version: "2.0",
+		Xmlns:   merchantNamespace,
+		Channel: feedChannel{
+			Title:       "Products",
+			Link:        u.config.StorefrontURL,
+			Description: "Active products",
+			Items:       items,
+		},
+	})
+}
+
+func feedCSV(items []feedItem) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(feedColumns); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if err := writer.Write(item.record()); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func marshalXML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeCatalog reports the version tests set.
+type fakeCatalog struct {
+	version string
+}
+
+func (c *fakeCatalog) Version() (string, error) {
+	return c.version, nil
+}
+
+func setupFeedUsecase(t *testing.T, sitemapSize int) (*feedUsecase, *fakeCatalog, *MockCategoryRepository, *mockProductRepository) {
+	products := newMockProductRepository()
+	assert.NoError(t, products.Create(&model.Product{
+		Name: "Lamp", Slug: "lamp", Description: "A desk lamp", Price: 100, Currency: "PLN", Stock: 3, IsActive: true, CategoryID: 2,
+		Images: []model.ProductImage{
+			{URL: "https://img.example.com/lamp-side.jpg"},
+			{URL: "/uploads/products/1/lamp.jpg", IsPrimary: true},
+		},
+	}))
+	assert.NoError(t, products.Create(&model.Product{Name: "Chair", Slug: "chair", Price: 50, Currency: "PLN", IsActive: true, CategoryID: 1}))
+	assert.NoError(t, products.Create(&model.Product{Name: "Hidden", Slug: "hidden", Price: 5, Currency: "PLN", IsActive: false, CategoryID: 1}))
+
+	categories := new(MockCategoryRepository)
+	categories.On("FindAll").Return([]model.Category{
+		{ID: 1, Name: "Home", Slug: "home"},
+		{ID: 2, Name: "Lighting", Slug: "lighting", ParentID: uintPtr(1)},
+	}, nil)
+
+	pricing := newTestPricing()
+	pricing.scheduleRepo.(*memoryPriceSchedules).schedules = []model.PriceSchedule{
+		{ID: 1, ProductID: 1, SalePrice: 80, StartsAt: time.Now().Add(-time.Hour)},
+	}
+	catalog := &fakeCatalog{version: "1"}
+	config := DefaultFeedConfig()
+	config.StorefrontURL = "https://shop.example.com/"
+	config.APIURL = "https://api.example.com"
+	config.SitemapSize = sitemapSize
+	uc := NewFeedUsecase(catalog, products, categories, pricing, config).(*feedUsecase)
+	return uc, catalog, categories, products
+}
+
+func TestFeedUsecaseSitemaps(t *testing.T) {
+	uc, _, _, _ := setupFeedUsecase(t, 3)
+
+	index, err := uc.SitemapIndex()
+	// Assertion 716: The index should list one file per SitemapSize URLs
+	assert.NoError(t, err)
+	assert.Contains(t, string(index), "<loc>https://api.example.com/sitemaps/1.xml</loc>")
+	assert.Contains(t, string(index), "<loc>https://api.example.com/sitemaps/2.xml</loc>")
+	assert.NotContains(t, string(index), "sitemaps/3.xml")
+
+	first, err := uc.Sitemap(1)
+	// Assertion 717: Sitemaps should list category pages, then active product pages
+	assert.NoError(t, err)
+	assert.Contains(t, string(first), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, string(first), "<loc>https://shop.example.com/categories/home</loc>")
+	assert.Contains(t, string(first), "<loc>https://shop.example.com/products/lamp</loc>")
+	second, _ := uc.Sitemap(2)
+	assert.Contains(t, string(second), "<loc>https://shop.example.com/products/chair</loc>")
+	assert.NotContains(t, string(first)+string(second), "hidden")
+
+	_, err = uc.Sitemap(3)
+	// Assertion 718: A sitemap past the last one should not be found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestFeedUsecaseProductFeed(t *testing.T) {
+	uc, _, _, _ := setupFeedUsecase(t, maxSitemapSize)
+
+	feed, err := uc.ProductFeed(FeedXML)
+	xml := string(feed)
+	// Assertion 719: The XML feed should be RSS with the Merchant Center namespace
+	assert.NoError(t, err)
+	assert.Contains(t, xml, `<rss version="2.0" xmlns:g="http://base.google.com/ns/1.0">`)
+	assert.Equal(t, 2, strings.Count(xml, "<item>"))
+
+	// Assertion 720: A product on sale should have its regular price and its sale price
+	assert.Contains(t, xml, "<g:price>100.00 PLN</g:price>")
+	assert.Contains(t, xml, "<g:sale_price>80.00 PLN</g:sale_price>")
+
+	// Assertion 721: Items should have availability, category path, link and primary image
+	assert.Contains(t, xml, "<g:availability>in_stock</g:availability>")
+	assert.Contains(t, xml, "<g:availability>out_of_stock</g:availability>")
+	assert.Contains(t, xml, "<g:product_type>Home &gt; Lighting</g:product_type>")
+	assert.Contains(t, xml, "<g:link>https://shop.example.com/products/lamp</g:link>")
+	assert.Contains(t, xml, "<g:image_link>https://api.example.com/uploads/products/1/lamp.jpg</g:image_link>")
+
+	csv, err := uc.ProductFeed(FeedCSV)
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	// Assertion 722: The CSV feed should have the same fields, one product per row
+	assert.NoError(t, err)
+	assert.Equal(t, "id,title,description,link,image_link,availability,price,sale_price,product_type,condition", lines[0])
+	assert.Equal(t, "2,Chair,Chair,https://shop.example.com/products/chair,,out_of_stock,50.00 PLN,,Home,new", lines[2])
+}
+
+func TestFeedUsecaseRegeneratesWhenCatalogChanges(t *testing.T) {
+	uc, catalog, categories, products := setupFeedUsecase(t, maxSitemapSize)
+
+	_, err := uc.ProductFeed(FeedXML)
+	assert.NoError(t, err)
+	products.products[1].Name = "Armchair"
+	feed, _ := uc.ProductFeed(FeedXML)
+	// Assertion 723: Files should be reused while the catalog version is the same
+	assert.NotContains(t, string(feed), "Armchair")
+	categories.AssertNumberOfCalls(t, "FindAll", 1)
+
+	catalog.version = "2"
+	feed, _ = uc.ProductFeed(FeedXML)
+	// Assertion 724: A new catalog version should make the files again
+	assert.Contains(t, string(feed), "<g:title>Armchair</g:title>")
+	categories.AssertNumberOfCalls(t, "FindAll", 2)
+}
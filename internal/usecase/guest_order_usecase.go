@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/mail"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"gorm.io/gorm"
+)
+
+// orderLookupPurpose scopes order link signatures to this use.
+const orderLookupPurpose = "order-lookup"
+
+var (
+	ErrInvalidGuestCheckout = NewError(KindValidation, "invalid_guest_checkout", "email, name, payment method and a shipping address with country, city, postcode and street are required")
+	ErrInvalidOrderLink     = NewError(KindValidation, "invalid_order_link", "invalid or expired order link")
+)
+
+// GuestOrderConfig holds the settings of guest checkout.
+type GuestOrderConfig struct {
+	// LookupURL is the storefront page that receives the order link token as ?token=.
+	LookupURL string
+	// LinkTTL is how long the link in the order confirmation stays valid.
+	LinkTTL time.Duration
+}
+
+func DefaultGuestOrderConfig() GuestOrderConfig {
+	return GuestOrderConfig{
+		LookupURL: "http://localhost:3000/orders/lookup",
+		LinkTTL:   90 * 24 * time.Hour,
+	}
+}
+
+// GuestCheckout is what a buyer without an account provides at checkout.
+type GuestCheckout struct {
+	Email            string
+	Name             string
+	Surname          string
+	PaymentMethod    model.PaymentMethod
+	ShippingAddress  model.Address
+	ShippingMethodID uint
+	// Currency the order is priced in, "" for the base currency.
+	Currency string
+	// Locale the item names are kept in, "" for the default one.
+	Locale string
+}
+
+// GuestOrderReceipt is returned by Checkout. LookupToken opens the order
+// through GET /orders/lookup, like the link in the confirmation email.
+type GuestOrderReceipt struct {
+	Order       *model.Order `json:"order"`
+	LookupToken string       `json:"lookup_token"`
+}
+
+type GuestOrderUsecase interface {
+	// Checkout places an order from the guest cart behind cartToken and emails
+	// the buyer a link to it. If a verified account already uses the email,
+	// the order goes to that account right away.
+	Checkout(actor Actor, cartToken string, checkout GuestCheckout) (*GuestOrderReceipt, error)
+	// Lookup returns a guest order by its number and the email it was placed
+	// with. Any mismatch is reported as not found.
+	Lookup(orderID uint, email string) (*model.Order, error)
+	// LookupByToken returns the order behind a signed order link.
+	LookupByToken(token string) (*model.Order, error)
+	// AttachToUser gives the user the guest orders placed with their email.
+	// Call it only once the user has verified that email.
+	AttachToUser(actor Actor, user *model.User) (int64, error)
+}
+
+type guestOrderUsecase struct {
+	orderUC   OrderUsecase
+	orderRepo repository.OrderRepository
+	userRepo  repository.UserRepository
+	mailer    mail.Mailer
+	signer    *signedtoken.Signer
+	auditor   Auditor
+	config    GuestOrderConfig
+	now       func() time.Time
+}
+
+func NewGuestOrderUsecase(
+	orderUC OrderUsecase,
+	orderRepo repository.OrderRepository,
+	userRepo repository.UserRepository,
+	mailer mail.Mailer,
+	signer *signedtoken.Signer,
+	auditor Auditor,
+	config GuestOrderConfig,
+) GuestOrderUsecase {
+	return &guestOrderUsecase{
+		orderUC:   orderUC,
+		orderRepo: orderRepo,
+		userRepo:  userRepo,
+		mailer:    mailer,
+		signer:    signer,
+		auditor:   auditor,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+func (u *guestOrderUsecase) Checkout(actor Actor, cartToken string, checkout GuestCheckout) (*GuestOrderReceipt, error) {
+	contact, err := normalizeGuestCheckout(&checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := u.orderUC.CreateFromGuestCart(actor, cartToken, contact, checkout.PaymentMethod, checkout.ShippingAddress, checkout.ShippingMethodID, checkout.Currency, checkout.Locale)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := u.now().Add(u.config.LinkTTL)
+	receipt := &GuestOrderReceipt{Order: order, LookupToken: u.signer.Sign(orderLookupPurpose, order.ID, expires)}
+	// The order is placed either way; a lost email can be replaced by a lookup.
+	if err := u.mailer.Send(u.confirmationMessage(order, receipt.LookupToken, expires)); err != nil {
+		log.Printf("guest checkout: order %d: failed to send confirmation: %v", order.ID, err)
+	}
+
+	// Likewise, an order that could not be attached stays a guest order the
+	// buyer can still open with the lookup link.
+	if err := u.attachToVerifiedAccount(actor, order, contact.Email); err != nil {
+		log.Printf("guest checkout: order %d: failed to attach to account: %v", order.ID, err)
+	}
+	return receipt, nil
+}
+
+// attachToVerifiedAccount hands the order to the account with the buyer's
+// email, if it is verified.
+func (u *guestOrderUsecase) attachToVerifiedAccount(actor Actor, order *model.Order, email string) error {
+	user, err := u.userRepo.FindByEmail(email)
+	if err != nil || user == nil || user.EmailVerifiedAt == nil {
+		return err
+	}
+	if _, err := u.AttachToUser(actor, user); err != nil {
+		return err
+	}
+	order.UserID = &user.ID
+	return nil
+}
+
+// normalizeGuestCheckout trims the input in place and returns the contact
+// details, with the email lower-cased so lookups and attachment ignore case.
+func normalizeGuestCheckout(checkout *GuestCheckout) (GuestContact, error) {
+	contact := GuestContact{
+		Email:   strings.ToLower(strings.TrimSpace(checkout.Email)),
+		Name:    strings.TrimSpace(checkout.Name),
+		Surname: strings.TrimSpace(checkout.Surname),
+	}
+	addr := &checkout.ShippingAddress
+	for _, field := range []*string{&addr.Country, &addr.City, &addr.Postcode, &addr.Street, &addr.Number, &addr.Company, &addr.TaxID} {
+		*field = strings.TrimSpace(*field)
+	}
+
+	var fields []FieldError
+	if contact.Email == "" {
+		fields = append(fields, FieldError{Field: "email", Code: FieldRequired})
+	} else if !strings.Contains(contact.Email, "@") || strings.HasSuffix(contact.Email, "@"+anonymizedEmailDomain) {
+		fields = append(fields, FieldError{Field: "email", Code: FieldInvalid})
+	}
+	for _, required := range []struct {
+		field string
+		value string
+	}{
+		{"name", contact.Name},
+		{"payment_method", string(checkout.PaymentMethod)},
+		{"shipping_address.country", addr.Country},
+		{"shipping_address.city", addr.City},
+		{"shipping_address.postcode", addr.Postcode},
+		{"shipping_address.street", addr.Street},
+	} {
+		if required.value == "" {
+			fields = append(fields, FieldError{Field: required.field, Code: FieldRequired})
+		}
+	}
+	if len(fields) > 0 {
+		return GuestContact{}, ErrInvalidGuestCheckout.WithFields(fields...)
+	}
+	return contact, nil
+}
+
+func (u *guestOrderUsecase) confirmationMessage(order *model.Order, token string, expires time.Time) mail.Message {
+	link := u.config.LookupURL + "?token=" + url.QueryEscape(token)
+
+	var items strings.Builder
+	for _, item := range order.Items {
+		fmt.Fprintf(&items, "- %d x %s\n", item.Quantity, item.Name)
+	}
+	return mail.Message{
+		To:      order.GuestEmail,
+		Subject: fmt.Sprintf("Your order #%d", order.ID),
+		Body: fmt.Sprintf("Hi %s,\n\nThank you for your order #%d:\n\n%s\nTotal: %.2f %s\n\n"+
+			"Check its status at %s (valid until %s), or look it up with the order number and this email address.\n\n"+
+			"Register with this email address and verify it to see the order in your account.",
+			order.GuestName, order.ID, items.String(), order.Total, order.Currency, link, expires.Format("2006-01-02")),
+	}
+}
+
+func (u *guestOrderUsecase) Lookup(orderID uint, email string) (*model.Order, error) {
+	order, err := u.orderRepo.FindByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	email = strings.ToLower(strings.TrimSpace(email))
+	if order == nil || order.GuestEmail == "" || order.GuestEmail != email {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return order, nil
+}
+
+func (u *guestOrderUsecase) LookupByToken(token string) (*model.Order, error) {
+	id, err := u.signer.Verify(orderLookupPurpose, token, u.now())
+	if err != nil {
+		return nil, ErrInvalidOrderLink
+	}
+	order, err := u.orderRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return order, nil
+}
+
+func (u *guestOrderUsecase) AttachToUser(actor Actor, user *model.User) (int64, error) {
+	attached, err := u.orderRepo.AttachGuestOrders(user.Email, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	if attached > 0 {
+		u.auditor.Record(actor, model.AuditAttachOrders, model.AuditEntityUser, user.ID, nil,
+			map[string]interface{}{"email": user.Email, "orders": attached})
+	}
+	return attached, nil
+}
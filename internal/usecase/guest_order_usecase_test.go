@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/signedtoken"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// stubGuestOrderPlacer places guest orders without touching carts or stock.
+type stubGuestOrderPlacer struct {
+	OrderUsecase
+	placed []GuestContact
+}
+
+func (s *stubGuestOrderPlacer) CreateFromGuestCart(actor Actor, cartToken string, contact GuestContact, paymentMethod model.PaymentMethod, address model.Address, shippingMethodID uint, currency, locale string) (*model.Order, error) {
+	if cartToken != "cart-token" {
+		return nil, ErrInvalidCartToken
+	}
+	s.placed = append(s.placed, contact)
+	return &model.Order{
+		ID:              42,
+		GuestEmail:      contact.Email,
+		GuestName:       contact.Name,
+		PaymentMethod:   paymentMethod,
+		ShippingAddress: address,
+		Total:           59.97,
+		Items:           []model.OrderItem{{Name: "Lamp", Quantity: 1}},
+	}, nil
+}
+
+func setupGuestOrderUsecase() (*guestOrderUsecase, *stubGuestOrderPlacer, *MockOrderRepository, *MockUserRepository, *recordingMailer) {
+	placer := &stubGuestOrderPlacer{}
+	orderRepo := new(MockOrderRepository)
+	userRepo := new(MockUserRepository)
+	mailer := &recordingMailer{}
+	uc := NewGuestOrderUsecase(placer, orderRepo, userRepo, mailer,
+		signedtoken.NewSigner([]byte("test-secret")), &recordingAuditor{}, DefaultGuestOrderConfig()).(*guestOrderUsecase)
+	return uc, placer, orderRepo, userRepo, mailer
+}
+
+func guestCheckoutInput() GuestCheckout {
+	return GuestCheckout{
+		Email:         " Guest@Example.com ",
+		Name:          "Anna",
+		PaymentMethod: model.PaymentCard,
+		ShippingAddress: model.Address{
+			Country: "Poland", City: "Krakow", Postcode: "30-001", Street: "Main", Number: "1",
+		},
+	}
+}
+
+func TestGuestOrderUsecaseCheckout(t *testing.T) {
+	uc, placer, _, userRepo, mailer := setupGuestOrderUsecase()
+	userRepo.On("FindByEmail", "guest@example.com").Return(nil, nil)
+
+	incomplete := guestCheckoutInput()
+	incomplete.ShippingAddress.City = " "
+	_, err := uc.Checkout(Actor{}, "cart-token", incomplete)
+	// Assertion 562: Checkout should require a complete shipping address
+	assert.ErrorIs(t, err, ErrInvalidGuestCheckout)
+	assert.Empty(t, placer.placed)
+
+	_, err = uc.Checkout(Actor{}, "unknown", guestCheckoutInput())
+	// Assertion 563: Checkout should reject unknown cart tokens
+	assert.ErrorIs(t, err, ErrInvalidCartToken)
+
+	receipt, err := uc.Checkout(Actor{}, "cart-token", guestCheckoutInput())
+	// Assertion 564: Checkout should place the order with a normalized email
+	assert.NoError(t, err)
+	assert.Equal(t, "guest@example.com", placer.placed[0].Email)
+	assert.Nil(t, receipt.Order.UserID)
+	// Assertion 565: Checkout should mail the buyer a link that opens the order
+	if assert.Len(t, mailer.sent, 1) {
+		assert.Equal(t, "guest@example.com", mailer.sent[0].To)
+		assert.Equal(t, receipt.LookupToken, restoreToken(t, mailer.sent[0].Body))
+	}
+	id, err := uc.signer.Verify(orderLookupPurpose, receipt.LookupToken, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), id)
+}
+
+func TestGuestOrderUsecaseCheckoutAttachesToVerifiedAccount(t *testing.T) {
+	uc, _, orderRepo, userRepo, _ := setupGuestOrderUsecase()
+	verifiedAt := time.Now()
+	user := &model.User{ID: 7, Email: "guest@example.com", EmailVerifiedAt: &verifiedAt}
+	userRepo.On("FindByEmail", "guest@example.com").Return(user, nil)
+	orderRepo.On("AttachGuestOrders", "guest@example.com", uint(7)).Return(int64(1), nil)
+
+	receipt, err := uc.Checkout(Actor{}, "cart-token", guestCheckoutInput())
+	// Assertion 566: Checkout should hand the order to a verified account using the same email
+	assert.NoError(t, err)
+	assert.Equal(t, uintPtr(7), receipt.Order.UserID)
+	orderRepo.AssertCalled(t, "AttachGuestOrders", "guest@example.com", uint(7))
+}
+
+func TestGuestOrderUsecaseCheckoutKeepsOrderWhenAttachFails(t *testing.T) {
+	uc, _, orderRepo, userRepo, mailer := setupGuestOrderUsecase()
+	verifiedAt := time.Now()
+	user := &model.User{ID: 7, Email: "guest@example.com", EmailVerifiedAt: &verifiedAt}
+	userRepo.On("FindByEmail", "guest@example.com").Return(user, nil)
+	orderRepo.On("AttachGuestOrders", "guest@example.com", uint(7)).Return(int64(0), errors.New("database is locked"))
+
+	receipt, err := uc.Checkout(Actor{}, "cart-token", guestCheckoutInput())
+	// Assertion 802: A placed order should still get its receipt when attaching it fails
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), receipt.Order.ID)
+	assert.Nil(t, receipt.Order.UserID)
+	assert.NotEmpty(t, receipt.LookupToken)
+	assert.Len(t, mailer.sent, 1)
+
+	uc, _, _, userRepo, _ = setupGuestOrderUsecase()
+	userRepo.On("FindByEmail", "guest@example.com").Return(nil, errors.New("database is locked"))
+	receipt, err = uc.Checkout(Actor{}, "cart-token", guestCheckoutInput())
+	// Assertion 803: A failed account lookup should not fail the checkout either
+	assert.NoError(t, err)
+	assert.NotNil(t, receipt)
+}
+
+func TestGuestOrderUsecaseLookup(t *testing.T) {
+	uc, _, orderRepo, _, _ := setupGuestOrderUsecase()
+	order := &model.Order{ID: 42, GuestEmail: "guest@example.com"}
+	orderRepo.On("FindByID", uint(42)).Return(order, nil)
+	orderRepo.On("FindByID", uint(43)).Return(nil, nil)
+	orderRepo.On("FindByID", uint(44)).Return(&model.Order{ID: 44, UserID: uintPtr(1)}, nil)
+
+	found, err := uc.Lookup(42, " GUEST@example.com")
+	// Assertion 567: Lookup should find a guest order by number and email, ignoring case
+	assert.NoError(t, err)
+	assert.Equal(t, order, found)
+
+	_, err = uc.Lookup(42, "other@example.com")
+	// Assertion 568: Lookup should report a wrong email as not found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = uc.Lookup(43, "guest@example.com")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	// Assertion 569: Lookup should never return orders placed from an account
+	_, err = uc.Lookup(44, "")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	token := uc.signer.Sign(orderLookupPurpose, 42, time.Now().Add(time.Hour))
+	found, err = uc.LookupByToken(token)
+	// Assertion 570: LookupByToken should open the order behind a signed link
+	assert.NoError(t, err)
+	assert.Equal(t, order, found)
+
+	expired := uc.signer.Sign(orderLookupPurpose, 42, time.Now().Add(-time.Hour))
+	_, err = uc.LookupByToken(expired)
+	// Assertion 571: LookupByToken should reject expired links and tokens signed for other purposes
+	assert.ErrorIs(t, err, ErrInvalidOrderLink)
+	_, err = uc.LookupByToken(uc.signer.Sign("cart-restore", 42, time.Now().Add(time.Hour)))
+	assert.ErrorIs(t, err, ErrInvalidOrderLink)
+}
@@ -0,0 +1,445 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/imaging"
+	"go-ecommerce-api/internal/infrastructure/storage"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidImage      = NewError(KindValidation, "invalid_image", "invalid image")
+	ErrImageTooLarge     = NewError(KindTooLarge, "image_too_large", "image too large")
+	ErrInvalidImageOrder = NewError(KindValidation, "invalid_image_order", "invalid image order")
+)
+
+// Error message constants
+const (
+	errImageEmpty    = "the file is empty"
+	errImageFileSize = "the file is over %d bytes"
+	errImageOrder    = "list each of the product's %d images once"
+)
+
+type ImageConfig struct {
+	// MaxFileSize is the largest file accepted, in bytes.
+	MaxFileSize int64
+	// MaxPixels limits width × height, as a small file can hold a huge
+	// image that would take a lot of memory to decode.
+	MaxPixels int
+	// Sizes of the variants: the longest side, in pixels.
+	ThumbnailSize int
+	MediumSize    int
+	LargeSize     int
+}
+
+func DefaultImageConfig() ImageConfig {
+	return ImageConfig{
+		MaxFileSize:   10 << 20,
+		MaxPixels:     40_000_000,
+		ThumbnailSize: 150,
+		MediumSize:    600,
+		LargeSize:     1200,
+	}
+}
+
+type ImageUpload struct {
+	Body io.Reader
+	// Primary makes the upload the product's primary image. A product's
+	// first image is always primary.
+	Primary bool
+}
+
+// ImageUsecase stores uploaded JPEG, PNG and GIF images with resized
+// variants. Uploads are sniffed rather than trusted by their name or
+// content type; anything else fails with ErrInvalidImage, and files or
+// images over the configured limits with ErrImageTooLarge.
+type ImageUsecase interface {
+	UploadProductImage(actor Actor, productID uint, upload ImageUpload) (*model.ProductImage, error)
+	// ReorderProductImages puts the product's images in the order of
+	// imageIDs, which must list each of them once.
+	ReorderProductImages(actor Actor, productID uint, imageIDs []uint) ([]model.ProductImage, error)
+	SetPrimaryProductImage(actor Actor, productID, imageID uint) ([]model.ProductImage, error)
+	// DeleteProductImage removes the image and its files. When it was the
+	// primary image the next one takes over.
+	DeleteProductImage(actor Actor, productID, imageID uint) error
+	// ReplaceProductImages sets the product's images to the given URLs, in
+	// order. Images already at one of the URLs are kept; the files of
+	// uploaded images left out are removed.
+	ReplaceProductImages(productID uint, urls []string) error
+	// UploadCategoryImage sets the category's icon, replacing and removing
+	// an uploaded one.
+	UploadCategoryImage(actor Actor, categoryID uint, upload ImageUpload) (*model.Category, error)
+	DeleteCategoryImage(actor Actor, categoryID uint) (*model.Category, error)
+}
+
+type imageUsecase struct {
+	imageRepo    repository.ProductImageRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	storage      storage.Storage
+	auditor      Auditor
+	config       ImageConfig
+}
+
+func NewImageUsecase(
+	imageRepo repository.ProductImageRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	storage storage.Storage,
+	auditor Auditor,
+	config ImageConfig,
+) ImageUsecase {
+	return &imageUsecase{
+		imageRepo:    imageRepo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		storage:      storage,
+		auditor:      auditor,
+		config:       config,
+	}
+}
+
+// storedImage is an upload saved with its variants.
+type storedImage struct {
+	url      string
+	variants model.ImageVariants
+	format   imaging.Format
+	width    int
+	height   int
+	keys     []string
+}
+
+// store checks and saves the upload under prefix, with its variants. The
+// original is kept as uploaded.
+func (u *imageUsecase) store(prefix string, body io.Reader) (*storedImage, error) {
+	data, err := io.ReadAll(io.LimitReader(body, u.config.MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImage, errImageEmpty)
+	}
+	if int64(len(data)) > u.config.MaxFileSize {
+		return nil, fmt.Errorf("%w: "+errImageFileSize, ErrImageTooLarge, u.config.MaxFileSize)
+	}
+	format, err := imaging.Sniff(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	img, err := imaging.Decode(data, format, u.config.MaxPixels)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return nil, fmt.Errorf("%w: %v", ErrImageTooLarge, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	name, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	base := prefix + "/" + name
+	stored := &storedImage{format: format, width: img.Bounds().Dx(), height: img.Bounds().Dy()}
+	key := base + format.Extension()
+	if err := u.storage.Put(key, bytes.NewReader(data), format.ContentType()); err != nil {
+		return nil, err
+	}
+	stored.keys = append(stored.keys, key)
+	stored.url = u.storage.URL(key)
+
+	// Each variant is scaled from the next larger one, which is quicker
+	// than going back to the original and looks the same.
+	for _, variant := range []struct {
+		name string
+		size int
+		url  *string
+	}{
+		{"large", u.config.LargeSize, &stored.variants.Large},
+		{"medium", u.config.MediumSize, &stored.variants.Medium},
+		{"thumbnail", u.config.ThumbnailSize, &stored.variants.Thumbnail},
+	} {
+		img = imaging.Fit(img, variant.size)
+		var buf bytes.Buffer
+		encoded, err := imaging.Encode(&buf, img, format)
+		if err != nil {
+			u.removeFiles(stored.keys)
+			return nil, err
+		}
+		key := base + "-" + variant.name + encoded.Extension()
+		if err := u.storage.Put(key, &buf, encoded.ContentType()); err != nil {
+			u.removeFiles(stored.keys)
+			return nil, err
+		}
+		stored.keys = append(stored.keys, key)
+		*variant.url = u.storage.URL(key)
+	}
+	return stored, nil
+}
+
+// removeFiles deletes stored files once nothing refers to them. Failures
+// are only logged: the change they belong to has been made.
+func (u *imageUsecase) removeFiles(keys []string) {
+	for _, key := range keys {
+		if err := u.storage.Delete(key); err != nil {
+			log.Printf("remove image file %s: %v", key, err)
+		}
+	}
+}
+
+// productImages returns the product's images by position.
+func (u *imageUsecase) productImages(productID uint) ([]model.ProductImage, error) {
+	product, err := u.productRepo.FindByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u.imageRepo.FindByProduct(productID)
+}
+
+// findImage returns the index of the image among images. Images of other
+// products are not found.
+func findImage(images []model.ProductImage, id uint) (int, error) {
+	for i, image := range images {
+		if image.ID == id {
+			return i, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// findPrimary returns the index of the primary image.
+func findPrimary(images []model.ProductImage) (int, error) {
+	for i, image := range images {
+		if image.IsPrimary {
+			return i, nil
+		}
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// makePrimary marks images[primary] as the primary image and returns the
+// images whose flag changed.
+func makePrimary(images []model.ProductImage, primary int) []model.ProductImage {
+	var changed []model.ProductImage
+	for i := range images {
+		if images[i].IsPrimary != (i == primary) {
+			images[i].IsPrimary = i == primary
+			changed = append(changed, images[i])
+		}
+	}
+	return changed
+}
+
+func (u *imageUsecase) UploadProductImage(actor Actor, productID uint, upload ImageUpload) (*model.ProductImage, error) {
+	images, err := u.productImages(productID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := u.store(fmt.Sprintf("products/%d", productID), upload.Body)
+	if err != nil {
+		return nil, err
+	}
+	image := &model.ProductImage{
+		ProductID:   productID,
+		URL:         stored.url,
+		Variants:    &stored.variants,
+		ContentType: stored.format.ContentType(),
+		Width:       stored.width,
+		Height:      stored.height,
+		StorageKeys: stored.keys,
+	}
+	if n := len(images); n > 0 {
+		image.Position = images[n-1].Position + 1
+	}
+	image.IsPrimary = upload.Primary
+	if _, err := findPrimary(images); err != nil {
+		image.IsPrimary = true
+	}
+	if err := u.imageRepo.Create(image); err != nil {
+		u.removeFiles(stored.keys)
+		return nil, err
+	}
+	if image.IsPrimary {
+		images = append(images, *image)
+		if err := u.imageRepo.Save(makePrimary(images, len(images)-1)); err != nil {
+			return nil, err
+		}
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityProductImage, image.ID, nil, image)
+	return image, nil
+}
+
+func (u *imageUsecase) ReorderProductImages(actor Actor, productID uint, imageIDs []uint) ([]model.ProductImage, error) {
+	images, err := u.productImages(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIDs) != len(images) {
+		return nil, fmt.Errorf("%w: "+errImageOrder, ErrInvalidImageOrder, len(images))
+	}
+	before := append([]model.ProductImage(nil), images...)
+	ordered := make([]model.ProductImage, 0, len(images))
+	seen := make(map[uint]bool, len(imageIDs))
+	for position, id := range imageIDs {
+		i, err := findImage(images, id)
+		if err != nil || seen[id] {
+			return nil, fmt.Errorf("%w: "+errImageOrder, ErrInvalidImageOrder, len(images))
+		}
+		seen[id] = true
+		images[i].Position = position
+		ordered = append(ordered, images[i])
+	}
+	if err := u.imageRepo.Save(ordered); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityProduct, productID, before, ordered)
+	return ordered, nil
+}
+
+func (u *imageUsecase) SetPrimaryProductImage(actor Actor, productID, imageID uint) ([]model.ProductImage, error) {
+	images, err := u.productImages(productID)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findImage(images, imageID)
+	if err != nil {
+		return nil, err
+	}
+	before := append([]model.ProductImage(nil), images...)
+	if err := u.imageRepo.Save(makePrimary(images, i)); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityProduct, productID, before, images)
+	return images, nil
+}
+
+func (u *imageUsecase) DeleteProductImage(actor Actor, productID, imageID uint) error {
+	images, err := u.productImages(productID)
+	if err != nil {
+		return err
+	}
+	i, err := findImage(images, imageID)
+	if err != nil {
+		return err
+	}
+	image := images[i]
+	if err := u.imageRepo.Delete(imageID); err != nil {
+		return err
+	}
+	rest := append(images[:i:i], images[i+1:]...)
+	if image.IsPrimary && len(rest) > 0 {
+		if err := u.imageRepo.Save(makePrimary(rest, 0)); err != nil {
+			return err
+		}
+	}
+	u.removeFiles(image.StorageKeys)
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityProductImage, imageID, image, nil)
+	return nil
+}
+
+func (u *imageUsecase) ReplaceProductImages(productID uint, urls []string) error {
+	current, err := u.imageRepo.FindByProduct(productID)
+	if err != nil {
+		return err
+	}
+	kept := make(map[uint]bool, len(current))
+	images := make([]model.ProductImage, len(urls))
+	for position, url := range urls {
+		images[position] = model.ProductImage{ProductID: productID, URL: url}
+		for _, image := range current {
+			if image.URL == url && !kept[image.ID] {
+				kept[image.ID] = true
+				images[position] = image
+				break
+			}
+		}
+		images[position].Position = position
+	}
+	if primary, err := findPrimary(images); err == nil {
+		makePrimary(images, primary)
+	} else if len(images) > 0 {
+		makePrimary(images, 0)
+	}
+	if err := u.imageRepo.Save(images); err != nil {
+		return err
+	}
+
+	var removed []uint
+	var keys []string
+	for _, image := range current {
+		if !kept[image.ID] {
+			removed = append(removed, image.ID)
+			keys = append(keys, image.StorageKeys...)
+		}
+	}
+	if err := u.imageRepo.Delete(removed...); err != nil {
+		return err
+	}
+	u.removeFiles(keys)
+	return nil
+}
+
+func (u *imageUsecase) category(id uint) (*model.Category, error) {
+	category, err := u.categoryRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if category == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return category, nil
+}
+
+// setCategoryImage saves the category with the given icon and removes the
+// files of the one it had.
+func (u *imageUsecase) setCategoryImage(actor Actor, category *model.Category, url *string, variants *model.ImageVariants, keys []string) (*model.Category, error) {
+	before := *category
+	// Only the category's own columns are saved.
+	update := *category
+	update.Products, update.Subcategories, update.ParentCategory = nil, nil, nil
+	update.IconURL, update.Image, update.ImageKeys = url, variants, keys
+	if err := u.categoryRepo.Update(&update); err != nil {
+		return nil, err
+	}
+	u.removeFiles(before.ImageKeys)
+	updated, err := u.category(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityCategory, category.ID, &before, updated)
+	return updated, nil
+}
+
+func (u *imageUsecase) UploadCategoryImage(actor Actor, categoryID uint, upload ImageUpload) (*model.Category, error) {
+	category, err := u.category(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := u.store(fmt.Sprintf("categories/%d", categoryID), upload.Body)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := u.setCategoryImage(actor, category, &stored.url, &stored.variants, stored.keys)
+	if err != nil {
+		u.removeFiles(stored.keys)
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (u *imageUsecase) DeleteCategoryImage(actor Actor, categoryID uint) (*model.Category, error) {
+	category, err := u.category(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return u.setCategoryImage(actor, category, nil, nil, nil)
+}
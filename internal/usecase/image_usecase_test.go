@@ -0,0 +1,339 @@
+package usecase
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// memoryProductImages keeps images on the products of a
+// mockProductRepository, the way the database preloads them.
+type memoryProductImages struct {
+	products *mockProductRepository
+	nextID   uint
+}
+
+func (r *memoryProductImages) product(id uint) *model.Product {
+	for i := range r.products.products {
+		if r.products.products[i].ID == id {
+			return &r.products.products[i]
+		}
+	}
+	return nil
+}
+
+func (r *memoryProductImages) FindByProduct(productID uint) ([]model.ProductImage, error) {
+	product := r.product(productID)
+	if product == nil {
+		return nil, nil
+	}
+	images := append([]model.ProductImage(nil), product.Images...)
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Position < images[j].Position })
+	return images, nil
+}
+
+func (r *memoryProductImages) Create(image *model.ProductImage) error {
+	r.nextID++
+	image.ID = r.nextID
+	product := r.product(image.ProductID)
+	product.Images = append(product.Images, *image)
+	return nil
+}
+
+func (r *memoryProductImages) Save(images []model.ProductImage) error {
+	for _, image := range images {
+		if image.ID == 0 {
+			if err := r.Create(&image); err != nil {
+				return err
+			}
+			continue
+		}
+		product := r.product(image.ProductID)
+		for i := range product.Images {
+			if product.Images[i].ID == image.ID {
+				product.Images[i] = image
+			}
+		}
+	}
+	return nil
+}
+
+func (r *memoryProductImages) Delete(ids ...uint) error {
+	for i := range r.products.products {
+		product := &r.products.products[i]
+		kept := product.Images[:0]
+		for _, image := range product.Images {
+			if !containsID(ids, image.ID) {
+				kept = append(kept, image)
+			}
+		}
+		product.Images = kept
+	}
+	return nil
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+type memoryStorage struct {
+	files map[string][]byte
+}
+
+func (s *memoryStorage) Put(key string, r io.Reader, contentType string) error {
+	data, err := io.ReadAll(r)
+	s.files[key] = data
+	return err
+}
+
+func (s *memoryStorage) Delete(key string) error {
+	delete(s.files, key)
+	return nil
+}
+
+func (s *memoryStorage) URL(key string) string {
+	return "/uploads/" + key
+}
+
+// file returns the stored file a URL points to.
+func (s *memoryStorage) file(url string) []byte {
+	return s.files[strings.TrimPrefix(url, "/uploads/")]
+}
+
+// setupImageUsecase sets up a product, ID 1, without images and a category,
+// ID 1, without an icon.
+func setupImageUsecase(t *testing.T) (*imageUsecase, *mockProductRepository, *memoryStorage, *model.Category) {
+	repo := newMockProductRepository()
+	assert.NoError(t, repo.Create(&model.Product{Name: "Lamp", Price: 40, CategoryID: 1}))
+
+	category := &model.Category{ID: 1, Name: "Home"}
+	categories := new(MockCategoryRepository)
+	categories.On("FindByID", uint(1)).Return(category, nil)
+	categories.On("FindByID", mock.Anything).Return(nil, nil)
+	categories.On("SlugTaken", mock.Anything, mock.Anything).Return(false, nil).Maybe()
+	categories.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+		*category = *args.Get(0).(*model.Category)
+	}).Return(nil)
+
+	files := &memoryStorage{files: map[string][]byte{}}
+	uc := NewImageUsecase(&memoryProductImages{products: repo}, repo, categories, files, &recordingAuditor{}, DefaultImageConfig()).(*imageUsecase)
+	return uc, repo, files, category
+}
+
+// testImage encodes a w×h image of one colour.
+func testImage(t *testing.T, format string, w, h int) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.RGBA{R: 200, A: 255}})
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func uploadOf(data []byte, primary bool) ImageUpload {
+	return ImageUpload{Body: bytes.NewReader(data), Primary: primary}
+}
+
+func TestImageUsecaseUploadsProductImages(t *testing.T) {
+	uc, repo, files, _ := setupImageUsecase(t)
+
+	first, err := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 2000, 1000), false))
+	// Assertion 671: Uploads should be stored with a thumbnail, medium and large variant
+	assert.NoError(t, err)
+	assert.Len(t, files.files, 4)
+	assert.Len(t, first.StorageKeys, 4)
+	assert.True(t, strings.HasPrefix(first.URL, "/uploads/products/1/"))
+	assert.Equal(t, "image/png", first.ContentType)
+	assert.Equal(t, 2000, first.Width)
+	assert.Equal(t, 1000, first.Height)
+
+	// Assertion 672: Variants should fit their size, keep the aspect ratio and the colour
+	for url, want := range map[string]image.Point{
+		first.Variants.Thumbnail: {150, 75},
+		first.Variants.Medium:    {600, 300},
+		first.Variants.Large:     {1200, 600},
+	} {
+		img, err := png.Decode(bytes.NewReader(files.file(url)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, img.Bounds().Size())
+		assert.Equal(t, color.RGBA{R: 200, A: 255}, color.RGBAModel.Convert(img.At(10, 10)))
+	}
+
+	// Assertion 673: A product's first image should be its primary image
+	assert.True(t, first.IsPrimary)
+	assert.Equal(t, 0, first.Position)
+
+	second, err := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "jpeg", 300, 400), true))
+	// Assertion 674: A primary upload should take the flag over and go last
+	assert.NoError(t, err)
+	assert.True(t, second.IsPrimary)
+	assert.Equal(t, 1, second.Position)
+	assert.True(t, strings.HasSuffix(second.Variants.Medium, "-medium.jpg"))
+	images, _ := uc.imageRepo.FindByProduct(1)
+	assert.False(t, images[0].IsPrimary)
+
+	third, err := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "gif", 100, 100), false))
+	// Assertion 675: GIF variants should be PNG, and small images should not be enlarged
+	assert.NoError(t, err)
+	assert.False(t, third.IsPrimary)
+	assert.True(t, strings.HasSuffix(third.URL, ".gif"))
+	assert.True(t, strings.HasSuffix(third.Variants.Large, "-large.png"))
+	config, err := png.DecodeConfig(bytes.NewReader(files.file(third.Variants.Large)))
+	assert.NoError(t, err)
+	assert.Equal(t, 100, config.Width)
+	assert.Len(t, repo.products[0].Images, 3)
+}
+
+func TestImageUsecaseRejectsBadUploads(t *testing.T) {
+	uc, _, files, _ := setupImageUsecase(t)
+
+	_, err := uc.UploadProductImage(testActor, 1, uploadOf([]byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), false))
+	// Assertion 676: Uploads should be sniffed and anything but JPEG, PNG and GIF refused
+	assert.ErrorIs(t, err, ErrInvalidImage)
+	_, err = uc.UploadProductImage(testActor, 1, uploadOf(nil, false))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+	_, err = uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 10, 10)[:40], false))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	uc.config.MaxFileSize = 100
+	_, err = uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "jpeg", 50, 50), false))
+	// Assertion 677: Files over MaxFileSize and images over MaxPixels should fail with ErrImageTooLarge
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+	uc.config = DefaultImageConfig()
+	uc.config.MaxPixels = 50 * 50
+	_, err = uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 51, 50), false))
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+
+	_, err = uc.UploadProductImage(testActor, 2, uploadOf(testImage(t, "png", 10, 10), false))
+	// Assertion 678: Uploads for unknown products should fail with ErrRecordNotFound and store nothing
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, files.files)
+}
+
+func TestImageUsecaseOrdersAndDeletesProductImages(t *testing.T) {
+	uc, repo, files, _ := setupImageUsecase(t)
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		image, err := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 20, 20), false))
+		assert.NoError(t, err)
+		ids = append(ids, image.ID)
+	}
+
+	_, err := uc.ReorderProductImages(testActor, 1, []uint{ids[2], ids[0]})
+	// Assertion 679: A new order should list each image once
+	assert.ErrorIs(t, err, ErrInvalidImageOrder)
+	_, err = uc.ReorderProductImages(testActor, 1, []uint{ids[2], ids[0], ids[0]})
+	assert.ErrorIs(t, err, ErrInvalidImageOrder)
+
+	images, err := uc.ReorderProductImages(testActor, 1, []uint{ids[2], ids[0], ids[1]})
+	// Assertion 680: Reordering should renumber the positions and keep the primary image
+	assert.NoError(t, err)
+	stored, _ := uc.imageRepo.FindByProduct(1)
+	assert.Equal(t, []uint{ids[2], ids[0], ids[1]}, []uint{stored[0].ID, stored[1].ID, stored[2].ID})
+	assert.True(t, images[1].IsPrimary)
+
+	_, err = uc.SetPrimaryProductImage(testActor, 1, 99)
+	// Assertion 681: SetPrimary should only find the product's own images
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = uc.SetPrimaryProductImage(testActor, 1, ids[2])
+	assert.NoError(t, err)
+
+	removed := stored[0]
+	// Assertion 682: Deleting the primary image should remove its files and pass the flag on
+	assert.NoError(t, uc.DeleteProductImage(testActor, 1, ids[2]))
+	for _, key := range removed.StorageKeys {
+		assert.NotContains(t, files.files, key)
+	}
+	assert.Len(t, files.files, 8)
+	stored, _ = uc.imageRepo.FindByProduct(1)
+	assert.Len(t, stored, 2)
+	assert.True(t, stored[0].IsPrimary)
+	assert.Equal(t, ids[0], stored[0].ID)
+
+	// Assertion 683: Deleting an image twice should fail with ErrRecordNotFound
+	assert.ErrorIs(t, uc.DeleteProductImage(testActor, 1, ids[2]), gorm.ErrRecordNotFound)
+	assert.Len(t, repo.products[0].Images, 2)
+}
+
+func TestImageUsecaseReplacesProductImages(t *testing.T) {
+	uc, _, files, _ := setupImageUsecase(t)
+	kept, _ := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 20, 20), false))
+	dropped, _ := uc.UploadProductImage(testActor, 1, uploadOf(testImage(t, "png", 20, 20), true))
+
+	// Assertion 684: Replacing should keep images at the given URLs and remove the others' files
+	assert.NoError(t, uc.ReplaceProductImages(1, []string{"https://img.example.com/a.jpg", kept.URL}))
+	images, _ := uc.imageRepo.FindByProduct(1)
+	assert.Len(t, images, 2)
+	assert.Equal(t, "https://img.example.com/a.jpg", images[0].URL)
+	assert.Equal(t, kept.ID, images[1].ID)
+	assert.Equal(t, 1, images[1].Position)
+	assert.Len(t, files.files, 4)
+	assert.NotContains(t, files.files, dropped.StorageKeys[0])
+
+	// Assertion 685: When the primary image is dropped the first one should become primary
+	assert.True(t, images[0].IsPrimary)
+	assert.False(t, images[1].IsPrimary)
+}
+
+func TestImageUsecaseCategoryImages(t *testing.T) {
+	uc, _, files, category := setupImageUsecase(t)
+
+	updated, err := uc.UploadCategoryImage(testActor, 1, uploadOf(testImage(t, "png", 800, 800), false))
+	// Assertion 686: A category upload should set the icon URL and its variants
+	assert.NoError(t, err)
+	assert.Equal(t, updated.Image.Thumbnail, category.Image.Thumbnail)
+	assert.True(t, strings.HasPrefix(*category.IconURL, "/uploads/categories/1/"))
+	assert.Len(t, category.ImageKeys, 4)
+	first := category.ImageKeys
+
+	_, err = uc.UploadCategoryImage(testActor, 1, uploadOf(testImage(t, "jpeg", 80, 80), false))
+	// Assertion 687: A new icon should replace the files of the old one
+	assert.NoError(t, err)
+	assert.Len(t, files.files, 4)
+	assert.NotContains(t, files.files, first[0])
+
+	categories := NewCategoryUsecase(uc.categoryRepo, newMemorySlugRedirects(), &recordingAuditor{})
+	_, err = categories.Update(testActor, &model.Category{ID: 1, Name: "House"})
+	// Assertion 688: Updating the category should keep the uploaded icon
+	assert.NoError(t, err)
+	assert.Equal(t, "House", category.Name)
+	assert.NotNil(t, category.IconURL)
+	assert.Len(t, category.ImageKeys, 4)
+
+	_, err = uc.DeleteCategoryImage(testActor, 1)
+	// Assertion 689: Deleting the icon should clear it and remove its files
+	assert.NoError(t, err)
+	assert.Nil(t, category.IconURL)
+	assert.Nil(t, category.Image)
+	assert.Empty(t, files.files)
+
+	_, err = uc.UploadCategoryImage(testActor, 2, uploadOf(testImage(t, "png", 10, 10), false))
+	// Assertion 690: Uploads for unknown categories should fail with ErrRecordNotFound
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, files.files)
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const (
+	supportReason      = "reproduce checkout issue #42"
+	modelImpersonation = "*model.Impersonation"
+)
+
+type MockImpersonationRepository struct {
+	mock.Mock
+}
+
+func (m *MockImpersonationRepository) FindWithFilters(filters map[string]string) ([]model.Impersonation, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]model.Impersonation), args.Error(1)
+}
+
+func (m *MockImpersonationRepository) Create(impersonation *model.Impersonation) error {
+	args := m.Called(impersonation)
+	return args.Error(0)
+}
+
+func setupImpersonationUsecase() (*impersonationUsecase, *MockImpersonationRepository, *MockUserRepository) {
+	mockRepo := new(MockImpersonationRepository)
+	mockUserRepo := new(MockUserRepository)
+	uc := &impersonationUsecase{
+		impersonationRepo: mockRepo,
+		userRepo:          mockUserRepo,
+		auditor:           &recordingAuditor{},
+	}
+	return uc, mockRepo, mockUserRepo
+}
+
+func TestImpersonationUsecaseStartSuccess(t *testing.T) {
+	uc, mockRepo, mockUserRepo := setupImpersonationUsecase()
+
+	target := &model.User{ID: 2, Email: userExampleEmail, Role: model.RoleUser}
+	mockUserRepo.On("FindByID", uint(2)).Return(target, nil)
+	mockRepo.On("Create", mock.AnythingOfType(modelImpersonation)).Return(nil)
+
+	record, user, err := uc.Start(Actor{UserID: 1, IP: "10.0.0.1"}, 2, supportReason, 3*time.Hour)
+
+	// Assertion 458: Start should succeed for a regular user
+	assert.NoError(t, err)
+	// Assertion 459: Start should return the impersonated user
+	assert.Equal(t, target, user)
+	// Assertion 460: Start should record the acting admin and the reason
+	assert.Equal(t, uint(1), record.AdminID)
+	assert.Equal(t, supportReason, record.Reason)
+	// Assertion 461: Start should cap the token lifetime
+	assert.WithinDuration(t, time.Now().Add(maxImpersonationTTL), record.ExpiresAt, time.Minute)
+
+	mockRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestImpersonationUsecaseStartRejectsInvalidTargets(t *testing.T) {
+	uc, mockRepo, mockUserRepo := setupImpersonationUsecase()
+
+	deactivatedAt := time.Now()
+	mockUserRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Role: model.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", uint(3)).Return(&model.User{ID: 3, Role: model.RoleUser, DeactivatedAt: &deactivatedAt}, nil)
+	mockUserRepo.On("FindByID", uint(4)).Return(nil, nil)
+
+	_, _, err := uc.Start(Actor{UserID: 1}, 2, "", 0)
+	// Assertion 462: Start should require a reason
+	assert.ErrorIs(t, err, ErrImpersonationReason)
+
+	_, _, err = uc.Start(Actor{UserID: 1}, 2, supportReason, 0)
+	// Assertion 463: Start should refuse to impersonate admins
+	assert.ErrorIs(t, err, ErrImpersonationTarget)
+
+	_, _, err = uc.Start(Actor{UserID: 1}, 3, supportReason, 0)
+	// Assertion 464: Start should refuse to impersonate deactivated users
+	assert.ErrorIs(t, err, ErrImpersonationTarget)
+
+	_, _, err = uc.Start(Actor{UserID: 1}, 4, supportReason, 0)
+	// Assertion 465: Start should report missing users
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
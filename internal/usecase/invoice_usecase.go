@@ -0,0 +1,473 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Error message constants
+const (
+	errCreditNoteEmpty       = "choose at least one item or the shipping"
+	errCreditNoteUnknownItem = "order item %d is not on the invoice"
+	errCreditNoteQuantity    = "order item %d: quantity must be between 1 and %d"
+	errCreditNoteShipping    = "the shipping has already been credited"
+	creditNoteCancelReason   = "Order cancelled"
+	invoiceShippingLine      = "Shipping"
+)
+
+var (
+	ErrOrderNotPaid      = NewError(KindConflict, "order_not_paid", "order has not been paid, so it has no invoice")
+	ErrOrderNotInvoiced  = NewError(KindConflict, "order_not_invoiced", "order has no invoice to correct")
+	ErrInvalidCreditNote = NewError(KindValidation, "invalid_credit_note", "invalid credit note")
+	ErrNothingToCredit   = NewError(KindConflict, "nothing_to_credit", "the invoice has already been credited in full")
+)
+
+// InvoiceConfig holds the seller details printed on invoices and the
+// prefixes of invoice and credit note numbers.
+type InvoiceConfig struct {
+	SellerName       string
+	SellerAddress    string
+	SellerTaxID      string
+	InvoicePrefix    string
+	CreditNotePrefix string
+}
+
+func DefaultInvoiceConfig() InvoiceConfig {
+	return InvoiceConfig{
+		SellerName:       "E-Commerce Store",
+		InvoicePrefix:    "INV",
+		CreditNotePrefix: "CN",
+	}
+}
+
+// CreditNoteItem refunds Quantity units of an order item.
+type CreditNoteItem struct {
+	OrderItemID uint
+	Quantity    int
+}
+
+// CreditNoteInput describes a refund: the items returned and whether the
+// shipping is refunded as well.
+type CreditNoteInput struct {
+	Reason   string
+	Items    []CreditNoteItem
+	Shipping bool
+}
+
+type InvoiceUsecase interface {
+	// Issue issues the invoice of a paid order. An order has one invoice;
+	// issuing it again returns the existing one.
+	Issue(actor Actor, orderID uint) (*model.Invoice, error)
+	// GetForOrder returns the invoice of an order, issuing it first when the
+	// order is paid but its OrderPaid event has not been handled yet.
+	GetForOrder(actor Actor, orderID uint) (*model.Invoice, error)
+	// GetDocuments returns the invoice and credit notes of an order, oldest first.
+	GetDocuments(orderID uint) ([]model.Invoice, error)
+	GetByID(id uint) (*model.Invoice, error)
+	// IssueCreditNote corrects the order's invoice for a refund. Items and
+	// shipping cannot be credited more than once in total.
+	IssueCreditNote(actor Actor, orderID uint, input CreditNoteInput) (*model.Invoice, error)
+	// Subscribe issues invoices on OrderPaid and credits whatever has not
+	// been refunded yet on OrderCancelled.
+	Subscribe(outbox OutboxUsecase)
+}
+
+type invoiceUsecase struct {
+	invoiceRepo repository.InvoiceRepository
+	orderRepo   repository.OrderRepository
+	auditor     Auditor
+	config      InvoiceConfig
+	now         func() time.Time
+}
+
+func NewInvoiceUsecase(
+	invoiceRepo repository.InvoiceRepository,
+	orderRepo repository.OrderRepository,
+	auditor Auditor,
+	config InvoiceConfig,
+) InvoiceUsecase {
+	return &invoiceUsecase{
+		invoiceRepo: invoiceRepo,
+		orderRepo:   orderRepo,
+		auditor:     auditor,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+func (u *invoiceUsecase) GetByID(id uint) (*model.Invoice, error) {
+	invoice, err := u.invoiceRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return invoice, nil
+}
+
+func (u *invoiceUsecase) GetDocuments(orderID uint) ([]model.Invoice, error) {
+	return u.invoiceRepo.FindByOrderID(orderID)
+}
+
+func (u *invoiceUsecase) GetForOrder(actor Actor, orderID uint) (*model.Invoice, error) {
+	invoice, _, err := u.documents(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice != nil {
+		return invoice, nil
+	}
+	return u.Issue(actor, orderID)
+}
+
+// documents returns the invoice of an order, nil if there is none yet, and
+// its credit notes.
+func (u *invoiceUsecase) documents(orderID uint) (*model.Invoice, []model.Invoice, error) {
+	docs, err := u.invoiceRepo.FindByOrderID(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	invoice, creditNotes := splitDocuments(docs)
+	return invoice, creditNotes, nil
+}
+
+// splitDocuments separates the invoice of an order from its credit notes.
+func splitDocuments(docs []model.Invoice) (*model.Invoice, []model.Invoice) {
+	var invoice *model.Invoice
+	var creditNotes []model.Invoice
+	for i := range docs {
+		if docs[i].Type == model.InvoiceTypeInvoice {
+			invoice = &docs[i]
+		} else {
+			creditNotes = append(creditNotes, docs[i])
+		}
+	}
+	return invoice, creditNotes
+}
+
+func (u *invoiceUsecase) Issue(actor Actor, orderID uint) (*model.Invoice, error) {
+	order, err := u.orderRepo.FindByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf(errFailedToGetOrder, err)
+	}
+	if order == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if order.PaidAt == nil {
+		return nil, ErrOrderNotPaid
+	}
+	if existing, _, err := u.documents(orderID); err != nil || existing != nil {
+		return existing, err
+	}
+
+	invoice := u.newDocument(model.InvoiceTypeInvoice, order.ID)
+	invoice.Currency = order.Currency
+	invoice.BuyerName, invoice.BuyerEmail = buyerOf(order)
+	invoice.BuyerCompany = order.ShippingAddress.Company
+	invoice.BuyerTaxID = order.ShippingAddress.TaxID
+	invoice.BuyerAddress = formatAddress(order.ShippingAddress)
+
+	for _, item := range order.Items {
+		net, tax := item.NetSubtotal, item.TaxAmount
+		if net == 0 && tax == 0 {
+			// Placed before taxes were recorded on orders.
+			net = item.Subtotal
+		}
+		itemID := item.ID
+		invoice.Lines = append(invoice.Lines, invoiceLine(&itemID, item.Name, item.Quantity, item.TaxRate, net, tax))
+	}
+	if order.ShippingCost != 0 {
+		name := invoiceShippingLine
+		if order.ShippingMethodName != "" {
+			name += ": " + order.ShippingMethodName
+		}
+		net := model.RoundMoney(order.ShippingCost - order.ShippingTax)
+		invoice.Lines = append(invoice.Lines, invoiceLine(nil, name, 1, order.ShippingTaxRate, net, order.ShippingTax))
+	}
+
+	if err := u.issue(actor, invoice, nil); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			// Issued concurrently, e.g. by the OrderPaid handler.
+			existing, _, err := u.documents(orderID)
+			return existing, err
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (u *invoiceUsecase) IssueCreditNote(actor Actor, orderID uint, input CreditNoteInput) (*model.Invoice, error) {
+	invoice, _, err := u.documents(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, ErrOrderNotInvoiced
+	}
+	if len(input.Items) == 0 && !input.Shipping {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCreditNote, errCreditNoteEmpty)
+	}
+
+	creditNote := u.newCreditNote(invoice, input.Reason)
+	err = u.issue(actor, creditNote, func(issued []model.Invoice) error {
+		_, creditNotes := splitDocuments(issued)
+		remaining := remainingLines(invoice, creditNotes)
+		for _, item := range input.Items {
+			var line *model.InvoiceLine
+			if item.OrderItemID != 0 {
+				line = findItemLine(remaining, item.OrderItemID)
+			}
+			if line == nil {
+				return fmt.Errorf("%w: "+errCreditNoteUnknownItem, ErrInvalidCreditNote, item.OrderItemID)
+			}
+			if item.Quantity < 1 || item.Quantity > line.Quantity {
+				return fmt.Errorf("%w: "+errCreditNoteQuantity, ErrInvalidCreditNote, item.OrderItemID, line.Quantity)
+			}
+			creditNote.Lines = append(creditNote.Lines, creditLine(line, item.Quantity))
+		}
+		if input.Shipping {
+			line := findItemLine(remaining, 0)
+			if line == nil || line.Quantity == 0 {
+				return fmt.Errorf("%w: %s", ErrInvalidCreditNote, errCreditNoteShipping)
+			}
+			creditNote.Lines = append(creditNote.Lines, creditLine(line, line.Quantity))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creditNote, nil
+}
+
+// creditRemaining issues a credit note for everything on the invoice that
+// has not been refunded yet.
+func (u *invoiceUsecase) creditRemaining(actor Actor, invoice *model.Invoice, reason string) (*model.Invoice, error) {
+	creditNote := u.newCreditNote(invoice, reason)
+	err := u.issue(actor, creditNote, func(issued []model.Invoice) error {
+		_, creditNotes := splitDocuments(issued)
+		for _, line := range remainingLines(invoice, creditNotes) {
+			if line.Quantity > 0 {
+				creditNote.Lines = append(creditNote.Lines, creditLine(&line, line.Quantity))
+			}
+		}
+		if len(creditNote.Lines) == 0 {
+			return ErrNothingToCredit
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return creditNote, nil
+}
+
+func (u *invoiceUsecase) newDocument(kind model.InvoiceType, orderID uint) *model.Invoice {
+	issuedAt := u.now()
+	return &model.Invoice{
+		Type:          kind,
+		Year:          issuedAt.Year(),
+		IssuedAt:      issuedAt,
+		OrderID:       orderID,
+		SellerName:    u.config.SellerName,
+		SellerAddress: u.config.SellerAddress,
+		SellerTaxID:   u.config.SellerTaxID,
+	}
+}
+
+// newCreditNote starts a credit note correcting invoice, for the same buyer.
+func (u *invoiceUsecase) newCreditNote(invoice *model.Invoice, reason string) *model.Invoice {
+	creditNote := u.newDocument(model.InvoiceTypeCreditNote, invoice.OrderID)
+	creditNote.CorrectedInvoiceID = &invoice.ID
+	creditNote.CorrectedNumber = invoice.Number
+	creditNote.Currency = invoice.Currency
+	creditNote.Reason = strings.TrimSpace(reason)
+	creditNote.BuyerName = invoice.BuyerName
+	creditNote.BuyerCompany = invoice.BuyerCompany
+	creditNote.BuyerTaxID = invoice.BuyerTaxID
+	creditNote.BuyerEmail = invoice.BuyerEmail
+	creditNote.BuyerAddress = invoice.BuyerAddress
+	return creditNote
+}
+
+// issue totals the document, numbers and saves it, and records it. When
+// addLines is given it fills in the lines from the documents already issued
+// for the order. It runs under the lock that numbers the document, so
+// concurrent credit notes cannot refund the same line twice.
+func (u *invoiceUsecase) issue(actor Actor, invoice *model.Invoice, addLines func(issued []model.Invoice) error) error {
+	prepare := func(issued []model.Invoice) error {
+		if addLines != nil {
+			if err := addLines(issued); err != nil {
+				return err
+			}
+		}
+		totalDocument(invoice)
+		return nil
+	}
+
+	prefix := u.config.InvoicePrefix
+	if invoice.Type == model.InvoiceTypeCreditNote {
+		prefix = u.config.CreditNotePrefix
+	}
+	err := u.invoiceRepo.Issue(invoice, prepare, func(sequence int) string {
+		return fmt.Sprintf("%s/%d/%06d", prefix, invoice.Year, sequence)
+	})
+	if err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityInvoice, invoice.ID, nil, invoice)
+	return nil
+}
+
+// totalDocument sums the lines of the document per tax rate and in total.
+func totalDocument(invoice *model.Invoice) {
+	var summary model.TaxSummary
+	for _, line := range invoice.Lines {
+		summary.Add(line.TaxRate, line.Net, line.Tax)
+	}
+	amounts, net, tax := summary.Amounts()
+	invoice.TaxLines = nil
+	for _, a := range amounts {
+		invoice.TaxLines = append(invoice.TaxLines, model.InvoiceTaxLine{TaxAmounts: a})
+	}
+	invoice.NetTotal, invoice.TaxTotal = net, tax
+	invoice.Total = model.RoundMoney(net + tax)
+}
+
+func invoiceLine(orderItemID *uint, name string, quantity int, rate, net, tax float64) model.InvoiceLine {
+	line := model.InvoiceLine{
+		OrderItemID: orderItemID,
+		Name:        name,
+		Quantity:    quantity,
+		TaxRate:     rate,
+		Net:         model.RoundMoney(net),
+		Tax:         model.RoundMoney(tax),
+		Gross:       model.RoundMoney(net + tax),
+	}
+	if quantity != 0 {
+		line.UnitNet = model.RoundMoney(net / float64(quantity))
+	}
+	return line
+}
+
+// remainingLines returns the invoice lines less what credit notes have
+// refunded so far.
+func remainingLines(invoice *model.Invoice, creditNotes []model.Invoice) []model.InvoiceLine {
+	remaining := make([]model.InvoiceLine, len(invoice.Lines))
+	copy(remaining, invoice.Lines)
+	for _, creditNote := range creditNotes {
+		for _, credited := range creditNote.Lines {
+			line := findItemLine(remaining, lineItemID(credited))
+			if line == nil {
+				continue
+			}
+			line.Quantity += credited.Quantity
+			line.Net = model.RoundMoney(line.Net + credited.Net)
+			line.Tax = model.RoundMoney(line.Tax + credited.Tax)
+		}
+	}
+	return remaining
+}
+
+// creditLine refunds quantity units of line, which holds what is left to
+// refund, and takes them off line. Refunding the rest of a line refunds its
+// exact remaining amounts, so rounding never leaves cents behind.
+func creditLine(line *model.InvoiceLine, quantity int) model.InvoiceLine {
+	net, tax := line.Net, line.Tax
+	if quantity < line.Quantity {
+		net = model.RoundMoney(line.Net * float64(quantity) / float64(line.Quantity))
+		tax = model.RoundMoney(line.Tax * float64(quantity) / float64(line.Quantity))
+	}
+	line.Quantity -= quantity
+	line.Net = model.RoundMoney(line.Net - net)
+	line.Tax = model.RoundMoney(line.Tax - tax)
+
+	credit := invoiceLine(line.OrderItemID, line.Name, -quantity, line.TaxRate, -net, -tax)
+	credit.UnitNet = line.UnitNet
+	return credit
+}
+
+// findItemLine finds the line of an order item; ID 0 finds the shipping line.
+func findItemLine(lines []model.InvoiceLine, orderItemID uint) *model.InvoiceLine {
+	for i := range lines {
+		if lineItemID(lines[i]) == orderItemID {
+			return &lines[i]
+		}
+	}
+	return nil
+}
+
+func lineItemID(line model.InvoiceLine) uint {
+	if line.OrderItemID == nil {
+		return 0
+	}
+	return *line.OrderItemID
+}
+
+// buyerOf returns the name and email of whoever placed the order.
+func buyerOf(order *model.Order) (name, email string) {
+	if order.User != nil {
+		return strings.TrimSpace(order.User.Name + " " + order.User.Surname), order.User.Email
+	}
+	return strings.TrimSpace(order.GuestName + " " + order.GuestSurname), order.GuestEmail
+}
+
+func formatAddress(addr model.Address) string {
+	street := strings.TrimSpace(addr.Street + " " + addr.Number)
+	city := strings.TrimSpace(addr.Postcode + " " + addr.City)
+	var parts []string
+	for _, part := range []string{street, city, addr.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (u *invoiceUsecase) Subscribe(outbox OutboxUsecase) {
+	outbox.Subscribe(model.EventOrderPaid, u.invoicePaidOrder)
+	outbox.Subscribe(model.EventOrderCancelled, u.creditCancelledOrder)
+}
+
+func (u *invoiceUsecase) invoicePaidOrder(event model.OutboxEvent) error {
+	var paid model.OrderPaid
+	if err := event.Decode(&paid); err != nil {
+		return err
+	}
+	_, err := u.Issue(eventActor(event), paid.OrderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+// creditCancelledOrder refunds the rest of a cancelled order's invoice.
+// Orders cancelled before they were invoiced need no credit note.
+func (u *invoiceUsecase) creditCancelledOrder(event model.OutboxEvent) error {
+	var cancelled model.OrderCancelled
+	if err := event.Decode(&cancelled); err != nil {
+		return err
+	}
+	invoice, _, err := u.documents(cancelled.OrderID)
+	if err != nil || invoice == nil {
+		return err
+	}
+	_, err = u.creditRemaining(eventActor(event), invoice, creditNoteCancelReason)
+	if errors.Is(err, ErrNothingToCredit) {
+		return nil
+	}
+	return err
+}
+
+// eventActor is the actor recorded for changes made by event handlers.
+func eventActor(event model.OutboxEvent) Actor {
+	return Actor{Role: systemRole, RequestID: "event:" + event.Type}
+}
@@ -0,0 +1,304 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryInvoices numbers invoices the way the GORM repository does: one
+// sequence per type and year.
+type memoryInvoices struct {
+	invoices  []model.Invoice
+	sequences map[string]int
+}
+
+func (m *memoryInvoices) FindByID(id uint) (*model.Invoice, error) {
+	for i := range m.invoices {
+		if m.invoices[i].ID == id {
+			invoice := m.invoices[i]
+			return &invoice, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryInvoices) FindByOrderID(orderID uint) ([]model.Invoice, error) {
+	var invoices []model.Invoice
+	for _, invoice := range m.invoices {
+		if invoice.OrderID == orderID {
+			invoices = append(invoices, invoice)
+		}
+	}
+	return invoices, nil
+}
+
+func (m *memoryInvoices) Issue(invoice *model.Invoice, prepare func(issued []model.Invoice) error, number func(sequence int) string) error {
+	for _, existing := range m.invoices {
+		if invoice.Type == model.InvoiceTypeInvoice && existing.Type == model.InvoiceTypeInvoice && existing.OrderID == invoice.OrderID {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	issued, _ := m.FindByOrderID(invoice.OrderID)
+	if err := prepare(issued); err != nil {
+		return err
+	}
+	if m.sequences == nil {
+		m.sequences = map[string]int{}
+	}
+	key := fmt.Sprintf("%s/%d", invoice.Type, invoice.Year)
+	m.sequences[key]++
+	invoice.ID = uint(len(m.invoices) + 1)
+	invoice.Sequence = m.sequences[key]
+	invoice.Number = number(invoice.Sequence)
+	m.invoices = append(m.invoices, *invoice)
+	return nil
+}
+
+// paidOrder has two books at 23% VAT, three packs of tea at 8% and
+// shipping at 23%, 167.70 in total.
+func paidOrder() *model.Order {
+	paidAt := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	return &model.Order{
+		ID:     1,
+		UserID: uintPtr(2),
+		User:   &model.User{ID: 2, Name: "Ann", Surname: "Smith", Email: "ann@example.com"},
+		PaidAt: &paidAt,
+		ShippingAddress: model.Address{
+			Street: "Long", Number: "5", Postcode: "00-001", City: "Warsaw", Country: "Poland",
+			Company: "Smith Ltd", TaxID: "PL1234567890",
+		},
+		ShippingMethodName: "Courier",
+		ShippingCost:       12.30,
+		ShippingTax:        2.30,
+		ShippingTaxRate:    23,
+		Items: []model.OrderItem{
+			{ID: 10, Name: "Book", Quantity: 2, TaxRate: 23, NetSubtotal: 100, TaxAmount: 23, Subtotal: 123},
+			{ID: 11, Name: "Tea", Quantity: 3, TaxRate: 8, NetSubtotal: 30, TaxAmount: 2.40, Subtotal: 32.40},
+		},
+	}
+}
+
+func setupInvoiceUsecase(order *model.Order) (*invoiceUsecase, *memoryInvoices, *MockOrderRepository) {
+	invoices := &memoryInvoices{}
+	orderRepo := new(MockOrderRepository)
+	orderRepo.On("FindByID", order.ID).Return(order, nil)
+	uc := &invoiceUsecase{
+		invoiceRepo: invoices,
+		orderRepo:   orderRepo,
+		auditor:     &recordingAuditor{},
+		config:      DefaultInvoiceConfig(),
+		now:         func() time.Time { return time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC) },
+	}
+	return uc, invoices, orderRepo
+}
+
+func TestInvoiceUsecaseIssue(t *testing.T) {
+	order := paidOrder()
+	order.PaidAt = nil
+	uc, _, _ := setupInvoiceUsecase(order)
+
+	_, err := uc.Issue(testActor, 1)
+	// Assertion 600: Only paid orders are invoiced
+	assert.ErrorIs(t, err, ErrOrderNotPaid)
+
+	order.PaidAt = paidOrder().PaidAt
+	uc.config.SellerTaxID = "PL0000000000"
+	invoice, err := uc.Issue(testActor, 1)
+	// Assertion 601: The invoice snapshots the seller and the buyer, company and tax ID included
+	assert.NoError(t, err)
+	assert.Equal(t, "INV/2026/000001", invoice.Number)
+	assert.Equal(t, "E-Commerce Store", invoice.SellerName)
+	assert.Equal(t, "PL0000000000", invoice.SellerTaxID)
+	assert.Equal(t, "Ann Smith", invoice.BuyerName)
+	assert.Equal(t, "Smith Ltd", invoice.BuyerCompany)
+	assert.Equal(t, "PL1234567890", invoice.BuyerTaxID)
+	assert.Equal(t, "Long 5, 00-001 Warsaw, Poland", invoice.BuyerAddress)
+
+	// Assertion 602: Order items and the shipping become lines, totalled per VAT rate
+	assert.Len(t, invoice.Lines, 3)
+	assert.Equal(t, 50.0, invoice.Lines[0].UnitNet)
+	assert.Equal(t, "Shipping: Courier", invoice.Lines[2].Name)
+	assert.Nil(t, invoice.Lines[2].OrderItemID)
+	assert.Equal(t, 10.0, invoice.Lines[2].Net)
+	assert.Equal(t, []model.InvoiceTaxLine{
+		{TaxAmounts: model.TaxAmounts{Rate: 23, Net: 110, Tax: 25.30, Gross: 135.30}},
+		{TaxAmounts: model.TaxAmounts{Rate: 8, Net: 30, Tax: 2.40, Gross: 32.40}},
+	}, invoice.TaxLines)
+	assert.Equal(t, 140.0, invoice.NetTotal)
+	assert.Equal(t, 27.70, invoice.TaxTotal)
+	assert.Equal(t, 167.70, invoice.Total)
+
+	again, err := uc.GetForOrder(testActor, 1)
+	// Assertion 603: An order has one invoice
+	assert.NoError(t, err)
+	assert.Equal(t, invoice.ID, again.ID)
+	again, err = uc.Issue(testActor, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, invoice.Number, again.Number)
+}
+
+func TestInvoiceUsecaseNumbering(t *testing.T) {
+	uc, invoices, orderRepo := setupInvoiceUsecase(paidOrder())
+	second := paidOrder()
+	second.ID = 2
+	orderRepo.On("FindByID", uint(2)).Return(second, nil)
+	third := paidOrder()
+	third.ID = 3
+	orderRepo.On("FindByID", uint(3)).Return(third, nil)
+
+	_, err := uc.GetForOrder(testActor, 1)
+	assert.NoError(t, err)
+	creditNote, err := uc.IssueCreditNote(testActor, 1, CreditNoteInput{Shipping: true})
+	assert.NoError(t, err)
+	_, err = uc.Issue(testActor, 2)
+	assert.NoError(t, err)
+	uc.now = func() time.Time { return time.Date(2027, 1, 1, 0, 5, 0, 0, time.UTC) }
+	_, err = uc.Issue(testActor, 3)
+	assert.NoError(t, err)
+
+	// Assertion 604: Invoices and credit notes are numbered separately, restarting every year
+	numbers := make([]string, 0, len(invoices.invoices))
+	for _, invoice := range invoices.invoices {
+		numbers = append(numbers, invoice.Number)
+	}
+	assert.Equal(t, []string{"INV/2026/000001", "CN/2026/000001", "INV/2026/000002", "INV/2027/000001"}, numbers)
+	assert.Equal(t, "INV/2026/000001", creditNote.CorrectedNumber)
+}
+
+func TestInvoiceUsecaseCreditNotes(t *testing.T) {
+	uc, _, _ := setupInvoiceUsecase(paidOrder())
+
+	_, err := uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 1}}})
+	// Assertion 605: A credit note needs an invoice to correct
+	assert.ErrorIs(t, err, ErrOrderNotInvoiced)
+
+	invoice, err := uc.GetForOrder(testActor, 1)
+	assert.NoError(t, err)
+	creditNote, err := uc.IssueCreditNote(testActor, 1, CreditNoteInput{
+		Reason: " Damaged ",
+		Items:  []CreditNoteItem{{OrderItemID: 10, Quantity: 1}, {OrderItemID: 11, Quantity: 1}},
+	})
+	// Assertion 606: A partial refund credits the returned units with negative amounts
+	assert.NoError(t, err)
+	assert.Equal(t, model.InvoiceTypeCreditNote, creditNote.Type)
+	assert.Equal(t, "CN/2026/000001", creditNote.Number)
+	assert.Equal(t, invoice.ID, *creditNote.CorrectedInvoiceID)
+	assert.Equal(t, "Damaged", creditNote.Reason)
+	assert.Equal(t, "Smith Ltd", creditNote.BuyerCompany)
+	assert.Equal(t, -1, creditNote.Lines[0].Quantity)
+	assert.Equal(t, 50.0, creditNote.Lines[0].UnitNet)
+	assert.Equal(t, -61.50, creditNote.Lines[0].Gross)
+	assert.Equal(t, -10.80, creditNote.Lines[1].Gross)
+	assert.Equal(t, -72.30, creditNote.Total)
+
+	// Assertion 607: Nothing is credited beyond what is left on the invoice
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 2}}})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 1}, {OrderItemID: 10, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 99, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 0, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Shipping: true})
+	assert.NoError(t, err)
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Shipping: true})
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+}
+
+func TestInvoiceUsecaseOrderEvents(t *testing.T) {
+	uc, invoices, _ := setupInvoiceUsecase(paidOrder())
+	cancelled, _ := json.Marshal(model.OrderCancelled{OrderID: 1, UserID: 2})
+	cancelEvent := model.OutboxEvent{ID: 2, Type: model.EventOrderCancelled, Payload: cancelled}
+
+	// Assertion 608: Cancelling an order that was never invoiced issues no credit note
+	assert.NoError(t, uc.creditCancelledOrder(cancelEvent))
+	assert.Empty(t, invoices.invoices)
+
+	paid, _ := json.Marshal(model.OrderPaid{OrderID: 1, UserID: 2})
+	assert.NoError(t, uc.invoicePaidOrder(model.OutboxEvent{ID: 1, Type: model.EventOrderPaid, Payload: paid}))
+	_, err := uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 1}}})
+	assert.NoError(t, err)
+	assert.NoError(t, uc.creditCancelledOrder(cancelEvent))
+	assert.NoError(t, uc.creditCancelledOrder(cancelEvent))
+
+	// Assertion 609: Cancelling a paid order credits exactly what had not been refunded yet
+	docs, err := uc.GetDocuments(1)
+	assert.NoError(t, err)
+	assert.Len(t, docs, 3)
+	assert.Equal(t, "INV/2026/000001", docs[0].Number)
+	assert.Equal(t, creditNoteCancelReason, docs[2].Reason)
+	assert.Len(t, docs[2].Lines, 3)
+	assert.Equal(t, -106.20, docs[2].Total)
+	sum := 0.0
+	for _, doc := range docs {
+		sum += doc.Total
+	}
+	assert.Equal(t, 0.0, model.RoundMoney(sum))
+	assert.Equal(t, systemRole, uc.auditor.(*recordingAuditor).records[2].actor.Role)
+}
+
+// racingInvoices runs race right before the next document is issued, as if
+// another request had issued its own document in the meantime.
+type racingInvoices struct {
+	*memoryInvoices
+	race func()
+}
+
+func (r *racingInvoices) Issue(invoice *model.Invoice, prepare func(issued []model.Invoice) error, number func(sequence int) string) error {
+	if race := r.race; race != nil {
+		r.race = nil
+		race()
+	}
+	return r.memoryInvoices.Issue(invoice, prepare, number)
+}
+
+func TestInvoiceUsecaseConcurrentCreditNotes(t *testing.T) {
+	cancelled, _ := json.Marshal(model.OrderCancelled{OrderID: 1, UserID: 2})
+	cancelEvent := model.OutboxEvent{ID: 2, Type: model.EventOrderCancelled, Payload: cancelled}
+
+	uc, invoices, _ := setupInvoiceUsecase(paidOrder())
+	invoice, err := uc.GetForOrder(testActor, 1)
+	assert.NoError(t, err)
+	racing := &racingInvoices{memoryInvoices: invoices}
+	uc.invoiceRepo = racing
+
+	racing.race = func() { assert.NoError(t, uc.creditCancelledOrder(cancelEvent)) }
+	_, err = uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 1}}})
+	// Assertion 846: A refund that loses the race against the cancellation fails instead of crediting again
+	assert.ErrorIs(t, err, ErrInvalidCreditNote)
+	assert.Len(t, invoices.invoices, 2)
+
+	racing.race = func() { assert.NoError(t, uc.creditCancelledOrder(cancelEvent)) }
+	_, err = uc.creditRemaining(testActor, invoice, creditNoteCancelReason)
+	// Assertion 847: Crediting the rest of a fully credited invoice fails once the lock is held
+	assert.ErrorIs(t, err, ErrNothingToCredit)
+	assert.Len(t, invoices.invoices, 2)
+
+	uc, invoices, _ = setupInvoiceUsecase(paidOrder())
+	_, err = uc.GetForOrder(testActor, 1)
+	assert.NoError(t, err)
+	racing = &racingInvoices{memoryInvoices: invoices}
+	uc.invoiceRepo = racing
+	racing.race = func() {
+		_, err := uc.IssueCreditNote(testActor, 1, CreditNoteInput{Items: []CreditNoteItem{{OrderItemID: 10, Quantity: 2}}})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, uc.creditCancelledOrder(cancelEvent))
+	// Assertion 848: A cancellation racing a refund credits only what the refund left
+	assert.Len(t, invoices.invoices, 3)
+	sum := 0.0
+	for _, doc := range invoices.invoices {
+		sum += doc.Total
+	}
+	assert.Equal(t, 0.0, model.RoundMoney(sum))
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+)
+
+// Names of the built-in maintenance jobs, as used by POST /jobs/:name/run.
+const (
+	JobCancelUnpaidOrders = "cancel-unpaid-orders"
+	JobPurgeExpiredTokens = "purge-expired-tokens"
+	JobPruneStaleCarts    = "prune-stale-carts"
+)
+
+// systemRole marks audit entries written by background jobs rather than a user.
+const systemRole = "system"
+
+// MaintenanceConfig holds the thresholds of the maintenance jobs.
+type MaintenanceConfig struct {
+	// UnpaidOrderTimeout is how long an order may stay PENDING before it is cancelled.
+	UnpaidOrderTimeout time.Duration
+	// StaleCartAge is how long an empty or guest cart may go untouched before it is removed.
+	StaleCartAge time.Duration
+	// UnusedGuestCartAge is how long a guest cart whose token never came back
+	// is kept.
+	UnusedGuestCartAge time.Duration
+}
+
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		UnpaidOrderTimeout: 48 * time.Hour,
+		StaleCartAge:       30 * 24 * time.Hour,
+		UnusedGuestCartAge: 24 * time.Hour,
+	}
+}
+
+type MaintenanceUsecase interface {
+	// CancelUnpaidOrders cancels PENDING orders older than the configured
+	// timeout, restoring their stock, and returns how many were cancelled.
+	CancelUnpaidOrders(ctx context.Context) (int, error)
+	// PurgeExpiredTokens deletes email change tokens that expired unconfirmed.
+	PurgeExpiredTokens(ctx context.Context) (int64, error)
+	// PruneStaleCarts deletes empty carts and guest carts nobody touched within
+	// the configured age, and guest carts never used after they were created.
+	PruneStaleCarts(ctx context.Context) (int64, error)
+	// Jobs returns the tasks above as scheduler jobs.
+	Jobs() []Job
+}
+
+type maintenanceUsecase struct {
+	orderRepo       repository.OrderRepository
+	emailChangeRepo repository.EmailChangeRepository
+	cartRepo        repository.CartRepository
+	orderUC         OrderUsecase
+	config          MaintenanceConfig
+	now             func() time.Time
+}
+
+func NewMaintenanceUsecase(
+	orderRepo repository.OrderRepository,
+	emailChangeRepo repository.EmailChangeRepository,
+	cartRepo repository.CartRepository,
+	orderUC OrderUsecase,
+	config MaintenanceConfig,
+) MaintenanceUsecase {
+	return &maintenanceUsecase{
+		orderRepo:       orderRepo,
+		emailChangeRepo: emailChangeRepo,
+		cartRepo:        cartRepo,
+		orderUC:         orderUC,
+		config:          config,
+		now:             time.Now,
+	}
+}
+
+func (u *maintenanceUsecase) CancelUnpaidOrders(ctx context.Context) (int, error) {
+	cutoff := u.now().Add(-u.config.UnpaidOrderTimeout)
+	orders, err := u.orderRepo.FindWithFilters(map[string]string{
+		"status":         string(model.StatusPending),
+		"created_before": cutoff.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	actor := systemActor(JobCancelUnpaidOrders)
+	cancelled := 0
+	var errs []error
+	for _, order := range orders {
+		if err := ctx.Err(); err != nil {
+			return cancelled, errors.Join(append(errs, err)...)
+		}
+		if _, err := u.orderUC.CancelOrder(actor, order.ID); err != nil {
+			// One order that cannot be cancelled must not hold up the rest;
+			// the next run retries it.
+			log.Printf("maintenance: order %d: %v", order.ID, err)
+			errs = append(errs, fmt.Errorf("failed to cancel order %d: %w", order.ID, err))
+			continue
+		}
+		cancelled++
+	}
+	return cancelled, errors.Join(errs...)
+}
+
+func (u *maintenanceUsecase) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	return u.emailChangeRepo.DeleteExpired(u.now())
+}
+
+func (u *maintenanceUsecase) PruneStaleCarts(ctx context.Context) (int64, error) {
+	now := u.now()
+	stale, err := u.cartRepo.DeleteStale(now.Add(-u.config.StaleCartAge))
+	if err != nil {
+		return stale, err
+	}
+	unused, err := u.cartRepo.DeleteUnusedGuests(now.Add(-u.config.UnusedGuestCartAge))
+	return stale + unused, err
+}
+
+func (u *maintenanceUsecase) Jobs() []Job {
+	return []Job{
+		{
+			Name:        JobCancelUnpaidOrders,
+			Description: fmt.Sprintf("Cancel orders left PENDING for more than %s", u.config.UnpaidOrderTimeout),
+			Schedule:    "*/15 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.CancelUnpaidOrders(ctx)
+				return fmt.Sprintf("cancelled %d orders", n), err
+			},
+		},
+		{
+			Name:        JobPurgeExpiredTokens,
+			Description: "Delete expired, unconfirmed email change tokens",
+			Schedule:    "0 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.PurgeExpiredTokens(ctx)
+				return fmt.Sprintf("deleted %d tokens", n), err
+			},
+		},
+		{
+			Name: JobPruneStaleCarts,
+			Description: fmt.Sprintf("Delete empty and guest carts untouched for more than %s, and unused guest carts after %s",
+				u.config.StaleCartAge, u.config.UnusedGuestCartAge),
+			Schedule: "30 3 * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.PruneStaleCarts(ctx)
+				return fmt.Sprintf("deleted %d carts", n), err
+			},
+		},
+	}
+}
+
+// systemActor attributes audit entries to the named job.
+func systemActor(job string) Actor {
+	return Actor{Role: systemRole, RequestID: "job:" + job}
+}
@@ -1,7 +1,6 @@
 package usecase
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -19,14 +18,19 @@ const (
 	errFailedToGetAddress   = "failed to get address: %w"
 	errFailedToGetProduct   = "failed to get product: %w"
 	errFailedToUpdateOrder  = "failed to update order: %w"
+	errFailedToApplyTaxes   = "failed to apply taxes: %w"
 	errFailedToCreateOrder  = "failed to create order: %w"
 	errFailedToUpdateStock  = "failed to update product stock: %w"
 	errFailedToRestoreStock = "failed to restore product stock: %w"
 	errFailedToClearCart    = "failed to clear cart: %w"
 	errFailedToUpdateCart   = "failed to update cart: %w"
-	errCartEmpty            = "cart is empty"
-	errAddressNotFound      = "shipping address not found"
-	errNotEnoughStock       = "not enough stock for product %s"
+	errNotEnoughStock       = "%w for product %s"
+)
+
+var (
+	ErrCartEmpty               = NewError(KindValidation, "cart_empty", "cart is empty")
+	ErrShippingAddressNotFound = NewError(KindNotFound, "shipping_address_not_found", "shipping address not found")
+	ErrInsufficientStock       = NewError(KindInsufficientStock, "insufficient_stock", "not enough stock")
 )
 
 type OrderUsecase interface {
@@ -34,9 +38,23 @@ type OrderUsecase interface {
 	GetByUserID(userID uint) ([]model.Order, error)
 	GetAll() ([]model.Order, error)
 	GetWithFilters(filters map[string]string) ([]model.Order, error)
-	CreateFromCart(userID uint, paymentMethod model.PaymentMethod, shippingAddressID uint) (*model.Order, error)
-	UpdateStatus(id uint, status model.OrderStatus) (*model.Order, error)
-	CancelOrder(id uint) (*model.Order, error)
+	// CreateFromCart places an order from the user's cart, priced in
+	// currency ("" for the base currency), with the item names in locale
+	// ("" for the default one). shippingMethodID may be 0 only while no
+	// shipping methods are set up.
+	CreateFromCart(actor Actor, userID uint, paymentMethod model.PaymentMethod, shippingAddressID, shippingMethodID uint, currency, locale string) (*model.Order, error)
+	// CreateFromGuestCart places an order for a buyer without an account from
+	// the guest cart behind cartToken. The address is stored for this order only.
+	CreateFromGuestCart(actor Actor, cartToken string, contact GuestContact, paymentMethod model.PaymentMethod, address model.Address, shippingMethodID uint, currency, locale string) (*model.Order, error)
+	UpdateStatus(actor Actor, id uint, status model.OrderStatus) (*model.Order, error)
+	CancelOrder(actor Actor, id uint) (*model.Order, error)
+}
+
+// GuestContact identifies the buyer of a guest order.
+type GuestContact struct {
+	Email   string
+	Name    string
+	Surname string
 }
 
 type orderUsecase struct {
@@ -46,6 +64,13 @@ type orderUsecase struct {
 	productRepo  repository.ProductRepository
 	userRepo     repository.UserRepository
 	addressRepo  repository.AddressRepository
+	transactor   repository.Transactor
+	shipping     ShippingQuoter
+	tax          TaxCalculator
+	prices       PriceConverter
+	pricing      PriceResolver
+	names        ProductLocalizer
+	auditor      Auditor
 }
 
 func NewOrderUsecase(
@@ -55,6 +80,13 @@ func NewOrderUsecase(
 	productRepo repository.ProductRepository,
 	userRepo repository.UserRepository,
 	addressRepo repository.AddressRepository,
+	transactor repository.Transactor,
+	shipping ShippingQuoter,
+	tax TaxCalculator,
+	prices PriceConverter,
+	pricing PriceResolver,
+	names ProductLocalizer,
+	auditor Auditor,
 ) OrderUsecase {
 	return &orderUsecase{
 		orderRepo:    orderRepo,
@@ -63,6 +95,13 @@ func NewOrderUsecase(
 		productRepo:  productRepo,
 		userRepo:     userRepo,
 		addressRepo:  addressRepo,
+		transactor:   transactor,
+		shipping:     shipping,
+		tax:          tax,
+		prices:       prices,
+		pricing:      pricing,
+		names:        names,
+		auditor:      auditor,
 	}
 }
 
@@ -97,13 +136,13 @@ func (u *orderUsecase) GetWithFilters(filters map[string]string) ([]model.Order,
 	return u.orderRepo.FindWithFilters(filters)
 }
 
-func (uc *orderUsecase) CreateFromCart(userID uint, paymentMethod model.PaymentMethod, shippingAddressID uint) (*model.Order, error) {
+func (uc *orderUsecase) CreateFromCart(actor Actor, userID uint, paymentMethod model.PaymentMethod, shippingAddressID, shippingMethodID uint, currency, locale string) (*model.Order, error) {
 	cart, err := uc.cartRepo.FindByUserID(userID)
 	if err != nil {
 		return nil, fmt.Errorf(errFailedToGetCart, err)
 	}
 	if cart == nil || len(cart.Items) == 0 {
-		return nil, errors.New(errCartEmpty)
+		return nil, ErrCartEmpty
 	}
 
 	address, err := uc.addressRepo.FindByID(shippingAddressID)
@@ -111,62 +150,182 @@ func (uc *orderUsecase) CreateFromCart(userID uint, paymentMethod model.PaymentM
 		return nil, fmt.Errorf(errFailedToGetAddress, err)
 	}
 	if address == nil {
-		return nil, errors.New(errAddressNotFound)
-	}
-
-	var orderItems []model.OrderItem
-	total := 0.0
-
-	for _, item := range cart.Items {
-		product, err := uc.productRepo.FindByID(item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf(errFailedToGetProduct, err)
-		}
-		if product.Stock < item.Quantity {
-			return nil, fmt.Errorf(errNotEnoughStock, product.Name)
-		}
-
-		product.Stock -= item.Quantity
-		if err := uc.productRepo.Update(product); err != nil {
-			return nil, fmt.Errorf(errFailedToUpdateStock, err)
-		}
-
-		orderItems = append(orderItems, model.OrderItem{
-			ProductID: product.ID,
-			Name:      product.Name,
-			UnitPrice: product.Price,
-			Quantity:  item.Quantity,
-			Subtotal:  product.Price * float64(item.Quantity),
-		})
-		total += product.Price * float64(item.Quantity)
+		return nil, ErrShippingAddressNotFound
 	}
 
 	order := &model.Order{
-		UserID:            userID,
+		UserID:            &userID,
 		Status:            model.StatusPending,
 		PaymentMethod:     paymentMethod,
 		ShippingAddressID: shippingAddressID,
-		Items:             orderItems,
-		Total:             total,
+		Currency:          currency,
+		Locale:            locale,
 	}
+	return uc.placeOrder(actor, cart, order, *address, shippingMethodID)
+}
 
-	if err := uc.orderRepo.Create(order); err != nil {
-		return nil, fmt.Errorf(errFailedToCreateOrder, err)
+func (uc *orderUsecase) CreateFromGuestCart(actor Actor, cartToken string, contact GuestContact, paymentMethod model.PaymentMethod, address model.Address, shippingMethodID uint, currency, locale string) (*model.Order, error) {
+	cart, err := uc.cartRepo.FindByGuestTokenHash(hashToken(cartToken))
+	if err != nil {
+		return nil, fmt.Errorf(errFailedToGetCart, err)
+	}
+	if cart == nil {
+		return nil, ErrInvalidCartToken
+	}
+	if len(cart.Items) == 0 {
+		return nil, ErrCartEmpty
 	}
 
-	if err := uc.cartItemRepo.ClearCart(cart.ID); err != nil {
-		return nil, fmt.Errorf(errFailedToClearCart, err)
+	// The address is created together with the order.
+	address.ID = 0
+	order := &model.Order{
+		Status:          model.StatusPending,
+		PaymentMethod:   paymentMethod,
+		ShippingAddress: address,
+		GuestEmail:      contact.Email,
+		GuestName:       contact.Name,
+		GuestSurname:    contact.Surname,
+		Currency:        currency,
+		Locale:          locale,
 	}
+	return uc.placeOrder(actor, cart, order, address, shippingMethodID)
+}
 
-	cart.Total = 0
-	if err := uc.cartRepo.Update(cart); err != nil {
-		return nil, fmt.Errorf(errFailedToUpdateCart, err)
+// placeOrder turns the cart into the order: it takes the items at current
+// prices, adds the shipping cost, reserves stock and empties the cart in one
+// transaction. Prices are converted to the order's currency at the current
+// rate, which is stored with the order, and item names are kept in the
+// order's locale.
+func (uc *orderUsecase) placeOrder(actor Actor, cart *model.Cart, order *model.Order, address model.Address, shippingMethodID uint) (*model.Order, error) {
+	currency, err := NormalizeCurrency(order.Currency)
+	if err != nil {
+		return nil, err
 	}
+	if currency == "" {
+		currency = uc.prices.BaseCurrency()
+	}
+	if order.ExchangeRate, err = uc.prices.Rate(currency); err != nil {
+		return nil, err
+	}
+	order.Currency = currency
+
+	err = uc.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		var events []model.DomainEvent
+		var parcel Parcel
+		created := model.OrderCreated{
+			UserID:        order.OwnerID(),
+			PaymentMethod: order.PaymentMethod,
+			Currency:      order.Currency,
+			ExchangeRate:  order.ExchangeRate,
+		}
+
+		for _, item := range cart.Items {
+			product, err := repos.Products.FindByID(item.ProductID)
+			if err != nil {
+				return fmt.Errorf(errFailedToGetProduct, err)
+			}
+			if product.Stock < item.Quantity {
+				return fmt.Errorf(errNotEnoughStock, ErrInsufficientStock, product.Name)
+			}
+
+			// Items are charged the sale price if one is running. Shipping
+			// rates are in the base currency, so the parcel is valued in it.
+			price, err := uc.pricing.EffectivePrice(product)
+			if err != nil {
+				return err
+			}
+			productRate, err := uc.prices.Rate(product.Currency)
+			if err != nil {
+				return err
+			}
+			basePrice := model.ConvertAmount(price, productRate, 1)
+			unitPrice := model.ConvertAmount(price, productRate, order.ExchangeRate)
+
+			previousStock := product.Stock
+			product.Stock -= item.Quantity
+			if err := repos.Products.Update(product); err != nil {
+				return fmt.Errorf(errFailedToUpdateStock, err)
+			}
+			if event, ok := stockLowEvent(product, previousStock); ok {
+				events = append(events, event)
+			}
+
+			order.Items = append(order.Items, model.OrderItem{
+				ProductID: product.ID,
+				Name:      product.Name,
+				UnitPrice: unitPrice,
+				Quantity:  item.Quantity,
+				TaxClass:  product.TaxClass,
+			})
+			parcel.Add(*product, item.Quantity, basePrice)
+			created.Items = append(created.Items, model.OrderCreatedItem{
+				ProductID: product.ID,
+				Quantity:  item.Quantity,
+				UnitPrice: unitPrice,
+			})
+		}
+		if err := uc.localizeItemNames(order); err != nil {
+			return err
+		}
+
+		shipping, err := uc.shipping.Quote(shippingMethodID, address, parcel)
+		if err != nil {
+			return err
+		}
+		if shipping != nil {
+			order.ShippingMethodID = &shipping.MethodID
+			order.ShippingMethodName = shipping.Name
+			order.ShippingCost = model.ConvertAmount(shipping.Cost, 1, order.ExchangeRate)
+		}
+
+		// Taxes follow the country the order ships to and set the totals.
+		if err := uc.tax.ApplyTaxes(order, address.Country); err != nil {
+			return fmt.Errorf(errFailedToApplyTaxes, err)
+		}
 
+		if err := repos.Orders.Create(order); err != nil {
+			return fmt.Errorf(errFailedToCreateOrder, err)
+		}
+
+		if err := repos.CartItems.ClearCart(cart.ID); err != nil {
+			return fmt.Errorf(errFailedToClearCart, err)
+		}
+
+		cart.Total = 0
+		cart.AbandonedAt = nil
+		if err := repos.Carts.Update(cart); err != nil {
+			return fmt.Errorf(errFailedToUpdateCart, err)
+		}
+
+		created.OrderID = order.ID
+		created.Total = order.Total
+		return appendEvents(repos.Outbox, append([]model.DomainEvent{created}, events...)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.auditor.Record(actor, model.AuditCreate, model.AuditEntityOrder, order.ID, nil, order)
 	return order, nil
 }
 
-func (uc *orderUsecase) UpdateStatus(id uint, status model.OrderStatus) (*model.Order, error) {
+// localizeItemNames replaces the item names with the names of the products
+// in the order's locale, so the order keeps them as the buyer saw them.
+func (uc *orderUsecase) localizeItemNames(order *model.Order) error {
+	products := make([]model.Product, len(order.Items))
+	for i, item := range order.Items {
+		products[i] = model.Product{ID: item.ProductID, Name: item.Name}
+	}
+	if err := uc.names.LocalizeProducts(products, order.Locale); err != nil {
+		return err
+	}
+	for i := range order.Items {
+		order.Items[i].Name = products[i].Name
+	}
+	return nil
+}
+
+func (uc *orderUsecase) UpdateStatus(actor Actor, id uint, status model.OrderStatus) (*model.Order, error) {
 	order, err := uc.orderRepo.FindByID(id)
 	if err != nil {
 		return nil, fmt.Errorf(errFailedToGetOrder, err)
@@ -174,23 +333,40 @@ func (uc *orderUsecase) UpdateStatus(id uint, status model.OrderStatus) (*model.
 	if order == nil {
 		return nil, gorm.ErrRecordNotFound
 	}
+	before := *order
 
 	order.Status = status
-	if status == model.StatusPaid {
-		now := time.Now()
+	now := time.Now()
+	var events []model.DomainEvent
+	switch status {
+	case model.StatusPaid:
 		order.PaidAt = &now
-	} else if status == model.StatusShipped {
-		now := time.Now()
+		if before.Status != model.StatusPaid {
+			events = append(events, model.OrderPaid{OrderID: order.ID, UserID: order.OwnerID(), Total: order.Total, Currency: order.Currency, PaidAt: now})
+		}
+	case model.StatusShipped:
 		order.ShippedAt = &now
+	case model.StatusCancelled:
+		if before.Status != model.StatusCancelled {
+			order.CancelledAt = &now
+			events = append(events, model.OrderCancelled{OrderID: order.ID, UserID: order.OwnerID(), Total: order.Total, Currency: order.Currency, CancelledAt: now})
+		}
 	}
 
-	if err := uc.orderRepo.Update(order); err != nil {
-		return nil, fmt.Errorf(errFailedToUpdateOrder, err)
+	err = uc.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if err := repos.Orders.Update(order); err != nil {
+			return fmt.Errorf(errFailedToUpdateOrder, err)
+		}
+		return appendEvents(repos.Outbox, events...)
+	})
+	if err != nil {
+		return nil, err
 	}
+	uc.auditor.Record(actor, model.AuditUpdate, model.AuditEntityOrder, order.ID, &before, order)
 	return order, nil
 }
 
-func (uc *orderUsecase) CancelOrder(id uint) (*model.Order, error) {
+func (uc *orderUsecase) CancelOrder(actor Actor, id uint) (*model.Order, error) {
 	order, err := uc.orderRepo.FindByID(id)
 	if err != nil {
 		return nil, fmt.Errorf(errFailedToGetOrder, err)
@@ -202,29 +378,43 @@ func (uc *orderUsecase) CancelOrder(id uint) (*model.Order, error) {
 	if order.Status == model.StatusCancelled {
 		return order, nil
 	}
+	before := *order
 
-	for _, item := range order.Items {
-		product, err := uc.productRepo.FindByID(item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf(errFailedToGetProduct, err)
-		}
-		if product == nil {
-			continue
-		}
-
-		product.Stock += item.Quantity
-		if err := uc.productRepo.Update(product); err != nil {
-			return nil, fmt.Errorf(errFailedToRestoreStock, err)
-		}
-	}
-
-	order.Status = model.StatusCancelled
 	now := time.Now()
+	order.Status = model.StatusCancelled
 	order.CancelledAt = &now
 
-	if err := uc.orderRepo.Update(order); err != nil {
-		return nil, fmt.Errorf(errFailedToUpdateOrder, err)
+	err = uc.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		for _, item := range order.Items {
+			product, err := repos.Products.FindByID(item.ProductID)
+			if err != nil {
+				return fmt.Errorf(errFailedToGetProduct, err)
+			}
+			if product == nil {
+				continue
+			}
+
+			product.Stock += item.Quantity
+			if err := repos.Products.Update(product); err != nil {
+				return fmt.Errorf(errFailedToRestoreStock, err)
+			}
+		}
+
+		if err := repos.Orders.Update(order); err != nil {
+			return fmt.Errorf(errFailedToUpdateOrder, err)
+		}
+		return appendEvents(repos.Outbox, model.OrderCancelled{
+			OrderID:     order.ID,
+			UserID:      order.OwnerID(),
+			Total:       order.Total,
+			Currency:    order.Currency,
+			CancelledAt: now,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	uc.auditor.Record(actor, model.AuditCancel, model.AuditEntityOrder, order.ID, &before, order)
 	return order, nil
 }
@@ -73,6 +73,16 @@ func (m *MockOrderRepository) Update(order *model.Order) error {
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) AttachGuestOrders(email string, userID uint) (int64, error) {
+	args := m.Called(email, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockOrderRepository) ClearGuestContact(userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
 type MockCartRepository struct {
 	mock.Mock
 }
@@ -101,6 +111,14 @@ func (m *MockCartRepository) FindByCartID(cartID uint) (*model.Cart, error) {
 	return args.Get(0).(*model.Cart), args.Error(1)
 }
 
+func (m *MockCartRepository) FindByGuestTokenHash(hash string) (*model.Cart, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Cart), args.Error(1)
+}
+
 func (m *MockCartRepository) FindWithFilters(filters map[string]string) ([]model.Cart, error) {
 	args := m.Called(filters)
 	return args.Get(0).([]model.Cart), args.Error(1)
@@ -126,6 +144,21 @@ func (m *MockCartRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockCartRepository) DeleteStale(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCartRepository) DeleteUnusedGuests(createdBefore time.Time) (int64, error) {
+	args := m.Called(createdBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCartRepository) MarkAbandoned(idleSince, now time.Time) (int64, error) {
+	args := m.Called(idleSince, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type MockCartItemRepository struct {
 	mock.Mock
 }
@@ -215,6 +248,32 @@ func (m *MockProductRepository) FindByID(id uint) (*model.Product, error) {
 	return args.Get(0).(*model.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) FindBySKU(sku string) (*model.Product, error) {
+	args := m.Called(sku)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) FindBySlug(slug string) (*model.Product, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) SlugTaken(slug string, exceptID uint) (bool, error) {
+	args := m.Called(slug, exceptID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockProductRepository) FindWithoutSlug() ([]model.Product, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
 func (m *MockProductRepository) FindAll() ([]model.Product, error) {
 	args := m.Called()
 	return args.Get(0).([]model.Product), args.Error(1)
@@ -230,6 +289,11 @@ func (m *MockProductRepository) FindByCategory(categoryID uint) ([]model.Product
 	return args.Get(0).([]model.Product), args.Error(1)
 }
 
+func (m *MockProductRepository) FindWithFiltersInBatches(filters map[string]string, batchSize int, fn func(products []model.Product) error) error {
+	args := m.Called(filters, batchSize, fn)
+	return args.Error(0)
+}
+
 func (m *MockProductRepository) Create(product *model.Product) error {
 	args := m.Called(product)
 	return args.Error(0)
@@ -293,6 +357,16 @@ func (m *MockUserRepository) Update(user *model.User) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) IncrementFailedLogins(id uint) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserRepository) LockUntil(id uint, until time.Time) error {
+	args := m.Called(id, until)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Delete(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
@@ -329,6 +403,11 @@ func (m *MockAddressRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockAddressRepository) IsUsedByOrders(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
 func setupOrderUsecase() (*orderUsecase, *MockOrderRepository, *MockCartRepository, *MockCartItemRepository, *MockProductRepository, *MockUserRepository, *MockAddressRepository) {
 	mockOrderRepo := new(MockOrderRepository)
 	mockCartRepo := new(MockCartRepository)
@@ -344,6 +423,13 @@ func setupOrderUsecase() (*orderUsecase, *MockOrderRepository, *MockCartReposito
 		productRepo:  mockProductRepo,
 		userRepo:     mockUserRepo,
 		addressRepo:  mockAddressRepo,
+		transactor:   newFakeTransactor(mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo),
+		shipping:     newTestShipping(),
+		tax:          newTestTax(),
+		prices:       newTestCurrency(),
+		pricing:      newTestPricing(),
+		names:        newTestTranslations(),
+		auditor:      &recordingAuditor{},
 	}
 
 	return uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, mockUserRepo, mockAddressRepo
@@ -357,7 +443,7 @@ func TestNewOrderUsecase(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAddressRepo := new(MockAddressRepository)
 
-	uc := NewOrderUsecase(mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, mockUserRepo, mockAddressRepo)
+	uc := NewOrderUsecase(mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, mockUserRepo, mockAddressRepo, newFakeTransactor(mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo), newTestShipping(), newTestTax(), newTestCurrency(), newTestPricing(), newTestTranslations(), &recordingAuditor{})
 
 	// Assertion 94: NewOrderUsecase should return a non-nil usecase instance
 	assert.NotNil(t, uc)
@@ -370,7 +456,7 @@ func TestOrderUsecaseGetByIDSuccess(t *testing.T) {
 
 	expectedOrder := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Total:  100.0,
 	}
@@ -386,7 +472,7 @@ func TestOrderUsecaseGetByIDSuccess(t *testing.T) {
 	// Assertion 98: GetByID should return an order with correct ID
 	assert.Equal(t, uint(1), result.ID)
 	// Assertion 99: GetByID should return an order with correct UserID
-	assert.Equal(t, uint(1), result.UserID)
+	assert.Equal(t, uintPtr(1), result.UserID)
 	// Assertion 100: GetByID should return an order with correct Status
 	assert.Equal(t, model.StatusPending, result.Status)
 	// Assertion 101: GetByID should return an order with correct Total
@@ -431,8 +517,8 @@ func TestOrderUsecaseGetByUserIDSuccess(t *testing.T) {
 	uc, mockOrderRepo, _, _, _, _, _ := setupOrderUsecase()
 
 	expectedOrders := []model.Order{
-		{ID: 1, UserID: 1, Status: model.StatusPending, Total: 100.0},
-		{ID: 2, UserID: 1, Status: model.StatusPaid, Total: 200.0},
+		{ID: 1, UserID: uintPtr(1), Status: model.StatusPending, Total: 100.0},
+		{ID: 2, UserID: uintPtr(1), Status: model.StatusPaid, Total: 200.0},
 	}
 
 	mockOrderRepo.On("FindByUserID", uint(1)).Return(expectedOrders, nil)
@@ -446,9 +532,9 @@ func TestOrderUsecaseGetByUserIDSuccess(t *testing.T) {
 	// Assertion 109: GetByUserID should return correct number of orders
 	assert.Len(t, result, 2)
 	// Assertion 110: GetByUserID should return orders with correct user ID
-	assert.Equal(t, uint(1), result[0].UserID)
+	assert.Equal(t, uintPtr(1), result[0].UserID)
 	// Assertion 111: GetByUserID should return orders with correct user ID for second order
-	assert.Equal(t, uint(1), result[1].UserID)
+	assert.Equal(t, uintPtr(1), result[1].UserID)
 
 	mockOrderRepo.AssertExpectations(t)
 }
@@ -491,9 +577,9 @@ func TestOrderUsecaseGetAllSuccess(t *testing.T) {
 	uc, mockOrderRepo, _, _, _, _, _ := setupOrderUsecase()
 
 	expectedOrders := []model.Order{
-		{ID: 1, UserID: 1, Status: model.StatusPending, Total: 100.0},
-		{ID: 2, UserID: 2, Status: model.StatusPaid, Total: 200.0},
-		{ID: 3, UserID: 3, Status: model.StatusShipped, Total: 300.0},
+		{ID: 1, UserID: uintPtr(1), Status: model.StatusPending, Total: 100.0},
+		{ID: 2, UserID: uintPtr(2), Status: model.StatusPaid, Total: 200.0},
+		{ID: 3, UserID: uintPtr(3), Status: model.StatusShipped, Total: 300.0},
 	}
 
 	mockOrderRepo.On("FindAll").Return(expectedOrders, nil)
@@ -555,7 +641,7 @@ func TestOrderUsecaseGetWithFiltersSuccess(t *testing.T) {
 	}
 
 	expectedOrders := []model.Order{
-		{ID: 1, UserID: 1, Status: model.StatusPending, Total: 100.0},
+		{ID: 1, UserID: uintPtr(1), Status: model.StatusPending, Total: 100.0},
 	}
 
 	mockOrderRepo.On("FindWithFilters", filters).Return(expectedOrders, nil)
@@ -596,7 +682,7 @@ func TestOrderUsecaseCreateFromCartSuccess(t *testing.T) {
 
 	cart := &model.Cart{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Total:  150.0,
 		Items: []model.CartItem{
 			{ID: 1, CartID: 1, ProductID: 1, Quantity: 2},
@@ -618,14 +704,14 @@ func TestOrderUsecaseCreateFromCartSuccess(t *testing.T) {
 	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
 	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
 
-	result, err := uc.CreateFromCart(1, model.PaymentCard, 1)
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
 
 	// Assertion 134: CreateFromCart should not return an error for valid cart and address
 	assert.NoError(t, err)
 	// Assertion 135: CreateFromCart should return a non-nil order
 	assert.NotNil(t, result)
 	// Assertion 136: CreateFromCart should set correct user ID on order
-	assert.Equal(t, uint(1), result.UserID)
+	assert.Equal(t, uintPtr(1), result.UserID)
 	// Assertion 137: CreateFromCart should set pending status on new order
 	assert.Equal(t, model.StatusPending, result.Status)
 	// Assertion 138: CreateFromCart should set correct payment method on order
@@ -655,13 +741,13 @@ func TestOrderUsecaseCreateFromCartEmptyCart(t *testing.T) {
 
 	cart := &model.Cart{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Items:  []model.CartItem{},
 	}
 
 	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
 
-	result, err := uc.CreateFromCart(1, model.PaymentCard, 1)
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
 
 	// Assertion 145: CreateFromCart should return error for empty cart
 	assert.Error(t, err)
@@ -678,7 +764,7 @@ func TestOrderUsecaseCreateFromCartCartNotFound(t *testing.T) {
 
 	mockCartRepo.On("FindByUserID", uint(999)).Return(nil, nil)
 
-	result, err := uc.CreateFromCart(999, model.PaymentCard, 1)
+	result, err := uc.CreateFromCart(testActor, 999, model.PaymentCard, 1, 0, "", "")
 
 	// Assertion 148: CreateFromCart should return error when cart not found
 	assert.Error(t, err)
@@ -695,7 +781,7 @@ func TestOrderUsecaseCreateFromCartAddressNotFound(t *testing.T) {
 
 	cart := &model.Cart{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Items: []model.CartItem{
 			{ID: 1, CartID: 1, ProductID: 1, Quantity: 1},
 		},
@@ -704,7 +790,7 @@ func TestOrderUsecaseCreateFromCartAddressNotFound(t *testing.T) {
 	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
 	mockAddressRepo.On("FindByID", uint(999)).Return(nil, nil)
 
-	result, err := uc.CreateFromCart(1, model.PaymentCard, 999)
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 999, 0, "", "")
 
 	// Assertion 151: CreateFromCart should return error when shipping address not found
 	assert.Error(t, err)
@@ -722,7 +808,7 @@ func TestOrderUsecaseCreateFromCartInsufficientStock(t *testing.T) {
 
 	cart := &model.Cart{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Items: []model.CartItem{
 			{ID: 1, CartID: 1, ProductID: 1, Quantity: 10},
 		},
@@ -735,7 +821,7 @@ func TestOrderUsecaseCreateFromCartInsufficientStock(t *testing.T) {
 	mockAddressRepo.On("FindByID", uint(1)).Return(address, nil)
 	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
 
-	result, err := uc.CreateFromCart(1, model.PaymentCard, 1)
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
 
 	// Assertion 154: CreateFromCart should return error when insufficient stock
 	assert.Error(t, err)
@@ -754,7 +840,7 @@ func TestOrderUsecaseCreateFromCartProductNotFound(t *testing.T) {
 
 	cart := &model.Cart{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Items: []model.CartItem{
 			{ID: 1, CartID: 1, ProductID: 999, Quantity: 1},
 		},
@@ -766,7 +852,7 @@ func TestOrderUsecaseCreateFromCartProductNotFound(t *testing.T) {
 	mockAddressRepo.On("FindByID", uint(1)).Return(address, nil)
 	mockProductRepo.On("FindByID", uint(999)).Return(nil, errors.New(productNotFound))
 
-	result, err := uc.CreateFromCart(1, model.PaymentCard, 1)
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
 
 	// Assertion 157: CreateFromCart should return error when product not found
 	assert.Error(t, err)
@@ -785,7 +871,7 @@ func TestOrderUsecaseUpdateStatusSuccess(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Total:  100.0,
 	}
@@ -793,7 +879,7 @@ func TestOrderUsecaseUpdateStatusSuccess(t *testing.T) {
 	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(nil)
 
-	result, err := uc.UpdateStatus(1, model.StatusPaid)
+	result, err := uc.UpdateStatus(testActor, 1, model.StatusPaid)
 
 	// Assertion 160: UpdateStatus should not return an error for valid order and status
 	assert.NoError(t, err)
@@ -814,7 +900,7 @@ func TestOrderUsecaseUpdateStatusToShipped(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPaid,
 		Total:  100.0,
 	}
@@ -822,7 +908,7 @@ func TestOrderUsecaseUpdateStatusToShipped(t *testing.T) {
 	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(nil)
 
-	result, err := uc.UpdateStatus(1, model.StatusShipped)
+	result, err := uc.UpdateStatus(testActor, 1, model.StatusShipped)
 
 	// Assertion 165: UpdateStatus should not return an error when updating to shipped
 	assert.NoError(t, err)
@@ -841,7 +927,7 @@ func TestOrderUsecaseUpdateStatusOrderNotFound(t *testing.T) {
 
 	mockOrderRepo.On("FindByID", uint(999)).Return(nil, nil)
 
-	result, err := uc.UpdateStatus(999, model.StatusPaid)
+	result, err := uc.UpdateStatus(testActor, 999, model.StatusPaid)
 
 	// Assertion 169: UpdateStatus should return gorm.ErrRecordNotFound for non-existent order
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
@@ -856,7 +942,7 @@ func TestOrderUsecaseUpdateStatusRepositoryError(t *testing.T) {
 
 	mockOrderRepo.On("FindByID", uint(1)).Return(nil, errors.New(dbError))
 
-	result, err := uc.UpdateStatus(1, model.StatusPaid)
+	result, err := uc.UpdateStatus(testActor, 1, model.StatusPaid)
 
 	// Assertion 171: UpdateStatus should return error when repository fails to find order
 	assert.Error(t, err)
@@ -873,7 +959,7 @@ func TestOrderUsecaseUpdateStatusUpdateRepositoryError(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Total:  100.0,
 	}
@@ -881,7 +967,7 @@ func TestOrderUsecaseUpdateStatusUpdateRepositoryError(t *testing.T) {
 	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(errors.New(updateFailed))
 
-	result, err := uc.UpdateStatus(1, model.StatusPaid)
+	result, err := uc.UpdateStatus(testActor, 1, model.StatusPaid)
 
 	// Assertion 174: UpdateStatus should return error when repository fails to update
 	assert.Error(t, err)
@@ -898,7 +984,7 @@ func TestOrderUsecaseCancelOrderSuccess(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Items: []model.OrderItem{
 			{ID: 1, ProductID: 1, Quantity: 2, UnitPrice: 50.0, Subtotal: 100.0},
@@ -916,7 +1002,7 @@ func TestOrderUsecaseCancelOrderSuccess(t *testing.T) {
 	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil).Twice()
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(nil)
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 177: CancelOrder should not return an error for valid order
 	assert.NoError(t, err)
@@ -939,7 +1025,7 @@ func TestOrderUsecaseCancelOrderAlreadyCancelled(t *testing.T) {
 	cancelledTime := time.Now().Add(-time.Hour)
 	order := &model.Order{
 		ID:          1,
-		UserID:      1,
+		UserID:      uintPtr(1),
 		Status:      model.StatusCancelled,
 		CancelledAt: &cancelledTime,
 		Total:       100.0,
@@ -947,7 +1033,7 @@ func TestOrderUsecaseCancelOrderAlreadyCancelled(t *testing.T) {
 
 	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 182: CancelOrder should not return an error for already cancelled order
 	assert.NoError(t, err)
@@ -966,7 +1052,7 @@ func TestOrderUsecaseCancelOrderOrderNotFound(t *testing.T) {
 
 	mockOrderRepo.On("FindByID", uint(999)).Return(nil, nil)
 
-	result, err := uc.CancelOrder(999)
+	result, err := uc.CancelOrder(testActor, 999)
 
 	// Assertion 186: CancelOrder should return gorm.ErrRecordNotFound for non-existent order
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
@@ -981,7 +1067,7 @@ func TestOrderUsecaseCancelOrderProductNotFound(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Items: []model.OrderItem{
 			{ID: 1, ProductID: 999, Quantity: 2, UnitPrice: 50.0, Subtotal: 100.0},
@@ -992,7 +1078,7 @@ func TestOrderUsecaseCancelOrderProductNotFound(t *testing.T) {
 	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
 	mockProductRepo.On("FindByID", uint(999)).Return(nil, errors.New(productNotFound))
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 188: CancelOrder should return error when product not found during cancellation
 	assert.Error(t, err)
@@ -1010,7 +1096,7 @@ func TestOrderUsecaseCancelOrderProductUpdateError(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Items: []model.OrderItem{
 			{ID: 1, ProductID: 1, Quantity: 2, UnitPrice: 50.0, Subtotal: 100.0},
@@ -1024,7 +1110,7 @@ func TestOrderUsecaseCancelOrderProductUpdateError(t *testing.T) {
 	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
 	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(errors.New(updateFailed))
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 191: CancelOrder should return error when product update fails during cancellation
 	assert.Error(t, err)
@@ -1042,7 +1128,7 @@ func TestOrderUsecaseCancelOrderOrderUpdateError(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Items: []model.OrderItem{
 			{ID: 1, ProductID: 1, Quantity: 2, UnitPrice: 50.0, Subtotal: 100.0},
@@ -1057,7 +1143,7 @@ func TestOrderUsecaseCancelOrderOrderUpdateError(t *testing.T) {
 	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(errors.New(orderUpdateFailed))
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 194: CancelOrder should return error when order update fails
 	assert.Error(t, err)
@@ -1075,7 +1161,7 @@ func TestOrderUsecaseCancelOrderProductNilHandling(t *testing.T) {
 
 	order := &model.Order{
 		ID:     1,
-		UserID: 1,
+		UserID: uintPtr(1),
 		Status: model.StatusPending,
 		Items: []model.OrderItem{
 			{ID: 1, ProductID: 1, Quantity: 2, UnitPrice: 50.0, Subtotal: 100.0},
@@ -1087,7 +1173,7 @@ func TestOrderUsecaseCancelOrderProductNilHandling(t *testing.T) {
 	mockProductRepo.On("FindByID", uint(1)).Return(nil, nil)
 	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(nil)
 
-	result, err := uc.CancelOrder(1)
+	result, err := uc.CancelOrder(testActor, 1)
 
 	// Assertion 197: CancelOrder should not return an error when product is nil (deleted product)
 	assert.NoError(t, err)
@@ -1101,3 +1187,75 @@ func TestOrderUsecaseCancelOrderProductNilHandling(t *testing.T) {
 	mockOrderRepo.AssertExpectations(t)
 	mockProductRepo.AssertExpectations(t)
 }
+
+func TestOrderUsecaseCreateFromCartRecordsEvents(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2}}}
+	product := &model.Product{ID: 1, Name: testProduct1Name, Price: 50.0, Stock: model.StockLowThreshold + 1}
+
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	_, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
+	outbox := uc.transactor.(*fakeTransactor).outbox
+
+	// Assertion 511: Placing an order records OrderCreated, and StockLow when stock drops below the threshold
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.EventOrderCreated, model.EventStockLow}, outbox.types())
+
+	var created model.OrderCreated
+	assert.NoError(t, outbox.events[0].Decode(&created))
+	// Assertion 512: OrderCreated carries the committed order ID, total and items
+	assert.Equal(t, uint(1), created.OrderID)
+	assert.Equal(t, 100.0, created.Total)
+	assert.Len(t, created.Items, 1)
+}
+
+func TestOrderUsecaseCreateFromCartFailsWhenEventCannotBeRecorded(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	uc.transactor.(*fakeTransactor).outbox.createErr = errors.New("disk full")
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 1}}}
+
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(&model.Product{ID: 1, Price: 10, Stock: 100}, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	result, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
+
+	// Assertion 513: An outbox failure fails the whole transaction
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "disk full")
+	assert.Nil(t, result)
+}
+
+func TestOrderUsecaseStatusChangesRecordEvents(t *testing.T) {
+	uc, mockOrderRepo, _, _, mockProductRepo, _, _ := setupOrderUsecase()
+
+	order := &model.Order{ID: 1, UserID: uintPtr(2), Status: model.StatusPending, Total: 30,
+		Items: []model.OrderItem{{ProductID: 1, Quantity: 1}}}
+	mockOrderRepo.On("FindByID", uint(1)).Return(order, nil)
+	mockOrderRepo.On("Update", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(&model.Product{ID: 1, Stock: 10}, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+
+	_, err := uc.UpdateStatus(testActor, 1, model.StatusPaid)
+	assert.NoError(t, err)
+	_, err = uc.UpdateStatus(testActor, 1, model.StatusShipped)
+	assert.NoError(t, err)
+	_, err = uc.CancelOrder(testActor, 1)
+	assert.NoError(t, err)
+
+	// Assertion 514: Paying and cancelling raise events, shipping does not
+	assert.Equal(t, []string{model.EventOrderPaid, model.EventOrderCancelled}, uc.transactor.(*fakeTransactor).outbox.types())
+}
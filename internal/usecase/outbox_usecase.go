@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Delivery settings for the outbox dispatcher. A failed event is retried with
+// exponential backoff (1s, 2s, 4s, ... capped at outboxMaxBackoff) and marked
+// FAILED after outboxMaxAttempts.
+const (
+	outboxBatchSize   = 50
+	outboxMaxAttempts = 10
+	outboxBaseBackoff = time.Second
+	outboxMaxBackoff  = time.Hour
+	outboxMaxErrorLen = 500
+)
+
+var ErrOutboxEventNotFailed = NewError(KindConflict, "outbox_event_not_failed", "only failed events can be retried")
+
+// EventHandler reacts to one outbox event. Delivery is at-least-once: an event
+// is redelivered to every handler of its type until all of them succeed, so
+// handlers must be idempotent (the event ID is a good deduplication key).
+type EventHandler func(event model.OutboxEvent) error
+
+type OutboxUsecase interface {
+	// Subscribe registers handler for eventType. Call it before Run.
+	Subscribe(eventType string, handler EventHandler)
+	// DispatchPending delivers one batch of due events and returns how many were processed.
+	DispatchPending() (int, error)
+	// Run calls DispatchPending every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
+	GetWithFilters(filters map[string]string) ([]model.OutboxEvent, error)
+	// Retry puts a FAILED event back into the queue with a fresh attempt budget.
+	Retry(id uint) (*model.OutboxEvent, error)
+}
+
+type outboxUsecase struct {
+	outboxRepo repository.OutboxRepository
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string][]EventHandler
+}
+
+func NewOutboxUsecase(outboxRepo repository.OutboxRepository) OutboxUsecase {
+	return &outboxUsecase{
+		outboxRepo: outboxRepo,
+		now:        time.Now,
+		handlers:   make(map[string][]EventHandler),
+	}
+}
+
+func (u *outboxUsecase) Subscribe(eventType string, handler EventHandler) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.handlers[eventType] = append(u.handlers[eventType], handler)
+}
+
+func (u *outboxUsecase) DispatchPending() (int, error) {
+	events, err := u.outboxRepo.FindDue(u.now(), outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range events {
+		event := &events[i]
+		if err := u.deliver(*event); err != nil {
+			u.scheduleRetry(event, err)
+		} else {
+			delivered := u.now()
+			event.Status = model.OutboxDelivered
+			event.DeliveredAt = &delivered
+			event.LastError = ""
+		}
+		event.Attempts++
+		if err := u.outboxRepo.Update(event); err != nil {
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+func (u *outboxUsecase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick.
+		for {
+			n, err := u.DispatchPending()
+			if err != nil {
+				log.Printf("outbox: dispatch: %v", err)
+				break
+			}
+			if n < outboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *outboxUsecase) GetWithFilters(filters map[string]string) ([]model.OutboxEvent, error) {
+	return u.outboxRepo.FindWithFilters(filters)
+}
+
+func (u *outboxUsecase) Retry(id uint) (*model.OutboxEvent, error) {
+	event, err := u.outboxRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if event.Status != model.OutboxFailed {
+		return nil, ErrOutboxEventNotFailed
+	}
+
+	event.Status = model.OutboxPending
+	event.Attempts = 0
+	event.NextAttemptAt = u.now()
+	if err := u.outboxRepo.Update(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// deliver runs every handler for the event type and returns the first error.
+// An event without subscribers counts as delivered.
+func (u *outboxUsecase) deliver(event model.OutboxEvent) error {
+	u.mu.RLock()
+	handlers := u.handlers[event.Type]
+	u.mu.RUnlock()
+
+	var firstErr error
+	for _, handle := range handlers {
+		if err := safeHandle(handle, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (u *outboxUsecase) scheduleRetry(event *model.OutboxEvent, err error) {
+	event.LastError = truncate(err.Error(), outboxMaxErrorLen)
+
+	if event.Attempts+1 >= outboxMaxAttempts {
+		event.Status = model.OutboxFailed
+		log.Printf("outbox: event %d (%s) failed after %d attempts: %v", event.ID, event.Type, event.Attempts+1, err)
+		return
+	}
+	event.NextAttemptAt = u.now().Add(exponentialBackoff(outboxBaseBackoff, outboxMaxBackoff, event.Attempts))
+}
+
+// exponentialBackoff returns base doubled once per previous attempt, capped at max.
+func exponentialBackoff(base, max time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 0; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return backoff
+}
+
+// safeHandle turns a handler panic into an error so one bad subscriber
+// cannot stop the dispatcher.
+func safeHandle(handle EventHandler, event model.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handle(event)
+}
+
+// appendEvents writes events to the outbox. Call it with the repository of the
+// transaction that makes the change the events describe.
+func appendEvents(outbox repository.OutboxRepository, events ...model.DomainEvent) error {
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", e.EventType(), err)
+		}
+		record := &model.OutboxEvent{
+			Type:          e.EventType(),
+			AggregateType: e.AggregateType(),
+			AggregateID:   e.AggregateID(),
+			Payload:       payload,
+			Status:        model.OutboxPending,
+			NextAttemptAt: time.Now(),
+		}
+		if err := outbox.Create(record); err != nil {
+			return fmt.Errorf("failed to record %s event: %w", e.EventType(), err)
+		}
+	}
+	return nil
+}
+
+// stockLowEvent reports whether a stock change crossed StockLowThreshold downwards.
+func stockLowEvent(product *model.Product, previousStock int) (model.DomainEvent, bool) {
+	if previousStock < model.StockLowThreshold || product.Stock >= model.StockLowThreshold {
+		return nil, false
+	}
+	return model.StockLow{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Stock:     product.Stock,
+		Threshold: model.StockLowThreshold,
+	}, true
+}
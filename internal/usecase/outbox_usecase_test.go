@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryOutbox is an in-memory OutboxRepository.
+type memoryOutbox struct {
+	events    []model.OutboxEvent
+	createErr error
+}
+
+func (o *memoryOutbox) FindByID(id uint) (*model.OutboxEvent, error) {
+	for i := range o.events {
+		if o.events[i].ID == id {
+			event := o.events[i]
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (o *memoryOutbox) FindWithFilters(filters map[string]string) ([]model.OutboxEvent, error) {
+	var out []model.OutboxEvent
+	for _, e := range o.events {
+		if v, ok := filters["status"]; ok && string(e.Status) != v {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (o *memoryOutbox) FindDue(now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var out []model.OutboxEvent
+	for _, e := range o.events {
+		if e.Status == model.OutboxPending && !e.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (o *memoryOutbox) Create(event *model.OutboxEvent) error {
+	if o.createErr != nil {
+		return o.createErr
+	}
+	event.ID = uint(len(o.events) + 1)
+	o.events = append(o.events, *event)
+	return nil
+}
+
+func (o *memoryOutbox) Update(event *model.OutboxEvent) error {
+	for i := range o.events {
+		if o.events[i].ID == event.ID {
+			o.events[i] = *event
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (o *memoryOutbox) types() []string {
+	types := make([]string, 0, len(o.events))
+	for _, e := range o.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// fakeTransactor runs fn directly against the given repositories, so tests can
+// keep their mocks and inspect the events written to outbox.
+type fakeTransactor struct {
+	repos  repository.TxRepositories
+	outbox *memoryOutbox
+}
+
+func newFakeTransactor(orders repository.OrderRepository, carts repository.CartRepository, cartItems repository.CartItemRepository, products repository.ProductRepository) *fakeTransactor {
+	outbox := &memoryOutbox{}
+	return &fakeTransactor{
+		repos: repository.TxRepositories{
+			Orders:    orders,
+			Carts:     carts,
+			CartItems: cartItems,
+			Products:  products,
+			Outbox:    outbox,
+		},
+		outbox: outbox,
+	}
+}
+
+func (t *fakeTransactor) WithinTransaction(fn func(repos repository.TxRepositories) error) error {
+	return fn(t.repos)
+}
+
+// setupOutboxUsecase appends events and freezes the dispatcher clock just after them.
+func setupOutboxUsecase(t *testing.T, events ...model.DomainEvent) (*outboxUsecase, *memoryOutbox, time.Time) {
+	outbox := &memoryOutbox{}
+	assert.NoError(t, appendEvents(outbox, events...))
+	now := time.Now()
+	uc := NewOutboxUsecase(outbox).(*outboxUsecase)
+	uc.now = func() time.Time { return now }
+	return uc, outbox, now
+}
+
+func TestOutboxUsecaseDispatchDeliversToSubscribers(t *testing.T) {
+	uc, outbox, _ := setupOutboxUsecase(t, model.OrderPaid{OrderID: 7, UserID: 2, Total: 30})
+
+	var got model.OrderPaid
+	calls := 0
+	uc.Subscribe(model.EventOrderPaid, func(event model.OutboxEvent) error {
+		calls++
+		return event.Decode(&got)
+	})
+	uc.Subscribe(model.EventOrderPaid, func(event model.OutboxEvent) error {
+		calls++
+		return nil
+	})
+
+	n, err := uc.DispatchPending()
+
+	// Assertion 504: Every subscriber of the type receives the typed payload
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, uint(7), got.OrderID)
+	// Assertion 505: A delivered event is marked and not picked up again
+	assert.Equal(t, model.OutboxDelivered, outbox.events[0].Status)
+	assert.Equal(t, 1, outbox.events[0].Attempts)
+	assert.NotNil(t, outbox.events[0].DeliveredAt)
+	n, _ = uc.DispatchPending()
+	assert.Equal(t, 0, n)
+}
+
+func TestOutboxUsecaseDispatchRetriesWithBackoffThenFails(t *testing.T) {
+	uc, outbox, now := setupOutboxUsecase(t, model.StockLow{ProductID: 3, Stock: 1, Threshold: model.StockLowThreshold})
+
+	uc.Subscribe(model.EventStockLow, func(event model.OutboxEvent) error {
+		return errors.New("erp unavailable")
+	})
+	uc.Subscribe(model.EventStockLow, func(event model.OutboxEvent) error {
+		panic("boom")
+	})
+
+	_, err := uc.DispatchPending()
+
+	// Assertion 506: A failing handler reschedules the event instead of dropping it
+	assert.NoError(t, err)
+	assert.Equal(t, model.OutboxPending, outbox.events[0].Status)
+	assert.Equal(t, "erp unavailable", outbox.events[0].LastError)
+	assert.Equal(t, now.Add(time.Second), outbox.events[0].NextAttemptAt)
+
+	// Assertion 507: Events are not retried before their next attempt is due
+	n, _ := uc.DispatchPending()
+	assert.Equal(t, 0, n)
+
+	for i := 1; i < outboxMaxAttempts; i++ {
+		outbox.events[0].NextAttemptAt = now
+		_, _ = uc.DispatchPending()
+	}
+
+	// Assertion 508: After the last attempt the event is marked FAILED
+	assert.Equal(t, model.OutboxFailed, outbox.events[0].Status)
+	assert.Equal(t, outboxMaxAttempts, outbox.events[0].Attempts)
+
+	retried, err := uc.Retry(outbox.events[0].ID)
+
+	// Assertion 509: Retry re-queues a failed event with a fresh attempt budget
+	assert.NoError(t, err)
+	assert.Equal(t, model.OutboxPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	_, err = uc.Retry(outbox.events[0].ID)
+	assert.ErrorIs(t, err, ErrOutboxEventNotFailed)
+}
+
+func TestOutboxBackoffIsCapped(t *testing.T) {
+	// Assertion 510: Backoff doubles per attempt and never exceeds the cap
+	assert.Equal(t, time.Second, exponentialBackoff(outboxBaseBackoff, outboxMaxBackoff, 0))
+	assert.Equal(t, 8*time.Second, exponentialBackoff(outboxBaseBackoff, outboxMaxBackoff, 3))
+	assert.Equal(t, outboxMaxBackoff, exponentialBackoff(outboxBaseBackoff, outboxMaxBackoff, 40))
+}
@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Error message constants
+const (
+	errSalePriceValue = "sale_price must be greater than 0 and below the regular price of %.2f"
+	errSaleStart      = "starts_at is required"
+	errSaleEndOrder   = "ends_at must be after starts_at"
+	errSaleEnded      = "ends_at must be in the future"
+)
+
+var (
+	ErrInvalidPriceSchedule = NewError(KindValidation, "invalid_price_schedule", "invalid price schedule")
+	ErrPriceScheduleOverlap = NewError(KindConflict, "price_schedule_overlap", "the product is already on sale at that time")
+)
+
+// JobRecordSalePrices is the name of the job recording the prices of sales
+// that started or ended in the price history.
+const JobRecordSalePrices = "record-sale-prices"
+
+const (
+	// omnibusPeriod is how far back the lowest price of a product is looked
+	// up, as the EU Omnibus Directive requires.
+	omnibusPeriod = 30 * 24 * time.Hour
+	// saleCatchUp is how far back the record-sale-prices job looks for
+	// sales that started or ended, so that missed runs are caught up.
+	saleCatchUp = 24 * time.Hour
+)
+
+// PriceScheduleInput describes a sale of one product.
+type PriceScheduleInput struct {
+	SalePrice float64
+	StartsAt  time.Time
+	EndsAt    *time.Time
+}
+
+// PriceHistoryReport is a product's current price with the figures the EU
+// Omnibus Directive asks to show next to a price reduction.
+type PriceHistoryReport struct {
+	ProductID      uint     `json:"product_id"`
+	Currency       string   `json:"currency"`
+	Price          float64  `json:"price"`
+	CompareAtPrice *float64 `json:"compare_at_price,omitempty"`
+	// LowestPrice30Days is the lowest price the product sold at in the
+	// past 30 days, the current one included.
+	LowestPrice30Days float64 `json:"lowest_price_30_days"`
+	// PriorPrice is the lowest price in the 30 days before the current
+	// price took effect: the price a reduction has to be announced against.
+	// It is nil when the product has no earlier prices.
+	PriorPrice *float64 `json:"prior_price,omitempty"`
+	// History lists the prices both figures were worked out from, oldest first.
+	History []model.PriceHistory `json:"history"`
+}
+
+// PriceResolver works out the price products sell at.
+type PriceResolver interface {
+	// EffectivePrice returns the price product sells at now, in its own
+	// currency: the lowest running sale price below the regular price, or
+	// else the regular price.
+	EffectivePrice(product *model.Product) (float64, error)
+	// ApplyPrices shows products at the price they sell at now. The
+	// regular price of products on sale is moved to CompareAtPrice.
+	ApplyPrices(products []model.Product) error
+}
+
+type PricingUsecase interface {
+	PriceResolver
+	GetSchedules(productID uint) ([]model.PriceSchedule, error)
+	// CreateSchedule fails with ErrPriceScheduleOverlap if the product is
+	// already on sale at any time during the new one.
+	CreateSchedule(actor Actor, productID uint, input PriceScheduleInput) (*model.PriceSchedule, error)
+	UpdateSchedule(actor Actor, productID, id uint, input PriceScheduleInput) (*model.PriceSchedule, error)
+	DeleteSchedule(actor Actor, productID, id uint) error
+	// RecordPrice adds the price product sells at now to its history,
+	// unless that is its latest entry already. The entry is written through
+	// history, so it can be part of the transaction that saves the product.
+	RecordPrice(history repository.PriceHistoryRepository, product *model.Product, reason model.PriceChangeReason) error
+	// History reports the product's price history of the past 30 days.
+	History(productID uint) (*PriceHistoryReport, error)
+	// RecordSalePrices records the prices of products whose sales started
+	// or ended since the previous run, returning how many were recorded.
+	RecordSalePrices(ctx context.Context) (int, error)
+	Jobs() []Job
+}
+
+type pricingUsecase struct {
+	productRepo  repository.ProductRepository
+	scheduleRepo repository.PriceScheduleRepository
+	historyRepo  repository.PriceHistoryRepository
+	auditor      Auditor
+	now          func() time.Time
+}
+
+func NewPricingUsecase(
+	productRepo repository.ProductRepository,
+	scheduleRepo repository.PriceScheduleRepository,
+	historyRepo repository.PriceHistoryRepository,
+	auditor Auditor,
+) PricingUsecase {
+	return &pricingUsecase{
+		productRepo:  productRepo,
+		scheduleRepo: scheduleRepo,
+		historyRepo:  historyRepo,
+		auditor:      auditor,
+		now:          time.Now,
+	}
+}
+
+// salePrice returns the lowest of the regular price and the sale prices.
+func salePrice(regular float64, schedules []model.PriceSchedule) float64 {
+	price := regular
+	for _, schedule := range schedules {
+		if schedule.SalePrice < price {
+			price = schedule.SalePrice
+		}
+	}
+	return price
+}
+
+func (u *pricingUsecase) EffectivePrice(product *model.Product) (float64, error) {
+	schedules, err := u.scheduleRepo.FindActive([]uint{product.ID}, u.now())
+	if err != nil {
+		return 0, err
+	}
+	return salePrice(product.Price, schedules), nil
+}
+
+func (u *pricingUsecase) ApplyPrices(products []model.Product) error {
+	ids := make([]uint, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+	}
+	schedules, err := u.scheduleRepo.FindActive(ids, u.now())
+	if err != nil {
+		return err
+	}
+	byProduct := map[uint][]model.PriceSchedule{}
+	for _, schedule := range schedules {
+		byProduct[schedule.ProductID] = append(byProduct[schedule.ProductID], schedule)
+	}
+	for i := range products {
+		product := &products[i]
+		if price := salePrice(product.Price, byProduct[product.ID]); price < product.Price {
+			regular := product.Price
+			product.Price, product.CompareAtPrice = price, &regular
+		}
+	}
+	return nil
+}
+
+// product returns the product with its regular price.
+func (u *pricingUsecase) product(id uint) (*model.Product, error) {
+	product, err := u.productRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
+func (u *pricingUsecase) GetSchedules(productID uint) ([]model.PriceSchedule, error) {
+	if _, err := u.product(productID); err != nil {
+		return nil, err
+	}
+	return u.scheduleRepo.FindByProduct(productID)
+}
+
+// schedule returns one of the product's schedules. Schedules of other
+// products are reported as not found.
+func (u *pricingUsecase) schedule(productID, id uint) (*model.PriceSchedule, error) {
+	schedule, err := u.scheduleRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil || schedule.ProductID != productID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return schedule, nil
+}
+
+func (u *pricingUsecase) CreateSchedule(actor Actor, productID uint, input PriceScheduleInput) (*model.PriceSchedule, error) {
+	product, err := u.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &model.PriceSchedule{ProductID: productID}
+	if err := u.applyScheduleInput(product, schedule, input); err != nil {
+		return nil, err
+	}
+	if err := u.scheduleRepo.Create(schedule); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityPriceSchedule, schedule.ID, nil, schedule)
+	if _, err := u.recordSaleChange(product, u.now()); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (u *pricingUsecase) UpdateSchedule(actor Actor, productID, id uint, input PriceScheduleInput) (*model.PriceSchedule, error) {
+	product, err := u.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := u.schedule(productID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *schedule
+	if err := u.applyScheduleInput(product, schedule, input); err != nil {
+		return nil, err
+	}
+	if err := u.scheduleRepo.Update(schedule); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityPriceSchedule, schedule.ID, &before, schedule)
+	if _, err := u.recordSaleChange(product, u.now()); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func (u *pricingUsecase) DeleteSchedule(actor Actor, productID, id uint) error {
+	product, err := u.product(productID)
+	if err != nil {
+		return err
+	}
+	schedule, err := u.schedule(productID, id)
+	if err != nil {
+		return err
+	}
+	if err := u.scheduleRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityPriceSchedule, id, schedule, nil)
+	_, err = u.recordSaleChange(product, u.now())
+	return err
+}
+
+func (u *pricingUsecase) applyScheduleInput(product *model.Product, schedule *model.PriceSchedule, input PriceScheduleInput) error {
+	switch {
+	case !(input.SalePrice > 0) || input.SalePrice >= product.Price:
+		return fmt.Errorf("%w: "+errSalePriceValue, ErrInvalidPriceSchedule, product.Price)
+	case input.StartsAt.IsZero():
+		return fmt.Errorf("%w: %s", ErrInvalidPriceSchedule, errSaleStart)
+	case input.EndsAt != nil && !input.EndsAt.After(input.StartsAt):
+		return fmt.Errorf("%w: %s", ErrInvalidPriceSchedule, errSaleEndOrder)
+	case input.EndsAt != nil && !input.EndsAt.After(u.now()):
+		return fmt.Errorf("%w: %s", ErrInvalidPriceSchedule, errSaleEnded)
+	}
+	schedule.SalePrice = model.RoundMoney(input.SalePrice)
+	schedule.StartsAt = input.StartsAt
+	schedule.EndsAt = input.EndsAt
+
+	others, err := u.scheduleRepo.FindByProduct(product.ID)
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID != schedule.ID && schedule.Overlaps(other) {
+			return fmt.Errorf("%w: schedule %d", ErrPriceScheduleOverlap, other.ID)
+		}
+	}
+	return nil
+}
+
+func (u *pricingUsecase) RecordPrice(history repository.PriceHistoryRepository, product *model.Product, reason model.PriceChangeReason) error {
+	price, err := u.EffectivePrice(product)
+	if err != nil {
+		return err
+	}
+	_, err = u.recordPrice(history, product, price, reason, u.now())
+	return err
+}
+
+// recordSaleChange records the price product sells at after a sale started
+// or ended at the given time. It reports whether the price changed.
+func (u *pricingUsecase) recordSaleChange(product *model.Product, at time.Time) (bool, error) {
+	price, err := u.EffectivePrice(product)
+	if err != nil {
+		return false, err
+	}
+	reason := model.PriceChangeSaleEnded
+	if price < product.Price {
+		reason = model.PriceChangeSaleStarted
+	}
+	return u.recordPrice(u.historyRepo, product, price, reason, at)
+}
+
+// recordPrice adds price to the product's history in history as of at,
+// unless it is the latest entry already. It reports whether it did.
+func (u *pricingUsecase) recordPrice(history repository.PriceHistoryRepository, product *model.Product, price float64, reason model.PriceChangeReason, at time.Time) (bool, error) {
+	latest, err := history.FindLatest(product.ID)
+	if err != nil {
+		return false, err
+	}
+	if latest != nil {
+		if latest.Price == price && latest.Currency == product.Currency {
+			return false, nil
+		}
+		// Entries stay in order even when a change is recorded late.
+		if at.Before(latest.ChangedAt) {
+			at = latest.ChangedAt
+		}
+	}
+	entry := &model.PriceHistory{
+		ProductID: product.ID,
+		Price:     price,
+		Currency:  product.Currency,
+		Reason:    reason,
+		ChangedAt: at,
+	}
+	return true, history.Create(entry)
+}
+
+func (u *pricingUsecase) History(productID uint) (*PriceHistoryReport, error) {
+	product, err := u.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	products := []model.Product{*product}
+	if err := u.ApplyPrices(products); err != nil {
+		return nil, err
+	}
+	report := &PriceHistoryReport{
+		ProductID:         product.ID,
+		Currency:          product.Currency,
+		Price:             products[0].Price,
+		CompareAtPrice:    products[0].CompareAtPrice,
+		LowestPrice30Days: products[0].Price,
+	}
+
+	now := u.now()
+	since := now.Add(-omnibusPeriod)
+	latest, err := u.historyRepo.FindLatest(productID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.ChangedAt.Add(-omnibusPeriod).Before(since) {
+		since = latest.ChangedAt.Add(-omnibusPeriod)
+	}
+	if report.History, err = u.historyRepo.FindSince(productID, since); err != nil {
+		return nil, err
+	}
+	if lowest, ok := lowestPrice(report.History, now.Add(-omnibusPeriod), now); ok && lowest < report.LowestPrice30Days {
+		report.LowestPrice30Days = lowest
+	}
+	if latest != nil {
+		if prior, ok := lowestPrice(report.History, latest.ChangedAt.Add(-omnibusPeriod), latest.ChangedAt); ok {
+			report.PriorPrice = &prior
+		}
+	}
+	return report, nil
+}
+
+// lowestPrice returns the lowest price in effect at any time from from
+// until to. Each entry is in effect until the next one; entries are oldest
+// first. It reports false if none was in effect.
+func lowestPrice(entries []model.PriceHistory, from, to time.Time) (float64, bool) {
+	lowest, found := 0.0, false
+	for i, entry := range entries {
+		if !entry.ChangedAt.Before(to) {
+			break
+		}
+		if i+1 < len(entries) && !entries[i+1].ChangedAt.After(from) {
+			continue
+		}
+		if !found || entry.Price < lowest {
+			lowest, found = entry.Price, true
+		}
+	}
+	return lowest, found
+}
+
+func (u *pricingUsecase) RecordSalePrices(ctx context.Context) (int, error) {
+	now := u.now()
+	ids, err := u.scheduleRepo.FindProductsChanged(now.Add(-saleCatchUp), now)
+	if err != nil {
+		return 0, err
+	}
+	recorded := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return recorded, err
+		}
+		product, err := u.productRepo.FindByID(id)
+		if err != nil {
+			return recorded, err
+		}
+		if product == nil {
+			continue
+		}
+		schedules, err := u.scheduleRepo.FindByProduct(id)
+		if err != nil {
+			return recorded, err
+		}
+		ok, err := u.recordSaleChange(product, lastSaleChange(schedules, now))
+		if err != nil {
+			return recorded, err
+		}
+		if ok {
+			recorded++
+		}
+	}
+	return recorded, nil
+}
+
+// lastSaleChange returns when the latest sale up to now started or ended.
+func lastSaleChange(schedules []model.PriceSchedule, now time.Time) time.Time {
+	var last time.Time
+	for _, schedule := range schedules {
+		if !schedule.StartsAt.After(now) && schedule.StartsAt.After(last) {
+			last = schedule.StartsAt
+		}
+		if schedule.EndsAt != nil && !schedule.EndsAt.After(now) && schedule.EndsAt.After(last) {
+			last = *schedule.EndsAt
+		}
+	}
+	return last
+}
+
+func (u *pricingUsecase) Jobs() []Job {
+	return []Job{
+		{
+			Name:        JobRecordSalePrices,
+			Description: "Record the prices of sales that started or ended in the price history",
+			Schedule:    "*/5 * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.RecordSalePrices(ctx)
+				return fmt.Sprintf("recorded %d prices", n), err
+			},
+		},
+	}
+}
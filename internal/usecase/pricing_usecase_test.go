@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type memoryPriceSchedules struct {
+	schedules []model.PriceSchedule
+}
+
+func (r *memoryPriceSchedules) FindByID(id uint) (*model.PriceSchedule, error) {
+	for i := range r.schedules {
+		if r.schedules[i].ID == id {
+			schedule := r.schedules[i]
+			return &schedule, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryPriceSchedules) FindByProduct(productID uint) ([]model.PriceSchedule, error) {
+	var found []model.PriceSchedule
+	for _, schedule := range r.schedules {
+		if schedule.ProductID == productID {
+			found = append(found, schedule)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryPriceSchedules) FindActive(productIDs []uint, at time.Time) ([]model.PriceSchedule, error) {
+	var found []model.PriceSchedule
+	for _, id := range productIDs {
+		for _, schedule := range r.schedules {
+			if schedule.ProductID == id && schedule.ActiveAt(at) {
+				found = append(found, schedule)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryPriceSchedules) FindProductsChanged(from, to time.Time) ([]uint, error) {
+	changed := func(t time.Time) bool { return t.After(from) && !t.After(to) }
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, schedule := range r.schedules {
+		if (changed(schedule.StartsAt) || schedule.EndsAt != nil && changed(*schedule.EndsAt)) && !seen[schedule.ProductID] {
+			seen[schedule.ProductID] = true
+			ids = append(ids, schedule.ProductID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryPriceSchedules) Create(schedule *model.PriceSchedule) error {
+	schedule.ID = uint(len(r.schedules) + 1)
+	r.schedules = append(r.schedules, *schedule)
+	return nil
+}
+
+func (r *memoryPriceSchedules) Update(schedule *model.PriceSchedule) error {
+	for i := range r.schedules {
+		if r.schedules[i].ID == schedule.ID {
+			r.schedules[i] = *schedule
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryPriceSchedules) Delete(id uint) error {
+	for i := range r.schedules {
+		if r.schedules[i].ID == id {
+			r.schedules = append(r.schedules[:i], r.schedules[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type memoryPriceHistory struct {
+	entries []model.PriceHistory
+}
+
+func (r *memoryPriceHistory) Create(entry *model.PriceHistory) error {
+	entry.ID = uint(len(r.entries) + 1)
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *memoryPriceHistory) FindLatest(productID uint) (*model.PriceHistory, error) {
+	var latest *model.PriceHistory
+	for i := range r.entries {
+		if r.entries[i].ProductID == productID {
+			latest = &r.entries[i]
+		}
+	}
+	return latest, nil
+}
+
+func (r *memoryPriceHistory) FindSince(productID uint, since time.Time) ([]model.PriceHistory, error) {
+	var found []model.PriceHistory
+	for _, entry := range r.entries {
+		if entry.ProductID != productID {
+			continue
+		}
+		if !entry.ChangedAt.After(since) {
+			found = []model.PriceHistory{entry}
+			continue
+		}
+		found = append(found, entry)
+	}
+	return found, nil
+}
+
+func newTestPricing() *pricingUsecase {
+	return &pricingUsecase{
+		scheduleRepo: &memoryPriceSchedules{},
+		historyRepo:  &memoryPriceHistory{},
+		auditor:      &recordingAuditor{},
+		now:          time.Now,
+	}
+}
+
+// pricingDay is the start of day n of the pricing tests.
+func pricingDay(n int) time.Time {
+	return time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// setupPricingUsecase sets up a lamp at 100, created on day 0, with the
+// clock at noon of day 10.
+func setupPricingUsecase(t *testing.T) (*pricingUsecase, *mockProductRepository) {
+	repo := newMockProductRepository()
+	repo.Create(&model.Product{Name: "Lamp", Price: 100, Currency: "USD", Stock: 10})
+	uc := newTestPricing()
+	uc.productRepo = repo
+	uc.now = func() time.Time { return pricingDay(0) }
+	lamp, _ := repo.FindByID(1)
+	assert.NoError(t, uc.RecordPrice(uc.historyRepo, lamp, model.PriceChangeCreated))
+	uc.now = func() time.Time { return pricingDay(10).Add(12 * time.Hour) }
+	return uc, repo
+}
+
+func TestPricingUsecaseValidatesSchedules(t *testing.T) {
+	uc, _ := setupPricingUsecase(t)
+
+	_, err := uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 100, StartsAt: pricingDay(11)})
+	// Assertion 639: CreateSchedule should refuse sale prices not below the regular price
+	assert.ErrorIs(t, err, ErrInvalidPriceSchedule)
+
+	_, err = uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 80, StartsAt: pricingDay(11), EndsAt: timePtr(pricingDay(11))})
+	// Assertion 640: CreateSchedule should refuse sales ending before they start
+	assert.ErrorIs(t, err, ErrInvalidPriceSchedule)
+
+	_, err = uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 80, StartsAt: pricingDay(1), EndsAt: timePtr(pricingDay(2))})
+	// Assertion 641: CreateSchedule should refuse sales that are over already
+	assert.ErrorIs(t, err, ErrInvalidPriceSchedule)
+
+	_, err = uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 80, StartsAt: pricingDay(11), EndsAt: timePtr(pricingDay(14))})
+	assert.NoError(t, err)
+	_, err = uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 70, StartsAt: pricingDay(13)})
+	// Assertion 642: CreateSchedule should refuse sales overlapping another one of the product
+	assert.ErrorIs(t, err, ErrPriceScheduleOverlap)
+
+	_, err = uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 70, StartsAt: pricingDay(14)})
+	// Assertion 643: CreateSchedule should allow a sale starting when the previous one ends
+	assert.NoError(t, err)
+
+	_, err = uc.UpdateSchedule(testActor, 2, 1, PriceScheduleInput{SalePrice: 80, StartsAt: pricingDay(11)})
+	// Assertion 644: UpdateSchedule should not find schedules of other products
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestPricingUsecaseAppliesSalePrices(t *testing.T) {
+	uc, repo := setupPricingUsecase(t)
+	_, err := uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 79.99, StartsAt: pricingDay(11), EndsAt: timePtr(pricingDay(14))})
+	assert.NoError(t, err)
+	lamp, _ := repo.FindByID(1)
+
+	price, err := uc.EffectivePrice(lamp)
+	// Assertion 645: EffectivePrice should be the regular price before the sale starts
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, price)
+
+	uc.now = func() time.Time { return pricingDay(11) }
+	products := []model.Product{*lamp}
+	// Assertion 646: ApplyPrices should show the sale price with the regular price to compare at
+	assert.NoError(t, uc.ApplyPrices(products))
+	assert.Equal(t, 79.99, products[0].Price)
+	assert.Equal(t, 100.0, *products[0].CompareAtPrice)
+
+	uc.now = func() time.Time { return pricingDay(14) }
+	price, _ = uc.EffectivePrice(lamp)
+	// Assertion 647: EffectivePrice should go back to the regular price when the sale ends
+	assert.Equal(t, 100.0, price)
+}
+
+func TestPricingUsecaseHistory(t *testing.T) {
+	uc, repo := setupPricingUsecase(t)
+	_, err := uc.CreateSchedule(testActor, 1, PriceScheduleInput{SalePrice: 80, StartsAt: pricingDay(40), EndsAt: timePtr(pricingDay(47))})
+	assert.NoError(t, err)
+	lamp, _ := repo.FindByID(1)
+	lamp.Price = 90
+	repo.Update(lamp)
+	uc.now = func() time.Time { return pricingDay(20) }
+	assert.NoError(t, uc.RecordPrice(uc.historyRepo, lamp, model.PriceChangeUpdated))
+
+	uc.now = func() time.Time { return pricingDay(40).Add(3 * time.Minute) }
+	n, err := uc.RecordSalePrices(context.Background())
+	// Assertion 648: RecordSalePrices should record the sale price as of the start of the sale
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	entries := uc.historyRepo.(*memoryPriceHistory).entries
+	assert.Equal(t, model.PriceHistory{ID: 3, ProductID: 1, Price: 80, Currency: "USD", Reason: model.PriceChangeSaleStarted, ChangedAt: pricingDay(40)}, entries[2])
+
+	n, _ = uc.RecordSalePrices(context.Background())
+	// Assertion 649: RecordSalePrices should record each change once
+	assert.Equal(t, 0, n)
+
+	uc.now = func() time.Time { return pricingDay(41) }
+	report, err := uc.History(1)
+	// Assertion 650: History should give the lowest price of the past 30 days and the one before the reduction
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, report.Price)
+	assert.Equal(t, 90.0, *report.CompareAtPrice)
+	assert.Equal(t, 80.0, report.LowestPrice30Days)
+	assert.Equal(t, 90.0, *report.PriorPrice)
+	assert.Len(t, report.History, 3)
+
+	uc.now = func() time.Time { return pricingDay(47).Add(time.Hour) }
+	_, _ = uc.RecordSalePrices(context.Background())
+	uc.now = func() time.Time { return pricingDay(60) }
+	report, _ = uc.History(1)
+	// Assertion 651: History should keep the sale price in the lowest price for 30 days after it ended
+	assert.Equal(t, 90.0, report.Price)
+	assert.Nil(t, report.CompareAtPrice)
+	assert.Equal(t, 80.0, report.LowestPrice30Days)
+	assert.Equal(t, 80.0, *report.PriorPrice)
+	assert.Equal(t, model.PriceChangeSaleEnded, report.History[len(report.History)-1].Reason)
+}
+
+func TestProductUsecaseRecordsPriceHistory(t *testing.T) {
+	repo := newMockProductRepository()
+	pricing := newTestPricing()
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), pricing, newMemorySlugRedirects())
+
+	created, _ := usecase.Create(testActor, &model.Product{Name: "Lamp", Price: 100})
+	_, _ = usecase.Update(testActor, &model.Product{ID: created.ID, Name: "Lamp", Price: 100, Stock: 3})
+	_, _ = usecase.Update(testActor, &model.Product{ID: created.ID, Name: "Lamp", Price: 95, Stock: 3})
+	reasons := []model.PriceChangeReason{}
+	for _, entry := range pricing.historyRepo.(*memoryPriceHistory).entries {
+		reasons = append(reasons, entry.Reason)
+	}
+	// Assertion 652: Create and Update should record price changes only
+	assert.Equal(t, []model.PriceChangeReason{model.PriceChangeCreated, model.PriceChangeUpdated}, reasons)
+}
+
+func TestOrderUsecaseCreateFromCartChargesSalePrice(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	pricing := newTestPricing()
+	pricing.scheduleRepo.Create(&model.PriceSchedule{ProductID: 1, SalePrice: 40, StartsAt: time.Now().Add(-time.Hour)})
+	uc.pricing = pricing
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2, UnitPrice: 50}}}
+	product := &model.Product{ID: 1, Name: testProduct1Name, Price: 50, Stock: 10}
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1, Country: "Poland"}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	order, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
+	// Assertion 653: CreateFromCart should charge the running sale price without storing it as the regular price
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, order.Items[0].UnitPrice)
+	assert.Equal(t, 80.0, order.Total)
+	assert.Equal(t, 50.0, product.Price)
+}
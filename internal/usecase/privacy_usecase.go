@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPrivacyRequestPending   = NewError(KindConflict, "privacy_request_pending", "an erasure request is already pending for this user")
+	ErrPrivacyRequestProcessed = NewError(KindConflict, "privacy_request_processed", "privacy request has already been processed")
+)
+
+// DataExport is everything the shop stores about a user, as returned for a
+// subject access request.
+type DataExport struct {
+	ExportedAt time.Time       `json:"exported_at"`
+	Profile    *model.User     `json:"profile"`
+	Addresses  []model.Address `json:"addresses"`
+	Cart       *model.Cart     `json:"cart"`
+	Orders     []model.Order   `json:"orders"`
+}
+
+type PrivacyUsecase interface {
+	// Export collects the user's data and records a completed EXPORT request.
+	Export(userID uint) (*DataExport, error)
+	// RequestErasure queues an ERASURE request for an admin to process.
+	RequestErasure(userID uint, note string) (*model.PrivacyRequest, error)
+	GetWithFilters(filters map[string]string) ([]model.PrivacyRequest, error)
+	// Complete carries out a pending request. For erasure, personal data on the
+	// user and every address they used is anonymized and the cart is emptied;
+	// orders and order items are kept for accounting.
+	Complete(actor Actor, requestID uint) (*model.PrivacyRequest, error)
+	Reject(actor Actor, requestID uint, note string) (*model.PrivacyRequest, error)
+}
+
+type privacyUsecase struct {
+	privacyRepo  repository.PrivacyRequestRepository
+	userRepo     repository.UserRepository
+	addrRepo     repository.AddressRepository
+	orderRepo    repository.OrderRepository
+	cartRepo     repository.CartRepository
+	cartItemRepo repository.CartItemRepository
+	transactor   repository.Transactor
+	auditor      Auditor
+}
+
+func NewPrivacyUsecase(
+	privacyRepo repository.PrivacyRequestRepository,
+	userRepo repository.UserRepository,
+	addrRepo repository.AddressRepository,
+	orderRepo repository.OrderRepository,
+	cartRepo repository.CartRepository,
+	cartItemRepo repository.CartItemRepository,
+	transactor repository.Transactor,
+	auditor Auditor,
+) PrivacyUsecase {
+	return &privacyUsecase{
+		privacyRepo:  privacyRepo,
+		userRepo:     userRepo,
+		addrRepo:     addrRepo,
+		orderRepo:    orderRepo,
+		cartRepo:     cartRepo,
+		cartItemRepo: cartItemRepo,
+		transactor:   transactor,
+		auditor:      auditor,
+	}
+}
+
+func (u *privacyUsecase) Export(userID uint) (*DataExport, error) {
+	user, err := findUser(u.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := u.orderRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	cart, err := u.cartRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &DataExport{
+		ExportedAt: time.Now(),
+		Profile:    user,
+		Addresses:  userAddresses(user, orders),
+		Cart:       cart,
+		Orders:     orders,
+	}
+
+	now := export.ExportedAt
+	record := &model.PrivacyRequest{
+		UserID:      userID,
+		Type:        model.PrivacyExport,
+		Status:      model.PrivacyCompleted,
+		ProcessedAt: &now,
+	}
+	if err := u.privacyRepo.Create(record); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// userAddresses returns the profile address followed by every distinct
+// shipping address used on the user's orders.
+func userAddresses(user *model.User, orders []model.Order) []model.Address {
+	seen := map[uint]bool{user.AddressID: true}
+	addresses := []model.Address{user.Address}
+	for _, order := range orders {
+		if seen[order.ShippingAddressID] {
+			continue
+		}
+		seen[order.ShippingAddressID] = true
+		addresses = append(addresses, order.ShippingAddress)
+	}
+	return addresses
+}
+
+func (u *privacyUsecase) RequestErasure(userID uint, note string) (*model.PrivacyRequest, error) {
+	if _, err := findUser(u.userRepo, userID); err != nil {
+		return nil, err
+	}
+	pending, err := u.privacyRepo.FindWithFilters(map[string]string{
+		"user_id": strconv.FormatUint(uint64(userID), 10),
+		"type":    string(model.PrivacyErasure),
+		"status":  string(model.PrivacyPending),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, ErrPrivacyRequestPending
+	}
+
+	request := &model.PrivacyRequest{
+		UserID: userID,
+		Type:   model.PrivacyErasure,
+		Status: model.PrivacyPending,
+		Note:   strings.TrimSpace(note),
+	}
+	if err := u.privacyRepo.Create(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (u *privacyUsecase) GetWithFilters(filters map[string]string) ([]model.PrivacyRequest, error) {
+	return u.privacyRepo.FindWithFilters(filters)
+}
+
+func (u *privacyUsecase) Complete(actor Actor, requestID uint) (*model.PrivacyRequest, error) {
+	request, err := u.pendingRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	// The erasure and the status change are committed together, so a failure
+	// part-way leaves the account as it was and the request pending.
+	return u.finish(actor, request, model.PrivacyCompleted, model.AuditComplete, func(request *model.PrivacyRequest) error {
+		return u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+			if request.Type == model.PrivacyErasure {
+				if err := erase(repos, request.UserID); err != nil {
+					return err
+				}
+			}
+			return repos.PrivacyRequests.Update(request)
+		})
+	})
+}
+
+func (u *privacyUsecase) Reject(actor Actor, requestID uint, note string) (*model.PrivacyRequest, error) {
+	request, err := u.pendingRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if note = strings.TrimSpace(note); note != "" {
+		request.Note = note
+	}
+	return u.finish(actor, request, model.PrivacyRejected, model.AuditReject, u.privacyRepo.Update)
+}
+
+func erase(repos repository.TxRepositories, userID uint) error {
+	user, err := findUser(repos.Users, userID)
+	if err != nil {
+		return err
+	}
+	orders, err := repos.Orders.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	// Shipping addresses are scrubbed in place: orders keep pointing at them,
+	// but they no longer identify the buyer.
+	scrubbed := map[uint]bool{}
+	for _, order := range orders {
+		if scrubbed[order.ShippingAddressID] {
+			continue
+		}
+		scrubbed[order.ShippingAddressID] = true
+		addr := order.ShippingAddress
+		addr.ID = order.ShippingAddressID
+		scrubAddress(&addr)
+		if err := repos.Addresses.Update(&addr); err != nil {
+			return err
+		}
+	}
+
+	cart, err := repos.Carts.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if cart != nil {
+		if err := repos.CartItems.ClearCart(cart.ID); err != nil {
+			return err
+		}
+		cart.Items = nil
+		cart.Total = 0
+		if err := repos.Carts.Update(cart); err != nil {
+			return err
+		}
+	}
+
+	return anonymizeUser(repos.Users, repos.Addresses, repos.Orders, user)
+}
+
+func (u *privacyUsecase) pendingRequest(id uint) (*model.PrivacyRequest, error) {
+	request, err := u.privacyRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if request.Status != model.PrivacyPending {
+		return nil, ErrPrivacyRequestProcessed
+	}
+	return request, nil
+}
+
+// finish marks the request processed and stores it with save.
+func (u *privacyUsecase) finish(actor Actor, request *model.PrivacyRequest, status model.PrivacyRequestStatus, action string, save func(*model.PrivacyRequest) error) (*model.PrivacyRequest, error) {
+	now := time.Now()
+	request.User = nil
+	before := *request
+	request.Status = status
+	request.ProcessedByID = &actor.UserID
+	request.ProcessedAt = &now
+	if err := save(request); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, action, model.AuditEntityPrivacyRequest, request.ID, &before, request)
+	// Reload so the response shows the user as they are after processing.
+	return u.privacyRepo.FindByID(request.ID)
+}
+
+func findUser(userRepo repository.UserRepository, id uint) (*model.User, error) {
+	user, err := userRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+const modelPrivacyRequest = "*model.PrivacyRequest"
+
+type MockPrivacyRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockPrivacyRequestRepository) FindByID(id uint) (*model.PrivacyRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PrivacyRequest), args.Error(1)
+}
+
+func (m *MockPrivacyRequestRepository) FindWithFilters(filters map[string]string) ([]model.PrivacyRequest, error) {
+	args := m.Called(filters)
+	return args.Get(0).([]model.PrivacyRequest), args.Error(1)
+}
+
+func (m *MockPrivacyRequestRepository) Create(request *model.PrivacyRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockPrivacyRequestRepository) Update(request *model.PrivacyRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+type privacyMocks struct {
+	privacy  *MockPrivacyRequestRepository
+	user     *MockUserRepository
+	addr     *MockAddressRepository
+	order    *MockOrderRepository
+	cart     *MockCartRepository
+	cartItem *MockCartItemRepository
+}
+
+func setupPrivacyUsecase() (*privacyUsecase, privacyMocks) {
+	m := privacyMocks{
+		privacy:  new(MockPrivacyRequestRepository),
+		user:     new(MockUserRepository),
+		addr:     new(MockAddressRepository),
+		order:    new(MockOrderRepository),
+		cart:     new(MockCartRepository),
+		cartItem: new(MockCartItemRepository),
+	}
+	uc := &privacyUsecase{
+		privacyRepo:  m.privacy,
+		userRepo:     m.user,
+		addrRepo:     m.addr,
+		orderRepo:    m.order,
+		cartRepo:     m.cart,
+		cartItemRepo: m.cartItem,
+		transactor: &fakeTransactor{repos: repository.TxRepositories{
+			Orders:          m.order,
+			Carts:           m.cart,
+			CartItems:       m.cartItem,
+			Users:           m.user,
+			Addresses:       m.addr,
+			PrivacyRequests: m.privacy,
+		}},
+		auditor: &recordingAuditor{},
+	}
+	return uc, m
+}
+
+func privacyTestOrders() []model.Order {
+	return []model.Order{
+		{ID: 1, UserID: uintPtr(1), ShippingAddressID: 10, ShippingAddress: model.Address{ID: 10, Country: "Poland", Street: "Main"}, Total: 50},
+		{ID: 2, UserID: uintPtr(1), ShippingAddressID: 20, ShippingAddress: model.Address{ID: 20, Country: "Germany", Street: "Haupt"}, Total: 70},
+		{ID: 3, UserID: uintPtr(1), ShippingAddressID: 20, ShippingAddress: model.Address{ID: 20, Country: "Germany", Street: "Haupt"}, Total: 30},
+	}
+}
+
+func TestPrivacyUsecaseExport(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	user := &model.User{ID: 1, Email: userExampleEmail, AddressID: 10, Address: model.Address{ID: 10, Country: "Poland"}}
+	cart := &model.Cart{ID: 5, UserID: uintPtr(1), Total: 12}
+	m.user.On("FindByID", uint(1)).Return(user, nil)
+	m.order.On("FindByUserID", uint(1)).Return(privacyTestOrders(), nil)
+	m.cart.On("FindByUserID", uint(1)).Return(cart, nil)
+	m.privacy.On("Create", mock.AnythingOfType(modelPrivacyRequest)).Return(nil)
+
+	export, err := uc.Export(1)
+
+	// Assertion 486: Export should succeed
+	assert.NoError(t, err)
+	// Assertion 487: Export should include profile, cart and orders
+	assert.Equal(t, user, export.Profile)
+	assert.Equal(t, cart, export.Cart)
+	assert.Len(t, export.Orders, 3)
+	// Assertion 488: Export should list every distinct address once
+	assert.Len(t, export.Addresses, 2)
+	// Assertion 489: Export should be recorded as a completed request
+	record := m.privacy.Calls[0].Arguments.Get(0).(*model.PrivacyRequest)
+	assert.Equal(t, model.PrivacyExport, record.Type)
+	assert.Equal(t, model.PrivacyCompleted, record.Status)
+}
+
+func TestPrivacyUsecaseExportIncludesAttachedGuestOrders(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	orders := privacyTestOrders()
+	orders[2].GuestEmail = userExampleEmail
+	orders[2].GuestName = "John"
+	user := &model.User{ID: 1, Email: userExampleEmail, AddressID: 10, Address: model.Address{ID: 10, Country: "Poland"}}
+	m.user.On("FindByID", uint(1)).Return(user, nil)
+	m.order.On("FindByUserID", uint(1)).Return(orders, nil)
+	m.cart.On("FindByUserID", uint(1)).Return(nil, nil)
+	m.privacy.On("Create", mock.AnythingOfType(modelPrivacyRequest)).Return(nil)
+
+	export, err := uc.Export(1)
+
+	// Assertion 844: Export should include guest orders attached to the account with their contact details
+	assert.NoError(t, err)
+	assert.Len(t, export.Orders, 3)
+	assert.Equal(t, userExampleEmail, export.Orders[2].GuestEmail)
+	assert.Equal(t, "John", export.Orders[2].GuestName)
+}
+
+func TestPrivacyUsecaseRequestErasureRejectsDuplicates(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	m.user.On("FindByID", uint(1)).Return(&model.User{ID: 1}, nil)
+	m.privacy.On("FindWithFilters", mock.Anything).Return([]model.PrivacyRequest{{ID: 9, Status: model.PrivacyPending}}, nil)
+
+	_, err := uc.RequestErasure(1, "")
+
+	// Assertion 490: RequestErasure should not queue a second pending erasure
+	assert.ErrorIs(t, err, ErrPrivacyRequestPending)
+	m.privacy.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestPrivacyUsecaseCompleteErasure(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	request := &model.PrivacyRequest{ID: 9, UserID: 1, Type: model.PrivacyErasure, Status: model.PrivacyPending}
+	user := &model.User{ID: 1, Email: userExampleEmail, Name: "John", AddressID: 10, Address: model.Address{ID: 10, Country: "Poland", Street: "Main"}}
+	cart := &model.Cart{ID: 5, UserID: uintPtr(1), Total: 12, Items: []model.CartItem{{ID: 1}}}
+
+	orders := privacyTestOrders()
+	orders[0].GuestEmail = userExampleEmail
+	orders[0].GuestName = "John"
+	orders[0].GuestSurname = "Doe"
+
+	var scrubbed []model.Address
+	m.privacy.On("FindByID", uint(9)).Return(request, nil)
+	m.privacy.On("Update", request).Return(nil)
+	m.user.On("FindByID", uint(1)).Return(user, nil)
+	m.user.On("Update", user).Return(nil)
+	m.order.On("FindByUserID", uint(1)).Return(orders, nil)
+	m.order.On("ClearGuestContact", uint(1)).Run(func(mock.Arguments) {
+		for i := range orders {
+			orders[i].GuestEmail, orders[i].GuestName, orders[i].GuestSurname = "", "", ""
+		}
+	}).Return(nil)
+	m.addr.On("Update", mock.AnythingOfType(modelAddress)).Run(func(args mock.Arguments) {
+		scrubbed = append(scrubbed, *args.Get(0).(*model.Address))
+	}).Return(nil)
+	m.addr.On("IsUsedByOrders", uint(10)).Return(true, nil)
+	m.addr.On("Create", mock.AnythingOfType(modelAddress)).Return(nil)
+	m.cart.On("FindByUserID", uint(1)).Return(cart, nil)
+	m.cartItem.On("ClearCart", uint(5)).Return(nil)
+	m.cart.On("Update", cart).Return(nil)
+
+	result, err := uc.Complete(Actor{UserID: 2}, 9)
+
+	// Assertion 491: Complete should succeed and mark the request completed by the admin
+	assert.NoError(t, err)
+	assert.Equal(t, model.PrivacyCompleted, result.Status)
+	assert.Equal(t, uint(2), *result.ProcessedByID)
+	// Assertion 492: Complete should scrub each shipping address once, keeping the country
+	assert.Len(t, scrubbed, 2)
+	for _, addr := range scrubbed {
+		assert.Empty(t, addr.Street)
+		assert.NotEmpty(t, addr.Country)
+	}
+	// Assertion 493: Complete should anonymize the user
+	assert.Equal(t, "deleted-1@anonymized.invalid", user.Email)
+	assert.NotNil(t, user.ClosedAt)
+	// Assertion 494: Complete should empty the cart
+	assert.Zero(t, cart.Total)
+	assert.Empty(t, cart.Items)
+	// Assertion 495: Complete should keep orders untouched
+	m.order.AssertNotCalled(t, "Update", mock.Anything)
+	// Assertion 843: Complete should remove the guest contact details from the user's orders
+	m.order.AssertCalled(t, "ClearGuestContact", uint(1))
+	for _, order := range orders {
+		assert.Empty(t, order.GuestEmail)
+		assert.Empty(t, order.GuestName)
+		assert.Empty(t, order.GuestSurname)
+	}
+}
+
+func TestPrivacyUsecaseCompleteErasureFailureKeepsRequestPending(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	request := &model.PrivacyRequest{ID: 9, UserID: 1, Type: model.PrivacyErasure, Status: model.PrivacyPending}
+	cart := &model.Cart{ID: 5, UserID: uintPtr(1)}
+	m.privacy.On("FindByID", uint(9)).Return(request, nil)
+	m.user.On("FindByID", uint(1)).Return(&model.User{ID: 1, Email: userExampleEmail}, nil)
+	m.order.On("FindByUserID", uint(1)).Return(privacyTestOrders(), nil)
+	m.addr.On("Update", mock.AnythingOfType(modelAddress)).Return(nil)
+	m.cart.On("FindByUserID", uint(1)).Return(cart, nil)
+	m.cartItem.On("ClearCart", uint(5)).Return(errors.New("disk full"))
+
+	result, err := uc.Complete(Actor{UserID: 2}, 9)
+
+	// Assertion 781: A failed erasure should be reported
+	assert.EqualError(t, err, "disk full")
+	assert.Nil(t, result)
+	// Assertion 782: A failed erasure should neither anonymize the user nor close the request
+	m.user.AssertNotCalled(t, "Update", mock.Anything)
+	m.privacy.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestPrivacyUsecaseRejectProcessedRequest(t *testing.T) {
+	uc, m := setupPrivacyUsecase()
+
+	m.privacy.On("FindByID", uint(9)).Return(&model.PrivacyRequest{ID: 9, Status: model.PrivacyCompleted}, nil)
+	m.privacy.On("FindByID", uint(10)).Return(nil, nil)
+
+	_, err := uc.Reject(Actor{UserID: 2}, 9, "duplicate")
+	// Assertion 496: Reject should refuse requests that were already processed
+	assert.ErrorIs(t, err, ErrPrivacyRequestProcessed)
+
+	_, err = uc.Reject(Actor{UserID: 2}, 10, "")
+	// Assertion 497: Reject should report unknown requests as not found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+)
+
+// Error message constants
+const (
+	errProductFileFormat    = "format must be csv or json"
+	errProductFileEmpty     = "the file has no products"
+	errProductFileColumn    = "unknown column %q"
+	errProductFileDuplicate = "column %q appears twice"
+	errProductFileJSON      = "the file must hold a JSON array of products"
+	errProductRowColumns    = "expected %d columns, got %d"
+	errProductRowValue      = "%s: %q is not valid"
+)
+
+// In CSV files a product's images are listed in one cell.
+const productImageSeparator = "|"
+
+// productColumns are the CSV columns, in the order they are exported.
+// category_id is accepted on import as an alternative to category.
+var productColumns = []string{
+	"id", "sku", "name", "description", "price", "currency", "stock", "is_active",
+	"tax_class", "weight", "length", "width", "height", "category", "images",
+}
+
+// ProductRow is a product as it is imported and exported. On import a row
+// updates the product with its id, else the one with its sku, else creates
+// a product; fields left out keep their value, or their default on new
+// products. Images, when given, replace the product's images.
+type ProductRow struct {
+	ID          *uint    `json:"id,omitempty"`
+	SKU         *string  `json:"sku,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+	Currency    *string  `json:"currency,omitempty"`
+	Stock       *int     `json:"stock,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+	TaxClass    *string  `json:"tax_class,omitempty"`
+	Weight      *float64 `json:"weight,omitempty"`
+	Length      *float64 `json:"length,omitempty"`
+	Width       *float64 `json:"width,omitempty"`
+	Height      *float64 `json:"height,omitempty"`
+	Category    *string  `json:"category,omitempty"`
+	CategoryID  *uint    `json:"category_id,omitempty"`
+	Images      []string `json:"images,omitempty"`
+}
+
+// ParseProductFileFormat accepts csv or json in any case.
+func ParseProductFileFormat(format string) (model.ProductFileFormat, error) {
+	switch f := model.ProductFileFormat(strings.ToLower(strings.TrimSpace(format))); f {
+	case model.ProductFileCSV, model.ProductFileJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrInvalidProductImport, errProductFileFormat)
+	}
+}
+
+// productRowOf describes a stored product as a full row.
+func productRowOf(product model.Product, categories categoryIndex) ProductRow {
+	id := product.ID
+	category := categories.path(product.CategoryID)
+	class := string(product.TaxClass)
+	images := make([]string, len(product.Images))
+	for i, image := range product.Images {
+		images[i] = image.URL
+	}
+	return ProductRow{
+		ID:          &id,
+		SKU:         product.SKU,
+		Name:        &product.Name,
+		Description: &product.Description,
+		Price:       &product.Price,
+		Currency:    &product.Currency,
+		Stock:       &product.Stock,
+		IsActive:    &product.IsActive,
+		TaxClass:    &class,
+		Weight:      &product.Weight,
+		Length:      &product.Length,
+		Width:       &product.Width,
+		Height:      &product.Height,
+		Category:    &category,
+		Images:      images,
+	}
+}
+
+func (r ProductRow) sku() string {
+	if r.SKU == nil {
+		return ""
+	}
+	return strings.TrimSpace(*r.SKU)
+}
+
+// cell formats a field for a CSV column.
+func (r ProductRow) cell(column string) string {
+	formatFloat := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	formatString := func(v *string) string {
+		if v == nil {
+			return ""
+		}
+		return *v
+	}
+	switch column {
+	case "id":
+		if r.ID != nil {
+			return strconv.FormatUint(uint64(*r.ID), 10)
+		}
+	case "sku":
+		return formatString(r.SKU)
+	case "name":
+		return formatString(r.Name)
+	case "description":
+		return formatString(r.Description)
+	case "price":
+		return formatFloat(r.Price)
+	case "currency":
+		return formatString(r.Currency)
+	case "stock":
+		if r.Stock != nil {
+			return strconv.Itoa(*r.Stock)
+		}
+	case "is_active":
+		if r.IsActive != nil {
+			return strconv.FormatBool(*r.IsActive)
+		}
+	case "tax_class":
+		return formatString(r.TaxClass)
+	case "weight":
+		return formatFloat(r.Weight)
+	case "length":
+		return formatFloat(r.Length)
+	case "width":
+		return formatFloat(r.Width)
+	case "height":
+		return formatFloat(r.Height)
+	case "category":
+		return formatString(r.Category)
+	case "category_id":
+		if r.CategoryID != nil {
+			return strconv.FormatUint(uint64(*r.CategoryID), 10)
+		}
+	case "images":
+		return strings.Join(r.Images, productImageSeparator)
+	}
+	return ""
+}
+
+// set reads a CSV cell into the row. Empty cells leave the field out.
+func (r *ProductRow) set(column, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	invalid := fmt.Errorf(errProductRowValue, column, value)
+	parseFloat := func(dest **float64) error {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid
+		}
+		*dest = &v
+		return nil
+	}
+	parseUint := func(dest **uint) error {
+		v, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return invalid
+		}
+		id := uint(v)
+		*dest = &id
+		return nil
+	}
+	switch column {
+	case "id":
+		return parseUint(&r.ID)
+	case "sku":
+		r.SKU = &value
+	case "name":
+		r.Name = &value
+	case "description":
+		r.Description = &value
+	case "price":
+		return parseFloat(&r.Price)
+	case "currency":
+		r.Currency = &value
+	case "stock":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return invalid
+		}
+		r.Stock = &v
+	case "is_active":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid
+		}
+		r.IsActive = &v
+	case "tax_class":
+		r.TaxClass = &value
+	case "weight":
+		return parseFloat(&r.Weight)
+	case "length":
+		return parseFloat(&r.Length)
+	case "width":
+		return parseFloat(&r.Width)
+	case "height":
+		return parseFloat(&r.Height)
+	case "category":
+		r.Category = &value
+	case "category_id":
+		return parseUint(&r.CategoryID)
+	case "images":
+		r.Images = strings.Split(value, productImageSeparator)
+	}
+	return nil
+}
+
+// productFileRow is a row as read from the file, or why it could not be.
+type productFileRow struct {
+	ProductRow
+	err error
+}
+
+// parseProductFile reads the rows of a file. It fails only when the file as
+// a whole cannot be read; a row that cannot be read carries its error.
+func parseProductFile(format model.ProductFileFormat, payload []byte) ([]productFileRow, error) {
+	var rows []productFileRow
+	var err error
+	switch format {
+	case model.ProductFileCSV:
+		rows, err = parseProductCSV(payload)
+	case model.ProductFileJSON:
+		rows, err = parseProductJSON(payload)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProductImport, errProductFileFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProductImport, errProductFileEmpty)
+	}
+	return rows, nil
+}
+
+func parseProductCSV(payload []byte) ([]productFileRow, error) {
+	// Spreadsheets often save CSV with a byte order mark.
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(payload, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProductImport, err)
+	}
+	known := map[string]bool{"category_id": true}
+	for _, column := range productColumns {
+		known[column] = true
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		column := strings.ToLower(strings.TrimSpace(name))
+		if !known[column] {
+			return nil, fmt.Errorf("%w: "+errProductFileColumn, ErrInvalidProductImport, name)
+		}
+		for _, previous := range columns[:i] {
+			if previous == column {
+				return nil, fmt.Errorf("%w: "+errProductFileDuplicate, ErrInvalidProductImport, column)
+			}
+		}
+		columns[i] = column
+	}
+
+	var rows []productFileRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProductImport, err)
+		}
+		var row productFileRow
+		if len(record) != len(columns) {
+			row.err = fmt.Errorf(errProductRowColumns, len(columns), len(record))
+		}
+		for i := 0; row.err == nil && i < len(record); i++ {
+			row.err = row.set(columns[i], record[i])
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseProductJSON(payload []byte) ([]productFileRow, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(payload, &items); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProductImport, errProductFileJSON)
+	}
+	rows := make([]productFileRow, len(items))
+	for i, item := range items {
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		rows[i].err = decoder.Decode(&rows[i].ProductRow)
+	}
+	return rows, nil
+}
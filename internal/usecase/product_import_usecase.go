@@ -0,0 +1,636 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidProductImport = NewError(KindValidation, "invalid_product_import", "invalid product import")
+
+// Error message constants
+const (
+	errProductRowNotFound   = "product %d not found"
+	errProductRowRepeated   = "the product is also in row %d"
+	errProductRowName       = "name is required"
+	errProductRowNew        = "price and category are required for new products"
+	errProductRowNegative   = "%s must not be negative"
+	errProductRowCategories = "give category or category_id, not both"
+	errProductRowCategory   = "category %q not found"
+	errProductRowCategoryID = "category %d not found"
+	errProductRowImage      = "image %q is not an http(s) URL"
+	errProductImportStopped = "the import was interrupted; rows after the last one processed were not imported"
+)
+
+const (
+	JobProcessProductImports = "process-product-imports"
+
+	// Queued imports save their progress every productImportCheckpoint rows.
+	productImportCheckpoint = 50
+	maxProductImportErrors  = 1000
+	maxProductImportError   = 500
+	recentProductImports    = 50
+	productExportBatchSize  = 500
+
+	// Categories are given by name or by path from the top, e.g.
+	// "Books > Fiction".
+	categoryPathSeparator = " > "
+)
+
+type ProductImportConfig struct {
+	// SyncRows is the largest file, in rows, imported while the request
+	// waits. Larger files are queued.
+	SyncRows int
+}
+
+func DefaultProductImportConfig() ProductImportConfig {
+	return ProductImportConfig{SyncRows: 200}
+}
+
+type ProductImportInput struct {
+	Format  model.ProductFileFormat
+	Payload []byte
+	// DryRun validates every row without writing anything.
+	DryRun bool
+	// Async queues the file whatever its size.
+	Async bool
+}
+
+type ProductImportUsecase interface {
+	// Import reads a CSV or JSON file of products. Files that cannot be read
+	// at all fail with ErrInvalidProductImport; rows that cannot be imported
+	// are reported on the returned import while the others are written.
+	// Files of more than SyncRows rows are queued and come back PENDING.
+	Import(actor Actor, input ProductImportInput) (*model.ProductImport, error)
+	GetImport(id uint) (*model.ProductImport, error)
+	// GetImports lists the latest imports, newest first.
+	GetImports() ([]model.ProductImport, error)
+	// ProcessPending runs queued imports one after the other until none is
+	// left or ctx is done, and reports how many it ran.
+	ProcessPending(ctx context.Context) (int, error)
+	// Export writes the products matching the filters to w in the format
+	// Import reads, at their regular prices.
+	Export(format model.ProductFileFormat, filters map[string]string, w io.Writer) error
+	Jobs() []Job
+}
+
+type productImportUsecase struct {
+	importRepo   repository.ProductImportRepository
+	productRepo  repository.ProductRepository
+	categoryRepo repository.CategoryRepository
+	products     ProductUsecase
+	images       ImageUsecase
+	prices       PriceConverter
+	config       ProductImportConfig
+	now          func() time.Time
+}
+
+func NewProductImportUsecase(
+	importRepo repository.ProductImportRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	products ProductUsecase,
+	images ImageUsecase,
+	prices PriceConverter,
+	config ProductImportConfig,
+) ProductImportUsecase {
+	return &productImportUsecase{
+		importRepo:   importRepo,
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		products:     products,
+		images:       images,
+		prices:       prices,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (u *productImportUsecase) Import(actor Actor, input ProductImportInput) (*model.ProductImport, error) {
+	rows, err := parseProductFile(input.Format, input.Payload)
+	if err != nil {
+		return nil, err
+	}
+	productImport := &model.ProductImport{
+		UserID:    actor.UserID,
+		APIKeyID:  actor.APIKeyID,
+		Role:      actor.Role,
+		Format:    input.Format,
+		DryRun:    input.DryRun,
+		TotalRows: len(rows),
+		Errors:    []model.ProductImportError{},
+	}
+
+	if input.Async || len(rows) > u.config.SyncRows {
+		productImport.Status = model.ProductImportPending
+		productImport.Payload = input.Payload
+		if err := u.importRepo.Create(productImport); err != nil {
+			return nil, err
+		}
+		return productImport, nil
+	}
+
+	started := u.now()
+	productImport.StartedAt = &started
+	if err := u.run(context.Background(), actor, productImport, rows, nil); err != nil {
+		return nil, err
+	}
+	u.finish(productImport, nil)
+	if err := u.importRepo.Create(productImport); err != nil {
+		return nil, err
+	}
+	return productImport, nil
+}
+
+func (u *productImportUsecase) GetImport(id uint) (*model.ProductImport, error) {
+	productImport, err := u.importRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if productImport == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return productImport, nil
+}
+
+func (u *productImportUsecase) GetImports() ([]model.ProductImport, error) {
+	return u.importRepo.FindRecent(recentProductImports)
+}
+
+func (u *productImportUsecase) ProcessPending(ctx context.Context) (int, error) {
+	// Only one replica runs the job at a time, so imports still RUNNING were
+	// cut off by a crash or shutdown.
+	if n, err := u.importRepo.FailRunning(errProductImportStopped); err != nil {
+		return 0, err
+	} else if n > 0 {
+		log.Printf("product import: marked %d interrupted import(s) as failed", n)
+	}
+
+	processed := 0
+	for ctx.Err() == nil {
+		productImport, err := u.importRepo.FindNextPending()
+		if err != nil {
+			return processed, err
+		}
+		if productImport == nil {
+			break
+		}
+		if err := u.process(ctx, productImport); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// process runs a queued import on behalf of whoever uploaded it.
+func (u *productImportUsecase) process(ctx context.Context, productImport *model.ProductImport) error {
+	started := u.now()
+	productImport.Status = model.ProductImportRunning
+	productImport.StartedAt = &started
+	if err := u.importRepo.Update(productImport); err != nil {
+		return err
+	}
+
+	actor := Actor{
+		UserID:    productImport.UserID,
+		APIKeyID:  productImport.APIKeyID,
+		Role:      productImport.Role,
+		RequestID: "job:" + JobProcessProductImports,
+	}
+	rows, err := parseProductFile(productImport.Format, productImport.Payload)
+	if err == nil {
+		err = u.run(ctx, actor, productImport, rows, func() error {
+			return u.importRepo.Update(productImport)
+		})
+	}
+	if ctx.Err() != nil {
+		err = errors.New(errProductImportStopped)
+	}
+	u.finish(productImport, err)
+	return u.importRepo.Update(productImport)
+}
+
+// run imports the rows, counting them on productImport. checkpoint, if set,
+// is called every productImportCheckpoint rows to save progress. It fails
+// only when the import cannot go on; rows in error are recorded instead.
+func (u *productImportUsecase) run(ctx context.Context, actor Actor, productImport *model.ProductImport, rows []productFileRow, checkpoint func() error) error {
+	categories, err := u.categoryIndex()
+	if err != nil {
+		return err
+	}
+	seen := map[string]int{}
+	for i, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		number := i + 1
+		err := row.err
+		if err == nil {
+			err = u.importRow(actor, productImport, row.ProductRow, number, categories, seen)
+		}
+		if err != nil {
+			productImport.Failed++
+			if len(productImport.Errors) < maxProductImportErrors {
+				productImport.Errors = append(productImport.Errors, model.ProductImportError{
+					Row:     number,
+					SKU:     row.sku(),
+					Message: err.Error(),
+				})
+			}
+		}
+		productImport.ProcessedRows = number
+		if checkpoint != nil && number%productImportCheckpoint == 0 && number < len(rows) {
+			if err := checkpoint(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *productImportUsecase) finish(productImport *model.ProductImport, err error) {
+	finished := u.now()
+	productImport.FinishedAt = &finished
+	productImport.Payload = nil
+	productImport.Status = model.ProductImportCompleted
+	if err != nil {
+		productImport.Status = model.ProductImportFailed
+		productImport.Error = truncate(err.Error(), maxProductImportError)
+	}
+}
+
+// importRow creates or updates the product a row describes, or for a dry
+// run only checks that it could.
+func (u *productImportUsecase) importRow(actor Actor, productImport *model.ProductImport, row ProductRow, number int, categories categoryIndex, seen map[string]int) error {
+	product, err := u.findRowProduct(row)
+	if err != nil {
+		return err
+	}
+	// A product may only appear once in a file, so that a dry run finds the
+	// same rows the import does.
+	keys := rowKeys(product, row)
+	for _, key := range keys {
+		if first, ok := seen[key]; ok {
+			return fmt.Errorf(errProductRowRepeated, first)
+		}
+	}
+	for _, key := range keys {
+		seen[key] = number
+	}
+
+	create := product == nil
+	if create {
+		if row.Price == nil || (row.Category == nil && row.CategoryID == nil) {
+			return errors.New(errProductRowNew)
+		}
+		product = &model.Product{IsActive: true}
+	}
+	if err := u.applyRow(product, row, categories); err != nil {
+		return err
+	}
+
+	if productImport.DryRun {
+		if create {
+			productImport.Created++
+		} else {
+			productImport.Updated++
+		}
+		return nil
+	}
+	if create {
+		if err := u.createProduct(actor, product); err != nil {
+			return err
+		}
+		productImport.Created++
+		return nil
+	}
+	// Images are replaced on their own; the loaded associations are not
+	// saved back.
+	images := product.Images
+	product.Category = model.Category{}
+	product.Images = nil
+	if _, err := u.products.Update(actor, product); err != nil {
+		return err
+	}
+	if row.Images != nil {
+		urls := make([]string, len(images))
+		for i, image := range images {
+			urls[i] = image.URL
+		}
+		if err := u.images.ReplaceProductImages(product.ID, urls); err != nil {
+			return err
+		}
+	}
+	productImport.Updated++
+	return nil
+}
+
+// createProduct creates the product with its images. The database gives
+// new products is_active = true when it is left false, so products imported
+// as inactive are switched off afterwards.
+func (u *productImportUsecase) createProduct(actor Actor, product *model.Product) error {
+	active := product.IsActive
+	created, err := u.products.Create(actor, product)
+	if err != nil {
+		return err
+	}
+	if active || !created.IsActive {
+		return nil
+	}
+	product.IsActive = false
+	product.Images = nil
+	_, err = u.products.Update(actor, product)
+	return err
+}
+
+// findRowProduct returns the product a row updates, or nil for a new one.
+func (u *productImportUsecase) findRowProduct(row ProductRow) (*model.Product, error) {
+	if row.ID != nil {
+		product, err := u.productRepo.FindByID(*row.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if product == nil {
+			return nil, fmt.Errorf(errProductRowNotFound, *row.ID)
+		}
+		return product, nil
+	}
+	if sku := row.sku(); sku != "" {
+		return u.productRepo.FindBySKU(sku)
+	}
+	return nil, nil
+}
+
+// rowKeys identify the product a row is about within the file.
+func rowKeys(product *model.Product, row ProductRow) []string {
+	var keys []string
+	if product != nil {
+		keys = append(keys, fmt.Sprintf("id:%d", product.ID))
+	}
+	if sku := row.sku(); sku != "" {
+		keys = append(keys, "sku:"+sku)
+	}
+	return keys
+}
+
+// applyRow copies the fields given in the row onto the product and checks
+// the result the way ProductUsecase will, so that dry runs catch the same
+// mistakes.
+func (u *productImportUsecase) applyRow(product *model.Product, row ProductRow, categories categoryIndex) error {
+	if row.SKU != nil {
+		sku := strings.TrimSpace(*row.SKU)
+		product.SKU = &sku
+		if sku == "" {
+			product.SKU = nil
+		} else if other, err := u.productRepo.FindBySKU(sku); err != nil {
+			return err
+		} else if other != nil && other.ID != product.ID {
+			return fmt.Errorf("%w: %s", ErrDuplicateSKU, sku)
+		}
+	}
+	if row.Name != nil {
+		product.Name = strings.TrimSpace(*row.Name)
+	}
+	if product.Name == "" {
+		return errors.New(errProductRowName)
+	}
+	if row.Description != nil {
+		product.Description = *row.Description
+	}
+	if row.IsActive != nil {
+		product.IsActive = *row.IsActive
+	}
+	for _, field := range []struct {
+		name  string
+		value *float64
+		dest  *float64
+	}{
+		{"price", row.Price, &product.Price},
+		{"weight", row.Weight, &product.Weight},
+		{"length", row.Length, &product.Length},
+		{"width", row.Width, &product.Width},
+		{"height", row.Height, &product.Height},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value < 0 {
+			return fmt.Errorf(errProductRowNegative, field.name)
+		}
+		*field.dest = *field.value
+	}
+	if row.Stock != nil {
+		if *row.Stock < 0 {
+			return fmt.Errorf(errProductRowNegative, "stock")
+		}
+		product.Stock = *row.Stock
+	}
+
+	if row.TaxClass != nil {
+		class, err := NormalizeTaxClass(model.TaxClass(*row.TaxClass))
+		if err != nil {
+			return err
+		}
+		product.TaxClass = class
+	}
+	if row.Currency != nil {
+		code, err := NormalizeCurrency(*row.Currency)
+		if err != nil {
+			return err
+		}
+		if code != "" {
+			if _, err := u.prices.Rate(code); err != nil {
+				return err
+			}
+		}
+		product.Currency = code
+	}
+
+	switch {
+	case row.Category != nil && row.CategoryID != nil:
+		return errors.New(errProductRowCategories)
+	case row.Category != nil:
+		id, ok := categories.find(*row.Category)
+		if !ok {
+			return fmt.Errorf(errProductRowCategory, *row.Category)
+		}
+		product.CategoryID = id
+	case row.CategoryID != nil:
+		if _, ok := categories.byID[*row.CategoryID]; !ok {
+			return fmt.Errorf(errProductRowCategoryID, *row.CategoryID)
+		}
+		product.CategoryID = *row.CategoryID
+	}
+
+	if row.Images != nil {
+		// Uploaded images may be served from a path; those the product
+		// already has are kept as they are.
+		current := make(map[string]bool, len(product.Images))
+		for _, image := range product.Images {
+			current[image.URL] = true
+		}
+		images := make([]model.ProductImage, 0, len(row.Images))
+		for i, raw := range row.Images {
+			link := strings.TrimSpace(raw)
+			if parsed, err := url.Parse(link); !current[link] && (err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "") {
+				return fmt.Errorf(errProductRowImage, raw)
+			}
+			images = append(images, model.ProductImage{URL: link, Position: i, IsPrimary: i == 0})
+		}
+		product.Images = images
+	}
+	return nil
+}
+
+func (u *productImportUsecase) Export(format model.ProductFileFormat, filters map[string]string, w io.Writer) error {
+	categories, err := u.categoryIndex()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case model.ProductFileCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(productColumns); err != nil {
+			return err
+		}
+		err := u.productRepo.FindWithFiltersInBatches(filters, productExportBatchSize, func(products []model.Product) error {
+			for _, product := range products {
+				row := productRowOf(product, categories)
+				record := make([]string, len(productColumns))
+				for i, column := range productColumns {
+					record[i] = row.cell(column)
+				}
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	case model.ProductFileJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		separator := ""
+		err := u.productRepo.FindWithFiltersInBatches(filters, productExportBatchSize, func(products []model.Product) error {
+			for _, product := range products {
+				if _, err := io.WriteString(w, separator); err != nil {
+					return err
+				}
+				if err := encoder.Encode(productRowOf(product, categories)); err != nil {
+					return err
+				}
+				separator = ","
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "]\n")
+		return err
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidProductImport, errProductFileFormat)
+	}
+}
+
+func (u *productImportUsecase) Jobs() []Job {
+	return []Job{
+		{
+			Name:        JobProcessProductImports,
+			Description: "Run product imports queued for the background",
+			Schedule:    "* * * * *",
+			Timeout:     30 * time.Minute,
+			Run: func(ctx context.Context) (string, error) {
+				n, err := u.ProcessPending(ctx)
+				return fmt.Sprintf("ran %d imports", n), err
+			},
+		},
+	}
+}
+
+// categoryIndex finds categories by name or path. Category names are
+// unique, so the last name of a path is enough to find one; the names
+// before it have to match its parents.
+type categoryIndex struct {
+	byID   map[uint]model.Category
+	byName map[string]model.Category
+}
+
+func (u *productImportUsecase) categoryIndex() (categoryIndex, error) {
+	categories, err := u.categoryRepo.FindAll()
+	if err != nil {
+		return categoryIndex{}, err
+	}
+	return newCategoryIndex(categories), nil
+}
+
+func newCategoryIndex(categories []model.Category) categoryIndex {
+	index := categoryIndex{
+		byID:   make(map[uint]model.Category, len(categories)),
+		byName: make(map[string]model.Category, len(categories)),
+	}
+	for _, category := range categories {
+		index.byID[category.ID] = category
+		index.byName[strings.ToLower(category.Name)] = category
+	}
+	return index
+}
+
+func (i categoryIndex) find(ref string) (uint, bool) {
+	names := strings.Split(ref, strings.TrimSpace(categoryPathSeparator))
+	category, ok := i.byName[strings.ToLower(strings.TrimSpace(names[len(names)-1]))]
+	if !ok {
+		return 0, false
+	}
+	current := category
+	for n := len(names) - 2; n >= 0; n-- {
+		if current.ParentID == nil {
+			return 0, false
+		}
+		parent, ok := i.byID[*current.ParentID]
+		if !ok || !strings.EqualFold(parent.Name, strings.TrimSpace(names[n])) {
+			return 0, false
+		}
+		current = parent
+	}
+	return category.ID, true
+}
+
+// path names the category from the top, e.g. "Books > Fiction".
+func (i categoryIndex) path(id uint) string {
+	var names []string
+	for depth := 0; depth < len(i.byID); depth++ {
+		category, ok := i.byID[id]
+		if !ok {
+			break
+		}
+		names = append([]string{category.Name}, names...)
+		if category.ParentID == nil {
+			break
+		}
+		id = *category.ParentID
+	}
+	return strings.Join(names, categoryPathSeparator)
+}
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryProductImports struct {
+	imports []model.ProductImport
+}
+
+func (r *memoryProductImports) FindByID(id uint) (*model.ProductImport, error) {
+	for _, productImport := range r.imports {
+		if productImport.ID == id {
+			productImport.Payload = nil
+			return &productImport, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryProductImports) FindRecent(limit int) ([]model.ProductImport, error) {
+	var recent []model.ProductImport
+	for i := len(r.imports) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, r.imports[i])
+	}
+	return recent, nil
+}
+
+func (r *memoryProductImports) FindNextPending() (*model.ProductImport, error) {
+	for _, productImport := range r.imports {
+		if productImport.Status == model.ProductImportPending {
+			return &productImport, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryProductImports) FailRunning(reason string) (int64, error) {
+	var n int64
+	for i := range r.imports {
+		if r.imports[i].Status == model.ProductImportRunning {
+			r.imports[i].Status = model.ProductImportFailed
+			r.imports[i].Error = reason
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memoryProductImports) Create(productImport *model.ProductImport) error {
+	productImport.ID = uint(len(r.imports) + 1)
+	r.imports = append(r.imports, *productImport)
+	return nil
+}
+
+func (r *memoryProductImports) Update(productImport *model.ProductImport) error {
+	r.imports[productImport.ID-1] = *productImport
+	return nil
+}
+
+// setupProductImportUsecase sets up a catalog with the categories
+// Books > Fiction and Home and one lamp, SKU LAMP-1, in Home.
+func setupProductImportUsecase(t *testing.T) (*productImportUsecase, *mockProductRepository, *memoryProductImports) {
+	repo := newMockProductRepository()
+	products, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
+	sku := "LAMP-1"
+	_, err := products.Create(testActor, &model.Product{SKU: &sku, Name: "Lamp", Price: 40, Stock: 2, IsActive: true, CategoryID: 3})
+	assert.NoError(t, err)
+
+	categories := new(MockCategoryRepository)
+	categories.On("FindAll").Return([]model.Category{
+		{ID: 1, Name: "Books"},
+		{ID: 2, Name: "Fiction", ParentID: uintPtr(1)},
+		{ID: 3, Name: "Home"},
+	}, nil)
+	imports := &memoryProductImports{}
+	images := NewImageUsecase(&memoryProductImages{products: repo}, repo, categories, &memoryStorage{files: map[string][]byte{}}, &recordingAuditor{}, DefaultImageConfig())
+	uc := NewProductImportUsecase(imports, repo, categories, products, images, newTestCurrency(), DefaultProductImportConfig()).(*productImportUsecase)
+	return uc, repo, imports
+}
+
+func importCSV(uc *productImportUsecase, csv string, dryRun bool) (*model.ProductImport, error) {
+	return uc.Import(testActor, ProductImportInput{Format: model.ProductFileCSV, Payload: []byte(csv), DryRun: dryRun})
+}
+
+func TestProductImportUsecaseDryRun(t *testing.T) {
+	uc, repo, _ := setupProductImportUsecase(t)
+	file := "sku,name,price,stock,category,images\n" +
+		"BK-1,Dune,20,5,Books > Fiction,https://img.example.com/dune.jpg\n" +
+		"LAMP-1,,45,,,\n" +
+		"BK-2,Emma,abc,1,Books,\n" +
+		"BK-3,Ulysses,15,1,Music,\n" +
+		"BK-4,Lolita,15,1,Home > Fiction,\n" +
+		"BK-5,Beloved,15,1\n" +
+		"BK-6,Ubik,9,1,Fiction,ftp://img.example.com/ubik.jpg\n" +
+		"BK-1,Dune,20,5,Fiction,\n" +
+		"BK-7,Solaris,,1,Books,\n"
+
+	report, err := importCSV(uc, file, true)
+	// Assertion 654: A dry run should report what would be created and updated and why rows would fail
+	assert.NoError(t, err)
+	assert.Equal(t, model.ProductImportCompleted, report.Status)
+	assert.Equal(t, 9, report.TotalRows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 7, report.Failed)
+	assert.Equal(t, []model.ProductImportError{
+		{Row: 3, SKU: "BK-2", Message: `price: "abc" is not valid`},
+		{Row: 4, SKU: "BK-3", Message: `category "Music" not found`},
+		{Row: 5, SKU: "BK-4", Message: `category "Home > Fiction" not found`},
+		{Row: 6, Message: "expected 6 columns, got 4"},
+		{Row: 7, SKU: "BK-6", Message: `image "ftp://img.example.com/ubik.jpg" is not an http(s) URL`},
+		{Row: 8, SKU: "BK-1", Message: "the product is also in row 1"},
+		{Row: 9, SKU: "BK-7", Message: errProductRowNew},
+	}, report.Errors)
+
+	// Assertion 655: A dry run should not write any product
+	assert.Len(t, repo.products, 1)
+	assert.Equal(t, 40.0, repo.products[0].Price)
+}
+
+func TestProductImportUsecaseRejectsUnreadableFiles(t *testing.T) {
+	uc, _, imports := setupProductImportUsecase(t)
+
+	_, err := importCSV(uc, "sku,name,colour\nBK-1,Dune,red\n", false)
+	// Assertion 656: Import should refuse CSV files with unknown columns
+	assert.ErrorIs(t, err, ErrInvalidProductImport)
+	assert.Contains(t, err.Error(), `unknown column "colour"`)
+
+	_, err = importCSV(uc, "sku,name\n", false)
+	// Assertion 657: Import should refuse files without products
+	assert.ErrorIs(t, err, ErrInvalidProductImport)
+
+	_, err = uc.Import(testActor, ProductImportInput{Format: model.ProductFileJSON, Payload: []byte(`{"sku":"BK-1"}`)})
+	// Assertion 658: Import should refuse JSON that is not an array of products
+	assert.ErrorIs(t, err, ErrInvalidProductImport)
+	assert.Empty(t, imports.imports)
+}
+
+func TestProductImportUsecaseCreatesAndUpdates(t *testing.T) {
+	uc, repo, _ := setupProductImportUsecase(t)
+	file := "\ufeffSKU,Name,Description,Price,Stock,Is_Active,Category,Images\n" +
+		"BK-1,Dune,\"Desert planet, spice\",20,5,false,Books > Fiction,https://img.example.com/a.jpg|https://img.example.com/b.jpg\n" +
+		"LAMP-1,,,45,,,,\n"
+
+	report, err := importCSV(uc, file, false)
+	// Assertion 659: Import should create new SKUs and update known ones
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Empty(t, report.Errors)
+
+	dune, _ := repo.FindBySKU("BK-1")
+	// Assertion 660: New products should get the category found by path, their images and is_active
+	assert.Equal(t, "Desert planet, spice", dune.Description)
+	assert.Equal(t, uint(2), dune.CategoryID)
+	assert.False(t, dune.IsActive)
+	assert.Equal(t, model.TaxStandard, dune.TaxClass)
+	assert.Len(t, dune.Images, 2)
+
+	lamp, _ := repo.FindBySKU("LAMP-1")
+	// Assertion 661: Updates should change the given fields and keep the others
+	assert.Equal(t, 45.0, lamp.Price)
+	assert.Equal(t, "Lamp", lamp.Name)
+	assert.Equal(t, 2, lamp.Stock)
+	assert.Equal(t, uint(3), lamp.CategoryID)
+
+	report, err = uc.Import(testActor, ProductImportInput{Format: model.ProductFileJSON, Payload: []byte(
+		`[{"id": 2, "sku": "BK-1b", "images": []}, {"id": 99, "name": "Ghost"}, {"sku": "LAMP-1", "colour": "red"}]`,
+	)})
+	// Assertion 662: JSON rows should update by id, clear images given as [] and report unknown fields
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, "product 99 not found", report.Errors[0].Message)
+	assert.Contains(t, report.Errors[1].Message, "colour")
+	dune, _ = repo.FindByID(2)
+	assert.Equal(t, "BK-1b", *dune.SKU)
+	assert.Empty(t, dune.Images)
+}
+
+func TestProductImportUsecaseQueuesLargeFiles(t *testing.T) {
+	uc, repo, imports := setupProductImportUsecase(t)
+	uc.config.SyncRows = 2
+	var file strings.Builder
+	file.WriteString("sku,name,price,category\n")
+	for i := 0; i < 120; i++ {
+		fmt.Fprintf(&file, "BK-%03d,Book,10,Books\n", i)
+	}
+
+	report, err := importCSV(uc, file.String(), false)
+	// Assertion 663: Files over SyncRows should be queued without importing anything
+	assert.NoError(t, err)
+	assert.Equal(t, model.ProductImportPending, report.Status)
+	assert.Equal(t, 120, report.TotalRows)
+	assert.Len(t, repo.products, 1)
+
+	imports.imports = append(imports.imports, model.ProductImport{ID: 2, Status: model.ProductImportRunning})
+	n, err := uc.ProcessPending(context.Background())
+	// Assertion 664: ProcessPending should run the queued import and fail imports cut off earlier
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, model.ProductImportCompleted, imports.imports[0].Status)
+	assert.Equal(t, 120, imports.imports[0].ProcessedRows)
+	assert.Equal(t, 120, imports.imports[0].Created)
+	assert.Nil(t, imports.imports[0].Payload)
+	assert.NotNil(t, imports.imports[0].FinishedAt)
+	assert.Equal(t, model.ProductImportFailed, imports.imports[1].Status)
+	assert.Len(t, repo.products, 121)
+
+	_, err = importCSV(uc, "sku,name,price,category\nBK-Z,Book,10,Books\n", false)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, _ = uc.ProcessPending(ctx)
+	// Assertion 665: ProcessPending should not start imports once ctx is done
+	assert.Equal(t, 0, n)
+}
+
+func TestProductImportUsecaseExportRoundTrips(t *testing.T) {
+	uc, repo, _ := setupProductImportUsecase(t)
+	_, err := importCSV(uc, "sku,name,description,price,category,images\nBK-1,Dune,\"Spice, worms\",20,Fiction,https://img.example.com/a.jpg\n", false)
+	assert.NoError(t, err)
+
+	var csv bytes.Buffer
+	// Assertion 666: Export should write every product with its category path and images
+	assert.NoError(t, uc.Export(model.ProductFileCSV, map[string]string{}, &csv))
+	assert.Equal(t, "id,sku,name,description,price,currency,stock,is_active,tax_class,weight,length,width,height,category,images\n"+
+		"1,LAMP-1,Lamp,,40,USD,2,true,STANDARD,0,0,0,0,Home,\n"+
+		"2,BK-1,Dune,\"Spice, worms\",20,USD,0,true,STANDARD,0,0,0,0,Books > Fiction,https://img.example.com/a.jpg\n", csv.String())
+
+	var json bytes.Buffer
+	assert.NoError(t, uc.Export(model.ProductFileJSON, map[string]string{}, &json))
+	// Assertion 667: JSON exports should be an array of rows
+	assert.True(t, strings.HasPrefix(json.String(), `[{"id":1,"sku":"LAMP-1","name":"Lamp",`))
+	assert.Contains(t, json.String(), `"category":"Books > Fiction","images":["https://img.example.com/a.jpg"]}`)
+
+	before := append([]model.Product(nil), repo.products...)
+	report, err := uc.Import(testActor, ProductImportInput{Format: model.ProductFileJSON, Payload: json.Bytes()})
+	// Assertion 668: Importing an export should update the same products without changing them
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Updated)
+	assert.Zero(t, report.Failed)
+	for i, product := range repo.products {
+		assert.Equal(t, before[i].Price, product.Price)
+		assert.Equal(t, before[i].CategoryID, product.CategoryID)
+		assert.Equal(t, len(before[i].Images), len(product.Images))
+	}
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/domain/repository"
@@ -9,24 +11,95 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	ErrDuplicateSKU       = NewError(KindConflict, "duplicate_sku", "another product has this SKU")
+	ErrInvalidProductData = NewError(KindValidation, "invalid_product_data", "invalid product data", FieldError{Field: "name", Code: FieldRequired})
+	ErrInvalidProduct     = NewError(KindValidation, "invalid_product", "invalid product")
+)
+
+// ProductUsecase shows products at the price they sell at now, with the
+// regular price in CompareAtPrice while they are on sale. Create and Update
+// take the regular price.
 type ProductUsecase interface {
 	GetByID(id uint) (*model.Product, error)
 	GetAll() ([]model.Product, error)
 	GetWithFilters(filters map[string]string) ([]model.Product, error)
-	Create(product *model.Product) (*model.Product, error)
-	Update(product *model.Product) (*model.Product, error)
-	Delete(id uint) error
+	Create(actor Actor, product *model.Product) (*model.Product, error)
+	Update(actor Actor, product *model.Product) (*model.Product, error)
+	Delete(actor Actor, id uint) error
+	// GetBySlug finds the product by its slug or by one it had before, in
+	// which case the product returned has a different slug.
+	GetBySlug(slug string) (*model.Product, error)
 }
 
 type productUsecase struct {
 	productRepo repository.ProductRepository
+	transactor  repository.Transactor
+	prices      PriceConverter
+	pricing     PricingUsecase
+	slugs       slugs
+	auditor     Auditor
 }
 
-func NewProductUsecase(productRepo repository.ProductRepository) ProductUsecase {
-	return &productUsecase{productRepo: productRepo}
+func NewProductUsecase(
+	productRepo repository.ProductRepository,
+	transactor repository.Transactor,
+	prices PriceConverter,
+	pricing PricingUsecase,
+	redirects repository.SlugRedirectRepository,
+	auditor Auditor,
+) ProductUsecase {
+	return &productUsecase{
+		productRepo: productRepo,
+		transactor:  transactor,
+		prices:      prices,
+		pricing:     pricing,
+		slugs:       productSlugs(productRepo, redirects),
+		auditor:     auditor,
+	}
 }
 
-func (u *productUsecase) GetByID(id uint) (*model.Product, error) {
+// normalizeCurrency upper-cases the currency a product is priced in,
+// defaulting to the base currency. Products can only be priced in
+// currencies with an exchange rate, so that they can be converted.
+func (u *productUsecase) normalizeCurrency(currency string) (string, error) {
+	code, err := NormalizeCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+	if code == "" {
+		return u.prices.BaseCurrency(), nil
+	}
+	if _, err := u.prices.Rate(code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// normalizeSKU trims the product's SKU, dropping an empty one, and makes
+// sure no other product has it.
+func (u *productUsecase) normalizeSKU(product *model.Product) error {
+	if product.SKU == nil {
+		return nil
+	}
+	sku := strings.TrimSpace(*product.SKU)
+	if sku == "" {
+		product.SKU = nil
+		return nil
+	}
+	product.SKU = &sku
+	other, err := u.productRepo.FindBySKU(sku)
+	if err != nil {
+		return err
+	}
+	if other != nil && other.ID != product.ID {
+		return fmt.Errorf("%w: %s", ErrDuplicateSKU, sku)
+	}
+	return nil
+}
+
+// find returns the product as stored, at its regular price.
+func (u *productUsecase) find(id uint) (*model.Product, error) {
 	prod, err := u.productRepo.FindByID(id)
 	if err != nil {
 		return nil, err
@@ -37,38 +110,174 @@ func (u *productUsecase) GetByID(id uint) (*model.Product, error) {
 	return prod, nil
 }
 
+// withPrices shows products at the price they sell at now.
+func (u *productUsecase) withPrices(products []model.Product, err error) ([]model.Product, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := u.pricing.ApplyPrices(products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// withPrice shows one product at the price it sells at now.
+func (u *productUsecase) withPrice(product *model.Product, err error) (*model.Product, error) {
+	if err != nil {
+		return nil, err
+	}
+	products, err := u.withPrices([]model.Product{*product}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+func (u *productUsecase) GetByID(id uint) (*model.Product, error) {
+	return u.withPrice(u.find(id))
+}
+
 func (u *productUsecase) GetAll() ([]model.Product, error) {
-	return u.productRepo.FindAll()
+	return u.withPrices(u.productRepo.FindAll())
 }
 
 func (u *productUsecase) GetWithFilters(filters map[string]string) ([]model.Product, error) {
-	return u.productRepo.FindWithFilters(filters)
+	return u.withPrices(u.productRepo.FindWithFilters(filters))
+}
+
+func (u *productUsecase) GetBySlug(slug string) (*model.Product, error) {
+	prod, err := u.productRepo.FindBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if prod != nil {
+		return u.withPrice(prod, nil)
+	}
+	redirect, err := u.slugs.redirects.Find(model.SlugEntityProduct, slug)
+	if err != nil {
+		return nil, err
+	}
+	if redirect == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u.GetByID(redirect.EntityID)
+}
+
+// normalizeSEO picks the product's slug and trims its meta fields.
+func (u *productUsecase) normalizeSEO(product *model.Product) (err error) {
+	product.MetaTitle = strings.TrimSpace(product.MetaTitle)
+	product.MetaDescription = strings.TrimSpace(product.MetaDescription)
+	product.Slug, err = u.slugs.choose(product.ID, product.Slug, product.Name)
+	return err
 }
 
-func (u *productUsecase) Create(product *model.Product) (*model.Product, error) {
+func (u *productUsecase) Create(actor Actor, product *model.Product) (*model.Product, error) {
 	if product == nil || product.Name == "" {
-		return nil, errors.New("invalid product data")
+		return nil, ErrInvalidProductData
+	}
+	class, err := NormalizeTaxClass(product.TaxClass)
+	if err != nil {
+		return nil, err
+	}
+	product.TaxClass = class
+	if product.Currency, err = u.normalizeCurrency(product.Currency); err != nil {
+		return nil, err
 	}
-	if err := u.productRepo.Create(product); err != nil {
+	if err := u.normalizeSKU(product); err != nil {
 		return nil, err
 	}
-	return u.productRepo.FindByID(product.ID)
+	if err := u.normalizeSEO(product); err != nil {
+		return nil, err
+	}
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if err := repos.Products.Create(product); err != nil {
+			return err
+		}
+		if err := u.slugs.using(repos.SlugRedirects).changed(product.ID, "", product.Slug); err != nil {
+			return err
+		}
+		return u.pricing.RecordPrice(repos.PriceHistory, product, model.PriceChangeCreated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	created, err := u.find(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityProduct, product.ID, nil, created)
+	return u.withPrice(created, nil)
 }
 
-func (u *productUsecase) Update(product *model.Product) (*model.Product, error) {
+func (u *productUsecase) Update(actor Actor, product *model.Product) (*model.Product, error) {
 	if product == nil || product.ID == 0 {
-		return nil, errors.New("invalid product")
+		return nil, ErrInvalidProduct
+	}
+	before, err := u.find(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = before.TaxClass
 	}
-	if err := u.productRepo.Update(product); err != nil {
+	if product.TaxClass, err = NormalizeTaxClass(product.TaxClass); err != nil {
+		return nil, err
+	}
+	if product.Currency == "" {
+		product.Currency = before.Currency
+	}
+	if product.Currency, err = u.normalizeCurrency(product.Currency); err != nil {
+		return nil, err
+	}
+	if err := u.normalizeSKU(product); err != nil {
+		return nil, err
+	}
+	if product.Slug == "" {
+		product.Slug = before.Slug
+	}
+	if err := u.normalizeSEO(product); err != nil {
+		return nil, err
+	}
+	var events []model.DomainEvent
+	if product.Price != before.Price {
+		events = append(events, model.ProductPriceChanged{
+			ProductID: product.ID,
+			OldPrice:  before.Price,
+			NewPrice:  product.Price,
+			Currency:  product.Currency,
+		})
+	}
+	if event, ok := stockLowEvent(product, before.Stock); ok {
+		events = append(events, event)
+	}
+
+	err = u.transactor.WithinTransaction(func(repos repository.TxRepositories) error {
+		if err := repos.Products.Update(product); err != nil {
+			return err
+		}
+		if err := u.slugs.using(repos.SlugRedirects).changed(product.ID, before.Slug, product.Slug); err != nil {
+			return err
+		}
+		if err := u.pricing.RecordPrice(repos.PriceHistory, product, model.PriceChangeUpdated); err != nil {
+			return err
+		}
+		return appendEvents(repos.Outbox, events...)
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, gorm.ErrRecordNotFound
 		}
 		return nil, err
 	}
-	return u.productRepo.FindByID(product.ID)
+	updated, err := u.find(product.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityProduct, product.ID, before, updated)
+	return u.withPrice(updated, nil)
 }
 
-func (u *productUsecase) Delete(id uint) error {
+func (u *productUsecase) Delete(actor Actor, id uint) error {
 	prod, err := u.productRepo.FindByID(id)
 	if err != nil {
 		return err
@@ -76,5 +285,9 @@ func (u *productUsecase) Delete(id uint) error {
 	if prod == nil {
 		return gorm.ErrRecordNotFound
 	}
-	return u.productRepo.Delete(id)
+	if err := u.productRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityProduct, id, prod, nil)
+	return nil
 }
@@ -1,9 +1,11 @@
 package usecase
 
 import (
+	"errors"
 	"testing"
 
 	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
 
 	"gorm.io/gorm"
 )
@@ -27,6 +29,15 @@ func newMockProductRepository() *mockProductRepository {
 	}
 }
 
+// newTestProductUsecase wires the product usecase so that the slug redirects
+// and price history written in its transactions are the ones it reads.
+func newTestProductUsecase(repo *mockProductRepository, prices PriceConverter, pricing *pricingUsecase, redirects repository.SlugRedirectRepository) (ProductUsecase, *fakeTransactor) {
+	transactor := newFakeTransactor(nil, nil, nil, repo)
+	transactor.repos.SlugRedirects = redirects
+	transactor.repos.PriceHistory = pricing.historyRepo
+	return NewProductUsecase(repo, transactor, prices, pricing, redirects, &recordingAuditor{}), transactor
+}
+
 func (m *mockProductRepository) FindByID(id uint) (*model.Product, error) {
 	for _, product := range m.products {
 		if product.ID == id {
@@ -36,6 +47,43 @@ func (m *mockProductRepository) FindByID(id uint) (*model.Product, error) {
 	return nil, gorm.ErrRecordNotFound
 }
 
+func (m *mockProductRepository) FindBySKU(sku string) (*model.Product, error) {
+	for _, product := range m.products {
+		if product.SKU != nil && *product.SKU == sku {
+			return &product, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockProductRepository) FindBySlug(slug string) (*model.Product, error) {
+	for _, product := range m.products {
+		if product.Slug == slug {
+			return &product, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockProductRepository) SlugTaken(slug string, exceptID uint) (bool, error) {
+	for _, product := range m.products {
+		if product.Slug == slug && product.ID != exceptID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockProductRepository) FindWithoutSlug() ([]model.Product, error) {
+	var result []model.Product
+	for _, product := range m.products {
+		if product.Slug == "" {
+			result = append(result, product)
+		}
+	}
+	return result, nil
+}
+
 func (m *mockProductRepository) FindAll() ([]model.Product, error) {
 	return m.products, nil
 }
@@ -58,6 +106,20 @@ func (m *mockProductRepository) FindWithFilters(filters map[string]string) ([]mo
 	return result, nil
 }
 
+func (m *mockProductRepository) FindWithFiltersInBatches(filters map[string]string, batchSize int, fn func(products []model.Product) error) error {
+	products, _ := m.FindWithFilters(filters)
+	for start := 0; start < len(products); start += batchSize {
+		end := start + batchSize
+		if end > len(products) {
+			end = len(products)
+		}
+		if err := fn(products[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *mockProductRepository) Create(product *model.Product) error {
 	product.ID = m.nextID
 	m.nextID++
@@ -87,7 +149,7 @@ func (m *mockProductRepository) Delete(id uint) error {
 
 func TestProductUsecaseGetByID(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Test Case 1: Get non-existent product
 	product, err := usecase.GetByID(999)
@@ -139,7 +201,7 @@ func TestProductUsecaseGetByID(t *testing.T) {
 
 func TestProductUsecaseGetAll(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Test Case 3: Get all products from empty repository
 	products, err := usecase.GetAll()
@@ -189,7 +251,7 @@ func TestProductUsecaseGetAll(t *testing.T) {
 
 func TestProductUsecaseGetWithFilters(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Add test products
 	testProducts := []*model.Product{
@@ -235,10 +297,10 @@ func TestProductUsecaseGetWithFilters(t *testing.T) {
 
 func TestProductUsecaseCreate(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Test Case 7: Create product with nil input
-	product, err := usecase.Create(nil)
+	product, err := usecase.Create(testActor, nil)
 	// Assertion 20: Should return error for nil product
 	if err == nil {
 		t.Error("Expected error for nil product")
@@ -254,7 +316,7 @@ func TestProductUsecaseCreate(t *testing.T) {
 
 	// Test Case 8: Create product with empty name
 	emptyProduct := &model.Product{Name: "", Price: 10.0}
-	product, err = usecase.Create(emptyProduct)
+	product, err = usecase.Create(testActor, emptyProduct)
 	// Assertion 23: Should return error for empty name
 	if err == nil {
 		t.Error("Expected error for empty product name")
@@ -274,7 +336,7 @@ func TestProductUsecaseCreate(t *testing.T) {
 		IsActive:    true,
 		CategoryID:  1,
 	}
-	product, err = usecase.Create(validProduct)
+	product, err = usecase.Create(testActor, validProduct)
 	// Assertion 25: No error should occur for valid product creation
 	if err != nil {
 		t.Errorf(errExpectedNoError, err)
@@ -300,10 +362,10 @@ func TestProductUsecaseCreate(t *testing.T) {
 
 func TestProductUsecaseUpdate(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Test Case 10: Update with nil product
-	product, err := usecase.Update(nil)
+	product, err := usecase.Update(testActor, nil)
 	// Assertion 30: Should return error for nil product update
 	if err == nil {
 		t.Error("Expected error for nil product")
@@ -315,7 +377,7 @@ func TestProductUsecaseUpdate(t *testing.T) {
 
 	// Test Case 11: Update with zero ID
 	zeroIDProduct := &model.Product{ID: 0, Name: "Test"}
-	_, err = usecase.Update(zeroIDProduct)
+	_, err = usecase.Update(testActor, zeroIDProduct)
 	// Assertion 32: Should return error for zero ID
 	if err == nil {
 		t.Error("Expected error for zero ID")
@@ -338,7 +400,7 @@ func TestProductUsecaseUpdate(t *testing.T) {
 
 	// Test Case 12: Update non-existent product
 	nonExistentProduct := &model.Product{ID: 999, Name: "Non-existent"}
-	_, err = usecase.Update(nonExistentProduct)
+	_, err = usecase.Update(testActor, nonExistentProduct)
 	// Assertion 34: Should return ErrRecordNotFound for non-existent product
 	if err != gorm.ErrRecordNotFound {
 		t.Errorf(errExpectedGormNotFound, err)
@@ -354,7 +416,7 @@ func TestProductUsecaseUpdate(t *testing.T) {
 		IsActive:   false,
 		CategoryID: 2,
 	}
-	product, err = usecase.Update(updateProduct)
+	product, err = usecase.Update(testActor, updateProduct)
 	// Assertion 35: No error should occur for valid update
 	if err != nil {
 		t.Errorf(errExpectedNoError, err)
@@ -380,10 +442,10 @@ func TestProductUsecaseUpdate(t *testing.T) {
 
 func TestProductUsecaseDelete(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	// Test Case 14: Delete non-existent product
-	err := usecase.Delete(999)
+	err := usecase.Delete(testActor, 999)
 	// Assertion 40: Should return ErrRecordNotFound for non-existent product
 	if err != gorm.ErrRecordNotFound {
 		t.Errorf(errExpectedGormNotFound, err)
@@ -412,7 +474,7 @@ func TestProductUsecaseDelete(t *testing.T) {
 	}
 
 	// Test Case 15: Delete existing product
-	err = usecase.Delete(1)
+	err = usecase.Delete(testActor, 1)
 	// Assertion 43: No error should occur for valid deletion
 	if err != nil {
 		t.Errorf("Expected no error for valid deletion, got %v", err)
@@ -451,7 +513,7 @@ func createTestProducts(usecase ProductUsecase) []*model.Product {
 
 	var createdProducts []*model.Product
 	for _, p := range products {
-		created, _ := usecase.Create(p)
+		created, _ := usecase.Create(testActor, p)
 		createdProducts = append(createdProducts, created)
 	}
 	return createdProducts
@@ -459,7 +521,7 @@ func createTestProducts(usecase ProductUsecase) []*model.Product {
 
 func TestProductUsecaseIntegrationCreateMultiple(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	products := createTestProducts(usecase)
 
@@ -489,7 +551,7 @@ func TestProductUsecaseIntegrationCreateMultiple(t *testing.T) {
 
 func TestProductUsecaseIntegrationFilterActive(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	createTestProducts(usecase)
 
@@ -508,7 +570,7 @@ func TestProductUsecaseIntegrationFilterActive(t *testing.T) {
 
 func TestProductUsecaseIntegrationUpdateProduct(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	createTestProducts(usecase)
 
@@ -522,7 +584,7 @@ func TestProductUsecaseIntegrationUpdateProduct(t *testing.T) {
 		IsActive:   true,
 		CategoryID: 1,
 	}
-	updated, err := usecase.Update(updateData)
+	updated, err := usecase.Update(testActor, updateData)
 	// Assertion 54: No error should occur during update
 	if err != nil {
 		t.Errorf("Expected no error updating product, got %v", err)
@@ -539,12 +601,12 @@ func TestProductUsecaseIntegrationUpdateProduct(t *testing.T) {
 
 func TestProductUsecaseIntegrationDeleteProduct(t *testing.T) {
 	repo := newMockProductRepository()
-	usecase := NewProductUsecase(repo)
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
 
 	createTestProducts(usecase)
 
 	// Delete second product
-	err := usecase.Delete(2)
+	err := usecase.Delete(testActor, 2)
 	// Assertion 57: No error should occur during deletion
 	if err != nil {
 		t.Errorf("Expected no error deleting product, got %v", err)
@@ -568,3 +630,93 @@ func TestProductUsecaseIntegrationDeleteProduct(t *testing.T) {
 		t.Errorf("Expected gorm.ErrRecordNotFound for deleted product, got %v", err)
 	}
 }
+
+func TestProductUsecaseUpdateRecordsEvents(t *testing.T) {
+	repo := newMockProductRepository()
+	usecase, transactor := newTestProductUsecase(repo, setupCurrencyUsecase(t), newTestPricing(), newMemorySlugRedirects())
+
+	repo.Create(&model.Product{Name: "Lamp", Price: 20, Currency: "EUR", Stock: 10})
+
+	// Test Case 24: Update that changes neither price nor crosses the stock threshold
+	_, err := usecase.Update(testActor, &model.Product{ID: 1, Name: "Lamp", Price: 20, Currency: "EUR", Stock: 9})
+	// Assertion 515: No events should be raised
+	if err != nil || len(transactor.outbox.events) != 0 {
+		t.Errorf("Expected no events, got %v (err %v)", transactor.outbox.types(), err)
+	}
+
+	// Test Case 25: Price change and stock drop below the threshold
+	_, err = usecase.Update(testActor, &model.Product{ID: 1, Name: "Lamp", Price: 25, Currency: "EUR", Stock: 2})
+	// Assertion 516: One event each should be raised
+	types := transactor.outbox.types()
+	if err != nil || len(types) != 2 || types[0] != model.EventProductPriceChanged || types[1] != model.EventStockLow {
+		t.Fatalf("Expected ProductPriceChanged and StockLow, got %v (err %v)", types, err)
+	}
+
+	var changed model.ProductPriceChanged
+	if err := transactor.outbox.events[0].Decode(&changed); err != nil {
+		t.Fatal(err)
+	}
+	// Assertion 517: ProductPriceChanged should carry old and new price
+	if changed.OldPrice != 20 || changed.NewPrice != 25 || changed.Currency != "EUR" {
+		t.Errorf("Unexpected payload %+v", changed)
+	}
+}
+
+func TestProductUsecaseTaxClass(t *testing.T) {
+	repo := newMockProductRepository()
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
+
+	// Test Case 28: Products get the standard tax class unless another one is given
+	created, err := usecase.Create(testActor, &model.Product{Name: "Lamp", Price: 20})
+	// Assertion 598: An empty tax class should default to STANDARD
+	if err != nil || created.TaxClass != model.TaxStandard {
+		t.Errorf("Expected STANDARD tax class, got %q (err %v)", created.TaxClass, err)
+	}
+
+	// Test Case 29: Unknown tax classes are rejected
+	_, err = usecase.Update(testActor, &model.Product{ID: created.ID, Name: "Lamp", Price: 20, TaxClass: "LUXURY"})
+	// Assertion 599: Update should fail with ErrInvalidTaxClass
+	if !errors.Is(err, ErrInvalidTaxClass) {
+		t.Errorf("Expected ErrInvalidTaxClass, got %v", err)
+	}
+}
+
+func TestProductUsecaseCurrency(t *testing.T) {
+	repo := newMockProductRepository()
+	usecase, _ := newTestProductUsecase(repo, setupCurrencyUsecase(t), newTestPricing(), newMemorySlugRedirects())
+
+	// Test Case 30: Products are priced in the base currency unless another one is given
+	created, err := usecase.Create(testActor, &model.Product{Name: "Lamp", Price: 20})
+	// Assertion 637: An empty currency should default to the base currency
+	if err != nil || created.Currency != "USD" {
+		t.Errorf("Expected USD, got %q (err %v)", created.Currency, err)
+	}
+
+	// Test Case 31: Currencies without an exchange rate are rejected
+	_, err = usecase.Create(testActor, &model.Product{Name: "Kettle", Price: 20, Currency: "GBP"})
+	// Assertion 638: Create should fail with ErrUnsupportedCurrency
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestProductUsecaseSKU(t *testing.T) {
+	repo := newMockProductRepository()
+	usecase, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), newMemorySlugRedirects())
+	sku := " LAMP-1 "
+	lamp, _ := usecase.Create(testActor, &model.Product{Name: "Lamp", Price: 20, SKU: &sku})
+
+	// Test Case 32: SKUs are trimmed and kept by their product on update
+	updated, err := usecase.Update(testActor, &model.Product{ID: lamp.ID, Name: "Lamp", Price: 25, SKU: &sku})
+	// Assertion 669: Update should keep the product's own SKU
+	if err != nil || updated.SKU == nil || *updated.SKU != "LAMP-1" {
+		t.Errorf("Expected SKU LAMP-1, got %v (err %v)", updated.SKU, err)
+	}
+
+	// Test Case 33: Another product cannot take the SKU
+	_, err = usecase.Create(testActor, &model.Product{Name: "Kettle", Price: 20, SKU: &sku})
+	// Assertion 670: Create should fail with ErrDuplicateSKU
+	if !errors.Is(err, ErrDuplicateSKU) {
+		t.Errorf("Expected ErrDuplicateSKU, got %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/cron"
+
+	"gorm.io/gorm"
+)
+
+// Scheduler settings. Schedules have minute resolution, so checking a few
+// times a minute is enough. A lease outlives the job timeout by
+// jobLeaseMargin so a slow replica cannot lose it while still running.
+// Jobs cancelled on Stop get jobCancelWait to return and record their run.
+const (
+	schedulerTick     = 10 * time.Second
+	defaultJobTimeout = 5 * time.Minute
+	jobLeaseMargin    = time.Minute
+	jobMaxResultLen   = 500
+	jobCancelWait     = 5 * time.Second
+)
+
+var (
+	ErrInvalidJob = NewError(KindValidation, "invalid_job", "invalid job")
+	ErrJobLocked  = NewError(KindConflict, "job_locked", "job is already running")
+)
+
+// Job is a recurring task. Run receives a context that is cancelled when the
+// timeout elapses or the scheduler is stopped, and returns a short summary.
+type Job struct {
+	Name        string
+	Description string
+	// Schedule is a five-field cron expression, evaluated in local time.
+	Schedule string
+	Timeout  time.Duration
+	Run      func(ctx context.Context) (string, error)
+}
+
+// JobInfo describes a registered job for the admin API.
+type JobInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	Timeout     string     `json:"timeout"`
+	NextRunAt   *time.Time `json:"next_run_at,omitempty"`
+	Running     bool       `json:"running"`
+}
+
+type SchedulerUsecase interface {
+	// Register adds a job. Call it before Run.
+	Register(job Job) error
+	// Run starts due jobs until ctx is done. Jobs already running keep going;
+	// use Stop to wait for them.
+	Run(ctx context.Context)
+	// Stop waits up to grace for running jobs, then cancels them and waits a
+	// little longer for them to return. It reports whether they all finished
+	// within grace. Call it once Run has returned.
+	Stop(grace time.Duration) bool
+	// RunNow starts a job outside its schedule and returns the RUNNING record.
+	RunNow(name string) (*model.JobRun, error)
+	GetJobs() []JobInfo
+	GetRuns(filters map[string]string) ([]model.JobRun, error)
+}
+
+type scheduledJob struct {
+	Job
+	schedule *cron.Schedule
+	next     time.Time
+	running  bool
+}
+
+type schedulerUsecase struct {
+	leaseRepo repository.JobLeaseRepository
+	runRepo   repository.JobRunRepository
+	owner     string
+	now       func() time.Time
+	// cancelWait bounds the wait for cancelled jobs on Stop.
+	cancelWait time.Duration
+
+	mu    sync.Mutex
+	jobs  map[string]*scheduledJob
+	order []string
+
+	// ctx is the parent of every job context; cancel aborts them on Stop.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewSchedulerUsecase(leaseRepo repository.JobLeaseRepository, runRepo repository.JobRunRepository) SchedulerUsecase {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &schedulerUsecase{
+		leaseRepo:  leaseRepo,
+		runRepo:    runRepo,
+		owner:      schedulerOwner(),
+		now:        time.Now,
+		cancelWait: jobCancelWait,
+		jobs:       make(map[string]*scheduledJob),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// schedulerOwner identifies this process in leases and run history.
+func schedulerOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		b := make([]byte, 4)
+		_, _ = rand.Read(b)
+		host = hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+func (u *schedulerUsecase) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("%w: name and run function are required", ErrInvalidJob)
+	}
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidJob, job.Name, err)
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultJobTimeout
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, exists := u.jobs[job.Name]; exists {
+		return fmt.Errorf("%w: %s is already registered", ErrInvalidJob, job.Name)
+	}
+	u.jobs[job.Name] = &scheduledJob{Job: job, schedule: schedule, next: schedule.Next(u.now())}
+	u.order = append(u.order, job.Name)
+	return nil
+}
+
+func (u *schedulerUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	for {
+		u.tick()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick starts every job whose next slot has come. Missed slots are not
+// caught up: after downtime a job runs once and resumes its schedule.
+func (u *schedulerUsecase) tick() {
+	now := u.now()
+
+	u.mu.Lock()
+	var due []*scheduledJob
+	var slots []time.Time
+	for _, name := range u.order {
+		job := u.jobs[name]
+		if job.next.IsZero() || job.next.After(now) {
+			continue
+		}
+		due = append(due, job)
+		slots = append(slots, job.next)
+		job.next = job.schedule.Next(now)
+	}
+	u.mu.Unlock()
+
+	for i, job := range due {
+		slot := slots[i]
+		if _, err := u.start(job, model.JobTriggerSchedule, &slot); err != nil && !errors.Is(err, ErrJobLocked) {
+			log.Printf("scheduler: %s: %v", job.Name, err)
+		}
+	}
+}
+
+func (u *schedulerUsecase) Stop(grace time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		u.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(grace):
+		u.cancel()
+		// A job that ignores the cancellation is abandoned.
+		select {
+		case <-done:
+		case <-time.After(u.cancelWait):
+		}
+		return false
+	}
+}
+
+func (u *schedulerUsecase) RunNow(name string) (*model.JobRun, error) {
+	u.mu.Lock()
+	job, ok := u.jobs[name]
+	u.mu.Unlock()
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return u.start(job, model.JobTriggerManual, nil)
+}
+
+// start claims the lease for the slot (now for manual runs), records the run
+// and executes the job in the background.
+func (u *schedulerUsecase) start(job *scheduledJob, trigger model.JobTrigger, slot *time.Time) (*model.JobRun, error) {
+	u.mu.Lock()
+	if job.running {
+		u.mu.Unlock()
+		return nil, ErrJobLocked
+	}
+	job.running = true
+	u.mu.Unlock()
+
+	run, err := u.claim(job, trigger, slot)
+	if err != nil {
+		u.mu.Lock()
+		job.running = false
+		u.mu.Unlock()
+		return nil, err
+	}
+
+	started := *run
+	u.running.Add(1)
+	go u.execute(job, run)
+	return &started, nil
+}
+
+func (u *schedulerUsecase) claim(job *scheduledJob, trigger model.JobTrigger, slot *time.Time) (*model.JobRun, error) {
+	now := u.now()
+	leaseSlot := now
+	if slot != nil {
+		leaseSlot = *slot
+	}
+
+	acquired, err := u.leaseRepo.Acquire(job.Name, u.owner, leaseSlot, now, now.Add(job.Timeout+jobLeaseMargin))
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if !acquired {
+		return nil, ErrJobLocked
+	}
+
+	run := &model.JobRun{
+		JobName:      job.Name,
+		Trigger:      trigger,
+		Owner:        u.owner,
+		ScheduledFor: slot,
+		StartedAt:    now,
+		Status:       model.JobRunning,
+	}
+	if err := u.runRepo.Create(run); err != nil {
+		_ = u.leaseRepo.Release(job.Name, u.owner)
+		return nil, fmt.Errorf("failed to record job run: %w", err)
+	}
+	return run, nil
+}
+
+func (u *schedulerUsecase) execute(job *scheduledJob, run *model.JobRun) {
+	defer u.running.Done()
+	defer func() {
+		u.mu.Lock()
+		job.running = false
+		u.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(u.ctx, job.Timeout)
+	result, err := safeRun(ctx, job.Run)
+	cancel()
+
+	finished := u.now()
+	run.FinishedAt = &finished
+	run.DurationMs = finished.Sub(run.StartedAt).Milliseconds()
+	run.Result = truncate(result, jobMaxResultLen)
+	if err != nil {
+		run.Status = model.JobFailed
+		run.Error = truncate(err.Error(), jobMaxResultLen)
+		log.Printf("scheduler: %s failed: %v", job.Name, err)
+	} else {
+		run.Status = model.JobSucceeded
+	}
+
+	if err := u.runRepo.Update(run); err != nil {
+		log.Printf("scheduler: %s: failed to record run %d: %v", job.Name, run.ID, err)
+	}
+	if err := u.leaseRepo.Release(job.Name, u.owner); err != nil {
+		log.Printf("scheduler: %s: failed to release lease: %v", job.Name, err)
+	}
+}
+
+// safeRun turns a job panic into an error so it is recorded like any failure.
+func safeRun(ctx context.Context, run func(context.Context) (string, error)) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+func (u *schedulerUsecase) GetJobs() []JobInfo {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(u.order))
+	for _, name := range u.order {
+		job := u.jobs[name]
+		info := JobInfo{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.Schedule,
+			Timeout:     job.Timeout.String(),
+			Running:     job.running,
+		}
+		if !job.next.IsZero() {
+			next := job.next
+			info.NextRunAt = &next
+		}
+		jobs = append(jobs, info)
+	}
+	return jobs
+}
+
+func (u *schedulerUsecase) GetRuns(filters map[string]string) ([]model.JobRun, error) {
+	return u.runRepo.FindWithFilters(filters)
+}
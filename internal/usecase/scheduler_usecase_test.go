@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// memoryJobLeases is an in-memory JobLeaseRepository shared by simulated replicas.
+type memoryJobLeases struct {
+	mu     sync.Mutex
+	leases map[string]model.JobLease
+}
+
+func newMemoryJobLeases() *memoryJobLeases {
+	return &memoryJobLeases{leases: make(map[string]model.JobLease)}
+}
+
+func (r *memoryJobLeases) Acquire(name, owner string, slot, now, until time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease := r.leases[name]
+	if lease.LockedUntil.After(now) || (lease.LastSlot != nil && !lease.LastSlot.Before(slot)) {
+		return false, nil
+	}
+	r.leases[name] = model.JobLease{Name: name, Owner: owner, LockedUntil: until, LastSlot: &slot}
+	return true, nil
+}
+
+func (r *memoryJobLeases) Release(name, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[name]; ok && lease.Owner == owner {
+		lease.LockedUntil = time.Time{}
+		r.leases[name] = lease
+	}
+	return nil
+}
+
+// memoryJobRuns is an in-memory JobRunRepository.
+type memoryJobRuns struct {
+	mu   sync.Mutex
+	runs []model.JobRun
+}
+
+func (r *memoryJobRuns) FindWithFilters(filters map[string]string) ([]model.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []model.JobRun
+	for _, run := range r.runs {
+		if v, ok := filters["job"]; ok && run.JobName != v {
+			continue
+		}
+		out = append(out, run)
+	}
+	return out, nil
+}
+
+func (r *memoryJobRuns) Create(run *model.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uint(len(r.runs) + 1)
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func (r *memoryJobRuns) Update(run *model.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID-1] = *run
+	return nil
+}
+
+func (r *memoryJobRuns) get(id uint) model.JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.runs[id-1]
+}
+
+func newTestScheduler(leases *memoryJobLeases, runs *memoryJobRuns, owner string, now time.Time) *schedulerUsecase {
+	s := NewSchedulerUsecase(leases, runs).(*schedulerUsecase)
+	s.owner = owner
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSchedulerUsecaseRegisterParsesSchedules(t *testing.T) {
+	now := time.Date(2024, 5, 10, 10, 7, 30, 0, time.UTC) // a Friday
+	s := newTestScheduler(newMemoryJobLeases(), &memoryJobRuns{}, "a", now)
+	noop := func(context.Context) (string, error) { return "", nil }
+
+	// Assertion 530: Malformed cron expressions are rejected
+	assert.ErrorIs(t, s.Register(Job{Name: "bad", Schedule: "61 * * * *", Run: noop}), ErrInvalidJob)
+	assert.ErrorIs(t, s.Register(Job{Name: "short", Schedule: "* * *", Run: noop}), ErrInvalidJob)
+
+	assert.NoError(t, s.Register(Job{Name: "quarter", Schedule: "*/15 * * * *", Run: noop}))
+	assert.NoError(t, s.Register(Job{Name: "nightly", Schedule: "30 3 * * *", Run: noop}))
+	assert.NoError(t, s.Register(Job{Name: "monday-or-first", Schedule: "0 0 1 * 1", Run: noop}))
+
+	jobs := s.GetJobs()
+	// Assertion 531: The next run is the first matching minute after now
+	if assert.Len(t, jobs, 3) {
+		assert.Equal(t, time.Date(2024, 5, 10, 10, 15, 0, 0, time.UTC), *jobs[0].NextRunAt)
+		assert.Equal(t, time.Date(2024, 5, 11, 3, 30, 0, 0, time.UTC), *jobs[1].NextRunAt)
+		// Restricted day-of-month and day-of-week match either way, like Vixie cron
+		assert.Equal(t, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), *jobs[2].NextRunAt)
+		assert.Equal(t, defaultJobTimeout.String(), jobs[0].Timeout)
+	}
+}
+
+func TestSchedulerUsecaseRunsEachSlotOnceAcrossReplicas(t *testing.T) {
+	start := time.Date(2024, 5, 10, 10, 14, 0, 0, time.UTC)
+	leases, runs := newMemoryJobLeases(), &memoryJobRuns{}
+	replicaA := newTestScheduler(leases, runs, "a", start)
+	replicaB := newTestScheduler(leases, runs, "b", start)
+
+	var mu sync.Mutex
+	calls := 0
+	job := Job{Name: "sweep", Schedule: "*/15 * * * *", Run: func(context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return "swept", nil
+	}}
+	assert.NoError(t, replicaA.Register(job))
+	assert.NoError(t, replicaB.Register(job))
+
+	replicaA.tick()
+	// Assertion 532: Nothing runs before the first slot
+	assert.Empty(t, runs.runs)
+
+	due := start.Add(time.Minute)
+	replicaA.now = func() time.Time { return due }
+	replicaB.now = func() time.Time { return due.Add(5 * time.Second) }
+	replicaA.tick()
+	replicaA.Stop(time.Second)
+	replicaB.tick()
+	replicaB.Stop(time.Second)
+
+	// Assertion 533: Only one replica runs a slot, even after the lease is released
+	assert.Equal(t, 1, calls)
+	if assert.Len(t, runs.runs, 1) {
+		run := runs.get(1)
+		// Assertion 534: The run history records the outcome of the slot
+		assert.Equal(t, model.JobSucceeded, run.Status)
+		assert.Equal(t, model.JobTriggerSchedule, run.Trigger)
+		assert.Equal(t, "swept", run.Result)
+		assert.Equal(t, "a", run.Owner)
+		assert.Equal(t, due, *run.ScheduledFor)
+	}
+
+	next := due.Add(15 * time.Minute)
+	replicaB.now = func() time.Time { return next }
+	replicaB.tick()
+	replicaB.Stop(time.Second)
+	// Assertion 535: The next slot can be picked up by another replica
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "b", runs.get(2).Owner)
+}
+
+func TestSchedulerUsecaseRunNowAndGracefulStop(t *testing.T) {
+	s := newTestScheduler(newMemoryJobLeases(), &memoryJobRuns{}, "a", time.Now())
+	runs := s.runRepo.(*memoryJobRuns)
+
+	started := make(chan struct{})
+	assert.NoError(t, s.Register(Job{Name: "slow", Schedule: "@daily", Run: func(ctx context.Context) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}))
+
+	_, err := s.RunNow("missing")
+	// Assertion 536: Unknown jobs are reported as not found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	run, err := s.RunNow("slow")
+	assert.NoError(t, err)
+	assert.Equal(t, model.JobRunning, run.Status)
+	assert.Equal(t, model.JobTriggerManual, run.Trigger)
+	<-started
+
+	_, err = s.RunNow("slow")
+	// Assertion 537: A job cannot be started while it is running
+	assert.ErrorIs(t, err, ErrJobLocked)
+
+	// Assertion 538: Stop cancels jobs that outlive the grace period and records them as failed
+	assert.False(t, s.Stop(10*time.Millisecond))
+	failed := runs.get(run.ID)
+	assert.Equal(t, model.JobFailed, failed.Status)
+	assert.Equal(t, context.Canceled.Error(), failed.Error)
+	assert.NotNil(t, failed.FinishedAt)
+	assert.False(t, s.GetJobs()[0].Running)
+}
+
+// stubOrderCanceller records CancelOrder calls made by the maintenance jobs.
+type stubOrderCanceller struct {
+	OrderUsecase
+	actors    []Actor
+	cancelled []uint
+	// failing lists orders whose cancellation fails.
+	failing map[uint]error
+}
+
+func (s *stubOrderCanceller) CancelOrder(actor Actor, id uint) (*model.Order, error) {
+	if err := s.failing[id]; err != nil {
+		return nil, err
+	}
+	s.actors = append(s.actors, actor)
+	s.cancelled = append(s.cancelled, id)
+	return &model.Order{ID: id, Status: model.StatusCancelled}, nil
+}
+
+func TestMaintenanceUsecaseJobs(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	orderRepo := new(MockOrderRepository)
+	emailChangeRepo := new(MockEmailChangeRepository)
+	cartRepo := new(MockCartRepository)
+	orders := &stubOrderCanceller{}
+	uc := NewMaintenanceUsecase(orderRepo, emailChangeRepo, cartRepo, orders, DefaultMaintenanceConfig()).(*maintenanceUsecase)
+	uc.now = func() time.Time { return now }
+
+	orderRepo.On("FindWithFilters", map[string]string{
+		"status":         string(model.StatusPending),
+		"created_before": "2024-05-08T12:00:00Z",
+	}).Return([]model.Order{{ID: 3}, {ID: 7}}, nil)
+	emailChangeRepo.On("DeleteExpired", now).Return(int64(2), nil)
+	cartRepo.On("DeleteStale", now.Add(-30*24*time.Hour)).Return(int64(4), nil)
+	cartRepo.On("DeleteUnusedGuests", now.Add(-24*time.Hour)).Return(int64(3), nil)
+
+	n, err := uc.CancelUnpaidOrders(context.Background())
+	// Assertion 539: Unpaid orders past the timeout are cancelled through OrderUsecase by a system actor
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []uint{3, 7}, orders.cancelled)
+	assert.Equal(t, systemRole, orders.actors[0].Role)
+	assert.Equal(t, "job:"+JobCancelUnpaidOrders, orders.actors[0].RequestID)
+
+	results := map[string]string{}
+	for _, job := range uc.Jobs() {
+		result, err := job.Run(context.Background())
+		assert.NoError(t, err)
+		results[job.Name] = result
+	}
+	// Assertion 540: Each maintenance job reports what it cleaned up
+	assert.Equal(t, "deleted 2 tokens", results[JobPurgeExpiredTokens])
+	assert.Equal(t, "deleted 7 carts", results[JobPruneStaleCarts])
+	mock.AssertExpectationsForObjects(t, orderRepo, emailChangeRepo, cartRepo)
+}
+
+func TestSchedulerUsecaseStopAbandonsJobsIgnoringCancellation(t *testing.T) {
+	s := newTestScheduler(newMemoryJobLeases(), &memoryJobRuns{}, "a", time.Now())
+	s.cancelWait = 20 * time.Millisecond
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	assert.NoError(t, s.Register(Job{Name: "stuck", Schedule: "@daily", Run: func(context.Context) (string, error) {
+		close(started)
+		<-release
+		return "", nil
+	}}))
+	_, err := s.RunNow("stuck")
+	assert.NoError(t, err)
+	<-started
+
+	stopped := make(chan bool)
+	go func() { stopped <- s.Stop(10 * time.Millisecond) }()
+	// Assertion 856: Stop returns after the cancel wait even if a job ignores the cancellation
+	select {
+	case finished := <-stopped:
+		assert.False(t, finished)
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestMaintenanceUsecaseCancelUnpaidOrdersContinuesAfterFailures(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	orderRepo := new(MockOrderRepository)
+	failure := errors.New("database is locked")
+	orders := &stubOrderCanceller{failing: map[uint]error{3: failure}}
+	uc := NewMaintenanceUsecase(orderRepo, nil, nil, orders, DefaultMaintenanceConfig()).(*maintenanceUsecase)
+	uc.now = func() time.Time { return now }
+	orderRepo.On("FindWithFilters", mock.Anything).Return([]model.Order{{ID: 3}, {ID: 7}, {ID: 9}}, nil)
+
+	n, err := uc.CancelUnpaidOrders(context.Background())
+	// Assertion 857: A failed cancellation is reported without stopping the others
+	assert.ErrorIs(t, err, failure)
+	assert.Contains(t, err.Error(), "order 3")
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []uint{7, 9}, orders.cancelled)
+}
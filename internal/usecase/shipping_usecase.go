@@ -0,0 +1,499 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Error message constants
+const (
+	errShippingNameRequired   = "name is required"
+	errShippingNoCountries    = "at least one country is required"
+	errShippingInvalidEntry   = "invalid entry %q"
+	errShippingUnknownType    = "type must be COURIER, PARCEL_LOCKER or PICKUP"
+	errShippingUnknownZone    = "zone %d does not exist"
+	errShippingNoRates        = "at least one rate is required"
+	errShippingInvalidRate    = "rate %d: bounds and price must not be negative and a maximum must not be below its minimum"
+	errShippingInvalidMaxSize = "maximum dimensions must not be negative"
+)
+
+var (
+	ErrInvalidShipping          = NewError(KindValidation, "invalid_shipping", "invalid shipping settings")
+	ErrShippingZoneInUse        = NewError(KindConflict, "shipping_zone_in_use", "shipping zone still has shipping methods")
+	ErrShippingAddressRequired  = NewError(KindValidation, "shipping_country_required", "country is required to list shipping options")
+	ErrShippingMethodRequired   = NewError(KindValidation, "shipping_method_required", "shipping_method_id is required; see GET /cart/shipping-options")
+	ErrShippingMethodNotAllowed = NewError(KindValidation, "shipping_method_not_allowed", "shipping method is not available for this cart and address")
+)
+
+var shippingMethodTypes = []model.ShippingMethodType{model.ShippingCourier, model.ShippingParcelLocker, model.ShippingPickup}
+
+// ShippingZoneInput describes a zone. Countries and PostcodeRanges follow
+// model.ShippingZone.
+type ShippingZoneInput struct {
+	Name           string
+	Countries      []string
+	PostcodeRanges []string
+}
+
+// ShippingMethodInput describes a method. On update, a nil IsActive leaves
+// the state unchanged and Rates replace the existing ones.
+type ShippingMethodInput struct {
+	ZoneID    uint
+	Name      string
+	Type      model.ShippingMethodType
+	IsActive  *bool
+	MaxLength float64
+	MaxWidth  float64
+	MaxHeight float64
+	Rates     []model.ShippingRate
+}
+
+// ShippingOption is a method that can deliver a parcel, with its price.
+type ShippingOption struct {
+	MethodID uint                     `json:"method_id"`
+	Name     string                   `json:"name"`
+	Type     model.ShippingMethodType `json:"type"`
+	ZoneID   uint                     `json:"zone_id"`
+	Zone     string                   `json:"zone"`
+	Cost     float64                  `json:"cost"`
+}
+
+// Parcel sums up what an order ships: weight in kg, value and number of
+// items, and the largest item with its sides sorted longest first.
+type Parcel struct {
+	Weight   float64
+	Subtotal float64
+	Items    int
+	largest  [3]float64
+}
+
+// Add puts quantity units of product, sold at unitPrice, into the parcel.
+func (p *Parcel) Add(product model.Product, quantity int, unitPrice float64) {
+	p.Weight += product.Weight * float64(quantity)
+	p.Subtotal += unitPrice * float64(quantity)
+	p.Items += quantity
+
+	sides := sortedSides(product.Length, product.Width, product.Height)
+	for i, side := range sides {
+		p.largest[i] = math.Max(p.largest[i], side)
+	}
+}
+
+// fits reports whether every item is within the method's size limits.
+func (p *Parcel) fits(method *model.ShippingMethod) bool {
+	limits := sortedSides(unlimitedIfZero(method.MaxLength), unlimitedIfZero(method.MaxWidth), unlimitedIfZero(method.MaxHeight))
+	for i, limit := range limits {
+		if p.largest[i] > limit {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedSides(a, b, c float64) [3]float64 {
+	sides := []float64{a, b, c}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sides)))
+	return [3]float64{sides[0], sides[1], sides[2]}
+}
+
+func unlimitedIfZero(v float64) float64 {
+	if v == 0 {
+		return math.Inf(1)
+	}
+	return v
+}
+
+// ShippingQuoter prices delivery when an order is placed.
+type ShippingQuoter interface {
+	// Quote prices delivering parcel to address with the method. A zero
+	// methodID is accepted, with a nil option, only while no shipping methods
+	// are set up; otherwise the buyer has to choose one.
+	Quote(methodID uint, address model.Address, parcel Parcel) (*ShippingOption, error)
+}
+
+type ShippingUsecase interface {
+	GetZones() ([]model.ShippingZone, error)
+	GetZone(id uint) (*model.ShippingZone, error)
+	CreateZone(actor Actor, input ShippingZoneInput) (*model.ShippingZone, error)
+	UpdateZone(actor Actor, id uint, input ShippingZoneInput) (*model.ShippingZone, error)
+	// DeleteZone fails with ErrShippingZoneInUse while methods use the zone.
+	DeleteZone(actor Actor, id uint) error
+	GetMethods() ([]model.ShippingMethod, error)
+	GetMethod(id uint) (*model.ShippingMethod, error)
+	CreateMethod(actor Actor, input ShippingMethodInput) (*model.ShippingMethod, error)
+	UpdateMethod(actor Actor, id uint, input ShippingMethodInput) (*model.ShippingMethod, error)
+	DeleteMethod(actor Actor, id uint) error
+	// Options lists the methods that can deliver the owner's cart to address,
+	// cheapest first. A nil address means the signed-in user's own address.
+	Options(owner CartOwner, address *model.Address) ([]ShippingOption, error)
+	ShippingQuoter
+}
+
+type shippingUsecase struct {
+	zoneRepo   repository.ShippingZoneRepository
+	methodRepo repository.ShippingMethodRepository
+	userRepo   repository.UserRepository
+	cartUC     CartUsecase
+	auditor    Auditor
+}
+
+func NewShippingUsecase(
+	zoneRepo repository.ShippingZoneRepository,
+	methodRepo repository.ShippingMethodRepository,
+	userRepo repository.UserRepository,
+	cartUC CartUsecase,
+	auditor Auditor,
+) ShippingUsecase {
+	return &shippingUsecase{
+		zoneRepo:   zoneRepo,
+		methodRepo: methodRepo,
+		userRepo:   userRepo,
+		cartUC:     cartUC,
+		auditor:    auditor,
+	}
+}
+
+func (u *shippingUsecase) GetZones() ([]model.ShippingZone, error) {
+	return u.zoneRepo.FindAll()
+}
+
+func (u *shippingUsecase) GetZone(id uint) (*model.ShippingZone, error) {
+	zone, err := u.zoneRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if zone == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return zone, nil
+}
+
+func (u *shippingUsecase) CreateZone(actor Actor, input ShippingZoneInput) (*model.ShippingZone, error) {
+	zone := &model.ShippingZone{}
+	if err := applyShippingZoneInput(zone, input); err != nil {
+		return nil, err
+	}
+	if err := u.zoneRepo.Create(zone); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityShippingZone, zone.ID, nil, zone)
+	return zone, nil
+}
+
+func (u *shippingUsecase) UpdateZone(actor Actor, id uint, input ShippingZoneInput) (*model.ShippingZone, error) {
+	zone, err := u.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *zone
+	if err := applyShippingZoneInput(zone, input); err != nil {
+		return nil, err
+	}
+	if err := u.zoneRepo.Update(zone); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityShippingZone, zone.ID, &before, zone)
+	return zone, nil
+}
+
+func (u *shippingUsecase) DeleteZone(actor Actor, id uint) error {
+	zone, err := u.GetZone(id)
+	if err != nil {
+		return err
+	}
+	methods, err := u.methodRepo.CountByZone(id)
+	if err != nil {
+		return err
+	}
+	if methods > 0 {
+		return ErrShippingZoneInUse
+	}
+	if err := u.zoneRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityShippingZone, id, zone, nil)
+	return nil
+}
+
+func applyShippingZoneInput(zone *model.ShippingZone, input ShippingZoneInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingNameRequired)
+	}
+	countries, err := cleanShippingList(input.Countries)
+	if err != nil {
+		return err
+	}
+	if len(countries) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingNoCountries)
+	}
+	ranges, err := cleanShippingList(input.PostcodeRanges)
+	if err != nil {
+		return err
+	}
+	for _, entry := range ranges {
+		if from, to, isRange := strings.Cut(entry, ".."); isRange &&
+			(model.NormalizePostcode(from) == "" || model.NormalizePostcode(to) == "") {
+			return fmt.Errorf("%w: "+errShippingInvalidEntry, ErrInvalidShipping, entry)
+		}
+	}
+
+	zone.Name = name
+	zone.Countries = countries
+	zone.PostcodeRanges = ranges
+	return nil
+}
+
+// cleanShippingList trims the entries and drops empty ones. Commas are
+// rejected because lists are stored comma-separated.
+func cleanShippingList(entries []string) (model.StringList, error) {
+	cleaned := model.StringList{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, ",") {
+			return nil, fmt.Errorf("%w: "+errShippingInvalidEntry, ErrInvalidShipping, entry)
+		}
+		cleaned = append(cleaned, entry)
+	}
+	return cleaned, nil
+}
+
+func (u *shippingUsecase) GetMethods() ([]model.ShippingMethod, error) {
+	return u.methodRepo.FindAll()
+}
+
+func (u *shippingUsecase) GetMethod(id uint) (*model.ShippingMethod, error) {
+	method, err := u.methodRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if method == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return method, nil
+}
+
+func (u *shippingUsecase) CreateMethod(actor Actor, input ShippingMethodInput) (*model.ShippingMethod, error) {
+	method := &model.ShippingMethod{IsActive: true}
+	if err := u.applyShippingMethodInput(method, input); err != nil {
+		return nil, err
+	}
+	if err := u.methodRepo.Create(method); err != nil {
+		return nil, err
+	}
+	created, err := u.GetMethod(method.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityShippingMethod, created.ID, nil, created)
+	return created, nil
+}
+
+func (u *shippingUsecase) UpdateMethod(actor Actor, id uint, input ShippingMethodInput) (*model.ShippingMethod, error) {
+	method, err := u.GetMethod(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *method
+	if err := u.applyShippingMethodInput(method, input); err != nil {
+		return nil, err
+	}
+	if err := u.methodRepo.Update(method); err != nil {
+		return nil, err
+	}
+	updated, err := u.GetMethod(id)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityShippingMethod, id, &before, updated)
+	return updated, nil
+}
+
+func (u *shippingUsecase) DeleteMethod(actor Actor, id uint) error {
+	method, err := u.GetMethod(id)
+	if err != nil {
+		return err
+	}
+	if err := u.methodRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityShippingMethod, id, method, nil)
+	return nil
+}
+
+func (u *shippingUsecase) applyShippingMethodInput(method *model.ShippingMethod, input ShippingMethodInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingNameRequired)
+	}
+	methodType := model.ShippingMethodType(strings.ToUpper(string(input.Type)))
+	if !containsShippingMethodType(methodType) {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingUnknownType)
+	}
+	if input.MaxLength < 0 || input.MaxWidth < 0 || input.MaxHeight < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingInvalidMaxSize)
+	}
+	if len(input.Rates) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidShipping, errShippingNoRates)
+	}
+	rates := make([]model.ShippingRate, len(input.Rates))
+	for i, rate := range input.Rates {
+		if !validShippingRate(rate) {
+			return fmt.Errorf("%w: "+errShippingInvalidRate, ErrInvalidShipping, i+1)
+		}
+		rates[i] = model.ShippingRate{
+			MinWeight:   rate.MinWeight,
+			MaxWeight:   rate.MaxWeight,
+			MinSubtotal: rate.MinSubtotal,
+			MaxSubtotal: rate.MaxSubtotal,
+			MinItems:    rate.MinItems,
+			MaxItems:    rate.MaxItems,
+			Price:       rate.Price,
+		}
+	}
+	zone, err := u.zoneRepo.FindByID(input.ZoneID)
+	if err != nil {
+		return err
+	}
+	if zone == nil {
+		return fmt.Errorf("%w: "+errShippingUnknownZone, ErrInvalidShipping, input.ZoneID)
+	}
+
+	method.ZoneID = zone.ID
+	method.Zone = *zone
+	method.Name = name
+	method.Type = methodType
+	if input.IsActive != nil {
+		method.IsActive = *input.IsActive
+	}
+	method.MaxLength = input.MaxLength
+	method.MaxWidth = input.MaxWidth
+	method.MaxHeight = input.MaxHeight
+	method.Rates = rates
+	return nil
+}
+
+func containsShippingMethodType(t model.ShippingMethodType) bool {
+	for _, known := range shippingMethodTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func validShippingRate(r model.ShippingRate) bool {
+	if r.MinWeight < 0 || r.MaxWeight < 0 || r.MinSubtotal < 0 || r.MaxSubtotal < 0 ||
+		r.MinItems < 0 || r.MaxItems < 0 || r.Price < 0 {
+		return false
+	}
+	return (r.MaxWeight == 0 || r.MaxWeight >= r.MinWeight) &&
+		(r.MaxSubtotal == 0 || r.MaxSubtotal >= r.MinSubtotal) &&
+		(r.MaxItems == 0 || r.MaxItems >= r.MinItems)
+}
+
+func (u *shippingUsecase) Options(owner CartOwner, address *model.Address) ([]ShippingOption, error) {
+	if address == nil {
+		if owner.UserID == 0 {
+			return nil, ErrShippingAddressRequired
+		}
+		user, err := u.userRepo.FindByID(owner.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		address = &user.Address
+	}
+	if strings.TrimSpace(address.Country) == "" {
+		return nil, ErrShippingAddressRequired
+	}
+
+	cart, err := u.cartUC.GetCart(owner)
+	if err != nil {
+		return nil, err
+	}
+	var parcel Parcel
+	// Cart prices are in the base currency, like the shipping rates.
+	for _, item := range cart.Items {
+		parcel.Add(item.Product, item.Quantity, item.UnitPrice)
+	}
+
+	methods, err := u.methodRepo.FindActive()
+	if err != nil {
+		return nil, err
+	}
+	options := []ShippingOption{}
+	for i := range methods {
+		if option, ok := quoteShipping(&methods[i], *address, parcel); ok {
+			options = append(options, *option)
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].Cost < options[j].Cost
+	})
+	return options, nil
+}
+
+func (u *shippingUsecase) Quote(methodID uint, address model.Address, parcel Parcel) (*ShippingOption, error) {
+	if methodID == 0 {
+		active, err := u.methodRepo.CountActive()
+		if err != nil {
+			return nil, err
+		}
+		if active > 0 {
+			return nil, ErrShippingMethodRequired
+		}
+		return nil, nil
+	}
+
+	method, err := u.methodRepo.FindByID(methodID)
+	if err != nil {
+		return nil, err
+	}
+	if method == nil {
+		return nil, ErrShippingMethodNotAllowed
+	}
+	option, ok := quoteShipping(method, address, parcel)
+	if !ok {
+		return nil, ErrShippingMethodNotAllowed
+	}
+	return option, nil
+}
+
+// quoteShipping prices the parcel with the cheapest matching rate of an
+// active method whose zone covers the address and whose limits fit the items.
+func quoteShipping(method *model.ShippingMethod, address model.Address, parcel Parcel) (*ShippingOption, bool) {
+	if !method.IsActive || !method.Zone.Covers(address) || !parcel.fits(method) {
+		return nil, false
+	}
+	var best *model.ShippingRate
+	for i := range method.Rates {
+		rate := &method.Rates[i]
+		if rate.Matches(parcel.Weight, parcel.Subtotal, parcel.Items) && (best == nil || rate.Price < best.Price) {
+			best = rate
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return &ShippingOption{
+		MethodID: method.ID,
+		Name:     method.Name,
+		Type:     method.Type,
+		ZoneID:   method.ZoneID,
+		Zone:     method.Zone.Name,
+		Cost:     best.Price,
+	}, true
+}
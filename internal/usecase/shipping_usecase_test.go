@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// memoryShippingZones keeps zones in memory.
+type memoryShippingZones struct {
+	zones []model.ShippingZone
+}
+
+func (r *memoryShippingZones) FindByID(id uint) (*model.ShippingZone, error) {
+	for i := range r.zones {
+		if r.zones[i].ID == id {
+			zone := r.zones[i]
+			return &zone, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryShippingZones) FindAll() ([]model.ShippingZone, error) {
+	return r.zones, nil
+}
+
+func (r *memoryShippingZones) Create(zone *model.ShippingZone) error {
+	zone.ID = uint(len(r.zones) + 1)
+	r.zones = append(r.zones, *zone)
+	return nil
+}
+
+func (r *memoryShippingZones) Update(zone *model.ShippingZone) error {
+	for i := range r.zones {
+		if r.zones[i].ID == zone.ID {
+			r.zones[i] = *zone
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryShippingZones) Delete(id uint) error {
+	for i := range r.zones {
+		if r.zones[i].ID == id {
+			r.zones = append(r.zones[:i], r.zones[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// memoryShippingMethods keeps methods, with their zone and rates, in memory.
+type memoryShippingMethods struct {
+	methods []model.ShippingMethod
+}
+
+func (r *memoryShippingMethods) FindByID(id uint) (*model.ShippingMethod, error) {
+	for i := range r.methods {
+		if r.methods[i].ID == id {
+			method := r.methods[i]
+			return &method, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryShippingMethods) FindAll() ([]model.ShippingMethod, error) {
+	return r.methods, nil
+}
+
+func (r *memoryShippingMethods) FindActive() ([]model.ShippingMethod, error) {
+	var active []model.ShippingMethod
+	for _, method := range r.methods {
+		if method.IsActive {
+			active = append(active, method)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryShippingMethods) CountByZone(zoneID uint) (int64, error) {
+	var count int64
+	for _, method := range r.methods {
+		if method.ZoneID == zoneID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryShippingMethods) CountActive() (int64, error) {
+	active, _ := r.FindActive()
+	return int64(len(active)), nil
+}
+
+func (r *memoryShippingMethods) Create(method *model.ShippingMethod) error {
+	method.ID = uint(len(r.methods) + 1)
+	r.methods = append(r.methods, *method)
+	return nil
+}
+
+func (r *memoryShippingMethods) Update(method *model.ShippingMethod) error {
+	for i := range r.methods {
+		if r.methods[i].ID == method.ID {
+			r.methods[i] = *method
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryShippingMethods) Delete(id uint) error {
+	for i := range r.methods {
+		if r.methods[i].ID == id {
+			r.methods = append(r.methods[:i], r.methods[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// newTestShipping returns a shipping usecase without any methods set up.
+func newTestShipping() *shippingUsecase {
+	return &shippingUsecase{
+		zoneRepo:   &memoryShippingZones{},
+		methodRepo: &memoryShippingMethods{},
+		auditor:    &recordingAuditor{},
+	}
+}
+
+// setupShippingUsecase sets up a Polish courier (free from 200), a parcel
+// locker limited to 64x38x41 cm and 25 kg, and a Krakow-only pickup point.
+func setupShippingUsecase(t *testing.T) (*shippingUsecase, *stubCartFiller) {
+	carts := &stubCartFiller{cart: &model.Cart{ID: 5, UserID: uintPtr(2)}}
+	uc := newTestShipping()
+	uc.cartUC = carts
+
+	poland, err := uc.CreateZone(testActor, ShippingZoneInput{Name: "Poland", Countries: []string{"Poland", "PL"}})
+	assert.NoError(t, err)
+	krakow, err := uc.CreateZone(testActor, ShippingZoneInput{Name: "Krakow", Countries: []string{"Poland"}, PostcodeRanges: []string{"30-001..31-999"}})
+	assert.NoError(t, err)
+
+	_, err = uc.CreateMethod(testActor, ShippingMethodInput{ZoneID: poland.ID, Name: "Courier", Type: model.ShippingCourier, Rates: []model.ShippingRate{
+		{MaxWeight: 10, Price: 15},
+		{MinWeight: 10, Price: 30},
+		{MinSubtotal: 200, Price: 0},
+	}})
+	assert.NoError(t, err)
+	_, err = uc.CreateMethod(testActor, ShippingMethodInput{ZoneID: poland.ID, Name: "Locker", Type: "parcel_locker",
+		MaxLength: 64, MaxWidth: 38, MaxHeight: 41, Rates: []model.ShippingRate{{MaxWeight: 25, Price: 12}}})
+	assert.NoError(t, err)
+	_, err = uc.CreateMethod(testActor, ShippingMethodInput{ZoneID: krakow.ID, Name: "Pickup", Type: model.ShippingPickup,
+		Rates: []model.ShippingRate{{MaxItems: 10, Price: 0}}})
+	assert.NoError(t, err)
+	return uc, carts
+}
+
+func shippingOptionNames(options []ShippingOption) []string {
+	names := []string{}
+	for _, option := range options {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+func TestShippingUsecaseValidatesSettings(t *testing.T) {
+	uc, _ := setupShippingUsecase(t)
+
+	_, err := uc.CreateZone(testActor, ShippingZoneInput{Name: "Nowhere"})
+	// Assertion 572: CreateZone should require at least one country
+	assert.ErrorIs(t, err, ErrInvalidShipping)
+
+	_, err = uc.CreateMethod(testActor, ShippingMethodInput{ZoneID: 1, Name: "Drone", Type: "DRONE", Rates: []model.ShippingRate{{Price: 5}}})
+	// Assertion 573: CreateMethod should reject unknown method types
+	assert.ErrorIs(t, err, ErrInvalidShipping)
+
+	_, err = uc.CreateMethod(testActor, ShippingMethodInput{ZoneID: 1, Name: "Courier", Type: model.ShippingCourier,
+		Rates: []model.ShippingRate{{MinWeight: 10, MaxWeight: 5, Price: 5}}})
+	// Assertion 574: CreateMethod should reject rates whose maximum is below the minimum
+	assert.ErrorIs(t, err, ErrInvalidShipping)
+
+	err = uc.DeleteZone(testActor, 1)
+	// Assertion 575: DeleteZone should refuse zones that still have methods
+	assert.ErrorIs(t, err, ErrShippingZoneInUse)
+}
+
+func TestShippingUsecaseOptions(t *testing.T) {
+	uc, carts := setupShippingUsecase(t)
+	lamp := model.Product{ID: 10, Price: 30, Weight: 2, Length: 30, Width: 20, Height: 20}
+	carts.cart.Items = []model.CartItem{{ProductID: 10, Quantity: 2, UnitPrice: 30, Product: lamp}}
+	krakow := &model.Address{Country: "poland", Postcode: "30-150"}
+
+	options, err := uc.Options(UserCart(2), krakow)
+	// Assertion 576: Options should list every method covering the address, cheapest first
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Pickup", "Locker", "Courier"}, shippingOptionNames(options))
+	assert.Equal(t, 15.0, options[2].Cost)
+
+	options, err = uc.Options(UserCart(2), &model.Address{Country: "PL", Postcode: "00-950"})
+	// Assertion 577: Options should leave out zones whose postcode ranges miss the address
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Locker", "Courier"}, shippingOptionNames(options))
+
+	options, err = uc.Options(UserCart(2), &model.Address{Country: "Germany"})
+	// Assertion 578: Options should be empty for addresses outside every zone
+	assert.NoError(t, err)
+	assert.Empty(t, options)
+
+	carts.cart.Items = []model.CartItem{{ProductID: 11, Quantity: 1, UnitPrice: 250, Product: model.Product{ID: 11, Price: 250, Weight: 12, Length: 45, Width: 70, Height: 30}}}
+	options, err = uc.Options(UserCart(2), krakow)
+	// Assertion 579: Options should drop methods the items do not fit and apply the free shipping rate above its value
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Courier", "Pickup"}, shippingOptionNames(options))
+	assert.Equal(t, 0.0, options[0].Cost)
+
+	_, err = uc.Options(GuestCart("token"), nil)
+	// Assertion 580: Options should need an address for guests
+	assert.ErrorIs(t, err, ErrShippingAddressRequired)
+}
+
+func TestShippingUsecaseQuote(t *testing.T) {
+	uc := newTestShipping()
+	var parcel Parcel
+	parcel.Add(model.Product{Weight: 1}, 1, 20)
+
+	option, err := uc.Quote(0, model.Address{Country: "Poland"}, parcel)
+	// Assertion 581: Quote should allow orders without shipping while no methods are set up
+	assert.NoError(t, err)
+	assert.Nil(t, option)
+
+	uc, _ = setupShippingUsecase(t)
+	_, err = uc.Quote(0, model.Address{Country: "Poland"}, parcel)
+	// Assertion 582: Quote should require a method once shipping is set up
+	assert.ErrorIs(t, err, ErrShippingMethodRequired)
+
+	_, err = uc.Quote(3, model.Address{Country: "Poland", Postcode: "00-950"}, parcel)
+	// Assertion 583: Quote should refuse methods that do not deliver to the address
+	assert.ErrorIs(t, err, ErrShippingMethodNotAllowed)
+
+	option, err = uc.Quote(1, model.Address{Country: "Poland", Postcode: "00-950"}, parcel)
+	// Assertion 584: Quote should price the chosen method
+	assert.NoError(t, err)
+	assert.Equal(t, "Courier", option.Name)
+	assert.Equal(t, 15.0, option.Cost)
+}
+
+func TestOrderUsecaseCreateFromCartAddsShipping(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	uc.shipping, _ = setupShippingUsecase(t)
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 2}}}
+	product := &model.Product{ID: 1, Name: testProduct1Name, Price: 50.0, Stock: 10, Weight: 6}
+	address := &model.Address{ID: 1, Country: "Poland", Postcode: "00-950"}
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(address, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	_, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
+	// Assertion 585: CreateFromCart should require a shipping method once shipping is set up
+	assert.ErrorIs(t, err, ErrShippingMethodRequired)
+
+	order, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 1, "", "")
+	// Assertion 586: CreateFromCart should price shipping by the order's weight and add it to the total
+	assert.NoError(t, err)
+	assert.Equal(t, uintPtr(1), order.ShippingMethodID)
+	assert.Equal(t, "Courier", order.ShippingMethodName)
+	assert.Equal(t, 30.0, order.ShippingCost)
+	assert.Equal(t, 130.0, order.Total)
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrInvalidSlug   = NewError(KindValidation, "invalid_slug", "slug must contain a letter or a digit")
+	ErrDuplicateSlug = NewError(KindConflict, "duplicate_slug", "slug is already in use")
+)
+
+// maxSlugLength leaves room in the 200-character column for the suffix
+// that tells apart slugs generated from the same name.
+const maxSlugLength = 190
+
+// transliterations covers the letters that do not decompose into a base
+// letter and a diacritic, such as the Polish ł.
+var transliterations = strings.NewReplacer(
+	"ł", "l", "Ł", "L",
+	"ß", "ss",
+	"æ", "ae", "Æ", "AE",
+	"œ", "oe", "Œ", "OE",
+	"ø", "o", "Ø", "O",
+	"đ", "d", "Đ", "D",
+)
+
+// Slugify turns s into lower-case ASCII letters and digits separated by
+// single hyphens, transliterating accented letters: "Żółta łódź" becomes
+// "zolta-lodz". It returns "" when s has no letter or digit to keep.
+func Slugify(s string) string {
+	s = norm.NFD.String(transliterations.Replace(s))
+	var b strings.Builder
+	separate := false
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			// Diacritics left by NFD and apostrophes are dropped without
+			// splitting the word.
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if separate && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			separate = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			separate = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
+
+// slugs hands out the slugs of one type of entity. A slug an entity had
+// before keeps redirecting to it, so it is not given to another one unless
+// an admin asks for it explicitly.
+type slugs struct {
+	entityType string
+	// taken reports whether an entity other than exceptID has the slug.
+	taken     func(slug string, exceptID uint) (bool, error)
+	redirects repository.SlugRedirectRepository
+}
+
+func productSlugs(products repository.ProductRepository, redirects repository.SlugRedirectRepository) slugs {
+	return slugs{entityType: model.SlugEntityProduct, taken: products.SlugTaken, redirects: redirects}
+}
+
+func categorySlugs(categories repository.CategoryRepository, redirects repository.SlugRedirectRepository) slugs {
+	return slugs{entityType: model.SlugEntityCategory, taken: categories.SlugTaken, redirects: redirects}
+}
+
+// using returns s with redirects read and written through redirects, e.g.
+// the repository of a transaction.
+func (s slugs) using(redirects repository.SlugRedirectRepository) slugs {
+	s.redirects = redirects
+	return s
+}
+
+// free reports whether the entity with id can be given slug without taking
+// it, or a link to it, from another entity. id is 0 for new entities.
+func (s slugs) free(id uint, slug string) (bool, error) {
+	taken, err := s.taken(slug, id)
+	if err != nil || taken {
+		return false, err
+	}
+	redirect, err := s.redirects.Find(s.entityType, slug)
+	if err != nil {
+		return false, err
+	}
+	return redirect == nil || redirect.EntityID == id, nil
+}
+
+// generate makes a free slug from name, adding -2, -3 and so on when other
+// entities have the same name.
+func (s slugs) generate(id uint, name string) (string, error) {
+	base := Slugify(name)
+	if base == "" {
+		base = s.entityType
+	}
+	for n := 1; ; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		free, err := s.free(id, slug)
+		if err != nil {
+			return "", err
+		}
+		if free {
+			return slug, nil
+		}
+	}
+}
+
+// choose returns the slug an entity is saved with: the requested one,
+// normalized, or one generated from name when none is requested.
+func (s slugs) choose(id uint, requested, name string) (string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return s.generate(id, name)
+	}
+	slug := Slugify(requested)
+	if slug == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidSlug, requested)
+	}
+	taken, err := s.taken(slug, id)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", fmt.Errorf("%w: %s", ErrDuplicateSlug, slug)
+	}
+	return slug, nil
+}
+
+// changed records that the entity with id went from slug from to slug to:
+// to no longer redirects anywhere, and from now redirects to the entity.
+func (s slugs) changed(id uint, from, to string) error {
+	if from == to {
+		return nil
+	}
+	if err := s.redirects.Delete(s.entityType, to); err != nil {
+		return err
+	}
+	if from == "" {
+		return nil
+	}
+	return s.redirects.Save(&model.SlugRedirect{EntityType: s.entityType, Slug: from, EntityID: id})
+}
+
+// BackfillSlugs gives a slug to the products and categories saved before
+// they had one. It runs at startup and does nothing once all of them do.
+func BackfillSlugs(
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	redirects repository.SlugRedirectRepository,
+) error {
+	products, err := productRepo.FindWithoutSlug()
+	if err != nil {
+		return err
+	}
+	forProducts := productSlugs(productRepo, redirects)
+	for i := range products {
+		if products[i].Slug, err = forProducts.generate(products[i].ID, products[i].Name); err != nil {
+			return err
+		}
+		if err := productRepo.Update(&products[i]); err != nil {
+			return err
+		}
+		if err := forProducts.changed(products[i].ID, "", products[i].Slug); err != nil {
+			return err
+		}
+	}
+	categories, err := categoryRepo.FindWithoutSlug()
+	if err != nil {
+		return err
+	}
+	forCategories := categorySlugs(categoryRepo, redirects)
+	for i := range categories {
+		if categories[i].Slug, err = forCategories.generate(categories[i].ID, categories[i].Name); err != nil {
+			return err
+		}
+		if err := categoryRepo.Update(&categories[i]); err != nil {
+			return err
+		}
+		if err := forCategories.changed(categories[i].ID, "", categories[i].Slug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
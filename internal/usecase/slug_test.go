@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type memorySlugRedirects struct {
+	redirects map[string]model.SlugRedirect
+}
+
+func newMemorySlugRedirects() *memorySlugRedirects {
+	return &memorySlugRedirects{redirects: map[string]model.SlugRedirect{}}
+}
+
+func (r *memorySlugRedirects) Find(entityType, slug string) (*model.SlugRedirect, error) {
+	redirect, ok := r.redirects[entityType+"/"+slug]
+	if !ok {
+		return nil, nil
+	}
+	return &redirect, nil
+}
+
+func (r *memorySlugRedirects) Save(redirect *model.SlugRedirect) error {
+	r.redirects[redirect.EntityType+"/"+redirect.Slug] = *redirect
+	return nil
+}
+
+func (r *memorySlugRedirects) Delete(entityType, slug string) error {
+	delete(r.redirects, entityType+"/"+slug)
+	return nil
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Żółta łódź":            "zolta-lodz",
+		"  Gęślą jaźń -- 2024 ": "gesla-jazn-2024",
+		"Don't Panic!":          "dont-panic",
+		"Straße & Smørrebrød":   "strasse-smorrebrod",
+		"!!!":                   "",
+	}
+	for input, want := range tests {
+		// Assertion 702: Slugify should transliterate and hyphenate the name
+		assert.Equal(t, want, Slugify(input), input)
+	}
+
+	// Assertion 703: Long slugs should be cut without a trailing hyphen
+	long := Slugify(strings.Repeat("a", maxSlugLength-1) + " bcd")
+	assert.Equal(t, strings.Repeat("a", maxSlugLength-1), long)
+}
+
+func TestProductUsecaseSlugs(t *testing.T) {
+	repo := newMockProductRepository()
+	redirects := newMemorySlugRedirects()
+	uc, _ := newTestProductUsecase(repo, newTestCurrency(), newTestPricing(), redirects)
+
+	first, err := uc.Create(testActor, &model.Product{Name: "Żółta łódź", Price: 10, MetaTitle: "  Boats  "})
+	// Assertion 704: Slugs should be generated from the name and meta fields trimmed
+	assert.NoError(t, err)
+	assert.Equal(t, "zolta-lodz", first.Slug)
+	assert.Equal(t, "Boats", first.MetaTitle)
+
+	second, err := uc.Create(testActor, &model.Product{Name: "Zółta Łódź", Price: 10})
+	// Assertion 705: A product with the same name should get a numbered slug
+	assert.NoError(t, err)
+	assert.Equal(t, "zolta-lodz-2", second.Slug)
+
+	_, err = uc.Create(testActor, &model.Product{Name: "Other", Price: 10, Slug: "Zolta Lodz"})
+	// Assertion 706: A requested slug another product has should be rejected
+	assert.ErrorIs(t, err, ErrDuplicateSlug)
+
+	_, err = uc.Create(testActor, &model.Product{Name: "Other", Price: 10, Slug: "???"})
+	// Assertion 707: A requested slug without letters or digits should be rejected
+	assert.ErrorIs(t, err, ErrInvalidSlug)
+
+	renamed, err := uc.Update(testActor, &model.Product{ID: first.ID, Name: "Yellow boat", Price: 10})
+	// Assertion 708: Updating without a slug should keep the one the product has
+	assert.NoError(t, err)
+	assert.Equal(t, "zolta-lodz", renamed.Slug)
+
+	moved, err := uc.Update(testActor, &model.Product{ID: first.ID, Name: "Yellow boat", Price: 10, Slug: "Yellow Boat"})
+	// Assertion 709: An edited slug should be normalized
+	assert.NoError(t, err)
+	assert.Equal(t, "yellow-boat", moved.Slug)
+
+	found, err := uc.GetBySlug("zolta-lodz")
+	// Assertion 710: The old slug should still find the product, with its new slug
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+	assert.Equal(t, "yellow-boat", found.Slug)
+
+	third, err := uc.Create(testActor, &model.Product{Name: "Żółta łódź", Price: 10})
+	// Assertion 711: A generated slug should not take over another product's old slug
+	assert.NoError(t, err)
+	assert.Equal(t, "zolta-lodz-3", third.Slug)
+
+	back, err := uc.Update(testActor, &model.Product{ID: first.ID, Name: "Yellow boat", Price: 10, Slug: "zolta-lodz"})
+	// Assertion 712: Going back to an old slug should drop its redirect and redirect the newer one
+	assert.NoError(t, err)
+	assert.Equal(t, "zolta-lodz", back.Slug)
+	redirect, _ := redirects.Find(model.SlugEntityProduct, "zolta-lodz")
+	assert.Nil(t, redirect)
+	redirect, _ = redirects.Find(model.SlugEntityProduct, "yellow-boat")
+	assert.Equal(t, first.ID, redirect.EntityID)
+
+	_, err = uc.GetBySlug("missing")
+	// Assertion 713: An unknown slug should not be found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCategoryUsecaseGetBySlugFollowsRedirect(t *testing.T) {
+	uc, mockRepo := setupCategoryUsecase()
+	category := &model.Category{ID: 4, Name: "Books", Slug: "books"}
+	mockRepo.On("FindBySlug", "ksiazki").Return(nil, nil)
+	mockRepo.On("FindByID", uint(4)).Return(category, nil)
+	assert.NoError(t, uc.slugs.changed(4, "ksiazki", "books"))
+
+	found, err := uc.GetBySlug("ksiazki")
+	// Assertion 714: A category's old slug should find it with its current slug
+	assert.NoError(t, err)
+	assert.Equal(t, "books", found.Slug)
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	products := newMockProductRepository()
+	assert.NoError(t, products.Create(&model.Product{Name: "Lamp"}))
+	assert.NoError(t, products.Create(&model.Product{Name: "Lamp"}))
+	categories := new(MockCategoryRepository)
+	categories.On("FindWithoutSlug").Return([]model.Category{{ID: 1, Name: "Książki"}}, nil)
+	categories.On("SlugTaken", "ksiazki", uint(1)).Return(false, nil)
+	categories.On("Update", mock.MatchedBy(func(c *model.Category) bool { return c.Slug == "ksiazki" })).Return(nil)
+
+	// Assertion 715: Products and categories without a slug should be given one
+	assert.NoError(t, BackfillSlugs(products, categories, newMemorySlugRedirects()))
+	assert.Equal(t, "lamp", products.products[0].Slug)
+	assert.Equal(t, "lamp-2", products.products[1].Slug)
+	categories.AssertExpectations(t)
+}
+
+// failingPriceHistory refuses new entries, as a failed write would.
+type failingPriceHistory struct {
+	memoryPriceHistory
+}
+
+func (r *failingPriceHistory) Create(*model.PriceHistory) error {
+	return errors.New("disk full")
+}
+
+func TestProductUsecaseWritesSlugsAndPricesInTransaction(t *testing.T) {
+	repo := newMockProductRepository()
+	pricing := newTestPricing()
+	uc, transactor := newTestProductUsecase(repo, newTestCurrency(), pricing, newMemorySlugRedirects())
+	txRedirects, txHistory := newMemorySlugRedirects(), &memoryPriceHistory{}
+	transactor.repos.SlugRedirects = txRedirects
+	transactor.repos.PriceHistory = txHistory
+
+	created, err := uc.Create(testActor, &model.Product{Name: "Lamp", Price: 10})
+	assert.NoError(t, err)
+	_, err = uc.Update(testActor, &model.Product{ID: created.ID, Name: "Lamp", Slug: "desk-lamp", Price: 12})
+	assert.NoError(t, err)
+	// Assertion 854: Create and Update should write the redirect and the price history in their transaction
+	assert.Len(t, txHistory.entries, 2)
+	assert.Empty(t, pricing.historyRepo.(*memoryPriceHistory).entries)
+	redirect, _ := txRedirects.Find(model.SlugEntityProduct, "lamp")
+	assert.NotNil(t, redirect)
+
+	transactor.repos.PriceHistory = &failingPriceHistory{}
+	_, err = uc.Update(testActor, &model.Product{ID: created.ID, Name: "Lamp", Slug: "desk-lamp", Price: 15})
+	// Assertion 855: A failed price history write should fail the update, so its transaction rolls back
+	assert.Error(t, err)
+}
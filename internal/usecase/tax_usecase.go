@@ -0,0 +1,304 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// Error message constants
+const (
+	errTaxCountryRequired = "country is required"
+	errTaxInvalidRate     = "rate must be between 0 and 100"
+	errTaxZeroClassRate   = "the ZERO class cannot have a rate above 0"
+)
+
+var (
+	ErrInvalidTaxRate  = NewError(KindValidation, "invalid_tax_rate", "invalid tax rate")
+	ErrInvalidTaxClass = NewError(KindValidation, "invalid_tax_class", "tax_class must be STANDARD, REDUCED or ZERO")
+	ErrTaxRateExists   = NewError(KindConflict, "tax_rate_exists", "a rate for this country and tax class already exists")
+)
+
+var taxClasses = []model.TaxClass{model.TaxStandard, model.TaxReduced, model.TaxZero}
+
+// TaxConfig holds the store-wide tax settings.
+type TaxConfig struct {
+	// PriceMode tells whether catalog and shipping prices include tax.
+	PriceMode model.PriceMode
+	// DisplayCountry is the country whose rates product and cart prices are
+	// split with when a request names none. Empty shows no split.
+	DisplayCountry string
+}
+
+func DefaultTaxConfig() TaxConfig {
+	return TaxConfig{PriceMode: model.PriceModeGross}
+}
+
+// TaxRateInput describes a rate of the tax table.
+type TaxRateInput struct {
+	Country string
+	Class   model.TaxClass
+	Rate    float64
+}
+
+// TaxCalculator works out the tax of an order when it is placed.
+type TaxCalculator interface {
+	// ApplyTaxes charges each item of order at the rate of its tax class in
+	// country, and the shipping at the standard rate. It fills in the items'
+	// tax, ShippingTax, the order's tax lines and totals, and sets Total.
+	ApplyTaxes(order *model.Order, country string) error
+}
+
+type TaxUsecase interface {
+	PriceMode() model.PriceMode
+	// ShowProductTaxes sets the products' price mode and, for country or
+	// else the display country, their net and gross prices.
+	ShowProductTaxes(products []model.Product, country string) error
+	// ShowCartTaxes does the same for the cart, its items and their
+	// products. Call it after the cart is converted to the display currency.
+	ShowCartTaxes(cart *model.Cart, country string) error
+	GetRates() ([]model.TaxRate, error)
+	GetRate(id uint) (*model.TaxRate, error)
+	// CreateRate fails with ErrTaxRateExists when the country already has a
+	// rate for the class.
+	CreateRate(actor Actor, input TaxRateInput) (*model.TaxRate, error)
+	UpdateRate(actor Actor, id uint, input TaxRateInput) (*model.TaxRate, error)
+	DeleteRate(actor Actor, id uint) error
+	TaxCalculator
+}
+
+type taxUsecase struct {
+	rateRepo repository.TaxRateRepository
+	auditor  Auditor
+	config   TaxConfig
+}
+
+func NewTaxUsecase(rateRepo repository.TaxRateRepository, auditor Auditor, config TaxConfig) TaxUsecase {
+	return &taxUsecase{rateRepo: rateRepo, auditor: auditor, config: config}
+}
+
+// NormalizeTaxClass upper-cases a tax class, defaulting an empty one to
+// STANDARD, and fails with ErrInvalidTaxClass for unknown classes.
+func NormalizeTaxClass(class model.TaxClass) (model.TaxClass, error) {
+	normalized := model.TaxClass(strings.ToUpper(strings.TrimSpace(string(class))))
+	if normalized == "" {
+		return model.TaxStandard, nil
+	}
+	for _, known := range taxClasses {
+		if normalized == known {
+			return normalized, nil
+		}
+	}
+	return "", ErrInvalidTaxClass
+}
+
+func (u *taxUsecase) PriceMode() model.PriceMode {
+	return u.config.PriceMode
+}
+
+func (u *taxUsecase) ShowProductTaxes(products []model.Product, country string) error {
+	rateOf, ok, err := u.displayRates(country)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		u.showProductTax(&products[i], rateOf, ok)
+	}
+	return nil
+}
+
+func (u *taxUsecase) ShowCartTaxes(cart *model.Cart, country string) error {
+	rateOf, ok, err := u.displayRates(country)
+	if err != nil {
+		return err
+	}
+	cart.PriceMode = u.config.PriceMode
+	var net, tax float64
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		if item.Product.ID != 0 {
+			u.showProductTax(&item.Product, rateOf, ok)
+		}
+		if ok {
+			itemNet, itemTax := model.SplitTax(item.Subtotal, rateOf[classOf(item.Product.TaxClass)], u.config.PriceMode)
+			item.NetSubtotal, item.GrossSubtotal = moneyPtr(itemNet), moneyPtr(itemNet+itemTax)
+			net, tax = net+itemNet, tax+itemTax
+		}
+	}
+	if ok {
+		cart.NetTotal, cart.TaxTotal, cart.GrossTotal = moneyPtr(net), moneyPtr(tax), moneyPtr(net+tax)
+	}
+	return nil
+}
+
+func (u *taxUsecase) showProductTax(product *model.Product, rateOf map[model.TaxClass]float64, ok bool) {
+	product.PriceMode = u.config.PriceMode
+	if !ok {
+		return
+	}
+	net, tax := model.SplitTax(product.Price, rateOf[classOf(product.TaxClass)], u.config.PriceMode)
+	product.NetPrice, product.GrossPrice = moneyPtr(net), moneyPtr(net+tax)
+}
+
+// displayRates returns the rates of country, or of the display country if
+// it is empty. ok is false when neither is set.
+func (u *taxUsecase) displayRates(country string) (rateOf map[model.TaxClass]float64, ok bool, err error) {
+	if country = strings.TrimSpace(country); country == "" {
+		country = u.config.DisplayCountry
+	}
+	if country == "" {
+		return nil, false, nil
+	}
+	rateOf, err = u.ratesOf(country)
+	return rateOf, err == nil, err
+}
+
+// ratesOf maps each tax class to its rate in country.
+func (u *taxUsecase) ratesOf(country string) (map[model.TaxClass]float64, error) {
+	rates, err := u.rateRepo.FindByCountry(country)
+	if err != nil {
+		return nil, err
+	}
+	rateOf := make(map[model.TaxClass]float64, len(rates))
+	for _, rate := range rates {
+		rateOf[rate.Class] = rate.Rate
+	}
+	return rateOf, nil
+}
+
+// classOf defaults an empty tax class to STANDARD.
+func classOf(class model.TaxClass) model.TaxClass {
+	if class == "" {
+		return model.TaxStandard
+	}
+	return class
+}
+
+func moneyPtr(amount float64) *float64 {
+	rounded := model.RoundMoney(amount)
+	return &rounded
+}
+
+func (u *taxUsecase) GetRates() ([]model.TaxRate, error) {
+	return u.rateRepo.FindAll()
+}
+
+func (u *taxUsecase) GetRate(id uint) (*model.TaxRate, error) {
+	rate, err := u.rateRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rate == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return rate, nil
+}
+
+func (u *taxUsecase) CreateRate(actor Actor, input TaxRateInput) (*model.TaxRate, error) {
+	rate := &model.TaxRate{}
+	if err := u.applyTaxRateInput(rate, input); err != nil {
+		return nil, err
+	}
+	if err := u.rateRepo.Create(rate); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityTaxRate, rate.ID, nil, rate)
+	return rate, nil
+}
+
+func (u *taxUsecase) UpdateRate(actor Actor, id uint, input TaxRateInput) (*model.TaxRate, error) {
+	rate, err := u.GetRate(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *rate
+	if err := u.applyTaxRateInput(rate, input); err != nil {
+		return nil, err
+	}
+	if err := u.rateRepo.Update(rate); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityTaxRate, rate.ID, &before, rate)
+	return rate, nil
+}
+
+func (u *taxUsecase) DeleteRate(actor Actor, id uint) error {
+	rate, err := u.GetRate(id)
+	if err != nil {
+		return err
+	}
+	if err := u.rateRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityTaxRate, id, rate, nil)
+	return nil
+}
+
+func (u *taxUsecase) applyTaxRateInput(rate *model.TaxRate, input TaxRateInput) error {
+	country := strings.TrimSpace(input.Country)
+	if country == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidTaxRate, errTaxCountryRequired)
+	}
+	class, err := NormalizeTaxClass(input.Class)
+	if err != nil {
+		return err
+	}
+	if input.Rate < 0 || input.Rate > 100 {
+		return fmt.Errorf("%w: %s", ErrInvalidTaxRate, errTaxInvalidRate)
+	}
+	if class == model.TaxZero && input.Rate != 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidTaxRate, errTaxZeroClassRate)
+	}
+
+	existing, err := u.rateRepo.FindByCountry(country)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if other.Class == class && other.ID != rate.ID {
+			return ErrTaxRateExists
+		}
+	}
+
+	rate.Country = country
+	rate.Class = class
+	rate.Rate = input.Rate
+	return nil
+}
+
+func (u *taxUsecase) ApplyTaxes(order *model.Order, country string) error {
+	rateOf, err := u.ratesOf(country)
+	if err != nil {
+		return err
+	}
+
+	var summary model.TaxSummary
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.TaxClass = classOf(item.TaxClass)
+		item.TaxRate = rateOf[item.TaxClass]
+		item.NetSubtotal, item.TaxAmount = model.SplitTax(item.UnitPrice*float64(item.Quantity), item.TaxRate, u.config.PriceMode)
+		item.Subtotal = model.RoundMoney(item.NetSubtotal + item.TaxAmount)
+		summary.Add(item.TaxRate, item.NetSubtotal, item.TaxAmount)
+	}
+
+	shippingRate := rateOf[model.TaxStandard]
+	shippingNet, shippingTax := model.SplitTax(order.ShippingCost, shippingRate, u.config.PriceMode)
+	order.ShippingCost = model.RoundMoney(shippingNet + shippingTax)
+	order.ShippingTax = shippingTax
+	order.ShippingTaxRate = shippingRate
+	summary.Add(shippingRate, shippingNet, shippingTax)
+
+	amounts, net, tax := summary.Amounts()
+	order.TaxLines = nil
+	for _, a := range amounts {
+		order.TaxLines = append(order.TaxLines, model.OrderTaxLine{TaxAmounts: a})
+	}
+	order.NetTotal, order.TaxTotal = net, tax
+	order.Total = model.RoundMoney(order.NetTotal + order.TaxTotal)
+	return nil
+}
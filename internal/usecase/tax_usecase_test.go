@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// memoryTaxRates keeps tax rates in memory.
+type memoryTaxRates struct {
+	rates []model.TaxRate
+}
+
+func (r *memoryTaxRates) FindByID(id uint) (*model.TaxRate, error) {
+	for i := range r.rates {
+		if r.rates[i].ID == id {
+			rate := r.rates[i]
+			return &rate, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryTaxRates) FindAll() ([]model.TaxRate, error) {
+	return r.rates, nil
+}
+
+func (r *memoryTaxRates) FindByCountry(country string) ([]model.TaxRate, error) {
+	var found []model.TaxRate
+	for _, rate := range r.rates {
+		if strings.EqualFold(rate.Country, strings.TrimSpace(country)) {
+			found = append(found, rate)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTaxRates) Create(rate *model.TaxRate) error {
+	rate.ID = uint(len(r.rates) + 1)
+	r.rates = append(r.rates, *rate)
+	return nil
+}
+
+func (r *memoryTaxRates) Update(rate *model.TaxRate) error {
+	for i := range r.rates {
+		if r.rates[i].ID == rate.ID {
+			r.rates[i] = *rate
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryTaxRates) Delete(id uint) error {
+	for i := range r.rates {
+		if r.rates[i].ID == id {
+			r.rates = append(r.rates[:i], r.rates[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// newTestTax returns a tax usecase with gross prices and no rates set up.
+func newTestTax() *taxUsecase {
+	return &taxUsecase{rateRepo: &memoryTaxRates{}, auditor: &recordingAuditor{}, config: DefaultTaxConfig()}
+}
+
+// setupTaxUsecase sets up Polish VAT: 23% standard and 8% reduced.
+func setupTaxUsecase(t *testing.T, mode model.PriceMode) *taxUsecase {
+	uc := newTestTax()
+	uc.config.PriceMode = mode
+	_, err := uc.CreateRate(testActor, TaxRateInput{Country: "Poland", Class: model.TaxStandard, Rate: 23})
+	assert.NoError(t, err)
+	_, err = uc.CreateRate(testActor, TaxRateInput{Country: "Poland", Class: "reduced", Rate: 8})
+	assert.NoError(t, err)
+	return uc
+}
+
+func TestTaxUsecaseValidatesRates(t *testing.T) {
+	uc := setupTaxUsecase(t, model.PriceModeGross)
+
+	_, err := uc.CreateRate(testActor, TaxRateInput{Country: "Germany", Class: model.TaxStandard, Rate: 119})
+	// Assertion 587: CreateRate should reject rates above 100%
+	assert.ErrorIs(t, err, ErrInvalidTaxRate)
+
+	_, err = uc.CreateRate(testActor, TaxRateInput{Country: "Germany", Class: "LUXURY", Rate: 19})
+	// Assertion 588: CreateRate should reject unknown tax classes
+	assert.ErrorIs(t, err, ErrInvalidTaxClass)
+
+	_, err = uc.CreateRate(testActor, TaxRateInput{Country: "Germany", Class: model.TaxZero, Rate: 7})
+	// Assertion 589: CreateRate should keep the ZERO class at 0%
+	assert.ErrorIs(t, err, ErrInvalidTaxRate)
+
+	_, err = uc.CreateRate(testActor, TaxRateInput{Country: "POLAND", Class: model.TaxStandard, Rate: 22})
+	// Assertion 590: CreateRate should refuse a second rate for the same country and class
+	assert.ErrorIs(t, err, ErrTaxRateExists)
+
+	updated, err := uc.UpdateRate(testActor, 1, TaxRateInput{Country: "Poland", Class: model.TaxStandard, Rate: 22})
+	// Assertion 591: UpdateRate should allow a rate to keep its own country and class
+	assert.NoError(t, err)
+	assert.Equal(t, 22.0, updated.Rate)
+}
+
+func taxTestOrder() *model.Order {
+	return &model.Order{
+		Items: []model.OrderItem{
+			{Name: "Lamp", UnitPrice: 123, Quantity: 2},
+			{Name: "Book", UnitPrice: 10.80, Quantity: 1, TaxClass: model.TaxReduced},
+			{Name: "Voucher", UnitPrice: 50, Quantity: 1, TaxClass: model.TaxZero},
+		},
+		ShippingCost: 12.30,
+	}
+}
+
+func TestTaxUsecaseApplyTaxesGross(t *testing.T) {
+	uc := setupTaxUsecase(t, model.PriceModeGross)
+	order := taxTestOrder()
+
+	err := uc.ApplyTaxes(order, "poland")
+	// Assertion 592: ApplyTaxes should take each item's tax out of its gross price at the rate of its class
+	assert.NoError(t, err)
+	assert.Equal(t, model.TaxStandard, order.Items[0].TaxClass)
+	assert.Equal(t, []float64{23, 200, 46, 246}, []float64{order.Items[0].TaxRate, order.Items[0].NetSubtotal, order.Items[0].TaxAmount, order.Items[0].Subtotal})
+	assert.Equal(t, []float64{8, 10, 0.8}, []float64{order.Items[1].TaxRate, order.Items[1].NetSubtotal, order.Items[1].TaxAmount})
+	assert.Equal(t, []float64{0, 50, 0}, []float64{order.Items[2].TaxRate, order.Items[2].NetSubtotal, order.Items[2].TaxAmount})
+	// Assertion 593: ApplyTaxes should charge shipping at the standard rate
+	assert.Equal(t, 12.30, order.ShippingCost)
+	assert.Equal(t, 2.30, order.ShippingTax)
+	// Assertion 594: ApplyTaxes should sum the order up per rate, highest first, with totals that add up
+	assert.Equal(t, []model.OrderTaxLine{
+		{TaxAmounts: model.TaxAmounts{Rate: 23, Net: 210, Tax: 48.30, Gross: 258.30}},
+		{TaxAmounts: model.TaxAmounts{Rate: 8, Net: 10, Tax: 0.80, Gross: 10.80}},
+		{TaxAmounts: model.TaxAmounts{Rate: 0, Net: 50, Tax: 0, Gross: 50}},
+	}, order.TaxLines)
+	assert.Equal(t, 270.0, order.NetTotal)
+	assert.Equal(t, 49.10, order.TaxTotal)
+	assert.Equal(t, 319.10, order.Total)
+}
+
+func TestTaxUsecaseApplyTaxesNet(t *testing.T) {
+	uc := setupTaxUsecase(t, model.PriceModeNet)
+	order := taxTestOrder()
+
+	err := uc.ApplyTaxes(order, "Poland")
+	// Assertion 595: ApplyTaxes should add tax on top of net prices and shipping
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{246, 56.58}, []float64{order.Items[0].NetSubtotal, order.Items[0].TaxAmount})
+	assert.Equal(t, 302.58, order.Items[0].Subtotal)
+	assert.Equal(t, 15.13, order.ShippingCost)
+	assert.Equal(t, 319.10, order.NetTotal)
+	assert.Equal(t, 60.27, order.TaxTotal)
+	assert.Equal(t, 379.37, order.Total)
+
+	order = taxTestOrder()
+	err = uc.ApplyTaxes(order, "United States")
+	// Assertion 596: ApplyTaxes should charge no tax in countries without rates
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, order.TaxTotal)
+	assert.Equal(t, 319.10, order.Total)
+}
+
+func TestOrderUsecaseCreateFromCartAppliesTaxes(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	uc.tax = setupTaxUsecase(t, model.PriceModeGross)
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 1}}}
+	product := &model.Product{ID: 1, Name: testProduct1Name, Price: 108, Stock: 10, TaxClass: model.TaxReduced}
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1, Country: "Poland"}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	order, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "")
+	// Assertion 597: CreateFromCart should tax items by the product's class in the shipping country
+	assert.NoError(t, err)
+	assert.Equal(t, model.TaxReduced, order.Items[0].TaxClass)
+	assert.Equal(t, 8.0, order.TaxTotal)
+	assert.Equal(t, 100.0, order.NetTotal)
+	assert.Equal(t, 108.0, order.Total)
+}
+
+func TestTaxUsecaseShowsNetAndGrossPrices(t *testing.T) {
+	uc := setupTaxUsecase(t, model.PriceModeGross)
+	products := []model.Product{{ID: 1, Price: 123}, {ID: 2, Price: 10.80, TaxClass: model.TaxReduced}}
+
+	err := uc.ShowProductTaxes(products, "Poland")
+	// Assertion 804: Products should show the price mode and their price without and with tax
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriceModeGross, products[0].PriceMode)
+	assert.Equal(t, []float64{100, 123}, []float64{*products[0].NetPrice, *products[0].GrossPrice})
+	assert.Equal(t, []float64{10, 10.80}, []float64{*products[1].NetPrice, *products[1].GrossPrice})
+
+	products = []model.Product{{ID: 1, Price: 123}}
+	err = uc.ShowProductTaxes(products, "")
+	// Assertion 805: Without a country only the price mode should be shown
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriceModeGross, products[0].PriceMode)
+	assert.Nil(t, products[0].NetPrice)
+	assert.Nil(t, products[0].GrossPrice)
+
+	uc.config.PriceMode = model.PriceModeNet
+	uc.config.DisplayCountry = "poland"
+	cart := &model.Cart{Items: []model.CartItem{
+		{Product: model.Product{ID: 1, Price: 100}, Quantity: 2, Subtotal: 200},
+		{Product: model.Product{ID: 2, Price: 10, TaxClass: model.TaxReduced}, Quantity: 1, Subtotal: 10},
+	}, Total: 210}
+	err = uc.ShowCartTaxes(cart, "")
+	// Assertion 806: Carts should split items and totals for the display country
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriceModeNet, cart.PriceMode)
+	assert.Equal(t, []float64{200, 246}, []float64{*cart.Items[0].NetSubtotal, *cart.Items[0].GrossSubtotal})
+	assert.Equal(t, []float64{10, 10.80}, []float64{*cart.Items[1].NetSubtotal, *cart.Items[1].GrossSubtotal})
+	assert.Equal(t, []float64{210, 46.80, 256.80}, []float64{*cart.NetTotal, *cart.TaxTotal, *cart.GrossTotal})
+	assert.Equal(t, 123.0, *cart.Items[0].Product.GrossPrice)
+}
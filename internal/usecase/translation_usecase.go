@@ -0,0 +1,466 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedLocale  = NewError(KindValidation, "unsupported_locale", "unsupported locale")
+	ErrInvalidTranslation = NewError(KindValidation, "invalid_translation", "invalid translation")
+)
+
+// Error message constants
+const (
+	errDefaultLocale       = "%s is the default locale; edit the product or category itself"
+	errTranslationNameless = "name is required"
+)
+
+type LocaleConfig struct {
+	// Default is the locale the products' and categories' own fields are
+	// in, shown when a request matches no other.
+	Default string
+	// Supported lists the locales content is shown in, Default included.
+	Supported []string
+}
+
+func DefaultLocaleConfig() LocaleConfig {
+	return LocaleConfig{Default: "en", Supported: []string{"en", "pl", "de"}}
+}
+
+type ProductTranslationInput struct {
+	Name        string
+	Description string
+}
+
+// ProductLocalizer shows products in the language of a request.
+type ProductLocalizer interface {
+	// LocalizeProducts replaces the names and descriptions of the products,
+	// and the names of their categories, with their translations into
+	// locale where they have one.
+	LocalizeProducts(products []model.Product, locale string) error
+}
+
+type TranslationUsecase interface {
+	ProductLocalizer
+	DefaultLocale() string
+	// Locales lists the supported locales, the default one first.
+	Locales() []string
+	// Negotiate picks the supported locale closest to lang, or when lang is
+	// empty to the Accept-Language header, falling back to the default.
+	Negotiate(lang, acceptLanguage string) string
+	// LocalizeCategories translates the names of the categories and of
+	// their parents and subcategories.
+	LocalizeCategories(categories []model.Category, locale string) error
+	LocalizeCategoryTree(nodes []model.CategoryNode, locale string) error
+	LocalizeBreadcrumbs(path []model.CategoryBreadcrumb, locale string) error
+
+	GetProductTranslations(productID uint) ([]model.ProductTranslation, error)
+	// SetProductTranslation creates or replaces the product's translation
+	// into locale, which must be supported and not the default.
+	SetProductTranslation(actor Actor, productID uint, locale string, input ProductTranslationInput) (*model.ProductTranslation, error)
+	DeleteProductTranslation(actor Actor, productID uint, locale string) error
+	GetCategoryTranslations(categoryID uint) ([]model.CategoryTranslation, error)
+	SetCategoryTranslation(actor Actor, categoryID uint, locale string, name string) (*model.CategoryTranslation, error)
+	DeleteCategoryTranslation(actor Actor, categoryID uint, locale string) error
+}
+
+type translationUsecase struct {
+	translationRepo repository.TranslationRepository
+	productRepo     repository.ProductRepository
+	categoryRepo    repository.CategoryRepository
+	auditor         Auditor
+	// locales are the supported locales, the default first, matching the
+	// tags the matcher was made from.
+	locales []string
+	matcher language.Matcher
+}
+
+func NewTranslationUsecase(
+	translationRepo repository.TranslationRepository,
+	productRepo repository.ProductRepository,
+	categoryRepo repository.CategoryRepository,
+	auditor Auditor,
+	config LocaleConfig,
+) TranslationUsecase {
+	// The matcher falls back to its first tag, so the default goes first.
+	var tags []language.Tag
+	var locales []string
+	for _, code := range append([]string{config.Default}, config.Supported...) {
+		tag, err := language.Parse(strings.TrimSpace(code))
+		if err != nil || (len(tags) > 0 && containsLocale(locales, tag.String())) {
+			continue
+		}
+		tags = append(tags, tag)
+		locales = append(locales, tag.String())
+	}
+	if len(tags) == 0 {
+		tags, locales = []language.Tag{language.English}, []string{language.English.String()}
+	}
+	return &translationUsecase{
+		translationRepo: translationRepo,
+		productRepo:     productRepo,
+		categoryRepo:    categoryRepo,
+		auditor:         auditor,
+		locales:         locales,
+		matcher:         language.NewMatcher(tags),
+	}
+}
+
+func containsLocale(locales []string, locale string) bool {
+	for _, l := range locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *translationUsecase) DefaultLocale() string {
+	return u.locales[0]
+}
+
+func (u *translationUsecase) Locales() []string {
+	return append([]string(nil), u.locales...)
+}
+
+func (u *translationUsecase) Negotiate(lang, acceptLanguage string) string {
+	var desired []language.Tag
+	if lang = strings.TrimSpace(lang); lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			desired = []language.Tag{tag}
+		}
+	} else {
+		desired, _, _ = language.ParseAcceptLanguage(acceptLanguage)
+	}
+	if len(desired) == 0 {
+		return u.DefaultLocale()
+	}
+	_, index, confidence := u.matcher.Match(desired...)
+	if confidence == language.No {
+		return u.DefaultLocale()
+	}
+	return u.locales[index]
+}
+
+// translatable reports whether content has translations into locale, as
+// opposed to being shown from its own fields.
+func (u *translationUsecase) translatable(locale string) bool {
+	return locale != "" && locale != u.DefaultLocale()
+}
+
+// normalizeLocale checks that translations can be stored for locale and
+// returns it in its canonical form, e.g. "pt-BR" for "pt_br".
+func (u *translationUsecase) normalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil || !containsLocale(u.locales, tag.String()) {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedLocale, locale)
+	}
+	if tag.String() == u.DefaultLocale() {
+		return "", fmt.Errorf("%w: "+errDefaultLocale, ErrUnsupportedLocale, tag.String())
+	}
+	return tag.String(), nil
+}
+
+func (u *translationUsecase) LocalizeProducts(products []model.Product, locale string) error {
+	if !u.translatable(locale) || len(products) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	translations, err := u.translationRepo.FindProductTranslationsIn(locale, ids)
+	if err != nil {
+		return err
+	}
+	byProduct := make(map[uint]model.ProductTranslation, len(translations))
+	for _, translation := range translations {
+		byProduct[translation.ProductID] = translation
+	}
+	categories := make([]*model.Category, 0, len(products))
+	for i := range products {
+		if translation, ok := byProduct[products[i].ID]; ok {
+			products[i].Name = translation.Name
+			if translation.Description != "" {
+				products[i].Description = translation.Description
+			}
+		}
+		if products[i].Category.ID != 0 {
+			categories = append(categories, &products[i].Category)
+		}
+	}
+	return u.localizeCategories(categories, locale)
+}
+
+func (u *translationUsecase) LocalizeCategories(categories []model.Category, locale string) error {
+	if !u.translatable(locale) {
+		return nil
+	}
+	var all []*model.Category
+	for i := range categories {
+		all = append(all, &categories[i])
+		if categories[i].ParentCategory != nil {
+			all = append(all, categories[i].ParentCategory)
+		}
+		for j := range categories[i].Subcategories {
+			all = append(all, &categories[i].Subcategories[j])
+		}
+	}
+	return u.localizeCategories(all, locale)
+}
+
+func (u *translationUsecase) localizeCategories(categories []*model.Category, locale string) error {
+	ids := make([]uint, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+	names, err := u.categoryNames(locale, ids)
+	if err != nil {
+		return err
+	}
+	for _, category := range categories {
+		if name, ok := names[category.ID]; ok {
+			category.Name = name
+		}
+	}
+	return nil
+}
+
+// categoryNames returns the names in locale of the categories that have one.
+func (u *translationUsecase) categoryNames(locale string, ids []uint) (map[uint]string, error) {
+	if !u.translatable(locale) || len(ids) == 0 {
+		return nil, nil
+	}
+	translations, err := u.translationRepo.FindCategoryTranslationsIn(locale, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(translations))
+	for _, translation := range translations {
+		names[translation.CategoryID] = translation.Name
+	}
+	return names, nil
+}
+
+func (u *translationUsecase) LocalizeCategoryTree(nodes []model.CategoryNode, locale string) error {
+	if !u.translatable(locale) {
+		return nil
+	}
+	var all []*model.CategoryNode
+	var collect func(nodes []model.CategoryNode)
+	collect = func(nodes []model.CategoryNode) {
+		for i := range nodes {
+			all = append(all, &nodes[i])
+			collect(nodes[i].Children)
+		}
+	}
+	collect(nodes)
+	ids := make([]uint, len(all))
+	for i, node := range all {
+		ids[i] = node.ID
+	}
+	names, err := u.categoryNames(locale, ids)
+	if err != nil {
+		return err
+	}
+	for _, node := range all {
+		if name, ok := names[node.ID]; ok {
+			node.Name = name
+		}
+	}
+	return nil
+}
+
+func (u *translationUsecase) LocalizeBreadcrumbs(path []model.CategoryBreadcrumb, locale string) error {
+	ids := make([]uint, len(path))
+	for i, crumb := range path {
+		ids[i] = crumb.ID
+	}
+	names, err := u.categoryNames(locale, ids)
+	if err != nil {
+		return err
+	}
+	for i := range path {
+		if name, ok := names[path[i].ID]; ok {
+			path[i].Name = name
+		}
+	}
+	return nil
+}
+
+func (u *translationUsecase) product(id uint) error {
+	product, err := u.productRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (u *translationUsecase) category(id uint) error {
+	category, err := u.categoryRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (u *translationUsecase) GetProductTranslations(productID uint) ([]model.ProductTranslation, error) {
+	if err := u.product(productID); err != nil {
+		return nil, err
+	}
+	return u.translationRepo.FindProductTranslations(productID)
+}
+
+// productTranslation returns the product's translation into locale, or nil.
+func (u *translationUsecase) productTranslation(productID uint, locale string) (*model.ProductTranslation, error) {
+	translations, err := u.translationRepo.FindProductTranslations(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, translation := range translations {
+		if translation.Locale == locale {
+			return &translation, nil
+		}
+	}
+	return nil, nil
+}
+
+func (u *translationUsecase) SetProductTranslation(actor Actor, productID uint, locale string, input ProductTranslationInput) (*model.ProductTranslation, error) {
+	locale, err := u.normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranslation, errTranslationNameless)
+	}
+	if err := u.product(productID); err != nil {
+		return nil, err
+	}
+	before, err := u.productTranslation(productID, locale)
+	if err != nil {
+		return nil, err
+	}
+	translation := &model.ProductTranslation{
+		ProductID:   productID,
+		Locale:      locale,
+		Name:        name,
+		Description: strings.TrimSpace(input.Description),
+	}
+	if err := u.translationRepo.SaveProductTranslation(translation); err != nil {
+		return nil, err
+	}
+	saved, err := u.productTranslation(productID, locale)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		u.auditor.Record(actor, model.AuditCreate, model.AuditEntityProductTranslation, saved.ID, nil, saved)
+	} else {
+		u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityProductTranslation, saved.ID, before, saved)
+	}
+	return saved, nil
+}
+
+func (u *translationUsecase) DeleteProductTranslation(actor Actor, productID uint, locale string) error {
+	locale, err := u.normalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+	before, err := u.productTranslation(productID, locale)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if err := u.translationRepo.DeleteProductTranslation(productID, locale); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityProductTranslation, before.ID, before, nil)
+	return nil
+}
+
+func (u *translationUsecase) GetCategoryTranslations(categoryID uint) ([]model.CategoryTranslation, error) {
+	if err := u.category(categoryID); err != nil {
+		return nil, err
+	}
+	return u.translationRepo.FindCategoryTranslations(categoryID)
+}
+
+// categoryTranslation returns the category's translation into locale, or nil.
+func (u *translationUsecase) categoryTranslation(categoryID uint, locale string) (*model.CategoryTranslation, error) {
+	translations, err := u.translationRepo.FindCategoryTranslations(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, translation := range translations {
+		if translation.Locale == locale {
+			return &translation, nil
+		}
+	}
+	return nil, nil
+}
+
+func (u *translationUsecase) SetCategoryTranslation(actor Actor, categoryID uint, locale string, name string) (*model.CategoryTranslation, error) {
+	locale, err := u.normalizeLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTranslation, errTranslationNameless)
+	}
+	if err := u.category(categoryID); err != nil {
+		return nil, err
+	}
+	before, err := u.categoryTranslation(categoryID, locale)
+	if err != nil {
+		return nil, err
+	}
+	translation := &model.CategoryTranslation{CategoryID: categoryID, Locale: locale, Name: name}
+	if err := u.translationRepo.SaveCategoryTranslation(translation); err != nil {
+		return nil, err
+	}
+	saved, err := u.categoryTranslation(categoryID, locale)
+	if err != nil {
+		return nil, err
+	}
+	if before == nil {
+		u.auditor.Record(actor, model.AuditCreate, model.AuditEntityCategoryTranslation, saved.ID, nil, saved)
+	} else {
+		u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityCategoryTranslation, saved.ID, before, saved)
+	}
+	return saved, nil
+}
+
+func (u *translationUsecase) DeleteCategoryTranslation(actor Actor, categoryID uint, locale string) error {
+	locale, err := u.normalizeLocale(locale)
+	if err != nil {
+		return err
+	}
+	before, err := u.categoryTranslation(categoryID, locale)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if err := u.translationRepo.DeleteCategoryTranslation(categoryID, locale); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityCategoryTranslation, before.ID, before, nil)
+	return nil
+}
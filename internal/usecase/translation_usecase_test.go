@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"testing"
+
+	"go-ecommerce-api/internal/domain/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// memoryTranslations keeps translations in slices, upserting them by
+// entity and locale like the database does.
+type memoryTranslations struct {
+	products   []model.ProductTranslation
+	categories []model.CategoryTranslation
+	nextID     uint
+}
+
+func (r *memoryTranslations) FindProductTranslations(productID uint) ([]model.ProductTranslation, error) {
+	var found []model.ProductTranslation
+	for _, translation := range r.products {
+		if translation.ProductID == productID {
+			found = append(found, translation)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTranslations) FindProductTranslationsIn(locale string, productIDs []uint) ([]model.ProductTranslation, error) {
+	var found []model.ProductTranslation
+	for _, translation := range r.products {
+		for _, id := range productIDs {
+			if translation.ProductID == id && translation.Locale == locale {
+				found = append(found, translation)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTranslations) SaveProductTranslation(translation *model.ProductTranslation) error {
+	for i, existing := range r.products {
+		if existing.ProductID == translation.ProductID && existing.Locale == translation.Locale {
+			r.products[i].Name, r.products[i].Description = translation.Name, translation.Description
+			return nil
+		}
+	}
+	r.nextID++
+	translation.ID = r.nextID
+	r.products = append(r.products, *translation)
+	return nil
+}
+
+func (r *memoryTranslations) DeleteProductTranslation(productID uint, locale string) error {
+	for i, existing := range r.products {
+		if existing.ProductID == productID && existing.Locale == locale {
+			r.products = append(r.products[:i], r.products[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryTranslations) FindCategoryTranslations(categoryID uint) ([]model.CategoryTranslation, error) {
+	var found []model.CategoryTranslation
+	for _, translation := range r.categories {
+		if translation.CategoryID == categoryID {
+			found = append(found, translation)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTranslations) FindCategoryTranslationsIn(locale string, categoryIDs []uint) ([]model.CategoryTranslation, error) {
+	var found []model.CategoryTranslation
+	for _, translation := range r.categories {
+		for _, id := range categoryIDs {
+			if translation.CategoryID == id && translation.Locale == locale {
+				found = append(found, translation)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryTranslations) SaveCategoryTranslation(translation *model.CategoryTranslation) error {
+	for i, existing := range r.categories {
+		if existing.CategoryID == translation.CategoryID && existing.Locale == translation.Locale {
+			r.categories[i].Name = translation.Name
+			return nil
+		}
+	}
+	r.nextID++
+	translation.ID = r.nextID
+	r.categories = append(r.categories, *translation)
+	return nil
+}
+
+func (r *memoryTranslations) DeleteCategoryTranslation(categoryID uint, locale string) error {
+	for i, existing := range r.categories {
+		if existing.CategoryID == categoryID && existing.Locale == locale {
+			r.categories = append(r.categories[:i], r.categories[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// newTestTranslations returns an English store that also sells in Polish
+// and German, without any translations.
+func newTestTranslations() *translationUsecase {
+	return NewTranslationUsecase(&memoryTranslations{}, newMockProductRepository(), new(MockCategoryRepository), &recordingAuditor{}, DefaultLocaleConfig()).(*translationUsecase)
+}
+
+func TestTranslationUsecaseNegotiate(t *testing.T) {
+	uc := newTestTranslations()
+
+	tests := []struct{ lang, acceptLanguage, want string }{
+		{"", "de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"", "fr-FR,pl;q=0.5", "pl"},
+		{"", "fr-FR", "en"},
+		{"", "", "en"},
+		{"pl-PL", "de-DE", "pl"},
+		{"PL", "", "pl"},
+		{"???", "de", "en"},
+	}
+	for _, test := range tests {
+		// Assertion 725: Negotiate should prefer lang, then Accept-Language, then the default locale
+		assert.Equal(t, test.want, uc.Negotiate(test.lang, test.acceptLanguage), test)
+	}
+
+	custom := NewTranslationUsecase(&memoryTranslations{}, nil, nil, &recordingAuditor{}, LocaleConfig{Default: "pl", Supported: []string{"de", " en "}})
+	// Assertion 726: The default locale should come first and be supported even when not listed
+	assert.Equal(t, []string{"pl", "de", "en"}, custom.Locales())
+	assert.Equal(t, "pl", custom.Negotiate("", "fr"))
+}
+
+func TestTranslationUsecaseLocalizes(t *testing.T) {
+	uc := newTestTranslations()
+	repo := uc.translationRepo.(*memoryTranslations)
+	repo.products = []model.ProductTranslation{
+		{ProductID: 1, Locale: "pl", Name: "Lampa", Description: "Lampa biurkowa"},
+		{ProductID: 2, Locale: "pl", Name: "Krzesło"},
+		{ProductID: 1, Locale: "de", Name: "Lampe"},
+	}
+	repo.categories = []model.CategoryTranslation{
+		{CategoryID: 1, Locale: "pl", Name: "Dom"},
+		{CategoryID: 2, Locale: "pl", Name: "Oświetlenie"},
+	}
+
+	products := []model.Product{
+		{ID: 1, Name: "Lamp", Description: "A desk lamp", Category: model.Category{ID: 2, Name: "Lighting"}},
+		{ID: 2, Name: "Chair", Description: "A chair"},
+		{ID: 3, Name: "Table"},
+	}
+	assert.NoError(t, uc.LocalizeProducts(products, "pl"))
+	// Assertion 727: Products should show their translations, keeping the fields that have none
+	assert.Equal(t, "Lampa", products[0].Name)
+	assert.Equal(t, "Lampa biurkowa", products[0].Description)
+	assert.Equal(t, "Oświetlenie", products[0].Category.Name)
+	assert.Equal(t, "Krzesło", products[1].Name)
+	assert.Equal(t, "A chair", products[1].Description)
+	assert.Equal(t, "Table", products[2].Name)
+
+	english := []model.Product{{ID: 1, Name: "Lamp"}}
+	assert.NoError(t, uc.LocalizeProducts(english, "en"))
+	// Assertion 728: Content in the default locale should be left as it is
+	assert.Equal(t, "Lamp", english[0].Name)
+
+	tree := []model.CategoryNode{{ID: 1, Name: "Home", Children: []model.CategoryNode{{ID: 2, Name: "Lighting"}}}}
+	path := []model.CategoryBreadcrumb{{ID: 1, Name: "Home"}, {ID: 2, Name: "Lighting"}}
+	categories := []model.Category{{ID: 2, Name: "Lighting", ParentCategory: &model.Category{ID: 1, Name: "Home"}}}
+	assert.NoError(t, uc.LocalizeCategoryTree(tree, "pl"))
+	assert.NoError(t, uc.LocalizeBreadcrumbs(path, "pl"))
+	assert.NoError(t, uc.LocalizeCategories(categories, "pl"))
+	// Assertion 729: Category trees, breadcrumbs and parents should be translated at every level
+	assert.Equal(t, "Dom", tree[0].Name)
+	assert.Equal(t, "Oświetlenie", tree[0].Children[0].Name)
+	assert.Equal(t, []model.CategoryBreadcrumb{{ID: 1, Name: "Dom"}, {ID: 2, Name: "Oświetlenie"}}, path)
+	assert.Equal(t, "Oświetlenie", categories[0].Name)
+	assert.Equal(t, "Dom", categories[0].ParentCategory.Name)
+}
+
+func TestTranslationUsecaseManagesProductTranslations(t *testing.T) {
+	uc := newTestTranslations()
+	products := uc.productRepo.(*mockProductRepository)
+	assert.NoError(t, products.Create(&model.Product{Name: "Lamp"}))
+	auditor := uc.auditor.(*recordingAuditor)
+
+	_, err := uc.SetProductTranslation(testActor, 1, "en", ProductTranslationInput{Name: "Lamp"})
+	// Assertion 730: The default locale and unsupported ones should not take translations
+	assert.ErrorIs(t, err, ErrUnsupportedLocale)
+	_, err = uc.SetProductTranslation(testActor, 1, "fr", ProductTranslationInput{Name: "Lampe"})
+	assert.ErrorIs(t, err, ErrUnsupportedLocale)
+
+	_, err = uc.SetProductTranslation(testActor, 1, "pl", ProductTranslationInput{Name: "  "})
+	// Assertion 731: A translation without a name should be rejected
+	assert.ErrorIs(t, err, ErrInvalidTranslation)
+
+	_, err = uc.SetProductTranslation(testActor, 9, "pl", ProductTranslationInput{Name: "Lampa"})
+	// Assertion 732: A missing product should not be found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	created, err := uc.SetProductTranslation(testActor, 1, "PL", ProductTranslationInput{Name: " Lampa "})
+	updated, _ := uc.SetProductTranslation(testActor, 1, "pl", ProductTranslationInput{Name: "Lampa biurkowa"})
+	// Assertion 733: Setting a translation should create it, then replace it
+	assert.NoError(t, err)
+	assert.Equal(t, "pl", created.Locale)
+	assert.Equal(t, "Lampa", created.Name)
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "Lampa biurkowa", updated.Name)
+
+	assert.NoError(t, uc.DeleteProductTranslation(testActor, 1, "pl"))
+	translations, _ := uc.GetProductTranslations(1)
+	// Assertion 734: A deleted translation should be gone, and deleting it again should not find it
+	assert.Empty(t, translations)
+	assert.ErrorIs(t, uc.DeleteProductTranslation(testActor, 1, "pl"), gorm.ErrRecordNotFound)
+
+	// Assertion 735: Changes to translations should be audited
+	assert.Equal(t, []string{"product_translation.create", "product_translation.update", "product_translation.delete"}, auditor.actions())
+}
+
+func TestTranslationUsecaseManagesCategoryTranslations(t *testing.T) {
+	uc := newTestTranslations()
+	categories := uc.categoryRepo.(*MockCategoryRepository)
+	categories.On("FindByID", uint(1)).Return(&model.Category{ID: 1, Name: "Home"}, nil)
+	categories.On("FindByID", mock.Anything).Return(nil, nil)
+
+	_, err := uc.SetCategoryTranslation(testActor, 1, "de-DE", "Zuhause")
+	// Assertion 736: Regional variants should not be confused with the supported locale
+	assert.ErrorIs(t, err, ErrUnsupportedLocale)
+
+	translation, err := uc.SetCategoryTranslation(testActor, 1, "de", "Zuhause")
+	// Assertion 737: A category translation should be stored under the category
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), translation.CategoryID)
+	translations, _ := uc.GetCategoryTranslations(1)
+	assert.Len(t, translations, 1)
+
+	_, err = uc.GetCategoryTranslations(2)
+	// Assertion 738: Translations of a missing category should not be found
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestOrderUsecaseCreateFromCartNamesItemsInLocale(t *testing.T) {
+	uc, mockOrderRepo, mockCartRepo, mockCartItemRepo, mockProductRepo, _, mockAddressRepo := setupOrderUsecase()
+	translations := newTestTranslations()
+	translations.translationRepo.(*memoryTranslations).products = []model.ProductTranslation{
+		{ProductID: 1, Locale: "pl", Name: "Lampa"},
+	}
+	uc.names = translations
+
+	cart := &model.Cart{ID: 1, UserID: uintPtr(1), Items: []model.CartItem{{ID: 1, CartID: 1, ProductID: 1, Quantity: 1, UnitPrice: 50}}}
+	product := &model.Product{ID: 1, Name: "Lamp", Price: 50, Stock: 10}
+	mockCartRepo.On("FindByUserID", uint(1)).Return(cart, nil)
+	mockAddressRepo.On("FindByID", uint(1)).Return(&model.Address{ID: 1, Country: "Poland"}, nil)
+	mockProductRepo.On("FindByID", uint(1)).Return(product, nil)
+	mockProductRepo.On("Update", mock.AnythingOfType(modelProduct)).Return(nil)
+	mockOrderRepo.On("Create", mock.AnythingOfType(modelOrder)).Return(nil)
+	mockCartItemRepo.On("ClearCart", uint(1)).Return(nil)
+	mockCartRepo.On("Update", mock.AnythingOfType(modelCart)).Return(nil)
+
+	order, err := uc.CreateFromCart(testActor, 1, model.PaymentCard, 1, 0, "", "pl")
+	// Assertion 739: Order items should keep the product name in the order's locale
+	assert.NoError(t, err)
+	assert.Equal(t, "pl", order.Locale)
+	assert.Equal(t, "Lampa", order.Items[0].Name)
+	assert.Equal(t, "Lamp", product.Name)
+}
@@ -1,34 +1,95 @@
 package usecase
 
 import (
-	"errors"
+	"time"
 
 	"go-ecommerce-api/internal/domain/model"
 	"go-ecommerce-api/internal/domain/repository"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Account lockout policy: after maxFailedLoginAttempts consecutive failures the
+// account is locked for baseLockoutDuration, doubling with every further failure.
+const (
+	maxFailedLoginAttempts = 5
+	baseLockoutDuration    = time.Minute
+	maxLockoutDuration     = 24 * time.Hour
+)
+
+// AccountLockedError is returned by Login while the account is locked out.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account temporarily locked"
+}
+
+var (
+	ErrAccountDeactivated = NewError(KindForbidden, "account_deactivated", "account deactivated")
+	ErrTokenRevoked       = NewError(KindUnauthorized, "token_revoked", "token was revoked, log in again")
+	ErrAccountClosed      = NewError(KindConflict, "account_closed", "closed accounts cannot be reactivated")
+	ErrInvalidRole        = NewError(KindValidation, "invalid_role", "invalid role")
+	ErrSelfModification   = NewError(KindForbidden, "self_modification", "admins cannot change their own role or status")
+	ErrInvalidUser        = NewError(KindValidation, "invalid_user", "invalid user")
+	ErrInvalidCredentials = NewError(KindUnauthorized, "invalid_credentials", "invalid credentials")
+	errRegistrationInput  = NewError(KindValidation, "invalid_input", "invalid input")
+)
+
+// PasswordHasher hashes new passwords and verifies stored hashes.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify checks password against encoded and reports whether the hash
+	// should be replaced because it uses an outdated algorithm or cost.
+	Verify(encoded, password string) (ok bool, needsRehash bool, err error)
+}
+
+// PasswordPolicy decides whether a new password is strong enough. Validate
+// returns an error listing the rules the password breaks.
+type PasswordPolicy interface {
+	Validate(password string) error
+}
+
 type UserUsecase interface {
 	GetByID(id uint) (*model.User, error)
 	GetAll() ([]model.User, error)
 	GetWithFilters(filters map[string]string) ([]model.User, error)
-	Register(user *model.User, password string, address *model.Address) (*model.User, error)
-	Login(email, password string) (*model.User, error)
-	Update(user *model.User) (*model.User, error)
-	Delete(id uint) error
+	Register(actor Actor, user *model.User, password string, address *model.Address) (*model.User, error)
+	// Login records every attempt, successful or not, in the audit log.
+	Login(actor Actor, email, password string) (*model.User, error)
+	Update(actor Actor, user *model.User) (*model.User, error)
+	Delete(actor Actor, id uint) error
+	ChangeRole(actor Actor, id uint, role string) (*model.User, error)
+	Deactivate(actor Actor, id uint) (*model.User, error)
+	// Reactivate lifts a deactivation. Closed accounts stay closed.
+	Reactivate(actor Actor, id uint) (*model.User, error)
+	// CheckActive returns the user's stored role, or an error unless the
+	// user exists, is not deactivated and tokenVersion is their current one.
+	CheckActive(id uint, tokenVersion int) (string, error)
 }
 
 type userUsecase struct {
 	userRepo repository.UserRepository
 	addrRepo repository.AddressRepository
+	hasher   PasswordHasher
+	policy   PasswordPolicy
+	auditor  Auditor
 }
 
-func NewUserUsecase(userRepo repository.UserRepository, addrRepo repository.AddressRepository) UserUsecase {
+func NewUserUsecase(
+	userRepo repository.UserRepository,
+	addrRepo repository.AddressRepository,
+	hasher PasswordHasher,
+	policy PasswordPolicy,
+	auditor Auditor,
+) UserUsecase {
 	return &userUsecase{
 		userRepo: userRepo,
 		addrRepo: addrRepo,
+		hasher:   hasher,
+		policy:   policy,
+		auditor:  auditor,
 	}
 }
 
@@ -51,9 +112,12 @@ func (u *userUsecase) GetWithFilters(filters map[string]string) ([]model.User, e
 	return u.userRepo.FindWithFilters(filters)
 }
 
-func (u *userUsecase) Register(user *model.User, password string, address *model.Address) (*model.User, error) {
+func (u *userUsecase) Register(actor Actor, user *model.User, plain string, address *model.Address) (*model.User, error) {
 	if user == nil || address == nil {
-		return nil, errors.New("invalid input")
+		return nil, errRegistrationInput
+	}
+	if err := u.policy.Validate(plain); err != nil {
+		return nil, err
 	}
 
 	existing, err := u.userRepo.FindByEmail(user.Email)
@@ -61,52 +125,155 @@ func (u *userUsecase) Register(user *model.User, password string, address *model
 		return nil, err
 	}
 	if existing != nil {
-		return nil, errors.New("email already in use")
+		return nil, ErrEmailInUse
 	}
 
 	if err := u.addrRepo.Create(address); err != nil {
 		return nil, err
 	}
 
-	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashed, err := u.hasher.Hash(plain)
 	if err != nil {
 		return nil, err
 	}
-	user.Password = string(hashed)
+	user.Password = hashed
 	user.AddressID = address.ID
 
 	if err := u.userRepo.Create(user); err != nil {
 		return nil, err
 	}
-	return u.userRepo.FindByID(user.ID)
+	created, err := u.userRepo.FindByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityUser, user.ID, nil, created)
+	return created, nil
 }
 
-func (u *userUsecase) Login(email, password string) (*model.User, error) {
+// Reasons recorded with failed logins.
+const (
+	loginFailedUnknownEmail  = "unknown_email"
+	loginFailedLocked        = "locked"
+	loginFailedWrongPassword = "wrong_password"
+	loginFailedDeactivated   = "deactivated"
+)
+
+func (u *userUsecase) Login(actor Actor, email, plain string) (*model.User, error) {
 	user, err := u.userRepo.FindByEmail(email)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New("invalid credentials")
+		u.recordFailedLogin(actor, email, nil, loginFailedUnknownEmail)
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		u.recordFailedLogin(actor, email, user, loginFailedLocked)
+		return nil, &AccountLockedError{Until: *user.LockedUntil}
+	}
+
+	ok, needsRehash, err := u.hasher.Verify(user.Password, plain)
+	if err != nil || !ok {
+		u.recordFailedLogin(actor, email, user, loginFailedWrongPassword)
+		return nil, u.registerFailedLogin(user, now)
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, errors.New("invalid credentials")
+	// Checked only after the password so the response does not reveal
+	// whether an arbitrary email belongs to a deactivated account.
+	if user.DeactivatedAt != nil {
+		u.recordFailedLogin(actor, email, user, loginFailedDeactivated)
+		return nil, ErrAccountDeactivated
 	}
+
+	changed := false
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = nil
+		changed = true
+	}
+	// Upgrade hashes made with an outdated algorithm or cost while the
+	// plaintext is at hand; a failure here must not block the login.
+	if needsRehash {
+		if hashed, err := u.hasher.Hash(plain); err == nil {
+			user.Password = hashed
+			changed = true
+		}
+	}
+	if changed {
+		if err := u.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+	}
+
+	actor.UserID = user.ID
+	actor.Role = user.Role
+	u.auditor.Record(actor, model.AuditLogin, model.AuditEntityUser, user.ID, nil, nil)
 	return user, nil
 }
 
-func (u *userUsecase) Update(user *model.User) (*model.User, error) {
+func (u *userUsecase) recordFailedLogin(actor Actor, email string, user *model.User, reason string) {
+	var userID uint
+	if user != nil {
+		userID = user.ID
+	}
+	u.auditor.Record(actor, model.AuditLoginFailed, model.AuditEntityUser, userID, nil,
+		map[string]string{"email": email, "reason": reason})
+}
+
+// registerFailedLogin counts a failed attempt and locks the account once the
+// threshold is reached. The count comes from the database, so parallel
+// guesses cannot stay under the threshold by overwriting each other's count.
+// It returns the error Login should report.
+func (u *userUsecase) registerFailedLogin(user *model.User, now time.Time) error {
+	attempts, err := u.userRepo.IncrementFailedLogins(user.ID)
+	if err != nil {
+		return err
+	}
+	user.FailedLoginAttempts = attempts
+	if attempts < maxFailedLoginAttempts {
+		return ErrInvalidCredentials
+	}
+	until := now.Add(lockoutDuration(attempts))
+	if err := u.userRepo.LockUntil(user.ID, until); err != nil {
+		return err
+	}
+	user.LockedUntil = &until
+	return &AccountLockedError{Until: until}
+}
+
+func lockoutDuration(attempts int) time.Duration {
+	d := baseLockoutDuration
+	for i := maxFailedLoginAttempts; i < attempts; i++ {
+		d *= 2
+		if d >= maxLockoutDuration {
+			return maxLockoutDuration
+		}
+	}
+	return d
+}
+
+func (u *userUsecase) Update(actor Actor, user *model.User) (*model.User, error) {
 	if user == nil || user.ID == 0 {
-		return nil, errors.New("invalid user")
+		return nil, ErrInvalidUser
+	}
+	before, err := u.GetByID(user.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := u.userRepo.Update(user); err != nil {
 		return nil, err
 	}
-	return u.userRepo.FindByID(user.ID)
+	updated, err := u.userRepo.FindByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityUser, user.ID, before, updated)
+	return updated, nil
 }
 
-func (u *userUsecase) Delete(id uint) error {
+func (u *userUsecase) Delete(actor Actor, id uint) error {
 	user, err := u.userRepo.FindByID(id)
 	if err != nil {
 		return err
@@ -114,5 +281,87 @@ func (u *userUsecase) Delete(id uint) error {
 	if user == nil {
 		return gorm.ErrRecordNotFound
 	}
-	return u.userRepo.Delete(id)
+	if err := u.userRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityUser, id, user, nil)
+	return nil
+}
+
+func (u *userUsecase) ChangeRole(actor Actor, id uint, role string) (*model.User, error) {
+	if !model.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if actor.UserID == id {
+		return nil, ErrSelfModification
+	}
+	user, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+	before := *user
+	user.Role = role
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditRoleChange, model.AuditEntityUser, id, &before, user)
+	return user, nil
+}
+
+func (u *userUsecase) Deactivate(actor Actor, id uint) (*model.User, error) {
+	if actor.UserID == id {
+		return nil, ErrSelfModification
+	}
+	user, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt != nil {
+		return user, nil
+	}
+	before := *user
+	now := time.Now()
+	user.DeactivatedAt = &now
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditDeactivate, model.AuditEntityUser, id, &before, user)
+	return user, nil
+}
+
+func (u *userUsecase) Reactivate(actor Actor, id uint) (*model.User, error) {
+	user, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.ClosedAt != nil {
+		return nil, ErrAccountClosed
+	}
+	if user.DeactivatedAt == nil {
+		return user, nil
+	}
+	before := *user
+	user.DeactivatedAt = nil
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditReactivate, model.AuditEntityUser, id, &before, user)
+	return user, nil
+}
+
+func (u *userUsecase) CheckActive(id uint, tokenVersion int) (string, error) {
+	user, err := u.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	if user.DeactivatedAt != nil {
+		return "", ErrAccountDeactivated
+	}
+	if user.TokenVersion != tokenVersion {
+		return "", ErrTokenRevoked
+	}
+	return user.Role, nil
 }
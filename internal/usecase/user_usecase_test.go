@@ -3,8 +3,10 @@ package usecase
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/password"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -63,8 +65,16 @@ const (
 	correctPassword         = "correctpassword"
 	wrongPassword           = "wrongpassword"
 	plainPassword           = "plainpassword"
+	strongPassword          = "Str0ngEnough!"
 )
 
+// testPasswordPolicy only enforces a minimum length so fixtures stay readable.
+var testPasswordPolicy = password.Policy{MinLength: 8}
+
+func newTestHasher() password.Hasher {
+	return password.NewHasher(password.NewBcryptScheme(bcrypt.DefaultCost))
+}
+
 func setupUserUsecase() (*userUsecase, *MockUserRepository, *MockAddressRepository) {
 	mockUserRepo := new(MockUserRepository)
 	mockAddrRepo := new(MockAddressRepository)
@@ -72,6 +82,9 @@ func setupUserUsecase() (*userUsecase, *MockUserRepository, *MockAddressReposito
 	uc := &userUsecase{
 		userRepo: mockUserRepo,
 		addrRepo: mockAddrRepo,
+		hasher:   newTestHasher(),
+		policy:   testPasswordPolicy,
+		auditor:  &recordingAuditor{},
 	}
 
 	return uc, mockUserRepo, mockAddrRepo
@@ -81,7 +94,7 @@ func TestNewUserUsecase(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAddrRepo := new(MockAddressRepository)
 
-	uc := NewUserUsecase(mockUserRepo, mockAddrRepo)
+	uc := NewUserUsecase(mockUserRepo, mockAddrRepo, newTestHasher(), testPasswordPolicy, &recordingAuditor{})
 
 	// Assertion 292: NewUserUsecase should return a non-nil usecase instance
 	assert.NotNil(t, uc)
@@ -301,7 +314,7 @@ func TestUserUsecaseRegisterSuccess(t *testing.T) {
 	})
 	mockUserRepo.On("FindByID", uint(1)).Return(createdUser, nil)
 
-	result, err := uc.Register(user, password123, address)
+	result, err := uc.Register(testActor, user, password123, address)
 
 	// Assertion 325: Register should not return an error for valid registration
 	assert.NoError(t, err)
@@ -323,7 +336,7 @@ func TestUserUsecaseRegisterNilUser(t *testing.T) {
 
 	address := &model.Address{Street: mainStreet, Number: testNumber, City: userTestCity}
 
-	result, err := uc.Register(nil, password123, address)
+	result, err := uc.Register(testActor, nil, password123, address)
 
 	// Assertion 330: Register should return error for nil user
 	assert.Error(t, err)
@@ -341,7 +354,7 @@ func TestUserUsecaseRegisterNilAddress(t *testing.T) {
 
 	user := &model.User{Email: testEmail, Name: newName, Surname: userSurname}
 
-	result, err := uc.Register(user, password123, nil)
+	result, err := uc.Register(testActor, user, password123, nil)
 
 	// Assertion 333: Register should return error for nil address
 	assert.Error(t, err)
@@ -368,7 +381,7 @@ func TestUserUsecaseRegisterEmailAlreadyExists(t *testing.T) {
 
 	mockUserRepo.On("FindByEmail", existingEmail).Return(existingUser, nil)
 
-	result, err := uc.Register(user, password123, address)
+	result, err := uc.Register(testActor, user, password123, address)
 
 	// Assertion 336: Register should return error for existing email
 	assert.Error(t, err)
@@ -390,7 +403,7 @@ func TestUserUsecaseRegisterAddressCreationError(t *testing.T) {
 	mockUserRepo.On("FindByEmail", testEmail).Return(nil, nil)
 	mockAddrRepo.On("Create", address).Return(errors.New(addressCreationFailed))
 
-	result, err := uc.Register(user, password123, address)
+	result, err := uc.Register(testActor, user, password123, address)
 
 	// Assertion 339: Register should return error when address creation fails
 	assert.Error(t, err)
@@ -417,7 +430,7 @@ func TestUserUsecaseLoginSuccess(t *testing.T) {
 
 	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
 
-	result, err := uc.Login(userExampleEmail, password123)
+	result, err := uc.Login(testActor, userExampleEmail, password123)
 
 	// Assertion 342: Login should not return an error for valid credentials
 	assert.NoError(t, err)
@@ -436,7 +449,7 @@ func TestUserUsecaseLoginUserNotFound(t *testing.T) {
 
 	mockUserRepo.On("FindByEmail", nonexistentEmail).Return(nil, nil)
 
-	result, err := uc.Login(nonexistentEmail, password123)
+	result, err := uc.Login(testActor, nonexistentEmail, password123)
 
 	// Assertion 346: Login should return error for non-existent user
 	assert.Error(t, err)
@@ -461,8 +474,9 @@ func TestUserUsecaseLoginWrongPassword(t *testing.T) {
 	}
 
 	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+	mockUserRepo.On("IncrementFailedLogins", uint(1)).Return(1, nil)
 
-	result, err := uc.Login(userExampleEmail, wrongPassword)
+	result, err := uc.Login(testActor, userExampleEmail, wrongPassword)
 
 	// Assertion 349: Login should return error for wrong password
 	assert.Error(t, err)
@@ -470,16 +484,116 @@ func TestUserUsecaseLoginWrongPassword(t *testing.T) {
 	assert.Nil(t, result)
 	// Assertion 351: Login should return appropriate error message for wrong password
 	assert.EqualError(t, err, invalidCredentials)
+	// Assertion 404: Login should count the failed attempt
+	assert.Equal(t, 1, existingUser.FailedLoginAttempts)
+	// Assertion 858: The failed attempt should be counted in the database, not saved over the whole user
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "LockUntil", mock.Anything, mock.Anything)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
+	existingUser := &model.User{
+		ID:                  1,
+		Email:               userExampleEmail,
+		Password:            string(hashedPassword),
+		FailedLoginAttempts: maxFailedLoginAttempts - 1,
+	}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+	mockUserRepo.On("IncrementFailedLogins", uint(1)).Return(maxFailedLoginAttempts, nil)
+	mockUserRepo.On("LockUntil", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := uc.Login(testActor, userExampleEmail, wrongPassword)
+
+	var lockedErr *AccountLockedError
+	// Assertion 405: Login should return AccountLockedError once the threshold is reached
+	assert.ErrorAs(t, err, &lockedErr)
+	// Assertion 406: Login should return nil user when locking the account
+	assert.Nil(t, result)
+	// Assertion 407: Login should persist the unlock timestamp
+	assert.NotNil(t, existingUser.LockedUntil)
+	// Assertion 408: Lockout error should carry the unlock timestamp
+	assert.Equal(t, *existingUser.LockedUntil, lockedErr.Until)
+	mockUserRepo.AssertCalled(t, "LockUntil", uint(1), lockedErr.Until)
 
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestUserUsecaseLoginRejectsLockedAccount(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
+	until := time.Now().Add(time.Hour)
+	existingUser := &model.User{
+		ID:                  1,
+		Email:               userExampleEmail,
+		Password:            string(hashedPassword),
+		FailedLoginAttempts: maxFailedLoginAttempts,
+		LockedUntil:         &until,
+	}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+
+	result, err := uc.Login(testActor, userExampleEmail, correctPassword)
+
+	var lockedErr *AccountLockedError
+	// Assertion 409: Login should reject even the correct password while locked
+	assert.ErrorAs(t, err, &lockedErr)
+	// Assertion 410: Login should return nil user while locked
+	assert.Nil(t, result)
+
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseLoginResetsFailedAttempts(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
+	expired := time.Now().Add(-time.Minute)
+	existingUser := &model.User{
+		ID:                  1,
+		Email:               userExampleEmail,
+		Password:            string(hashedPassword),
+		FailedLoginAttempts: maxFailedLoginAttempts,
+		LockedUntil:         &expired,
+	}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+	mockUserRepo.On("Update", existingUser).Return(nil)
+
+	result, err := uc.Login(testActor, userExampleEmail, correctPassword)
+
+	// Assertion 411: Login should succeed once the lock has expired
+	assert.NoError(t, err)
+	// Assertion 412: Login should reset the failed attempt counter
+	assert.Equal(t, 0, result.FailedLoginAttempts)
+	// Assertion 413: Login should clear the unlock timestamp
+	assert.Nil(t, result.LockedUntil)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestLockoutDurationGrowsProgressively(t *testing.T) {
+	// Assertion 414: First lockout should last the base duration
+	assert.Equal(t, baseLockoutDuration, lockoutDuration(maxFailedLoginAttempts))
+	// Assertion 415: Each further failure should double the lockout
+	assert.Equal(t, 4*baseLockoutDuration, lockoutDuration(maxFailedLoginAttempts+2))
+	// Assertion 416: Lockout should be capped
+	assert.Equal(t, maxLockoutDuration, lockoutDuration(maxFailedLoginAttempts+100))
+}
+
 func TestUserUsecaseLoginRepositoryError(t *testing.T) {
 	uc, mockUserRepo, _ := setupUserUsecase()
 
 	mockUserRepo.On("FindByEmail", userExampleEmail).Return(nil, errors.New(dbError))
 
-	result, err := uc.Login(userExampleEmail, password123)
+	result, err := uc.Login(testActor, userExampleEmail, password123)
 
 	// Assertion 352: Login should return error when repository fails
 	assert.Error(t, err)
@@ -513,7 +627,7 @@ func TestUserUsecaseUpdateSuccess(t *testing.T) {
 	mockUserRepo.On("Update", updateUser).Return(nil)
 	mockUserRepo.On("FindByID", uint(1)).Return(updatedUser, nil)
 
-	result, err := uc.Update(updateUser)
+	result, err := uc.Update(testActor, updateUser)
 
 	// Assertion 355: Update should not return an error for valid user
 	assert.NoError(t, err)
@@ -534,7 +648,7 @@ func TestUserUsecaseUpdateSuccess(t *testing.T) {
 func TestUserUsecaseUpdateNilUser(t *testing.T) {
 	uc, mockUserRepo, _ := setupUserUsecase()
 
-	result, err := uc.Update(nil)
+	result, err := uc.Update(testActor, nil)
 
 	// Assertion 361: Update should return error for nil user
 	assert.Error(t, err)
@@ -551,7 +665,7 @@ func TestUserUsecaseUpdateZeroID(t *testing.T) {
 
 	zeroIDUser := &model.User{ID: 0, Email: testEmail, Name: testName}
 
-	result, err := uc.Update(zeroIDUser)
+	result, err := uc.Update(testActor, zeroIDUser)
 
 	// Assertion 364: Update should return error for zero ID
 	assert.Error(t, err)
@@ -568,9 +682,10 @@ func TestUserUsecaseUpdateRepositoryUpdateError(t *testing.T) {
 
 	updateUser := &model.User{ID: 1, Email: testEmail, Name: testName}
 
+	mockUserRepo.On("FindByID", uint(1)).Return(&model.User{ID: 1, Email: testEmail}, nil)
 	mockUserRepo.On("Update", updateUser).Return(errors.New(updateFailed))
 
-	result, err := uc.Update(updateUser)
+	result, err := uc.Update(testActor, updateUser)
 
 	// Assertion 367: Update should return error when repository update fails
 	assert.Error(t, err)
@@ -580,7 +695,7 @@ func TestUserUsecaseUpdateRepositoryUpdateError(t *testing.T) {
 	assert.EqualError(t, err, updateFailed)
 
 	mockUserRepo.AssertExpectations(t)
-	mockUserRepo.AssertNotCalled(t, "FindByID")
+	mockUserRepo.AssertNumberOfCalls(t, "FindByID", 1)
 }
 
 func TestUserUsecaseUpdateRepositoryFindError(t *testing.T) {
@@ -588,10 +703,11 @@ func TestUserUsecaseUpdateRepositoryFindError(t *testing.T) {
 
 	updateUser := &model.User{ID: 1, Email: testEmail, Name: testName}
 
+	mockUserRepo.On("FindByID", uint(1)).Return(&model.User{ID: 1, Email: testEmail}, nil).Once()
 	mockUserRepo.On("Update", updateUser).Return(nil)
 	mockUserRepo.On("FindByID", uint(1)).Return(nil, errors.New(findFailed))
 
-	result, err := uc.Update(updateUser)
+	result, err := uc.Update(testActor, updateUser)
 
 	// Assertion 370: Update should return error when repository find fails after update
 	assert.Error(t, err)
@@ -615,7 +731,7 @@ func TestUserUsecaseDeleteSuccess(t *testing.T) {
 	mockUserRepo.On("FindByID", uint(1)).Return(existingUser, nil)
 	mockUserRepo.On("Delete", uint(1)).Return(nil)
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 373: Delete should not return an error for valid user ID
 	assert.NoError(t, err)
@@ -628,7 +744,7 @@ func TestUserUsecaseDeleteUserNotFound(t *testing.T) {
 
 	mockUserRepo.On("FindByID", uint(999)).Return(nil, nil)
 
-	err := uc.Delete(999)
+	err := uc.Delete(testActor, 999)
 
 	// Assertion 374: Delete should return gorm.ErrRecordNotFound for non-existent user
 	assert.Equal(t, gorm.ErrRecordNotFound, err)
@@ -642,7 +758,7 @@ func TestUserUsecaseDeleteRepositoryFindError(t *testing.T) {
 
 	mockUserRepo.On("FindByID", uint(1)).Return(nil, errors.New(findError))
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 375: Delete should return error when repository find fails
 	assert.Error(t, err)
@@ -661,7 +777,7 @@ func TestUserUsecaseDeleteRepositoryDeleteError(t *testing.T) {
 	mockUserRepo.On("FindByID", uint(1)).Return(existingUser, nil)
 	mockUserRepo.On("Delete", uint(1)).Return(errors.New(deleteFailed))
 
-	err := uc.Delete(1)
+	err := uc.Delete(testActor, 1)
 
 	// Assertion 377: Delete should return error when repository delete fails
 	assert.Error(t, err)
@@ -710,7 +826,7 @@ func TestUserUsecaseIntegrationCompleteUserFlow(t *testing.T) {
 	mockUserRepo.On("FindByID", uint(2)).Return(registeredUser, nil).Once()
 
 	// Register the user
-	registered, err := uc.Register(user, password123, address)
+	registered, err := uc.Register(testActor, user, password123, address)
 
 	// Assertion 379: Integration test should successfully register user
 	assert.NoError(t, err)
@@ -731,7 +847,7 @@ func TestUserUsecaseIntegrationCompleteUserFlow(t *testing.T) {
 
 	mockUserRepo.On("FindByEmail", integrationEmail).Return(loginUser, nil).Once()
 
-	loggedIn, err := uc.Login(integrationEmail, password123)
+	loggedIn, err := uc.Login(testActor, integrationEmail, password123)
 
 	// Assertion 382: Integration test should successfully login user
 	assert.NoError(t, err)
@@ -757,10 +873,11 @@ func TestUserUsecaseIntegrationCompleteUserFlow(t *testing.T) {
 		Role:    adminRole,
 	}
 
+	mockUserRepo.On("FindByID", uint(2)).Return(registeredUser, nil).Once()
 	mockUserRepo.On("Update", updateUser).Return(nil).Once()
 	mockUserRepo.On("FindByID", uint(2)).Return(updatedUser, nil).Once()
 
-	updated, err := uc.Update(updateUser)
+	updated, err := uc.Update(testActor, updateUser)
 
 	// Assertion 385: Integration test should successfully update user
 	assert.NoError(t, err)
@@ -812,7 +929,7 @@ func TestUserUsecaseIntegrationCompleteUserFlow(t *testing.T) {
 	mockUserRepo.On("FindByID", uint(2)).Return(updatedUser, nil).Once()
 	mockUserRepo.On("Delete", uint(2)).Return(nil).Once()
 
-	err = uc.Delete(2)
+	err = uc.Delete(testActor, 2)
 
 	// Assertion 397: Integration test should successfully delete user
 	assert.NoError(t, err)
@@ -852,7 +969,7 @@ func TestUserUsecaseRegisterPasswordHashing(t *testing.T) {
 	})
 	mockUserRepo.On("FindByID", uint(3)).Return(createdUser, nil)
 
-	result, err := uc.Register(user, plainPassword, address)
+	result, err := uc.Register(testActor, user, plainPassword, address)
 
 	// Assertion 400: Register should successfully hash password
 	assert.NoError(t, err)
@@ -866,3 +983,204 @@ func TestUserUsecaseRegisterPasswordHashing(t *testing.T) {
 	mockUserRepo.AssertExpectations(t)
 	mockAddrRepo.AssertExpectations(t)
 }
+
+func TestUserUsecaseRegisterRejectsWeakPassword(t *testing.T) {
+	uc, mockUserRepo, mockAddrRepo := setupUserUsecase()
+	uc.policy = password.DefaultPolicy()
+
+	user := &model.User{Email: testEmail, Name: newName, Surname: userSurname}
+	address := &model.Address{Street: mainStreet, Number: testNumber, City: userTestCity}
+
+	for _, weak := range []string{"", "short1A", "alllowercase1", "Password123"} {
+		result, err := uc.Register(testActor, user, weak, address)
+
+		var policyErr *password.PolicyError
+		// Assertion 417: Register should reject passwords violating the policy
+		assert.ErrorAs(t, err, &policyErr, weak)
+		// Assertion 418: Register should list at least one violation
+		assert.NotEmpty(t, policyErr.Violations, weak)
+		// Assertion 419: Register should return nil user for a weak password
+		assert.Nil(t, result)
+	}
+
+	mockUserRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	mockAddrRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUserUsecaseRegisterAcceptsStrongPassword(t *testing.T) {
+	// Assertion 420: Default policy should accept a long mixed-class password
+	assert.NoError(t, password.DefaultPolicy().Validate(strongPassword))
+}
+
+func TestUserUsecaseLoginUpgradesOutdatedHash(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+	argon := &password.Argon2idScheme{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	uc.hasher = password.NewHasher(argon, password.NewBcryptScheme(bcrypt.DefaultCost))
+
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte(password123), bcrypt.MinCost)
+	existingUser := &model.User{ID: 1, Email: userExampleEmail, Password: string(legacyHash)}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+	mockUserRepo.On("Update", existingUser).Return(nil).Once()
+
+	result, err := uc.Login(testActor, userExampleEmail, password123)
+
+	// Assertion 421: Login should succeed with a legacy bcrypt hash
+	assert.NoError(t, err)
+	// Assertion 422: Login should replace the legacy hash with the preferred scheme
+	assert.True(t, argon.Recognizes(result.Password))
+	// Assertion 423: The upgraded hash should still verify the password
+	ok, needsRehash, err := uc.hasher.Verify(result.Password, password123)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseLoginUpgradesLowBcryptCost(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	cheapHash, _ := bcrypt.GenerateFromPassword([]byte(password123), bcrypt.MinCost)
+	existingUser := &model.User{ID: 1, Email: userExampleEmail, Password: string(cheapHash)}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+	mockUserRepo.On("Update", existingUser).Return(nil).Once()
+
+	result, err := uc.Login(testActor, userExampleEmail, password123)
+
+	// Assertion 424: Login should succeed with a low-cost bcrypt hash
+	assert.NoError(t, err)
+	// Assertion 425: Login should rehash with the configured cost
+	cost, _ := bcrypt.Cost([]byte(result.Password))
+	assert.Equal(t, bcrypt.DefaultCost, cost)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseLoginRejectsDeactivatedAccount(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
+	deactivatedAt := time.Now()
+	existingUser := &model.User{
+		ID:            1,
+		Email:         userExampleEmail,
+		Password:      string(hashedPassword),
+		DeactivatedAt: &deactivatedAt,
+	}
+
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(existingUser, nil)
+
+	result, err := uc.Login(testActor, userExampleEmail, correctPassword)
+
+	// Assertion 445: Login should reject deactivated accounts
+	assert.ErrorIs(t, err, ErrAccountDeactivated)
+	// Assertion 446: Login should return nil user for deactivated accounts
+	assert.Nil(t, result)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseChangeRole(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	target := &model.User{ID: 2, Email: userExampleEmail, Role: userRole}
+	mockUserRepo.On("FindByID", uint(2)).Return(target, nil)
+	mockUserRepo.On("Update", target).Return(nil).Once()
+
+	result, err := uc.ChangeRole(testActor, 2, adminRole)
+
+	// Assertion 447: ChangeRole should promote a user
+	assert.NoError(t, err)
+	// Assertion 448: ChangeRole should persist the new role
+	assert.Equal(t, adminRole, result.Role)
+
+	role, err := uc.CheckActive(2, 0)
+	// Assertion 774: CheckActive should return the stored role, not the one in old tokens
+	assert.NoError(t, err)
+	assert.Equal(t, adminRole, role)
+
+	_, err = uc.ChangeRole(testActor, 2, "superuser")
+	// Assertion 449: ChangeRole should reject unknown roles
+	assert.ErrorIs(t, err, ErrInvalidRole)
+
+	_, err = uc.ChangeRole(testActor, 1, userRole)
+	// Assertion 450: ChangeRole should not let admins demote themselves
+	assert.ErrorIs(t, err, ErrSelfModification)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseDeactivateAndReactivate(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	target := &model.User{ID: 2, Email: userExampleEmail, Role: userRole}
+	mockUserRepo.On("FindByID", uint(2)).Return(target, nil)
+	mockUserRepo.On("Update", target).Return(nil).Twice()
+
+	result, err := uc.Deactivate(testActor, 2)
+	// Assertion 451: Deactivate should succeed for another user
+	assert.NoError(t, err)
+	// Assertion 452: Deactivate should set the deactivation timestamp
+	assert.NotNil(t, result.DeactivatedAt)
+	_, err = uc.CheckActive(2, 0)
+	// Assertion 453: CheckActive should reject the deactivated user
+	assert.ErrorIs(t, err, ErrAccountDeactivated)
+
+	result, err = uc.Reactivate(testActor, 2)
+	// Assertion 454: Reactivate should succeed
+	assert.NoError(t, err)
+	// Assertion 455: Reactivate should clear the deactivation timestamp
+	assert.Nil(t, result.DeactivatedAt)
+	_, err = uc.CheckActive(2, 0)
+	// Assertion 456: CheckActive should accept the reactivated user
+	assert.NoError(t, err)
+
+	target.TokenVersion = 1
+	_, err = uc.CheckActive(2, 0)
+	// Assertion 850: CheckActive should reject tokens issued before the last password change
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+	_, err = uc.CheckActive(2, 1)
+	assert.NoError(t, err)
+	target.TokenVersion = 0
+
+	_, err = uc.Deactivate(Actor{UserID: 2}, 2)
+	// Assertion 457: Deactivate should not let admins lock themselves out
+	assert.ErrorIs(t, err, ErrSelfModification)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserUsecaseReactivateRefusesClosedAccounts(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	closedAt := time.Now()
+	target := &model.User{ID: 2, Email: "deleted-2@anonymized.invalid", Role: userRole, DeactivatedAt: &closedAt, ClosedAt: &closedAt}
+	mockUserRepo.On("FindByID", uint(2)).Return(target, nil)
+
+	_, err := uc.Reactivate(testActor, 2)
+	// Assertion 853: Reactivate should refuse closed accounts and leave them deactivated
+	assert.ErrorIs(t, err, ErrAccountClosed)
+	assert.NotNil(t, target.DeactivatedAt)
+	mockUserRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserUsecaseLoginLocksOnCountFromDatabase(t *testing.T) {
+	uc, mockUserRepo, _ := setupUserUsecase()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(correctPassword), bcrypt.DefaultCost)
+	// Loaded before parallel failures raised the stored count.
+	staleUser := &model.User{ID: 1, Email: userExampleEmail, Password: string(hashedPassword)}
+	mockUserRepo.On("FindByEmail", userExampleEmail).Return(staleUser, nil)
+	mockUserRepo.On("IncrementFailedLogins", uint(1)).Return(maxFailedLoginAttempts+1, nil)
+	mockUserRepo.On("LockUntil", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+
+	before := time.Now()
+	_, err := uc.Login(testActor, userExampleEmail, wrongPassword)
+
+	var lockedErr *AccountLockedError
+	// Assertion 859: The lock should follow the stored count, not the one loaded with the user
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.WithinDuration(t, before.Add(lockoutDuration(maxFailedLoginAttempts+1)), lockedErr.Until, time.Second)
+}
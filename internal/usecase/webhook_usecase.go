@@ -0,0 +1,443 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/domain/repository"
+	"go-ecommerce-api/internal/infrastructure/webhook"
+
+	"gorm.io/gorm"
+)
+
+// Delivery settings for webhooks. A failed attempt is retried with exponential
+// backoff (30s, 1m, 2m, ... capped at webhookMaxBackoff) and the delivery is
+// marked FAILED after webhookMaxAttempts. A subscription is disabled once
+// webhookDisableAfter attempts in a row have failed, across all its deliveries.
+const (
+	webhookSecretTag      = "whsec"
+	webhookSecretBytes    = 32
+	webhookBatchSize      = 50
+	webhookMaxAttempts    = 8
+	webhookBaseBackoff    = 30 * time.Second
+	webhookMaxBackoff     = 6 * time.Hour
+	webhookDisableAfter   = 20
+	webhookMaxResponseLen = 1000
+	webhookMaxErrorLen    = 500
+)
+
+// Error message constants
+const (
+	errWebhookInvalidURL       = "url must be an absolute http or https URL"
+	errWebhookForbiddenURL     = "url must point at a public address"
+	errWebhookNoEventTypes     = "at least one event type is required"
+	errWebhookUnknownEventType = "unknown event type %q"
+	errWebhookSubscriptionGone = "subscription deleted or disabled"
+)
+
+var (
+	ErrInvalidWebhook  = NewError(KindValidation, "invalid_webhook", "invalid webhook")
+	ErrWebhookDisabled = NewError(KindConflict, "webhook_disabled", "webhook subscription is disabled")
+)
+
+// WebhookInput describes a subscription. On update, a nil IsActive leaves the
+// state unchanged; re-activating clears the failure counter.
+type WebhookInput struct {
+	URL         string
+	Description string
+	EventTypes  []string
+	IsActive    *bool
+}
+
+// webhookEnvelope is the JSON body POSTed to subscribers.
+type webhookEnvelope struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type WebhookUsecase interface {
+	GetAll() ([]model.WebhookSubscription, error)
+	GetByID(id uint) (*model.WebhookSubscription, error)
+	// Create returns the subscription with its signing secret, which is shown only once.
+	Create(actor Actor, input WebhookInput) (*model.WebhookSubscription, string, error)
+	Update(actor Actor, id uint, input WebhookInput) (*model.WebhookSubscription, error)
+	Delete(actor Actor, id uint) error
+	RotateSecret(actor Actor, id uint) (*model.WebhookSubscription, string, error)
+	GetDeliveries(subscriptionID uint, filters map[string]string) ([]model.WebhookDelivery, error)
+	// Redeliver queues a new delivery with the same payload as an earlier one.
+	Redeliver(subscriptionID, deliveryID uint) (*model.WebhookDelivery, error)
+	// Subscribe registers the webhook fan-out for every WebhookEventTypes entry with the outbox.
+	Subscribe(outbox OutboxUsecase)
+	// DeliverPending sends one batch of due deliveries and returns how many were processed.
+	DeliverPending() (int, error)
+	// Run calls DeliverPending every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type webhookUsecase struct {
+	subscriptionRepo repository.WebhookSubscriptionRepository
+	deliveryRepo     repository.WebhookDeliveryRepository
+	sender           webhook.Sender
+	auditor          Auditor
+	now              func() time.Time
+}
+
+func NewWebhookUsecase(
+	subscriptionRepo repository.WebhookSubscriptionRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	sender webhook.Sender,
+	auditor Auditor,
+) WebhookUsecase {
+	return &webhookUsecase{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		sender:           sender,
+		auditor:          auditor,
+		now:              time.Now,
+	}
+}
+
+func (u *webhookUsecase) GetAll() ([]model.WebhookSubscription, error) {
+	return u.subscriptionRepo.FindAll()
+}
+
+func (u *webhookUsecase) GetByID(id uint) (*model.WebhookSubscription, error) {
+	subscription, err := u.subscriptionRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return subscription, nil
+}
+
+func (u *webhookUsecase) Create(actor Actor, input WebhookInput) (*model.WebhookSubscription, string, error) {
+	if err := u.validateWebhookInput(input); err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	subscription := &model.WebhookSubscription{
+		URL:         input.URL,
+		Description: strings.TrimSpace(input.Description),
+		EventTypes:  input.EventTypes,
+		Secret:      secret,
+		IsActive:    input.IsActive == nil || *input.IsActive,
+		CreatedByID: actor.UserID,
+	}
+	if err := u.subscriptionRepo.Create(subscription); err != nil {
+		return nil, "", err
+	}
+	u.auditor.Record(actor, model.AuditCreate, model.AuditEntityWebhook, subscription.ID, nil, subscription)
+	return subscription, secret, nil
+}
+
+func (u *webhookUsecase) Update(actor Actor, id uint, input WebhookInput) (*model.WebhookSubscription, error) {
+	if err := u.validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+	subscription, err := u.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *subscription
+
+	subscription.URL = input.URL
+	subscription.Description = strings.TrimSpace(input.Description)
+	subscription.EventTypes = input.EventTypes
+	if input.IsActive != nil {
+		subscription.IsActive = *input.IsActive
+		if subscription.IsActive {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+		}
+	}
+
+	if err := u.subscriptionRepo.Update(subscription); err != nil {
+		return nil, err
+	}
+	u.auditor.Record(actor, model.AuditUpdate, model.AuditEntityWebhook, subscription.ID, &before, subscription)
+	return subscription, nil
+}
+
+func (u *webhookUsecase) Delete(actor Actor, id uint) error {
+	subscription, err := u.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := u.subscriptionRepo.Delete(id); err != nil {
+		return err
+	}
+	u.auditor.Record(actor, model.AuditDelete, model.AuditEntityWebhook, id, subscription, nil)
+	return nil
+}
+
+func (u *webhookUsecase) RotateSecret(actor Actor, id uint) (*model.WebhookSubscription, string, error) {
+	subscription, err := u.GetByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	subscription.Secret = secret
+	if err := u.subscriptionRepo.Update(subscription); err != nil {
+		return nil, "", err
+	}
+	u.auditor.Record(actor, model.AuditRotateSecret, model.AuditEntityWebhook, subscription.ID, nil, nil)
+	return subscription, secret, nil
+}
+
+func (u *webhookUsecase) GetDeliveries(subscriptionID uint, filters map[string]string) ([]model.WebhookDelivery, error) {
+	if _, err := u.GetByID(subscriptionID); err != nil {
+		return nil, err
+	}
+	scoped := map[string]string{}
+	for k, v := range filters {
+		scoped[k] = v
+	}
+	scoped["subscription_id"] = fmt.Sprint(subscriptionID)
+	return u.deliveryRepo.FindWithFilters(scoped)
+}
+
+func (u *webhookUsecase) Redeliver(subscriptionID, deliveryID uint) (*model.WebhookDelivery, error) {
+	subscription, err := u.GetByID(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.IsActive {
+		return nil, ErrWebhookDisabled
+	}
+	original, err := u.deliveryRepo.FindByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.SubscriptionID != subscriptionID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	delivery := &model.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		OutboxEventID:  original.OutboxEventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		RedeliveryOfID: &original.ID,
+		Status:         model.WebhookDeliveryPending,
+		NextAttemptAt:  u.now(),
+	}
+	if err := u.deliveryRepo.Create(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (u *webhookUsecase) Subscribe(outbox OutboxUsecase) {
+	for _, eventType := range model.WebhookEventTypes {
+		outbox.Subscribe(eventType, u.fanOut)
+	}
+}
+
+// fanOut queues one delivery per active subscription interested in the event.
+// It is safe to call again for the same event: existing deliveries are kept.
+func (u *webhookUsecase) fanOut(event model.OutboxEvent) error {
+	subscriptions, err := u.subscriptionRepo.FindActive()
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(event.Type) {
+			continue
+		}
+		existing, err := u.deliveryRepo.FindByEvent(subscription.ID, event.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(webhookEnvelope{
+				ID:         event.ID,
+				Type:       event.Type,
+				OccurredAt: event.CreatedAt,
+				Data:       event.Payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		delivery := &model.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			OutboxEventID:  event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         model.WebhookDeliveryPending,
+			NextAttemptAt:  u.now(),
+		}
+		if err := u.deliveryRepo.Create(delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *webhookUsecase) DeliverPending() (int, error) {
+	deliveries, err := u.deliveryRepo.FindDue(u.now(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range deliveries {
+		if err := u.deliver(&deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+func (u *webhookUsecase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := u.DeliverPending()
+			if err != nil {
+				log.Printf("webhooks: deliver: %v", err)
+				break
+			}
+			if n < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver makes one attempt and stores its outcome on the delivery and the
+// subscription's failure counter.
+func (u *webhookUsecase) deliver(delivery *model.WebhookDelivery) error {
+	subscription, err := u.subscriptionRepo.FindByID(delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	if subscription == nil || !subscription.IsActive {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = errWebhookSubscriptionGone
+		return u.deliveryRepo.Update(delivery)
+	}
+
+	started := u.now()
+	resp, sendErr := u.sender.Send(webhook.Request{
+		URL:        subscription.URL,
+		EventType:  delivery.EventType,
+		DeliveryID: delivery.ID,
+		Secret:     subscription.Secret,
+		Body:       delivery.Payload,
+		Timestamp:  started,
+	})
+	delivery.DurationMs = u.now().Sub(started).Milliseconds()
+	delivery.Attempts++
+
+	if resp != nil {
+		delivery.ResponseCode = resp.StatusCode
+		delivery.ResponseBody = truncate(resp.Body, webhookMaxResponseLen)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			sendErr = fmt.Errorf("receiver responded with %d", resp.StatusCode)
+		}
+	} else {
+		delivery.ResponseCode = 0
+		delivery.ResponseBody = ""
+	}
+
+	if sendErr == nil {
+		delivered := u.now()
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &delivered
+		delivery.LastError = ""
+		if err := u.deliveryRepo.Update(delivery); err != nil {
+			return err
+		}
+		if subscription.ConsecutiveFailures == 0 {
+			return nil
+		}
+		subscription.ConsecutiveFailures = 0
+		return u.subscriptionRepo.Update(subscription)
+	}
+
+	delivery.LastError = truncate(sendErr.Error(), webhookMaxErrorLen)
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+	} else {
+		delivery.NextAttemptAt = u.now().Add(exponentialBackoff(webhookBaseBackoff, webhookMaxBackoff, delivery.Attempts-1))
+	}
+	if err := u.deliveryRepo.Update(delivery); err != nil {
+		return err
+	}
+
+	subscription.ConsecutiveFailures++
+	if subscription.ConsecutiveFailures >= webhookDisableAfter {
+		disabled := u.now()
+		subscription.IsActive = false
+		subscription.DisabledAt = &disabled
+		log.Printf("webhooks: subscription %d disabled after %d failed attempts", subscription.ID, subscription.ConsecutiveFailures)
+	}
+	return u.subscriptionRepo.Update(subscription)
+}
+
+// validateWebhookInput also asks the sender whether it would deliver to the
+// URL, so internal addresses are refused when saved; the sender checks again
+// on every delivery.
+func (u *webhookUsecase) validateWebhookInput(input WebhookInput) error {
+	parsed, err := url.Parse(input.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidWebhook, errWebhookInvalidURL)
+	}
+	if err := u.sender.CheckURL(input.URL); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidWebhook, errWebhookForbiddenURL, err)
+	}
+	if len(input.EventTypes) == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidWebhook, errWebhookNoEventTypes)
+	}
+	for _, t := range input.EventTypes {
+		if !model.StringList(model.WebhookEventTypes).Contains(t) {
+			return fmt.Errorf("%w: "+errWebhookUnknownEventType, ErrInvalidWebhook, t)
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret, err := randomHex(webhookSecretBytes)
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretTag + "_" + secret, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
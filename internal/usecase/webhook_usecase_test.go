@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-ecommerce-api/internal/domain/model"
+	"go-ecommerce-api/internal/infrastructure/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryWebhookSubscriptions is an in-memory WebhookSubscriptionRepository.
+type memoryWebhookSubscriptions struct {
+	subscriptions []model.WebhookSubscription
+}
+
+func (r *memoryWebhookSubscriptions) FindByID(id uint) (*model.WebhookSubscription, error) {
+	for _, s := range r.subscriptions {
+		if s.ID == id {
+			return &s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWebhookSubscriptions) FindAll() ([]model.WebhookSubscription, error) {
+	return r.subscriptions, nil
+}
+
+func (r *memoryWebhookSubscriptions) FindActive() ([]model.WebhookSubscription, error) {
+	var active []model.WebhookSubscription
+	for _, s := range r.subscriptions {
+		if s.IsActive {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryWebhookSubscriptions) Create(subscription *model.WebhookSubscription) error {
+	subscription.ID = uint(len(r.subscriptions) + 1)
+	r.subscriptions = append(r.subscriptions, *subscription)
+	return nil
+}
+
+func (r *memoryWebhookSubscriptions) Update(subscription *model.WebhookSubscription) error {
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID == subscription.ID {
+			r.subscriptions[i] = *subscription
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryWebhookSubscriptions) Delete(id uint) error {
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID == id {
+			r.subscriptions = append(r.subscriptions[:i], r.subscriptions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// memoryWebhookDeliveries is an in-memory WebhookDeliveryRepository.
+type memoryWebhookDeliveries struct {
+	deliveries []model.WebhookDelivery
+}
+
+func (r *memoryWebhookDeliveries) FindByID(id uint) (*model.WebhookDelivery, error) {
+	for _, d := range r.deliveries {
+		if d.ID == id {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWebhookDeliveries) FindByEvent(subscriptionID, outboxEventID uint) (*model.WebhookDelivery, error) {
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID && d.OutboxEventID == outboxEventID && d.RedeliveryOfID == nil {
+			return &d, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryWebhookDeliveries) FindWithFilters(filters map[string]string) ([]model.WebhookDelivery, error) {
+	var out []model.WebhookDelivery
+	for _, d := range r.deliveries {
+		if v, ok := filters["subscription_id"]; ok && strconv.FormatUint(uint64(d.SubscriptionID), 10) != v {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (r *memoryWebhookDeliveries) FindDue(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var out []model.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == model.WebhookDeliveryPending && !d.NextAttemptAt.After(now) && len(out) < limit {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryWebhookDeliveries) Create(delivery *model.WebhookDelivery) error {
+	delivery.ID = uint(len(r.deliveries) + 1)
+	r.deliveries = append(r.deliveries, *delivery)
+	return nil
+}
+
+func (r *memoryWebhookDeliveries) Update(delivery *model.WebhookDelivery) error {
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = *delivery
+			return nil
+		}
+	}
+	return nil
+}
+
+// webhookReceiver is a local partner endpoint that verifies signatures.
+type webhookReceiver struct {
+	server   *httptest.Server
+	secret   string
+	status   int
+	received []string
+	badSigs  int
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	r := &webhookReceiver{status: http.StatusOK}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		unix, _ := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+		if !webhook.Verify(r.secret, time.Unix(unix, 0), body, req.Header.Get(webhook.SignatureHeader)) {
+			r.badSigs++
+		}
+		r.received = append(r.received, req.Header.Get(webhook.EventHeader))
+		w.WriteHeader(r.status)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func setupWebhookUsecase(t *testing.T) (*webhookUsecase, *memoryWebhookSubscriptions, *memoryWebhookDeliveries, *webhookReceiver) {
+	subscriptions := &memoryWebhookSubscriptions{}
+	deliveries := &memoryWebhookDeliveries{}
+	uc := NewWebhookUsecase(subscriptions, deliveries, webhook.NewHTTPSender(true), &recordingAuditor{}).(*webhookUsecase)
+	return uc, subscriptions, deliveries, newWebhookReceiver(t)
+}
+
+func TestWebhookUsecaseCreateValidatesInput(t *testing.T) {
+	uc, _, _, _ := setupWebhookUsecase(t)
+
+	_, _, err := uc.Create(testActor, WebhookInput{URL: "ftp://example.com", EventTypes: []string{model.EventOrderPaid}})
+	// Assertion 519: Only absolute http(s) URLs are accepted
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+
+	_, _, err = uc.Create(testActor, WebhookInput{URL: "https://example.com/hook", EventTypes: []string{model.EventCartUpdated}})
+	// Assertion 520: Internal events cannot be subscribed to
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+
+	sub, secret, err := uc.Create(testActor, WebhookInput{URL: "https://example.com/hook", EventTypes: []string{model.EventOrderPaid}})
+	// Assertion 521: A new subscription is active and gets a one-time signing secret
+	assert.NoError(t, err)
+	assert.True(t, sub.IsActive)
+	assert.True(t, strings.HasPrefix(secret, webhookSecretTag+"_"))
+	assert.Equal(t, secret, sub.Secret)
+}
+
+func TestWebhookUsecaseRejectsInternalURLs(t *testing.T) {
+	uc, _, _, receiver := setupWebhookUsecase(t)
+	uc.sender = webhook.NewHTTPSender(false)
+
+	for _, rawURL := range []string{receiver.server.URL, "http://169.254.169.254/latest/meta-data"} {
+		_, _, err := uc.Create(testActor, WebhookInput{URL: rawURL, EventTypes: []string{model.EventOrderPaid}})
+		// Assertion 793: Subscriptions pointing at internal addresses should be refused
+		assert.ErrorIs(t, err, ErrInvalidWebhook, rawURL)
+	}
+}
+
+func TestWebhookUsecaseDeliversSignedEvents(t *testing.T) {
+	uc, _, deliveries, receiver := setupWebhookUsecase(t)
+
+	sub, secret, err := uc.Create(testActor, WebhookInput{URL: receiver.server.URL, EventTypes: []string{model.EventOrderPaid}})
+	assert.NoError(t, err)
+	receiver.secret = secret
+	_, _, err = uc.Create(testActor, WebhookInput{URL: receiver.server.URL, EventTypes: []string{model.EventStockLow}})
+	assert.NoError(t, err)
+
+	event := model.OutboxEvent{ID: 9, Type: model.EventOrderPaid, Payload: []byte(`{"order_id":1}`), CreatedAt: time.Now()}
+	assert.NoError(t, uc.fanOut(event))
+	assert.NoError(t, uc.fanOut(event))
+
+	// Assertion 522: Only interested subscriptions get a delivery, once per event
+	if !assert.Len(t, deliveries.deliveries, 1) {
+		return
+	}
+	assert.Equal(t, sub.ID, deliveries.deliveries[0].SubscriptionID)
+
+	n, err := uc.DeliverPending()
+
+	// Assertion 523: The receiver gets the event with a valid HMAC signature
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{model.EventOrderPaid}, receiver.received)
+	assert.Zero(t, receiver.badSigs)
+	// Assertion 524: The delivery log records the outcome and response code
+	assert.Equal(t, model.WebhookDeliverySucceeded, deliveries.deliveries[0].Status)
+	assert.Equal(t, http.StatusOK, deliveries.deliveries[0].ResponseCode)
+	assert.Equal(t, 1, deliveries.deliveries[0].Attempts)
+}
+
+func TestWebhookUsecaseRetriesAndDisablesFailingSubscriptions(t *testing.T) {
+	uc, subscriptions, deliveries, receiver := setupWebhookUsecase(t)
+	receiver.status = http.StatusInternalServerError
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	sub, secret, err := uc.Create(testActor, WebhookInput{URL: receiver.server.URL, EventTypes: []string{model.EventOrderCancelled}})
+	assert.NoError(t, err)
+	receiver.secret = secret
+	assert.NoError(t, uc.fanOut(model.OutboxEvent{ID: 1, Type: model.EventOrderCancelled, Payload: []byte(`{}`)}))
+
+	_, err = uc.DeliverPending()
+
+	// Assertion 525: A non-2xx response is logged and retried with backoff
+	assert.NoError(t, err)
+	failed := deliveries.deliveries[0]
+	assert.Equal(t, model.WebhookDeliveryPending, failed.Status)
+	assert.Equal(t, http.StatusInternalServerError, failed.ResponseCode)
+	assert.Equal(t, now.Add(webhookBaseBackoff), failed.NextAttemptAt)
+	assert.Equal(t, 1, subscriptions.subscriptions[0].ConsecutiveFailures)
+
+	for i := 1; i < webhookMaxAttempts; i++ {
+		deliveries.deliveries[0].NextAttemptAt = now
+		_, _ = uc.DeliverPending()
+	}
+	// Assertion 526: The delivery is marked FAILED after the last attempt
+	assert.Equal(t, model.WebhookDeliveryFailed, deliveries.deliveries[0].Status)
+	assert.Equal(t, webhookMaxAttempts, deliveries.deliveries[0].Attempts)
+
+	subscriptions.subscriptions[0].ConsecutiveFailures = webhookDisableAfter - 1
+	redelivery, err := uc.Redeliver(sub.ID, failed.ID)
+	assert.NoError(t, err)
+	// Assertion 527: Manual redelivery queues a new delivery linked to the original
+	assert.Equal(t, failed.ID, *redelivery.RedeliveryOfID)
+	assert.Equal(t, model.WebhookDeliveryPending, redelivery.Status)
+
+	_, _ = uc.DeliverPending()
+
+	// Assertion 528: Too many failures in a row disable the subscription
+	assert.False(t, subscriptions.subscriptions[0].IsActive)
+	assert.NotNil(t, subscriptions.subscriptions[0].DisabledAt)
+	_, err = uc.Redeliver(sub.ID, failed.ID)
+	assert.ErrorIs(t, err, ErrWebhookDisabled)
+
+	active := true
+	updated, err := uc.Update(testActor, sub.ID, WebhookInput{URL: sub.URL, EventTypes: sub.EventTypes, IsActive: &active})
+	// Assertion 529: Re-enabling a subscription resets its failure counter
+	assert.NoError(t, err)
+	assert.True(t, updated.IsActive)
+	assert.Zero(t, updated.ConsecutiveFailures)
+}